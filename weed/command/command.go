@@ -20,6 +20,7 @@ var Commands = []*Command{
 	cmdFilerBackup,
 	cmdFilerCat,
 	cmdFilerCopy,
+	cmdFilerKafkaSink,
 	cmdFilerMetaBackup,
 	cmdFilerMetaTail,
 	cmdFilerRemoteGateway,
@@ -27,12 +28,15 @@ var Commands = []*Command{
 	cmdFilerReplicate,
 	cmdFilerSynchronize,
 	cmdFix,
+	cmdFtp,
 	cmdFuse,
 	cmdIam,
 	cmdMaster,
 	cmdMasterFollower,
+	cmdMigrate,
 	cmdMount,
 	cmdMqBroker,
+	cmdNfs,
 	cmdS3,
 	cmdScaffold,
 	cmdServer,
@@ -41,6 +45,7 @@ var Commands = []*Command{
 	cmdUpload,
 	cmdVersion,
 	cmdVolume,
+	cmdVolumeIndexRebuild,
 	cmdWebDav,
 }
 
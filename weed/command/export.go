@@ -70,6 +70,13 @@ var (
 	localLocation, _             = time.LoadLocation("Local")
 )
 
+// the file name extensions of the compressed needles, by their content encoding
+var compressedFileExts = map[string]string{
+	"gzip": ".gz",
+	"zstd": ".zst",
+	"lz4":  ".lz4",
+}
+
 func printNeedle(vid needle.VolumeId, n *needle.Needle, version needle.Version, deleted bool, offset int64, onDiskSize int64) {
 	key := needle.NewFileIdFromNeedle(vid, n).String()
 	size := int32(n.DataSize)
@@ -245,11 +252,8 @@ func writeFile(vid needle.VolumeId, n *needle.Needle) (err error) {
 
 	fileName := fileNameTemplateBuffer.String()
 
-	if n.IsCompressed() {
-		if util.IsGzippedContent(n.Data) && path.Ext(fileName) != ".gz" {
-			fileName = fileName + ".gz"
-		}
-		// TODO other compression method
+	if ext, found := compressedFileExts[n.Compression()]; found && path.Ext(fileName) != ext {
+		fileName = fileName + ext
 	}
 
 	tarHeader.Name, tarHeader.Size = fileName, int64(len(n.Data))
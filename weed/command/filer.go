@@ -1,6 +1,8 @@
 package command
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
@@ -10,16 +12,19 @@ import (
 	"strings"
 	"time"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/grpcweb"
 	"github.com/seaweedfs/seaweedfs/weed/security"
 	weed_server "github.com/seaweedfs/seaweedfs/weed/server"
 	stats_collect "github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/grace"
 )
 
 var (
@@ -39,6 +44,10 @@ type FilerOptions struct {
 	bindIp                  *string
 	port                    *int
 	portGrpc                *int
+	portGrpcWeb             *int
+	portAdmin               *int
+	grpcWebWhiteList        *string
+	grpcWebAllowedOrigins   *string
 	publicPort              *int
 	filerGroup              *string
 	collection              *string
@@ -61,6 +70,11 @@ type FilerOptions struct {
 	showUIDirectoryDelete   *bool
 	downloadMaxMBps         *int
 	diskType                *string
+	auditLog                *string
+	enableTiering           *bool
+	enableIntegrityCheck    *bool
+	integrityScanRateMB     *int
+	uploadStatus            *bool
 }
 
 func init() {
@@ -72,6 +86,10 @@ func init() {
 	f.bindIp = cmdFiler.Flag.String("ip.bind", "", "ip address to bind to. If empty, default to same as -ip option.")
 	f.port = cmdFiler.Flag.Int("port", 8888, "filer server http listen port")
 	f.portGrpc = cmdFiler.Flag.Int("port.grpc", 0, "filer server grpc listen port")
+	f.portGrpcWeb = cmdFiler.Flag.Int("port.grpcWeb", 0, "if set, serve ListEntries and GetFilerConfiguration to the gRPC-Web browser clients on this http port")
+	f.grpcWebWhiteList = cmdFiler.Flag.String("port.grpcWeb.whiteList", "", "comma separated Ip addresses or CIDR ranges allowed to call the gRPC-Web methods. Either this or the jwt.filer_signing.read key of security.toml is required.")
+	f.grpcWebAllowedOrigins = cmdFiler.Flag.String("port.grpcWeb.allowedOrigins", "", "comma separated origins of the browser pages allowed to call the gRPC-Web methods, e.g., https://app.example.com")
+	f.portAdmin = cmdFiler.Flag.Int("port.admin", 0, "if set, serve the OpenAPI spec at /api/openapi.json and its docs page at /api/docs on this http port")
 	f.publicPort = cmdFiler.Flag.Int("port.readonly", 0, "readonly port opened to public")
 	f.defaultReplicaPlacement = cmdFiler.Flag.String("defaultReplicaPlacement", "", "default replication type. If not specified, use master setting.")
 	f.disableDirListing = cmdFiler.Flag.Bool("disableDirListing", false, "turn off directory listing")
@@ -91,6 +109,11 @@ func init() {
 	f.showUIDirectoryDelete = cmdFiler.Flag.Bool("ui.deleteDir", true, "enable filer UI show delete directory button")
 	f.downloadMaxMBps = cmdFiler.Flag.Int("downloadMaxMBps", 0, "download max speed for each download request, in MB per second")
 	f.diskType = cmdFiler.Flag.String("disk", "", "[hdd|ssd|<tag>] hard drive or solid state drive or any tag")
+	f.enableTiering = cmdFiler.Flag.Bool("enable-tiering", false, "move files not modified for the seaweedfs.tiering.unmodified-ttl of their directory to its seaweedfs.tiering.warm-collection. Enable it on only one filer.")
+	f.enableIntegrityCheck = cmdFiler.Flag.Bool("enable-integrity-check", false, "periodically read the files and verify their md5, reporting mismatches at /admin/integrity/report. Enable it on only one filer.")
+	f.integrityScanRateMB = cmdFiler.Flag.Int("integrity-scan-rate", 10, "limit the integrity check reading speed in MB/s, 0 means unlimited")
+	f.uploadStatus = cmdFiler.Flag.Bool("upload-status", false, "track the progress of the uploads, reported at /upload/status?uploadId=<id>")
+	f.auditLog = cmdFiler.Flag.String("audit-log", "", "path to a json audit log file recording all metadata mutations via gRPC and http, rotated by size and age")
 
 	// start s3 on filer
 	filerStartS3 = cmdFiler.Flag.Bool("s3", false, "whether to start S3 gateway")
@@ -104,6 +127,7 @@ func init() {
 	filerS3Options.auditLogConfig = cmdFiler.Flag.String("s3.auditLogConfig", "", "path to the audit log config file")
 	filerS3Options.allowEmptyFolder = cmdFiler.Flag.Bool("s3.allowEmptyFolder", true, "allow empty folders")
 	filerS3Options.allowDeleteBucketNotEmpty = cmdFiler.Flag.Bool("s3.allowDeleteBucketNotEmpty", true, "allow recursive deleting all entries along with bucket")
+	filerS3Options.directUpload = cmdFiler.Flag.Bool("s3.directUpload", false, "upload the objects larger than the filer chunk size directly to the volume servers, instead of through the filer")
 
 	// start webdav on filer
 	filerStartWebDav = cmdFiler.Flag.Bool("webdav", false, "whether to start webdav gateway")
@@ -116,6 +140,7 @@ func init() {
 	filerWebDavOptions.cacheDir = cmdFiler.Flag.String("webdav.cacheDir", os.TempDir(), "local cache directory for file chunks")
 	filerWebDavOptions.cacheSizeMB = cmdFiler.Flag.Int64("webdav.cacheCapacityMB", 0, "local cache capacity in MB")
 	filerWebDavOptions.filerRootPath = cmdFiler.Flag.String("webdav.filer.path", "/", "use this remote path from filer server")
+	filerWebDavOptions.lockRedis = cmdFiler.Flag.String("webdav.lock.redis", "", "redis host:port to share webdav locks among several webdav servers")
 
 	// start iam on filer
 	filerStartIam = cmdFiler.Flag.Bool("iam", false, "whether to start IAM service")
@@ -164,6 +189,8 @@ func runFiler(cmd *Command, args []string) bool {
 	}
 
 	util.LoadConfiguration("security", false)
+	util.LoadConfiguration("weed", false)
+	pb.LoadTracing(util.GetViper(), "seaweedfs-filer")
 
 	go stats_collect.StartMetricsServer(*f.bindIp, *f.metricsHttpPort)
 
@@ -221,6 +248,10 @@ func (fo *FilerOptions) startFiler() {
 	if *fo.publicPort != 0 {
 		publicVolumeMux = http.NewServeMux()
 	}
+	var adminMux *http.ServeMux
+	if *fo.portAdmin != 0 {
+		adminMux = http.NewServeMux()
+	}
 	if *fo.portGrpc == 0 {
 		*fo.portGrpc = 10000 + *fo.port
 	}
@@ -232,6 +263,15 @@ func (fo *FilerOptions) startFiler() {
 
 	filerAddress := pb.NewServerAddress(*fo.ip, *fo.port, *fo.portGrpc)
 
+	var auditLogger *filer.AuditLogger
+	if *fo.auditLog != "" {
+		auditLogger = filer.NewAuditLogger(*fo.auditLog, security.SigningKey(util.GetViper().GetString("jwt.filer_signing.key")))
+		grace.OnInterrupt(func() {
+			auditLogger.Close()
+		})
+		glog.V(0).Infof("filer audit log to %s", *fo.auditLog)
+	}
+
 	fs, nfs_err := weed_server.NewFilerServer(defaultMux, publicVolumeMux, &weed_server.FilerOption{
 		Masters:               fo.masters,
 		FilerGroup:            *fo.filerGroup,
@@ -251,6 +291,12 @@ func (fo *FilerOptions) startFiler() {
 		ShowUIDirectoryDelete: *fo.showUIDirectoryDelete,
 		DownloadMaxBytesPs:    int64(*fo.downloadMaxMBps) * 1024 * 1024,
 		DiskType:              *fo.diskType,
+		AuditLogger:           auditLogger,
+		EnableTiering:         *fo.enableTiering,
+		EnableIntegrityCheck:  *fo.enableIntegrityCheck,
+		IntegrityScanBytesPs:  int64(*fo.integrityScanRateMB) * 1024 * 1024,
+		UploadStatus:          *fo.uploadStatus,
+		AdminMux:              adminMux,
 	})
 	if nfs_err != nil {
 		glog.Fatalf("Filer startup error: %v", nfs_err)
@@ -277,6 +323,19 @@ func (fo *FilerOptions) startFiler() {
 		}
 	}
 
+	if adminMux != nil {
+		adminListener, err := util.NewListener(util.JoinHostPort(*fo.bindIp, *fo.portAdmin), 0)
+		if err != nil {
+			glog.Fatalf("Filer server admin listener error on port %d:%v", *fo.portAdmin, err)
+		}
+		glog.V(0).Infof("Start Seaweed filer admin api docs at %s:%d", *fo.ip, *fo.portAdmin)
+		go func() {
+			if e := http.Serve(adminListener, adminMux); e != nil {
+				glog.Errorf("Filer server fail to serve admin: %v", e)
+			}
+		}()
+	}
+
 	glog.V(0).Infof("Start Seaweed Filer %s at %s:%d", util.Version(), *fo.ip, *fo.port)
 	filerListener, filerLocalListener, e := util.NewIpAndLocalListeners(
 		*fo.bindIp, *fo.port,
@@ -292,7 +351,13 @@ func (fo *FilerOptions) startFiler() {
 	if err != nil {
 		glog.Fatalf("failed to listen on grpc port %d: %v", grpcPort, err)
 	}
-	grpcS := pb.NewGrpcServer(security.LoadServerTLS(util.GetViper(), "grpc.filer"))
+	var auditLogOption, auditLogStreamOption grpc.ServerOption
+	if auditLogger != nil {
+		auditLogOption = grpc.UnaryInterceptor(auditLogger.UnaryServerInterceptor())
+		auditLogStreamOption = grpc.StreamInterceptor(auditLogger.StreamServerInterceptor())
+	}
+	tlsOption, tlsStreamOption := security.LoadServerTLS(util.GetViper(), "grpc.filer")
+	grpcS := pb.NewGrpcServer(tlsOption, tlsStreamOption, auditLogOption, auditLogStreamOption)
 	filer_pb.RegisterSeaweedFilerServer(grpcS, fs)
 	reflection.Register(grpcS)
 	if grpcLocalL != nil {
@@ -300,6 +365,10 @@ func (fo *FilerOptions) startFiler() {
 	}
 	go grpcS.Serve(grpcL)
 
+	if *fo.portGrpcWeb != 0 {
+		go fo.startGrpcWebProxy(filerAddress.ToGrpcAddress())
+	}
+
 	httpS := &http.Server{Handler: defaultMux}
 	if runtime.GOOS != "windows" {
 		localSocket := *fo.localSocket
@@ -330,3 +399,47 @@ func (fo *FilerOptions) startFiler() {
 	}
 
 }
+
+// startGrpcWebProxy translates the gRPC-Web requests from browsers to the filer gRPC server.
+// The callers must be in the white list or have the filer read jwt, and the browser pages must be from the allowed origins.
+func (fo *FilerOptions) startGrpcWebProxy(grpcAddress string) {
+	v := util.GetViper()
+	whiteList := util.StringSplit(*fo.grpcWebWhiteList, ",")
+	readSigningKey := v.GetString("jwt.filer_signing.read.key")
+	if len(whiteList) == 0 && readSigningKey == "" {
+		glog.Fatalf("grpc-web needs -port.grpcWeb.whiteList or the jwt.filer_signing.read key of security.toml")
+	}
+	allowedOrigins := util.StringSplit(*fo.grpcWebAllowedOrigins, ",")
+	if len(allowedOrigins) == 0 {
+		glog.Fatalf("grpc-web needs -port.grpcWeb.allowedOrigins")
+	}
+	guard := security.NewGuard(whiteList, "", 0, readSigningKey, v.GetInt("jwt.filer_signing.read.expires_after_seconds"))
+
+	conn, err := pb.GrpcDial(context.Background(), grpcAddress, false, security.LoadClientTLS(v, "grpc.client"))
+	if err != nil {
+		glog.Fatalf("grpc-web dial filer %s: %v", grpcAddress, err)
+	}
+	handler := grpcweb.NewHandler(conn, map[string]bool{
+		"/filer_pb.SeaweedFiler/ListEntries":           true,
+		"/filer_pb.SeaweedFiler/GetFilerConfiguration": false,
+	}, allowedOrigins, guard.CheckFilerRead)
+	listener, err := util.NewListener(util.JoinHostPort(*fo.bindIp, *fo.portGrpcWeb), 0)
+	if err != nil {
+		glog.Fatalf("failed to listen on grpc-web port %d: %v", *fo.portGrpcWeb, err)
+	}
+	// the browsers can not present the grpc client certs, so only the server side of the grpc tls is used
+	tlsConfig, err := security.LoadServerTLSConfig(v, "grpc.filer")
+	if err != nil {
+		glog.Fatalf("grpc-web tls: %v", err)
+	}
+	if tlsConfig != nil {
+		glog.V(0).Infof("Start filer grpc-web proxy at https://%s:%d", *fo.ip, *fo.portGrpcWeb)
+		err = http.Serve(tls.NewListener(listener, tlsConfig), handler)
+	} else {
+		glog.V(0).Infof("Start filer grpc-web proxy at %s:%d", *fo.ip, *fo.portGrpcWeb)
+		err = http.Serve(listener, handler)
+	}
+	if err != nil {
+		glog.Errorf("filer grpc-web proxy: %v", err)
+	}
+}
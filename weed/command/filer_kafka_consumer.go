@@ -0,0 +1,289 @@
+package command
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/grace"
+)
+
+type FilerKafkaSinkOptions struct {
+	filer       *string
+	brokers     *string
+	topic       *string
+	group       *string
+	errorTopic  *string
+	replication *string
+	collection  *string
+	diskType    *string
+	retries     *int
+
+	grpcDialOption grpc.DialOption
+	filerAddress   pb.ServerAddress
+	signature      int32
+	errorProducer  sarama.SyncProducer
+}
+
+var (
+	kafkaSinkOptions FilerKafkaSinkOptions
+)
+
+func init() {
+	cmdFilerKafkaSink.Run = runFilerKafkaSink // break init cycle
+	kafkaSinkOptions.filer = cmdFilerKafkaSink.Flag.String("filer", "localhost:8888", "filer hostname:port")
+	kafkaSinkOptions.brokers = cmdFilerKafkaSink.Flag.String("brokers", "localhost:9092", "comma-separated kafka brokers")
+	kafkaSinkOptions.topic = cmdFilerKafkaSink.Flag.String("topic", "", "kafka topic to read the files from")
+	kafkaSinkOptions.group = cmdFilerKafkaSink.Flag.String("group", "seaweedfs-filer-sink", "kafka consumer group")
+	kafkaSinkOptions.errorTopic = cmdFilerKafkaSink.Flag.String("errorTopic", "", "kafka topic to write the failed messages to. If empty, a failed message stops the consumer.")
+	kafkaSinkOptions.replication = cmdFilerKafkaSink.Flag.String("replication", "", "replication type")
+	kafkaSinkOptions.collection = cmdFilerKafkaSink.Flag.String("collection", "", "optional collection name")
+	kafkaSinkOptions.diskType = cmdFilerKafkaSink.Flag.String("disk", "", "[hdd|ssd|<tag>] hard drive or solid state drive or any tag")
+	kafkaSinkOptions.retries = cmdFilerKafkaSink.Flag.Int("retries", 3, "number of attempts to write a file to the filer before giving up on the message")
+}
+
+var cmdFilerKafkaSink = &Command{
+	UsageLine: "filer.kafka.sink -topic=<topic> [-brokers=localhost:9092] [-filer=localhost:8888] [-errorTopic=<topic>]",
+	Short:     "create filer entries from kafka messages",
+	Long: `create or update the files on the filer from the messages of a kafka topic.
+
+	weed filer.kafka.sink -topic=ingest -brokers=kafka1:9092,kafka2:9092 -errorTopic=ingest.errors
+
+	Each message is a json record:
+
+	{"path":"/dir/file.txt", "content_base64":"...", "mime":"text/plain", "metadata_map":{"key":"value"}}
+
+	The metadata_map is saved as the extended attributes of the file.
+	The consumer group offsets are committed only after the file is written to the filer.
+	The messages failed to decode or to write are sent to the -errorTopic, with the error in the "error" header.
+
+  `,
+}
+
+type kafkaSinkMessage struct {
+	Path          string            `json:"path"`
+	ContentBase64 string            `json:"content_base64"`
+	Mime          string            `json:"mime"`
+	MetadataMap   map[string]string `json:"metadata_map"`
+
+	content []byte
+}
+
+func runFilerKafkaSink(cmd *Command, args []string) bool {
+
+	util.LoadConfiguration("security", false)
+	kafkaSinkOptions.grpcDialOption = security.LoadClientTLS(util.GetViper(), "grpc.client")
+	kafkaSinkOptions.filerAddress = pb.ServerAddress(*kafkaSinkOptions.filer)
+	kafkaSinkOptions.signature = util.RandomInt32()
+
+	if *kafkaSinkOptions.topic == "" {
+		glog.Errorf("missing -topic")
+		return false
+	}
+	brokers := strings.Split(*kafkaSinkOptions.brokers, ",")
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Consumer.Offsets.AutoCommit.Enable = false
+	config.Consumer.Return.Errors = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+
+	if *kafkaSinkOptions.errorTopic != "" {
+		producer, err := sarama.NewSyncProducer(brokers, config)
+		if err != nil {
+			glog.Errorf("connect to kafka %v: %v", brokers, err)
+			return true
+		}
+		defer producer.Close()
+		kafkaSinkOptions.errorProducer = producer
+	}
+
+	consumerGroup, err := sarama.NewConsumerGroup(brokers, *kafkaSinkOptions.group, config)
+	if err != nil {
+		glog.Errorf("connect to kafka %v: %v", brokers, err)
+		return true
+	}
+	defer consumerGroup.Close()
+	go func() {
+		for err := range consumerGroup.Errors() {
+			glog.Errorf("kafka consumer: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	grace.OnInterrupt(cancel)
+
+	glog.V(0).Infof("sink kafka topic %s to filer %s", *kafkaSinkOptions.topic, kafkaSinkOptions.filerAddress)
+	for ctx.Err() == nil {
+		// Consume returns when the consumer group is rebalanced
+		if err := consumerGroup.Consume(ctx, []string{*kafkaSinkOptions.topic}, &kafkaSinkOptions); err != nil {
+			glog.Errorf("consume kafka topic %s: %v", *kafkaSinkOptions.topic, err)
+			time.Sleep(1747 * time.Millisecond)
+		}
+	}
+
+	return true
+}
+
+func (option *FilerKafkaSinkOptions) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (option *FilerKafkaSinkOptions) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (option *FilerKafkaSinkOptions) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		if err := option.processMessage(msg); err != nil {
+			glog.Errorf("kafka message %s/%d/%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+			if sendErr := option.sendToErrorTopic(msg, err); sendErr != nil {
+				// the offset is not committed, so the message is consumed again after the session is restarted
+				return fmt.Errorf("kafka message %s/%d/%d: %v", msg.Topic, msg.Partition, msg.Offset, sendErr)
+			}
+		}
+		session.MarkMessage(msg, "")
+		session.Commit()
+	}
+	return nil
+}
+
+func (option *FilerKafkaSinkOptions) processMessage(msg *sarama.ConsumerMessage) error {
+	message, err := parseKafkaSinkMessage(msg.Value)
+	if err != nil {
+		return err
+	}
+	for i := 1; ; i++ {
+		if err = option.saveToFiler(message); err == nil || i >= *option.retries {
+			return err
+		}
+		glog.V(0).Infof("retry %d kafka sink %s: %v", i, message.Path, err)
+		time.Sleep(time.Duration(i) * time.Second)
+	}
+}
+
+func parseKafkaSinkMessage(data []byte) (*kafkaSinkMessage, error) {
+	message := &kafkaSinkMessage{}
+	if err := json.Unmarshal(data, message); err != nil {
+		return nil, fmt.Errorf("decode message: %v", err)
+	}
+	if !strings.HasPrefix(message.Path, "/") || strings.HasSuffix(message.Path, "/") {
+		return nil, fmt.Errorf("invalid file path %q", message.Path)
+	}
+	content, err := base64.StdEncoding.DecodeString(message.ContentBase64)
+	if err != nil {
+		return nil, fmt.Errorf("decode content of %s: %v", message.Path, err)
+	}
+	message.content = content
+	if message.Mime == "" && len(content) > 0 {
+		message.Mime = http.DetectContentType(content)
+	}
+	return message, nil
+}
+
+func (option *FilerKafkaSinkOptions) saveToFiler(message *kafkaSinkMessage) error {
+	dir, name := util.FullPath(message.Path).DirAndName()
+
+	var chunks []*filer_pb.FileChunk
+	if len(message.content) > 0 {
+		fileId, uploadResult, uploadErr, _ := operation.UploadWithRetry(
+			option,
+			&filer_pb.AssignVolumeRequest{
+				Count:       1,
+				Replication: *option.replication,
+				Collection:  *option.collection,
+				DiskType:    *option.diskType,
+				Path:        message.Path,
+			},
+			&operation.UploadOption{
+				Filename: name,
+				MimeType: message.Mime,
+			},
+			func(host, fileId string) string {
+				return fmt.Sprintf("http://%s/%s", host, fileId)
+			},
+			util.NewBytesReader(message.content),
+		)
+		if uploadErr != nil {
+			return fmt.Errorf("upload %s: %v", message.Path, uploadErr)
+		}
+		if uploadResult.Error != "" {
+			return fmt.Errorf("upload %s: %v", message.Path, uploadResult.Error)
+		}
+		chunks = append(chunks, uploadResult.ToPbFileChunk(fileId, 0, time.Now().UnixNano()))
+	}
+
+	extended := make(map[string][]byte, len(message.MetadataMap))
+	for k, v := range message.MetadataMap {
+		extended[k] = []byte(v)
+	}
+
+	return option.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		now := time.Now().Unix()
+		request := &filer_pb.CreateEntryRequest{
+			Directory: dir,
+			Entry: &filer_pb.Entry{
+				Name: name,
+				Attributes: &filer_pb.FuseAttributes{
+					Crtime:   now,
+					Mtime:    now,
+					FileSize: uint64(len(message.content)),
+					FileMode: uint32(0644),
+					Mime:     message.Mime,
+				},
+				Chunks:   chunks,
+				Extended: extended,
+			},
+		}
+		if err := filer_pb.CreateEntry(client, request); err != nil {
+			return fmt.Errorf("create entry %s: %v", message.Path, err)
+		}
+		return nil
+	})
+}
+
+func (option *FilerKafkaSinkOptions) sendToErrorTopic(msg *sarama.ConsumerMessage, processErr error) error {
+	if option.errorProducer == nil {
+		return processErr
+	}
+	_, _, err := option.errorProducer.SendMessage(&sarama.ProducerMessage{
+		Topic: *option.errorTopic,
+		Key:   sarama.ByteEncoder(msg.Key),
+		Value: sarama.ByteEncoder(msg.Value),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte("error"), Value: []byte(processErr.Error())},
+			{Key: []byte("source"), Value: []byte(fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset))},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("send to error topic %s: %v", *option.errorTopic, err)
+	}
+	return nil
+}
+
+func (option *FilerKafkaSinkOptions) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithGrpcFilerClient(streamingMode, option.signature, option.filerAddress, option.grpcDialOption, fn)
+}
+
+func (option *FilerKafkaSinkOptions) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (option *FilerKafkaSinkOptions) GetDataCenter() string {
+	return ""
+}
@@ -0,0 +1,26 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKafkaSinkMessage(t *testing.T) {
+	message, err := parseKafkaSinkMessage([]byte(`{"path":"/dir/a.txt","content_base64":"aGVsbG8=","metadata_map":{"k":"v"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "/dir/a.txt", message.Path)
+	assert.Equal(t, []byte("hello"), message.content)
+	assert.Equal(t, "text/plain; charset=utf-8", message.Mime)
+	assert.Equal(t, "v", message.MetadataMap["k"])
+
+	for _, data := range []string{
+		`not json`,
+		`{"path":"dir/a.txt"}`,
+		`{"path":"/dir/"}`,
+		`{"path":"/dir/a.txt","content_base64":"!!"}`,
+	} {
+		_, err = parseKafkaSinkMessage([]byte(data))
+		assert.NotNil(t, err, data)
+	}
+}
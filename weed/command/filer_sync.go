@@ -46,6 +46,7 @@ type SyncOptions struct {
 	metricsHttpIp   *string
 	metricsHttpPort *int
 	concurrency     *int
+	maxLag          *time.Duration
 	clientId        int32
 	clientEpoch     int32
 }
@@ -85,6 +86,7 @@ func init() {
 	syncOptions.aFromTsMs = cmdFilerSynchronize.Flag.Int64("a.fromTsMs", 0, "synchronization from timestamp on filer A. The unit is millisecond")
 	syncOptions.bFromTsMs = cmdFilerSynchronize.Flag.Int64("b.fromTsMs", 0, "synchronization from timestamp on filer B. The unit is millisecond")
 	syncOptions.concurrency = cmdFilerSynchronize.Flag.Int("concurrency", DefaultConcurrencyLimit, "The maximum number of files that will be synced concurrently.")
+	syncOptions.maxLag = cmdFilerSynchronize.Flag.Duration("maxLag", 0, "if the replication lag exceeds this, log an error and stop taking new changes until the target filer catches up. 0 means no limit.")
 	syncCpuProfile = cmdFilerSynchronize.Flag.String("cpuprofile", "", "cpu profile output file")
 	syncMemProfile = cmdFilerSynchronize.Flag.String("memprofile", "", "memory profile output file")
 	syncOptions.metricsHttpIp = cmdFilerSynchronize.Flag.String("metricsIp", "", "metrics listen ip")
@@ -103,10 +105,14 @@ var cmdFilerSynchronize = &Command{
 	* filer.sync only works between two filers.
 	* filer.sync does not need any special message queue setup.
 	* filer.sync supports both active-active and active-passive modes.
+	  With active-active, a file changed on both filers keeps the change with the later modification time.
 	
 	If restarted, the synchronization will resume from the previous checkpoints, persisted every minute.
 	A fresh sync will start from the earliest metadata logs.
 
+	The replication lag, how far the oldest change being synchronized is behind the newest change received from the source,
+	is exported as the replication_lag_seconds metric.
+
 `,
 }
 
@@ -163,6 +169,7 @@ func runFilerSynchronize(cmd *Command, args []string) bool {
 				*syncOptions.bDiskType,
 				*syncOptions.bDebug,
 				*syncOptions.concurrency,
+				*syncOptions.maxLag,
 				aFilerSignature,
 				bFilerSignature)
 			if err != nil {
@@ -200,6 +207,7 @@ func runFilerSynchronize(cmd *Command, args []string) bool {
 					*syncOptions.aDiskType,
 					*syncOptions.aDebug,
 					*syncOptions.concurrency,
+					*syncOptions.maxLag,
 					bFilerSignature,
 					aFilerSignature)
 				if err != nil {
@@ -232,7 +240,7 @@ func initOffsetFromTsMs(grpcDialOption grpc.DialOption, targetFiler pb.ServerAdd
 }
 
 func doSubscribeFilerMetaChanges(clientId int32, clientEpoch int32, grpcDialOption grpc.DialOption, sourceFiler pb.ServerAddress, sourcePath string, sourceExcludePaths []string, sourceReadChunkFromFiler bool, targetFiler pb.ServerAddress, targetPath string,
-	replicationStr, collection string, ttlSec int, sinkWriteChunkByFiler bool, diskType string, debug bool, concurrency int, sourceFilerSignature int32, targetFilerSignature int32) error {
+	replicationStr, collection string, ttlSec int, sinkWriteChunkByFiler bool, diskType string, debug bool, concurrency int, maxLag time.Duration, sourceFilerSignature int32, targetFilerSignature int32) error {
 
 	// if first time, start from now
 	// if has previously synced, resume from that point of time
@@ -268,6 +276,7 @@ func doSubscribeFilerMetaChanges(clientId int32, clientEpoch int32, grpcDialOpti
 		concurrency = DefaultConcurrencyLimit
 	}
 	processor := NewMetadataProcessor(processEventFn, concurrency)
+	processor.maxLag = maxLag
 
 	var lastLogTsNs = time.Now().UnixNano()
 	var clientName = fmt.Sprintf("syncFrom_%s_To_%s", string(sourceFiler), string(targetFiler))
@@ -287,6 +296,23 @@ func doSubscribeFilerMetaChanges(clientId int32, clientEpoch int32, grpcDialOpti
 		return setOffset(grpcDialOption, targetFiler, getSignaturePrefixByPath(sourcePath), sourceFilerSignature, processor.processedTsWatermark)
 	})
 
+	// the lag is also collected while no new changes arrive, when the active jobs finish
+	lagGauge := statsCollect.FilerSyncLagGauge.WithLabelValues(sourceFiler.String(), targetFiler.String(), clientName, sourcePath)
+	stopLagCollection := make(chan struct{})
+	defer close(stopLagCollection)
+	go func() {
+		ticker := time.NewTicker(3 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				lagGauge.Set(processor.Lag().Seconds())
+			case <-stopLagCollection:
+				return
+			}
+		}
+	}()
+
 	metadataFollowOption := &pb.MetadataFollowOption{
 		ClientName:             clientName,
 		ClientId:               clientId,
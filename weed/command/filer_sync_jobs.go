@@ -6,6 +6,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"sync"
+	"time"
 )
 
 type MetadataProcessor struct {
@@ -15,6 +16,10 @@ type MetadataProcessor struct {
 	concurrencyLimit     int
 	fn                   pb.ProcessMetadataFunc
 	processedTsWatermark int64
+	// the newest change taken waits while the oldest active job is older by more than maxLag
+	maxLag        time.Duration
+	lastTakenTsNs int64
+	isOverMaxLag  bool
 }
 
 func NewMetadataProcessor(fn pb.ProcessMetadataFunc, concurrency int) *MetadataProcessor {
@@ -35,9 +40,11 @@ func (t *MetadataProcessor) AddSyncJob(resp *filer_pb.SubscribeMetadataResponse)
 	t.activeJobsLock.Lock()
 	defer t.activeJobsLock.Unlock()
 
-	for len(t.activeJobs) >= t.concurrencyLimit || t.conflictsWith(resp) {
+	for len(t.activeJobs) >= t.concurrencyLimit || t.conflictsWith(resp) || t.lagsBehind(resp.TsNs) {
 		t.activeJobsCond.Wait()
 	}
+	t.isOverMaxLag = false
+	t.lastTakenTsNs = resp.TsNs
 	t.activeJobs[resp.TsNs] = resp
 	go func() {
 
@@ -67,6 +74,42 @@ func (t *MetadataProcessor) AddSyncJob(resp *filer_pb.SubscribeMetadataResponse)
 	}()
 }
 
+// Lag is the age of the oldest active job, compared to the last change taken, or 0 if all the changes are processed.
+func (t *MetadataProcessor) Lag() time.Duration {
+	t.activeJobsLock.Lock()
+	defer t.activeJobsLock.Unlock()
+	return t.lagOf(t.lastTakenTsNs)
+}
+
+func (t *MetadataProcessor) lagOf(tsNs int64) time.Duration {
+	var oldestTsNs int64
+	for activeTsNs := range t.activeJobs {
+		if oldestTsNs == 0 || activeTsNs < oldestTsNs {
+			oldestTsNs = activeTsNs
+		}
+	}
+	if oldestTsNs == 0 || tsNs < oldestTsNs {
+		return 0
+	}
+	return time.Duration(tsNs - oldestTsNs)
+}
+
+// lagsBehind checks whether taking the change would exceed the max lag, and logs once when it starts waiting
+func (t *MetadataProcessor) lagsBehind(tsNs int64) bool {
+	if t.maxLag <= 0 {
+		return false
+	}
+	lag := t.lagOf(tsNs)
+	if lag <= t.maxLag {
+		return false
+	}
+	if !t.isOverMaxLag {
+		t.isOverMaxLag = true
+		glog.Errorf("replication lag %v exceeds %v, waiting for the %d active jobs", lag, t.maxLag, len(t.activeJobs))
+	}
+	return true
+}
+
 func (t *MetadataProcessor) conflictsWith(resp *filer_pb.SubscribeMetadataResponse) bool {
 	for _, r := range t.activeJobs {
 		if shouldWaitFor(resp, r) {
@@ -0,0 +1,152 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/ftpd"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+var (
+	ftpStandaloneOptions FtpOption
+)
+
+type FtpOption struct {
+	filer            *string
+	ip               *string
+	ipBind           *string
+	port             *int
+	ftpRoot          *string
+	collection       *string
+	replication      *string
+	disk             *string
+	passivePortStart *int
+	passivePortStop  *int
+}
+
+func init() {
+	cmdFtp.Run = runFtp // break init cycle
+	ftpStandaloneOptions.filer = cmdFtp.Flag.String("filer", "localhost:8888", "filer server address")
+	ftpStandaloneOptions.ip = cmdFtp.Flag.String("ip", util.DetectedHostAddress(), "public ip address sent to the clients in passive mode")
+	ftpStandaloneOptions.ipBind = cmdFtp.Flag.String("ip.bind", "", "ip address to bind to. Default listen to all.")
+	ftpStandaloneOptions.port = cmdFtp.Flag.Int("port", 8021, "ftp server listen port")
+	ftpStandaloneOptions.ftpRoot = cmdFtp.Flag.String("filer.path", "/", "the home directories of the ftp users are under this filer path")
+	ftpStandaloneOptions.collection = cmdFtp.Flag.String("collection", "", "collection to create the files")
+	ftpStandaloneOptions.replication = cmdFtp.Flag.String("replication", "", "replication to create the files")
+	ftpStandaloneOptions.disk = cmdFtp.Flag.String("disk", "", "[hdd|ssd|<tag>] hard drive or solid state drive or any tag")
+	ftpStandaloneOptions.passivePortStart = cmdFtp.Flag.Int("port.passive.start", 30000, "passive port range start")
+	ftpStandaloneOptions.passivePortStop = cmdFtp.Flag.Int("port.passive.stop", 30100, "passive port range stop")
+}
+
+var cmdFtp = &Command{
+	UsageLine: "ftp -port=8021 -filer=<ip:port>",
+	Short:     "start an ftp server that is backed by a filer",
+	Long: `start an ftp server that is backed by a filer.
+
+	The ftp users are configured in the [ftp.users.<name>] sections of security.toml,
+	each with a password and a home directory on the filer.
+
+	FTPS is enabled with the [ftp] cert and key in security.toml.
+	Set [ftp] tls_required to refuse clients not using TLS.
+
+`,
+}
+
+func runFtp(cmd *Command, args []string) bool {
+
+	util.LoadConfiguration("security", false)
+
+	glog.V(0).Infof("Starting Seaweed FTP Server %s at port %d", util.Version(), *ftpStandaloneOptions.port)
+
+	return ftpStandaloneOptions.startFtpServer()
+
+}
+
+func (fo *FtpOption) startFtpServer() bool {
+
+	v := util.GetViper()
+
+	users := make(map[string]ftpd.FtpUser)
+	for name := range v.GetStringMap("ftp.users") {
+		users[name] = ftpd.FtpUser{
+			Password: v.GetString("ftp.users." + name + ".password"),
+			Home:     v.GetString("ftp.users." + name + ".home"),
+		}
+	}
+	if len(users) == 0 {
+		glog.Warningf("no ftp users are configured in security.toml")
+	}
+
+	tlsConfig, err := security.LoadServerTLSConfig(v, "ftp")
+	if err != nil {
+		glog.Fatalf("FTP Server TLS: %v", err)
+	}
+
+	filerAddress := pb.ServerAddress(*fo.filer)
+
+	grpcDialOption := security.LoadClientTLS(v, "grpc.client")
+
+	var cipher bool
+	// connect to filer
+	for {
+		err := pb.WithGrpcFilerClient(false, 0, filerAddress, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+			resp, err := client.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
+			if err != nil {
+				return fmt.Errorf("get filer %s configuration: %v", filerAddress, err)
+			}
+			cipher = resp.Cipher
+			return nil
+		})
+		if err != nil {
+			glog.V(0).Infof("wait to connect to filer %s grpc address %s", *fo.filer, filerAddress.ToGrpcAddress())
+			time.Sleep(time.Second)
+		} else {
+			glog.V(0).Infof("connected to filer %s grpc address %s", *fo.filer, filerAddress.ToGrpcAddress())
+			break
+		}
+	}
+
+	listenAddress := util.JoinHostPort(*fo.ipBind, *fo.port)
+	// the control connection can be idle between transfers, so no read timeout here
+	ftpListener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		glog.Fatalf("FTP Server listener on %s error: %v", listenAddress, err)
+	}
+
+	ftpServer, err := ftpd.NewFtpServer(ftpListener, &ftpd.FtpServerOption{
+		Filer:            *fo.filer,
+		IP:               *fo.ip,
+		IpBind:           *fo.ipBind,
+		Port:             *fo.port,
+		FilerGrpcAddress: filerAddress.ToGrpcAddress(),
+		FtpRoot:          *fo.ftpRoot,
+		GrpcDialOption:   grpcDialOption,
+		PassivePortStart: *fo.passivePortStart,
+		PassivePortStop:  *fo.passivePortStop,
+		Collection:       *fo.collection,
+		Replication:      *fo.replication,
+		DiskType:         *fo.disk,
+		Cipher:           cipher,
+		Users:            users,
+		TLSConfig:        tlsConfig,
+		TLSRequired:      v.GetBool("ftp.tls_required"),
+	})
+	if err != nil {
+		glog.Fatalf("FTP Server startup error: %v", err)
+	}
+
+	glog.V(0).Infof("Start Seaweed FTP Server %s at %s", util.Version(), listenAddress)
+	if err = ftpServer.Serve(); err != nil {
+		glog.Fatalf("FTP Server Fail to serve: %v", err)
+	}
+
+	return true
+
+}
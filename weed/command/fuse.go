@@ -153,6 +153,12 @@ func runFuse(cmd *Command, args []string) bool {
 			} else {
 				panic(fmt.Errorf("cacheCapacityMB: %s", err))
 			}
+		case "metaCacheMemMB":
+			if parsed, err := strconv.ParseInt(parameter.value, 0, 64); err == nil {
+				mountOptions.metaCacheMemMB = &parsed
+			} else {
+				panic(fmt.Errorf("metaCacheMemMB: %s", err))
+			}
 		case "dataCenter":
 			mountOptions.dataCenter = &parameter.value
 		case "allowOthers":
@@ -175,12 +181,46 @@ func runFuse(cmd *Command, args []string) bool {
 			mountOptions.uidMap = &parameter.value
 		case "map.gid":
 			mountOptions.gidMap = &parameter.value
+		case "ldap-server":
+			mountOptions.ldapServer = &parameter.value
+		case "ldap-start-tls":
+			if parsed, err := strconv.ParseBool(parameter.value); err == nil {
+				mountOptions.ldapStartTLS = &parsed
+			} else {
+				panic(fmt.Errorf("ldap-start-tls: %s", err))
+			}
+		case "ldap-base-dn":
+			mountOptions.ldapBaseDn = &parameter.value
+		case "ldap-bind-dn":
+			mountOptions.ldapBindDn = &parameter.value
+		case "ldap-bind-password":
+			mountOptions.ldapBindPassword = &parameter.value
+		case "ldap-cache-ttl":
+			if parsed, err := time.ParseDuration(parameter.value); err == nil {
+				mountOptions.ldapCacheTtl = &parsed
+			} else {
+				panic(fmt.Errorf("ldap-cache-ttl: %s", err))
+			}
 		case "readOnly":
 			if parsed, err := strconv.ParseBool(parameter.value); err == nil {
 				mountOptions.readOnly = &parsed
 			} else {
 				panic(fmt.Errorf("readOnly: %s", err))
 			}
+		case "healthCheckInterval":
+			if parsed, err := time.ParseDuration(parameter.value); err == nil {
+				mountOptions.healthCheck = &parsed
+			} else {
+				panic(fmt.Errorf("healthCheckInterval: %s", err))
+			}
+		case "namespaceConfig":
+			mountOptions.namespaceConfig = &parameter.value
+		case "showTrash":
+			if parsed, err := strconv.ParseBool(parameter.value); err == nil {
+				mountOptions.showTrash = &parsed
+			} else {
+				panic(fmt.Errorf("showTrash: %s", err))
+			}
 		case "cpuprofile":
 			mountCpuProfile = &parameter.value
 		case "memprofile":
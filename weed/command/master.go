@@ -21,12 +21,14 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/util/grace"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/security"
 	weed_server "github.com/seaweedfs/seaweedfs/weed/server"
 	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
@@ -44,18 +46,21 @@ type MasterOptions struct {
 	volumeSizeLimitMB *uint
 	volumePreallocate *bool
 	// pulseSeconds       *int
-	defaultReplication *string
-	garbageThreshold   *float64
-	whiteList          *string
-	disableHttp        *bool
-	metricsAddress     *string
-	metricsIntervalSec *int
-	raftResumeState    *bool
-	metricsHttpPort    *int
-	heartbeatInterval  *time.Duration
-	electionTimeout    *time.Duration
-	raftHashicorp      *bool
-	raftBootstrap      *bool
+	defaultReplication      *string
+	garbageThreshold        *float64
+	vacuumParallelism       *int
+	deadVolumeServerTimeout *time.Duration
+	auditLog                *string
+	whiteList               *string
+	disableHttp             *bool
+	metricsAddress          *string
+	metricsIntervalSec      *int
+	raftResumeState         *bool
+	metricsHttpPort         *int
+	heartbeatInterval       *time.Duration
+	electionTimeout         *time.Duration
+	raftHashicorp           *bool
+	raftBootstrap           *bool
 }
 
 func init() {
@@ -71,6 +76,9 @@ func init() {
 	// m.pulseSeconds = cmdMaster.Flag.Int("pulseSeconds", 5, "number of seconds between heartbeats")
 	m.defaultReplication = cmdMaster.Flag.String("defaultReplication", "", "Default replication type if not specified.")
 	m.garbageThreshold = cmdMaster.Flag.Float64("garbageThreshold", 0.3, "threshold to vacuum and reclaim spaces")
+	m.vacuumParallelism = cmdMaster.Flag.Int("vacuum-parallelism", topology.DefaultVacuumParallelism, "number of volumes to vacuum at the same time")
+	m.deadVolumeServerTimeout = cmdMaster.Flag.Duration("dead-volume-server-timeout", topology.DefaultDeadVolumeServerTimeout, "remove the volume servers without heartbeat for this long, and re-replicate their volumes. 0 to disable")
+	m.auditLog = cmdMaster.Flag.String("audit-log", "", "path to a json audit log file recording the dead volume server recovery actions")
 	m.whiteList = cmdMaster.Flag.String("whiteList", "", "comma separated Ip addresses having write permission. No limit if empty.")
 	m.disableHttp = cmdMaster.Flag.Bool("disableHttp", false, "disable http requests, only gRPC operations are allowed.")
 	m.metricsAddress = cmdMaster.Flag.String("metrics.address", "", "Prometheus gateway address <host>:<port>")
@@ -104,6 +112,8 @@ func runMaster(cmd *Command, args []string) bool {
 
 	util.LoadConfiguration("security", false)
 	util.LoadConfiguration("master", false)
+	util.LoadConfiguration("weed", false)
+	pb.LoadTracing(util.GetViper(), "seaweedfs-master")
 
 	grace.SetupProfiling(*masterCpuProfile, *masterMemProfile)
 
@@ -144,8 +154,17 @@ func startMaster(masterOption MasterOptions, masterWhiteList []string) {
 		masterPeers[string(peer)] = peer
 	}
 
+	option := masterOption.toMasterOption(masterWhiteList)
+	if *masterOption.auditLog != "" {
+		option.AuditLogger = filer.NewAuditLogger(*masterOption.auditLog, nil)
+		grace.OnInterrupt(func() {
+			option.AuditLogger.Close()
+		})
+		glog.V(0).Infof("master audit log to %s", *masterOption.auditLog)
+	}
+
 	r := mux.NewRouter()
-	ms := weed_server.NewMasterServer(r, masterOption.toMasterOption(masterWhiteList), masterPeers)
+	ms := weed_server.NewMasterServer(r, option, masterPeers)
 	listeningAddress := util.JoinHostPort(*masterOption.ipBind, *masterOption.port)
 	glog.V(0).Infof("Start Seaweed Master %s at %s", util.Version(), listeningAddress)
 	masterListener, masterLocalListener, e := util.NewIpAndLocalListeners(*masterOption.ipBind, *masterOption.port, 0)
@@ -307,6 +326,8 @@ func (m *MasterOptions) toMasterOption(whiteList []string) *weed_server.MasterOp
 		// PulseSeconds:            *m.pulseSeconds,
 		DefaultReplicaPlacement: *m.defaultReplication,
 		GarbageThreshold:        *m.garbageThreshold,
+		VacuumParallelism:       *m.vacuumParallelism,
+		DeadVolumeServerTimeout: *m.deadVolumeServerTimeout,
 		WhiteList:               whiteList,
 		DisableHttp:             *m.disableHttp,
 		MetricsAddress:          *m.metricsAddress,
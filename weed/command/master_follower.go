@@ -13,6 +13,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/security"
 	weed_server "github.com/seaweedfs/seaweedfs/weed/server"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"google.golang.org/grpc/reflection"
 )
@@ -34,6 +35,9 @@ func init() {
 	mf.volumePreallocate = nil
 	mf.defaultReplication = nil
 	mf.garbageThreshold = aws.Float64(0.1)
+	mf.vacuumParallelism = aws.Int(topology.DefaultVacuumParallelism)
+	mf.deadVolumeServerTimeout = new(time.Duration)
+	mf.auditLog = aws.String("")
 	mf.whiteList = nil
 	mf.disableHttp = aws.Bool(false)
 	mf.metricsAddress = aws.String("")
@@ -0,0 +1,463 @@
+package command
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/wdclient"
+)
+
+type MigrateOptions struct {
+	srcFiler         *string
+	dstFiler         *string
+	srcPath          *string
+	dstPath          *string
+	parallelism      *int
+	checkpointFile   *string
+	progressInterval *time.Duration
+}
+
+var (
+	migrateOptions MigrateOptions
+)
+
+func init() {
+	cmdMigrate.Run = runMigrate // break init cycle
+	migrateOptions.srcFiler = cmdMigrate.Flag.String("src-filer", "", "filer of the source cluster, e.g., localhost:8888")
+	migrateOptions.dstFiler = cmdMigrate.Flag.String("dst-filer", "", "filer of the destination cluster")
+	migrateOptions.srcPath = cmdMigrate.Flag.String("src-path", "/", "directory to migrate on the source filer")
+	migrateOptions.dstPath = cmdMigrate.Flag.String("dst-path", "/", "directory to write to on the destination filer")
+	migrateOptions.parallelism = cmdMigrate.Flag.Int("parallelism", 8, "number of files transferred at the same time")
+	migrateOptions.checkpointFile = cmdMigrate.Flag.String("checkpoint", "", "sqlite file recording the migrated files, to resume an interrupted migration. Needs the weed binary built with the sqlite tag")
+	migrateOptions.progressInterval = cmdMigrate.Flag.Duration("progress-interval", 10*time.Second, "how often to print the progress")
+}
+
+var cmdMigrate = &Command{
+	UsageLine: "migrate -src-filer=<host>:<port> -dst-filer=<host>:<port> -src-path=/path -dst-path=/path",
+	Short:     "copy a directory tree from one SeaweedFS cluster to another",
+	Long: `copy a directory tree from one SeaweedFS cluster to another
+
+	The files are read from the source volume servers, and written through the http api of the destination filer,
+	which stores them according to its own collection, replication and ttl settings.
+	The md5 of each file is checked against the source entry and the destination filer after the transfer.
+	The directories, including the empty ones, and the symlinks are created through the grpc api of the destination filer.
+	The modification time, the owner, and the extended attributes of each entry are kept.
+
+	With -checkpoint, the migrated files are recorded in a sqlite file, and a rerun with the same file skips
+	the ones not changed since. Failed files are not recorded, so a rerun retries them.
+
+	The progress is printed as "<bytes_migrated> / <total_bytes>".
+
+`,
+}
+
+func runMigrate(cmd *Command, args []string) bool {
+
+	util.LoadConfiguration("security", false)
+
+	if *migrateOptions.srcFiler == "" || *migrateOptions.dstFiler == "" {
+		return false
+	}
+	if *migrateOptions.parallelism <= 0 {
+		fmt.Printf("-parallelism should be positive\n")
+		return false
+	}
+
+	checkpoint, err := newMigrateCheckpoint(*migrateOptions.checkpointFile)
+	if err != nil {
+		fmt.Printf("open checkpoint %s: %v\n", *migrateOptions.checkpointFile, err)
+		return true
+	}
+	defer checkpoint.close()
+
+	v := util.GetViper()
+	grpcDialOption := security.LoadClientTLS(v, "grpc.client")
+	src := &migrateFilerClient{
+		address:        pb.ServerAddress(*migrateOptions.srcFiler),
+		grpcDialOption: grpcDialOption,
+	}
+	src.lookupFn = filer.LookupFn(src)
+	m := &migrator{
+		src:      src,
+		srcFiler: src.address,
+		dst: &migrateFilerClient{
+			address:        pb.ServerAddress(*migrateOptions.dstFiler),
+			grpcDialOption: grpcDialOption,
+		},
+		dstFiler:         pb.ServerAddress(*migrateOptions.dstFiler),
+		dstPath:          util.FullPath(*migrateOptions.dstPath),
+		signingKey:       security.SigningKey(v.GetString("jwt.filer_signing.key")),
+		expiresAfterSec:  v.GetInt("jwt.filer_signing.expires_after_seconds"),
+		checkpoint:       checkpoint,
+		srcPath:          util.FullPath(*migrateOptions.srcPath),
+		parallelism:      *migrateOptions.parallelism,
+		progressInterval: *migrateOptions.progressInterval,
+	}
+	if err = m.run(); err != nil {
+		fmt.Printf("migrate %s%s to %s%s: %v\n", m.srcFiler, m.srcPath, m.dstFiler, m.dstPath, err)
+	}
+	return true
+}
+
+// migrateCheckpoint records the migrated files, keyed by the source path.
+// A file is skipped only if its size and modification time are the same as recorded.
+type migrateCheckpoint interface {
+	isMigrated(path util.FullPath, entry *filer_pb.Entry) (bool, error)
+	markMigrated(path util.FullPath, entry *filer_pb.Entry) error
+	close() error
+}
+
+func newMigrateCheckpoint(file string) (migrateCheckpoint, error) {
+	if file == "" {
+		return noMigrateCheckpoint{}, nil
+	}
+	return newSqliteMigrateCheckpoint(file)
+}
+
+type noMigrateCheckpoint struct{}
+
+func (noMigrateCheckpoint) isMigrated(util.FullPath, *filer_pb.Entry) (bool, error) {
+	return false, nil
+}
+func (noMigrateCheckpoint) markMigrated(util.FullPath, *filer_pb.Entry) error { return nil }
+func (noMigrateCheckpoint) close() error                                      { return nil }
+
+type migrateFilerClient struct {
+	address        pb.ServerAddress
+	grpcDialOption grpc.DialOption
+	lookupFn       wdclient.LookupFileIdFunctionType
+}
+
+var _ = migrateSource(&migrateFilerClient{})
+
+// migrateSource is the source filer, whose file content is read from its volume servers
+type migrateSource interface {
+	filer_pb.FilerClient
+	wdclient.HasLookupFileIdFunction
+}
+
+func (c *migrateFilerClient) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithGrpcFilerClient(streamingMode, 0, c.address, c.grpcDialOption, fn)
+}
+
+func (c *migrateFilerClient) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (c *migrateFilerClient) GetDataCenter() string {
+	return ""
+}
+
+func (c *migrateFilerClient) GetLookupFileIdFunction() wdclient.LookupFileIdFunctionType {
+	return c.lookupFn
+}
+
+type migrateTask struct {
+	srcPath util.FullPath
+	dstPath util.FullPath
+	entry   *filer_pb.Entry
+}
+
+type migrator struct {
+	src              migrateSource
+	srcFiler         pb.ServerAddress
+	srcPath          util.FullPath
+	dst              filer_pb.FilerClient
+	dstFiler         pb.ServerAddress
+	dstPath          util.FullPath
+	signingKey       security.SigningKey
+	expiresAfterSec  int
+	checkpoint       migrateCheckpoint
+	parallelism      int
+	progressInterval time.Duration
+
+	totalBytes    uint64
+	migratedBytes atomic.Uint64
+	failedFiles   atomic.Int64
+}
+
+func (m *migrator) run() error {
+	if m.srcPath != "/" {
+		entry, err := filer_pb.GetEntry(m.src, m.srcPath)
+		if err != nil {
+			return err
+		}
+		if entry == nil || !entry.IsDirectory {
+			return fmt.Errorf("%s is not a directory", m.srcPath)
+		}
+	}
+
+	// count the bytes first, so the progress has a total
+	if err := m.walk(m.srcPath, m.dstPath, func(task *migrateTask) error {
+		m.totalBytes += filer.FileSize(task.entry)
+		return nil
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("migrating %d bytes from %s%s to %s%s\n", m.totalBytes, m.srcFiler, m.srcPath, m.dstFiler, m.dstPath)
+
+	tasks := make(chan *migrateTask, m.parallelism)
+	var wg sync.WaitGroup
+	for i := 0; i < m.parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				if err := m.migrateFile(task); err != nil {
+					glog.Errorf("migrate %s: %v", task.srcPath, err)
+					m.failedFiles.Add(1)
+				}
+			}
+		}()
+	}
+
+	stopProgress := make(chan struct{})
+	go m.printProgress(stopProgress)
+
+	err := m.walk(m.srcPath, m.dstPath, func(task *migrateTask) error {
+		if task.entry.IsDirectory {
+			// created here, before the files in it
+			if err := m.createEntry(task); err != nil {
+				glog.Errorf("migrate %s: %v", task.srcPath, err)
+				m.failedFiles.Add(1)
+			}
+			return nil
+		}
+		tasks <- task
+		return nil
+	})
+	close(tasks)
+	wg.Wait()
+	close(stopProgress)
+
+	fmt.Printf("%d / %d bytes migrated\n", m.migratedBytes.Load(), m.totalBytes)
+	if err != nil {
+		return err
+	}
+	if failed := m.failedFiles.Load(); failed > 0 {
+		return fmt.Errorf("%d files failed, rerun to retry them", failed)
+	}
+	return nil
+}
+
+// walk lists the source tree depth first, calling fn for each entry, and for each directory before the entries in it.
+// The entries of a directory are handed out after its listing, so a slow fn does not hold the listing stream open.
+func (m *migrator) walk(srcDir, dstDir util.FullPath, fn func(task *migrateTask) error) error {
+	var files, subDirs []*filer_pb.Entry
+	err := filer_pb.ReadDirAllEntries(m.src, srcDir, "", func(entry *filer_pb.Entry, isLast bool) error {
+		if entry.IsDirectory {
+			subDirs = append(subDirs, entry)
+		} else {
+			files = append(files, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("list %s: %v", srcDir, err)
+	}
+	for _, entry := range files {
+		if err = fn(&migrateTask{
+			srcPath: srcDir.Child(entry.Name),
+			dstPath: dstDir.Child(entry.Name),
+			entry:   entry,
+		}); err != nil {
+			return err
+		}
+	}
+	for _, entry := range subDirs {
+		task := &migrateTask{
+			srcPath: srcDir.Child(entry.Name),
+			dstPath: dstDir.Child(entry.Name),
+			entry:   entry,
+		}
+		if err = fn(task); err != nil {
+			return err
+		}
+		if err = m.walk(task.srcPath, task.dstPath, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *migrator) migrateFile(task *migrateTask) error {
+	if task.entry.GetAttributes().GetSymlinkTarget() != "" {
+		return m.createEntry(task)
+	}
+
+	size := filer.FileSize(task.entry)
+	migrated, err := m.checkpoint.isMigrated(task.srcPath, task.entry)
+	if err != nil {
+		return fmt.Errorf("read checkpoint: %v", err)
+	}
+	if migrated {
+		m.migratedBytes.Add(size)
+		return nil
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		var readErr error
+		if len(task.entry.Content) > 0 {
+			_, readErr = writer.Write(task.entry.Content)
+		} else {
+			readErr = filer.StreamContent(m.src, writer, task.entry.GetChunks(), 0, int64(size))
+		}
+		writer.CloseWithError(readErr)
+	}()
+	defer reader.Close()
+
+	hash := md5.New()
+	var transferred uint64
+	body := io.TeeReader(reader, writerFunc(func(p []byte) (int, error) {
+		transferred += uint64(len(p))
+		return hash.Write(p)
+	}))
+
+	dstMd5, err := m.upload(task, body, int64(size))
+	if err != nil {
+		return err
+	}
+	if transferred != size {
+		return fmt.Errorf("transferred %d bytes, expected %d", transferred, size)
+	}
+	srcMd5 := hash.Sum(nil)
+	if expected := task.entry.GetAttributes().GetMd5(); len(expected) > 0 && !bytes.Equal(expected, srcMd5) {
+		return fmt.Errorf("source md5 %x, expected %x", srcMd5, expected)
+	}
+	if dstMd5 != "" && dstMd5 != util.Base64Encode(srcMd5) {
+		return fmt.Errorf("destination md5 %s, expected %s", dstMd5, util.Base64Encode(srcMd5))
+	}
+
+	if err = m.keepAttributes(task); err != nil {
+		return err
+	}
+
+	m.migratedBytes.Add(size)
+	if err = m.checkpoint.markMigrated(task.srcPath, task.entry); err != nil {
+		return fmt.Errorf("write checkpoint: %v", err)
+	}
+	return nil
+}
+
+// upload writes the file to the destination filer, and returns the md5 reported by the filer
+func (m *migrator) upload(task *migrateTask, body io.Reader, size int64) (md5InBase64 string, err error) {
+	u := url.URL{
+		Scheme:   "http",
+		Host:     m.dstFiler.ToHttpAddress(),
+		Path:     string(task.dstPath),
+		RawQuery: url.Values{"mode": {fmt.Sprintf("%o", task.entry.GetAttributes().GetFileMode()&uint32(os.ModePerm))}}.Encode(),
+	}
+	req, err := http.NewRequest(http.MethodPut, u.String(), body)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	if mime := task.entry.GetAttributes().GetMime(); mime != "" {
+		req.Header.Set("Content-Type", mime)
+	}
+	if len(m.signingKey) > 0 {
+		req.Header.Set("Authorization", "BEARER "+string(security.GenJwtForFilerServer(m.signingKey, m.expiresAfterSec)))
+	}
+	resp, err := util.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload to %s: %v", u.String(), err)
+	}
+	defer util.CloseResponse(resp)
+	if resp.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("upload to %s: %s %s", u.String(), resp.Status, strings.TrimSpace(string(message)))
+	}
+	return resp.Header.Get("Content-MD5"), nil
+}
+
+// createEntry creates a directory or a symlink on the destination filer, which has no content to upload
+func (m *migrator) createEntry(task *migrateTask) error {
+	dir, name := task.dstPath.DirAndName()
+	return m.dst.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.CreateEntry(client, &filer_pb.CreateEntryRequest{
+			Directory: dir,
+			Entry: &filer_pb.Entry{
+				Name:        name,
+				IsDirectory: task.entry.IsDirectory,
+				Attributes:  migratedAttributes(task.entry.Attributes, &filer_pb.FuseAttributes{}),
+				Extended:    task.entry.Extended,
+			},
+		})
+	})
+}
+
+// keepAttributes sets the modification time, the owner, and the extended attributes of the uploaded file to the source ones
+func (m *migrator) keepAttributes(task *migrateTask) error {
+	entry, err := filer_pb.GetEntry(m.dst, task.dstPath)
+	if err != nil {
+		return fmt.Errorf("read uploaded %s: %v", task.dstPath, err)
+	}
+	entry.Attributes = migratedAttributes(task.entry.Attributes, entry.Attributes)
+	for k, v := range task.entry.Extended {
+		if entry.Extended == nil {
+			entry.Extended = make(map[string][]byte)
+		}
+		entry.Extended[k] = v
+	}
+	dir, _ := task.dstPath.DirAndName()
+	return m.dst.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory: dir,
+			Entry:     entry,
+		})
+	})
+}
+
+// migratedAttributes copies the source attributes kept by the migration onto the destination ones.
+// The storage settings, e.g., the collection and the ttl, are the destination filer's own.
+func migratedAttributes(src, dst *filer_pb.FuseAttributes) *filer_pb.FuseAttributes {
+	if dst == nil {
+		dst = &filer_pb.FuseAttributes{}
+	}
+	dst.Mtime = src.GetMtime()
+	dst.Crtime = src.GetCrtime()
+	dst.FileMode = src.GetFileMode()
+	dst.Uid = src.GetUid()
+	dst.Gid = src.GetGid()
+	dst.UserName = src.GetUserName()
+	dst.GroupName = src.GetGroupName()
+	dst.SymlinkTarget = src.GetSymlinkTarget()
+	return dst
+}
+
+func (m *migrator) printProgress(stop chan struct{}) {
+	ticker := time.NewTicker(m.progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			fmt.Printf("%d / %d bytes migrated\n", m.migratedBytes.Load(), m.totalBytes)
+		}
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,60 @@
+//go:build (linux || darwin || windows) && sqlite
+// +build linux darwin windows
+// +build sqlite
+
+// limited GOOS due to modernc.org/libc/unistd
+
+package command
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+type sqliteMigrateCheckpoint struct {
+	db *sql.DB
+}
+
+func newSqliteMigrateCheckpoint(file string) (migrateCheckpoint, error) {
+	db, err := sql.Open("sqlite", file)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if _, err = db.Exec(`CREATE TABLE IF NOT EXISTS migrated (
+		path TEXT PRIMARY KEY,
+		size BIGINT,
+		mtime BIGINT
+	)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteMigrateCheckpoint{db: db}, nil
+}
+
+func (c *sqliteMigrateCheckpoint) isMigrated(path util.FullPath, entry *filer_pb.Entry) (bool, error) {
+	var size, mtime int64
+	err := c.db.QueryRow("SELECT size, mtime FROM migrated WHERE path = ?", string(path)).Scan(&size, &mtime)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return size == int64(filer.FileSize(entry)) && mtime == entry.GetAttributes().GetMtime(), nil
+}
+
+func (c *sqliteMigrateCheckpoint) markMigrated(path util.FullPath, entry *filer_pb.Entry) error {
+	_, err := c.db.Exec("INSERT OR REPLACE INTO migrated (path, size, mtime) VALUES (?, ?, ?)",
+		string(path), int64(filer.FileSize(entry)), entry.GetAttributes().GetMtime())
+	return err
+}
+
+func (c *sqliteMigrateCheckpoint) close() error {
+	return c.db.Close()
+}
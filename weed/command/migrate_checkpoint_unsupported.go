@@ -0,0 +1,12 @@
+//go:build (!linux && !darwin && !windows) || !sqlite
+// +build !linux,!darwin,!windows !sqlite
+
+package command
+
+import (
+	"fmt"
+)
+
+func newSqliteMigrateCheckpoint(file string) (migrateCheckpoint, error) {
+	return nil, fmt.Errorf("the checkpoint file needs the weed binary built with the sqlite tag")
+}
@@ -0,0 +1,200 @@
+package command
+
+import (
+	"context"
+	"crypto/md5"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/wdclient"
+)
+
+// migrateTestFiler keeps the entries in memory, serving the grpc calls used by the migration
+type migrateTestFiler struct {
+	filer_pb.SeaweedFilerClient
+	sync.Mutex
+	entries map[util.FullPath]*filer_pb.Entry
+}
+
+func newMigrateTestFiler() *migrateTestFiler {
+	return &migrateTestFiler{entries: make(map[util.FullPath]*filer_pb.Entry)}
+}
+
+func (f *migrateTestFiler) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return fn(f)
+}
+
+func (f *migrateTestFiler) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (f *migrateTestFiler) GetDataCenter() string {
+	return ""
+}
+
+func (f *migrateTestFiler) GetLookupFileIdFunction() wdclient.LookupFileIdFunctionType {
+	return nil
+}
+
+func (f *migrateTestFiler) put(p util.FullPath, entry *filer_pb.Entry) {
+	f.Lock()
+	defer f.Unlock()
+	f.entries[p] = proto.Clone(entry).(*filer_pb.Entry)
+}
+
+func (f *migrateTestFiler) get(p util.FullPath) *filer_pb.Entry {
+	f.Lock()
+	defer f.Unlock()
+	if entry, found := f.entries[p]; found {
+		return proto.Clone(entry).(*filer_pb.Entry)
+	}
+	return nil
+}
+
+func (f *migrateTestFiler) LookupDirectoryEntry(ctx context.Context, in *filer_pb.LookupDirectoryEntryRequest, opts ...grpc.CallOption) (*filer_pb.LookupDirectoryEntryResponse, error) {
+	entry := f.get(util.NewFullPath(in.Directory, in.Name))
+	if entry == nil {
+		return nil, filer_pb.ErrNotFound
+	}
+	return &filer_pb.LookupDirectoryEntryResponse{Entry: entry}, nil
+}
+
+func (f *migrateTestFiler) ListEntries(ctx context.Context, in *filer_pb.ListEntriesRequest, opts ...grpc.CallOption) (filer_pb.SeaweedFiler_ListEntriesClient, error) {
+	f.Lock()
+	defer f.Unlock()
+	stream := &migrateTestListStream{}
+	for p, entry := range f.entries {
+		if dir, name := p.DirAndName(); dir == in.Directory && name > in.StartFromFileName {
+			stream.entries = append(stream.entries, proto.Clone(entry).(*filer_pb.Entry))
+		}
+	}
+	sort.Slice(stream.entries, func(i, j int) bool {
+		return stream.entries[i].Name < stream.entries[j].Name
+	})
+	return stream, nil
+}
+
+func (f *migrateTestFiler) CreateEntry(ctx context.Context, in *filer_pb.CreateEntryRequest, opts ...grpc.CallOption) (*filer_pb.CreateEntryResponse, error) {
+	f.put(util.NewFullPath(in.Directory, in.Entry.Name), in.Entry)
+	return &filer_pb.CreateEntryResponse{}, nil
+}
+
+func (f *migrateTestFiler) UpdateEntry(ctx context.Context, in *filer_pb.UpdateEntryRequest, opts ...grpc.CallOption) (*filer_pb.UpdateEntryResponse, error) {
+	f.put(util.NewFullPath(in.Directory, in.Entry.Name), in.Entry)
+	return &filer_pb.UpdateEntryResponse{}, nil
+}
+
+type migrateTestListStream struct {
+	grpc.ClientStream
+	entries []*filer_pb.Entry
+}
+
+func (s *migrateTestListStream) Recv() (*filer_pb.ListEntriesResponse, error) {
+	if len(s.entries) == 0 {
+		return nil, io.EOF
+	}
+	entry := s.entries[0]
+	s.entries = s.entries[1:]
+	return &filer_pb.ListEntriesResponse{Entry: entry}, nil
+}
+
+// ServeHTTP saves the uploaded file as the filer http api does, with the current time and no extended attributes
+func (f *migrateTestFiler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	mode, _ := strconv.ParseUint(r.URL.Query().Get("mode"), 8, 32)
+	p := util.FullPath(r.URL.Path)
+	_, name := p.DirAndName()
+	f.put(p, &filer_pb.Entry{
+		Name:    name,
+		Content: data,
+		Attributes: &filer_pb.FuseAttributes{
+			FileSize: uint64(len(data)),
+			Mtime:    time.Now().Unix(),
+			FileMode: uint32(mode),
+		},
+	})
+	hash := md5.Sum(data)
+	w.Header().Set("Content-MD5", util.Base64Encode(hash[:]))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func TestMigrateKeepsEntries(t *testing.T) {
+	src, dst := newMigrateTestFiler(), newMigrateTestFiler()
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC).Unix()
+	src.put("/data", &filer_pb.Entry{Name: "data", IsDirectory: true,
+		Attributes: &filer_pb.FuseAttributes{Mtime: mtime, FileMode: uint32(os.ModeDir | 0755)}})
+	src.put("/data/file", &filer_pb.Entry{Name: "file", Content: []byte("hello"),
+		Attributes: &filer_pb.FuseAttributes{Mtime: mtime, FileMode: 0640, FileSize: 5, Uid: 1000, Gid: 1000},
+		Extended:   map[string][]byte{"user.color": []byte("blue")}})
+	src.put("/data/link", &filer_pb.Entry{Name: "link",
+		Attributes: &filer_pb.FuseAttributes{Mtime: mtime, FileMode: uint32(os.ModeSymlink | 0777), SymlinkTarget: "file"}})
+	src.put("/data/empty", &filer_pb.Entry{Name: "empty", IsDirectory: true,
+		Attributes: &filer_pb.FuseAttributes{Mtime: mtime, FileMode: uint32(os.ModeDir | 0700)},
+		Extended:   map[string][]byte{"user.owner": []byte("team")}})
+
+	server := httptest.NewServer(dst)
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	m := &migrator{
+		src:              src,
+		srcFiler:         "src:8888",
+		srcPath:          "/data",
+		dst:              dst,
+		dstFiler:         pb.ServerAddress(serverUrl.Host),
+		dstPath:          "/copy",
+		checkpoint:       noMigrateCheckpoint{},
+		parallelism:      2,
+		progressInterval: time.Minute,
+	}
+	assert.Nil(t, m.run())
+
+	file := dst.get("/copy/file")
+	if assert.NotNil(t, file) {
+		assert.Equal(t, []byte("hello"), file.Content)
+		assert.Equal(t, mtime, file.Attributes.Mtime)
+		assert.Equal(t, uint32(0640), file.Attributes.FileMode)
+		assert.Equal(t, uint32(1000), file.Attributes.Uid)
+		assert.Equal(t, []byte("blue"), file.Extended["user.color"])
+	}
+
+	link := dst.get("/copy/link")
+	if assert.NotNil(t, link, "the symlink is migrated") {
+		assert.Equal(t, "file", link.Attributes.SymlinkTarget)
+		assert.Empty(t, link.Content, "not an empty file")
+		assert.True(t, os.FileMode(link.Attributes.FileMode)&os.ModeSymlink != 0)
+		assert.Equal(t, mtime, link.Attributes.Mtime)
+	}
+
+	empty := dst.get("/copy/empty")
+	if assert.NotNil(t, empty, "the empty directory is migrated") {
+		assert.True(t, empty.IsDirectory)
+		assert.Equal(t, mtime, empty.Attributes.Mtime)
+		assert.Equal(t, uint32(os.ModeDir|0700), empty.Attributes.FileMode)
+		assert.Equal(t, []byte("team"), empty.Extended["user.owner"])
+	}
+
+	for p := range dst.entries {
+		assert.True(t, strings.HasPrefix(string(p), "/copy/"), p)
+	}
+}
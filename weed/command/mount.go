@@ -19,6 +19,7 @@ type MountOptions struct {
 	concurrentWriters  *int
 	cacheDir           *string
 	cacheSizeMB        *int64
+	metaCacheMemMB     *int64
 	dataCenter         *string
 	allowOthers        *bool
 	umaskString        *string
@@ -26,11 +27,32 @@ type MountOptions struct {
 	volumeServerAccess *string
 	uidMap             *string
 	gidMap             *string
+	ldapServer         *string
+	ldapStartTLS       *bool
+	ldapBaseDn         *string
+	ldapBindDn         *string
+	ldapBindPassword   *string
+	ldapCacheTtl       *time.Duration
 	readOnly           *bool
 	debug              *bool
 	debugPort          *int
 	localSocket        *string
 	disableXAttr       *bool
+	showTrash          *bool
+	namespaceConfig    *string
+	healthCheck        *time.Duration
+	readTimeout        *time.Duration
+	writeTimeout       *time.Duration
+	metadataTimeout    *time.Duration
+	breakerFailures    *uint
+	breakerWindow      *time.Duration
+	breakerOpenTime    *time.Duration
+	readRetryBase      *time.Duration
+	readRetryCap       *time.Duration
+	maxDownloadMBps    *int
+	maxUploadMBps      *int
+	readAheadMB        *int
+	mmapCoherence      *string
 	extraOptions       []string
 }
 
@@ -56,6 +78,7 @@ func init() {
 	mountOptions.concurrentWriters = cmdMount.Flag.Int("concurrentWriters", 32, "limit concurrent goroutine writers")
 	mountOptions.cacheDir = cmdMount.Flag.String("cacheDir", os.TempDir(), "local cache directory for file chunks and meta data")
 	mountOptions.cacheSizeMB = cmdMount.Flag.Int64("cacheCapacityMB", 0, "file chunk read cache capacity in MB")
+	mountOptions.metaCacheMemMB = cmdMount.Flag.Int64("metaCacheMemMB", 64, "in-memory metadata cache capacity in MB, in front of the on-disk metadata cache")
 	mountOptions.dataCenter = cmdMount.Flag.String("dataCenter", "", "prefer to write to the data center")
 	mountOptions.allowOthers = cmdMount.Flag.Bool("allowOthers", true, "allows other users to access the file system")
 	mountOptions.umaskString = cmdMount.Flag.String("umask", "022", "octal umask, e.g., 022, 0111")
@@ -63,11 +86,36 @@ func init() {
 	mountOptions.volumeServerAccess = cmdMount.Flag.String("volumeServerAccess", "direct", "access volume servers by [direct|publicUrl|filerProxy]")
 	mountOptions.uidMap = cmdMount.Flag.String("map.uid", "", "map local uid to uid on filer, comma-separated <local_uid>:<filer_uid>")
 	mountOptions.gidMap = cmdMount.Flag.String("map.gid", "", "map local gid to gid on filer, comma-separated <local_gid>:<filer_gid>")
+	mountOptions.ldapServer = cmdMount.Flag.String("ldap-server", "", "map the local uid and gid to the filer ones by the user and group names on this ldap server, e.g., ldaps://ldap.example.com")
+	mountOptions.ldapStartTLS = cmdMount.Flag.Bool("ldap-start-tls", false, "upgrade the ldap:// connection to tls with StartTLS")
+	mountOptions.ldapBaseDn = cmdMount.Flag.String("ldap-base-dn", "", "the ldap base dn to search for the (uid=<username>) and (cn=<groupname>) entries")
+	mountOptions.ldapBindDn = cmdMount.Flag.String("ldap-bind-dn", "", "the ldap bind dn, empty for anonymous searches")
+	mountOptions.ldapBindPassword = cmdMount.Flag.String("ldap-bind-password", "", "the ldap bind password")
+	mountOptions.ldapCacheTtl = cmdMount.Flag.Duration("ldap-cache-ttl", 5*time.Minute, "cache the ldap uid and gid lookups for this long")
 	mountOptions.readOnly = cmdMount.Flag.Bool("readOnly", false, "read only")
-	mountOptions.debug = cmdMount.Flag.Bool("debug", false, "serves runtime profiling data, e.g., http://localhost:<debug.port>/debug/pprof/goroutine?debug=2")
+	mountOptions.debug = cmdMount.Flag.Bool("debug", false, "serves runtime profiling data, e.g., http://localhost:<debug.port>/debug/pprof/goroutine?debug=2, and prometheus metrics at /metrics")
 	mountOptions.debugPort = cmdMount.Flag.Int("debug.port", 6061, "http port for debugging")
 	mountOptions.localSocket = cmdMount.Flag.String("localSocket", "", "default to /tmp/seaweedfs-mount-<mount_dir_hash>.sock")
 	mountOptions.disableXAttr = cmdMount.Flag.Bool("disableXAttr", false, "disable xattr")
+	mountOptions.healthCheck = cmdMount.Flag.Duration("healthCheckInterval", 0, "check the mount and the filer at this interval, e.g. 30s, and remount after 3 consecutive failures. 0 to disable")
+	mountOptions.readTimeout = cmdMount.Flag.Duration("readTimeout", 30*time.Second, "fail a read with ETIMEDOUT if the filer does not respond in time. 0 to wait forever")
+	mountOptions.writeTimeout = cmdMount.Flag.Duration("writeTimeout", 30*time.Second, "fail a flush with ETIMEDOUT if the filer does not respond in time. 0 to wait forever")
+	mountOptions.metadataTimeout = cmdMount.Flag.Duration("metadataTimeout", 30*time.Second, "fail a lookup, readdir, or getattr with ETIMEDOUT if the filer does not respond in time. 0 to wait forever")
+	mountOptions.breakerFailures = cmdMount.Flag.Uint("circuitBreaker.failures", 0, "fail the filer calls fast after this many consecutive filer failures, e.g., 5. 0 disables the circuit breaker")
+	mountOptions.breakerWindow = cmdMount.Flag.Duration("circuitBreaker.window", 10*time.Second, "count the consecutive filer failures within this window")
+	mountOptions.breakerOpenTime = cmdMount.Flag.Duration("circuitBreaker.openTime", 30*time.Second, "fail the filer calls fast for this long, before trying the filer again")
+	mountOptions.readRetryBase = cmdMount.Flag.Duration("readRetryBase", 100*time.Millisecond, "retry the failed chunk reads after a random wait up to readRetryBase * 2^attempt")
+	mountOptions.readRetryCap = cmdMount.Flag.Duration("readRetryCap", 10*time.Second, "the cap of the random wait between the chunk read retries")
+	mountOptions.maxDownloadMBps = cmdMount.Flag.Int("maxDownloadMBps", 0, "limit the read speed of the mount in MB/s, 0 for unlimited. A directory xattr user.seaweedfs.download-rate=<bytes per second> overrides it for the files under the directory")
+	mountOptions.maxUploadMBps = cmdMount.Flag.Int("maxUploadMBps", 0, "limit the chunk upload speed of the mount in MB/s, 0 for unlimited. A directory xattr user.seaweedfs.upload-rate=<bytes per second> overrides it for the files under the directory")
+	mountOptions.readAheadMB = cmdMount.Flag.Int("readAheadMB", 0, "cap the memory of the chunks prefetched for the sequential reads of all files in MB, 0 for no cap")
+	mountOptions.mmapCoherence = cmdMount.Flag.String("mmapCoherence", "none", "when to drop the kernel cached pages of the memory mapped files: "+
+		"none keeps them until evicted or reopened, fastest but a MAP_SHARED mapping may see stale data; "+
+		"local drops them after each write or truncate through this mount, costing page faults on the next access; "+
+		"distributed also drops them when other clients change the files, as the filer events arrive, so the mappings are eventually coherent across mounts")
+	mountOptions.showTrash = cmdMount.Flag.Bool("showTrash", false, "list the .trash directories, which keep the deleted files of the directories with a trash ttl")
+
+	mountOptions.namespaceConfig = cmdMount.Flag.String("namespaceConfig", "", "a yaml file mapping the mount sub-paths to their own filers, reloaded on SIGHUP")
 
 	mountCpuProfile = cmdMount.Flag.String("cpuprofile", "", "cpu profile output file")
 	mountMemProfile = cmdMount.Flag.String("memprofile", "", "memory profile output file")
@@ -15,7 +15,10 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mount_pb"
 	"github.com/seaweedfs/seaweedfs/weed/security"
+	stats_collect "github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 	"net"
 	"net/http"
@@ -24,6 +27,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/util"
@@ -33,6 +37,7 @@ import (
 func runMount(cmd *Command, args []string) bool {
 
 	if *mountOptions.debug {
+		http.Handle("/metrics", stats_collect.MetricsHandler())
 		go http.ListenAndServe(fmt.Sprintf(":%d", *mountOptions.debugPort), nil)
 	}
 
@@ -67,11 +72,34 @@ func RunMount(option *MountOptions, umask os.FileMode) bool {
 	// try to connect to filer
 	filerAddresses := pb.ServerAddresses(*option.filer).ToAddresses()
 	util.LoadConfiguration("security", false)
+	util.LoadConfiguration("weed", false)
+	pb.LoadTracing(util.GetViper(), "seaweedfs-mount")
 	grpcDialOption := security.LoadClientTLS(util.GetViper(), "grpc.client")
+	filerGrpcDialOption := grpcDialOption
+	var filerCredentials credentials.Bundle
+	tokenRefresherStopCh := make(chan struct{})
+	defer close(tokenRefresherStopCh)
+	if tokenUrl := util.GetViper().GetString("grpc.client.jwt.token_url"); tokenUrl != "" {
+		transportCredentials := security.LoadClientTransportCredentials(util.GetViper(), "grpc.client")
+		if transportCredentials.Info().SecurityProtocol != "tls" {
+			glog.Errorf("grpc.client.jwt.token_url requires grpc.client cert, key and grpc.ca, so that the jwt is not sent in cleartext")
+			return true
+		}
+		util.GetViper().SetDefault("grpc.client.jwt.refresh_before_seconds", 300)
+		refreshBefore := time.Duration(util.GetViper().GetInt("grpc.client.jwt.refresh_before_seconds")) * time.Second
+		tokenRefresher, err := mount.NewTokenRefresher(tokenUrl, refreshBefore)
+		if err != nil {
+			glog.Errorf("failed to get jwt: %v", err)
+			return true
+		}
+		go tokenRefresher.Start(tokenRefresherStopCh)
+		filerCredentials = tokenRefresher.CredentialsBundle(transportCredentials)
+		filerGrpcDialOption = grpc.WithCredentialsBundle(filerCredentials)
+	}
 	var cipher bool
 	var err error
 	for i := 0; i < 10; i++ {
-		err = pb.WithOneOfGrpcFilerClients(false, filerAddresses, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		err = pb.WithOneOfGrpcFilerClients(false, filerAddresses, filerGrpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
 			resp, err := client.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
 			if err != nil {
 				return fmt.Errorf("get filer grpc address %v configuration: %v", filerAddresses, err)
@@ -99,22 +127,23 @@ func RunMount(option *MountOptions, umask os.FileMode) bool {
 		return false
 	}
 
+	// a running mount on the directory or the socket is not unmounted
+	if *option.localSocket == "" {
+		*option.localSocket = pb.LocalMountSocket(dir)
+	}
+	if err := checkMountCollision(dir, *option.localSocket); err != nil {
+		mountFailed("%v", err)
+	}
+
 	unmount.Unmount(dir)
 
 	// start on local unix socket
-	if *option.localSocket == "" {
-		mountDirHash := util.HashToInt32([]byte(dir))
-		if mountDirHash < 0 {
-			mountDirHash = -mountDirHash
-		}
-		*option.localSocket = fmt.Sprintf("/tmp/seaweedfs-mount-%d.sock", mountDirHash)
-	}
 	if err := os.Remove(*option.localSocket); err != nil && !os.IsNotExist(err) {
-		glog.Fatalf("Failed to remove %s, error: %s", *option.localSocket, err.Error())
+		mountFailed("Failed to remove %s, error: %s", *option.localSocket, err.Error())
 	}
 	montSocketListener, err := net.Listen("unix", *option.localSocket)
 	if err != nil {
-		glog.Fatalf("Failed to listen on %s: %v", *option.localSocket, err)
+		mountFailed("Failed to listen on %s: %v", *option.localSocket, err)
 	}
 
 	// detect mount folder mode
@@ -154,6 +183,21 @@ func RunMount(option *MountOptions, umask os.FileMode) bool {
 		fmt.Printf("failed to parse %s %s: %v\n", *option.uidMap, *option.gidMap, err)
 		return false
 	}
+	if *option.ldapServer != "" {
+		ldapMapper, err := meta_cache.NewLDAPUidGidMapper(*option.ldapServer, *option.ldapStartTLS, *option.ldapBaseDn, *option.ldapBindDn, *option.ldapBindPassword, *option.ldapCacheTtl)
+		if err != nil {
+			fmt.Printf("failed to use ldap server %s: %v\n", *option.ldapServer, err)
+			return false
+		}
+		uidGidMapper.UseLDAP(ldapMapper)
+	}
+
+	switch *option.mmapCoherence {
+	case mount.MmapCoherenceNone, mount.MmapCoherenceLocal, mount.MmapCoherenceDistributed:
+	default:
+		fmt.Printf("unknown -mmapCoherence %s, should be none, local, or distributed\n", *option.mmapCoherence)
+		return false
+	}
 
 	// Ensure target mount point availability
 	if isValid := checkMountPointAvailable(dir); !isValid {
@@ -214,31 +258,74 @@ func RunMount(option *MountOptions, umask os.FileMode) bool {
 		mountRoot = mountRoot[0 : len(mountRoot)-1]
 	}
 
+	var namespaceRouter *mount.NamespaceRouter
+	if *option.namespaceConfig != "" {
+		namespaceRouter, err = mount.NewNamespaceRouter(*option.namespaceConfig, util.FullPath(mountRoot))
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			return false
+		}
+		grace.OnReload(func() {
+			if err := namespaceRouter.Reload(); err != nil {
+				glog.Errorf("reload namespaces: %v", err)
+			}
+		})
+	}
+
+	// set by the health monitor, to mount again after server.Serve() returns
+	var remounting atomic.Bool
 	seaweedFileSystem := mount.NewSeaweedFileSystem(&mount.Option{
-		MountDirectory:     dir,
-		FilerAddresses:     filerAddresses,
-		GrpcDialOption:     grpcDialOption,
-		FilerMountRootPath: mountRoot,
-		Collection:         *option.collection,
-		Replication:        *option.replication,
-		TtlSec:             int32(*option.ttlSec),
-		DiskType:           types.ToDiskType(*option.diskType),
-		ChunkSizeLimit:     int64(chunkSizeLimitMB) * 1024 * 1024,
-		ConcurrentWriters:  *option.concurrentWriters,
-		CacheDir:           *option.cacheDir,
-		CacheSizeMB:        *option.cacheSizeMB,
-		DataCenter:         *option.dataCenter,
-		Quota:              int64(*option.collectionQuota) * 1024 * 1024,
-		MountUid:           uid,
-		MountGid:           gid,
-		MountMode:          mountMode,
-		MountCtime:         fileInfo.ModTime(),
-		MountMtime:         time.Now(),
-		Umask:              umask,
-		VolumeServerAccess: *mountOptions.volumeServerAccess,
-		Cipher:             cipher,
-		UidGidMapper:       uidGidMapper,
-		DisableXAttr:       *option.disableXAttr,
+		MountDirectory:            dir,
+		LocalSocket:               *option.localSocket,
+		FilerAddresses:            filerAddresses,
+		GrpcDialOption:            grpcDialOption,
+		FilerCredentials:          filerCredentials,
+		FilerMountRootPath:        mountRoot,
+		Collection:                *option.collection,
+		Replication:               *option.replication,
+		TtlSec:                    int32(*option.ttlSec),
+		DiskType:                  types.ToDiskType(*option.diskType),
+		ChunkSizeLimit:            int64(chunkSizeLimitMB) * 1024 * 1024,
+		ConcurrentWriters:         *option.concurrentWriters,
+		CacheDir:                  *option.cacheDir,
+		CacheSizeMB:               *option.cacheSizeMB,
+		MemCacheSizeMB:            *option.metaCacheMemMB,
+		DataCenter:                *option.dataCenter,
+		Quota:                     int64(*option.collectionQuota) * 1024 * 1024,
+		MountUid:                  uid,
+		MountGid:                  gid,
+		MountMode:                 mountMode,
+		MountCtime:                fileInfo.ModTime(),
+		MountMtime:                time.Now(),
+		Umask:                     umask,
+		VolumeServerAccess:        *mountOptions.volumeServerAccess,
+		Cipher:                    cipher,
+		UidGidMapper:              uidGidMapper,
+		DisableXAttr:              *option.disableXAttr,
+		ShowTrash:                 *option.showTrash,
+		NamespaceRouter:           namespaceRouter,
+		HealthCheckInterval:       *option.healthCheck,
+		ReadTimeout:               *option.readTimeout,
+		WriteTimeout:              *option.writeTimeout,
+		MetadataTimeout:           *option.metadataTimeout,
+		CircuitBreakerFailures:    uint32(*option.breakerFailures),
+		CircuitBreakerWindow:      *option.breakerWindow,
+		CircuitBreakerOpenTime:    *option.breakerOpenTime,
+		ReadRetryBase:             *option.readRetryBase,
+		ReadRetryCap:              *option.readRetryCap,
+		MaxDownloadBytesPerSecond: int64(*option.maxDownloadMBps) * 1024 * 1024,
+		MaxUploadBytesPerSecond:   int64(*option.maxUploadMBps) * 1024 * 1024,
+		ReadAheadMB:               int64(*option.readAheadMB),
+		MmapCoherence:             *option.mmapCoherence,
+		OnUnhealthy: func() {
+			remounting.Store(true)
+			glog.Warningf("unmount unresponsive %s to remount", dir)
+			if err := unmount.Unmount(dir); err != nil {
+				// server.Serve() goes on, so it must not be mounted again when it returns later
+				remounting.Store(false)
+				glog.Errorf("unmount %s: %v", dir, err)
+			}
+		},
 	})
 
 	server, err := fuse.NewServer(seaweedFileSystem, dir, fuseMountOptions)
@@ -259,7 +346,43 @@ func RunMount(option *MountOptions, umask os.FileMode) bool {
 	glog.V(0).Infof("mounted %s%s to %v", *option.filer, mountRoot, dir)
 	glog.V(0).Infof("This is SeaweedFS version %s %s %s", util.Version(), runtime.GOOS, runtime.GOARCH)
 
-	server.Serve()
+	for {
+		server.Serve()
+		if !remounting.CompareAndSwap(true, false) {
+			break
+		}
+		// the same file system is mounted again with the same options, keeping the caches and the filer subscription
+		server, err = fuse.NewServer(seaweedFileSystem, dir, fuseMountOptions)
+		if err != nil {
+			glog.Errorf("remount %s: %v", dir, err)
+			return true
+		}
+		glog.V(0).Infof("remounted %s%s to %v", *option.filer, mountRoot, dir)
+	}
 
 	return true
 }
+
+// mountFailed reports the error without the stack traces of glog.Fatalf, and exits with a non-zero status
+func mountFailed(format string, args ...interface{}) {
+	glog.Errorf(format, args...)
+	glog.Flush()
+	os.Exit(1)
+}
+
+// checkMountCollision fails if a running mount already serves the socket or the mount directory.
+func checkMountCollision(dir, localSocket string) error {
+	if mounts, err := pb.ListMountsOnSocket(localSocket, time.Second); err == nil && len(mounts) > 0 {
+		return fmt.Errorf("socket %s is used by the mount on %s, pid %d", localSocket, mounts[0].MountDirectory, mounts[0].Pid)
+	}
+	mounts, err := pb.ListLocalMounts(time.Second)
+	if err != nil {
+		return fmt.Errorf("list local mounts: %v", err)
+	}
+	for _, m := range mounts {
+		if m.MountDirectory == dir {
+			return fmt.Errorf("%s is already mounted from %s%s, pid %d, socket %s", dir, m.Filer, m.FilerMountRootPath, m.Pid, m.LocalSocket)
+		}
+	}
+	return nil
+}
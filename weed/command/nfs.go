@@ -0,0 +1,140 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/nfs"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+var (
+	nfsStandaloneOptions NfsOption
+)
+
+type NfsOption struct {
+	filer         *string
+	filerRootPath *string
+	ip            *string
+	port          *int
+	collection    *string
+	replication   *string
+	disk          *string
+	handleLimit   *int
+}
+
+func init() {
+	cmdNfs.Run = runNfs // break init cycle
+	nfsStandaloneOptions.filer = cmdNfs.Flag.String("filer", "localhost:8888", "filer server address")
+	nfsStandaloneOptions.filerRootPath = cmdNfs.Flag.String("filer.path", "/", "use this remote path from filer server")
+	nfsStandaloneOptions.ip = cmdNfs.Flag.String("ip.bind", "", "ip address to bind to. Default listen to all.")
+	nfsStandaloneOptions.port = cmdNfs.Flag.Int("port", 2049, "nfs server listen port")
+	nfsStandaloneOptions.collection = cmdNfs.Flag.String("collection", "", "collection to create the files")
+	nfsStandaloneOptions.replication = cmdNfs.Flag.String("replication", "", "replication to create the files")
+	nfsStandaloneOptions.disk = cmdNfs.Flag.String("disk", "", "[hdd|ssd|<tag>] hard drive or solid state drive or any tag")
+	nfsStandaloneOptions.handleLimit = cmdNfs.Flag.Int("handleLimit", 1024*1024, "max number of file handles remembered by the server")
+}
+
+var cmdNfs = &Command{
+	UsageLine: "nfs -port=2049 -filer=<ip:port>",
+	Short:     "start an NFSv3 server that is backed by a filer",
+	Long: `start an NFSv3 server that is backed by a filer.
+
+	All reads and writes go through the filer gRPC api, the same as "weed mount",
+	so NFS clients can access SeaweedFS without a FUSE mount.
+
+	The file handles are kept in memory. After a restart, NFS clients get a stale
+	file handle error, and need to re-mount.
+
+	On the client:
+		mount -t nfs -o port=2049,mountport=2049,nfsvers=3,noacl,tcp <host>:/ /mnt/seaweedfs
+
+`,
+}
+
+func runNfs(cmd *Command, args []string) bool {
+
+	util.LoadConfiguration("security", false)
+
+	glog.V(0).Infof("Starting Seaweed NFS Server %s at port %d", util.Version(), *nfsStandaloneOptions.port)
+
+	return nfsStandaloneOptions.startNfsServer()
+
+}
+
+func (no *NfsOption) startNfsServer() bool {
+
+	// detect current user
+	uid, gid := uint32(0), uint32(0)
+	if u, err := user.Current(); err == nil {
+		if parsedId, pe := strconv.ParseUint(u.Uid, 10, 32); pe == nil {
+			uid = uint32(parsedId)
+		}
+		if parsedId, pe := strconv.ParseUint(u.Gid, 10, 32); pe == nil {
+			gid = uint32(parsedId)
+		}
+	}
+
+	filerAddress := pb.ServerAddress(*no.filer)
+
+	grpcDialOption := security.LoadClientTLS(util.GetViper(), "grpc.client")
+
+	var cipher bool
+	// connect to filer
+	for {
+		err := pb.WithGrpcFilerClient(false, 0, filerAddress, grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+			resp, err := client.GetFilerConfiguration(context.Background(), &filer_pb.GetFilerConfigurationRequest{})
+			if err != nil {
+				return fmt.Errorf("get filer %s configuration: %v", filerAddress, err)
+			}
+			cipher = resp.Cipher
+			return nil
+		})
+		if err != nil {
+			glog.V(0).Infof("wait to connect to filer %s grpc address %s", *no.filer, filerAddress.ToGrpcAddress())
+			time.Sleep(time.Second)
+		} else {
+			glog.V(0).Infof("connected to filer %s grpc address %s", *no.filer, filerAddress.ToGrpcAddress())
+			break
+		}
+	}
+
+	nfsServer, err := nfs.NewNfsServer(&nfs.NfsServerOption{
+		Filer:          filerAddress,
+		FilerRootPath:  *no.filerRootPath,
+		GrpcDialOption: grpcDialOption,
+		Collection:     *no.collection,
+		Replication:    *no.replication,
+		DiskType:       *no.disk,
+		Uid:            uid,
+		Gid:            gid,
+		Cipher:         cipher,
+		HandleLimit:    *no.handleLimit,
+	})
+	if err != nil {
+		glog.Fatalf("NFS Server startup error: %v", err)
+	}
+
+	listenAddress := util.JoinHostPort(*no.ip, *no.port)
+	// nfs clients keep idle connections open, so no read timeout here
+	nfsListener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		glog.Fatalf("NFS Server listener on %s error: %v", listenAddress, err)
+	}
+
+	glog.V(0).Infof("Start Seaweed NFS Server %s at %s", util.Version(), listenAddress)
+	if err = nfsServer.Serve(nfsListener); err != nil {
+		glog.Fatalf("NFS Server Fail to serve: %v", err)
+	}
+
+	return true
+
+}
@@ -43,6 +43,7 @@ type S3Options struct {
 	auditLogConfig            *string
 	localFilerSocket          *string
 	dataCenter                *string
+	directUpload              *bool
 	certProvider              certprovider.Provider
 }
 
@@ -62,6 +63,7 @@ func init() {
 	s3StandaloneOptions.allowEmptyFolder = cmdS3.Flag.Bool("allowEmptyFolder", true, "allow empty folders")
 	s3StandaloneOptions.allowDeleteBucketNotEmpty = cmdS3.Flag.Bool("allowDeleteBucketNotEmpty", true, "allow recursive deleting all entries along with bucket")
 	s3StandaloneOptions.localFilerSocket = cmdS3.Flag.String("localFilerSocket", "", "local filer socket path")
+	s3StandaloneOptions.directUpload = cmdS3.Flag.Bool("directUpload", false, "upload the objects larger than the filer chunk size directly to the volume servers, instead of through the filer")
 }
 
 var cmdS3 = &Command{
@@ -166,6 +168,8 @@ func (s3opt *S3Options) startS3Server() bool {
 
 	filerBucketsPath := "/buckets"
 	filerGroup := ""
+	var chunkSizeLimit int64
+	var cipher bool
 
 	grpcDialOption := security.LoadClientTLS(util.GetViper(), "grpc.client")
 
@@ -181,6 +185,7 @@ func (s3opt *S3Options) startS3Server() bool {
 			}
 			filerBucketsPath = resp.DirBuckets
 			filerGroup = resp.FilerGroup
+			chunkSizeLimit, cipher = int64(resp.MaxMb)*1024*1024, resp.Cipher
 			metricsAddress, metricsIntervalSec = resp.MetricsAddress, int(resp.MetricsIntervalSec)
 			glog.V(0).Infof("S3 read filer buckets dir: %s", filerBucketsPath)
 			return nil
@@ -213,6 +218,9 @@ func (s3opt *S3Options) startS3Server() bool {
 		LocalFilerSocket:          localFilerSocket,
 		DataCenter:                *s3opt.dataCenter,
 		FilerGroup:                filerGroup,
+		DirectUpload:              *s3opt.directUpload,
+		ChunkSizeLimit:            chunkSizeLimit,
+		Cipher:                    cipher,
 	})
 	if s3ApiServer_err != nil {
 		glog.Fatalf("S3 API Server startup error: %v", s3ApiServer_err)
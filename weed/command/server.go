@@ -11,6 +11,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"github.com/seaweedfs/seaweedfs/weed/util/grace"
 )
@@ -93,6 +94,9 @@ func init() {
 	masterOptions.volumePreallocate = cmdServer.Flag.Bool("master.volumePreallocate", false, "Preallocate disk space for volumes.")
 	masterOptions.defaultReplication = cmdServer.Flag.String("master.defaultReplication", "", "Default replication type if not specified.")
 	masterOptions.garbageThreshold = cmdServer.Flag.Float64("master.garbageThreshold", 0.3, "threshold to vacuum and reclaim spaces")
+	masterOptions.vacuumParallelism = cmdServer.Flag.Int("master.vacuum-parallelism", topology.DefaultVacuumParallelism, "number of volumes to vacuum at the same time")
+	masterOptions.deadVolumeServerTimeout = cmdServer.Flag.Duration("master.dead-volume-server-timeout", topology.DefaultDeadVolumeServerTimeout, "remove the volume servers without heartbeat for this long, and re-replicate their volumes. 0 to disable")
+	masterOptions.auditLog = cmdServer.Flag.String("master.audit-log", "", "path to a json audit log file recording the dead volume server recovery actions")
 	masterOptions.metricsAddress = cmdServer.Flag.String("master.metrics.address", "", "Prometheus gateway address")
 	masterOptions.metricsIntervalSec = cmdServer.Flag.Int("master.metrics.intervalSeconds", 15, "Prometheus push interval in seconds")
 	masterOptions.raftResumeState = cmdServer.Flag.Bool("master.resumeState", false, "resume previous state on start master server")
@@ -105,6 +109,10 @@ func init() {
 	filerOptions.collection = cmdServer.Flag.String("filer.collection", "", "all data will be stored in this collection")
 	filerOptions.port = cmdServer.Flag.Int("filer.port", 8888, "filer server http listen port")
 	filerOptions.portGrpc = cmdServer.Flag.Int("filer.port.grpc", 0, "filer server grpc listen port")
+	filerOptions.portGrpcWeb = cmdServer.Flag.Int("filer.port.grpcWeb", 0, "if set, serve ListEntries and GetFilerConfiguration to the gRPC-Web browser clients on this http port")
+	filerOptions.grpcWebWhiteList = cmdServer.Flag.String("filer.port.grpcWeb.whiteList", "", "comma separated Ip addresses or CIDR ranges allowed to call the gRPC-Web methods. Either this or the jwt.filer_signing.read key of security.toml is required.")
+	filerOptions.grpcWebAllowedOrigins = cmdServer.Flag.String("filer.port.grpcWeb.allowedOrigins", "", "comma separated origins of the browser pages allowed to call the gRPC-Web methods, e.g., https://app.example.com")
+	filerOptions.portAdmin = cmdServer.Flag.Int("filer.port.admin", 0, "if set, serve the OpenAPI spec at /api/openapi.json and its docs page at /api/docs on this http port")
 	filerOptions.publicPort = cmdServer.Flag.Int("filer.port.public", 0, "filer server public http listen port")
 	filerOptions.defaultReplicaPlacement = cmdServer.Flag.String("filer.defaultReplicaPlacement", "", "default replication type. If not specified, use master setting.")
 	filerOptions.disableDirListing = cmdServer.Flag.Bool("filer.disableDirListing", false, "turn off directory listing")
@@ -117,6 +125,11 @@ func init() {
 	filerOptions.showUIDirectoryDelete = cmdServer.Flag.Bool("filer.ui.deleteDir", true, "enable filer UI show delete directory button")
 	filerOptions.downloadMaxMBps = cmdServer.Flag.Int("filer.downloadMaxMBps", 0, "download max speed for each download request, in MB per second")
 	filerOptions.diskType = cmdServer.Flag.String("filer.disk", "", "[hdd|ssd|<tag>] hard drive or solid state drive or any tag")
+	filerOptions.enableTiering = cmdServer.Flag.Bool("filer.enable-tiering", false, "move files not modified for the seaweedfs.tiering.unmodified-ttl of their directory to its seaweedfs.tiering.warm-collection")
+	filerOptions.enableIntegrityCheck = cmdServer.Flag.Bool("filer.enable-integrity-check", false, "periodically read the files and verify their md5, reporting mismatches at /admin/integrity/report")
+	filerOptions.integrityScanRateMB = cmdServer.Flag.Int("filer.integrity-scan-rate", 10, "limit the integrity check reading speed in MB/s, 0 means unlimited")
+	filerOptions.uploadStatus = cmdServer.Flag.Bool("filer.upload-status", false, "track the progress of the uploads, reported at /upload/status?uploadId=<id>")
+	filerOptions.auditLog = cmdServer.Flag.String("filer.audit-log", "", "path to a json audit log file recording all metadata mutations via gRPC and http, rotated by size and age")
 
 	serverOptions.v.port = cmdServer.Flag.Int("volume.port", 8080, "volume server http listen port")
 	serverOptions.v.portGrpc = cmdServer.Flag.Int("volume.port.grpc", 0, "volume server grpc listen port")
@@ -137,6 +150,14 @@ func init() {
 	serverOptions.v.inflightUploadDataTimeout = cmdServer.Flag.Duration("volume.inflightUploadDataTimeout", 60*time.Second, "inflight upload data wait timeout of volume servers")
 	serverOptions.v.hasSlowRead = cmdServer.Flag.Bool("volume.hasSlowRead", true, "<experimental> if true, this prevents slow reads from blocking other requests, but large file read P99 latency will increase.")
 	serverOptions.v.readBufferSizeMB = cmdServer.Flag.Int("volume.readBufferSizeMB", 4, "<experimental> larger values can optimize query performance but will increase some memory usage,Use with hasSlowRead normally")
+	serverOptions.v.collectionConfigFile = cmdServer.Flag.String("volume.collection-config-file", "", "a yaml file of the per-collection settings, e.g., the compression codec [none|gzip|zstd|lz4]")
+	serverOptions.v.shutdownTimeout = cmdServer.Flag.Duration("volume.shutdownTimeout", 60*time.Second, "on shutdown, wait for the in-flight writes up to this long, and exit with non-zero code if they are not finished")
+	serverOptions.v.maxWriteQueueDepth = cmdServer.Flag.Int("volume.max-write-queue-depth", 1000, "reject the uploads with 503 when more writes are being processed or waiting, so the clients try another replica. 0 means no limit")
+	serverOptions.v.maxCacheMemMB = cmdServer.Flag.Int("volume.max-cache-mem-mb", 0, "cache the recently read needles in memory up to this size, evicting the least recently used ones. 0 disables the cache")
+	serverOptions.v.diskIoClass = cmdServer.Flag.String("volume.disk-io-class", "none", "[none|best-effort|realtime|idle] linux disk io scheduling class, applied to the whole server process. The volume compaction always runs in the idle class")
+	serverOptions.v.diskIoPriority = cmdServer.Flag.Int("volume.disk-io-priority", 4, "disk io priority in the best-effort or realtime class, 0 for the highest and 7 for the lowest")
+	serverOptions.v.compactionSegmentMB = cmdServer.Flag.Int("volume.compactionSegmentMB", 0, "compact the volumes in place one segment of this size at a time, instead of copying the whole volume, 0 disables it. Needs a file system collapsing file ranges, e.g., ext4 or xfs on Linux")
+	serverOptions.v.readOnly = cmdServer.Flag.Bool("volume.read-only", false, "serve the existing volumes only, rejecting the writes and deletes, and report no free space so the master assigns no new volumes here")
 
 	s3Options.port = cmdServer.Flag.Int("s3.port", 8333, "s3 server http listen port")
 	s3Options.portGrpc = cmdServer.Flag.Int("s3.port.grpc", 0, "s3 server grpc listen port")
@@ -147,6 +168,7 @@ func init() {
 	s3Options.auditLogConfig = cmdServer.Flag.String("s3.auditLogConfig", "", "path to the audit log config file")
 	s3Options.allowEmptyFolder = cmdServer.Flag.Bool("s3.allowEmptyFolder", true, "allow empty folders")
 	s3Options.allowDeleteBucketNotEmpty = cmdServer.Flag.Bool("s3.allowDeleteBucketNotEmpty", true, "allow recursive deleting all entries along with bucket")
+	s3Options.directUpload = cmdServer.Flag.Bool("s3.directUpload", false, "upload the objects larger than the filer chunk size directly to the volume servers, instead of through the filer")
 
 	iamOptions.port = cmdServer.Flag.Int("iam.port", 8111, "iam server http listen port")
 
@@ -159,6 +181,7 @@ func init() {
 	webdavOptions.cacheDir = cmdServer.Flag.String("webdav.cacheDir", os.TempDir(), "local cache directory for file chunks")
 	webdavOptions.cacheSizeMB = cmdServer.Flag.Int64("webdav.cacheCapacityMB", 0, "local cache capacity in MB")
 	webdavOptions.filerRootPath = cmdServer.Flag.String("webdav.filer.path", "/", "use this remote path from filer server")
+	webdavOptions.lockRedis = cmdServer.Flag.String("webdav.lock.redis", "", "redis host:port to share webdav locks among several webdav servers")
 
 	mqBrokerOptions.port = cmdServer.Flag.Int("mq.broker.port", 17777, "message queue broker gRPC listen port")
 
@@ -172,6 +195,8 @@ func runServer(cmd *Command, args []string) bool {
 
 	util.LoadConfiguration("security", false)
 	util.LoadConfiguration("master", false)
+	util.LoadConfiguration("weed", false)
+	pb.LoadTracing(util.GetViper(), "seaweedfs-server")
 
 	grace.SetupProfiling(*serverOptions.cpuprofile, *serverOptions.memprofile)
 
@@ -69,6 +69,14 @@ type VolumeServerOptions struct {
 	hasSlowRead               *bool
 	readBufferSizeMB          *int
 	ldbTimeout                *int64
+	collectionConfigFile      *string
+	shutdownTimeout           *time.Duration
+	maxCacheMemMB             *int
+	maxWriteQueueDepth        *int
+	diskIoClass               *string
+	diskIoPriority            *int
+	readOnly                  *bool
+	compactionSegmentMB       *int
 }
 
 func init() {
@@ -102,6 +110,14 @@ func init() {
 	v.inflightUploadDataTimeout = cmdVolume.Flag.Duration("inflightUploadDataTimeout", 60*time.Second, "inflight upload data wait timeout of volume servers")
 	v.hasSlowRead = cmdVolume.Flag.Bool("hasSlowRead", true, "<experimental> if true, this prevents slow reads from blocking other requests, but large file read P99 latency will increase.")
 	v.readBufferSizeMB = cmdVolume.Flag.Int("readBufferSizeMB", 4, "<experimental> larger values can optimize query performance but will increase some memory usage,Use with hasSlowRead normally.")
+	v.collectionConfigFile = cmdVolume.Flag.String("collection-config-file", "", "a yaml file of the per-collection settings, e.g., the compression codec [none|gzip|zstd|lz4]")
+	v.shutdownTimeout = cmdVolume.Flag.Duration("shutdownTimeout", 60*time.Second, "on shutdown, wait for the in-flight writes up to this long, and exit with non-zero code if they are not finished")
+	v.maxWriteQueueDepth = cmdVolume.Flag.Int("max-write-queue-depth", 1000, "reject the uploads with 503 when more writes are being processed or waiting, so the clients try another replica. 0 means no limit")
+	v.maxCacheMemMB = cmdVolume.Flag.Int("max-cache-mem-mb", 0, "cache the recently read needles in memory up to this size, evicting the least recently used ones. 0 disables the cache")
+	v.diskIoClass = cmdVolume.Flag.String("disk-io-class", "none", "[none|best-effort|realtime|idle] linux disk io scheduling class of the volume server. The compaction always runs in the idle class")
+	v.diskIoPriority = cmdVolume.Flag.Int("disk-io-priority", 4, "disk io priority in the best-effort or realtime class, 0 for the highest and 7 for the lowest")
+	v.compactionSegmentMB = cmdVolume.Flag.Int("compactionSegmentMB", 0, "compact the volumes in place one segment of this size at a time, instead of copying the whole volume, 0 disables it. Needs a file system collapsing file ranges, e.g., ext4 or xfs on Linux")
+	v.readOnly = cmdVolume.Flag.Bool("read-only", false, "serve the existing volumes only, rejecting the writes and deletes, and report no free space so the master assigns no new volumes here")
 }
 
 var cmdVolume = &Command{
@@ -123,6 +139,8 @@ var (
 func runVolume(cmd *Command, args []string) bool {
 
 	util.LoadConfiguration("security", false)
+	util.LoadConfiguration("weed", false)
+	pb.LoadTracing(util.GetViper(), "seaweedfs-volume")
 
 	// If --pprof is set we assume the caller wants to be able to collect
 	// cpu and memory profiles via go tool pprof
@@ -191,6 +209,15 @@ func (v VolumeServerOptions) startVolumeServer(volumeFolders, maxVolumeCounts, v
 		glog.Fatalf("%d directories by -dir, but only %d disk types is set by -disk", len(v.folders), len(diskTypes))
 	}
 
+	// set disk io priority
+	diskIoClass, err := storage.ParseIoClass(*v.diskIoClass)
+	if err != nil {
+		glog.Fatalf("-disk-io-class: %v", err)
+	}
+	if err = storage.SetDiskIoPriority(diskIoClass, *v.diskIoPriority); err != nil {
+		glog.Fatalf("set disk io priority: %v", err)
+	}
+
 	// security related white list configuration
 	v.whiteList = util.StringSplit(volumeWhiteListOption, ",")
 
@@ -236,6 +263,14 @@ func (v VolumeServerOptions) startVolumeServer(volumeFolders, maxVolumeCounts, v
 		volumeNeedleMapKind = storage.NeedleMapLevelDbLarge
 	}
 
+	var collectionConfigs map[string]*storage.CollectionConfig
+	if *v.collectionConfigFile != "" {
+		var err error
+		if collectionConfigs, err = storage.LoadCollectionConfigs(*v.collectionConfigFile); err != nil {
+			glog.Fatalf("%v", err)
+		}
+	}
+
 	volumeServer := weed_server.NewVolumeServer(volumeMux, publicVolumeMux,
 		*v.ip, *v.port, *v.portGrpc, *v.publicUrl,
 		v.folders, v.folderMaxLimits, minFreeSpaces, diskTypes,
@@ -252,6 +287,11 @@ func (v VolumeServerOptions) startVolumeServer(volumeFolders, maxVolumeCounts, v
 		*v.hasSlowRead,
 		*v.readBufferSizeMB,
 		*v.ldbTimeout,
+		collectionConfigs,
+		*v.maxCacheMemMB,
+		*v.maxWriteQueueDepth,
+		*v.readOnly,
+		*v.compactionSegmentMB,
 	)
 	// starting grpc server
 	grpcS := v.startGrpcService(volumeServer)
@@ -281,7 +321,19 @@ func (v VolumeServerOptions) startVolumeServer(volumeFolders, maxVolumeCounts, v
 			time.Sleep(time.Duration(*v.preStopSeconds) * time.Second)
 		}
 
+		shutdownErr := volumeServer.GracefulShutdown(*v.shutdownTimeout)
+		if shutdownErr != nil {
+			glog.Errorf("volume server graceful shutdown: %v", shutdownErr)
+			// do not wait for the abandoned gRPC writes in the graceful stop
+			grpcS.Stop()
+		}
+
 		shutdown(publicHttpDown, clusterHttpServer, grpcS, volumeServer)
+		if shutdownErr != nil {
+			// the volumes are closed, the abandoned requests are failed by exiting
+			glog.Flush()
+			os.Exit(1)
+		}
 		stopChan <- true
 	})
 
@@ -320,13 +372,14 @@ func (v VolumeServerOptions) isSeparatedPublicPort() bool {
 	return *v.publicPort != *v.port
 }
 
-func (v VolumeServerOptions) startGrpcService(vs volume_server_pb.VolumeServerServer) *grpc.Server {
+func (v VolumeServerOptions) startGrpcService(vs *weed_server.VolumeServer) *grpc.Server {
 	grpcPort := *v.portGrpc
 	grpcL, err := util.NewListener(util.JoinHostPort(*v.bindIp, grpcPort), 0)
 	if err != nil {
 		glog.Fatalf("failed to listen on grpc port %d: %v", grpcPort, err)
 	}
-	grpcS := pb.NewGrpcServer(security.LoadServerTLS(util.GetViper(), "grpc.volume"))
+	tlsOption, tlsStreamOption := security.LoadServerTLS(util.GetViper(), "grpc.volume")
+	grpcS := pb.NewGrpcServer(append(vs.GrpcShutdownOptions(), tlsOption, tlsStreamOption)...)
 	volume_server_pb.RegisterVolumeServerServer(grpcS, vs)
 	reflection.Register(grpcS)
 	go func() {
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	cmdVolumeIndexRebuild.Run = runVolumeIndexRebuild // break init cycle
+}
+
+var cmdVolumeIndexRebuild = &Command{
+	UsageLine: "volume.index.rebuild [-collection=bigData] [-dir.idx=/idx] /tmp 234",
+	Short:     "rebuild the .idx file of a volume from its .dat file, skipping corrupted needles",
+	Long: `Rebuild scans the .dat file of a volume, and writes the .idx file with the needles found.
+
+  Unlike "weed fix", the scan does not stop at a corrupted needle. A needle failing the checksum is left out of
+  the index and reported. After an unreadable needle header, the following aligned offsets are searched for
+  the next needle with a valid checksum, and the skipped range is reported.
+
+  The existing .idx file is kept as .idx.bak. The volume server should not be serving the volume.
+  `,
+}
+
+var (
+	volumeIndexRebuildCollection = cmdVolumeIndexRebuild.Flag.String("collection", "", "the volume collection name")
+	volumeIndexRebuildIdxDir     = cmdVolumeIndexRebuild.Flag.String("dir.idx", "", "the directory of the .idx file, if different from the volume directory")
+)
+
+func runVolumeIndexRebuild(cmd *Command, args []string) bool {
+	if len(args) != 2 {
+		return false
+	}
+	dir := util.ResolvePath(args[0])
+	volumeId, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		fmt.Printf("parse volume id %s: %v\n", args[1], err)
+		return true
+	}
+	idxDir := dir
+	if *volumeIndexRebuildIdxDir != "" {
+		idxDir = util.ResolvePath(*volumeIndexRebuildIdxDir)
+	}
+	datFileName := storage.VolumeFileName(dir, *volumeIndexRebuildCollection, int(volumeId)) + ".dat"
+	idxFileName := storage.VolumeFileName(idxDir, *volumeIndexRebuildCollection, int(volumeId)) + ".idx"
+
+	lastPercent := -1
+	report, err := storage.RebuildIndex(datFileName, idxFileName+".rebuild", func(scanned, total int64) {
+		if total <= 0 {
+			return
+		}
+		if percent := int(scanned * 100 / total); percent != lastPercent {
+			lastPercent = percent
+			fmt.Printf("\rscanned %d%% of %s", percent, datFileName)
+		}
+	})
+	fmt.Println()
+	if err != nil {
+		fmt.Printf("rebuild %s: %v\n", idxFileName, err)
+		return true
+	}
+
+	if util.FileExists(idxFileName) {
+		if err = os.Rename(idxFileName, idxFileName+".bak"); err != nil {
+			fmt.Printf("keep %s: %v\n", idxFileName, err)
+			return true
+		}
+		fmt.Printf("kept the old index as %s.bak\n", idxFileName)
+	}
+	if err = os.Rename(idxFileName+".rebuild", idxFileName); err != nil {
+		fmt.Printf("save %s: %v\n", idxFileName, err)
+		return true
+	}
+
+	fmt.Printf("rebuilt %s: %d needles, %d deletions\n", idxFileName, report.Needles, report.Deleted)
+	if len(report.CorruptNeedles) > 0 {
+		fmt.Printf("%d corrupted needles, left out of the index:\n", len(report.CorruptNeedles))
+		for _, n := range report.CorruptNeedles {
+			fmt.Printf("  needle %s at offset %d size %d: %s\n", n.Id, n.Offset, n.Size, n.Error)
+		}
+	}
+	if len(report.SkippedRanges) > 0 {
+		fmt.Printf("%d unreadable ranges skipped:\n", len(report.SkippedRanges))
+		for _, r := range report.SkippedRanges {
+			fmt.Printf("  offset %d length %d\n", r.Offset, r.Length)
+		}
+	}
+	return true
+}
@@ -32,6 +32,7 @@ type WebDavOption struct {
 	tlsCertificate *string
 	cacheDir       *string
 	cacheSizeMB    *int64
+	lockRedis      *string
 }
 
 func init() {
@@ -46,6 +47,7 @@ func init() {
 	webDavStandaloneOptions.cacheDir = cmdWebDav.Flag.String("cacheDir", os.TempDir(), "local cache directory for file chunks")
 	webDavStandaloneOptions.cacheSizeMB = cmdWebDav.Flag.Int64("cacheCapacityMB", 0, "local cache capacity in MB")
 	webDavStandaloneOptions.filerRootPath = cmdWebDav.Flag.String("filer.path", "/", "use this remote path from filer server")
+	webDavStandaloneOptions.lockRedis = cmdWebDav.Flag.String("lock.redis", "", "redis host:port to share webdav locks among several webdav servers, password in env WEED_WEBDAV_LOCK_REDIS_PASSWORD")
 }
 
 var cmdWebDav = &Command{
@@ -104,24 +106,29 @@ func (wo *WebDavOption) startWebDav() bool {
 		}
 	}
 
+	hostname, _ := os.Hostname()
+
 	ws, webdavServer_err := weed_server.NewWebDavServer(&weed_server.WebDavOption{
-		Filer:          filerAddress,
-		FilerRootPath:  *wo.filerRootPath,
-		GrpcDialOption: grpcDialOption,
-		Collection:     *wo.collection,
-		Replication:    *wo.replication,
-		DiskType:       *wo.disk,
-		Uid:            uid,
-		Gid:            gid,
-		Cipher:         cipher,
-		CacheDir:       util.ResolvePath(*wo.cacheDir),
-		CacheSizeMB:    *wo.cacheSizeMB,
+		Filer:             filerAddress,
+		FilerRootPath:     *wo.filerRootPath,
+		GrpcDialOption:    grpcDialOption,
+		Collection:        *wo.collection,
+		Replication:       *wo.replication,
+		DiskType:          *wo.disk,
+		Uid:               uid,
+		Gid:               gid,
+		Cipher:            cipher,
+		CacheDir:          util.ResolvePath(*wo.cacheDir),
+		CacheSizeMB:       *wo.cacheSizeMB,
+		LockRedisAddress:  *wo.lockRedis,
+		LockRedisPassword: os.Getenv("WEED_WEBDAV_LOCK_REDIS_PASSWORD"),
+		LockServerId:      fmt.Sprintf("%s:%d", hostname, *wo.port),
 	})
 	if webdavServer_err != nil {
 		glog.Fatalf("WebDav Server startup error: %v", webdavServer_err)
 	}
 
-	httpS := &http.Server{Handler: ws.Handler}
+	httpS := &http.Server{Handler: ws}
 
 	listenAddress := fmt.Sprintf(":%d", *wo.port)
 	webDavListener, err := util.NewListener(listenAddress, time.Duration(10)*time.Second)
@@ -27,6 +27,7 @@ type SqlGenerator interface {
 type AbstractSqlStore struct {
 	SqlGenerator
 	DB                 *sql.DB
+	ReadDB             func() *sql.DB // optional, picks a read replica for the client lookups and listings
 	SupportBucketTable bool
 	dbs                map[string]bool
 	dbsLock            sync.Mutex
@@ -145,6 +146,21 @@ func (store *AbstractSqlStore) getTxOrDB(ctx context.Context, fullpath util.Full
 	return
 }
 
+// getTxOrReadDB is getTxOrDB for the reads, which go to a read replica only for the client lookups and listings outside of transactions
+func (store *AbstractSqlStore) getTxOrReadDB(ctx context.Context, fullpath util.FullPath, isForChildren bool) (txOrDB TxOrDB, bucket string, shortPath util.FullPath, err error) {
+	txOrDB, bucket, shortPath, err = store.getTxOrDB(ctx, fullpath, isForChildren)
+	if err != nil || store.ReadDB == nil || !filer.IsReadReplicaAllowed(ctx) {
+		return
+	}
+	if _, isTx := ctx.Value("tx").(*sql.Tx); isTx {
+		return
+	}
+	if readDB := store.ReadDB(); readDB != nil {
+		txOrDB = readDB
+	}
+	return
+}
+
 func (store *AbstractSqlStore) InsertEntry(ctx context.Context, entry *filer.Entry) (err error) {
 
 	db, bucket, shortPath, err := store.getTxOrDB(ctx, entry.FullPath, false)
@@ -215,7 +231,7 @@ func (store *AbstractSqlStore) UpdateEntry(ctx context.Context, entry *filer.Ent
 
 func (store *AbstractSqlStore) FindEntry(ctx context.Context, fullpath util.FullPath) (*filer.Entry, error) {
 
-	db, bucket, shortPath, err := store.getTxOrDB(ctx, fullpath, false)
+	db, bucket, shortPath, err := store.getTxOrReadDB(ctx, fullpath, false)
 	if err != nil {
 		return nil, fmt.Errorf("findDB %s : %v", fullpath, err)
 	}
@@ -296,7 +312,7 @@ func (store *AbstractSqlStore) DeleteFolderChildren(ctx context.Context, fullpat
 
 func (store *AbstractSqlStore) ListDirectoryPrefixedEntries(ctx context.Context, dirPath util.FullPath, startFileName string, includeStartFile bool, limit int64, prefix string, eachEntryFunc filer.ListEachEntryFunc) (lastFileName string, err error) {
 
-	db, bucket, shortPath, err := store.getTxOrDB(ctx, dirPath, true)
+	db, bucket, shortPath, err := store.getTxOrReadDB(ctx, dirPath, true)
 	if err != nil {
 		return lastFileName, fmt.Errorf("findDB %s : %v", dirPath, err)
 	}
@@ -339,7 +355,7 @@ func (store *AbstractSqlStore) ListDirectoryPrefixedEntries(ctx context.Context,
 }
 
 func (store *AbstractSqlStore) ListDirectoryEntries(ctx context.Context, dirPath util.FullPath, startFileName string, includeStartFile bool, limit int64, eachEntryFunc filer.ListEachEntryFunc) (lastFileName string, err error) {
-	return store.ListDirectoryPrefixedEntries(ctx, dirPath, startFileName, includeStartFile, limit, "", nil)
+	return store.ListDirectoryPrefixedEntries(ctx, dirPath, startFileName, includeStartFile, limit, "", eachEntryFunc)
 }
 
 func (store *AbstractSqlStore) Shutdown() {
@@ -0,0 +1,331 @@
+package filer
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	recentEventsLimit       = 100
+	dashboardTopDirsLimit   = 10
+	dashboardListLimit      = 1024
+	dashboardRefreshSeconds = 5
+
+	grpcRequestSecondsMetric = "SeaweedFS_grpc_server_request_seconds"
+	grpcConnectionsMetric    = "SeaweedFS_grpc_server_connections"
+)
+
+// RecentEvents keeps the last recentEventsLimit metadata mutations for the admin dashboard.
+type RecentEvents struct {
+	sync.Mutex
+	events []*RecentEvent
+	next   int
+}
+
+type RecentEvent struct {
+	Time    time.Time
+	Type    string
+	Path    string
+	NewPath string
+}
+
+func NewRecentEvents() *RecentEvents {
+	return &RecentEvents{
+		events: make([]*RecentEvent, 0, recentEventsLimit),
+	}
+}
+
+func (r *RecentEvents) Add(oldEntry, newEntry *Entry) {
+	event := &RecentEvent{Time: time.Now()}
+	switch {
+	case oldEntry == nil:
+		event.Type, event.Path = "create", string(newEntry.FullPath)
+	case newEntry == nil:
+		event.Type, event.Path = "delete", string(oldEntry.FullPath)
+	case oldEntry.FullPath != newEntry.FullPath:
+		event.Type, event.Path, event.NewPath = "rename", string(oldEntry.FullPath), string(newEntry.FullPath)
+	default:
+		event.Type, event.Path = "update", string(newEntry.FullPath)
+	}
+	r.Lock()
+	defer r.Unlock()
+	if len(r.events) < recentEventsLimit {
+		r.events = append(r.events, event)
+		return
+	}
+	r.events[r.next] = event
+	r.next = (r.next + 1) % recentEventsLimit
+}
+
+// List returns the events, the latest first
+func (r *RecentEvents) List() []*RecentEvent {
+	r.Lock()
+	defer r.Unlock()
+	events := make([]*RecentEvent, 0, len(r.events))
+	for i := len(r.events) - 1; i >= 0; i-- {
+		events = append(events, r.events[(r.next+i)%len(r.events)])
+	}
+	return events
+}
+
+// AdminDashboard serves an html page at /admin/ui, refreshed by server-sent events from /admin/ui/events.
+type AdminDashboard struct {
+	filer   *Filer
+	clients func() map[string]int
+}
+
+type dashboardData struct {
+	Time            time.Time
+	GrpcConnections int
+	Clients         []dashboardClient
+	TopDirs         []dashboardDir
+	Latencies       []dashboardLatency
+	Events          []*RecentEvent
+}
+
+type dashboardClient struct {
+	Name          string
+	Subscriptions int
+}
+
+type dashboardDir struct {
+	Path      string
+	Bytes     uint64
+	Inodes    uint64
+	UpdatedAt time.Time
+}
+
+type dashboardLatency struct {
+	Method string
+	Count  uint64
+	P50    time.Duration
+	P99    time.Duration
+}
+
+// NewAdminDashboard takes the subscribed metadata clients, by client name, from the filer server.
+func NewAdminDashboard(f *Filer, clients func() map[string]int) *AdminDashboard {
+	return &AdminDashboard{
+		filer:   f,
+		clients: clients,
+	}
+}
+
+func (d *AdminDashboard) PageHandler(w http.ResponseWriter, r *http.Request) {
+	data := d.collect(r.Context())
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardPageTemplate.Execute(w, data); err != nil {
+		glog.V(1).Infof("render admin dashboard: %v", err)
+	}
+}
+
+// EventsHandler sends the rendered dashboard body every dashboardRefreshSeconds
+func (d *AdminDashboard) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ticker := time.NewTicker(dashboardRefreshSeconds * time.Second)
+	defer ticker.Stop()
+	for {
+		var body strings.Builder
+		if err := dashboardBodyTemplate.Execute(&body, d.collect(r.Context())); err != nil {
+			glog.V(1).Infof("render admin dashboard: %v", err)
+			return
+		}
+		// each line of a multi-line event is prefixed with "data: "
+		for _, line := range strings.Split(body.String(), "\n") {
+			fmt.Fprintf(w, "data: %s\n", line)
+		}
+		fmt.Fprint(w, "\n")
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *AdminDashboard) collect(ctx context.Context) *dashboardData {
+	data := &dashboardData{
+		Time:    time.Now(),
+		TopDirs: d.topDirectories(ctx),
+		Events:  d.filer.RecentEvents.List(),
+	}
+	for name, count := range d.clients() {
+		data.Clients = append(data.Clients, dashboardClient{Name: name, Subscriptions: count})
+	}
+	sort.Slice(data.Clients, func(i, j int) bool {
+		return data.Clients[i].Name < data.Clients[j].Name
+	})
+
+	families, err := stats.Gather.Gather()
+	if err != nil {
+		glog.V(1).Infof("gather metrics: %v", err)
+	}
+	for _, family := range families {
+		switch family.GetName() {
+		case grpcConnectionsMetric:
+			for _, m := range family.GetMetric() {
+				data.GrpcConnections += int(m.GetGauge().GetValue())
+			}
+		case grpcRequestSecondsMetric:
+			data.Latencies = filerRpcLatencies(family)
+		}
+	}
+	return data
+}
+
+// topDirectories ranks the directories under the root and the buckets folder by the usage saved in their xattrs
+func (d *AdminDashboard) topDirectories(ctx context.Context) (dirs []dashboardDir) {
+	for _, parent := range []util.FullPath{"/", util.FullPath(d.filer.DirBucketsPath)} {
+		_, err := d.filer.StreamListDirectoryEntries(ctx, parent, "", false, dashboardListLimit, "", "", "", func(entry *Entry) bool {
+			if !entry.IsDirectory() {
+				return true
+			}
+			if usage, found := parseDirectoryUsage(entry.Extended); found {
+				dirs = append(dirs, dashboardDir{
+					Path:      string(entry.FullPath),
+					Bytes:     usage.Bytes,
+					Inodes:    usage.Inodes,
+					UpdatedAt: usage.UpdatedAt,
+				})
+			}
+			return true
+		})
+		if err != nil && err != filer_pb.ErrNotFound {
+			glog.V(1).Infof("list %s: %v", parent, err)
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].Bytes > dirs[j].Bytes
+	})
+	if len(dirs) > dashboardTopDirsLimit {
+		dirs = dirs[:dashboardTopDirsLimit]
+	}
+	return
+}
+
+func filerRpcLatencies(family *dto.MetricFamily) (latencies []dashboardLatency) {
+	for _, m := range family.GetMetric() {
+		method := ""
+		for _, label := range m.GetLabel() {
+			if label.GetName() == "method" {
+				method = label.GetValue()
+			}
+		}
+		if !strings.HasPrefix(method, "/filer_pb.") || m.GetHistogram().GetSampleCount() == 0 {
+			continue
+		}
+		latencies = append(latencies, dashboardLatency{
+			Method: strings.TrimPrefix(method, "/filer_pb.SeaweedFiler/"),
+			Count:  m.GetHistogram().GetSampleCount(),
+			P50:    histogramQuantile(0.5, m.GetHistogram()),
+			P99:    histogramQuantile(0.99, m.GetHistogram()),
+		})
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i].Count > latencies[j].Count
+	})
+	return
+}
+
+// histogramQuantile interpolates linearly within the bucket of the quantile, as prometheus histogram_quantile()
+func histogramQuantile(q float64, h *dto.Histogram) time.Duration {
+	total := float64(h.GetSampleCount())
+	if total == 0 {
+		return 0
+	}
+	rank := q * total
+	lowerBound, lowerCount := 0.0, 0.0
+	for _, b := range h.GetBucket() {
+		count := float64(b.GetCumulativeCount())
+		if count >= rank {
+			upperBound := b.GetUpperBound()
+			if count == lowerCount {
+				return time.Duration(upperBound * float64(time.Second))
+			}
+			v := lowerBound + (upperBound-lowerBound)*(rank-lowerCount)/(count-lowerCount)
+			return time.Duration(v * float64(time.Second))
+		}
+		lowerBound, lowerCount = b.GetUpperBound(), count
+	}
+	// in the +Inf bucket
+	if math.IsInf(lowerBound, 1) {
+		return 0
+	}
+	return time.Duration(lowerBound * float64(time.Second))
+}
+
+const dashboardBody = `<p>Updated {{.Time.Format "2006-01-02 15:04:05"}}, {{.GrpcConnections}} grpc connections</p>
+<h2>Metadata Subscribers</h2>
+<table>
+<tr><th>Client</th><th>Subscriptions</th></tr>
+{{range .Clients}}<tr><td>{{.Name}}</td><td>{{.Subscriptions}}</td></tr>
+{{else}}<tr><td colspan="2">none</td></tr>
+{{end}}</table>
+<h2>Top Directories</h2>
+<table>
+<tr><th>Directory</th><th>Size</th><th>Files and Directories</th><th>Walked At</th></tr>
+{{range .TopDirs}}<tr><td>{{.Path}}</td><td>{{bytes .Bytes}}</td><td>{{.Inodes}}</td><td>{{.UpdatedAt.Format "2006-01-02 15:04:05"}}</td></tr>
+{{else}}<tr><td colspan="4">no directory usage yet, see GET /&lt;dir&gt;?op=usage</td></tr>
+{{end}}</table>
+<h2>gRPC Latency</h2>
+<table>
+<tr><th>Method</th><th>Count</th><th>p50</th><th>p99</th></tr>
+{{range .Latencies}}<tr><td>{{.Method}}</td><td>{{.Count}}</td><td>{{.P50}}</td><td>{{.P99}}</td></tr>
+{{end}}</table>
+<h2>Recent Events</h2>
+<table>
+<tr><th>Time</th><th>Type</th><th>Path</th></tr>
+{{range .Events}}<tr><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Type}}</td><td>{{.Path}}{{if .NewPath}} &rarr; {{.NewPath}}{{end}}</td></tr>
+{{end}}</table>`
+
+var (
+	dashboardFuncs = template.FuncMap{
+		"bytes": func(b uint64) string {
+			return util.BytesToHumanReadable(b)
+		},
+	}
+	dashboardBodyTemplate = template.Must(template.New("body").Funcs(dashboardFuncs).Parse(dashboardBody))
+	dashboardPageTemplate = template.Must(template.Must(dashboardBodyTemplate.Clone()).New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>SeaweedFS Filer Admin</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>SeaweedFS Filer Admin</h1>
+<div id="dashboard">{{template "body" .}}</div>
+<script>
+new EventSource("/admin/ui/events").onmessage = function(e) {
+	document.getElementById("dashboard").innerHTML = e.data;
+};
+</script>
+</body>
+</html>`))
+)
@@ -0,0 +1,49 @@
+package filer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestRecentEvents(t *testing.T) {
+	r := NewRecentEvents()
+	for i := 0; i < recentEventsLimit+5; i++ {
+		r.Add(nil, &Entry{FullPath: util.FullPath(fmt.Sprintf("/f%d", i))})
+	}
+	events := r.List()
+	if len(events) != recentEventsLimit {
+		t.Fatalf("events %d", len(events))
+	}
+	if events[0].Path != fmt.Sprintf("/f%d", recentEventsLimit+4) || events[recentEventsLimit-1].Path != "/f5" {
+		t.Errorf("latest %s, oldest %s", events[0].Path, events[recentEventsLimit-1].Path)
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	h := &dto.Histogram{
+		SampleCount: proto.Uint64(100),
+		Bucket: []*dto.Bucket{
+			{UpperBound: proto.Float64(0.1), CumulativeCount: proto.Uint64(50)},
+			{UpperBound: proto.Float64(0.2), CumulativeCount: proto.Uint64(90)},
+			{UpperBound: proto.Float64(1), CumulativeCount: proto.Uint64(100)},
+		},
+	}
+	for _, tt := range []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.5, 100 * time.Millisecond},
+		{0.7, 150 * time.Millisecond},
+		{0.99, 920 * time.Millisecond},
+	} {
+		if got := histogramQuantile(tt.q, h); (got - tt.want).Abs() > time.Microsecond {
+			t.Errorf("quantile %v = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
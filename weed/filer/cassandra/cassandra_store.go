@@ -20,6 +20,7 @@ type CassandraStore struct {
 	cluster                 *gocql.ClusterConfig
 	session                 *gocql.Session
 	superLargeDirectoryHash map[string]string
+	batchSize               int
 }
 
 func (store *CassandraStore) GetName() string {
@@ -27,6 +28,11 @@ func (store *CassandraStore) GetName() string {
 }
 
 func (store *CassandraStore) Initialize(configuration util.Configuration, prefix string) (err error) {
+	configuration.SetDefault(prefix+"batch_size", DefaultBatchSize)
+	store.batchSize = configuration.GetInt(prefix + "batch_size")
+	if store.batchSize <= 0 {
+		store.batchSize = DefaultBatchSize
+	}
 	return store.initialize(
 		configuration.GetString(prefix+"keyspace"),
 		configuration.GetStringSlice(prefix+"hosts"),
@@ -57,6 +63,8 @@ func (store *CassandraStore) initialize(keyspace string, hosts []string, usernam
 	}
 	store.cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(fallback)
 	store.cluster.Consistency = gocql.LocalQuorum
+	store.cluster.QueryObserver = queryObserver{}
+	store.cluster.BatchObserver = queryObserver{}
 
 	store.session, err = store.cluster.CreateSession()
 	if err != nil {
@@ -78,16 +86,6 @@ func (store *CassandraStore) initialize(keyspace string, hosts []string, usernam
 	return
 }
 
-func (store *CassandraStore) BeginTransaction(ctx context.Context) (context.Context, error) {
-	return ctx, nil
-}
-func (store *CassandraStore) CommitTransaction(ctx context.Context) error {
-	return nil
-}
-func (store *CassandraStore) RollbackTransaction(ctx context.Context) error {
-	return nil
-}
-
 func (store *CassandraStore) InsertEntry(ctx context.Context, entry *filer.Entry) (err error) {
 
 	dir, name := entry.FullPath.DirAndName()
@@ -104,9 +102,13 @@ func (store *CassandraStore) InsertEntry(ctx context.Context, entry *filer.Entry
 		meta = util.MaybeGzipData(meta)
 	}
 
-	if err := store.session.Query(
-		"INSERT INTO filemeta (directory,name,meta) VALUES(?,?,?) USING TTL ? ",
-		dir, name, meta, entry.TtlSec).Exec(); err != nil {
+	const insertCql = "INSERT INTO filemeta (directory,name,meta) VALUES(?,?,?) USING TTL ? "
+	if batch := store.getBatch(ctx); batch != nil {
+		batch.add(dir, name, &batchStatement{cql: insertCql, args: []interface{}{dir, name, meta, entry.TtlSec}, meta: meta})
+		return nil
+	}
+
+	if err := store.session.Query(insertCql, dir, name, meta, entry.TtlSec).Exec(); err != nil {
 		return fmt.Errorf("insert %s: %s", entry.FullPath, err)
 	}
 
@@ -126,11 +128,21 @@ func (store *CassandraStore) FindEntry(ctx context.Context, fullpath util.FullPa
 	}
 
 	var data []byte
-	if err := store.session.Query(
-		"SELECT meta FROM filemeta WHERE directory=? AND name=?",
-		dir, name).Scan(&data); err != nil {
-		if err != gocql.ErrNotFound {
-			return nil, filer_pb.ErrNotFound
+	if batch := store.getBatch(ctx); batch != nil {
+		if meta, found := batch.find(dir, name); found {
+			if meta == nil {
+				return nil, filer_pb.ErrNotFound
+			}
+			data = meta
+		}
+	}
+	if data == nil {
+		if err := store.session.Query(
+			"SELECT meta FROM filemeta WHERE directory=? AND name=?",
+			dir, name).Scan(&data); err != nil {
+			if err != gocql.ErrNotFound {
+				return nil, filer_pb.ErrNotFound
+			}
 		}
 	}
 
@@ -156,9 +168,13 @@ func (store *CassandraStore) DeleteEntry(ctx context.Context, fullpath util.Full
 		dir, name = dirHash+name, ""
 	}
 
-	if err := store.session.Query(
-		"DELETE FROM filemeta WHERE directory=? AND name=?",
-		dir, name).Exec(); err != nil {
+	const deleteCql = "DELETE FROM filemeta WHERE directory=? AND name=?"
+	if batch := store.getBatch(ctx); batch != nil {
+		batch.add(dir, name, &batchStatement{cql: deleteCql, args: []interface{}{dir, name}})
+		return nil
+	}
+
+	if err := store.session.Query(deleteCql, dir, name).Exec(); err != nil {
 		return fmt.Errorf("delete %s : %v", fullpath, err)
 	}
 
@@ -170,6 +186,14 @@ func (store *CassandraStore) DeleteFolderChildren(ctx context.Context, fullpath
 		return nil // filer.ErrUnsupportedSuperLargeDirectoryListing
 	}
 
+	// the pending statements go first, or the inserts into the directory would share the timestamp
+	// of the partition deletion, and be deleted too
+	if batch := store.getBatch(ctx); batch != nil {
+		if err := store.flushBatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+
 	if err := store.session.Query(
 		"DELETE FROM filemeta WHERE directory=?",
 		fullpath).Exec(); err != nil {
@@ -0,0 +1,135 @@
+package cassandra
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gocql/gocql"
+
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+)
+
+const DefaultBatchSize = 100
+
+type batchKey struct{}
+
+type batchStatement struct {
+	cql  string
+	args []interface{}
+	meta []byte // the entry of an insert, nil for a delete
+}
+
+type rowKey struct {
+	directory string
+	name      string
+}
+
+// cassandraBatch collects the entry inserts and deletes of a transaction, written by CommitTransaction
+// with unlogged batches. A later statement on the same row replaces the earlier one, since the statements
+// of a batch share the same timestamp.
+type cassandraBatch struct {
+	sync.Mutex
+	store      *CassandraStore
+	rows       map[rowKey]int
+	statements []*batchStatement
+}
+
+// getBatch returns the batch of the transaction begun by this store, nil outside of transactions
+func (store *CassandraStore) getBatch(ctx context.Context) *cassandraBatch {
+	if batch, ok := ctx.Value(batchKey{}).(*cassandraBatch); ok && batch.store == store {
+		return batch
+	}
+	return nil
+}
+
+func (b *cassandraBatch) add(directory, name string, statement *batchStatement) {
+	b.Lock()
+	defer b.Unlock()
+	key := rowKey{directory, name}
+	if i, found := b.rows[key]; found {
+		b.statements[i] = statement
+		return
+	}
+	b.rows[key] = len(b.statements)
+	b.statements = append(b.statements, statement)
+}
+
+// find returns the pending entry of the row, and whether the row has a pending statement
+func (b *cassandraBatch) find(directory, name string) (meta []byte, found bool) {
+	b.Lock()
+	defer b.Unlock()
+	i, found := b.rows[rowKey{directory, name}]
+	if !found {
+		return nil, false
+	}
+	return b.statements[i].meta, true
+}
+
+func (b *cassandraBatch) take() []*batchStatement {
+	b.Lock()
+	defer b.Unlock()
+	statements := b.statements
+	b.statements = nil
+	b.rows = make(map[rowKey]int)
+	return statements
+}
+
+func (store *CassandraStore) BeginTransaction(ctx context.Context) (context.Context, error) {
+	return context.WithValue(ctx, batchKey{}, &cassandraBatch{store: store, rows: make(map[rowKey]int)}), nil
+}
+
+func (store *CassandraStore) CommitTransaction(ctx context.Context) error {
+	if batch := store.getBatch(ctx); batch != nil {
+		return store.flushBatch(ctx, batch)
+	}
+	return nil
+}
+
+func (store *CassandraStore) RollbackTransaction(ctx context.Context) error {
+	if batch := store.getBatch(ctx); batch != nil {
+		batch.take()
+	}
+	return nil
+}
+
+// flushBatch writes the pending statements, batchSize statements per unlogged batch
+func (store *CassandraStore) flushBatch(ctx context.Context, batch *cassandraBatch) error {
+	statements := batch.take()
+	for len(statements) > 0 {
+		n := len(statements)
+		if n > store.batchSize {
+			n = store.batchSize
+		}
+		b := store.session.NewBatch(gocql.UnloggedBatch).WithContext(ctx)
+		for _, statement := range statements[:n] {
+			b.Query(statement.cql, statement.args...)
+		}
+		stats.FilerCassandraBatchSizeHistogram.Observe(float64(n))
+		if err := store.session.ExecuteBatch(b); err != nil {
+			return fmt.Errorf("write batch of %d: %v", n, err)
+		}
+		statements = statements[n:]
+	}
+	return nil
+}
+
+// queryObserver measures the cql statements by their first word, and the batches as "BATCH"
+type queryObserver struct{}
+
+func (queryObserver) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	stats.FilerCassandraQueryHistogram.WithLabelValues(statementType(q.Statement)).Observe(q.End.Sub(q.Start).Seconds())
+}
+
+func (queryObserver) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	stats.FilerCassandraQueryHistogram.WithLabelValues("BATCH").Observe(b.End.Sub(b.Start).Seconds())
+}
+
+func statementType(statement string) string {
+	statement = strings.TrimSpace(statement)
+	if i := strings.IndexByte(statement, ' '); i > 0 {
+		statement = statement[:i]
+	}
+	return strings.ToUpper(statement)
+}
@@ -0,0 +1,26 @@
+package cockroachdb
+
+import (
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer/abstract_sql"
+	"github.com/seaweedfs/seaweedfs/weed/filer/postgres"
+)
+
+// SqlGenCockroachDB is the postgres sql, with the table locality for multi-region databases.
+type SqlGenCockroachDB struct {
+	postgres.SqlGenPostgres
+	Locality string
+}
+
+var (
+	_ = abstract_sql.SqlGenerator(&SqlGenCockroachDB{})
+)
+
+func (gen *SqlGenCockroachDB) GetSqlCreateTable(tableName string) string {
+	createTable := gen.SqlGenPostgres.GetSqlCreateTable(tableName)
+	if gen.Locality == "" {
+		return createTable
+	}
+	return fmt.Sprintf(`%s; ALTER TABLE "%s" SET LOCALITY %s`, createTable, tableName, gen.Locality)
+}
@@ -0,0 +1,155 @@
+package cockroachdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/filer/abstract_sql"
+	"github.com/seaweedfs/seaweedfs/weed/filer/postgres"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+var _ filer.BucketAware = (*CockroachDBStore)(nil)
+
+func init() {
+	filer.Stores = append(filer.Stores, &CockroachDBStore{})
+}
+
+// CockroachDBStore keeps the filer metadata in CockroachDB, via its postgres wire protocol.
+type CockroachDBStore struct {
+	abstract_sql.AbstractSqlStore
+}
+
+func (store *CockroachDBStore) GetName() string {
+	return "cockroachdb"
+}
+
+func (store *CockroachDBStore) Initialize(configuration util.Configuration, prefix string) (err error) {
+	return store.initialize(
+		configuration.GetString(prefix+"createTable"),
+		configuration.GetString(prefix+"upsertQuery"),
+		configuration.GetString(prefix+"username"),
+		configuration.GetString(prefix+"password"),
+		configuration.GetString(prefix+"hostname"),
+		configuration.GetInt(prefix+"port"),
+		configuration.GetString(prefix+"database"),
+		configuration.GetString(prefix+"schema"),
+		configuration.GetString(prefix+"sslmode"),
+		configuration.GetString(prefix+"sslrootcert"),
+		configuration.GetString(prefix+"primary_region"),
+		configuration.GetStringSlice(prefix+"regions"),
+		configuration.GetString(prefix+"locality"),
+		configuration.GetInt(prefix+"connection_max_idle"),
+		configuration.GetInt(prefix+"connection_max_open"),
+		configuration.GetInt(prefix+"connection_max_lifetime_seconds"),
+	)
+}
+
+func (store *CockroachDBStore) initialize(createTable, upsertQuery string, user, password, hostname string, port int, database, schema, sslmode, sslrootcert string,
+	primaryRegion string, regions []string, locality string, maxIdle, maxOpen, maxLifetimeSeconds int) (err error) {
+
+	store.SupportBucketTable = true
+	store.SqlGenerator = &SqlGenCockroachDB{
+		SqlGenPostgres: postgres.SqlGenPostgres{
+			CreateTableSqlTemplate: createTable,
+			DropTableSqlTemplate:   `drop table "%s"`,
+			UpsertQueryTemplate:    upsertQuery,
+		},
+		Locality: locality,
+	}
+
+	sqlUrl := "connect_timeout=30"
+	if hostname != "" {
+		sqlUrl += " host=" + hostname
+	}
+	if port != 0 {
+		sqlUrl += " port=" + strconv.Itoa(port)
+	}
+	if sslmode != "" {
+		sqlUrl += " sslmode=" + sslmode
+	}
+	if sslrootcert != "" {
+		sqlUrl += " sslrootcert=" + sslrootcert
+	}
+	if user != "" {
+		sqlUrl += " user=" + user
+	}
+	adaptedSqlUrl := sqlUrl
+	if password != "" {
+		sqlUrl += " password=" + password
+		adaptedSqlUrl += " password=ADAPTED"
+	}
+	if database != "" {
+		sqlUrl += " dbname=" + database
+		adaptedSqlUrl += " dbname=" + database
+	}
+	if schema != "" {
+		sqlUrl += " search_path=" + schema
+		adaptedSqlUrl += " search_path=" + schema
+	}
+	var dbErr error
+	store.DB, dbErr = sql.Open("postgres", sqlUrl)
+	if dbErr != nil {
+		store.DB.Close()
+		store.DB = nil
+		return fmt.Errorf("can not connect to %s error:%v", adaptedSqlUrl, dbErr)
+	}
+
+	store.DB.SetMaxIdleConns(maxIdle)
+	store.DB.SetMaxOpenConns(maxOpen)
+	store.DB.SetConnMaxLifetime(time.Duration(maxLifetimeSeconds) * time.Second)
+
+	if err = store.DB.Ping(); err != nil {
+		return fmt.Errorf("connect to %s error:%v", adaptedSqlUrl, err)
+	}
+
+	if err = store.setRegions(database, primaryRegion, regions); err != nil {
+		return fmt.Errorf("set regions of database %s: %v", database, err)
+	}
+
+	if err = store.CreateTable(context.Background(), abstract_sql.DEFAULT_TABLE); err != nil {
+		return fmt.Errorf("init table %s: %v", abstract_sql.DEFAULT_TABLE, err)
+	}
+
+	return nil
+}
+
+// setRegions makes the database multi-region, so the tables can be placed by their locality.
+func (store *CockroachDBStore) setRegions(database, primaryRegion string, regions []string) error {
+	if primaryRegion == "" {
+		return nil
+	}
+	if _, err := store.DB.Exec(fmt.Sprintf(`ALTER DATABASE "%s" PRIMARY REGION "%s"`, database, primaryRegion)); err != nil {
+		return err
+	}
+	for _, region := range regions {
+		region = strings.TrimSpace(region)
+		if region == "" || region == primaryRegion {
+			continue
+		}
+		if _, err := store.DB.Exec(fmt.Sprintf(`ALTER DATABASE "%s" ADD REGION IF NOT EXISTS "%s"`, database, region)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BeginTransaction uses serializable transactions, the CockroachDB default,
+// so that a rename is atomic across the directories of different ranges.
+func (store *CockroachDBStore) BeginTransaction(ctx context.Context) (context.Context, error) {
+	tx, err := store.DB.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelSerializable,
+		ReadOnly:  false,
+	})
+	if err != nil {
+		return ctx, err
+	}
+
+	return context.WithValue(ctx, "tx", tx), nil
+}
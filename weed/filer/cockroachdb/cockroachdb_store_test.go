@@ -0,0 +1,18 @@
+package cockroachdb
+
+import (
+	"github.com/seaweedfs/seaweedfs/weed/filer/store_test"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	// run "make test_cockroachdb" under docker folder.
+	// to set up local env
+	if false {
+		store := &CockroachDBStore{}
+		store.initialize(`CREATE TABLE IF NOT EXISTS "%s" (dirhash BIGINT, name VARCHAR(65535), directory VARCHAR(65535), meta BYTES, PRIMARY KEY (dirhash, name))`,
+			`UPSERT INTO "%[1]s" (dirhash,name,directory,meta) VALUES($1,$2,$3,$4)`,
+			"root", "", "localhost", 26257, "defaultdb", "", "disable", "", "", nil, "", 2, 10, 0)
+		store_test.TestFilerStore(t, store)
+	}
+}
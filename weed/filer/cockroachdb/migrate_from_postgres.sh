@@ -0,0 +1,32 @@
+#!/bin/sh
+# Copy the filer metadata from a postgres or postgres2 store to a cockroachdb store.
+# The table schema is the same, except bytea is named BYTES in cockroachdb.
+#
+# Stop the filer, run this script, then switch filer.toml to [cockroachdb] and start the filer.
+#
+#   PG_URL="postgresql://postgres@pg:5432/postgres" \
+#   CRDB_URL="postgresql://root@crdb:26257/defaultdb?sslmode=disable" \
+#   ./migrate_from_postgres.sh filemeta [bucket_table ...]
+#
+# With postgres2, list the per-bucket tables after filemeta.
+
+set -e
+
+if [ -z "$PG_URL" ] || [ -z "$CRDB_URL" ] || [ $# -eq 0 ]; then
+  echo "usage: PG_URL=... CRDB_URL=... $0 <table> [table ...]" >&2
+  exit 1
+fi
+
+for table in "$@"; do
+  echo "migrating table $table"
+  psql "$CRDB_URL" -v ON_ERROR_STOP=1 -c "CREATE TABLE IF NOT EXISTS \"$table\" (
+    dirhash   BIGINT,
+    name      VARCHAR(65535),
+    directory VARCHAR(65535),
+    meta      BYTES,
+    PRIMARY KEY (dirhash, name)
+  )"
+  psql "$PG_URL" -v ON_ERROR_STOP=1 -c "\\copy \"$table\" (dirhash,name,directory,meta) TO STDOUT" |
+    psql "$CRDB_URL" -v ON_ERROR_STOP=1 -c "\\copy \"$table\" (dirhash,name,directory,meta) FROM STDIN"
+  echo "table $table: $(psql "$PG_URL" -At -c "SELECT count(*) FROM \"$table\"") rows in postgres, $(psql "$CRDB_URL" -At -c "SELECT count(*) FROM \"$table\"") rows in cockroachdb"
+done
@@ -2,6 +2,7 @@ package filer
 
 import (
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -57,6 +58,32 @@ func (entry *Entry) Timestamp() time.Time {
 	}
 }
 
+// GetXAttrInodeCount returns the number of files and directories under the directory,
+// saved by the usage walk, and kept up to date under the directories with an inode quota.
+func (entry *Entry) GetXAttrInodeCount() (uint64, bool) {
+	count, err := strconv.ParseUint(string(entry.Extended[DirUsageInodeKey]), 10, 64)
+	return count, err == nil
+}
+
+// SetXAttrInodeCount copies the extended attributes before setting the count, since they may be shared by a clone.
+func (entry *Entry) SetXAttrInodeCount(count uint64) {
+	extended := make(map[string][]byte, len(entry.Extended)+1)
+	for k, v := range entry.Extended {
+		extended[k] = v
+	}
+	extended[DirUsageInodeKey] = []byte(strconv.FormatUint(count, 10))
+	entry.Extended = extended
+}
+
+// GetInodeQuota returns the limit in InodeQuotaXattr, or 0 if there is none.
+func (entry *Entry) GetInodeQuota() uint64 {
+	if entry == nil || !entry.IsDirectory() {
+		return 0
+	}
+	limit, _ := strconv.ParseUint(string(entry.Extended[InodeQuotaXattr]), 10, 64)
+	return limit
+}
+
 func (entry *Entry) ShallowClone() *Entry {
 	if entry == nil {
 		return nil
@@ -3,7 +3,10 @@ package etcd
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -47,10 +50,42 @@ func (store *EtcdStore) Initialize(configuration weed_util.Configuration, prefix
 		timeout = "3s"
 	}
 
-	return store.initialize(servers, username, password, timeout)
+	tlsConfig, err := loadTlsConfig(configuration.GetString(prefix+"tls_ca_file"),
+		configuration.GetString(prefix+"tls_client_cert_file"), configuration.GetString(prefix+"tls_client_key_file"))
+	if err != nil {
+		return err
+	}
+
+	return store.initialize(servers, username, password, timeout, tlsConfig)
+}
+
+// loadTlsConfig returns nil without any file, to connect without tls
+func loadTlsConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{}
+	if caFile != "" {
+		caCert, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read etcd ca %s: %v", caFile, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificate in etcd ca %s", caFile)
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load etcd client certificate %s %s: %v", certFile, keyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
 }
 
-func (store *EtcdStore) initialize(servers string, username string, password string, timeout string) (err error) {
+func (store *EtcdStore) initialize(servers string, username string, password string, timeout string, tlsConfig *tls.Config) (err error) {
 	glog.Infof("filer store etcd: %s", servers)
 
 	to, err := time.ParseDuration(timeout)
@@ -63,6 +98,7 @@ func (store *EtcdStore) initialize(servers string, username string, password str
 		Username:    username,
 		Password:    password,
 		DialTimeout: to,
+		TLS:         tlsConfig,
 	})
 	if err != nil {
 		return fmt.Errorf("connect to etcd %s: %s", servers, err)
@@ -15,13 +15,16 @@ type ChunkGroup struct {
 	readerCache  *ReaderCache
 }
 
-func NewChunkGroup(lookupFn wdclient.LookupFileIdFunctionType, chunkCache chunk_cache.ChunkCache, chunks []*filer_pb.FileChunk) (*ChunkGroup, error) {
+// NewChunkGroup creates the chunk group of a file, the readRetry and readAhead are optional
+func NewChunkGroup(lookupFn wdclient.LookupFileIdFunctionType, chunkCache chunk_cache.ChunkCache, chunks []*filer_pb.FileChunk, readRetry *ReadRetryBackoff, readAhead *ReadAheadLimit) (*ChunkGroup, error) {
 	group := &ChunkGroup{
 		lookupFn:    lookupFn,
 		chunkCache:  chunkCache,
 		sections:    make(map[SectionIndex]*FileChunkSection),
 		readerCache: NewReaderCache(32, chunkCache, lookupFn),
 	}
+	group.readerCache.readRetry = readRetry
+	group.readerCache.readAhead = readAhead
 
 	err := group.SetChunks(chunks)
 	return group, err
@@ -107,6 +110,11 @@ func (group *ChunkGroup) SetChunks(chunks []*filer_pb.FileChunk) error {
 	return nil
 }
 
+// Destroy frees the prefetched chunks, after the file is closed
+func (group *ChunkGroup) Destroy() {
+	group.readerCache.destroy()
+}
+
 const (
 	// see weedfs_file_lseek.go
 	SEEK_DATA uint32 = 3 // seek to next data after the offset
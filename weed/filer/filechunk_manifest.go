@@ -116,40 +116,26 @@ func fetchWholeChunk(bytesBuffer *bytes.Buffer, lookupFileIdFn wdclient.LookupFi
 	return nil
 }
 
-func fetchChunkRange(buffer []byte, lookupFileIdFn wdclient.LookupFileIdFunctionType, fileId string, cipherKey []byte, isGzipped bool, offset int64) (int, error) {
+func fetchChunkRange(buffer []byte, lookupFileIdFn wdclient.LookupFileIdFunctionType, fileId string, cipherKey []byte, isGzipped bool, offset int64, readRetry *ReadRetryBackoff) (int, error) {
 	urlStrings, err := lookupFileIdFn(fileId)
 	if err != nil {
 		glog.Errorf("operation LookupFileId %s failed, err: %v", fileId, err)
 		return 0, err
 	}
-	return retriedFetchChunkData(buffer, urlStrings, cipherKey, isGzipped, false, offset)
+	return retriedFetchChunkData(buffer, urlStrings, cipherKey, isGzipped, false, offset, readRetry)
 }
 
-func retriedFetchChunkData(buffer []byte, urlStrings []string, cipherKey []byte, isGzipped bool, isFullChunk bool, offset int64) (n int, err error) {
+// retriedFetchChunkData backs off by the readRetry if not nil, or by the fixed growing wait time otherwise
+func retriedFetchChunkData(buffer []byte, urlStrings []string, cipherKey []byte, isGzipped bool, isFullChunk bool, offset int64, readRetry *ReadRetryBackoff) (n int, err error) {
+
+	if readRetry != nil {
+		return readRetry.fetchChunkData(buffer, urlStrings, cipherKey, isGzipped, isFullChunk, offset)
+	}
 
 	var shouldRetry bool
 
 	for waitTime := time.Second; waitTime < util.RetryWaitTime; waitTime += waitTime / 2 {
-		for _, urlString := range urlStrings {
-			n = 0
-			if strings.Contains(urlString, "%") {
-				urlString = url.PathEscape(urlString)
-			}
-			shouldRetry, err = util.ReadUrlAsStream(urlString+"?readDeleted=true", cipherKey, isGzipped, isFullChunk, offset, len(buffer), func(data []byte) {
-				if n < len(buffer) {
-					x := copy(buffer[n:], data)
-					n += x
-				}
-			})
-			if !shouldRetry {
-				break
-			}
-			if err != nil {
-				glog.V(0).Infof("read %s failed, err: %v", urlString, err)
-			} else {
-				break
-			}
-		}
+		n, shouldRetry, err = fetchChunkDataFromUrls(buffer, urlStrings, cipherKey, isGzipped, isFullChunk, offset)
 		if err != nil && shouldRetry {
 			glog.V(0).Infof("retry reading in %v", waitTime)
 			time.Sleep(waitTime)
@@ -162,6 +148,31 @@ func retriedFetchChunkData(buffer []byte, urlStrings []string, cipherKey []byte,
 
 }
 
+// fetchChunkDataFromUrls tries the urls in order, until one succeeds or the error should not be retried
+func fetchChunkDataFromUrls(buffer []byte, urlStrings []string, cipherKey []byte, isGzipped bool, isFullChunk bool, offset int64) (n int, shouldRetry bool, err error) {
+	for _, urlString := range urlStrings {
+		n = 0
+		if strings.Contains(urlString, "%") {
+			urlString = url.PathEscape(urlString)
+		}
+		shouldRetry, err = util.ReadUrlAsStream(urlString+"?readDeleted=true", cipherKey, isGzipped, isFullChunk, offset, len(buffer), func(data []byte) {
+			if n < len(buffer) {
+				x := copy(buffer[n:], data)
+				n += x
+			}
+		})
+		if !shouldRetry {
+			break
+		}
+		if err != nil {
+			glog.V(0).Infof("read %s failed, err: %v", urlString, err)
+		} else {
+			break
+		}
+	}
+	return
+}
+
 func retriedStreamFetchChunkData(writer io.Writer, urlStrings []string, cipherKey []byte, isGzipped bool, isFullChunk bool, offset int64, size int) (err error) {
 
 	var shouldRetry bool
@@ -47,7 +47,14 @@ type Filer struct {
 	MetaAggregator      *MetaAggregator
 	Signature           int32
 	FilerConf           *FilerConf
+	filerConfReload     filerConfReloadTracker
 	RemoteStorage       *FilerRemoteStorage
+	MaxHardLinks        int32
+	DedupIndex          DeduplicationIndex
+	TagIndex            *KvTagIndex
+	InodeQuota          *InodeQuota
+	TrashRoots          *TrashRoots
+	RecentEvents        *RecentEvents
 }
 
 func NewFiler(masters map[string]pb.ServerAddress, grpcDialOption grpc.DialOption, filerHost pb.ServerAddress,
@@ -59,7 +66,13 @@ func NewFiler(masters map[string]pb.ServerAddress, grpcDialOption grpc.DialOptio
 		FilerConf:           NewFilerConf(),
 		RemoteStorage:       NewFilerRemoteStorage(),
 		UniqueFilerId:       util.RandomInt32(),
+		MaxHardLinks:        DefaultMaxHardLinks,
+		RecentEvents:        NewRecentEvents(),
 	}
+	f.DedupIndex = NewKvDeduplicationIndex(f)
+	f.TagIndex = NewKvTagIndex(f)
+	f.InodeQuota = NewInodeQuota(f, filerHost)
+	f.TrashRoots = NewTrashRoots(f)
 	if f.UniqueFilerId < 0 {
 		f.UniqueFilerId = -f.UniqueFilerId
 	}
@@ -178,8 +191,13 @@ func (f *Filer) CreateEntry(ctx context.Context, entry *Entry, o_excl bool, isFr
 		return nil
 	}
 
+	ctx = WithFromOtherCluster(ctx, isFromOtherCluster)
 	oldEntry, _ := f.FindEntry(ctx, entry.FullPath)
 
+	if err := f.checkHardLinkLimit(oldEntry, entry); err != nil {
+		return err
+	}
+
 	/*
 		if !hasWritePermission(lastDirectoryEntry, entry) {
 			glog.V(0).Infof("directory %s: %v, entry: uid=%d gid=%d",
@@ -197,8 +215,18 @@ func (f *Filer) CreateEntry(ctx context.Context, entry *Entry, o_excl bool, isFr
 			}
 		}
 
+		if err := beforeCreateEntry(ctx, entry); err != nil {
+			return err
+		}
+
+		referenced, err := f.referenceDedupChunks(ctx, nil, entry)
+		if err != nil {
+			return err
+		}
+
 		glog.V(4).Infof("InsertEntry %s: new entry: %v", entry.FullPath, entry.Name())
 		if err := f.Store.InsertEntry(ctx, entry); err != nil {
+			f.unreferenceDedupChunks(ctx, referenced)
 			glog.Errorf("insert entry %s: %v", entry.FullPath, err)
 			return fmt.Errorf("insert entry %s: %v", entry.FullPath, err)
 		}
@@ -216,7 +244,13 @@ func (f *Filer) CreateEntry(ctx context.Context, entry *Entry, o_excl bool, isFr
 
 	f.NotifyUpdateEvent(ctx, oldEntry, entry, true, isFromOtherCluster, signatures)
 
-	f.deleteChunksIfNotNew(oldEntry, entry)
+	AfterCommit(ctx, func() {
+		f.deleteChunksIfNotNew(oldEntry, entry)
+	})
+
+	if oldEntry == nil {
+		afterCreateEntry(ctx, entry)
+	}
 
 	glog.V(4).Infof("CreateEntry %s: created", entry.FullPath)
 
@@ -271,6 +305,7 @@ func (f *Filer) ensureParentDirectoryEntry(ctx context.Context, entry *Entry, di
 			if !strings.HasPrefix("/"+util.Join(dirParts[:]...), SystemLogDir) {
 				f.NotifyUpdateEvent(ctx, nil, dirEntry, false, isFromOtherCluster, nil)
 			}
+			afterCreateEntry(ctx, dirEntry)
 		}
 
 	} else if !dirEntry.IsDirectory() {
@@ -282,6 +317,9 @@ func (f *Filer) ensureParentDirectoryEntry(ctx context.Context, entry *Entry, di
 }
 
 func (f *Filer) UpdateEntry(ctx context.Context, oldEntry, entry *Entry) (err error) {
+	if err = f.checkHardLinkLimit(oldEntry, entry); err != nil {
+		return err
+	}
 	if oldEntry != nil {
 		entry.Attr.Crtime = oldEntry.Attr.Crtime
 		if oldEntry.IsDirectory() && !entry.IsDirectory() {
@@ -293,7 +331,19 @@ func (f *Filer) UpdateEntry(ctx context.Context, oldEntry, entry *Entry) (err er
 			return fmt.Errorf("existing %s is a file", oldEntry.FullPath)
 		}
 	}
-	return f.Store.UpdateEntry(ctx, entry)
+	if err = beforeUpdateEntry(ctx, oldEntry, entry); err != nil {
+		return err
+	}
+	referenced, err := f.referenceDedupChunks(ctx, oldEntry, entry)
+	if err != nil {
+		return err
+	}
+	if err = f.Store.UpdateEntry(ctx, entry); err != nil {
+		f.unreferenceDedupChunks(ctx, referenced)
+		return err
+	}
+	afterUpdateEntry(ctx, oldEntry, entry)
+	return nil
 }
 
 var (
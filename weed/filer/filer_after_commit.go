@@ -0,0 +1,59 @@
+package filer
+
+import (
+	"context"
+	"sync"
+)
+
+type afterCommitKey struct{}
+
+// afterCommitActions are the side effects of the filer operations in a store transaction,
+// i.e., the chunk deletions and the metadata events, which must not happen if the transaction is rolled back.
+type afterCommitActions struct {
+	sync.Mutex
+	actions []func()
+	done    bool
+}
+
+// WithAfterCommit returns a context that queues the chunk deletions and the metadata events
+// of the filer operations done with it, until RunAfterCommit or DiscardAfterCommit.
+func WithAfterCommit(ctx context.Context) context.Context {
+	return context.WithValue(ctx, afterCommitKey{}, &afterCommitActions{})
+}
+
+// AfterCommit runs fn after the transaction of ctx is committed, or right away without a transaction.
+func AfterCommit(ctx context.Context, fn func()) {
+	if a, ok := ctx.Value(afterCommitKey{}).(*afterCommitActions); ok {
+		a.Lock()
+		if !a.done {
+			a.actions = append(a.actions, fn)
+			a.Unlock()
+			return
+		}
+		a.Unlock()
+	}
+	fn()
+}
+
+// RunAfterCommit runs the queued actions, once the transaction is committed.
+func RunAfterCommit(ctx context.Context) {
+	for _, fn := range takeAfterCommit(ctx) {
+		fn()
+	}
+}
+
+// DiscardAfterCommit drops the queued actions, once the transaction is rolled back.
+func DiscardAfterCommit(ctx context.Context) {
+	takeAfterCommit(ctx)
+}
+
+func takeAfterCommit(ctx context.Context) (actions []func()) {
+	a, ok := ctx.Value(afterCommitKey{}).(*afterCommitActions)
+	if !ok {
+		return nil
+	}
+	a.Lock()
+	defer a.Unlock()
+	actions, a.actions, a.done = a.actions, nil, true
+	return
+}
@@ -0,0 +1,250 @@
+package filer
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestAfterCommit(t *testing.T) {
+	var ran []int
+
+	// without WithAfterCommit, the actions run right away
+	AfterCommit(context.Background(), func() { ran = append(ran, 0) })
+	assert.Equal(t, []int{0}, ran)
+
+	ctx := WithAfterCommit(context.Background())
+	AfterCommit(ctx, func() { ran = append(ran, 1) })
+	AfterCommit(ctx, func() { ran = append(ran, 2) })
+	assert.Equal(t, []int{0}, ran)
+	RunAfterCommit(ctx)
+	assert.Equal(t, []int{0, 1, 2}, ran)
+
+	// after the commit, the actions run right away
+	AfterCommit(ctx, func() { ran = append(ran, 3) })
+	assert.Equal(t, []int{0, 1, 2, 3}, ran)
+
+	ctx = WithAfterCommit(context.Background())
+	AfterCommit(ctx, func() { ran = append(ran, 4) })
+	DiscardAfterCommit(ctx)
+	RunAfterCommit(ctx)
+	assert.Equal(t, []int{0, 1, 2, 3}, ran)
+}
+
+func TestDeleteEntryAfterCommit(t *testing.T) {
+	f := NewFiler(nil, nil, "", "", "", "", "", nil)
+	f.SetStore(newMemoryStore())
+	ctx := context.Background()
+
+	file := &Entry{
+		FullPath: "/dir/file",
+		Attr:     Attr{Mode: 0644},
+		Chunks:   []*filer_pb.FileChunk{{FileId: "1,0123456789", Size: 10}},
+	}
+	assert.Nil(t, f.CreateEntry(ctx, file, false, false, nil, false))
+	eventCount := len(f.RecentEvents.List())
+
+	// the transaction is rolled back, so neither the chunks are deleted nor the event is published
+	txCtx := WithAfterCommit(ctx)
+	assert.Nil(t, f.DeleteEntryMetaAndData(txCtx, file.FullPath, false, false, true, false, nil))
+	_, err := f.FindEntry(ctx, file.FullPath)
+	assert.Equal(t, filer_pb.ErrNotFound, err)
+	assert.Equal(t, 2, pendingAfterCommit(txCtx), "the event and the chunk deletion")
+	DiscardAfterCommit(txCtx)
+	assert.Equal(t, eventCount, len(f.RecentEvents.List()))
+
+	empty := &Entry{FullPath: "/dir/empty", Attr: Attr{Mode: 0644}}
+	assert.Nil(t, f.CreateEntry(ctx, empty, false, false, nil, false))
+	eventCount = len(f.RecentEvents.List())
+
+	txCtx = WithAfterCommit(ctx)
+	assert.Nil(t, f.DeleteEntryMetaAndData(txCtx, empty.FullPath, false, false, true, false, nil))
+	assert.Equal(t, eventCount, len(f.RecentEvents.List()))
+	RunAfterCommit(txCtx)
+	assert.Equal(t, eventCount+1, len(f.RecentEvents.List()))
+}
+
+func TestCreateEntryAfterCommit(t *testing.T) {
+	f := NewFiler(nil, nil, "", "", "", "", "", nil)
+	f.SetStore(newMemoryStore())
+	ctx := context.Background()
+
+	file := &Entry{
+		FullPath: "/dir/file",
+		Attr:     Attr{Mode: 0644},
+		Chunks:   []*filer_pb.FileChunk{{FileId: "1,0123456789", Size: 10}},
+	}
+	assert.Nil(t, f.CreateEntry(ctx, file, false, false, nil, false))
+	eventCount := len(f.RecentEvents.List())
+
+	// the transaction is rolled back, so neither the old chunks are deleted nor the event is published
+	txCtx := WithAfterCommit(ctx)
+	overwritten := &Entry{
+		FullPath: "/dir/file",
+		Attr:     Attr{Mode: 0644},
+		Chunks:   []*filer_pb.FileChunk{{FileId: "1,0223456789", Size: 10}},
+	}
+	assert.Nil(t, f.CreateEntry(txCtx, overwritten, false, false, nil, false))
+	assert.Equal(t, 2, pendingAfterCommit(txCtx), "the event and the chunk deletion")
+	DiscardAfterCommit(txCtx)
+	assert.Equal(t, eventCount, len(f.RecentEvents.List()))
+
+	txCtx = WithAfterCommit(ctx)
+	assert.Nil(t, f.CreateEntry(txCtx, &Entry{FullPath: "/dir/new", Attr: Attr{Mode: 0644}}, false, false, nil, false))
+	assert.Equal(t, eventCount, len(f.RecentEvents.List()))
+	RunAfterCommit(txCtx)
+	assert.Equal(t, eventCount+1, len(f.RecentEvents.List()))
+}
+
+func pendingAfterCommit(ctx context.Context) int {
+	a, ok := ctx.Value(afterCommitKey{}).(*afterCommitActions)
+	if !ok {
+		return 0
+	}
+	a.Lock()
+	defer a.Unlock()
+	return len(a.actions)
+}
+
+// memoryStore is a FilerStore in a map, without transactions
+type memoryStore struct {
+	sync.Mutex
+	entries map[util.FullPath]*Entry
+	kv      map[string][]byte
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		entries: make(map[util.FullPath]*Entry),
+		kv:      make(map[string][]byte),
+	}
+}
+
+func (store *memoryStore) GetName() string {
+	return "memory"
+}
+
+func (store *memoryStore) Initialize(configuration util.Configuration, prefix string) error {
+	return nil
+}
+
+func (store *memoryStore) InsertEntry(ctx context.Context, entry *Entry) error {
+	store.Lock()
+	defer store.Unlock()
+	store.entries[entry.FullPath] = entry.ShallowClone()
+	return nil
+}
+
+func (store *memoryStore) UpdateEntry(ctx context.Context, entry *Entry) error {
+	return store.InsertEntry(ctx, entry)
+}
+
+func (store *memoryStore) FindEntry(ctx context.Context, p util.FullPath) (*Entry, error) {
+	store.Lock()
+	defer store.Unlock()
+	entry, found := store.entries[p]
+	if !found {
+		return nil, filer_pb.ErrNotFound
+	}
+	return entry.ShallowClone(), nil
+}
+
+func (store *memoryStore) DeleteEntry(ctx context.Context, p util.FullPath) error {
+	store.Lock()
+	defer store.Unlock()
+	delete(store.entries, p)
+	return nil
+}
+
+func (store *memoryStore) DeleteFolderChildren(ctx context.Context, p util.FullPath) error {
+	store.Lock()
+	defer store.Unlock()
+	for entryPath := range store.entries {
+		if strings.HasPrefix(string(entryPath), string(p)+"/") {
+			delete(store.entries, entryPath)
+		}
+	}
+	return nil
+}
+
+func (store *memoryStore) ListDirectoryEntries(ctx context.Context, dirPath util.FullPath, startFileName string, includeStartFile bool, limit int64, eachEntryFunc ListEachEntryFunc) (string, error) {
+	return store.ListDirectoryPrefixedEntries(ctx, dirPath, startFileName, includeStartFile, limit, "", eachEntryFunc)
+}
+
+func (store *memoryStore) ListDirectoryPrefixedEntries(ctx context.Context, dirPath util.FullPath, startFileName string, includeStartFile bool, limit int64, prefix string, eachEntryFunc ListEachEntryFunc) (lastFileName string, err error) {
+	store.Lock()
+	var names []string
+	for entryPath := range store.entries {
+		dir, name := entryPath.DirAndName()
+		if util.FullPath(dir) != dirPath || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if name < startFileName || name == startFileName && !includeStartFile {
+			continue
+		}
+		names = append(names, name)
+	}
+	store.Unlock()
+	sort.Strings(names)
+	for _, name := range names {
+		if limit <= 0 {
+			break
+		}
+		limit--
+		entry, findErr := store.FindEntry(ctx, dirPath.Child(name))
+		if findErr != nil {
+			continue
+		}
+		lastFileName = name
+		if !eachEntryFunc(entry) {
+			break
+		}
+	}
+	return
+}
+
+func (store *memoryStore) BeginTransaction(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (store *memoryStore) CommitTransaction(ctx context.Context) error {
+	return nil
+}
+
+func (store *memoryStore) RollbackTransaction(ctx context.Context) error {
+	return nil
+}
+
+func (store *memoryStore) KvPut(ctx context.Context, key []byte, value []byte) error {
+	store.Lock()
+	defer store.Unlock()
+	store.kv[string(key)] = value
+	return nil
+}
+
+func (store *memoryStore) KvGet(ctx context.Context, key []byte) ([]byte, error) {
+	store.Lock()
+	defer store.Unlock()
+	value, found := store.kv[string(key)]
+	if !found {
+		return nil, ErrKvNotFound
+	}
+	return value, nil
+}
+
+func (store *memoryStore) KvDelete(ctx context.Context, key []byte) error {
+	store.Lock()
+	defer store.Unlock()
+	delete(store.kv, string(key))
+	return nil
+}
+
+func (store *memoryStore) Shutdown() {
+}
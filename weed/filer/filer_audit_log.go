@@ -0,0 +1,285 @@
+package filer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	AuditLogMaxSizeMB  = 100
+	AuditLogMaxAgeDays = 30
+	AuditLogMaxBackups = 10
+)
+
+// AuditRecord is one line in the audit log.
+type AuditRecord struct {
+	Time          time.Time `json:"time"`
+	ClientIp      string    `json:"client_ip,omitempty"`
+	Identity      string    `json:"identity,omitempty"`
+	IdentityError string    `json:"identity_error,omitempty"` // a bearer token was presented but not verified
+	Operation     string    `json:"operation"`
+	Path          string    `json:"path"`
+	NewPath       string    `json:"new_path,omitempty"`
+	FileSize      uint64    `json:"file_size"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// AuditLogger records every metadata mutation received via gRPC or the filer http api,
+// one JSON object per line, into a size and age rotated log file.
+// Bearer tokens are verified with the filer http write key, jwt.filer_signing.key,
+// since filer gRPC does not define a signing key of its own.
+type AuditLogger struct {
+	sync.Mutex
+	writer     io.WriteCloser
+	encoder    *json.Encoder
+	signingKey security.SigningKey
+}
+
+func NewAuditLogger(logFile string, signingKey security.SigningKey) *AuditLogger {
+	writer := &lumberjack.Logger{
+		Filename:   util.ResolvePath(logFile),
+		MaxSize:    AuditLogMaxSizeMB,
+		MaxAge:     AuditLogMaxAgeDays,
+		MaxBackups: AuditLogMaxBackups,
+		LocalTime:  true,
+	}
+	return &AuditLogger{
+		writer:     writer,
+		encoder:    json.NewEncoder(writer),
+		signingKey: signingKey,
+	}
+}
+
+func (al *AuditLogger) Log(record *AuditRecord) {
+	al.Lock()
+	defer al.Unlock()
+	if err := al.encoder.Encode(record); err != nil {
+		glog.Errorf("write audit log %+v: %v", record, err)
+	}
+}
+
+func (al *AuditLogger) Close() error {
+	al.Lock()
+	defer al.Unlock()
+	return al.writer.Close()
+}
+
+// UnaryServerInterceptor audits CreateEntry, UpdateEntry, AppendToEntry, DeleteEntry, EraseEntry and AtomicRenameEntry calls.
+func (al *AuditLogger) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		record := newAuditRecord(req)
+		if record == nil {
+			return handler(ctx, req)
+		}
+
+		resp, err := handler(ctx, req)
+
+		al.logGrpc(ctx, record, auditErrorOf(resp, err))
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor audits StreamRenameEntry calls, which is how "weed mount" renames.
+func (al *AuditLogger) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if info.FullMethod != "/filer_pb.SeaweedFiler/StreamRenameEntry" {
+			return handler(srv, ss)
+		}
+
+		auditStream := &auditServerStream{ServerStream: ss}
+		err := handler(srv, auditStream)
+
+		if auditStream.record != nil {
+			al.logGrpc(ss.Context(), auditStream.record, auditErrorOf(nil, err))
+		}
+
+		return err
+	}
+}
+
+type auditServerStream struct {
+	grpc.ServerStream
+	record *AuditRecord
+}
+
+func (s *auditServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.record == nil {
+		s.record = newAuditRecord(m)
+	}
+	return err
+}
+
+func (al *AuditLogger) logGrpc(ctx context.Context, record *AuditRecord, errMessage string) {
+	record.Time = time.Now()
+	record.ClientIp, record.Identity, record.IdentityError = al.callerOf(ctx)
+	record.Status = "ok"
+	if errMessage != "" {
+		record.Status = "error"
+		record.Error = errMessage
+	}
+	al.Log(record)
+}
+
+// LogHttp audits one mutation done through the filer http api.
+func (al *AuditLogger) LogHttp(r *http.Request, operation, path, newPath string, fileSize uint64, statusCode int) {
+	record := &AuditRecord{
+		Time:      time.Now(),
+		Operation: operation,
+		Path:      path,
+		NewPath:   newPath,
+		FileSize:  fileSize,
+		Status:    "ok",
+	}
+	record.ClientIp, record.Identity, record.IdentityError = al.httpCallerOf(r)
+	if statusCode >= http.StatusBadRequest {
+		record.Status = "error"
+		record.Error = fmt.Sprintf("http status %d", statusCode)
+	}
+	al.Log(record)
+}
+
+func newAuditRecord(req interface{}) *AuditRecord {
+	switch r := req.(type) {
+	case *filer_pb.CreateEntryRequest:
+		return &AuditRecord{
+			Operation: "CreateEntry",
+			Path:      util.Join(r.Directory, r.Entry.GetName()),
+			FileSize:  FileSize(r.Entry),
+		}
+	case *filer_pb.UpdateEntryRequest:
+		return &AuditRecord{
+			Operation: "UpdateEntry",
+			Path:      util.Join(r.Directory, r.Entry.GetName()),
+			FileSize:  FileSize(r.Entry),
+		}
+	case *filer_pb.AppendToEntryRequest:
+		return &AuditRecord{
+			Operation: "AppendToEntry",
+			Path:      util.Join(r.Directory, r.EntryName),
+			FileSize:  TotalSize(r.Chunks),
+		}
+	case *filer_pb.DeleteEntryRequest:
+		return &AuditRecord{
+			Operation: "DeleteEntry",
+			Path:      util.Join(r.Directory, r.Name),
+		}
+	case *filer_pb.EraseEntryRequest:
+		return &AuditRecord{
+			Operation: "EraseEntry",
+			Path:      util.Join(r.Directory, r.Name),
+		}
+	case *filer_pb.AtomicRenameEntryRequest:
+		return &AuditRecord{
+			Operation: "AtomicRenameEntry",
+			Path:      util.Join(r.OldDirectory, r.OldName),
+			NewPath:   util.Join(r.NewDirectory, r.NewName),
+		}
+	case *filer_pb.StreamRenameEntryRequest:
+		return &AuditRecord{
+			Operation: "StreamRenameEntry",
+			Path:      util.Join(r.OldDirectory, r.OldName),
+			NewPath:   util.Join(r.NewDirectory, r.NewName),
+		}
+	}
+	return nil
+}
+
+// auditErrorOf also checks the error field, since some responses carry errors in the message body.
+func auditErrorOf(resp interface{}, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	switch r := resp.(type) {
+	case *filer_pb.CreateEntryResponse:
+		return r.GetError()
+	case *filer_pb.DeleteEntryResponse:
+		return r.GetError()
+	case *filer_pb.EraseEntryResponse:
+		return r.GetError()
+	}
+	return ""
+}
+
+// callerOf returns the caller ip, and the mTLS common name or the verified JWT subject.
+// If a bearer token is present but can not be verified, identityError tells why.
+func (al *AuditLogger) callerOf(ctx context.Context) (clientIp, identity, identityError string) {
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			clientIp = host
+		} else {
+			clientIp = p.Addr.String()
+		}
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.PeerCertificates) > 0 {
+			identity = tlsInfo.State.PeerCertificates[0].Subject.CommonName
+		}
+	}
+	if identity != "" {
+		return
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return
+	}
+	for _, bearer := range md.Get("authorization") {
+		if len(bearer) <= 7 || strings.ToUpper(bearer[0:6]) != "BEARER" {
+			continue
+		}
+		identity, identityError = al.subjectOf(security.EncodedJwt(bearer[7:]))
+		if identity != "" {
+			return
+		}
+	}
+	return
+}
+
+func (al *AuditLogger) httpCallerOf(r *http.Request) (clientIp, identity, identityError string) {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		clientIp = host
+	} else {
+		clientIp = r.RemoteAddr
+	}
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		identity = r.TLS.PeerCertificates[0].Subject.CommonName
+		return
+	}
+	if tokenStr := security.GetJwt(r); tokenStr != "" {
+		identity, identityError = al.subjectOf(tokenStr)
+	}
+	return
+}
+
+func (al *AuditLogger) subjectOf(tokenStr security.EncodedJwt) (subject, identityError string) {
+	if len(al.signingKey) == 0 {
+		return "", "bearer token not verified: jwt.filer_signing.key is not set"
+	}
+	claims := &security.SeaweedFilerClaims{}
+	token, err := security.DecodeJwt(al.signingKey, tokenStr, claims)
+	if err != nil {
+		return "", fmt.Sprintf("bearer token rejected: %v", err)
+	}
+	if !token.Valid {
+		return "", "bearer token rejected: invalid token"
+	}
+	return claims.Subject, ""
+}
@@ -0,0 +1,188 @@
+package filer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+)
+
+func TestNewAuditRecord(t *testing.T) {
+	tests := []struct {
+		req       interface{}
+		operation string
+		path      string
+		newPath   string
+		fileSize  uint64
+	}{
+		{
+			req: &filer_pb.CreateEntryRequest{Directory: "/a", Entry: &filer_pb.Entry{
+				Name:       "b.txt",
+				Attributes: &filer_pb.FuseAttributes{FileSize: 42},
+			}},
+			operation: "CreateEntry", path: "/a/b.txt", fileSize: 42,
+		},
+		{
+			req:       &filer_pb.UpdateEntryRequest{Directory: "/a", Entry: &filer_pb.Entry{Name: "b.txt"}},
+			operation: "UpdateEntry", path: "/a/b.txt",
+		},
+		{
+			req: &filer_pb.AppendToEntryRequest{Directory: "/a", EntryName: "log", Chunks: []*filer_pb.FileChunk{
+				{Offset: 0, Size: 10},
+				{Offset: 10, Size: 5},
+			}},
+			operation: "AppendToEntry", path: "/a/log", fileSize: 15,
+		},
+		{
+			req:       &filer_pb.DeleteEntryRequest{Directory: "/a", Name: "b.txt"},
+			operation: "DeleteEntry", path: "/a/b.txt",
+		},
+		{
+			req:       &filer_pb.AtomicRenameEntryRequest{OldDirectory: "/a", OldName: "b", NewDirectory: "/c", NewName: "d"},
+			operation: "AtomicRenameEntry", path: "/a/b", newPath: "/c/d",
+		},
+		{
+			req:       &filer_pb.StreamRenameEntryRequest{OldDirectory: "/a", OldName: "b", NewDirectory: "/c", NewName: "d"},
+			operation: "StreamRenameEntry", path: "/a/b", newPath: "/c/d",
+		},
+	}
+	for _, tt := range tests {
+		record := newAuditRecord(tt.req)
+		if !assert.NotNil(t, record, "%T", tt.req) {
+			continue
+		}
+		assert.Equal(t, tt.operation, record.Operation)
+		assert.Equal(t, tt.path, record.Path)
+		assert.Equal(t, tt.newPath, record.NewPath)
+		assert.Equal(t, tt.fileSize, record.FileSize)
+	}
+
+	assert.Nil(t, newAuditRecord(&filer_pb.LookupDirectoryEntryRequest{Directory: "/a", Name: "b"}))
+}
+
+func TestAuditErrorOf(t *testing.T) {
+	tests := []struct {
+		resp     interface{}
+		err      error
+		expected string
+	}{
+		{resp: &filer_pb.CreateEntryResponse{}, expected: ""},
+		{resp: &filer_pb.CreateEntryResponse{Error: "exists"}, expected: "exists"},
+		{resp: &filer_pb.DeleteEntryResponse{Error: "not empty"}, expected: "not empty"},
+		{resp: &filer_pb.UpdateEntryResponse{}, expected: ""},
+		{resp: nil, err: errors.New("rpc failed"), expected: "rpc failed"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, auditErrorOf(tt.resp, tt.err))
+	}
+}
+
+func TestAuditCallerOf(t *testing.T) {
+	signingKey := security.SigningKey("secret")
+	al := &AuditLogger{signingKey: signingKey}
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 4321}
+
+	tlsPeer := &peer.Peer{
+		Addr: addr,
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "mount-1"}}},
+		}},
+	}
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		signingKey    security.SigningKey
+		clientIp      string
+		identity      string
+		identityError bool
+	}{
+		{
+			name:     "mtls common name",
+			ctx:      peer.NewContext(context.Background(), tlsPeer),
+			clientIp: "10.0.0.5",
+			identity: "mount-1",
+		},
+		{
+			name:     "valid jwt",
+			ctx:      withBearer(peer.NewContext(context.Background(), &peer.Peer{Addr: addr}), signTestJwt(t, signingKey, "alice")),
+			clientIp: "10.0.0.5",
+			identity: "alice",
+		},
+		{
+			name:          "invalid jwt",
+			ctx:           withBearer(peer.NewContext(context.Background(), &peer.Peer{Addr: addr}), signTestJwt(t, security.SigningKey("other"), "mallory")),
+			clientIp:      "10.0.0.5",
+			identityError: true,
+		},
+		{
+			name:     "no credentials",
+			ctx:      peer.NewContext(context.Background(), &peer.Peer{Addr: addr}),
+			clientIp: "10.0.0.5",
+		},
+	}
+	for _, tt := range tests {
+		clientIp, identity, identityError := al.callerOf(tt.ctx)
+		assert.Equal(t, tt.clientIp, clientIp, tt.name)
+		assert.Equal(t, tt.identity, identity, tt.name)
+		assert.Equal(t, tt.identityError, identityError != "", tt.name)
+	}
+
+	noKey := &AuditLogger{}
+	_, identity, identityError := noKey.callerOf(withBearer(context.Background(), signTestJwt(t, signingKey, "alice")))
+	assert.Equal(t, "", identity)
+	assert.Contains(t, identityError, "jwt.filer_signing.key")
+}
+
+func TestAuditUnaryServerInterceptor(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	al := NewAuditLogger(logFile, nil)
+
+	interceptor := al.UnaryServerInterceptor()
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.5"), Port: 4321}})
+	req := &filer_pb.DeleteEntryRequest{Directory: "/a", Name: "b.txt"}
+	_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/filer_pb.SeaweedFiler/DeleteEntry"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &filer_pb.DeleteEntryResponse{Error: "not empty"}, nil
+		})
+	assert.Nil(t, err)
+	assert.Nil(t, al.Close())
+
+	data, err := os.ReadFile(logFile)
+	assert.Nil(t, err)
+
+	var record AuditRecord
+	assert.Nil(t, json.Unmarshal(data, &record))
+	assert.Equal(t, "DeleteEntry", record.Operation)
+	assert.Equal(t, "/a/b.txt", record.Path)
+	assert.Equal(t, "10.0.0.5", record.ClientIp)
+	assert.Equal(t, "error", record.Status)
+	assert.Equal(t, "not empty", record.Error)
+}
+
+func withBearer(ctx context.Context, token string) context.Context {
+	return metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func signTestJwt(t *testing.T, signingKey security.SigningKey, subject string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{Subject: subject})
+	encoded, err := token.SignedString([]byte(signingKey))
+	assert.Nil(t, err)
+	return encoded
+}
@@ -169,6 +169,7 @@ func mergePathConf(a, b *filer_pb.FilerConf_PathConf) {
 	a.DataCenter = util.Nvl(b.DataCenter, a.DataCenter)
 	a.Rack = util.Nvl(b.Rack, a.Rack)
 	a.DataNode = util.Nvl(b.DataNode, a.DataNode)
+	a.Dedup = b.Dedup || a.Dedup
 }
 
 func (fc *FilerConf) ToProto() *filer_pb.FilerConf {
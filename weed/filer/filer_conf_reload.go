@@ -0,0 +1,41 @@
+package filer
+
+import (
+	"sync"
+	"time"
+)
+
+// FilerConfReloadStatus tells whether the active filer.conf is the latest one.
+// The filer.conf is reloaded on its metadata change events, from this filer or the peer filers.
+type FilerConfReloadStatus struct {
+	LoadedAt time.Time
+	FailedAt time.Time
+	Error    string
+}
+
+// Pending is true if the latest filer.conf change failed to load, and the previous one is still active
+func (s FilerConfReloadStatus) Pending() bool {
+	return s.Error != ""
+}
+
+type filerConfReloadTracker struct {
+	sync.Mutex
+	status FilerConfReloadStatus
+}
+
+func (t *filerConfReloadTracker) record(err error) {
+	t.Lock()
+	defer t.Unlock()
+	if err != nil {
+		t.status.FailedAt = time.Now()
+		t.status.Error = err.Error()
+		return
+	}
+	t.status = FilerConfReloadStatus{LoadedAt: time.Now()}
+}
+
+func (f *Filer) FilerConfReloadStatus() FilerConfReloadStatus {
+	f.filerConfReload.Lock()
+	defer f.filerConfReload.Unlock()
+	return f.filerConfReload.status
+}
@@ -1,6 +1,7 @@
 package filer
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -47,3 +48,20 @@ func TestFilerConf(t *testing.T) {
 	assert.Equal(t, false, fc.MatchStorageRule("/buckets/other").ReadOnly)
 
 }
+
+func TestFilerConfReloadStatus(t *testing.T) {
+	var tracker filerConfReloadTracker
+
+	tracker.record(nil)
+	assert.False(t, tracker.status.Pending())
+	assert.False(t, tracker.status.LoadedAt.IsZero())
+
+	tracker.record(fmt.Errorf("unexpected token"))
+	assert.True(t, tracker.status.Pending())
+	assert.Equal(t, "unexpected token", tracker.status.Error)
+
+	// a later successful reload clears the failure
+	tracker.record(nil)
+	assert.False(t, tracker.status.Pending())
+	assert.True(t, tracker.status.FailedAt.IsZero())
+}
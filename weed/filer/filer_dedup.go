@@ -0,0 +1,349 @@
+package filer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	dedupHashKeyPrefix  = "__dedup_hash__/"
+	dedupChunkKeyPrefix = "__dedup_chunk__/"
+	// DirectoryDedup has a directory for each deduplicated chunk, with one entry for each file chunk
+	// referencing it, and one entry for each release in progress
+	DirectoryDedup = DirectoryEtcSeaweedFS + "/dedup"
+)
+
+// ErrDedupChunkReleased is returned when a file references a deduplicated chunk whose data is being deleted
+var ErrDedupChunkReleased = errors.New("deduplicated chunk is released")
+
+// DeduplicationIndex finds the chunks by the SHA-256 of their content, to reuse an uploaded chunk
+// instead of uploading the same content again. The chunks are only shared within one collection.
+//
+// The file chunks referencing a deduplicated chunk are flagged with IsDeduplicated, and so are the manifest
+// chunks with any such data chunk, so the deletions only check the references of the flagged chunks.
+type DeduplicationIndex interface {
+	// LookupChunkByHash returns the chunk with the same content.
+	LookupChunkByHash(ctx context.Context, collection string, hash []byte) (*filer_pb.FileChunk, bool)
+	// AddChunk indexes a newly uploaded chunk by the SHA-256 of its content, read back from the volume servers.
+	AddChunk(ctx context.Context, collection string, chunk *filer_pb.FileChunk) (hash []byte, err error)
+	// Reference adds the reference of the file chunk, and returns false if the chunk is not deduplicated.
+	Reference(ctx context.Context, chunk *filer_pb.FileChunk) (bool, error)
+	// Unreference drops the reference of the file chunk, and keeps the chunk data.
+	Unreference(ctx context.Context, chunk *filer_pb.FileChunk)
+	// Release drops the reference of the file chunk, and returns true if the chunk data is no longer referenced.
+	Release(ctx context.Context, chunk *filer_pb.FileChunk) bool
+}
+
+type dedupChunk struct {
+	Collection string `json:"collection"`
+	Hash       string `json:"hash"`
+}
+
+// KvDeduplicationIndex keeps the hashes in the filer store KV, and the references as entries in the filer store.
+// Each reference is its own entry, so the filers sharing the store never update the same key concurrently.
+//
+// A release adds a release entry before checking the references for the last time, and a new reference
+// checks the release entries after it is added, so either the release sees the reference and keeps
+// the chunk data, or the reference sees the release and fails with ErrDedupChunkReleased.
+type KvDeduplicationIndex struct {
+	filer *Filer
+}
+
+var _ = DeduplicationIndex(&KvDeduplicationIndex{})
+
+func NewKvDeduplicationIndex(f *Filer) *KvDeduplicationIndex {
+	return &KvDeduplicationIndex{
+		filer: f,
+	}
+}
+
+func dedupHashKey(collection string, hash []byte) []byte {
+	return []byte(dedupHashKeyPrefix + collection + "/" + hex.EncodeToString(hash))
+}
+
+func dedupChunkKey(fileId string) []byte {
+	return []byte(dedupChunkKeyPrefix + fileId)
+}
+
+func dedupRefsDir(fileId string) util.FullPath {
+	return util.NewFullPath(DirectoryDedup, fileId).Child("refs")
+}
+
+func dedupReleasesDir(fileId string) util.FullPath {
+	return util.NewFullPath(DirectoryDedup, fileId).Child("releases")
+}
+
+// dedupRefName identifies the file chunk. A renamed file, or another hard link, keeps the same reference.
+func dedupRefName(chunk *filer_pb.FileChunk) string {
+	return fmt.Sprintf("%d_%d", chunk.Offset, chunk.ModifiedTsNs)
+}
+
+func (idx *KvDeduplicationIndex) LookupChunkByHash(ctx context.Context, collection string, hash []byte) (*filer_pb.FileChunk, bool) {
+	data, err := idx.filer.Store.KvGet(ctx, dedupHashKey(collection, hash))
+	if err != nil {
+		if err != ErrKvNotFound {
+			glog.Warningf("dedup lookup %x: %v", hash, err)
+		}
+		return nil, false
+	}
+	chunk := &filer_pb.FileChunk{}
+	if err = proto.Unmarshal(data, chunk); err != nil {
+		glog.Warningf("dedup lookup %x: %v", hash, err)
+		return nil, false
+	}
+	chunk.IsDeduplicated = true
+	return chunk, true
+}
+
+func (idx *KvDeduplicationIndex) AddChunk(ctx context.Context, collection string, chunk *filer_pb.FileChunk) ([]byte, error) {
+	fileId := chunk.GetFileIdString()
+
+	// the client only claims the hash, so the index is built from the stored content
+	var buf bytes.Buffer
+	if err := fetchWholeChunk(&buf, idx.filer.MasterClient.GetLookupFileIdFunction(), fileId, chunk.CipherKey, chunk.IsCompressed); err != nil {
+		return nil, fmt.Errorf("read chunk %s: %v", fileId, err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	hash := sum[:]
+
+	// a concurrent upload of the same content is already indexed, and this chunk stays not shared
+	if _, err := idx.filer.Store.KvGet(ctx, dedupHashKey(collection, hash)); err != ErrKvNotFound {
+		return hash, err
+	}
+	record, err := json.Marshal(&dedupChunk{
+		Collection: collection,
+		Hash:       hex.EncodeToString(hash),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = idx.filer.Store.KvPut(ctx, dedupChunkKey(fileId), record); err != nil {
+		return nil, err
+	}
+	// only the content related fields are kept, the offset and the time are from each write
+	data, err := proto.Marshal(&filer_pb.FileChunk{
+		FileId:       fileId,
+		Size:         chunk.Size,
+		ETag:         chunk.ETag,
+		CipherKey:    chunk.CipherKey,
+		IsCompressed: chunk.IsCompressed,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hash, idx.filer.Store.KvPut(ctx, dedupHashKey(collection, hash), data)
+}
+
+func (idx *KvDeduplicationIndex) Reference(ctx context.Context, chunk *filer_pb.FileChunk) (bool, error) {
+	fileId := chunk.GetFileIdString()
+	if _, err := idx.loadChunk(ctx, fileId); err != nil {
+		if err == ErrKvNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	refPath := dedupRefsDir(fileId).Child(dedupRefName(chunk))
+	if err := idx.filer.Store.InsertEntry(ctx, newDedupEntry(refPath)); err != nil {
+		return false, fmt.Errorf("reference %s: %v", fileId, err)
+	}
+
+	// check the releases first, since a finished release deletes the chunk record before its release entry
+	releasing, err := idx.hasEntries(ctx, dedupReleasesDir(fileId))
+	if err == nil && !releasing {
+		_, err = idx.loadChunk(ctx, fileId)
+	}
+	if err == nil && !releasing {
+		return true, nil
+	}
+	idx.Unreference(ctx, chunk)
+	if err != nil && err != ErrKvNotFound {
+		return false, err
+	}
+	return false, fmt.Errorf("%w: %s", ErrDedupChunkReleased, fileId)
+}
+
+func (idx *KvDeduplicationIndex) Unreference(ctx context.Context, chunk *filer_pb.FileChunk) {
+	fileId := chunk.GetFileIdString()
+	if err := idx.filer.Store.DeleteEntry(ctx, dedupRefsDir(fileId).Child(dedupRefName(chunk))); err != nil {
+		glog.Warningf("dedup unreference %s: %v", fileId, err)
+	}
+}
+
+func (idx *KvDeduplicationIndex) Release(ctx context.Context, chunk *filer_pb.FileChunk) bool {
+	fileId := chunk.GetFileIdString()
+	record, err := idx.loadChunk(ctx, fileId)
+	if err == ErrKvNotFound {
+		return true
+	}
+	if err != nil {
+		// keep the data if not sure
+		glog.Warningf("dedup release %s: %v", fileId, err)
+		return false
+	}
+
+	refsDir := dedupRefsDir(fileId)
+	if err = idx.filer.Store.DeleteEntry(ctx, refsDir.Child(dedupRefName(chunk))); err != nil {
+		glog.Warningf("dedup release %s: %v", fileId, err)
+		return false
+	}
+	if referenced, err := idx.hasEntries(ctx, refsDir); err != nil || referenced {
+		return false
+	}
+
+	releasePath := dedupReleasesDir(fileId).Child(uuid.New().String())
+	if err = idx.filer.Store.InsertEntry(ctx, newDedupEntry(releasePath)); err != nil {
+		glog.Warningf("dedup release %s: %v", fileId, err)
+		return false
+	}
+	if referenced, err := idx.hasEntries(ctx, refsDir); err != nil || referenced {
+		idx.deleteEntry(ctx, releasePath)
+		return false
+	}
+
+	if err = idx.removeHash(ctx, fileId, record); err != nil {
+		glog.Warningf("dedup release %s: %v", fileId, err)
+	}
+	if err = idx.filer.Store.KvDelete(ctx, dedupChunkKey(fileId)); err != nil {
+		glog.Warningf("dedup release %s: %v", fileId, err)
+		return false
+	}
+	idx.deleteEntry(ctx, releasePath)
+	return true
+}
+
+// removeHash deletes the hash of the chunk, if the hash is not indexed to another chunk
+func (idx *KvDeduplicationIndex) removeHash(ctx context.Context, fileId string, record *dedupChunk) error {
+	hash, err := hex.DecodeString(record.Hash)
+	if err != nil {
+		return nil
+	}
+	data, err := idx.filer.Store.KvGet(ctx, dedupHashKey(record.Collection, hash))
+	if err == ErrKvNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	chunk := &filer_pb.FileChunk{}
+	if err = proto.Unmarshal(data, chunk); err == nil && chunk.GetFileIdString() != fileId {
+		return nil
+	}
+	return idx.filer.Store.KvDelete(ctx, dedupHashKey(record.Collection, hash))
+}
+
+func (idx *KvDeduplicationIndex) loadChunk(ctx context.Context, fileId string) (*dedupChunk, error) {
+	data, err := idx.filer.Store.KvGet(ctx, dedupChunkKey(fileId))
+	if err != nil {
+		return nil, err
+	}
+	record := &dedupChunk{}
+	return record, json.Unmarshal(data, record)
+}
+
+func (idx *KvDeduplicationIndex) hasEntries(ctx context.Context, dir util.FullPath) (found bool, err error) {
+	_, err = idx.filer.Store.ListDirectoryEntries(ctx, dir, "", false, 1, func(entry *Entry) bool {
+		found = true
+		return false
+	})
+	return
+}
+
+func (idx *KvDeduplicationIndex) deleteEntry(ctx context.Context, fullPath util.FullPath) {
+	if err := idx.filer.Store.DeleteEntry(ctx, fullPath); err != nil {
+		glog.Warningf("dedup delete %s: %v", fullPath, err)
+	}
+}
+
+func newDedupEntry(fullPath util.FullPath) *Entry {
+	now := time.Now()
+	return &Entry{
+		FullPath: fullPath,
+		Attr: Attr{
+			Mtime:  now,
+			Crtime: now,
+			Mode:   os.FileMode(0644),
+		},
+	}
+}
+
+// referenceDedupChunks adds the references of the deduplicated chunks new in the entry, and flags them.
+// The chunks kept from the old entry keep their flags. Only the files in the paths configured with dedup,
+// or with the chunks flagged by the client, are checked. The added references are returned,
+// to be dropped if the entry is not saved.
+func (f *Filer) referenceDedupChunks(ctx context.Context, oldEntry, entry *Entry) (referenced []*filer_pb.FileChunk, err error) {
+	if f.DedupIndex == nil || entry == nil || entry.IsDirectory() || len(entry.GetChunks()) == 0 {
+		return nil, nil
+	}
+	// a new hard link shares the chunks, and the references, of the existing links
+	if oldEntry == nil && len(entry.HardLinkId) > 0 {
+		return nil, nil
+	}
+	oldChunks := make(map[string]*filer_pb.FileChunk)
+	if oldEntry != nil {
+		for _, chunk := range oldEntry.GetChunks() {
+			oldChunks[chunk.GetFileIdString()+"/"+dedupRefName(chunk)] = chunk
+		}
+	}
+	dedupPath := f.FilerConf != nil && f.FilerConf.MatchStorageRule(string(entry.FullPath)).Dedup
+
+	for _, chunk := range entry.GetChunks() {
+		if oldChunk, found := oldChunks[chunk.GetFileIdString()+"/"+dedupRefName(chunk)]; found {
+			chunk.IsDeduplicated = oldChunk.IsDeduplicated
+			continue
+		}
+		if !dedupPath && !chunk.IsDeduplicated {
+			continue
+		}
+		dataChunks := []*filer_pb.FileChunk{chunk}
+		if chunk.IsChunkManifest {
+			if dataChunks, err = ResolveOneChunkManifest(f.MasterClient.LookupFileId, chunk); err != nil {
+				f.unreferenceDedupChunks(ctx, referenced)
+				return nil, fmt.Errorf("resolve manifest %s: %v", chunk.GetFileIdString(), err)
+			}
+		}
+		chunk.IsDeduplicated = false
+		for _, dataChunk := range dataChunks {
+			ok, refErr := f.DedupIndex.Reference(ctx, dataChunk)
+			if refErr != nil {
+				f.unreferenceDedupChunks(ctx, referenced)
+				return nil, refErr
+			}
+			if ok {
+				referenced = append(referenced, dataChunk)
+				chunk.IsDeduplicated = true
+			}
+		}
+	}
+	return referenced, nil
+}
+
+// unreferenceDedupChunks drops the references added for an entry not saved
+func (f *Filer) unreferenceDedupChunks(ctx context.Context, referenced []*filer_pb.FileChunk) {
+	for _, chunk := range referenced {
+		f.DedupIndex.Unreference(ctx, chunk)
+	}
+}
+
+// releaseDedupChunk drops the reference of a deduplicated chunk, and returns whether the chunk data can be deleted.
+// The data chunks of a flagged manifest chunk are not flagged themselves, so deduplicated is from the manifest chunk.
+func (f *Filer) releaseDedupChunk(chunk *filer_pb.FileChunk, deduplicated bool) bool {
+	if f.DedupIndex == nil || !deduplicated && !chunk.IsDeduplicated || chunk.IsChunkManifest {
+		return true
+	}
+	return f.DedupIndex.Release(context.Background(), chunk)
+}
@@ -0,0 +1,98 @@
+package filer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+func newDedupTestFiler(t *testing.T, fileId string, hash []byte) (*Filer, *KvDeduplicationIndex) {
+	f := NewFiler(nil, nil, "", "", "", "", "", nil)
+	f.SetStore(newMemoryStore())
+	idx := NewKvDeduplicationIndex(f)
+	f.DedupIndex = idx
+	ctx := context.Background()
+	assert.Nil(t, f.Store.KvPut(ctx, dedupChunkKey(fileId), []byte(`{"collection":"c","hash":"0102"}`)))
+	data, err := proto.Marshal(&filer_pb.FileChunk{FileId: fileId})
+	assert.Nil(t, err)
+	assert.Nil(t, f.Store.KvPut(ctx, dedupHashKey("c", hash), data))
+	return f, idx
+}
+
+func TestDedupReferences(t *testing.T) {
+	hash := []byte{1, 2}
+	f, idx := newDedupTestFiler(t, "1,0123456789", hash)
+	ctx := context.Background()
+
+	a := &filer_pb.FileChunk{FileId: "1,0123456789", Offset: 0, ModifiedTsNs: 1}
+	b := &filer_pb.FileChunk{FileId: "1,0123456789", Offset: 0, ModifiedTsNs: 2}
+	for _, chunk := range []*filer_pb.FileChunk{a, b} {
+		ok, err := idx.Reference(ctx, chunk)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+
+	notShared := &filer_pb.FileChunk{FileId: "2,0123456789"}
+	ok, err := idx.Reference(ctx, notShared)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.True(t, idx.Release(ctx, notShared))
+
+	assert.False(t, idx.Release(ctx, a), "still referenced by b")
+	assert.True(t, idx.Release(ctx, b))
+	_, err = f.Store.KvGet(ctx, dedupHashKey("c", hash))
+	assert.Equal(t, ErrKvNotFound, err)
+
+	// the chunk data is deleted, so it can not be referenced again
+	ok, err = idx.Reference(ctx, a)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestDedupReferenceDuringRelease(t *testing.T) {
+	f, idx := newDedupTestFiler(t, "1,0123456789", []byte{1, 2})
+	ctx := context.Background()
+
+	// a release is checking the references for the last time
+	assert.Nil(t, f.Store.InsertEntry(ctx, newDedupEntry(dedupReleasesDir("1,0123456789").Child("release"))))
+
+	chunk := &filer_pb.FileChunk{FileId: "1,0123456789", ModifiedTsNs: 1}
+	ok, err := idx.Reference(ctx, chunk)
+	assert.False(t, ok)
+	assert.True(t, errors.Is(err, ErrDedupChunkReleased))
+	referenced, err := idx.hasEntries(ctx, dedupRefsDir("1,0123456789"))
+	assert.Nil(t, err)
+	assert.False(t, referenced)
+}
+
+func TestDedupFlags(t *testing.T) {
+	f, _ := newDedupTestFiler(t, "1,0123456789", []byte{1, 2})
+	ctx := context.Background()
+
+	entry := &Entry{
+		FullPath: "/dir/file",
+		Attr:     Attr{Mode: 0644},
+		Chunks: []*filer_pb.FileChunk{
+			{FileId: "1,0123456789", Size: 10, ModifiedTsNs: 1, IsDeduplicated: true},
+			{FileId: "2,0123456789", Offset: 10, Size: 10, ModifiedTsNs: 1, IsDeduplicated: true},
+		},
+	}
+	assert.Nil(t, f.CreateEntry(ctx, entry, false, false, nil, false))
+	stored, err := f.FindEntry(ctx, entry.FullPath)
+	assert.Nil(t, err)
+	assert.True(t, stored.GetChunks()[0].IsDeduplicated)
+	assert.False(t, stored.GetChunks()[1].IsDeduplicated, "not in the index")
+
+	// the client does not keep the flag
+	updated := entry.ShallowClone()
+	updated.Chunks = []*filer_pb.FileChunk{{FileId: "1,0123456789", Size: 10, ModifiedTsNs: 1}}
+	assert.Nil(t, f.UpdateEntry(ctx, stored, updated))
+	stored, err = f.FindEntry(ctx, entry.FullPath)
+	assert.Nil(t, err)
+	assert.True(t, stored.GetChunks()[0].IsDeduplicated)
+}
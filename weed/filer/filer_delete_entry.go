@@ -21,23 +21,31 @@ func (f *Filer) DeleteEntryMetaAndData(ctx context.Context, p util.FullPath, isR
 		return nil
 	}
 
+	ctx = WithFromOtherCluster(ctx, isFromOtherCluster)
 	entry, findErr := f.FindEntry(ctx, p)
 	if findErr != nil {
 		return findErr
 	}
+	if err = beforeDeleteEntry(ctx, entry); err != nil {
+		return err
+	}
 	isDeleteCollection := f.isBucket(entry)
 	if entry.IsDirectory() {
 		// delete the folder children, not including the folder itself
 		err = f.doBatchDeleteFolderMetaAndData(ctx, entry, isRecursive, ignoreRecursiveError, shouldDeleteChunks && !isDeleteCollection, isDeleteCollection, isFromOtherCluster, signatures, func(chunks []*filer_pb.FileChunk) error {
 			if shouldDeleteChunks && !isDeleteCollection {
-				f.DirectDeleteChunks(chunks)
+				AfterCommit(ctx, func() {
+					f.DirectDeleteChunks(chunks)
+				})
 			}
 			return nil
 		}, func(hardLinkIds []HardLinkId) error {
 			// A case not handled:
 			// what if the chunk is in a different collection?
 			if shouldDeleteChunks {
-				f.maybeDeleteHardLinks(hardLinkIds)
+				AfterCommit(ctx, func() {
+					f.maybeDeleteHardLinks(hardLinkIds)
+				})
 			}
 			return nil
 		})
@@ -47,21 +55,27 @@ func (f *Filer) DeleteEntryMetaAndData(ctx context.Context, p util.FullPath, isR
 		}
 	}
 
-	if shouldDeleteChunks && !isDeleteCollection {
-		f.DirectDeleteChunks(entry.GetChunks())
-	}
-
 	// delete the file or folder
 	err = f.doDeleteEntryMetaAndData(ctx, entry, shouldDeleteChunks, isFromOtherCluster, signatures)
 	if err != nil {
 		return fmt.Errorf("delete file %s: %v", p, err)
 	}
 
+	// the data is deleted after the store transaction, if any, is committed
+	if shouldDeleteChunks && !isDeleteCollection {
+		AfterCommit(ctx, func() {
+			f.DirectDeleteChunks(entry.GetChunks())
+		})
+	}
 	if isDeleteCollection {
 		collectionName := entry.Name()
-		f.doDeleteCollection(collectionName)
+		AfterCommit(ctx, func() {
+			f.doDeleteCollection(collectionName)
+		})
 	}
 
+	afterDeleteEntry(ctx, entry)
+
 	return nil
 }
 
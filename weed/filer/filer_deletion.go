@@ -1,6 +1,7 @@
 package filer
 
 import (
+	"context"
 	"github.com/seaweedfs/seaweedfs/weed/storage"
 	"math"
 	"strings"
@@ -98,7 +99,9 @@ func (f *Filer) DirectDeleteChunks(chunks []*filer_pb.FileChunk) {
 	var fileIdsToDelete []string
 	for _, chunk := range chunks {
 		if !chunk.IsChunkManifest {
-			fileIdsToDelete = append(fileIdsToDelete, chunk.GetFileIdString())
+			if f.releaseDedupChunk(chunk, false) {
+				fileIdsToDelete = append(fileIdsToDelete, chunk.GetFileIdString())
+			}
 			continue
 		}
 		dataChunks, manifestResolveErr := ResolveOneChunkManifest(f.MasterClient.LookupFileId, chunk)
@@ -106,7 +109,9 @@ func (f *Filer) DirectDeleteChunks(chunks []*filer_pb.FileChunk) {
 			glog.V(0).Infof("failed to resolve manifest %s: %v", chunk.FileId, manifestResolveErr)
 		}
 		for _, dChunk := range dataChunks {
-			fileIdsToDelete = append(fileIdsToDelete, dChunk.GetFileIdString())
+			if f.releaseDedupChunk(dChunk, chunk.IsDeduplicated) {
+				fileIdsToDelete = append(fileIdsToDelete, dChunk.GetFileIdString())
+			}
 		}
 		fileIdsToDelete = append(fileIdsToDelete, chunk.GetFileIdString())
 	}
@@ -117,7 +122,9 @@ func (f *Filer) DirectDeleteChunks(chunks []*filer_pb.FileChunk) {
 func (f *Filer) DeleteChunks(chunks []*filer_pb.FileChunk) {
 	for _, chunk := range chunks {
 		if !chunk.IsChunkManifest {
-			f.fileIdDeletionQueue.EnQueue(chunk.GetFileIdString())
+			if f.releaseDedupChunk(chunk, false) {
+				f.fileIdDeletionQueue.EnQueue(chunk.GetFileIdString())
+			}
 			continue
 		}
 		dataChunks, manifestResolveErr := ResolveOneChunkManifest(f.MasterClient.LookupFileId, chunk)
@@ -125,15 +132,23 @@ func (f *Filer) DeleteChunks(chunks []*filer_pb.FileChunk) {
 			glog.V(0).Infof("failed to resolve manifest %s: %v", chunk.FileId, manifestResolveErr)
 		}
 		for _, dChunk := range dataChunks {
-			f.fileIdDeletionQueue.EnQueue(dChunk.GetFileIdString())
+			if f.releaseDedupChunk(dChunk, chunk.IsDeduplicated) {
+				f.fileIdDeletionQueue.EnQueue(dChunk.GetFileIdString())
+			}
 		}
 		f.fileIdDeletionQueue.EnQueue(chunk.GetFileIdString())
 	}
 }
 
 func (f *Filer) DeleteChunksNotRecursive(chunks []*filer_pb.FileChunk) {
+	f.deleteChunksNotRecursive(chunks, false)
+}
+
+func (f *Filer) deleteChunksNotRecursive(chunks []*filer_pb.FileChunk, deduplicated bool) {
 	for _, chunk := range chunks {
-		f.fileIdDeletionQueue.EnQueue(chunk.GetFileIdString())
+		if f.releaseDedupChunk(chunk, deduplicated) {
+			f.fileIdDeletionQueue.EnQueue(chunk.GetFileIdString())
+		}
 	}
 }
 
@@ -156,8 +171,10 @@ func (f *Filer) deleteChunksIfNotNew(oldEntry, newEntry *Entry) {
 			newEntry.GetChunks(), oldEntry.Chunks)
 		return
 	}
+	newDedupRefs := make(map[string]bool)
 	for _, newChunk := range newDataChunks {
 		newChunkIds[newChunk.GetFileIdString()] = true
+		newDedupRefs[newChunk.GetFileIdString()+"/"+dedupRefName(newChunk)] = true
 	}
 	for _, newChunk := range newManifestChunks {
 		newChunkIds[newChunk.GetFileIdString()] = true
@@ -170,9 +187,17 @@ func (f *Filer) deleteChunksIfNotNew(oldEntry, newEntry *Entry) {
 			newEntry.GetChunks(), oldEntry.GetChunks())
 		return
 	}
+	// the data chunks of the manifest chunks are not flagged themselves
+	deduplicated := false
+	for _, oldChunk := range oldManifestChunks {
+		deduplicated = deduplicated || oldChunk.IsDeduplicated
+	}
 	for _, oldChunk := range oldDataChunks {
 		if _, found := newChunkIds[oldChunk.GetFileIdString()]; !found {
 			toDelete = append(toDelete, oldChunk)
+		} else if (deduplicated || oldChunk.IsDeduplicated) && f.DedupIndex != nil && !newDedupRefs[oldChunk.GetFileIdString()+"/"+dedupRefName(oldChunk)] {
+			// the deduplicated chunk is kept at another offset, with its own reference
+			f.DedupIndex.Unreference(context.Background(), oldChunk)
 		}
 	}
 	for _, oldChunk := range oldManifestChunks {
@@ -180,5 +205,5 @@ func (f *Filer) deleteChunksIfNotNew(oldEntry, newEntry *Entry) {
 			toDelete = append(toDelete, oldChunk)
 		}
 	}
-	f.DeleteChunksNotRecursive(toDelete)
+	f.deleteChunksNotRecursive(toDelete, deduplicated)
 }
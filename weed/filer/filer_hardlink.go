@@ -1,16 +1,34 @@
 package filer
 
 import (
+	"errors"
+
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
 const (
 	HARD_LINK_MARKER = '\x01'
+	// DefaultMaxHardLinks is the same as the ext4 limit
+	DefaultMaxHardLinks = 65535
 )
 
+var ErrTooManyLinks = errors.New("too many links")
+
 type HardLinkId []byte // 16 bytes + 1 marker byte
 
 func NewHardLinkId() HardLinkId {
 	bytes := append(util.RandomBytes(16), HARD_LINK_MARKER)
 	return bytes
 }
+
+// checkHardLinkLimit refuses to add a hard link beyond MaxHardLinks.
+// Decreasing or keeping the counter is always allowed, to fix the entries already over the limit.
+func (f *Filer) checkHardLinkLimit(oldEntry, entry *Entry) error {
+	if len(entry.HardLinkId) == 0 || f.MaxHardLinks <= 0 || entry.HardLinkCounter <= f.MaxHardLinks {
+		return nil
+	}
+	if oldEntry != nil && entry.HardLinkCounter <= oldEntry.HardLinkCounter {
+		return nil
+	}
+	return ErrTooManyLinks
+}
@@ -0,0 +1,354 @@
+package filer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	// InodeQuotaXattr set on a directory, e.g., by "setfattr -n quota-inode -v 100000 <dir>" on a mount,
+	// limits the number of files and directories under it, at any depth
+	InodeQuotaXattr = "xattr-quota-inode"
+
+	inodeQuotaRootsKey      = "__inode_quota_roots__"
+	inodeQuotaCounterRoot   = "/.seaweedfs.inode-quota"
+	inodeQuotaCountKey      = "count"
+	inodeQuotaRootsReloadAt = 30 * time.Second
+	inodeQuotaListLimit     = 1024
+)
+
+var ErrInodeQuotaExceeded = filer_pb.ErrInodeQuotaExceeded
+
+// InodeQuota counts the files and directories under the quota roots, the directories with InodeQuotaXattr,
+// and rejects creating entries under a quota root having reached its limit. It is a FilerPlugin.
+//
+// Each filer adds the entries it creates and deletes to its own counter record of the quota root, in a store
+// directory per quota root under inodeQuotaCounterRoot, and the count is the sum of the records, so the filers
+// sharing a store do not overwrite the changes of each other. The changes replayed from the peer filers with
+// their own stores are counted by CountReplay. When a quota root is added, the existing entries are counted
+// by the usage walk in the background, and the quota root is only counted and limited after that.
+// The limit is checked before the entry is created, so concurrent creations may go slightly over the limit.
+type InodeQuota struct {
+	filer       *Filer
+	counterName string
+
+	sync.Mutex
+	// quota root => the existing entries are counted
+	roots       map[util.FullPath]bool
+	rootsLoaded time.Time
+	// deleted directory => the entries under it, counted before the deletion
+	deleting map[util.FullPath]int64
+
+	// guards the read-modify-write of the counter records of this filer
+	counterLock sync.Mutex
+}
+
+var _ = FilerPlugin(&InodeQuota{})
+
+func NewInodeQuota(f *Filer, self pb.ServerAddress) *InodeQuota {
+	counterName := string(self)
+	if counterName == "" {
+		counterName = "localhost"
+	}
+	return &InodeQuota{
+		filer:       f,
+		counterName: counterName,
+		deleting:    make(map[util.FullPath]int64),
+	}
+}
+
+// IsOverInodeQuota checks whether the directory, or one of its parents, has an inode quota already reached.
+func (f *Filer) IsOverInodeQuota(ctx context.Context, dir util.FullPath) bool {
+	if f.InodeQuota == nil {
+		return false
+	}
+	return f.InodeQuota.isOver(ctx, dir, "")
+}
+
+// isOver skips the quota roots containing the rename source, since moving an entry within them keeps the count
+func (q *InodeQuota) isOver(ctx context.Context, dir util.FullPath, renameSource util.FullPath) bool {
+	for _, root := range q.rootsOf(ctx, dir, true) {
+		if renameSource != "" && renameSource.IsUnder(root) {
+			continue
+		}
+		entry, err := q.filer.FindEntry(ctx, root)
+		if err != nil {
+			continue
+		}
+		limit := entry.GetInodeQuota()
+		if limit == 0 {
+			continue
+		}
+		count, err := q.count(ctx, root)
+		if err != nil {
+			glog.Warningf("count inodes under %s: %v", root, err)
+			continue
+		}
+		if count >= int64(limit) {
+			glog.V(1).Infof("inode quota of %s: %d >= %d", root, count, limit)
+			return true
+		}
+	}
+	return false
+}
+
+func (q *InodeQuota) BeforeCreateEntry(ctx context.Context, entry *Entry) error {
+	// the changes replicated by filer.sync are already accepted by the other cluster, and are only counted
+	if IsFromOtherCluster(ctx) {
+		return nil
+	}
+	dir, _ := entry.FullPath.DirAndName()
+	if q.isOver(ctx, util.FullPath(dir), RenameSource(ctx)) {
+		return fmt.Errorf("create %s: %w", entry.FullPath, ErrInodeQuotaExceeded)
+	}
+	return nil
+}
+
+func (q *InodeQuota) AfterCreateEntry(ctx context.Context, entry *Entry) {
+	q.onChange(ctx, nil, entry)
+	dir, _ := entry.FullPath.DirAndName()
+	q.addInodes(ctx, util.FullPath(dir), 1)
+}
+
+func (q *InodeQuota) BeforeUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) error {
+	return nil
+}
+
+func (q *InodeQuota) AfterUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) {
+	q.onChange(ctx, oldEntry, newEntry)
+}
+
+// BeforeDeleteEntry counts the entries under a deleted directory, which are removed without calling the hooks
+func (q *InodeQuota) BeforeDeleteEntry(ctx context.Context, entry *Entry) error {
+	removed := q.countRemoved(ctx, entry)
+	q.Lock()
+	defer q.Unlock()
+	q.deleting[entry.FullPath] = removed
+	return nil
+}
+
+func (q *InodeQuota) AfterDeleteEntry(ctx context.Context, entry *Entry) {
+	q.Lock()
+	removed, found := q.deleting[entry.FullPath]
+	delete(q.deleting, entry.FullPath)
+	q.Unlock()
+	if !found {
+		removed = 1
+	}
+	q.onChange(ctx, entry, nil)
+	dir, _ := entry.FullPath.DirAndName()
+	q.addInodes(ctx, util.FullPath(dir), -removed)
+}
+
+// countRemoved returns the number of entries removed by deleting the entry, including the entries under it
+func (q *InodeQuota) countRemoved(ctx context.Context, entry *Entry) int64 {
+	if !entry.IsDirectory() || len(q.rootsOf(ctx, entry.FullPath, false)) == 0 {
+		return 1
+	}
+	usage, err := q.filer.walkDirectoryUsage(ctx, entry.FullPath)
+	if err != nil {
+		glog.Warningf("count inodes under deleted %s: %v", entry.FullPath, err)
+		return 1
+	}
+	return 1 + int64(usage.Inodes)
+}
+
+// CountReplay counts a change from a peer filer with its own store, before it is replayed
+// to the store without calling the plugins. Deleting a directory on the peer filer
+// sends the events of the entries under it first, and the directory may be sent twice.
+func (q *InodeQuota) CountReplay(ctx context.Context, resp *filer_pb.SubscribeMetadataResponse) {
+	message := resp.EventNotification
+	var oldEntry, newEntry *Entry
+	if message.OldEntry != nil {
+		oldEntry = FromPbEntry(resp.Directory, message.OldEntry)
+	}
+	if message.NewEntry != nil {
+		dir := resp.Directory
+		if message.NewParentPath != "" {
+			dir = message.NewParentPath
+		}
+		newEntry = FromPbEntry(dir, message.NewEntry)
+	}
+	q.onChange(ctx, oldEntry, newEntry)
+
+	if oldEntry != nil && (newEntry == nil || newEntry.FullPath != oldEntry.FullPath) {
+		if existing, err := q.filer.FindEntry(ctx, oldEntry.FullPath); err == nil {
+			dir, _ := oldEntry.FullPath.DirAndName()
+			q.addInodes(ctx, util.FullPath(dir), -q.countRemoved(ctx, existing))
+		}
+	}
+	if newEntry != nil && (oldEntry == nil || newEntry.FullPath != oldEntry.FullPath) {
+		if _, err := q.filer.FindEntry(ctx, newEntry.FullPath); err == filer_pb.ErrNotFound {
+			dir, _ := newEntry.FullPath.DirAndName()
+			q.addInodes(ctx, util.FullPath(dir), 1)
+		}
+	}
+}
+
+func (q *InodeQuota) onChange(ctx context.Context, oldEntry, newEntry *Entry) {
+	wasRoot, isRoot := oldEntry.GetInodeQuota() > 0, newEntry.GetInodeQuota() > 0
+	switch {
+	case isRoot && !wasRoot:
+		q.enable(ctx, newEntry.FullPath)
+	case wasRoot && !isRoot:
+		q.disable(ctx, oldEntry.FullPath)
+	}
+}
+
+func (q *InodeQuota) counterDir(root util.FullPath) util.FullPath {
+	return util.JoinPath(inodeQuotaCounterRoot, string(root))
+}
+
+// count sums the counter records of all the filers
+func (q *InodeQuota) count(ctx context.Context, root util.FullPath) (int64, error) {
+	var count int64
+	lastFileName := ""
+	for {
+		listed := 0
+		_, err := q.filer.Store.ListDirectoryEntries(ctx, q.counterDir(root), lastFileName, false, inodeQuotaListLimit, func(record *Entry) bool {
+			listed++
+			lastFileName = record.Name()
+			n, _ := strconv.ParseInt(string(record.Extended[inodeQuotaCountKey]), 10, 64)
+			count += n
+			return true
+		})
+		if err != nil {
+			return 0, err
+		}
+		if listed < inodeQuotaListLimit {
+			return count, nil
+		}
+	}
+}
+
+// addInodes adds delta to the counter records of this filer, for the counted quota roots containing the directory.
+func (q *InodeQuota) addInodes(ctx context.Context, dir util.FullPath, delta int64) {
+	for _, root := range q.rootsOf(ctx, dir, true) {
+		if err := q.addInodesTo(ctx, root, delta); err != nil {
+			glog.Warningf("update inode count of %s: %v", root, err)
+		}
+	}
+}
+
+func (q *InodeQuota) addInodesTo(ctx context.Context, root util.FullPath, delta int64) error {
+	q.counterLock.Lock()
+	defer q.counterLock.Unlock()
+
+	recordPath := q.counterDir(root).Child(q.counterName)
+	var count int64
+	record, err := q.filer.Store.FindEntry(ctx, recordPath)
+	if err == nil {
+		count, _ = strconv.ParseInt(string(record.Extended[inodeQuotaCountKey]), 10, 64)
+	} else if err != filer_pb.ErrNotFound {
+		return err
+	}
+	now := time.Now()
+	// the records are written to the store directly, without the parent directories, and are not listed in the filer
+	return q.filer.Store.InsertEntry(ctx, &Entry{
+		FullPath: recordPath,
+		Attr:     Attr{Mtime: now, Crtime: now, Mode: 0644},
+		Extended: map[string][]byte{inodeQuotaCountKey: []byte(strconv.FormatInt(count+delta, 10))},
+	})
+}
+
+// rootsOf returns the counted quota roots containing the path, the outermost first.
+func (q *InodeQuota) rootsOf(ctx context.Context, p util.FullPath, inclusive bool) (roots []util.FullPath) {
+	q.Lock()
+	defer q.Unlock()
+	q.maybeLoadRoots(ctx)
+
+	for root, counted := range q.roots {
+		if counted && (inclusive && root == p || p.IsUnder(root)) {
+			roots = append(roots, root)
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return len(roots[i]) < len(roots[j])
+	})
+	return roots
+}
+
+// maybeLoadRoots reloads the quota roots, which may be changed by other filers
+func (q *InodeQuota) maybeLoadRoots(ctx context.Context) {
+	if q.roots != nil && time.Since(q.rootsLoaded) < inodeQuotaRootsReloadAt {
+		return
+	}
+	roots := make(map[util.FullPath]bool)
+	data, err := q.filer.Store.KvGet(ctx, []byte(inodeQuotaRootsKey))
+	if err == nil && len(data) > 0 {
+		err = json.Unmarshal(data, &roots)
+	}
+	if err != nil && err != ErrKvNotFound {
+		glog.Warningf("load inode quota roots: %v", err)
+		if q.roots != nil {
+			return
+		}
+	}
+	q.roots, q.rootsLoaded = roots, time.Now()
+}
+
+func (q *InodeQuota) saveRoots(ctx context.Context) {
+	data, err := json.Marshal(q.roots)
+	if err == nil {
+		err = q.filer.Store.KvPut(ctx, []byte(inodeQuotaRootsKey), data)
+	}
+	if err != nil {
+		glog.Warningf("save inode quota roots: %v", err)
+	}
+}
+
+// enable counts the existing entries under the quota root in the background, replacing the records of an earlier count
+func (q *InodeQuota) enable(ctx context.Context, root util.FullPath) {
+	glog.V(0).Infof("enable inode quota on %s", root)
+	q.Lock()
+	q.maybeLoadRoots(ctx)
+	q.roots[root] = false
+	q.saveRoots(ctx)
+	q.Unlock()
+
+	go func() {
+		ctx := context.Background()
+		if err := q.filer.Store.DeleteFolderChildren(ctx, q.counterDir(root)); err != nil {
+			glog.Errorf("reset inode count of %s: %v", root, err)
+			return
+		}
+		usage, err := q.filer.walkDirectoryUsage(ctx, root)
+		if err != nil {
+			glog.Errorf("count inodes under %s: %v", root, err)
+			return
+		}
+		if err = q.addInodesTo(ctx, root, int64(usage.Inodes)); err != nil {
+			glog.Errorf("save inode count of %s: %v", root, err)
+			return
+		}
+
+		q.Lock()
+		defer q.Unlock()
+		q.maybeLoadRoots(ctx)
+		if _, found := q.roots[root]; !found {
+			return
+		}
+		q.roots[root] = true
+		q.saveRoots(ctx)
+		glog.V(0).Infof("counted %d inodes under %s", usage.Inodes, root)
+	}()
+}
+
+func (q *InodeQuota) disable(ctx context.Context, root util.FullPath) {
+	glog.V(0).Infof("disable inode quota on %s", root)
+	q.Lock()
+	defer q.Unlock()
+	q.maybeLoadRoots(ctx)
+	delete(q.roots, root)
+	q.saveRoots(ctx)
+}
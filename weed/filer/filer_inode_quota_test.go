@@ -0,0 +1,192 @@
+package filer
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXAttrInodeCount(t *testing.T) {
+	shared := map[string][]byte{InodeQuotaXattr: []byte("100")}
+	dir := &Entry{FullPath: "/quota", Attr: Attr{Mode: os.ModeDir}, Extended: shared}
+	_, found := dir.GetXAttrInodeCount()
+	assert.False(t, found)
+	assert.Equal(t, uint64(100), dir.GetInodeQuota())
+
+	dir.SetXAttrInodeCount(42)
+	count, found := dir.GetXAttrInodeCount()
+	assert.True(t, found)
+	assert.Equal(t, uint64(42), count)
+	assert.NotContains(t, shared, DirUsageInodeKey, "the shared extended attributes are not changed")
+
+	file := &Entry{FullPath: "/quota/file", Extended: shared}
+	assert.Equal(t, uint64(0), file.GetInodeQuota(), "only directories have inode quota")
+	var missing *Entry
+	assert.Equal(t, uint64(0), missing.GetInodeQuota())
+}
+
+func TestInodeQuotaRootsOf(t *testing.T) {
+	q := NewInodeQuota(nil, "")
+	q.roots = map[util.FullPath]bool{
+		"/a/b":   false,
+		"/a":     true,
+		"/a/b/c": true,
+		"/other": true,
+	}
+	q.rootsLoaded = time.Now()
+	ctx := context.Background()
+
+	assert.Equal(t, []util.FullPath{"/a", "/a/b/c"}, q.rootsOf(ctx, "/a/b/c/d", true), "only the counted roots")
+	assert.Equal(t, []util.FullPath{"/a"}, q.rootsOf(ctx, "/a/b/c", false))
+	assert.Empty(t, q.rootsOf(ctx, "/ab", true))
+}
+
+func newInodeQuotaTestFiler(t *testing.T, limit string) *Filer {
+	f := NewFiler(nil, nil, "", "", "", "", "", nil)
+	f.SetStore(newMemoryStore())
+	ctx := context.Background()
+	assert.Nil(t, f.Store.InsertEntry(ctx, &Entry{
+		FullPath: "/q",
+		Attr:     Attr{Mode: os.ModeDir | 0755},
+		Extended: map[string][]byte{InodeQuotaXattr: []byte(limit)},
+	}))
+	return f
+}
+
+func createWithInodeQuota(ctx context.Context, f *Filer, q *InodeQuota, p util.FullPath, isDirectory bool) error {
+	entry := &Entry{FullPath: p, Attr: Attr{Mode: 0644}}
+	if isDirectory {
+		entry.Mode = os.ModeDir | 0755
+	}
+	if err := q.BeforeCreateEntry(ctx, entry); err != nil {
+		return err
+	}
+	if err := f.Store.InsertEntry(ctx, entry); err != nil {
+		return err
+	}
+	q.AfterCreateEntry(ctx, entry)
+	return nil
+}
+
+func deleteWithInodeQuota(ctx context.Context, f *Filer, q *InodeQuota, p util.FullPath) error {
+	entry, err := f.Store.FindEntry(ctx, p)
+	if err != nil {
+		return err
+	}
+	if err = q.BeforeDeleteEntry(ctx, entry); err != nil {
+		return err
+	}
+	if err = f.Store.DeleteFolderChildren(ctx, p); err != nil {
+		return err
+	}
+	if err = f.Store.DeleteEntry(ctx, p); err != nil {
+		return err
+	}
+	q.AfterDeleteEntry(ctx, entry)
+	return nil
+}
+
+func TestInodeQuotaCount(t *testing.T) {
+	f := newInodeQuotaTestFiler(t, "3")
+	ctx := context.Background()
+	assert.Nil(t, f.Store.KvPut(ctx, []byte(inodeQuotaRootsKey), []byte(`{"/q": true}`)))
+	count := func() int64 {
+		n, err := NewInodeQuota(f, "").count(ctx, "/q")
+		assert.Nil(t, err)
+		return n
+	}
+
+	// two filers sharing the store keep their own counter records
+	q1, q2 := NewInodeQuota(f, "filer1:8888"), NewInodeQuota(f, "filer2:8888")
+	assert.Nil(t, createWithInodeQuota(ctx, f, q1, "/q/a", false))
+	assert.Nil(t, createWithInodeQuota(ctx, f, q2, "/q/b", false))
+	assert.Nil(t, createWithInodeQuota(ctx, f, q2, "/q/d", true))
+	assert.Equal(t, int64(3), count())
+
+	err := createWithInodeQuota(ctx, f, q1, "/q/e", false)
+	assert.ErrorIs(t, err, ErrInodeQuotaExceeded)
+	assert.True(t, f.IsOverInodeQuota(ctx, "/q/d"))
+
+	// a rename within the quota root is not rejected, but a rename into it is
+	assert.Nil(t, createWithInodeQuota(WithRenameSource(ctx, "/q/a"), f, q1, "/q/d/a", false))
+	assert.Nil(t, deleteWithInodeQuota(ctx, f, q1, "/q/a"))
+	assert.Equal(t, int64(3), count())
+	err = createWithInodeQuota(WithRenameSource(ctx, "/other/x"), f, q1, "/q/x", false)
+	assert.ErrorIs(t, err, ErrInodeQuotaExceeded)
+
+	// the changes replicated by filer.sync are counted, and not rejected
+	assert.Nil(t, createWithInodeQuota(WithFromOtherCluster(ctx, true), f, q2, "/q/f", false))
+	assert.Equal(t, int64(4), count())
+
+	// deleting a directory removes the entries under it
+	assert.Nil(t, deleteWithInodeQuota(ctx, f, q1, "/q/d"))
+	assert.Equal(t, int64(2), count())
+
+	// the records are not listed in the filer
+	var names []string
+	_, err = f.StreamListDirectoryEntries(ctx, "/", "", false, 100, "", "", "", func(entry *Entry) bool {
+		names = append(names, entry.Name())
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"q"}, names)
+}
+
+func TestInodeQuotaCountReplay(t *testing.T) {
+	f := newInodeQuotaTestFiler(t, "100")
+	ctx := context.Background()
+	assert.Nil(t, f.Store.KvPut(ctx, []byte(inodeQuotaRootsKey), []byte(`{"/q": true}`)))
+	q := NewInodeQuota(f, "filer1:8888")
+
+	// the events from a peer filer are counted once, even if sent again
+	replay := func(resp *filer_pb.SubscribeMetadataResponse) {
+		q.CountReplay(ctx, resp)
+		assert.Nil(t, Replay(f.Store, resp))
+	}
+	dir := &filer_pb.Entry{Name: "dir", IsDirectory: true, Attributes: &filer_pb.FuseAttributes{FileMode: uint32(os.ModeDir | 0755)}}
+	file := &filer_pb.Entry{Name: "file", Attributes: &filer_pb.FuseAttributes{FileMode: 0644}}
+	createDir := &filer_pb.SubscribeMetadataResponse{Directory: "/q", EventNotification: &filer_pb.EventNotification{NewEntry: dir}}
+	createFile := &filer_pb.SubscribeMetadataResponse{Directory: "/q/dir", EventNotification: &filer_pb.EventNotification{NewEntry: file}}
+	replay(createDir)
+	replay(createFile)
+	replay(createFile)
+	count, err := q.count(ctx, "/q")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// a directory deleted on the peer filer sends the entries under it, and the directory twice
+	deleteFile := &filer_pb.SubscribeMetadataResponse{Directory: "/q/dir", EventNotification: &filer_pb.EventNotification{OldEntry: file}}
+	deleteDir := &filer_pb.SubscribeMetadataResponse{Directory: "/q", EventNotification: &filer_pb.EventNotification{OldEntry: dir}}
+	replay(deleteFile)
+	replay(deleteDir)
+	replay(deleteDir)
+	count, err = q.count(ctx, "/q")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestInodeQuotaEnable(t *testing.T) {
+	f := newInodeQuotaTestFiler(t, "100")
+	ctx := context.Background()
+	for _, p := range []util.FullPath{"/q/a", "/q/sub/b"} {
+		assert.Nil(t, f.CreateEntry(ctx, &Entry{FullPath: p, Attr: Attr{Mode: 0644}}, false, false, nil, false))
+	}
+	q := NewInodeQuota(f, "filer1:8888")
+	// a record left from an earlier count
+	assert.Nil(t, q.addInodesTo(ctx, "/q", 42))
+
+	root, err := f.Store.FindEntry(ctx, "/q")
+	assert.Nil(t, err)
+	q.AfterUpdateEntry(ctx, &Entry{FullPath: "/q", Attr: root.Attr}, root)
+	assert.Eventually(t, func() bool {
+		return len(q.rootsOf(ctx, "/q/a", true)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	count, err := q.count(ctx, "/q")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count, "a, sub, and sub/b")
+}
@@ -0,0 +1,107 @@
+package filer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// mimeSniffLen is the number of bytes http.DetectContentType considers
+const mimeSniffLen = 512
+
+// MimeDetector guesses the mime type of the files without one from their first bytes,
+// and saves it to the entry in the background, so later reads skip the detection.
+type MimeDetector struct {
+	filer *Filer
+
+	inFlightLock sync.Mutex
+	inFlight     map[util.FullPath]bool
+}
+
+func NewMimeDetector(f *Filer) *MimeDetector {
+	return &MimeDetector{
+		filer:    f,
+		inFlight: make(map[util.FullPath]bool),
+	}
+}
+
+// Detect returns the mime type of the entry content, or "" if it can not be detected.
+func (d *MimeDetector) Detect(entry *Entry) string {
+	if entry.IsDirectory() || entry.SymlinkTarget != "" || entry.Mime != "" {
+		return ""
+	}
+	data, err := d.readHead(entry)
+	if err != nil {
+		glog.V(1).Infof("detect mime of %s: %v", entry.FullPath, err)
+		return ""
+	}
+	if len(data) == 0 {
+		return ""
+	}
+
+	mimeType := http.DetectContentType(data)
+	d.writeBack(entry, mimeType)
+	return mimeType
+}
+
+// readHead reads the first bytes of the file, up to mimeSniffLen.
+// The file size may be larger than the inline content, e.g., after a truncate to extend the file.
+func (d *MimeDetector) readHead(entry *Entry) ([]byte, error) {
+	size := entry.Size()
+	if size == 0 {
+		return nil, nil
+	}
+	if size > mimeSniffLen {
+		size = mimeSniffLen
+	}
+	if len(entry.Content) > 0 {
+		if size > uint64(len(entry.Content)) {
+			size = uint64(len(entry.Content))
+		}
+		return entry.Content[:size], nil
+	}
+	data := make([]byte, size)
+	reader := NewChunkStreamReaderFromFiler(d.filer.MasterClient, entry.GetChunks())
+	defer reader.Close()
+	n, err := io.ReadFull(reader, data)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+// writeBack saves the detected mime type, unless the file is changed meanwhile.
+func (d *MimeDetector) writeBack(entry *Entry, mimeType string) {
+	d.inFlightLock.Lock()
+	if d.inFlight[entry.FullPath] {
+		d.inFlightLock.Unlock()
+		return
+	}
+	d.inFlight[entry.FullPath] = true
+	d.inFlightLock.Unlock()
+
+	go func() {
+		defer func() {
+			d.inFlightLock.Lock()
+			delete(d.inFlight, entry.FullPath)
+			d.inFlightLock.Unlock()
+		}()
+
+		ctx := context.Background()
+		current, err := d.filer.FindEntry(ctx, entry.FullPath)
+		if err != nil || current.Mime != "" || !current.Mtime.Equal(entry.Mtime) || ETagEntry(current) != ETagEntry(entry) {
+			return
+		}
+		newEntry := current.ShallowClone()
+		newEntry.Mime = mimeType
+		if err := d.filer.UpdateEntry(ctx, current, newEntry); err != nil {
+			glog.V(1).Infof("save mime %s of %s: %v", mimeType, entry.FullPath, err)
+			return
+		}
+		d.filer.NotifyUpdateEvent(ctx, current, newEntry, false, false, nil)
+	}()
+}
@@ -0,0 +1,39 @@
+package filer
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMimeDetectorSkips(t *testing.T) {
+	d := NewMimeDetector(nil)
+
+	dir := &Entry{FullPath: "/dir", Attr: Attr{Mode: os.ModeDir | 0755}}
+	assert.Equal(t, "", d.Detect(dir))
+
+	symlink := &Entry{FullPath: "/link", Attr: Attr{SymlinkTarget: "/file"}, Content: []byte("<html></html>")}
+	assert.Equal(t, "", d.Detect(symlink))
+
+	withMime := &Entry{FullPath: "/file", Attr: Attr{Mime: "text/plain"}, Content: []byte("<html></html>")}
+	assert.Equal(t, "", d.Detect(withMime))
+
+	empty := &Entry{FullPath: "/empty"}
+	assert.Equal(t, "", d.Detect(empty))
+}
+
+func TestMimeDetectorReadHead(t *testing.T) {
+	d := NewMimeDetector(nil)
+
+	// the file is extended past the inline content
+	extended := &Entry{FullPath: "/extended", Attr: Attr{FileSize: 4096}, Content: []byte("<html></html>")}
+	data, err := d.readHead(extended)
+	assert.Nil(t, err)
+	assert.Equal(t, "<html></html>", string(data))
+
+	large := &Entry{FullPath: "/large", Content: make([]byte, 2*mimeSniffLen)}
+	data, err = d.readHead(large)
+	assert.Nil(t, err)
+	assert.Equal(t, mimeSniffLen, len(data))
+}
@@ -17,7 +17,15 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
+// NotifyUpdateEvent publishes the metadata event. In a store transaction with WithAfterCommit,
+// the event is published after the transaction is committed.
 func (f *Filer) NotifyUpdateEvent(ctx context.Context, oldEntry, newEntry *Entry, deleteChunks, isFromOtherCluster bool, signatures []int32) {
+	AfterCommit(ctx, func() {
+		f.notifyUpdateEvent(ctx, oldEntry, newEntry, deleteChunks, isFromOtherCluster, signatures)
+	})
+}
+
+func (f *Filer) notifyUpdateEvent(ctx context.Context, oldEntry, newEntry *Entry, deleteChunks, isFromOtherCluster bool, signatures []int32) {
 	var fullpath string
 	if oldEntry != nil {
 		fullpath = string(oldEntry.FullPath)
@@ -63,6 +71,8 @@ func (f *Filer) NotifyUpdateEvent(ctx context.Context, oldEntry, newEntry *Entry
 		}
 	}
 
+	f.RecentEvents.Add(oldEntry, newEntry)
+
 	f.logMetaEvent(ctx, fullpath, eventNotification)
 
 }
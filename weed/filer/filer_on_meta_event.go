@@ -61,10 +61,12 @@ func (f *Filer) readEntry(chunks []*filer_pb.FileChunk, size uint64) ([]byte, er
 func (f *Filer) reloadFilerConfiguration(entry *filer_pb.Entry) {
 	fc := NewFilerConf()
 	err := fc.loadFromChunks(f, entry.Content, entry.GetChunks(), FileSize(entry))
+	f.filerConfReload.record(err)
 	if err != nil {
-		glog.Errorf("read filer conf chunks: %v", err)
+		glog.Errorf("read filer conf chunks, keep the previous filer conf: %v", err)
 		return
 	}
+	glog.V(0).Infof("reloaded %s/%s", DirectoryEtcSeaweedFS, FilerConfName)
 	f.FilerConf = fc
 }
 
@@ -73,6 +75,7 @@ func (f *Filer) LoadFilerConf() {
 	err := util.Retry("loadFilerConf", func() error {
 		return fc.loadFromFiler(f)
 	})
+	f.filerConfReload.record(err)
 	if err != nil {
 		glog.Errorf("read filer conf: %v", err)
 		return
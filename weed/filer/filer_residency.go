@@ -0,0 +1,73 @@
+package filer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// ErrNoPolicy is returned when no volume is available in the locations allowed by the residency policy
+var ErrNoPolicy = errors.New("ENOPOLICY")
+
+// ResidencyRule restricts the chunks of the files under the path prefix to the volume servers
+// in the data centers, or racks. An empty list allows any data center, or rack.
+type ResidencyRule struct {
+	Prefix      string   `mapstructure:"prefix"`
+	DataCenters []string `mapstructure:"data_centers"`
+	Racks       []string `mapstructure:"racks"`
+}
+
+// ResidencyPolicy is loaded from the [residency] section of filer.toml, e.g.,
+//
+//	[[residency.rules]]
+//	prefix = "/buckets/eu-data/"
+//	data_centers = ["eu-west", "eu-central"]
+//
+// The longest matching prefix applies.
+type ResidencyPolicy struct {
+	rules []*ResidencyRule
+}
+
+func LoadResidencyPolicy(v *util.ViperProxy) (*ResidencyPolicy, error) {
+	var rules []*ResidencyRule
+	if err := v.UnmarshalKey("residency.rules", &rules); err != nil {
+		return nil, fmt.Errorf("parse residency rules: %v", err)
+	}
+	return NewResidencyPolicy(rules)
+}
+
+func NewResidencyPolicy(rules []*ResidencyRule) (*ResidencyPolicy, error) {
+	for _, rule := range rules {
+		if !strings.HasPrefix(rule.Prefix, "/") {
+			return nil, fmt.Errorf("residency rule prefix %q should start with /", rule.Prefix)
+		}
+		if len(rule.DataCenters) == 0 && len(rule.Racks) == 0 {
+			return nil, fmt.Errorf("residency rule %s has neither data_centers nor racks", rule.Prefix)
+		}
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].Prefix) > len(rules[j].Prefix)
+	})
+	return &ResidencyPolicy{rules: rules}, nil
+}
+
+// Residency returns the locations allowed for the file, or nil if the file is not restricted
+func (p *ResidencyPolicy) Residency(path string) *operation.Residency {
+	if p == nil {
+		return nil
+	}
+	for _, rule := range p.rules {
+		if strings.HasPrefix(path, rule.Prefix) {
+			return &operation.Residency{
+				Path:        path,
+				DataCenters: rule.DataCenters,
+				Racks:       rule.Racks,
+			}
+		}
+	}
+	return nil
+}
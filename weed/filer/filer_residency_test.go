@@ -0,0 +1,87 @@
+package filer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+)
+
+func TestResidencyPolicy(t *testing.T) {
+	policy, err := NewResidencyPolicy([]*ResidencyRule{
+		{Prefix: "/buckets/eu/", DataCenters: []string{"eu-west", "eu-central"}},
+		{Prefix: "/buckets/eu/rack-pinned/", Racks: []string{"rack1"}},
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, policy.Residency("/buckets/us/file"))
+	assert.Nil(t, policy.Residency("/buckets/eu"))
+
+	residency := policy.Residency("/buckets/eu/dir/file")
+	assert.Equal(t, []string{"eu-west", "eu-central"}, residency.DataCenters)
+	assert.Equal(t, "/buckets/eu/dir/file", residency.Path)
+
+	// the longest prefix applies
+	residency = policy.Residency("/buckets/eu/rack-pinned/file")
+	assert.Empty(t, residency.DataCenters)
+	assert.Equal(t, []string{"rack1"}, residency.Racks)
+
+	var noPolicy *ResidencyPolicy
+	assert.Nil(t, noPolicy.Residency("/buckets/eu/file"))
+
+	_, err = NewResidencyPolicy([]*ResidencyRule{{Prefix: "buckets/eu/", DataCenters: []string{"eu-west"}}})
+	assert.NotNil(t, err, "relative prefix")
+	_, err = NewResidencyPolicy([]*ResidencyRule{{Prefix: "/buckets/eu/"}})
+	assert.NotNil(t, err, "no locations")
+}
+
+func TestResidencyAssignRequests(t *testing.T) {
+	so := &operation.StorageOption{
+		Collection: "eu",
+		DataCenter: "eu-central",
+		DataNode:   "10.0.0.1:8080",
+		Residency: &operation.Residency{
+			DataCenters: []string{"eu-west", "eu-central"},
+			Racks:       []string{"rack1", "rack2"},
+		},
+	}
+	requests := so.ToResidencyAssignRequests(1)
+	assert.Equal(t, 4, len(requests))
+	// the requested data center first, and no fallback to any location
+	assert.Equal(t, "eu-central", requests[0].DataCenter)
+	assert.Equal(t, "rack1", requests[0].Rack)
+	assert.Equal(t, "eu-west", requests[3].DataCenter)
+	assert.Equal(t, "rack2", requests[3].Rack)
+	for _, request := range requests {
+		assert.Equal(t, "eu", request.Collection)
+		assert.Empty(t, request.DataNode)
+		assert.NotEmpty(t, request.DataCenter)
+		assert.NotEmpty(t, request.Rack)
+	}
+
+	assert.Empty(t, so.Residency.Violations(&operation.AssignResult{DataCenter: "eu-west", Rack: "rack1"}))
+	assert.Equal(t, []string{"dataCenter:us-east"}, so.Residency.Violations(&operation.AssignResult{
+		DataCenter: "eu-west",
+		Rack:       "rack1",
+		Replicas:   []operation.Location{{DataCenter: "eu-central", Rack: "rack2"}, {DataCenter: "us-east", Rack: "rack1"}},
+	}))
+}
+
+func TestResidencyRackViolations(t *testing.T) {
+	residency := &operation.Residency{Racks: []string{"rack1", "rack2"}}
+
+	assert.Empty(t, residency.Violations(&operation.AssignResult{
+		DataCenter: "dc1",
+		Rack:       "rack1",
+		Replicas:   []operation.Location{{DataCenter: "dc2", Rack: "rack2"}},
+	}))
+	// a replica in another rack, e.g., with the replication 010
+	assert.Equal(t, []string{"rack:rack3"}, residency.Violations(&operation.AssignResult{
+		DataCenter: "dc1",
+		Rack:       "rack1",
+		Replicas:   []operation.Location{{DataCenter: "dc1", Rack: "rack3"}},
+	}))
+	// the rack is unknown to an older master
+	assert.Equal(t, []string{"rack:"}, residency.Violations(&operation.AssignResult{DataCenter: "dc1"}))
+}
@@ -0,0 +1,80 @@
+package filer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// MaxSortedListingEntries caps the entries of a directory sorted in memory
+const MaxSortedListingEntries = 100000
+
+var (
+	ErrTooManyEntriesToSort = fmt.Errorf("more than %d entries to sort", MaxSortedListingEntries)
+	ErrUnknownSort          = errors.New("sort should be one of name, size, mtime, ctime")
+)
+
+// ListSortedDirectoryEntries lists all entries of the directory, sorted by "name", "size", "mtime", or "ctime",
+// and returns the limit entries after skipping offset entries.
+func (f *Filer) ListSortedDirectoryEntries(ctx context.Context, p util.FullPath, sortBy string, desc bool, offset, limit int, namePattern string, namePatternExclude string) (entries []*Entry, hasMore bool, err error) {
+	less, err := entryLessFunc(sortBy)
+	if err != nil {
+		return nil, false, err
+	}
+
+	entries, tooMany, err := f.ListDirectoryEntries(ctx, p, "", false, MaxSortedListingEntries, "", namePattern, namePatternExclude)
+	if err != nil {
+		return nil, false, err
+	}
+	if tooMany {
+		return nil, false, ErrTooManyEntriesToSort
+	}
+
+	sortEntries(entries, less, desc)
+
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(entries) {
+		return nil, false, nil
+	}
+	entries = entries[offset:]
+	if len(entries) > limit {
+		return entries[:limit], true, nil
+	}
+	return entries, false, nil
+}
+
+// sortEntries sorts the entries, with the names breaking the ties
+func sortEntries(entries []*Entry, less func(a, b *Entry) bool, desc bool) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return a.Name() < b.Name()
+	})
+}
+
+func entryLessFunc(sortBy string) (func(a, b *Entry) bool, error) {
+	switch sortBy {
+	case "name":
+		return func(a, b *Entry) bool { return a.Name() < b.Name() }, nil
+	case "size":
+		return func(a, b *Entry) bool { return a.Size() < b.Size() }, nil
+	case "mtime":
+		return func(a, b *Entry) bool { return a.Attr.Mtime.Before(b.Attr.Mtime) }, nil
+	case "ctime":
+		return func(a, b *Entry) bool { return a.Attr.Crtime.Before(b.Attr.Crtime) }, nil
+	}
+	return nil, ErrUnknownSort
+}
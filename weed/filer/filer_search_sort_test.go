@@ -0,0 +1,50 @@
+package filer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestSortEntries(t *testing.T) {
+	now := time.Now()
+	newEntry := func(name string, size uint64, mtime time.Time) *Entry {
+		return &Entry{
+			FullPath: util.FullPath("/dir/" + name),
+			Attr:     Attr{FileSize: size, Mtime: mtime, Crtime: mtime},
+		}
+	}
+	entries := []*Entry{
+		newEntry("b", 10, now),
+		newEntry("c", 30, now.Add(-time.Hour)),
+		newEntry("a", 10, now.Add(time.Hour)),
+	}
+	names := func() (names []string) {
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return
+	}
+
+	less, err := entryLessFunc("size")
+	assert.Nil(t, err)
+	sortEntries(entries, less, false)
+	assert.Equal(t, []string{"a", "b", "c"}, names(), "the names break the ties")
+
+	sortEntries(entries, less, true)
+	assert.Equal(t, []string{"c", "b", "a"}, names())
+
+	less, _ = entryLessFunc("mtime")
+	sortEntries(entries, less, false)
+	assert.Equal(t, []string{"c", "b", "a"}, names())
+
+	less, _ = entryLessFunc("name")
+	sortEntries(entries, less, true)
+	assert.Equal(t, []string{"c", "b", "a"}, names())
+
+	_, err = entryLessFunc("owner")
+	assert.Equal(t, ErrUnknownSort, err)
+}
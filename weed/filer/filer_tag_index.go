@@ -0,0 +1,297 @@
+package filer
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	// TagIndexXattr set to "true" on a directory, e.g., by "setfattr -n seaweedfs.tag-index -v true <dir>" on a mount,
+	// indexes the extended attributes of all entries under the directory
+	TagIndexXattr = "xattr-seaweedfs.tag-index"
+	// TagIndexMaxValueLength skips indexing longer extended attribute values, which are searched by walking
+	TagIndexMaxValueLength = 256
+
+	tagIndexDirsKey      = "__tag_index_dirs__"
+	tagIndexRoot         = "/.seaweedfs.tag-index"
+	tagIndexDirsReloadAt = 30 * time.Second
+	tagIndexListLimit    = 1024
+)
+
+// KvTagIndex is the inverted map from each extended attribute key and value to the entry paths,
+// for the directories with TagIndexXattr. It is kept in the filer store, and updated as a FilerPlugin.
+// Each (tag, path) pair is one store record, in a store directory per tag under tagIndexRoot, so the
+// updates do not read and rewrite the other paths, and the filers can update the index at the same time.
+// The records are written to the store directly, without the parent directories, and are not listed in the filer.
+// Deleting a directory does not remove the entries under it from the index, so the paths found
+// are checked against the entries, and stale paths are skipped.
+type KvTagIndex struct {
+	filer *Filer
+
+	sync.Mutex
+	// indexed directory => the existing entries are indexed
+	dirs       map[util.FullPath]bool
+	dirsLoaded time.Time
+	// the directories being indexed by this filer
+	building map[util.FullPath]bool
+}
+
+var _ = FilerPlugin(&KvTagIndex{})
+
+func NewKvTagIndex(f *Filer) *KvTagIndex {
+	return &KvTagIndex{
+		filer:    f,
+		building: make(map[util.FullPath]bool),
+	}
+}
+
+// tagIndexDir is the store directory of the records of the paths with the tag, under the indexed directory
+func tagIndexDir(dir util.FullPath, key, value string) util.FullPath {
+	return util.NewFullPath(tagIndexRoot+string(dir), url.QueryEscape(key)+"="+url.QueryEscape(value))
+}
+
+func isTagIndexed(dir *Entry) bool {
+	return dir != nil && dir.IsDirectory() && string(dir.Extended[TagIndexXattr]) == "true"
+}
+
+// Search looks up the entries in the index of the directory, or one of its parents.
+// It returns false if the directory is not indexed yet, to search by walking instead.
+func (idx *KvTagIndex) Search(ctx context.Context, dir util.FullPath, tags map[string]string, fn func(entry *Entry) error) (bool, error) {
+	indexedDir, built := idx.indexedDirOf(ctx, dir, true)
+	if indexedDir == "" || !built {
+		return false, nil
+	}
+	for _, value := range tags {
+		if len(value) > TagIndexMaxValueLength {
+			return false, nil
+		}
+	}
+
+	// the records of any one tag are the candidates, checked against the entries for all the tags
+	var key, value string
+	for key, value = range tags {
+		break
+	}
+	recordDir := tagIndexDir(indexedDir, key, value)
+	lastFileName := ""
+	for {
+		var paths []util.FullPath
+		count := 0
+		_, err := idx.filer.Store.ListDirectoryEntries(ctx, recordDir, lastFileName, false, tagIndexListLimit, func(record *Entry) bool {
+			count++
+			lastFileName = record.Name()
+			if p, err := url.PathUnescape(record.Name()); err == nil && util.FullPath(p).IsUnder(dir) {
+				paths = append(paths, util.FullPath(p))
+			}
+			return true
+		})
+		if err != nil {
+			return true, err
+		}
+		for _, p := range paths {
+			entry, err := idx.filer.FindEntry(ctx, p)
+			if err != nil || !MatchTags(entry, tags) {
+				// deleted, renamed, or changed by a filer not updating the index
+				continue
+			}
+			if err = fn(entry); err != nil {
+				return true, err
+			}
+		}
+		if count < tagIndexListLimit {
+			return true, nil
+		}
+	}
+}
+
+func (idx *KvTagIndex) BeforeCreateEntry(ctx context.Context, entry *Entry) error {
+	return nil
+}
+
+func (idx *KvTagIndex) AfterCreateEntry(ctx context.Context, entry *Entry) {
+	idx.onChange(ctx, nil, entry)
+}
+
+func (idx *KvTagIndex) BeforeUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) error {
+	return nil
+}
+
+func (idx *KvTagIndex) AfterUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) {
+	idx.onChange(ctx, oldEntry, newEntry)
+}
+
+func (idx *KvTagIndex) BeforeDeleteEntry(ctx context.Context, entry *Entry) error {
+	return nil
+}
+
+func (idx *KvTagIndex) AfterDeleteEntry(ctx context.Context, entry *Entry) {
+	idx.onChange(ctx, entry, nil)
+}
+
+func (idx *KvTagIndex) onChange(ctx context.Context, oldEntry, newEntry *Entry) {
+	if isTagIndexed(oldEntry) != isTagIndexed(newEntry) {
+		if newEntry != nil && isTagIndexed(newEntry) {
+			idx.enable(ctx, newEntry.FullPath)
+		} else {
+			idx.disable(ctx, oldEntry.FullPath)
+		}
+	}
+
+	var p util.FullPath
+	var oldTags, newTags map[string][]byte
+	if oldEntry != nil {
+		p, oldTags = oldEntry.FullPath, oldEntry.Extended
+	}
+	if newEntry != nil {
+		p, newTags = newEntry.FullPath, newEntry.Extended
+	}
+	indexedDir, _ := idx.indexedDirOf(ctx, p, false)
+	if indexedDir == "" {
+		return
+	}
+	for key, value := range oldTags {
+		if newValue, found := newTags[key]; !found || string(newValue) != string(value) {
+			idx.updatePaths(ctx, indexedDir, key, value, string(p), false)
+		}
+	}
+	for key, value := range newTags {
+		if oldValue, found := oldTags[key]; !found || string(oldValue) != string(value) {
+			idx.updatePaths(ctx, indexedDir, key, value, string(p), true)
+		}
+	}
+}
+
+// indexedDirOf returns the indexed directory containing the path, and whether its existing entries are indexed.
+func (idx *KvTagIndex) indexedDirOf(ctx context.Context, p util.FullPath, inclusive bool) (util.FullPath, bool) {
+	idx.Lock()
+	defer idx.Unlock()
+	idx.maybeLoadDirs(ctx)
+
+	var indexedDir util.FullPath
+	for dir := range idx.dirs {
+		if (inclusive && dir == p || p.IsUnder(dir)) && len(dir) > len(indexedDir) {
+			indexedDir = dir
+		}
+	}
+	return indexedDir, indexedDir != "" && idx.dirs[indexedDir]
+}
+
+// maybeLoadDirs reloads the indexed directories, which may be changed by other filers
+func (idx *KvTagIndex) maybeLoadDirs(ctx context.Context) {
+	if idx.dirs != nil && time.Since(idx.dirsLoaded) < tagIndexDirsReloadAt {
+		return
+	}
+	dirs := make(map[util.FullPath]bool)
+	data, err := idx.filer.Store.KvGet(ctx, []byte(tagIndexDirsKey))
+	if err == nil && len(data) > 0 {
+		err = json.Unmarshal(data, &dirs)
+	}
+	if err != nil && err != ErrKvNotFound {
+		glog.Warningf("load tag index directories: %v", err)
+		if idx.dirs != nil {
+			return
+		}
+	}
+	isFirstLoad := idx.dirs == nil
+	idx.dirs, idx.dirsLoaded = dirs, time.Now()
+
+	// the indexing stopped by a restart is started again, and the existing records are simply written again
+	if isFirstLoad {
+		for dir, built := range idx.dirs {
+			if !built {
+				idx.build(dir)
+			}
+		}
+	}
+}
+
+func (idx *KvTagIndex) saveDirs(ctx context.Context) {
+	data, err := json.Marshal(idx.dirs)
+	if err == nil {
+		err = idx.filer.Store.KvPut(ctx, []byte(tagIndexDirsKey), data)
+	}
+	if err != nil {
+		glog.Warningf("save tag index directories: %v", err)
+	}
+}
+
+// enable indexes the existing entries under the directory in the background,
+// and the searches walk the directory until done
+func (idx *KvTagIndex) enable(ctx context.Context, dir util.FullPath) {
+	glog.V(0).Infof("enable tag index on %s", dir)
+	idx.Lock()
+	defer idx.Unlock()
+	idx.maybeLoadDirs(ctx)
+	idx.dirs[dir] = false
+	idx.saveDirs(ctx)
+	idx.build(dir)
+}
+
+// build indexes the existing entries under the directory, and marks it built, unless disabled meanwhile.
+// It is called with the lock held.
+func (idx *KvTagIndex) build(dir util.FullPath) {
+	if idx.building[dir] {
+		return
+	}
+	idx.building[dir] = true
+	go func() {
+		ctx := context.Background()
+		err := idx.filer.walkByTags(ctx, dir, nil, func(entry *Entry) error {
+			for key, value := range entry.Extended {
+				idx.updatePaths(ctx, dir, key, value, string(entry.FullPath), true)
+			}
+			return nil
+		})
+
+		idx.Lock()
+		defer idx.Unlock()
+		delete(idx.building, dir)
+		if err != nil {
+			glog.Errorf("build tag index on %s: %v", dir, err)
+			return
+		}
+		idx.maybeLoadDirs(ctx)
+		if _, found := idx.dirs[dir]; !found {
+			return
+		}
+		idx.dirs[dir] = true
+		idx.saveDirs(ctx)
+		glog.V(0).Infof("built tag index on %s", dir)
+	}()
+}
+
+// disable leaves the records in the store, and they are only used after being built again
+func (idx *KvTagIndex) disable(ctx context.Context, dir util.FullPath) {
+	glog.V(0).Infof("disable tag index on %s", dir)
+	idx.Lock()
+	defer idx.Unlock()
+	idx.maybeLoadDirs(ctx)
+	delete(idx.dirs, dir)
+	idx.saveDirs(ctx)
+}
+
+func (idx *KvTagIndex) updatePaths(ctx context.Context, dir util.FullPath, key string, value []byte, p string, add bool) {
+	if len(value) > TagIndexMaxValueLength {
+		return
+	}
+	recordPath := tagIndexDir(dir, key, string(value)).Child(url.PathEscape(p))
+	var err error
+	if add {
+		now := time.Now()
+		err = idx.filer.Store.InsertEntry(ctx, &Entry{
+			FullPath: recordPath,
+			Attr:     Attr{Mtime: now, Crtime: now, Mode: 0644},
+		})
+	} else {
+		err = idx.filer.Store.DeleteEntry(ctx, recordPath)
+	}
+	if err != nil {
+		glog.Warningf("update tag index %s %s: %v", dir, key, err)
+	}
+}
@@ -0,0 +1,103 @@
+package filer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func newTagIndexTestFiler(t *testing.T) *Filer {
+	f := NewFiler(nil, nil, "", "", "", "", "", nil)
+	f.SetStore(newMemoryStore())
+	ctx := context.Background()
+	for _, entry := range []*Entry{
+		{FullPath: "/data", Attr: Attr{Mode: os.ModeDir | 0755}, Extended: map[string][]byte{TagIndexXattr: []byte("true")}},
+		{FullPath: "/data/a", Attr: Attr{Mode: 0644}, Extended: map[string][]byte{"color": []byte("blue"), "size": []byte("L")}},
+		{FullPath: "/data/sub/b", Attr: Attr{Mode: 0644}, Extended: map[string][]byte{"color": []byte("blue"), "size": []byte("M")}},
+		{FullPath: "/data/c", Attr: Attr{Mode: 0644}, Extended: map[string][]byte{"color": []byte("red")}},
+	} {
+		assert.Nil(t, f.CreateEntry(ctx, entry, false, false, nil, false))
+	}
+	return f
+}
+
+func searchTagIndex(t *testing.T, idx *KvTagIndex, dir util.FullPath, tags map[string]string) (searched bool, found []string) {
+	searched, err := idx.Search(context.Background(), dir, tags, func(entry *Entry) error {
+		found = append(found, string(entry.FullPath))
+		return nil
+	})
+	assert.Nil(t, err)
+	sort.Strings(found)
+	return
+}
+
+func TestTagIndexSearch(t *testing.T) {
+	f := newTagIndexTestFiler(t)
+	ctx := context.Background()
+	idx := NewKvTagIndex(f)
+
+	searched, _ := searchTagIndex(t, idx, "/data", map[string]string{"color": "blue"})
+	assert.False(t, searched, "not indexed")
+
+	idx.Lock()
+	idx.maybeLoadDirs(ctx)
+	idx.dirs["/data"] = true
+	idx.Unlock()
+	idx.updatePaths(ctx, "/data", "color", []byte("blue"), "/data/a", true)
+	idx.updatePaths(ctx, "/data", "size", []byte("L"), "/data/a", true)
+	idx.updatePaths(ctx, "/data", "color", []byte("blue"), "/data/sub/b", true)
+	idx.updatePaths(ctx, "/data", "color", []byte("red"), "/data/c", true)
+	// a stale record, of a deleted entry
+	idx.updatePaths(ctx, "/data", "color", []byte("blue"), "/data/deleted", true)
+
+	searched, found := searchTagIndex(t, idx, "/data", map[string]string{"color": "blue"})
+	assert.True(t, searched)
+	assert.Equal(t, []string{"/data/a", "/data/sub/b"}, found)
+	_, found = searchTagIndex(t, idx, "/data/sub", map[string]string{"color": "blue"})
+	assert.Equal(t, []string{"/data/sub/b"}, found)
+	_, found = searchTagIndex(t, idx, "/data", map[string]string{"color": "blue", "size": "L"})
+	assert.Equal(t, []string{"/data/a"}, found)
+
+	// the removed record is not found, even if the entry still has the tag
+	idx.updatePaths(ctx, "/data", "color", []byte("blue"), "/data/a", false)
+	_, found = searchTagIndex(t, idx, "/data", map[string]string{"color": "blue"})
+	assert.Equal(t, []string{"/data/sub/b"}, found)
+
+	// the records are not listed in the filer
+	var names []string
+	_, err := f.StreamListDirectoryEntries(ctx, "/", "", false, 100, "", "", "", func(entry *Entry) bool {
+		names = append(names, entry.Name())
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"data"}, names)
+}
+
+func TestTagIndexBuildResumedAfterRestart(t *testing.T) {
+	f := newTagIndexTestFiler(t)
+	ctx := context.Background()
+
+	// the filer stopped while indexing /data
+	data, _ := json.Marshal(map[util.FullPath]bool{"/data": false})
+	assert.Nil(t, f.Store.KvPut(ctx, []byte(tagIndexDirsKey), data))
+
+	idx := NewKvTagIndex(f)
+	searched, _ := searchTagIndex(t, idx, "/data", map[string]string{"color": "blue"})
+	assert.False(t, searched, "searched before being indexed")
+
+	assert.Eventually(t, func() bool {
+		searched, found := searchTagIndex(t, idx, "/data", map[string]string{"color": "blue"})
+		return searched && len(found) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	data, err := f.Store.KvGet(ctx, []byte(tagIndexDirsKey))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"/data": true}`, string(data))
+}
@@ -0,0 +1,135 @@
+package filer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	TagSearchWorkers   = 8
+	tagSearchListLimit = 1024
+)
+
+// ParseTags parses "key1:val1,key2:val2" into the extended attributes to search for.
+func ParseTags(s string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if pair == "" {
+			continue
+		}
+		key, value, found := strings.Cut(pair, ":")
+		if !found || key == "" {
+			return nil, fmt.Errorf("tag %q should be key:value", pair)
+		}
+		tags[key] = value
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no tags in %q", s)
+	}
+	return tags, nil
+}
+
+// MatchTags checks the extended attributes of the entry have all the tags.
+func MatchTags(entry *Entry, tags map[string]string) bool {
+	for key, value := range tags {
+		v, found := entry.Extended[key]
+		if !found || string(v) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchByTags calls fn with each entry under the directory having all the tags,
+// looked up in the tag index if the directory is indexed, or found by walking the directory tree.
+// fn is not called concurrently, and stops the search by returning an error.
+func (f *Filer) SearchByTags(ctx context.Context, dir util.FullPath, tags map[string]string, fn func(entry *Entry) error) error {
+	if f.TagIndex != nil {
+		if searched, err := f.TagIndex.Search(ctx, dir, tags, fn); searched {
+			return err
+		}
+	}
+	return f.walkByTags(ctx, dir, tags, fn)
+}
+
+// walkByTags lists the directories in parallel, with TagSearchWorkers listing at the same time.
+func (f *Filer) walkByTags(ctx context.Context, dir util.FullPath, tags map[string]string, fn func(entry *Entry) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		fnLock   sync.Mutex
+		errOnce  sync.Once
+		firstErr error
+	)
+	workers := make(chan struct{}, TagSearchWorkers)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	eachMatch := func(entry *Entry) error {
+		fnLock.Lock()
+		defer fnLock.Unlock()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fn(entry)
+	}
+
+	var walk func(d util.FullPath)
+	walk = func(d util.FullPath) {
+		defer wg.Done()
+		workers <- struct{}{}
+		subDirs, err := f.listByTags(ctx, d, tags, eachMatch)
+		<-workers
+		if err != nil {
+			fail(err)
+			return
+		}
+		for _, subDir := range subDirs {
+			wg.Add(1)
+			go walk(subDir)
+		}
+	}
+	wg.Add(1)
+	go walk(dir)
+	wg.Wait()
+
+	return firstErr
+}
+
+// listByTags lists one directory, and returns its sub directories.
+func (f *Filer) listByTags(ctx context.Context, dir util.FullPath, tags map[string]string, eachMatch func(entry *Entry) error) (subDirs []util.FullPath, err error) {
+	lastFileName := ""
+	for {
+		count := 0
+		var matchErr error
+		_, err = f.StreamListDirectoryEntries(ctx, dir, lastFileName, false, tagSearchListLimit, "", "", "", func(entry *Entry) bool {
+			count++
+			lastFileName = entry.Name()
+			if entry.IsDirectory() {
+				subDirs = append(subDirs, entry.FullPath)
+			}
+			if MatchTags(entry, tags) {
+				matchErr = eachMatch(entry)
+			}
+			return matchErr == nil
+		})
+		if matchErr != nil {
+			return nil, matchErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %v", dir, err)
+		}
+		if count < tagSearchListLimit {
+			return subDirs, nil
+		}
+	}
+}
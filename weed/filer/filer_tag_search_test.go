@@ -0,0 +1,29 @@
+package filer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags("project:apollo,stage:")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"project": "apollo", "stage": ""}, tags)
+
+	_, err = ParseTags("project")
+	assert.NotNil(t, err, "no value separator")
+	_, err = ParseTags(":apollo")
+	assert.NotNil(t, err, "empty key")
+	_, err = ParseTags("")
+	assert.NotNil(t, err, "no tags")
+}
+
+func TestMatchTags(t *testing.T) {
+	entry := &Entry{Extended: map[string][]byte{"project": []byte("apollo"), "stage": []byte("done")}}
+	assert.True(t, MatchTags(entry, map[string]string{"project": "apollo"}))
+	assert.True(t, MatchTags(entry, map[string]string{"project": "apollo", "stage": "done"}))
+	assert.False(t, MatchTags(entry, map[string]string{"project": "gemini"}))
+	assert.False(t, MatchTags(entry, map[string]string{"owner": ""}))
+	assert.True(t, MatchTags(entry, nil))
+}
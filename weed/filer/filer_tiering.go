@@ -0,0 +1,431 @@
+package filer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	// TieringUnmodifiedTtlKey is the directory xattr, e.g., "30d". The files not modified for this long are moved to the warm collection.
+	TieringUnmodifiedTtlKey = "seaweedfs.tiering.unmodified-ttl"
+	// TieringWarmCollectionKey is the directory xattr naming the collection to move the files to
+	TieringWarmCollectionKey = "seaweedfs.tiering.warm-collection"
+	// tieringCollectionKey marks the files already moved, with the collection they are moved to
+	tieringCollectionKey = "seaweedfs.tiering.collection"
+
+	// tieringDirsKey keeps the directories with the tiering xattrs in the filer store
+	tieringDirsKey = "__tiering_dirs__"
+
+	TieringScanInterval = time.Hour
+	tieringListLimit    = 1024
+)
+
+type tieringRule struct {
+	unmodifiedTtl  time.Duration
+	warmCollection string
+}
+
+// parseTieringRule reads the tiering xattrs of a directory. It returns nil if the directory has no rule.
+func parseTieringRule(extended map[string][]byte) (*tieringRule, error) {
+	ttl, collection := string(extended[TieringUnmodifiedTtlKey]), string(extended[TieringWarmCollectionKey])
+	if ttl == "" && collection == "" {
+		return nil, nil
+	}
+	if ttl == "" || collection == "" {
+		return nil, fmt.Errorf("both %s and %s are required", TieringUnmodifiedTtlKey, TieringWarmCollectionKey)
+	}
+	unmodifiedTtl, err := parseTieringTtl(ttl)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q: %v", TieringUnmodifiedTtlKey, ttl, err)
+	}
+	return &tieringRule{
+		unmodifiedTtl:  unmodifiedTtl,
+		warmCollection: collection,
+	}, nil
+}
+
+// parseTieringTtl accepts days, e.g., "30d", besides the time.Duration format.
+func parseTieringTtl(ttl string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(ttl, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid days")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(ttl)
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("not positive")
+	}
+	return d, err
+}
+
+// isCold tells whether the file should be moved to the warm collection.
+// The file reads go to the volume servers, so the filer only knows the modification time.
+func (rule *tieringRule) isCold(entry *Entry, now time.Time) bool {
+	if entry.IsDirectory() || len(entry.GetChunks()) == 0 || entry.IsInRemoteOnly() {
+		return false
+	}
+	if string(entry.Extended[tieringCollectionKey]) == rule.warmCollection {
+		return false
+	}
+	return entry.Mtime.Add(rule.unmodifiedTtl).Before(now)
+}
+
+// TieringPolicy moves the files that are not modified for a while, under the directories
+// with the tiering xattrs, to a warm collection. A rule applies to the whole directory tree,
+// unless a sub directory has its own rule.
+// As a FilerPlugin, it records the directories with the tiering xattrs in the filer store,
+// so that Run only scans their trees instead of the whole namespace.
+type TieringPolicy struct {
+	filer    *Filer
+	interval time.Duration
+	dirsLock sync.Mutex
+}
+
+var _ = FilerPlugin(&TieringPolicy{})
+
+func NewTieringPolicy(f *Filer, interval time.Duration) *TieringPolicy {
+	return &TieringPolicy{
+		filer:    f,
+		interval: interval,
+	}
+}
+
+func (t *TieringPolicy) Run() {
+	for {
+		start := time.Now()
+		if err := t.scanDirs(context.Background(), start); err != nil {
+			glog.Errorf("tiering scan: %v", err)
+		}
+		glog.V(1).Infof("tiering scan took %v", time.Since(start))
+		time.Sleep(t.interval)
+	}
+}
+
+func (t *TieringPolicy) scanDirs(ctx context.Context, now time.Time) error {
+	dirs, err := t.loadDirs(ctx)
+	if err == ErrKvNotFound {
+		// the directories are not recorded yet, look for them once
+		if dirs, err = t.discoverDirs(ctx); err == nil {
+			err = t.saveDirs(ctx, dirs)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	for dir := range dirs {
+		if ancestorIn(dir, dirs) {
+			// scanned with the ancestor
+			continue
+		}
+		entry, err := t.filer.FindEntry(ctx, dir)
+		if err != nil && err != filer_pb.ErrNotFound {
+			glog.Warningf("tiering %s: %v", dir, err)
+			continue
+		}
+		var rule *tieringRule
+		if entry != nil {
+			rule, err = parseTieringRule(entry.Extended)
+		}
+		if err != nil {
+			glog.Warningf("tiering %s: %v", dir, err)
+			continue
+		}
+		if rule == nil {
+			// the directory is deleted, or its xattrs are removed
+			t.setDir(ctx, dir, false)
+			continue
+		}
+		if err := t.scan(ctx, dir, rule, now); err != nil {
+			glog.Warningf("tiering %s: %v", dir, err)
+		}
+	}
+	return nil
+}
+
+func ancestorIn(dir util.FullPath, dirs map[util.FullPath]bool) bool {
+	for p := dir; p != "/"; {
+		parent, _ := p.DirAndName()
+		p = util.FullPath(parent)
+		if dirs[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverDirs walks all the directories, for the tiering xattrs set before they are recorded
+func (t *TieringPolicy) discoverDirs(ctx context.Context) (map[util.FullPath]bool, error) {
+	dirs := make(map[util.FullPath]bool)
+	var walk func(dir util.FullPath) error
+	walk = func(dir util.FullPath) error {
+		var subDirs []util.FullPath
+		lastFileName := ""
+		for {
+			count := 0
+			_, err := t.filer.StreamListDirectoryEntries(ctx, dir, lastFileName, false, tieringListLimit, "", "", "", func(entry *Entry) bool {
+				count++
+				lastFileName = entry.Name()
+				if entry.IsDirectory() && entry.FullPath != SystemLogDir {
+					subDirs = append(subDirs, entry.FullPath)
+					if rule, _ := parseTieringRule(entry.Extended); rule != nil {
+						dirs[entry.FullPath] = true
+					}
+				}
+				return true
+			})
+			if err != nil {
+				return fmt.Errorf("list %s: %v", dir, err)
+			}
+			if count < tieringListLimit {
+				break
+			}
+		}
+		for _, subDir := range subDirs {
+			if err := walk(subDir); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return dirs, walk("/")
+}
+
+func (t *TieringPolicy) loadDirs(ctx context.Context) (map[util.FullPath]bool, error) {
+	data, err := t.filer.Store.KvGet(ctx, []byte(tieringDirsKey))
+	if err != nil {
+		return nil, err
+	}
+	dirs := make(map[util.FullPath]bool)
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &dirs); err != nil {
+			return nil, fmt.Errorf("decode %s: %v", tieringDirsKey, err)
+		}
+	}
+	return dirs, nil
+}
+
+func (t *TieringPolicy) saveDirs(ctx context.Context, dirs map[util.FullPath]bool) error {
+	data, err := json.Marshal(dirs)
+	if err != nil {
+		return err
+	}
+	return t.filer.Store.KvPut(ctx, []byte(tieringDirsKey), data)
+}
+
+func (t *TieringPolicy) setDir(ctx context.Context, dir util.FullPath, hasRule bool) {
+	t.dirsLock.Lock()
+	defer t.dirsLock.Unlock()
+	dirs, err := t.loadDirs(ctx)
+	if err == ErrKvNotFound {
+		// leave it to the discovery
+		return
+	}
+	if err != nil {
+		glog.Warningf("load tiering directories: %v", err)
+		return
+	}
+	if dirs[dir] == hasRule {
+		return
+	}
+	if hasRule {
+		dirs[dir] = true
+	} else {
+		delete(dirs, dir)
+	}
+	if err = t.saveDirs(ctx, dirs); err != nil {
+		glog.Warningf("save tiering directories: %v", err)
+	}
+}
+
+func (t *TieringPolicy) onChange(ctx context.Context, oldEntry, newEntry *Entry) {
+	var hadRule, hasRule bool
+	if oldEntry != nil && oldEntry.IsDirectory() {
+		_, hadRule = oldEntry.Extended[TieringUnmodifiedTtlKey]
+	}
+	if newEntry != nil && newEntry.IsDirectory() {
+		_, hasRule = newEntry.Extended[TieringUnmodifiedTtlKey]
+	}
+	if oldEntry != nil && newEntry != nil && oldEntry.FullPath != newEntry.FullPath && hadRule {
+		t.setDir(ctx, oldEntry.FullPath, false)
+		hadRule = false
+	}
+	switch {
+	case hasRule && !hadRule:
+		t.setDir(ctx, newEntry.FullPath, true)
+	case hadRule && !hasRule:
+		t.setDir(ctx, oldEntry.FullPath, false)
+	}
+}
+
+func (t *TieringPolicy) BeforeCreateEntry(ctx context.Context, entry *Entry) error {
+	return nil
+}
+
+func (t *TieringPolicy) AfterCreateEntry(ctx context.Context, entry *Entry) {
+	t.onChange(ctx, nil, entry)
+}
+
+func (t *TieringPolicy) BeforeUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) error {
+	return nil
+}
+
+func (t *TieringPolicy) AfterUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) {
+	t.onChange(ctx, oldEntry, newEntry)
+}
+
+func (t *TieringPolicy) BeforeDeleteEntry(ctx context.Context, entry *Entry) error {
+	return nil
+}
+
+func (t *TieringPolicy) AfterDeleteEntry(ctx context.Context, entry *Entry) {
+	t.onChange(ctx, entry, nil)
+}
+
+func (t *TieringPolicy) scan(ctx context.Context, dir util.FullPath, rule *tieringRule, now time.Time) error {
+	lastFileName := ""
+	for {
+		var entries []*Entry
+		_, err := t.filer.StreamListDirectoryEntries(ctx, dir, lastFileName, false, tieringListLimit, "", "", "", func(entry *Entry) bool {
+			entries = append(entries, entry)
+			return true
+		})
+		if err != nil {
+			return fmt.Errorf("list %s: %v", dir, err)
+		}
+
+		// process the entries after the listing, to not update the store while iterating it
+		for _, entry := range entries {
+			lastFileName = entry.Name()
+			if entry.IsDirectory() {
+				if entry.FullPath == SystemLogDir {
+					continue
+				}
+				childRule, err := parseTieringRule(entry.Extended)
+				if err != nil {
+					glog.Warningf("tiering %s: %v", entry.FullPath, err)
+				}
+				if childRule == nil {
+					childRule = rule
+				}
+				if err := t.scan(ctx, entry.FullPath, childRule, now); err != nil {
+					glog.Warningf("tiering %s: %v", entry.FullPath, err)
+				}
+				continue
+			}
+			stats.FilerTieringCounter.WithLabelValues("scanned").Inc()
+			if !rule.isCold(entry, now) {
+				continue
+			}
+			if err := t.moveToCollection(ctx, entry, rule.warmCollection); err != nil {
+				stats.FilerTieringCounter.WithLabelValues("failed").Inc()
+				glog.Warningf("tiering move %s to %s: %v", entry.FullPath, rule.warmCollection, err)
+				continue
+			}
+			stats.FilerTieringCounter.WithLabelValues("moved").Inc()
+			stats.FilerTieringCounter.WithLabelValues("movedBytes").Add(float64(entry.Size()))
+		}
+
+		if len(entries) < tieringListLimit {
+			return nil
+		}
+	}
+}
+
+// moveToCollection copies the file chunks to the collection, and switches the entry to the new chunks.
+func (t *TieringPolicy) moveToCollection(ctx context.Context, entry *Entry, collection string) error {
+	lookupFn := t.filer.MasterClient.GetLookupFileIdFunction()
+	dataChunks, _, err := ResolveChunkManifest(lookupFn, entry.GetChunks(), 0, math.MaxInt64)
+	if err != nil {
+		return fmt.Errorf("resolve chunk manifest: %v", err)
+	}
+
+	var newChunks []*filer_pb.FileChunk
+	deleteNewChunks := func() {
+		t.filer.DeleteChunks(newChunks)
+	}
+	saveFunc := func(reader io.Reader, name string, offset int64, tsNs int64) (*filer_pb.FileChunk, error) {
+		return t.saveDataAsChunk(reader, entry, collection, offset, tsNs)
+	}
+	var buffer bytes.Buffer
+	for _, chunk := range dataChunks {
+		buffer.Reset()
+		if err := fetchWholeChunk(&buffer, lookupFn, chunk.GetFileIdString(), chunk.CipherKey, chunk.IsCompressed); err != nil {
+			deleteNewChunks()
+			return fmt.Errorf("read chunk %s: %v", chunk.GetFileIdString(), err)
+		}
+		newChunk, err := saveFunc(&buffer, "", chunk.Offset, chunk.ModifiedTsNs)
+		if err != nil {
+			deleteNewChunks()
+			return err
+		}
+		newChunks = append(newChunks, newChunk)
+	}
+	manifestedChunks, err := MaybeManifestize(saveFunc, newChunks)
+	if err != nil {
+		deleteNewChunks()
+		return fmt.Errorf("manifestize: %v", err)
+	}
+
+	// the file may be changed during the copy
+	current, err := t.filer.FindEntry(ctx, entry.FullPath)
+	if err != nil || !current.Mtime.Equal(entry.Mtime) || ETagEntry(current) != ETagEntry(entry) {
+		deleteNewChunks()
+		return fmt.Errorf("changed during the move")
+	}
+
+	newEntry := current.ShallowClone()
+	newEntry.Chunks = manifestedChunks
+	newEntry.Extended = make(map[string][]byte, len(current.Extended)+1)
+	for k, v := range current.Extended {
+		newEntry.Extended[k] = v
+	}
+	newEntry.Extended[tieringCollectionKey] = []byte(collection)
+	if err := t.filer.UpdateEntry(ctx, current, newEntry); err != nil {
+		deleteNewChunks()
+		return fmt.Errorf("update entry: %v", err)
+	}
+	t.filer.NotifyUpdateEvent(ctx, current, newEntry, true, false, nil)
+	t.filer.DeleteChunks(current.GetChunks())
+	glog.V(2).Infof("tiering moved %s to collection %s", entry.FullPath, collection)
+	return nil
+}
+
+func (t *TieringPolicy) saveDataAsChunk(reader io.Reader, entry *Entry, collection string, offset int64, tsNs int64) (*filer_pb.FileChunk, error) {
+	assignResult, err := operation.Assign(t.filer.GetMaster, t.filer.GrpcDialOption, &operation.VolumeAssignRequest{
+		Count:      1,
+		Collection: collection,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("assign volume in collection %s: %v", collection, err)
+	}
+	uploadResult, err, _ := operation.Upload(reader, &operation.UploadOption{
+		UploadUrl: fmt.Sprintf("http://%s/%s", assignResult.Url, assignResult.Fid),
+		Filename:  entry.Name(),
+		Cipher:    t.filer.Cipher,
+		MimeType:  entry.Mime,
+		Jwt:       assignResult.Auth,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("upload chunk: %v", err)
+	}
+	if uploadResult.Error != "" {
+		return nil, fmt.Errorf("upload chunk: %v", uploadResult.Error)
+	}
+	return uploadResult.ToPbFileChunk(assignResult.Fid, offset, tsNs), nil
+}
@@ -0,0 +1,67 @@
+package filer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestParseTieringRule(t *testing.T) {
+	rule, err := parseTieringRule(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, rule)
+
+	rule, err = parseTieringRule(map[string][]byte{
+		TieringUnmodifiedTtlKey:  []byte("30d"),
+		TieringWarmCollectionKey: []byte("warm"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 30*24*time.Hour, rule.unmodifiedTtl)
+	assert.Equal(t, "warm", rule.warmCollection)
+
+	rule, err = parseTieringRule(map[string][]byte{
+		TieringUnmodifiedTtlKey:  []byte("36h"),
+		TieringWarmCollectionKey: []byte("warm"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 36*time.Hour, rule.unmodifiedTtl)
+
+	_, err = parseTieringRule(map[string][]byte{TieringUnmodifiedTtlKey: []byte("30d")})
+	assert.NotNil(t, err, "missing collection")
+
+	for _, ttl := range []string{"xd", "0d", "-1h", "abc"} {
+		_, err = parseTieringTtl(ttl)
+		assert.NotNil(t, err, ttl)
+	}
+}
+
+func TestTieringRuleIsCold(t *testing.T) {
+	now := time.Now()
+	rule := &tieringRule{unmodifiedTtl: 24 * time.Hour, warmCollection: "warm"}
+	chunks := []*filer_pb.FileChunk{{FileId: "1,1234", Size: 10}}
+
+	old := &Entry{FullPath: "/a/old", Attr: Attr{Mtime: now.Add(-48 * time.Hour)}, Chunks: chunks}
+	assert.True(t, rule.isCold(old, now))
+
+	recent := &Entry{FullPath: "/a/recent", Attr: Attr{Mtime: now.Add(-time.Hour)}, Chunks: chunks}
+	assert.False(t, rule.isCold(recent, now))
+
+	inline := &Entry{FullPath: "/a/inline", Attr: Attr{Mtime: now.Add(-48 * time.Hour)}, Content: []byte("x")}
+	assert.False(t, rule.isCold(inline, now))
+
+	moved := &Entry{FullPath: "/a/moved", Attr: Attr{Mtime: now.Add(-48 * time.Hour)}, Chunks: chunks,
+		Extended: map[string][]byte{tieringCollectionKey: []byte("warm")}}
+	assert.False(t, rule.isCold(moved, now))
+}
+
+func TestTieringAncestorIn(t *testing.T) {
+	dirs := map[util.FullPath]bool{"/a": true, "/a/b/c": true, "/d": true}
+	assert.False(t, ancestorIn("/a", dirs))
+	assert.True(t, ancestorIn("/a/b/c", dirs))
+	assert.False(t, ancestorIn("/ab", dirs))
+	assert.False(t, ancestorIn("/d", dirs))
+}
@@ -0,0 +1,305 @@
+package filer
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	// TrashTtlKey is the directory xattr, e.g., "7d", to keep the deleted files in its trash
+	TrashTtlKey = "seaweedfs.trash-ttl"
+	// TrashDirName is the trash under the directory with TrashTtlKey
+	TrashDirName = ".trash"
+	// TrashDeletedAtKey is the unix time the entry is moved to the trash
+	TrashDeletedAtKey = "deleted-at"
+	// TrashOriginalPathKey is the full path of the entry before it is deleted
+	TrashOriginalPathKey = "seaweedfs.trash.original-path"
+
+	trashRootsKvKey       = "__trash_roots__"
+	trashRootsReloadAt    = 30 * time.Second
+	trashPurgeInterval    = 10 * time.Minute
+	trashPurgeListLimit   = 1024
+	trashMaxPurgesPerLoop = 1024 * 1024
+)
+
+// IsInTrash tells whether the path is a trash, or under a trash.
+func IsInTrash(p util.FullPath) bool {
+	return strings.HasSuffix(string(p), "/"+TrashDirName) || strings.Contains(string(p), "/"+TrashDirName+"/")
+}
+
+// TrashDir is where the deleted entry is kept, named by the hash of its original path.
+func TrashDir(trashRoot, p util.FullPath) util.FullPath {
+	hash := md5.Sum([]byte(p))
+	return trashRoot.Child(TrashDirName).Child(hex.EncodeToString(hash[:]))
+}
+
+// ParseTrashTtl accepts days, e.g., "7d", besides the time.Duration format.
+func ParseTrashTtl(ttl string) (time.Duration, error) {
+	return parseTieringTtl(ttl)
+}
+
+// FindTrashRoot returns the nearest parent directory of p with a trash ttl.
+// Only the recorded trash roots are looked up, so deleting outside of them costs no extra lookups.
+func (f *Filer) FindTrashRoot(ctx context.Context, p util.FullPath) (trashRoot util.FullPath, ttl time.Duration, found bool) {
+	if IsInTrash(p) {
+		return "", 0, false
+	}
+	for dir, _ := p.DirAndName(); ; dir, _ = util.FullPath(dir).DirAndName() {
+		if f.TrashRoots.contains(ctx, util.FullPath(dir)) {
+			entry, err := f.FindEntry(ctx, util.FullPath(dir))
+			if err == nil && entry.Extended != nil {
+				if value, hasTtl := entry.Extended[TrashTtlKey]; hasTtl {
+					ttl, err = ParseTrashTtl(string(value))
+					if err != nil {
+						glog.Warningf("%s %s=%q: %v", dir, TrashTtlKey, value, err)
+						return "", 0, false
+					}
+					return util.FullPath(dir), ttl, true
+				}
+			}
+		}
+		if dir == "/" {
+			return "", 0, false
+		}
+	}
+}
+
+// PrepareTrashDir creates the trash dir of p, with the deleted-at and original path xattrs.
+// An earlier deleted entry with the same path is removed.
+func (f *Filer) PrepareTrashDir(ctx context.Context, trashRoot, p util.FullPath, signatures []int32) (util.FullPath, error) {
+	trashDir := TrashDir(trashRoot, p)
+	if err := f.DeleteEntryMetaAndData(ctx, trashDir, true, true, true, false, signatures); err != nil && err != filer_pb.ErrNotFound {
+		return "", err
+	}
+	now := time.Now()
+	if err := f.CreateEntry(ctx, &Entry{
+		FullPath: trashDir,
+		Attr: Attr{
+			Mtime:  now,
+			Crtime: now,
+			Mode:   os.ModeDir | 0700,
+			Uid:    OS_UID,
+			Gid:    OS_GID,
+		},
+		Extended: map[string][]byte{
+			TrashDeletedAtKey:    []byte(strconv.FormatInt(now.Unix(), 10)),
+			TrashOriginalPathKey: []byte(p),
+		},
+	}, false, false, signatures, false); err != nil {
+		return "", err
+	}
+	return trashDir, f.addTrashRoot(ctx, trashRoot)
+}
+
+var trashRootsLock sync.Mutex
+
+// the trash roots are kept in the filer store, to purge the trash without scanning all directories
+func (f *Filer) loadTrashRoots(ctx context.Context) (roots map[util.FullPath]bool, err error) {
+	roots = make(map[util.FullPath]bool)
+	data, err := f.Store.KvGet(ctx, []byte(trashRootsKvKey))
+	if err == ErrKvNotFound || (err == nil && len(data) == 0) {
+		return roots, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return roots, json.Unmarshal(data, &roots)
+}
+
+func (f *Filer) saveTrashRoots(ctx context.Context, roots map[util.FullPath]bool) error {
+	data, err := json.Marshal(roots)
+	if err != nil {
+		return err
+	}
+	return f.Store.KvPut(ctx, []byte(trashRootsKvKey), data)
+}
+
+func (f *Filer) addTrashRoot(ctx context.Context, trashRoot util.FullPath) error {
+	trashRootsLock.Lock()
+	defer trashRootsLock.Unlock()
+	roots, err := f.loadTrashRoots(ctx)
+	if err != nil || roots[trashRoot] {
+		return err
+	}
+	roots[trashRoot] = true
+	if err = f.saveTrashRoots(ctx, roots); err != nil {
+		return err
+	}
+	f.TrashRoots.set(roots)
+	return nil
+}
+
+// LoopPurgeTrash permanently deletes the trash entries older than the trash ttl of their directory.
+func (f *Filer) LoopPurgeTrash() {
+	for {
+		time.Sleep(trashPurgeInterval)
+		if err := f.purgeTrash(context.Background(), time.Now()); err != nil {
+			glog.Warningf("purge trash: %v", err)
+		}
+	}
+}
+
+func (f *Filer) purgeTrash(ctx context.Context, now time.Time) error {
+	trashRootsLock.Lock()
+	roots, err := f.loadTrashRoots(ctx)
+	trashRootsLock.Unlock()
+	if err != nil {
+		return err
+	}
+	for trashRoot := range roots {
+		entry, err := f.FindEntry(ctx, trashRoot)
+		if err == filer_pb.ErrNotFound {
+			f.removeTrashRoot(ctx, trashRoot)
+			continue
+		}
+		if err != nil {
+			glog.Warningf("purge trash %s: %v", trashRoot, err)
+			continue
+		}
+		// without the ttl, the trash is turned off, and everything in it is deleted
+		var ttl time.Duration
+		if value, found := entry.Extended[TrashTtlKey]; found {
+			if ttl, err = ParseTrashTtl(string(value)); err != nil {
+				continue
+			}
+		}
+		f.purgeTrashOf(ctx, trashRoot, ttl, now)
+	}
+	return nil
+}
+
+func (f *Filer) purgeTrashOf(ctx context.Context, trashRoot util.FullPath, ttl time.Duration, now time.Time) {
+	trash := trashRoot.Child(TrashDirName)
+	var expired []util.FullPath
+	lastFileName := ""
+	for len(expired) < trashMaxPurgesPerLoop {
+		count := 0
+		_, err := f.StreamListDirectoryEntries(ctx, trash, lastFileName, false, trashPurgeListLimit, "", "", "", func(entry *Entry) bool {
+			count++
+			lastFileName = entry.Name()
+			deletedAt, parseErr := strconv.ParseInt(string(entry.Extended[TrashDeletedAtKey]), 10, 64)
+			if parseErr != nil || time.Unix(deletedAt, 0).Add(ttl).Before(now) {
+				expired = append(expired, entry.FullPath)
+			}
+			return true
+		})
+		if err != nil || count < trashPurgeListLimit {
+			break
+		}
+	}
+	for _, p := range expired {
+		if err := f.DeleteEntryMetaAndData(ctx, p, true, true, true, false, nil); err != nil && err != filer_pb.ErrNotFound {
+			glog.Warningf("purge trash %s: %v", p, err)
+			continue
+		}
+		glog.V(2).Infof("purged trash %s", p)
+	}
+}
+
+func (f *Filer) removeTrashRoot(ctx context.Context, trashRoot util.FullPath) {
+	trashRootsLock.Lock()
+	defer trashRootsLock.Unlock()
+	roots, err := f.loadTrashRoots(ctx)
+	if err != nil || !roots[trashRoot] {
+		return
+	}
+	delete(roots, trashRoot)
+	if err = f.saveTrashRoots(ctx, roots); err != nil {
+		glog.Warningf("remove trash root %s: %v", trashRoot, err)
+	}
+}
+
+// TrashRoots caches the trash roots of the filer store, reloading them for the changes by the other filers.
+// As a FilerPlugin, it records the directories as trash roots when their trash ttl is set.
+type TrashRoots struct {
+	filer *Filer
+
+	sync.Mutex
+	roots  map[util.FullPath]bool
+	loaded time.Time
+}
+
+var _ = FilerPlugin(&TrashRoots{})
+
+func NewTrashRoots(f *Filer) *TrashRoots {
+	return &TrashRoots{
+		filer: f,
+	}
+}
+
+func (tr *TrashRoots) contains(ctx context.Context, dir util.FullPath) bool {
+	tr.Lock()
+	defer tr.Unlock()
+	if tr.roots == nil || time.Since(tr.loaded) >= trashRootsReloadAt {
+		trashRootsLock.Lock()
+		roots, err := tr.filer.loadTrashRoots(ctx)
+		trashRootsLock.Unlock()
+		if err != nil {
+			glog.Warningf("load trash roots: %v", err)
+			if tr.roots == nil {
+				// check the directory instead
+				return true
+			}
+		} else {
+			tr.roots, tr.loaded = roots, time.Now()
+		}
+	}
+	return tr.roots[dir]
+}
+
+func (tr *TrashRoots) set(roots map[util.FullPath]bool) {
+	tr.Lock()
+	defer tr.Unlock()
+	tr.roots, tr.loaded = roots, time.Now()
+}
+
+func (tr *TrashRoots) onChange(ctx context.Context, oldEntry, newEntry *Entry) {
+	if newEntry == nil || !newEntry.IsDirectory() {
+		return
+	}
+	if _, hasTtl := newEntry.Extended[TrashTtlKey]; !hasTtl {
+		return
+	}
+	if oldEntry != nil {
+		if _, hadTtl := oldEntry.Extended[TrashTtlKey]; hadTtl && oldEntry.FullPath == newEntry.FullPath {
+			return
+		}
+	}
+	if err := tr.filer.addTrashRoot(ctx, newEntry.FullPath); err != nil {
+		glog.Warningf("add trash root %s: %v", newEntry.FullPath, err)
+	}
+}
+
+func (tr *TrashRoots) BeforeCreateEntry(ctx context.Context, entry *Entry) error {
+	return nil
+}
+
+func (tr *TrashRoots) AfterCreateEntry(ctx context.Context, entry *Entry) {
+	tr.onChange(ctx, nil, entry)
+}
+
+func (tr *TrashRoots) BeforeUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) error {
+	return nil
+}
+
+func (tr *TrashRoots) AfterUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) {
+	tr.onChange(ctx, oldEntry, newEntry)
+}
+
+func (tr *TrashRoots) BeforeDeleteEntry(ctx context.Context, entry *Entry) error {
+	return nil
+}
+
+func (tr *TrashRoots) AfterDeleteEntry(ctx context.Context, entry *Entry) {
+}
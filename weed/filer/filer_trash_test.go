@@ -0,0 +1,33 @@
+package filer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestIsInTrash(t *testing.T) {
+	assert.True(t, IsInTrash("/dir/.trash"))
+	assert.True(t, IsInTrash("/dir/.trash/0123/file"))
+	assert.False(t, IsInTrash("/dir/file.trash"))
+	assert.False(t, IsInTrash("/dir/.trashcan/file"))
+}
+
+func TestTrashDir(t *testing.T) {
+	trashDir := TrashDir("/dir", "/dir/sub/file")
+	assert.True(t, IsInTrash(trashDir))
+	dir, _ := trashDir.DirAndName()
+	assert.Equal(t, util.FullPath("/dir/.trash"), util.FullPath(dir))
+	assert.NotEqual(t, trashDir, TrashDir("/dir", "/dir/sub/file2"))
+}
+
+func TestTrashRootsContains(t *testing.T) {
+	tr := NewTrashRoots(nil)
+	tr.set(map[util.FullPath]bool{"/dir": true})
+	assert.True(t, tr.contains(context.Background(), "/dir"))
+	assert.False(t, tr.contains(context.Background(), "/dir/sub"))
+	assert.False(t, tr.contains(context.Background(), "/"))
+}
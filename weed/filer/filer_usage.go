@@ -0,0 +1,197 @@
+package filer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	// DirUsageSizeKey is the total file size under the directory, saved by the usage walk
+	DirUsageSizeKey = "xattr-size"
+	// DirUsageInodeKey is the number of files and directories under the directory
+	DirUsageInodeKey = "xattr-inode"
+	// DirUsageAtKey is the unix time the usage is walked
+	DirUsageAtKey = "xattr-usage-at"
+
+	usageListLimit = 1024
+	// usageRefreshAfter is the age of the saved usage, after which it is walked again in the background
+	usageRefreshAfter = time.Hour
+	// the root entry is not in the store, so its usage is kept in the kv
+	rootUsageKvKey = "__root_usage__"
+)
+
+var ErrDirectoryUsagePending = errors.New("directory usage is being calculated")
+
+var (
+	usageWalksLock sync.Mutex
+	usageWalks     = make(map[util.FullPath]bool)
+)
+
+// DirectoryUsage is the recursive usage of a directory, as of UpdatedAt.
+type DirectoryUsage struct {
+	Bytes     uint64
+	Inodes    uint64
+	UpdatedAt time.Time
+}
+
+// IsDirectoryUsageKey tells whether the extended attribute is the usage saved by the filer,
+// which is not listed as a user xattr.
+func IsDirectoryUsageKey(key string) bool {
+	return key == DirUsageSizeKey || key == DirUsageInodeKey || key == DirUsageAtKey
+}
+
+// GetDirectoryUsage returns the usage saved on the directory by an earlier walk.
+// The saved usage is not updated by the later changes, so a usage older than usageRefreshAfter
+// is returned as is, and walked again in the background.
+// If there is none, or useCache is false, it starts a walk in the background and returns ErrDirectoryUsagePending.
+func (f *Filer) GetDirectoryUsage(ctx context.Context, p util.FullPath, useCache bool) (*DirectoryUsage, error) {
+	entry, err := f.FindEntry(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.IsDirectory() {
+		return nil, fmt.Errorf("%s is not a directory", p)
+	}
+	if useCache {
+		extended := entry.Extended
+		if p == "/" {
+			extended = f.loadRootUsage(ctx)
+		}
+		if usage, found := parseDirectoryUsage(extended); found {
+			if time.Since(usage.UpdatedAt) > usageRefreshAfter {
+				f.startDirectoryUsageWalk(p)
+			}
+			return usage, nil
+		}
+	}
+
+	f.startDirectoryUsageWalk(p)
+	return nil, ErrDirectoryUsagePending
+}
+
+func (f *Filer) startDirectoryUsageWalk(p util.FullPath) {
+	usageWalksLock.Lock()
+	defer usageWalksLock.Unlock()
+	if !usageWalks[p] {
+		usageWalks[p] = true
+		go func() {
+			if _, err := f.walkDirectoryUsage(context.Background(), p); err != nil {
+				glog.Warningf("walk usage of %s: %v", p, err)
+			}
+			usageWalksLock.Lock()
+			delete(usageWalks, p)
+			usageWalksLock.Unlock()
+		}()
+	}
+}
+
+func parseDirectoryUsage(extended map[string][]byte) (*DirectoryUsage, bool) {
+	size, sizeErr := strconv.ParseUint(string(extended[DirUsageSizeKey]), 10, 64)
+	inodes, inodeErr := strconv.ParseUint(string(extended[DirUsageInodeKey]), 10, 64)
+	if sizeErr != nil || inodeErr != nil {
+		return nil, false
+	}
+	usage := &DirectoryUsage{
+		Bytes:  size,
+		Inodes: inodes,
+	}
+	if at, err := strconv.ParseInt(string(extended[DirUsageAtKey]), 10, 64); err == nil {
+		usage.UpdatedAt = time.Unix(at, 0)
+	}
+	return usage, true
+}
+
+// walkDirectoryUsage sums up the usage of the directory tree, and saves it on every directory walked.
+func (f *Filer) walkDirectoryUsage(ctx context.Context, dir util.FullPath) (*DirectoryUsage, error) {
+	usage := &DirectoryUsage{UpdatedAt: time.Now()}
+	lastFileName := ""
+	for {
+		var subDirs []util.FullPath
+		count := 0
+		_, err := f.StreamListDirectoryEntries(ctx, dir, lastFileName, false, usageListLimit, "", "", "", func(entry *Entry) bool {
+			count++
+			lastFileName = entry.Name()
+			usage.Inodes++
+			if entry.IsDirectory() {
+				subDirs = append(subDirs, entry.FullPath)
+			} else {
+				usage.Bytes += entry.Size()
+			}
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %v", dir, err)
+		}
+		// walk the sub directories after the listing, to not update the store while iterating it
+		for _, subDir := range subDirs {
+			subUsage, err := f.walkDirectoryUsage(ctx, subDir)
+			if err != nil {
+				return nil, err
+			}
+			usage.Bytes += subUsage.Bytes
+			usage.Inodes += subUsage.Inodes
+		}
+		if count < usageListLimit {
+			break
+		}
+	}
+
+	if err := f.saveDirectoryUsage(ctx, dir, usage); err != nil {
+		glog.V(1).Infof("save usage of %s: %v", dir, err)
+	}
+	return usage, nil
+}
+
+func (f *Filer) saveDirectoryUsage(ctx context.Context, dir util.FullPath, usage *DirectoryUsage) error {
+	if dir == "/" {
+		data, err := json.Marshal(usageToExtended(usage))
+		if err != nil {
+			return err
+		}
+		return f.Store.KvPut(ctx, []byte(rootUsageKvKey), data)
+	}
+	current, err := f.FindEntry(ctx, dir)
+	if err != nil {
+		return err
+	}
+	newEntry := current.ShallowClone()
+	newEntry.Extended = make(map[string][]byte, len(current.Extended)+3)
+	for k, v := range current.Extended {
+		newEntry.Extended[k] = v
+	}
+	for k, v := range usageToExtended(usage) {
+		newEntry.Extended[k] = v
+	}
+	if err := f.UpdateEntry(ctx, current, newEntry); err != nil {
+		return err
+	}
+	f.NotifyUpdateEvent(ctx, current, newEntry, false, false, nil)
+	return nil
+}
+
+func usageToExtended(usage *DirectoryUsage) map[string][]byte {
+	return map[string][]byte{
+		DirUsageSizeKey:  []byte(strconv.FormatUint(usage.Bytes, 10)),
+		DirUsageInodeKey: []byte(strconv.FormatUint(usage.Inodes, 10)),
+		DirUsageAtKey:    []byte(strconv.FormatInt(usage.UpdatedAt.Unix(), 10)),
+	}
+}
+
+func (f *Filer) loadRootUsage(ctx context.Context) (extended map[string][]byte) {
+	data, err := f.Store.KvGet(ctx, []byte(rootUsageKvKey))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	if err = json.Unmarshal(data, &extended); err != nil {
+		glog.V(1).Infof("load root usage: %v", err)
+	}
+	return
+}
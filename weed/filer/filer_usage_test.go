@@ -0,0 +1,30 @@
+package filer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDirectoryUsage(t *testing.T) {
+	_, found := parseDirectoryUsage(nil)
+	assert.False(t, found)
+
+	_, found = parseDirectoryUsage(map[string][]byte{DirUsageSizeKey: []byte("10")})
+	assert.False(t, found)
+
+	now := time.Unix(time.Now().Unix(), 0)
+	usage, found := parseDirectoryUsage(usageToExtended(&DirectoryUsage{Bytes: 1024, Inodes: 3, UpdatedAt: now}))
+	assert.True(t, found)
+	assert.Equal(t, uint64(1024), usage.Bytes)
+	assert.Equal(t, uint64(3), usage.Inodes)
+	assert.Equal(t, now, usage.UpdatedAt)
+}
+
+func TestIsDirectoryUsageKey(t *testing.T) {
+	for key := range usageToExtended(&DirectoryUsage{}) {
+		assert.True(t, IsDirectoryUsageKey(key), key)
+	}
+	assert.False(t, IsDirectoryUsageKey("xattr-user.comment"))
+}
@@ -52,3 +52,17 @@ type BucketAware interface {
 type Debuggable interface {
 	Debug(writer io.Writer)
 }
+
+type readReplicaKey struct{}
+
+// WithReadReplica marks the context of the client lookups and listings, which a store may serve from a read replica.
+// The reads of the filer itself, e.g., the old entry of an update, always go to the primary.
+func WithReadReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readReplicaKey{}, true)
+}
+
+// IsReadReplicaAllowed checks whether the read may be served from a read replica
+func IsReadReplicaAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(readReplicaKey{}).(bool)
+	return allowed
+}
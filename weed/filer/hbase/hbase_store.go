@@ -32,12 +32,17 @@ func (store *HbaseStore) GetName() string {
 func (store *HbaseStore) Initialize(configuration util.Configuration, prefix string) (err error) {
 	return store.initialize(
 		configuration.GetString(prefix+"zkquorum"),
+		configuration.GetString(prefix+"zkroot"),
 		configuration.GetString(prefix+"table"),
 	)
 }
 
-func (store *HbaseStore) initialize(zkquorum, table string) (err error) {
-	store.Client = gohbase.NewClient(zkquorum)
+func (store *HbaseStore) initialize(zkquorum, zkroot, table string) (err error) {
+	var options []gohbase.Option
+	if zkroot != "" {
+		options = append(options, gohbase.ZookeeperRoot(zkroot))
+	}
+	store.Client = gohbase.NewClient(zkquorum, options...)
 	store.table = []byte(table)
 	store.cfKv = "kv"
 	store.cfMetaDir = "meta"
@@ -56,7 +61,7 @@ func (store *HbaseStore) initialize(zkquorum, table string) (err error) {
 	}
 
 	// create table
-	adminClient := gohbase.NewAdminClient(zkquorum)
+	adminClient := gohbase.NewAdminClient(zkquorum, options...)
 	cFamilies := []string{store.cfKv, store.cfMetaDir}
 	cf := make(map[string]map[string]string, len(cFamilies))
 	for _, f := range cFamilies {
@@ -155,7 +160,12 @@ func (store *HbaseStore) ListDirectoryEntries(ctx context.Context, dirPath util.
 func (store *HbaseStore) ListDirectoryPrefixedEntries(ctx context.Context, dirPath util.FullPath, startFileName string, includeStartFile bool, limit int64, prefix string, eachEntryFunc filer.ListEachEntryFunc) (lastFileName string, err error) {
 	family := map[string][]string{store.cfMetaDir: {COLUMN_NAME}}
 	expectedPrefix := []byte(dirPath.Child(prefix))
-	scan, err := hrpc.NewScanRange(ctx, store.table, expectedPrefix, nil, hrpc.Families(family))
+	// the rows are sorted by the full path, so the scan can start from the start file
+	startRow := expectedPrefix
+	if startFileName > prefix {
+		startRow = []byte(dirPath.Child(startFileName))
+	}
+	scan, err := hrpc.NewScanRange(ctx, store.table, startRow, nil, hrpc.Families(family))
 	if err != nil {
 		return lastFileName, err
 	}
@@ -0,0 +1,206 @@
+package filer
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	IntegrityScanInterval = 24 * time.Hour
+	// the md5 of a file covers its whole content, so only the files up to this size are verified
+	integrityCheckMaxSize = 8 * 1024 * 1024
+	integrityListLimit    = 1024
+	// integrityErrorsKey is the filer store key of the recent integrity errors, as a json list
+	integrityErrorsKey   = "integrity_errors"
+	integrityErrorsLimit = 1000
+)
+
+type IntegrityError struct {
+	Path        string    `json:"path"`
+	ExpectedMd5 string    `json:"expected_md5"`
+	ActualMd5   string    `json:"actual_md5"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// IntegrityChecker periodically reads the files with an md5, and records the files whose content
+// does not match the md5 anymore, e.g., because of bit rot on the volume servers.
+type IntegrityChecker struct {
+	filer     *Filer
+	interval  time.Duration
+	throttler *util.WriteThrottler
+
+	// serializes the updates of the recent errors
+	errorsLock sync.Mutex
+}
+
+// NewIntegrityChecker creates a checker reading at most scanBytesPerSecond, or unlimited if it is 0.
+func NewIntegrityChecker(f *Filer, interval time.Duration, scanBytesPerSecond int64) *IntegrityChecker {
+	return &IntegrityChecker{
+		filer:     f,
+		interval:  interval,
+		throttler: util.NewWriteThrottler(scanBytesPerSecond),
+	}
+}
+
+func (c *IntegrityChecker) Run() {
+	for {
+		start := time.Now()
+		if err := c.scan(context.Background(), "/"); err != nil {
+			glog.Errorf("integrity scan: %v", err)
+		}
+		glog.V(1).Infof("integrity scan took %v", time.Since(start))
+		time.Sleep(c.interval)
+	}
+}
+
+func (c *IntegrityChecker) scan(ctx context.Context, dir util.FullPath) error {
+	lastFileName := ""
+	for {
+		var entries []*Entry
+		_, err := c.filer.StreamListDirectoryEntries(ctx, dir, lastFileName, false, integrityListLimit, "", "", "", func(entry *Entry) bool {
+			entries = append(entries, entry)
+			return true
+		})
+		if err != nil {
+			return fmt.Errorf("list %s: %v", dir, err)
+		}
+
+		for _, entry := range entries {
+			lastFileName = entry.Name()
+			if entry.IsDirectory() {
+				if entry.FullPath == SystemLogDir {
+					continue
+				}
+				if err := c.scan(ctx, entry.FullPath); err != nil {
+					glog.Warningf("integrity scan %s: %v", entry.FullPath, err)
+				}
+				continue
+			}
+			c.checkEntry(ctx, entry)
+		}
+
+		if len(entries) < integrityListLimit {
+			return nil
+		}
+	}
+}
+
+func (c *IntegrityChecker) checkEntry(ctx context.Context, entry *Entry) {
+	if len(entry.Md5) == 0 || entry.IsInRemoteOnly() {
+		return
+	}
+	size := entry.Size()
+	if size > integrityCheckMaxSize {
+		stats.FilerIntegrityCounter.WithLabelValues("skipped").Inc()
+		return
+	}
+
+	actualMd5, err := c.readMd5(entry, size)
+	if err != nil {
+		stats.FilerIntegrityCounter.WithLabelValues("failed").Inc()
+		glog.Warningf("integrity check %s: %v", entry.FullPath, err)
+		return
+	}
+	stats.FilerIntegrityCounter.WithLabelValues("verified").Inc()
+	if bytes.Equal(actualMd5, entry.Md5) {
+		return
+	}
+
+	// the file may be changed during the check
+	current, err := c.filer.FindEntry(ctx, entry.FullPath)
+	if err != nil || !current.Mtime.Equal(entry.Mtime) || !bytes.Equal(current.Md5, entry.Md5) {
+		return
+	}
+	stats.FilerIntegrityCounter.WithLabelValues("corrupted").Inc()
+	glog.Errorf("integrity check %s: expected md5 %x, actual %x", entry.FullPath, entry.Md5, actualMd5)
+	if err := c.recordError(ctx, &IntegrityError{
+		Path:        string(entry.FullPath),
+		ExpectedMd5: hex.EncodeToString(entry.Md5),
+		ActualMd5:   hex.EncodeToString(actualMd5),
+		Timestamp:   time.Now(),
+	}); err != nil {
+		glog.Errorf("record integrity error of %s: %v", entry.FullPath, err)
+	}
+}
+
+func (c *IntegrityChecker) readMd5(entry *Entry, size uint64) ([]byte, error) {
+	hash := md5.New()
+	if len(entry.Content) > 0 {
+		hash.Write(entry.Content)
+	} else if err := StreamContent(c.filer.MasterClient, hash, entry.GetChunks(), 0, int64(size)); err != nil {
+		return nil, err
+	}
+	stats.FilerIntegrityCounter.WithLabelValues("bytes").Add(float64(size))
+	c.throttler.MaybeSlowdown(int64(size))
+	return hash.Sum(nil), nil
+}
+
+// recordError adds the error to the recent errors in the filer store, keeping the latest integrityErrorsLimit ones
+func (c *IntegrityChecker) recordError(ctx context.Context, integrityError *IntegrityError) error {
+	c.errorsLock.Lock()
+	defer c.errorsLock.Unlock()
+
+	integrityErrors, err := c.loadErrors(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(appendIntegrityError(integrityErrors, integrityError, integrityErrorsLimit))
+	if err != nil {
+		return err
+	}
+	return c.filer.Store.KvPut(ctx, []byte(integrityErrorsKey), data)
+}
+
+func appendIntegrityError(integrityErrors []*IntegrityError, integrityError *IntegrityError, limit int) []*IntegrityError {
+	integrityErrors = append(integrityErrors, integrityError)
+	if len(integrityErrors) > limit {
+		integrityErrors = integrityErrors[len(integrityErrors)-limit:]
+	}
+	return integrityErrors
+}
+
+func (c *IntegrityChecker) loadErrors(ctx context.Context) (integrityErrors []*IntegrityError, err error) {
+	data, err := c.filer.Store.KvGet(ctx, []byte(integrityErrorsKey))
+	if err == ErrKvNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &integrityErrors)
+	return
+}
+
+// ReportHandler returns the recent integrity errors, latest first, by GET /admin/integrity/report?limit=<n>
+func (c *IntegrityChecker) ReportHandler(w http.ResponseWriter, r *http.Request) {
+	integrityErrors, err := c.loadErrors(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for i, j := 0, len(integrityErrors)-1; i < j; i, j = i+1, j-1 {
+		integrityErrors[i], integrityErrors[j] = integrityErrors[j], integrityErrors[i]
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit >= 0 && limit < len(integrityErrors) {
+		integrityErrors = integrityErrors[:limit]
+	}
+	if integrityErrors == nil {
+		integrityErrors = []*IntegrityError{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"errors": integrityErrors}); err != nil {
+		glog.V(1).Infof("write integrity report: %v", err)
+	}
+}
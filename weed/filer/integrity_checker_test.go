@@ -0,0 +1,17 @@
+package filer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendIntegrityError(t *testing.T) {
+	var integrityErrors []*IntegrityError
+	for _, path := range []string{"/a", "/b", "/c", "/d"} {
+		integrityErrors = appendIntegrityError(integrityErrors, &IntegrityError{Path: path}, 3)
+	}
+	assert.Equal(t, 3, len(integrityErrors))
+	assert.Equal(t, "/b", integrityErrors[0].Path)
+	assert.Equal(t, "/d", integrityErrors[2].Path)
+}
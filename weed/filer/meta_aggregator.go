@@ -154,6 +154,10 @@ func (ma *MetaAggregator) doSubscribeToOneFiler(f *Filer, self pb.ServerAddress,
 		var counter int64
 		var synced bool
 		maybeReplicateMetadataChange = func(event *filer_pb.SubscribeMetadataResponse) {
+			// the replayed changes do not call the plugins
+			if f.InodeQuota != nil {
+				f.InodeQuota.CountReplay(context.Background(), event)
+			}
 			if err := Replay(f.Store, event); err != nil {
 				glog.Errorf("failed to reply metadata change from %v: %v", peer, err)
 				return
@@ -0,0 +1,134 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+const (
+	replicaCheckInterval = 5 * time.Second
+	replicaCheckTimeout  = 3 * time.Second
+)
+
+type readReplica struct {
+	address string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// readReplicas spreads the reads over the replicas round robin,
+// skipping the ones lagging more than maxLagSeconds behind the primary.
+type readReplicas struct {
+	replicas      []*readReplica
+	maxLagSeconds int
+	next          atomic.Uint32
+	stopOnce      sync.Once
+	stopCh        chan struct{}
+}
+
+func newReadReplicas(replicas []*readReplica, maxLagSeconds int) *readReplicas {
+	r := &readReplicas{
+		replicas:      replicas,
+		maxLagSeconds: maxLagSeconds,
+		stopCh:        make(chan struct{}),
+	}
+	r.checkAll()
+	go r.loopCheck()
+	return r
+}
+
+// pick returns a healthy replica, or nil to read from the primary
+func (r *readReplicas) pick() *sql.DB {
+	n := uint32(len(r.replicas))
+	start := r.next.Add(1)
+	for i := uint32(0); i < n; i++ {
+		if replica := r.replicas[(start+i)%n]; replica.healthy.Load() {
+			return replica.db
+		}
+	}
+	return nil
+}
+
+func (r *readReplicas) loopCheck() {
+	ticker := time.NewTicker(replicaCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+			r.checkAll()
+		}
+	}
+}
+
+func (r *readReplicas) checkAll() {
+	for _, replica := range r.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaCheckTimeout)
+		lag, err := replicationLagSeconds(ctx, replica.db)
+		cancel()
+		healthy := err == nil && lag <= r.maxLagSeconds
+		if healthy != replica.healthy.Load() {
+			if healthy {
+				glog.V(0).Infof("mysql read replica %s is back, lag %ds", replica.address, lag)
+			} else {
+				glog.Warningf("skip mysql read replica %s, lag %ds: %v", replica.address, lag, err)
+			}
+		}
+		replica.healthy.Store(healthy)
+	}
+}
+
+func (r *readReplicas) close() {
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+	for _, replica := range r.replicas {
+		replica.db.Close()
+	}
+}
+
+// replicationLagSeconds reads Seconds_Behind_Master from SHOW SLAVE STATUS.
+// A server without the replication status, e.g., a TiDB server, is only checked to be reachable.
+func replicationLagSeconds(ctx context.Context, db *sql.DB) (int, error) {
+	rows, err := db.QueryContext(ctx, "SHOW SLAVE STATUS")
+	if err != nil {
+		if pingErr := db.PingContext(ctx); pingErr != nil {
+			return 0, pingErr
+		}
+		return 0, nil
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+	for i, column := range columns {
+		if column != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, fmt.Errorf("replication is not running")
+		}
+		return strconv.Atoi(string(values[i]))
+	}
+	return 0, nil
+}
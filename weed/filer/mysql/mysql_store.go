@@ -3,6 +3,8 @@ package mysql
 import (
 	"database/sql"
 	"fmt"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
@@ -22,6 +24,7 @@ func init() {
 
 type MysqlStore struct {
 	abstract_sql.AbstractSqlStore
+	readReplicas *readReplicas
 }
 
 func (store *MysqlStore) GetName() string {
@@ -29,7 +32,8 @@ func (store *MysqlStore) GetName() string {
 }
 
 func (store *MysqlStore) Initialize(configuration util.Configuration, prefix string) (err error) {
-	return store.initialize(
+	configuration.SetDefault(prefix+"read_replica_max_lag_seconds", 5)
+	if err = store.initialize(
 		configuration.GetString(prefix+"upsertQuery"),
 		configuration.GetBool(prefix+"enableUpsert"),
 		configuration.GetString(prefix+"username"),
@@ -41,6 +45,19 @@ func (store *MysqlStore) Initialize(configuration util.Configuration, prefix str
 		configuration.GetInt(prefix+"connection_max_open"),
 		configuration.GetInt(prefix+"connection_max_lifetime_seconds"),
 		configuration.GetBool(prefix+"interpolateParams"),
+	); err != nil {
+		return err
+	}
+	return store.initializeReadReplicas(
+		configuration.GetStringSlice(prefix+"read_replica_addresses"),
+		configuration.GetInt(prefix+"read_replica_max_lag_seconds"),
+		configuration.GetString(prefix+"username"),
+		configuration.GetString(prefix+"password"),
+		configuration.GetString(prefix+"database"),
+		configuration.GetInt(prefix+"connection_max_idle"),
+		configuration.GetInt(prefix+"connection_max_open"),
+		configuration.GetInt(prefix+"connection_max_lifetime_seconds"),
+		configuration.GetBool(prefix+"interpolateParams"),
 	)
 }
 
@@ -82,3 +99,45 @@ func (store *MysqlStore) initialize(upsertQuery string, enableUpsert bool, user,
 
 	return nil
 }
+
+// initializeReadReplicas sends FindEntry and the listings outside of transactions to the replicas "host:port",
+// which use the same user and database as the primary. The writes always go to the primary.
+func (store *MysqlStore) initializeReadReplicas(addresses []string, maxLagSeconds int, user, password, database string, maxIdle, maxOpen,
+	maxLifetimeSeconds int, interpolateParams bool) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+	var replicas []*readReplica
+	for _, address := range addresses {
+		host, portString, err := net.SplitHostPort(address)
+		if err != nil {
+			return fmt.Errorf("mysql read replica %s: %v", address, err)
+		}
+		port, err := strconv.Atoi(portString)
+		if err != nil {
+			return fmt.Errorf("mysql read replica %s port: %v", address, err)
+		}
+		sqlUrl := fmt.Sprintf(CONNECTION_URL_PATTERN, user, password, host, port, database)
+		if interpolateParams {
+			sqlUrl += "&interpolateParams=true"
+		}
+		db, err := sql.Open("mysql", sqlUrl)
+		if err != nil {
+			return fmt.Errorf("can not connect to mysql read replica %s error:%v", address, err)
+		}
+		db.SetMaxIdleConns(maxIdle)
+		db.SetMaxOpenConns(maxOpen)
+		db.SetConnMaxLifetime(time.Duration(maxLifetimeSeconds) * time.Second)
+		replicas = append(replicas, &readReplica{address: address, db: db})
+	}
+	store.readReplicas = newReadReplicas(replicas, maxLagSeconds)
+	store.ReadDB = store.readReplicas.pick
+	return nil
+}
+
+func (store *MysqlStore) Shutdown() {
+	if store.readReplicas != nil {
+		store.readReplicas.close()
+	}
+	store.AbstractSqlStore.Shutdown()
+}
@@ -0,0 +1,159 @@
+package filer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// FilerPlugin hooks into the entry creation, update, and deletion of the filer.
+// An error from a Before* method aborts the operation with the error.
+// Deleting a directory calls the delete hooks once, for the directory itself.
+// The parent directories created along with an entry only call AfterCreateEntry.
+type FilerPlugin interface {
+	BeforeCreateEntry(ctx context.Context, entry *Entry) error
+	AfterCreateEntry(ctx context.Context, entry *Entry)
+	BeforeUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) error
+	AfterUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry)
+	BeforeDeleteEntry(ctx context.Context, entry *Entry) error
+	AfterDeleteEntry(ctx context.Context, entry *Entry)
+}
+
+// FilerPluginSymbol is the exported variable, of type FilerPlugin, looked up in the plugin shared libraries
+const FilerPluginSymbol = "Plugin"
+
+type fromOtherClusterKey struct{}
+
+// WithFromOtherCluster marks the context of the changes replicated from another cluster, e.g., by filer.sync
+func WithFromOtherCluster(ctx context.Context, isFromOtherCluster bool) context.Context {
+	if !isFromOtherCluster {
+		return ctx
+	}
+	return context.WithValue(ctx, fromOtherClusterKey{}, true)
+}
+
+// IsFromOtherCluster checks whether the change is replicated from another cluster, so the plugins can skip it
+func IsFromOtherCluster(ctx context.Context) bool {
+	isFromOtherCluster, _ := ctx.Value(fromOtherClusterKey{}).(bool)
+	return isFromOtherCluster
+}
+
+type renameSourceKey struct{}
+
+// WithRenameSource marks the context of creating the entry moved from the source path by a rename
+func WithRenameSource(ctx context.Context, source util.FullPath) context.Context {
+	return context.WithValue(ctx, renameSourceKey{}, source)
+}
+
+// RenameSource returns the path the created entry is moved from, or "" if it is a new entry
+func RenameSource(ctx context.Context) util.FullPath {
+	source, _ := ctx.Value(renameSourceKey{}).(util.FullPath)
+	return source
+}
+
+var (
+	plugins     []FilerPlugin
+	pluginsLock sync.RWMutex
+)
+
+func RegisterPlugin(p FilerPlugin) {
+	pluginsLock.Lock()
+	defer pluginsLock.Unlock()
+	plugins = append(plugins, p)
+}
+
+func registeredPlugins() []FilerPlugin {
+	pluginsLock.RLock()
+	defer pluginsLock.RUnlock()
+	return plugins
+}
+
+// LoadPlugins registers the plugins in the *.so files in the directory, built with "go build -buildmode=plugin".
+// Loading the shared libraries requires a cgo build on linux, freebsd, or darwin.
+func LoadPlugins(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		p, err := loadPlugin(file)
+		if err != nil {
+			return fmt.Errorf("load filer plugin %s: %v", file, err)
+		}
+		glog.V(0).Infof("loaded filer plugin %s", file)
+		RegisterPlugin(p)
+	}
+	return nil
+}
+
+func loadPlugin(file string) (FilerPlugin, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, err
+	}
+	lib, err := plugin.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := lib.Lookup(FilerPluginSymbol)
+	if err != nil {
+		return nil, err
+	}
+	switch p := symbol.(type) {
+	case *FilerPlugin:
+		return *p, nil
+	case FilerPlugin:
+		return p, nil
+	}
+	return nil, fmt.Errorf("%s is %T, not a filer.FilerPlugin", FilerPluginSymbol, symbol)
+}
+
+func beforeCreateEntry(ctx context.Context, entry *Entry) error {
+	for _, p := range registeredPlugins() {
+		if err := p.BeforeCreateEntry(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func afterCreateEntry(ctx context.Context, entry *Entry) {
+	for _, p := range registeredPlugins() {
+		p.AfterCreateEntry(ctx, entry)
+	}
+}
+
+func beforeUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) error {
+	for _, p := range registeredPlugins() {
+		if err := p.BeforeUpdateEntry(ctx, oldEntry, newEntry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func afterUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) {
+	for _, p := range registeredPlugins() {
+		p.AfterUpdateEntry(ctx, oldEntry, newEntry)
+	}
+}
+
+func beforeDeleteEntry(ctx context.Context, entry *Entry) error {
+	for _, p := range registeredPlugins() {
+		if err := p.BeforeDeleteEntry(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func afterDeleteEntry(ctx context.Context, entry *Entry) {
+	for _, p := range registeredPlugins() {
+		p.AfterDeleteEntry(ctx, entry)
+	}
+}
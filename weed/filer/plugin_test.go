@@ -0,0 +1,121 @@
+package filer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rejectingPlugin struct {
+	VirusScanPlugin
+	created []string
+}
+
+func (p *rejectingPlugin) BeforeCreateEntry(ctx context.Context, entry *Entry) error {
+	if entry.Name() == "rejected" {
+		return fmt.Errorf("%s is rejected", entry.FullPath)
+	}
+	return nil
+}
+
+func (p *rejectingPlugin) AfterCreateEntry(ctx context.Context, entry *Entry) {
+	p.created = append(p.created, string(entry.FullPath))
+}
+
+func TestFilerPlugins(t *testing.T) {
+	p := &rejectingPlugin{}
+	RegisterPlugin(p)
+	defer func() {
+		plugins = nil
+	}()
+
+	ctx := context.Background()
+	assert.Nil(t, beforeCreateEntry(ctx, &Entry{FullPath: "/dir/accepted"}))
+	afterCreateEntry(ctx, &Entry{FullPath: "/dir/accepted"})
+	assert.EqualError(t, beforeCreateEntry(ctx, &Entry{FullPath: "/dir/rejected"}), "/dir/rejected is rejected")
+	assert.Equal(t, []string{"/dir/accepted"}, p.created)
+}
+
+func TestVirusScan(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	// a fake clamd, finding the virus in any stream containing "EICAR"
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			command := make([]byte, len("zINSTREAM\x00"))
+			io.ReadFull(conn, command)
+			var stream []byte
+			size := make([]byte, 4)
+			for {
+				io.ReadFull(conn, size)
+				n := binary.BigEndian.Uint32(size)
+				if n == 0 {
+					break
+				}
+				chunk := make([]byte, n)
+				io.ReadFull(conn, chunk)
+				stream = append(stream, chunk...)
+			}
+			if bytes.Contains(stream, []byte("EICAR")) {
+				conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+			} else {
+				conn.Write([]byte("stream: OK\x00"))
+			}
+			conn.Close()
+		}
+	}()
+
+	v := NewVirusScanPlugin(nil, listener.Addr().String(), 10, true)
+	infected := make(chan string, 2)
+	v.onInfected = func(entry *Entry, virus string) {
+		infected <- string(entry.FullPath) + ": " + virus
+	}
+	ctx := context.Background()
+
+	virus, err := v.scanEntry(&Entry{FullPath: "/clean", Content: []byte("hello")})
+	assert.Nil(t, err)
+	assert.Empty(t, virus)
+
+	// the writes are not held by the scan
+	assert.Nil(t, v.BeforeCreateEntry(ctx, &Entry{FullPath: "/infected", Content: []byte("xEICARx")}))
+	v.AfterCreateEntry(ctx, &Entry{FullPath: "/clean", Content: []byte("hello")})
+	v.AfterCreateEntry(ctx, &Entry{FullPath: "/infected", Content: []byte("xEICARx")})
+	select {
+	case found := <-infected:
+		assert.Equal(t, "/infected: Eicar-Signature", found)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("no virus found in /infected")
+	}
+
+	// the files replicated by filer.sync are not scanned
+	v.AfterCreateEntry(WithFromOtherCluster(ctx, true), &Entry{FullPath: "/synced", Content: []byte("xEICARx")})
+	v.AfterUpdateEntry(ctx, &Entry{FullPath: "/infected", Content: []byte("xEICARx")}, &Entry{FullPath: "/infected", Content: []byte("xEICARx")})
+	select {
+	case found := <-infected:
+		t.Errorf("unexpected scan of %s", found)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the files too large to scan are rejected, except the ones replicated by filer.sync
+	large := &Entry{FullPath: "/large", Content: []byte("0123456789a")}
+	assert.NotNil(t, v.BeforeCreateEntry(ctx, large))
+	assert.Nil(t, v.BeforeCreateEntry(WithFromOtherCluster(ctx, true), large))
+	v.rejectOversized = false
+	assert.Nil(t, v.BeforeCreateEntry(ctx, large))
+
+	_, err = parseClamdReply("INSTREAM size limit exceeded. ERROR")
+	assert.NotNil(t, err)
+}
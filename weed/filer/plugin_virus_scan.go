@@ -0,0 +1,207 @@
+package filer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+const (
+	// same as the clamd default StreamMaxLength
+	DefaultVirusScanMaxSize = 25 * 1024 * 1024
+	virusScanChunkSize      = 64 * 1024
+	virusScanTimeout        = time.Minute
+	virusScanQueueSize      = 1024
+	virusScanConcurrency    = 4
+)
+
+// VirusScanPlugin scans the new and changed files with clamd, via its INSTREAM command over TCP.
+// The files are scanned in the background after the writes, and an infected file is deleted unless changed since.
+// The files larger than maxSize are not scanned: they are logged, or rejected if rejectOversized.
+// The files replicated by filer.sync from another cluster are left to the scan of the source cluster.
+type VirusScanPlugin struct {
+	filer           *Filer
+	clamdAddress    string
+	maxSize         uint64
+	rejectOversized bool
+	queue           chan *Entry
+	onInfected      func(entry *Entry, virus string)
+}
+
+var _ = FilerPlugin(&VirusScanPlugin{})
+
+func NewVirusScanPlugin(filer *Filer, clamdAddress string, maxSize int64, rejectOversized bool) *VirusScanPlugin {
+	if maxSize <= 0 {
+		maxSize = DefaultVirusScanMaxSize
+	}
+	v := &VirusScanPlugin{
+		filer:           filer,
+		clamdAddress:    clamdAddress,
+		maxSize:         uint64(maxSize),
+		rejectOversized: rejectOversized,
+		queue:           make(chan *Entry, virusScanQueueSize),
+	}
+	v.onInfected = v.deleteInfected
+	for i := 0; i < virusScanConcurrency; i++ {
+		go v.loopScan()
+	}
+	return v
+}
+
+func (v *VirusScanPlugin) BeforeCreateEntry(ctx context.Context, entry *Entry) error {
+	return v.checkSize(ctx, entry)
+}
+
+func (v *VirusScanPlugin) AfterCreateEntry(ctx context.Context, entry *Entry) {
+	v.enqueue(ctx, entry)
+}
+
+func (v *VirusScanPlugin) BeforeUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) error {
+	if isMetadataChange(oldEntry, newEntry) {
+		return nil
+	}
+	return v.checkSize(ctx, newEntry)
+}
+
+func (v *VirusScanPlugin) AfterUpdateEntry(ctx context.Context, oldEntry, newEntry *Entry) {
+	if isMetadataChange(oldEntry, newEntry) {
+		return
+	}
+	v.enqueue(ctx, newEntry)
+}
+
+func (v *VirusScanPlugin) BeforeDeleteEntry(ctx context.Context, entry *Entry) error {
+	return nil
+}
+
+func (v *VirusScanPlugin) AfterDeleteEntry(ctx context.Context, entry *Entry) {
+}
+
+func isMetadataChange(oldEntry, newEntry *Entry) bool {
+	return oldEntry != nil && bytes.Equal(oldEntry.Content, newEntry.Content) && ETagEntry(oldEntry) == ETagEntry(newEntry)
+}
+
+func (v *VirusScanPlugin) shouldScan(ctx context.Context, entry *Entry) bool {
+	return !IsFromOtherCluster(ctx) && !entry.IsDirectory() && !entry.IsInRemoteOnly() && entry.Size() > 0
+}
+
+// checkSize reports the files too large to scan, or rejects them if rejectOversized
+func (v *VirusScanPlugin) checkSize(ctx context.Context, entry *Entry) error {
+	if !v.shouldScan(ctx, entry) || entry.Size() <= v.maxSize {
+		return nil
+	}
+	if v.rejectOversized {
+		return fmt.Errorf("%s with %d bytes is too large for the virus scan, limit %d", entry.FullPath, entry.Size(), v.maxSize)
+	}
+	glog.Warningf("skip virus scan of %s with %d bytes, limit %d", entry.FullPath, entry.Size(), v.maxSize)
+	return nil
+}
+
+// enqueue does not wait for the scan, and skips it if the scans fall behind
+func (v *VirusScanPlugin) enqueue(ctx context.Context, entry *Entry) {
+	if !v.shouldScan(ctx, entry) || entry.Size() > v.maxSize {
+		return
+	}
+	select {
+	case v.queue <- entry:
+	default:
+		glog.Warningf("skip virus scan of %s: %d files are waiting for the scan", entry.FullPath, len(v.queue))
+	}
+}
+
+func (v *VirusScanPlugin) loopScan() {
+	for entry := range v.queue {
+		virus, err := v.scanEntry(entry)
+		if err != nil {
+			glog.Errorf("virus scan %s: %v", entry.FullPath, err)
+			continue
+		}
+		if virus != "" {
+			glog.Warningf("virus %s found in %s", virus, entry.FullPath)
+			v.onInfected(entry, virus)
+		}
+	}
+}
+
+// scanEntry returns the virus signature found in the file content, if any
+func (v *VirusScanPlugin) scanEntry(entry *Entry) (virus string, err error) {
+	content := entry.Content
+	if len(content) == 0 {
+		if content, err = v.filer.readEntry(entry.GetChunks(), entry.Size()); err != nil {
+			return "", fmt.Errorf("read %s: %v", entry.FullPath, err)
+		}
+	}
+	return v.scan(content)
+}
+
+// deleteInfected deletes the scanned version of the file, but not a later one
+func (v *VirusScanPlugin) deleteInfected(entry *Entry, virus string) {
+	ctx := context.Background()
+	current, err := v.filer.FindEntry(ctx, entry.FullPath)
+	if err != nil || ETagEntry(current) != ETagEntry(entry) || !bytes.Equal(current.Content, entry.Content) {
+		glog.Warningf("infected %s is changed or deleted since the scan", entry.FullPath)
+		return
+	}
+	if err = v.filer.DeleteEntryMetaAndData(ctx, entry.FullPath, false, false, true, false, nil); err != nil {
+		glog.Errorf("delete %s infected with %s: %v", entry.FullPath, virus, err)
+	}
+}
+
+// scan streams the content to clamd, and returns the virus signature if any
+func (v *VirusScanPlugin) scan(content []byte) (found string, err error) {
+	conn, err := net.DialTimeout("tcp", v.clamdAddress, virusScanTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(virusScanTimeout))
+
+	if _, err = conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return "", err
+	}
+	size := make([]byte, 4)
+	for len(content) > 0 {
+		chunk := content
+		if len(chunk) > virusScanChunkSize {
+			chunk = chunk[:virusScanChunkSize]
+		}
+		binary.BigEndian.PutUint32(size, uint32(len(chunk)))
+		if _, err = conn.Write(size); err != nil {
+			return "", err
+		}
+		if _, err = conn.Write(chunk); err != nil {
+			return "", err
+		}
+		content = content[len(chunk):]
+	}
+	// a zero length chunk ends the stream
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err = conn.Write(size); err != nil {
+		return "", err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		return "", fmt.Errorf("read clamd reply: %v", err)
+	}
+	return parseClamdReply(strings.TrimRight(reply, "\x00"))
+}
+
+// parseClamdReply parses "stream: OK", "stream: Eicar-Signature FOUND", or "... ERROR"
+func parseClamdReply(reply string) (found string, err error) {
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	}
+	return "", fmt.Errorf("clamd: %s", reply)
+}
@@ -0,0 +1,37 @@
+package filer
+
+import (
+	"sync/atomic"
+)
+
+// ReadAheadLimit caps the memory of the chunks prefetched for the sequential reads, shared by all files of a mount.
+// The chunks are prefetched only while the total stays under the limit, and count until the reader moves past them.
+type ReadAheadLimit struct {
+	limit int64
+	used  int64
+}
+
+func NewReadAheadLimit(limit int64) *ReadAheadLimit {
+	return &ReadAheadLimit{limit: limit}
+}
+
+func (l *ReadAheadLimit) tryAcquire(size int64) bool {
+	for {
+		used := atomic.LoadInt64(&l.used)
+		if used+size > l.limit {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&l.used, used, used+size) {
+			return true
+		}
+	}
+}
+
+func (l *ReadAheadLimit) release(size int64) {
+	atomic.AddInt64(&l.used, -size)
+}
+
+// Used returns the bytes of the prefetched chunks
+func (l *ReadAheadLimit) Used() int64 {
+	return atomic.LoadInt64(&l.used)
+}
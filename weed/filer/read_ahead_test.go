@@ -0,0 +1,34 @@
+package filer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestReadAheadLimit(t *testing.T) {
+	rc := NewReaderCache(32, &mockChunkCache{}, func(fileId string) ([]string, error) {
+		return nil, fmt.Errorf("lookup %s: not found", fileId)
+	})
+	rc.readAhead = NewReadAheadLimit(2 * 1024)
+
+	var chunkViews *Interval[*ChunkView]
+	for i := 3; i > 0; i-- {
+		chunkViews = &Interval[*ChunkView]{
+			Value: &ChunkView{FileId: fmt.Sprint(i), ChunkSize: 1024},
+			Next:  chunkViews,
+		}
+	}
+	rc.MaybeCache(chunkViews)
+	if len(rc.downloaders) != 2 || rc.readAhead.Used() != 2*1024 {
+		t.Errorf("prefetched %d chunks of %d bytes over the limit", len(rc.downloaders), rc.readAhead.Used())
+	}
+
+	rc.UnCache("1")
+	if rc.readAhead.Used() != 1024 {
+		t.Errorf("uncached chunk still counted, %d bytes", rc.readAhead.Used())
+	}
+	rc.destroy()
+	if rc.readAhead.Used() != 0 {
+		t.Errorf("destroyed chunks still counted, %d bytes", rc.readAhead.Used())
+	}
+}
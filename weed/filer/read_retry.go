@@ -0,0 +1,59 @@
+package filer
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// ReadRetryBackoff retries the chunk reads with exponential backoff and full jitter, so that the many
+// readers of an unavailable volume server do not retry all at once. Each retry starts from the next
+// replica. The retries stop once the total backoff reaches util.RetryWaitTime.
+type ReadRetryBackoff struct {
+	Base time.Duration
+	Cap  time.Duration
+}
+
+// backoff returns a random duration in [0, min(Cap, Base * 2^attempt)]
+func (b *ReadRetryBackoff) backoff(attempt int) time.Duration {
+	ceiling := b.Cap
+	if attempt < 62 {
+		if exp := b.Base << uint(attempt); exp > 0 && exp < ceiling {
+			ceiling = exp
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+func (b *ReadRetryBackoff) fetchChunkData(buffer []byte, urlStrings []string, cipherKey []byte, isGzipped bool, isFullChunk bool, offset int64) (n int, err error) {
+	var shouldRetry bool
+	var totalWait time.Duration
+	for attempt := 0; ; attempt++ {
+		replicas := rotateReplicas(urlStrings, attempt)
+		if len(replicas) > 0 {
+			glog.V(3).Infof("read attempt %d starts from replica %s of %d", attempt, replicas[0], len(replicas))
+		}
+		n, shouldRetry, err = fetchChunkDataFromUrls(buffer, replicas, cipherKey, isGzipped, isFullChunk, offset)
+		if err == nil || !shouldRetry || totalWait >= util.RetryWaitTime {
+			return n, err
+		}
+		waitTime := b.backoff(attempt)
+		glog.V(0).Infof("retry reading in %v", waitTime)
+		time.Sleep(waitTime)
+		totalWait += waitTime
+	}
+}
+
+// rotateReplicas starts the attempt from a different replica than the previous attempt
+func rotateReplicas(urlStrings []string, attempt int) []string {
+	if len(urlStrings) <= 1 {
+		return urlStrings
+	}
+	start := attempt % len(urlStrings)
+	return append(append(make([]string, 0, len(urlStrings)), urlStrings[start:]...), urlStrings[:start]...)
+}
@@ -0,0 +1,28 @@
+package filer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRetryBackoff(t *testing.T) {
+	b := &ReadRetryBackoff{Base: 100 * time.Millisecond, Cap: time.Second}
+	for i := 0; i < 100; i++ {
+		assert.LessOrEqual(t, b.backoff(0), 100*time.Millisecond)
+		assert.LessOrEqual(t, b.backoff(2), 400*time.Millisecond)
+		assert.LessOrEqual(t, b.backoff(10), time.Second)
+		assert.LessOrEqual(t, b.backoff(100), time.Second)
+		assert.GreaterOrEqual(t, b.backoff(100), time.Duration(0))
+	}
+}
+
+func TestRotateReplicas(t *testing.T) {
+	urls := []string{"a", "b", "c"}
+	assert.Equal(t, []string{"a", "b", "c"}, rotateReplicas(urls, 0))
+	assert.Equal(t, []string{"b", "c", "a"}, rotateReplicas(urls, 1))
+	assert.Equal(t, []string{"c", "a", "b"}, rotateReplicas(urls, 2))
+	assert.Equal(t, []string{"a", "b", "c"}, rotateReplicas(urls, 3))
+	assert.Equal(t, []string{"a"}, rotateReplicas([]string{"a"}, 1))
+}
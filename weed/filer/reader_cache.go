@@ -17,6 +17,10 @@ type ReaderCache struct {
 	sync.Mutex
 	downloaders map[string]*SingleChunkCacher
 	limit       int
+	// readRetry is optional, to back off the chunk reads with jitter
+	readRetry *ReadRetryBackoff
+	// readAhead is optional, to cap the memory of the prefetched chunks
+	readAhead *ReadAheadLimit
 }
 
 type SingleChunkCacher struct {
@@ -29,6 +33,7 @@ type SingleChunkCacher struct {
 	isGzipped        bool
 	chunkSize        int
 	shouldCache      bool
+	readAheadSize    int64 // counted in parent.readAhead until destroyed
 	wg               sync.WaitGroup
 	cacheStartedCh   chan struct{}
 	completedTimeNew int64
@@ -66,9 +71,17 @@ func (rc *ReaderCache) MaybeCache(chunkViews *Interval[*ChunkView]) {
 			return
 		}
 
+		if rc.readAhead != nil && !rc.readAhead.tryAcquire(int64(chunkView.ChunkSize)) {
+			// the other files are prefetching too much
+			return
+		}
+
 		// glog.V(4).Infof("prefetch %s offset %d", chunkView.FileId, chunkView.ViewOffset)
 		// cache this chunk if not yet
 		cacher := newSingleChunkCacher(rc, chunkView.FileId, chunkView.CipherKey, chunkView.IsGzipped, int(chunkView.ChunkSize), false)
+		if rc.readAhead != nil {
+			cacher.readAheadSize = int64(chunkView.ChunkSize)
+		}
 		go cacher.startCaching()
 		<-cacher.cacheStartedCh
 		rc.downloaders[chunkView.FileId] = cacher
@@ -170,7 +183,7 @@ func (s *SingleChunkCacher) startCaching() {
 
 	s.data = mem.Allocate(s.chunkSize)
 
-	_, s.err = retriedFetchChunkData(s.data, urlStrings, s.cipherKey, s.isGzipped, true, 0)
+	_, s.err = retriedFetchChunkData(s.data, urlStrings, s.cipherKey, s.isGzipped, true, 0, s.parent.readRetry)
 	if s.err != nil {
 		mem.Free(s.data)
 		s.data = nil
@@ -191,6 +204,10 @@ func (s *SingleChunkCacher) destroy() {
 	s.Lock()
 	defer s.Unlock()
 
+	if s.readAheadSize > 0 {
+		s.parent.readAhead.release(s.readAheadSize)
+		s.readAheadSize = 0
+	}
 	if s.data != nil {
 		mem.Free(s.data)
 		s.data = nil
@@ -0,0 +1,288 @@
+package ftpd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	ftpserver "github.com/fclairamb/ftpserverlib"
+	"github.com/spf13/afero"
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+var errUseFileTransfer = errors.New("files are only transferred via GetHandle")
+
+// FtpDriver serves one logged in ftp user. All paths are under the home directory of the user,
+// and every operation is a filer gRPC call.
+type FtpDriver struct {
+	option    *FtpServerOption
+	home      string
+	filer     pb.ServerAddress
+	signature int32
+}
+
+var (
+	_ = ftpserver.ClientDriver(&FtpDriver{})
+	_ = ftpserver.ClientDriverExtensionFileList(&FtpDriver{})
+	_ = ftpserver.ClientDriverExtentionFileTransfer(&FtpDriver{})
+	_ = ftpserver.ClientDriverExtensionRemoveDir(&FtpDriver{})
+	_ = filer_pb.FilerClient(&FtpDriver{})
+)
+
+func newFtpDriver(option *FtpServerOption, home string, filerAddress pb.ServerAddress) *FtpDriver {
+	return &FtpDriver{
+		option:    option,
+		home:      home,
+		filer:     filerAddress,
+		signature: util.RandomInt32(),
+	}
+}
+
+func (d *FtpDriver) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithGrpcClient(streamingMode, d.signature, func(grpcConnection *grpc.ClientConn) error {
+		client := filer_pb.NewSeaweedFilerClient(grpcConnection)
+		return fn(client)
+	}, d.filer.ToGrpcAddress(), false, d.option.GrpcDialOption)
+}
+
+func (d *FtpDriver) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (d *FtpDriver) GetDataCenter() string {
+	return ""
+}
+
+// fullPath maps the ftp path to the filer path. ".." can not go above the home directory.
+func (d *FtpDriver) fullPath(name string) util.FullPath {
+	return util.FullPath(path.Join(d.home, path.Clean("/"+name)))
+}
+
+func (d *FtpDriver) lookup(name string) (util.FullPath, *filer_pb.Entry, error) {
+	fullPath := d.fullPath(name)
+	if string(fullPath) == "/" {
+		return fullPath, &filer_pb.Entry{
+			Name:        "/",
+			IsDirectory: true,
+			Attributes: &filer_pb.FuseAttributes{
+				Mtime:    time.Now().Unix(),
+				FileMode: uint32(0777 | os.ModeDir),
+			},
+		}, nil
+	}
+	entry, err := filer_pb.GetEntry(d, fullPath)
+	if err == filer_pb.ErrNotFound || (err == nil && entry == nil) {
+		return fullPath, nil, os.ErrNotExist
+	}
+	if err != nil {
+		return fullPath, nil, err
+	}
+	return fullPath, entry, nil
+}
+
+func (d *FtpDriver) Name() string {
+	return "SeaweedFS"
+}
+
+func (d *FtpDriver) Stat(name string) (os.FileInfo, error) {
+	fullPath, entry, err := d.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return newFileInfo(fullPath, entry), nil
+}
+
+// ReadDir lists the directory, for LIST, NLST and MLSD.
+func (d *FtpDriver) ReadDir(name string) (infos []os.FileInfo, err error) {
+	fullPath := d.fullPath(name)
+	err = filer_pb.ReadDirAllEntries(d, fullPath, "", func(entry *filer_pb.Entry, isLast bool) error {
+		infos = append(infos, newFileInfo(fullPath.Child(entry.Name), entry))
+		return nil
+	})
+	return infos, err
+}
+
+func (d *FtpDriver) Mkdir(name string, perm os.FileMode) error {
+	fullPath := d.fullPath(name)
+	dir, dirName := fullPath.DirAndName()
+	return filer_pb.Mkdir(d, dir, dirName, func(entry *filer_pb.Entry) {
+		entry.Attributes.FileMode = uint32(perm&os.ModePerm | os.ModeDir)
+	})
+}
+
+// MkdirAll creates the directory. The filer creates the missing parent directories.
+func (d *FtpDriver) MkdirAll(name string, perm os.FileMode) error {
+	if _, entry, err := d.lookup(name); err == nil && entry.IsDirectory {
+		return nil
+	}
+	return d.Mkdir(name, perm)
+}
+
+// Remove deletes a file, for DELE.
+func (d *FtpDriver) Remove(name string) error {
+	fullPath, entry, err := d.lookup(name)
+	if err != nil {
+		return err
+	}
+	if entry.IsDirectory {
+		return fmt.Errorf("%s is a directory", name)
+	}
+	dir, entryName := fullPath.DirAndName()
+	return filer_pb.Remove(d, dir, entryName, true, false, false, false, []int32{d.signature})
+}
+
+// RemoveDir deletes an empty directory, for RMD.
+func (d *FtpDriver) RemoveDir(name string) error {
+	fullPath, entry, err := d.lookup(name)
+	if err != nil {
+		return err
+	}
+	if !entry.IsDirectory || string(fullPath) == d.home {
+		return fmt.Errorf("%s is not a removable directory", name)
+	}
+	dir, entryName := fullPath.DirAndName()
+	return filer_pb.Remove(d, dir, entryName, true, false, false, false, []int32{d.signature})
+}
+
+func (d *FtpDriver) RemoveAll(name string) error {
+	fullPath := d.fullPath(name)
+	if string(fullPath) == d.home {
+		return fmt.Errorf("can not remove the home directory")
+	}
+	dir, entryName := fullPath.DirAndName()
+	err := filer_pb.Remove(d, dir, entryName, true, true, true, false, []int32{d.signature})
+	if err != nil && strings.Contains(err.Error(), filer_pb.ErrNotFound.Error()) {
+		return nil
+	}
+	return err
+}
+
+func (d *FtpDriver) Rename(oldName, newName string) error {
+	oldDir, oldEntryName := d.fullPath(oldName).DirAndName()
+	newDir, newEntryName := d.fullPath(newName).DirAndName()
+	return d.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		_, err := client.AtomicRenameEntry(context.Background(), &filer_pb.AtomicRenameEntryRequest{
+			OldDirectory: oldDir,
+			OldName:      oldEntryName,
+			NewDirectory: newDir,
+			NewName:      newEntryName,
+			Signatures:   []int32{d.signature},
+		})
+		return err
+	})
+}
+
+func (d *FtpDriver) changeAttributes(name string, fn func(attributes *filer_pb.FuseAttributes)) error {
+	fullPath, entry, err := d.lookup(name)
+	if err != nil {
+		return err
+	}
+	fn(entry.Attributes)
+	dir, _ := fullPath.DirAndName()
+	return d.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		_, err := client.UpdateEntry(context.Background(), &filer_pb.UpdateEntryRequest{
+			Directory:  dir,
+			Entry:      entry,
+			Signatures: []int32{d.signature},
+		})
+		return err
+	})
+}
+
+func (d *FtpDriver) Chmod(name string, mode os.FileMode) error {
+	return d.changeAttributes(name, func(attributes *filer_pb.FuseAttributes) {
+		attributes.FileMode = uint32(os.FileMode(attributes.FileMode)&^os.ModePerm | mode&os.ModePerm)
+	})
+}
+
+func (d *FtpDriver) Chown(name string, uid, gid int) error {
+	return d.changeAttributes(name, func(attributes *filer_pb.FuseAttributes) {
+		attributes.Uid, attributes.Gid = uint32(uid), uint32(gid)
+	})
+}
+
+// Chtimes sets the modification time, for MFMT.
+func (d *FtpDriver) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return d.changeAttributes(name, func(attributes *filer_pb.FuseAttributes) {
+		attributes.Mtime = mtime.Unix()
+	})
+}
+
+// GetHandle opens a file to download, for RETR, or to upload, for STOR and APPE.
+func (d *FtpDriver) GetHandle(name string, flags int, offset int64) (ftpserver.FileTransfer, error) {
+	fullPath, entry, err := d.lookup(name)
+	if err != nil && err != os.ErrNotExist {
+		return nil, err
+	}
+	if entry != nil && entry.IsDirectory {
+		return nil, fmt.Errorf("%s is a directory", name)
+	}
+
+	if flags&os.O_WRONLY == 0 {
+		if entry == nil {
+			return nil, os.ErrNotExist
+		}
+		return newDownload(d, fullPath, entry), nil
+	}
+
+	upload := newUpload(d, fullPath, entry)
+	if entry == nil || flags&os.O_TRUNC != 0 {
+		upload.truncate()
+	}
+	if flags&os.O_APPEND != 0 {
+		upload.offset = int64(filer.FileSize(upload.entry))
+	} else {
+		upload.offset = offset
+	}
+	glog.V(2).Infof("ftp upload %s from %d", fullPath, upload.offset)
+	return upload, nil
+}
+
+func (d *FtpDriver) Create(name string) (afero.File, error) {
+	return nil, errUseFileTransfer
+}
+
+func (d *FtpDriver) Open(name string) (afero.File, error) {
+	return nil, errUseFileTransfer
+}
+
+func (d *FtpDriver) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	return nil, errUseFileTransfer
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func newFileInfo(fullPath util.FullPath, entry *filer_pb.Entry) *fileInfo {
+	mode := os.FileMode(entry.Attributes.FileMode)
+	if entry.IsDirectory {
+		mode |= os.ModeDir
+	}
+	return &fileInfo{
+		name:    fullPath.Name(),
+		size:    int64(filer.FileSize(entry)),
+		mode:    mode,
+		modTime: time.Unix(entry.Attributes.Mtime, 0),
+	}
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *fileInfo) Sys() interface{}   { return nil }
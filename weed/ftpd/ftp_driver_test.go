@@ -0,0 +1,24 @@
+package ftpd
+
+import (
+	"testing"
+)
+
+func TestFtpDriverFullPath(t *testing.T) {
+	d := newFtpDriver(&FtpServerOption{}, "/home/alice", "localhost:8888")
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"/", "/home/alice"},
+		{"/a/b.txt", "/home/alice/a/b.txt"},
+		{"a", "/home/alice/a"},
+		{"/../bob/c.txt", "/home/alice/bob/c.txt"},
+		{"/a/../../..", "/home/alice"},
+	}
+	for _, tt := range tests {
+		if got := string(d.fullPath(tt.name)); got != tt.want {
+			t.Errorf("fullPath(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
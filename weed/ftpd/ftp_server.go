@@ -1,13 +1,19 @@
 package ftpd
 
 import (
+	"crypto/subtle"
 	"crypto/tls"
 	"errors"
-	"github.com/seaweedfs/seaweedfs/weed/util"
+	"fmt"
 	"net"
+	"path"
 
 	ftpserver "github.com/fclairamb/ftpserverlib"
 	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
 type FtpServerOption struct {
@@ -20,6 +26,21 @@ type FtpServerOption struct {
 	GrpcDialOption   grpc.DialOption
 	PassivePortStart int
 	PassivePortStop  int
+	Collection       string
+	Replication      string
+	DiskType         string
+	Cipher           bool
+	// Users are the ftp accounts, by user name
+	Users map[string]FtpUser
+	// TLSConfig enables FTPS if not nil
+	TLSConfig   *tls.Config
+	TLSRequired bool
+}
+
+// FtpUser can only access its home directory, under the FtpRoot on the filer.
+type FtpUser struct {
+	Password string
+	Home     string
 }
 
 type SftpServer struct {
@@ -39,6 +60,11 @@ func NewFtpServer(ftpListener net.Listener, option *FtpServerOption) (*SftpServe
 	return server, err
 }
 
+// Serve accepts ftp clients until the listener is closed
+func (s *SftpServer) Serve() error {
+	return ftpserver.NewFtpServer(s).ListenAndServe()
+}
+
 // GetSettings returns some general settings around the server setup
 func (s *SftpServer) GetSettings() (*ftpserver.Settings, error) {
 	var portRange *ftpserver.PortRange
@@ -49,6 +75,11 @@ func (s *SftpServer) GetSettings() (*ftpserver.Settings, error) {
 		}
 	}
 
+	tlsRequired := ftpserver.ClearOrEncrypted
+	if s.option.TLSConfig != nil && s.option.TLSRequired {
+		tlsRequired = ftpserver.MandatoryEncryption
+	}
+
 	return &ftpserver.Settings{
 		Listener:                 s.ftpListener,
 		ListenAddr:               util.JoinHostPort(s.option.IpBind, s.option.Port),
@@ -57,6 +88,7 @@ func (s *SftpServer) GetSettings() (*ftpserver.Settings, error) {
 		ActiveTransferPortNon20:  true,
 		IdleTimeout:              -1,
 		ConnectionTimeout:        20,
+		TLSRequired:              tlsRequired,
 	}, nil
 }
 
@@ -71,11 +103,24 @@ func (s *SftpServer) ClientDisconnected(cc ftpserver.ClientContext) {
 
 // AuthUser authenticates the user and selects an handling driver
 func (s *SftpServer) AuthUser(cc ftpserver.ClientContext, username, password string) (ftpserver.ClientDriver, error) {
-	return nil, nil
+	user, found := s.option.Users[username]
+	if !found || subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) != 1 {
+		glog.V(0).Infof("ftp login %s from %v failed", username, cc.RemoteAddr())
+		return nil, fmt.Errorf("invalid user or password")
+	}
+	glog.V(1).Infof("ftp login %s from %v", username, cc.RemoteAddr())
+	driver := newFtpDriver(s.option, path.Join("/", s.option.FtpRoot, user.Home), pb.ServerAddress(s.option.Filer))
+	if err := driver.MkdirAll("/", 0755); err != nil {
+		return nil, fmt.Errorf("create home directory of %s: %v", username, err)
+	}
+	return driver, nil
 }
 
 // GetTLSConfig returns a TLS Certificate to use
 // The certificate could frequently change if we use something like "let's encrypt"
 func (s *SftpServer) GetTLSConfig() (*tls.Config, error) {
-	return nil, errors.New("no TLS certificate configured")
+	if s.option.TLSConfig == nil {
+		return nil, errors.New("no TLS certificate configured")
+	}
+	return s.option.TLSConfig, nil
 }
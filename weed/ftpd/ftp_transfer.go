@@ -0,0 +1,218 @@
+package ftpd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// uploadChunkSize is the size of the chunks an ftp upload is cut into
+const uploadChunkSize = 4 * 1024 * 1024
+
+// download streams the file content from the volume servers.
+type download struct {
+	fullPath util.FullPath
+	reader   io.ReadSeeker
+}
+
+func newDownload(d *FtpDriver, fullPath util.FullPath, entry *filer_pb.Entry) *download {
+	var reader io.ReadSeeker
+	if len(entry.Content) > 0 {
+		reader = bytes.NewReader(entry.Content)
+	} else {
+		reader = filer.NewChunkStreamReader(d, entry.GetChunks())
+	}
+	return &download{
+		fullPath: fullPath,
+		reader:   reader,
+	}
+}
+
+func (dl *download) Read(p []byte) (int, error) {
+	return dl.reader.Read(p)
+}
+
+func (dl *download) Seek(offset int64, whence int) (int64, error) {
+	return dl.reader.Seek(offset, whence)
+}
+
+func (dl *download) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("%s is opened for download", dl.fullPath)
+}
+
+func (dl *download) Close() error {
+	if chunkReader, ok := dl.reader.(*filer.ChunkStreamReader); ok {
+		chunkReader.Close()
+	}
+	return nil
+}
+
+// upload cuts the written data into chunks, and saves the entry on Close.
+type upload struct {
+	d         *FtpDriver
+	fullPath  util.FullPath
+	entry     *filer_pb.Entry
+	isNew     bool
+	offset    int64
+	buffer    bytes.Buffer
+	bufferOff int64
+	failed    bool
+}
+
+func newUpload(d *FtpDriver, fullPath util.FullPath, entry *filer_pb.Entry) *upload {
+	return &upload{
+		d:        d,
+		fullPath: fullPath,
+		entry:    entry,
+	}
+}
+
+// truncate starts a new empty entry, which replaces the existing one on Close.
+func (u *upload) truncate() {
+	now := time.Now().Unix()
+	u.entry = &filer_pb.Entry{
+		Name: u.fullPath.Name(),
+		Attributes: &filer_pb.FuseAttributes{
+			Mtime:    now,
+			Crtime:   now,
+			FileMode: 0644,
+		},
+	}
+	u.isNew = true
+}
+
+func (u *upload) Read(p []byte) (int, error) {
+	return 0, fmt.Errorf("%s is opened for upload", u.fullPath)
+}
+
+func (u *upload) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart || u.buffer.Len() > 0 {
+		return u.offset, os.ErrInvalid
+	}
+	u.offset = offset
+	return u.offset, nil
+}
+
+func (u *upload) Write(p []byte) (n int, err error) {
+	if u.buffer.Len() == 0 {
+		u.bufferOff = u.offset
+	}
+	n, _ = u.buffer.Write(p)
+	u.offset += int64(n)
+	if u.buffer.Len() >= uploadChunkSize {
+		err = u.flush()
+	}
+	return
+}
+
+func (u *upload) flush() error {
+	if u.buffer.Len() == 0 {
+		return nil
+	}
+	if len(u.entry.Content) > 0 {
+		// keep the existing inline content as a chunk, under the appended data
+		chunk, err := u.saveDataAsChunk(bytes.NewReader(u.entry.Content), 0, u.entry.Attributes.Mtime*1e9)
+		if err != nil {
+			u.failed = true
+			return err
+		}
+		u.entry.Content = nil
+		u.entry.Chunks = append(u.entry.GetChunks(), chunk)
+	}
+	chunk, err := u.saveDataAsChunk(bytes.NewReader(u.buffer.Bytes()), u.bufferOff, time.Now().UnixNano())
+	if err != nil {
+		u.failed = true
+		return err
+	}
+	u.entry.Chunks = append(u.entry.GetChunks(), chunk)
+	if end := uint64(u.bufferOff) + uint64(u.buffer.Len()); end > u.entry.Attributes.FileSize {
+		u.entry.Attributes.FileSize = end
+	}
+	u.bufferOff += int64(u.buffer.Len())
+	u.buffer.Reset()
+	return nil
+}
+
+func (u *upload) saveDataAsChunk(reader io.Reader, offset int64, tsNs int64) (chunk *filer_pb.FileChunk, err error) {
+	option := u.d.option
+	fileId, uploadResult, uploadErr, _ := operation.UploadWithRetry(
+		u.d,
+		&filer_pb.AssignVolumeRequest{
+			Count:       1,
+			Replication: option.Replication,
+			Collection:  option.Collection,
+			DiskType:    option.DiskType,
+			Path:        string(u.fullPath),
+		},
+		&operation.UploadOption{
+			Filename: u.entry.Name,
+			Cipher:   option.Cipher,
+		},
+		func(host, fileId string) string {
+			return fmt.Sprintf("http://%s/%s", host, fileId)
+		},
+		reader,
+	)
+	if uploadErr != nil {
+		glog.V(0).Infof("upload data %v: %v", u.fullPath, uploadErr)
+		return nil, fmt.Errorf("upload data: %v", uploadErr)
+	}
+	if uploadResult.Error != "" {
+		glog.V(0).Infof("upload failure %v: %v", u.fullPath, uploadResult.Error)
+		return nil, fmt.Errorf("upload result: %v", uploadResult.Error)
+	}
+	return uploadResult.ToPbFileChunk(fileId, offset, tsNs), nil
+}
+
+// TransferError is called by the ftp server if the data connection fails,
+// so that a partial upload does not replace the existing file.
+func (u *upload) TransferError(err error) {
+	u.failed = true
+}
+
+func (u *upload) Close() error {
+	if u.failed {
+		return nil
+	}
+	if err := u.flush(); err != nil {
+		return err
+	}
+
+	manifestedChunks, manifestErr := filer.MaybeManifestize(func(reader io.Reader, name string, offset int64, tsNs int64) (*filer_pb.FileChunk, error) {
+		return u.saveDataAsChunk(reader, offset, tsNs)
+	}, u.entry.GetChunks())
+	if manifestErr != nil {
+		// not good, but should be ok
+		glog.V(0).Infof("file %s close MaybeManifestize: %v", u.fullPath, manifestErr)
+	} else {
+		u.entry.Chunks = manifestedChunks
+	}
+	u.entry.Attributes.Mtime = time.Now().Unix()
+
+	dir, _ := u.fullPath.DirAndName()
+	return u.d.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		if u.isNew {
+			// replaces the existing entry, if any, and deletes its chunks
+			return filer_pb.CreateEntry(client, &filer_pb.CreateEntryRequest{
+				Directory:  dir,
+				Entry:      u.entry,
+				Signatures: []int32{u.d.signature},
+			})
+		}
+		_, err := client.UpdateEntry(context.Background(), &filer_pb.UpdateEntryRequest{
+			Directory:  dir,
+			Entry:      u.entry,
+			Signatures: []int32{u.d.signature},
+		})
+		return err
+	})
+}
@@ -455,13 +455,17 @@ type loggingT struct {
 
 	// added by seaweedfs
 	exited bool
+	// sink replaces the text output if set, e.g., by -log-format=json.
+	sink        LogSink
+	sinkChecked bool
 }
 
 // buffer holds a byte Buffer for reuse. The zero value is ready for use.
 type buffer struct {
 	bytes.Buffer
-	tmp  [64]byte // temporary byte array for creating headers.
-	next *buffer
+	tmp       [64]byte // temporary byte array for creating headers.
+	next      *buffer
+	headerLen int // length of the text header, skipped by the log sink.
 }
 
 var logging loggingT
@@ -585,6 +589,7 @@ func (l *loggingT) formatHeader(s severity, file string, line int) *buffer {
 	n := buf.someDigits(1, line)
 	buf.tmp[n+1] = ' '
 	buf.Write(buf.tmp[:n+2])
+	buf.headerLen = buf.Len()
 	return buf
 }
 
@@ -678,7 +683,15 @@ func (l *loggingT) output(s severity, buf *buffer, file string, line int, alsoTo
 		}
 	}
 	data := buf.Bytes()
-	if l.toStderr {
+	if sink := l.logSink(); sink != nil {
+		sink.Emit(LogEntry{
+			Time:    timeNow(),
+			Level:   severityName[s],
+			File:    file,
+			Line:    line,
+			Message: string(data[buf.headerLen:]),
+		})
+	} else if l.toStderr {
 		os.Stderr.Write(data)
 	} else {
 		if alsoToStderr || l.alsoToStderr || s >= l.stderrThreshold.get() {
@@ -896,6 +909,9 @@ func (l *loggingT) lockAndFlushAll() {
 // flushAll flushes all the logs and attempts to "sync" their data to disk.
 // l.mu is held.
 func (l *loggingT) flushAll() {
+	if l.sink != nil {
+		l.sink.Flush() // ignore error
+	}
 	// Flush from fatal down, in case there's trouble flushing.
 	for s := fatalLog; s >= infoLog; s-- {
 		file := l.file[s]
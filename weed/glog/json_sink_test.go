@@ -0,0 +1,39 @@
+package glog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJsonSink(t *testing.T) {
+	setFlags()
+	defer logging.swap(logging.newBuffers())
+
+	var out bytes.Buffer
+	SetLogSink(NewJsonSink(&out))
+	defer SetLogSink(nil)
+
+	Warningf("disk %s is %d%% full", "/data", 95)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("not a json line %q: %v", out.String(), err)
+	}
+	if line["level"] != "WARNING" {
+		t.Errorf("level = %v, want WARNING", line["level"])
+	}
+	if line["msg"] != "disk /data is 95% full" {
+		t.Errorf("msg = %q", line["msg"])
+	}
+	if caller, _ := line["caller"].(string); !strings.HasPrefix(caller, "json_sink_test.go:") {
+		t.Errorf("caller = %q", caller)
+	}
+	if _, ok := line["ts"]; !ok {
+		t.Errorf("missing ts in %v", line)
+	}
+	if contents(warningLog) != "" {
+		t.Errorf("text log should be replaced by the sink, got %q", contents(warningLog))
+	}
+}
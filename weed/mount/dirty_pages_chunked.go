@@ -12,6 +12,7 @@ import (
 type ChunkedDirtyPages struct {
 	fh             *FileHandle
 	writeWaitGroup sync.WaitGroup
+	lastErrLock    sync.Mutex
 	lastErr        error
 	collection     string
 	replication    string
@@ -51,6 +52,8 @@ func (pages *ChunkedDirtyPages) FlushData() error {
 		return nil
 	}
 	pages.uploadPipeline.FlushAll()
+	pages.lastErrLock.Lock()
+	defer pages.lastErrLock.Unlock()
 	if pages.lastErr != nil {
 		return fmt.Errorf("flush data: %v", pages.lastErr)
 	}
@@ -73,7 +76,10 @@ func (pages *ChunkedDirtyPages) saveChunkedFileIntervalToStorage(reader io.Reade
 	chunk, err := pages.fh.wfs.saveDataAsChunk(fileFullPath)(reader, fileName, offset, modifiedTsNs)
 	if err != nil {
 		glog.V(0).Infof("%v saveToStorage [%d,%d): %v", fileFullPath, offset, offset+size, err)
+		// the chunks are uploaded in parallel by the upload pipeline
+		pages.lastErrLock.Lock()
 		pages.lastErr = err
+		pages.lastErrLock.Unlock()
 		return
 	}
 	pages.fh.AddChunks([]*filer_pb.FileChunk{chunk})
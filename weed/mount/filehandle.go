@@ -31,6 +31,9 @@ type FileHandle struct {
 
 	isDeleted bool
 
+	downloadLimiter     *tokenBucket
+	downloadLimiterOnce sync.Once
+
 	// for debugging
 	mirrorFile *os.File
 }
@@ -76,7 +79,10 @@ func (fh *FileHandle) SetEntry(entry *filer_pb.Entry) {
 		fileSize := filer.FileSize(entry)
 		entry.Attributes.FileSize = fileSize
 		var resolveManifestErr error
-		fh.entryChunkGroup, resolveManifestErr = filer.NewChunkGroup(fh.wfs.LookupFn(), fh.wfs.chunkCache, entry.Chunks)
+		if fh.entryChunkGroup != nil {
+			fh.entryChunkGroup.Destroy()
+		}
+		fh.entryChunkGroup, resolveManifestErr = filer.NewChunkGroup(fh.wfs.LookupFn(fh.FullPath()), fh.wfs.readChunkCache(), entry.Chunks, fh.wfs.readRetry, fh.wfs.readAhead)
 		if resolveManifestErr != nil {
 			glog.Warningf("failed to resolve manifest chunks in %+v", entry)
 		}
@@ -109,6 +115,9 @@ func (fh *FileHandle) ReleaseHandle() {
 	defer fh.entryLock.Unlock()
 
 	fh.dirtyPages.Destroy()
+	if fh.entryChunkGroup != nil {
+		fh.entryChunkGroup.Destroy()
+	}
 	if IsDebugFileReadWrite {
 		fh.mirrorFile.Close()
 	}
@@ -256,24 +256,40 @@ func (i *InodeToPath) MovePath(sourcePath, targetPath util.FullPath) (sourceInod
 }
 
 func (i *InodeToPath) Forget(inode, nlookup uint64, onForgetDir func(dir util.FullPath)) {
+	i.ForgetBatch([]forgetRequest{{inode: inode, nlookup: nlookup}}, onForgetDir)
+}
+
+// ForgetBatch takes the lock once for the batch, and returns the evicted inodes.
+// onForgetDir is called after the lock is released.
+func (i *InodeToPath) ForgetBatch(forgets []forgetRequest, onForgetDir func(dir util.FullPath)) (evicted []uint64) {
+	var forgottenDirs []*InodeEntry
 	i.Lock()
-	path, found := i.inode2path[inode]
-	if found {
-		path.nlookup -= nlookup
+	for _, forget := range forgets {
+		path, found := i.inode2path[forget.inode]
+		if !found {
+			continue
+		}
+		path.nlookup -= forget.nlookup
 		if path.nlookup <= 0 {
 			for _, p := range path.paths {
 				delete(i.path2inode, p)
 			}
-			delete(i.inode2path, inode)
+			delete(i.inode2path, forget.inode)
+			evicted = append(evicted, forget.inode)
+			if path.isDirectory {
+				forgottenDirs = append(forgottenDirs, path)
+			}
 		}
 	}
 	i.Unlock()
-	if found {
-		if path.isDirectory && path.nlookup <= 0 && onForgetDir != nil {
-			path.isChildrenCached = false
-			for _, p := range path.paths {
-				onForgetDir(p)
-			}
+	if onForgetDir == nil {
+		return
+	}
+	for _, path := range forgottenDirs {
+		path.isChildrenCached = false
+		for _, p := range path.paths {
+			onForgetDir(p)
 		}
 	}
+	return
 }
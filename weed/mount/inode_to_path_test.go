@@ -1,6 +1,8 @@
 package mount
 
 import (
+	"fmt"
+	"github.com/hanwen/go-fuse/v2/fuse"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"testing"
 )
@@ -91,3 +93,55 @@ func TestInodeEntry_removeOnePath(t *testing.T) {
 		})
 	}
 }
+
+func TestInodeToPath_ForgetBatch(t *testing.T) {
+	i := NewInodeToPath("/")
+	dir := i.Lookup("/d", 1, true, false, 0, true)
+	file := i.Lookup("/d/f", 1, false, false, 0, true)
+	i.Lookup("/d/f", 1, false, false, 0, true)
+
+	var forgottenDirs []util.FullPath
+	evicted := i.ForgetBatch([]forgetRequest{
+		{inode: dir, nlookup: 1},
+		{inode: file, nlookup: 1},
+		{inode: 12345, nlookup: 1},
+	}, func(dir util.FullPath) {
+		forgottenDirs = append(forgottenDirs, dir)
+	})
+
+	if len(evicted) != 1 || evicted[0] != dir {
+		t.Errorf("evicted %v, want [%d]", evicted, dir)
+	}
+	if len(forgottenDirs) != 1 || forgottenDirs[0] != "/d" {
+		t.Errorf("forgotten dirs %v", forgottenDirs)
+	}
+	if _, status := i.GetPath(file); status != fuse.OK {
+		t.Errorf("file looked up twice is evicted after one forget: %v", status)
+	}
+}
+
+func BenchmarkInodeToPath_LookupWithForget(b *testing.B) {
+	i := NewInodeToPath("/")
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		batch := make([]forgetRequest, 0, forgetBatchSize)
+		for n := 0; ; n++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			p := util.FullPath(fmt.Sprintf("/dir/f%d", n%10000))
+			batch = append(batch, forgetRequest{inode: i.Lookup(p, 1, false, false, 0, true), nlookup: 1})
+			if len(batch) == forgetBatchSize {
+				i.ForgetBatch(batch, nil)
+				batch = batch[:0]
+			}
+		}
+	}()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		i.Lookup("/dir/hot", 1, false, false, 0, true)
+	}
+}
@@ -14,6 +14,9 @@ type UidGidMapper struct {
 type IdMapper struct {
 	localToFiler map[uint32]uint32
 	filerToLocal map[uint32]uint32
+	// optional, for the ids not in the static maps
+	lookupLocalToFiler func(id uint32) (uint32, bool)
+	lookupFilerToLocal func(id uint32) (uint32, bool)
 }
 
 // UidGidMapper translates local uid/gid to filer uid/gid
@@ -36,6 +39,14 @@ func NewUidGidMapper(uidPairsStr, gidPairStr string) (*UidGidMapper, error) {
 	}, nil
 }
 
+// UseLDAP maps the ids not in the static uid and gid maps by the ldap user and group names.
+func (m *UidGidMapper) UseLDAP(ldapMapper *LDAPUidGidMapper) {
+	m.uidMapper.lookupLocalToFiler = ldapMapper.uidLocalToFiler
+	m.uidMapper.lookupFilerToLocal = ldapMapper.uidFilerToLocal
+	m.gidMapper.lookupLocalToFiler = ldapMapper.gidLocalToFiler
+	m.gidMapper.lookupFilerToLocal = ldapMapper.gidFilerToLocal
+}
+
 func (m *UidGidMapper) LocalToFiler(uid, gid uint32) (uint32, uint32) {
 	return m.uidMapper.LocalToFiler(uid), m.gidMapper.LocalToFiler(gid)
 }
@@ -48,6 +59,11 @@ func (m *IdMapper) LocalToFiler(id uint32) uint32 {
 	if found {
 		return value
 	}
+	if m.lookupLocalToFiler != nil {
+		if value, found = m.lookupLocalToFiler(id); found {
+			return value
+		}
+	}
 	return id
 }
 func (m *IdMapper) FilerToLocal(id uint32) uint32 {
@@ -55,6 +71,11 @@ func (m *IdMapper) FilerToLocal(id uint32) uint32 {
 	if found {
 		return value
 	}
+	if m.lookupFilerToLocal != nil {
+		if value, found = m.lookupFilerToLocal(id); found {
+			return value
+		}
+	}
 	return id
 }
 
@@ -0,0 +1,353 @@
+package meta_cache
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// a minimal LDAPv3 client (RFC 4511), only for StartTLS, a simple bind and an equality search
+
+const (
+	berTagBoolean     = 0x01
+	berTagInteger     = 0x02
+	berTagOctetString = 0x04
+	berTagEnumerated  = 0x0a
+	berTagSequence    = 0x30
+	berTagSet         = 0x31
+
+	ldapTagBindRequest       = 0x60
+	ldapTagBindResponse      = 0x61
+	ldapTagUnbindRequest     = 0x42
+	ldapTagSearchRequest     = 0x63
+	ldapTagSearchResultEntry = 0x64
+	ldapTagSearchResultDone  = 0x65
+	ldapTagSearchResultRef   = 0x73
+	ldapTagExtendedRequest   = 0x77
+	ldapTagExtendedResponse  = 0x78
+	ldapTagExtendedName      = 0x80
+	ldapTagSimpleAuth        = 0x80
+	ldapTagEqualityMatch     = 0xa3
+
+	ldapScopeWholeSubtree = 2
+	ldapResultSuccess     = 0
+
+	ldapStartTLSOid = "1.3.6.1.4.1.1466.20037"
+
+	ldapTimeout = 5 * time.Second
+	// the uid and gid searches return a few small attributes, so a larger message is rejected before reading it
+	ldapMaxMessageSize = 1024 * 1024
+)
+
+type ldapConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	messageId int64
+}
+
+// dialLdap connects to ldap://host[:389] or ldaps://host[:636], and upgrades ldap:// to tls with startTLS
+func dialLdap(server string, startTLS bool) (*ldapConn, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("parse ldap server %s: %v", server, err)
+	}
+	host := u.Host
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: ldapTimeout}
+	switch u.Scheme {
+	case "ldap":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+		conn, err = dialer.Dial("tcp", host)
+	case "ldaps":
+		if startTLS {
+			return nil, fmt.Errorf("ldap server %s: startTLS is only for ldap://", server)
+		}
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("ldap server %s: unsupported scheme %q, expecting ldap:// or ldaps://", server, u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dial ldap server %s: %v", server, err)
+	}
+	c := &ldapConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+	if startTLS {
+		if err = c.StartTLS(u.Hostname()); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// StartTLS upgrades the connection to tls, before any bind
+func (c *ldapConn) StartTLS(serverName string) error {
+	if err := c.send(berEncode(ldapTagExtendedRequest,
+		berEncode(ldapTagExtendedName, []byte(ldapStartTLSOid)),
+	)); err != nil {
+		return err
+	}
+	tag, op, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if tag != ldapTagExtendedResponse {
+		return fmt.Errorf("ldap startTLS: unexpected response tag 0x%x", tag)
+	}
+	if err = ldapResultError("startTLS", op); err != nil {
+		return err
+	}
+	tlsConn := tls.Client(c.conn, &tls.Config{ServerName: serverName})
+	tlsConn.SetDeadline(time.Now().Add(ldapTimeout))
+	if err = tlsConn.Handshake(); err != nil {
+		return fmt.Errorf("ldap startTLS handshake: %v", err)
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+func (c *ldapConn) Close() error {
+	c.messageId++
+	c.conn.Write(berEncode(berTagSequence, berInteger(berTagInteger, c.messageId), []byte{ldapTagUnbindRequest, 0}))
+	return c.conn.Close()
+}
+
+func (c *ldapConn) Bind(dn, password string) error {
+	if err := c.send(berEncode(ldapTagBindRequest,
+		berInteger(berTagInteger, 3),
+		berEncode(berTagOctetString, []byte(dn)),
+		berEncode(ldapTagSimpleAuth, []byte(password)),
+	)); err != nil {
+		return err
+	}
+	tag, op, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if tag != ldapTagBindResponse {
+		return fmt.Errorf("ldap bind: unexpected response tag 0x%x", tag)
+	}
+	return ldapResultError("bind", op)
+}
+
+// Search returns the values of the attribute on the entries matching (filterAttr=filterValue) under the base dn
+func (c *ldapConn) Search(baseDn, filterAttr, filterValue, attr string) (values []string, err error) {
+	if err = c.send(berEncode(ldapTagSearchRequest,
+		berEncode(berTagOctetString, []byte(baseDn)),
+		berInteger(berTagEnumerated, ldapScopeWholeSubtree),
+		berInteger(berTagEnumerated, 0), // neverDerefAliases
+		berInteger(berTagInteger, 0),    // no size limit
+		berInteger(berTagInteger, 0),    // no time limit
+		berEncode(berTagBoolean, []byte{0}),
+		berEncode(ldapTagEqualityMatch,
+			berEncode(berTagOctetString, []byte(filterAttr)),
+			berEncode(berTagOctetString, []byte(filterValue)),
+		),
+		berEncode(berTagSequence, berEncode(berTagOctetString, []byte(attr))),
+	)); err != nil {
+		return nil, err
+	}
+	for {
+		tag, op, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+		switch tag {
+		case ldapTagSearchResultEntry:
+			entryValues, err := parseSearchResultEntry(op, attr)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, entryValues...)
+		case ldapTagSearchResultRef:
+			// referrals are not followed
+		case ldapTagSearchResultDone:
+			return values, ldapResultError("search", op)
+		default:
+			return nil, fmt.Errorf("ldap search: unexpected response tag 0x%x", tag)
+		}
+	}
+}
+
+// send also sets the deadline of the response, since the connection is kept between the searches
+func (c *ldapConn) send(protocolOp []byte) error {
+	c.messageId++
+	c.conn.SetDeadline(time.Now().Add(ldapTimeout))
+	_, err := c.conn.Write(berEncode(berTagSequence, berInteger(berTagInteger, c.messageId), protocolOp))
+	return err
+}
+
+// receive returns the protocol op of the next message
+func (c *ldapConn) receive() (tag byte, op []byte, err error) {
+	tag, message, err := berRead(c.reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("read ldap message: %v", err)
+	}
+	if tag != berTagSequence {
+		return 0, nil, fmt.Errorf("ldap message tag 0x%x", tag)
+	}
+	_, _, rest, err := berParse(message) // message id
+	if err != nil {
+		return 0, nil, err
+	}
+	tag, op, _, err = berParse(rest)
+	return
+}
+
+func ldapResultError(name string, result []byte) error {
+	_, code, rest, err := berParse(result)
+	if err != nil {
+		return err
+	}
+	if resultCode := berParseInt(code); resultCode != ldapResultSuccess {
+		_, _, rest, _ = berParse(rest) // matched dn
+		_, message, _, _ := berParse(rest)
+		return fmt.Errorf("ldap %s: result code %d: %s", name, resultCode, message)
+	}
+	return nil
+}
+
+func parseSearchResultEntry(entry []byte, attr string) (values []string, err error) {
+	_, _, rest, err := berParse(entry) // object name
+	if err != nil {
+		return nil, err
+	}
+	_, attributes, _, err := berParse(rest)
+	if err != nil {
+		return nil, err
+	}
+	for len(attributes) > 0 {
+		var attribute, name, vals []byte
+		if _, attribute, attributes, err = berParse(attributes); err != nil {
+			return nil, err
+		}
+		if _, name, attribute, err = berParse(attribute); err != nil {
+			return nil, err
+		}
+		if _, vals, _, err = berParse(attribute); err != nil {
+			return nil, err
+		}
+		if string(name) != attr {
+			continue
+		}
+		for len(vals) > 0 {
+			var val []byte
+			if _, val, vals, err = berParse(vals); err != nil {
+				return nil, err
+			}
+			values = append(values, string(val))
+		}
+	}
+	return values, nil
+}
+
+func berEncode(tag byte, contents ...[]byte) []byte {
+	length := 0
+	for _, c := range contents {
+		length += len(c)
+	}
+	buf := []byte{tag}
+	if length < 0x80 {
+		buf = append(buf, byte(length))
+	} else {
+		var lengthBytes []byte
+		for l := length; l > 0; l >>= 8 {
+			lengthBytes = append([]byte{byte(l)}, lengthBytes...)
+		}
+		buf = append(buf, 0x80|byte(len(lengthBytes)))
+		buf = append(buf, lengthBytes...)
+	}
+	for _, c := range contents {
+		buf = append(buf, c...)
+	}
+	return buf
+}
+
+func berInteger(tag byte, v int64) []byte {
+	var b []byte
+	for {
+		b = append([]byte{byte(v)}, b...)
+		if (v < 0x80 && v >= -0x80) || len(b) == 8 {
+			break
+		}
+		v >>= 8
+	}
+	return berEncode(tag, b)
+}
+
+func berParseInt(b []byte) (v int64) {
+	for i, c := range b {
+		if i == 0 && c&0x80 != 0 {
+			v = -1
+		}
+		v = v<<8 | int64(c)
+	}
+	return
+}
+
+// berParse splits the first tag-length-value from data
+func berParse(data []byte) (tag byte, value, rest []byte, err error) {
+	if len(data) < 2 {
+		return 0, nil, nil, fmt.Errorf("ber: truncated")
+	}
+	tag = data[0]
+	length, n := int(data[1]), 2
+	if length&0x80 != 0 {
+		size := length & 0x7f
+		if size == 0 || size > 4 || len(data) < 2+size {
+			return 0, nil, nil, fmt.Errorf("ber: invalid length")
+		}
+		length = 0
+		for _, b := range data[2 : 2+size] {
+			length = length<<8 | int(b)
+		}
+		n += size
+	}
+	if len(data) < n+length {
+		return 0, nil, nil, fmt.Errorf("ber: truncated")
+	}
+	return tag, data[n : n+length], data[n+length:], nil
+}
+
+// berRead reads one tag-length-value from the stream
+func berRead(r *bufio.Reader) (tag byte, value []byte, err error) {
+	if tag, err = r.ReadByte(); err != nil {
+		return
+	}
+	b, err := r.ReadByte()
+	if err != nil {
+		return
+	}
+	length := int(b)
+	if length&0x80 != 0 {
+		size := length & 0x7f
+		if size == 0 || size > 4 {
+			return 0, nil, fmt.Errorf("ber: invalid length")
+		}
+		length = 0
+		for i := 0; i < size; i++ {
+			if b, err = r.ReadByte(); err != nil {
+				return
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	if length > ldapMaxMessageSize {
+		return 0, nil, fmt.Errorf("ber: length %d exceeds %d", length, ldapMaxMessageSize)
+	}
+	value = make([]byte, length)
+	_, err = io.ReadFull(r, value)
+	return
+}
@@ -0,0 +1,226 @@
+package meta_cache
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+// LDAPUidGidMapper maps the local uid and gid to the filer ones by the user and group names.
+// The filer uid is the uidNumber of the (uid=<username>) entry, and the filer gid is
+// the gidNumber of the (cn=<groupname>) entry. The results, including the misses, are cached for the ttl.
+// The searches share one bound connection, and a file operation waits at most lookupTimeout for a search.
+type LDAPUidGidMapper struct {
+	server        string
+	startTLS      bool
+	baseDn        string
+	bindDn        string
+	bindPassword  string
+	ttl           time.Duration
+	lookupTimeout time.Duration
+	search        func(filterAttr, filterValue, attr string) (string, bool, error)
+
+	connLock sync.Mutex
+	conn     *ldapConn
+
+	cacheLock sync.Mutex
+	cache     map[string]*ldapCacheEntry
+	searching map[string]chan struct{}
+}
+
+type ldapCacheEntry struct {
+	value    string
+	found    bool
+	expireAt time.Time
+}
+
+const ldapLookupTimeout = 2 * time.Second
+
+func NewLDAPUidGidMapper(server string, startTLS bool, baseDn, bindDn, bindPassword string, ttl time.Duration) (*LDAPUidGidMapper, error) {
+	if baseDn == "" {
+		return nil, fmt.Errorf("missing ldap base dn")
+	}
+
+	m := &LDAPUidGidMapper{
+		server:        server,
+		startTLS:      startTLS,
+		baseDn:        baseDn,
+		bindDn:        bindDn,
+		bindPassword:  bindPassword,
+		ttl:           ttl,
+		lookupTimeout: ldapLookupTimeout,
+		cache:         make(map[string]*ldapCacheEntry),
+	}
+	m.search = m.searchLdap
+	// fail early on a bad server or credentials
+	m.connLock.Lock()
+	defer m.connLock.Unlock()
+	if _, err := m.boundConn(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// boundConn returns the connection kept between the searches, and dials and binds it if needed
+func (m *LDAPUidGidMapper) boundConn() (*ldapConn, error) {
+	if m.conn != nil {
+		return m.conn, nil
+	}
+	conn, err := dialLdap(m.server, m.startTLS)
+	if err != nil {
+		return nil, err
+	}
+	if m.bindDn != "" {
+		if err := conn.Bind(m.bindDn, m.bindPassword); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	m.conn = conn
+	return conn, nil
+}
+
+func (m *LDAPUidGidMapper) searchLdap(filterAttr, filterValue, attr string) (value string, found bool, err error) {
+	m.connLock.Lock()
+	defer m.connLock.Unlock()
+	// the server may have closed an idle connection, so retry once on a new one
+	for i := 0; i < 2; i++ {
+		var conn *ldapConn
+		if conn, err = m.boundConn(); err != nil {
+			return "", false, err
+		}
+		var values []string
+		if values, err = conn.Search(m.baseDn, filterAttr, filterValue, attr); err == nil {
+			if len(values) == 0 {
+				return "", false, nil
+			}
+			return values[0], true, nil
+		}
+		conn.Close()
+		m.conn = nil
+	}
+	return "", false, err
+}
+
+// lookup caches the search results. The failed searches are also cached, to not block each file operation on a down ldap server.
+// A file operation waits at most lookupTimeout for a search, and then uses the expired entry if any,
+// while the search goes on in the background to fill the cache. The concurrent lookups of a key share one search.
+func (m *LDAPUidGidMapper) lookup(filterAttr, filterValue, attr string) (string, bool) {
+	key := filterAttr + "=" + filterValue + ":" + attr
+	m.cacheLock.Lock()
+	entry, found := m.cache[key]
+	if found && time.Now().Before(entry.expireAt) {
+		m.cacheLock.Unlock()
+		return entry.value, entry.found
+	}
+	if m.searching == nil {
+		m.searching = make(map[string]chan struct{})
+	}
+	done, isSearching := m.searching[key]
+	if !isSearching {
+		done = make(chan struct{})
+		m.searching[key] = done
+		go m.refresh(key, filterAttr, filterValue, attr, done)
+	}
+	m.cacheLock.Unlock()
+
+	timer := time.NewTimer(m.lookupTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		glog.V(1).Infof("ldap search (%s=%s) %s: timed out after %v", filterAttr, filterValue, attr, m.lookupTimeout)
+	}
+
+	m.cacheLock.Lock()
+	defer m.cacheLock.Unlock()
+	if entry, found = m.cache[key]; found {
+		return entry.value, entry.found
+	}
+	return "", false
+}
+
+func (m *LDAPUidGidMapper) refresh(key, filterAttr, filterValue, attr string, done chan struct{}) {
+	value, found, err := m.search(filterAttr, filterValue, attr)
+	m.cacheLock.Lock()
+	defer m.cacheLock.Unlock()
+	if err != nil {
+		glog.Warningf("ldap search (%s=%s) %s: %v", filterAttr, filterValue, attr, err)
+		// keep the previous result while the ldap server is down
+		if previous, hasPrevious := m.cache[key]; hasPrevious {
+			value, found = previous.value, previous.found
+		}
+	}
+	m.cache[key] = &ldapCacheEntry{
+		value:    value,
+		found:    found,
+		expireAt: time.Now().Add(m.ttl),
+	}
+	delete(m.searching, key)
+	close(done)
+}
+
+func (m *LDAPUidGidMapper) lookupId(filterAttr, filterValue, attr string) (uint32, bool) {
+	value, found := m.lookup(filterAttr, filterValue, attr)
+	if !found {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		glog.Warningf("ldap (%s=%s) %s: %v", filterAttr, filterValue, attr, err)
+		return 0, false
+	}
+	return uint32(id), true
+}
+
+func (m *LDAPUidGidMapper) uidLocalToFiler(uid uint32) (uint32, bool) {
+	u, err := user.LookupId(strconv.Itoa(int(uid)))
+	if err != nil {
+		return 0, false
+	}
+	return m.lookupId("uid", u.Username, "uidNumber")
+}
+
+func (m *LDAPUidGidMapper) uidFilerToLocal(uid uint32) (uint32, bool) {
+	username, found := m.lookup("uidNumber", strconv.Itoa(int(uid)), "uid")
+	if !found {
+		return 0, false
+	}
+	u, err := user.Lookup(username)
+	if err != nil {
+		return 0, false
+	}
+	return parseLocalId(u.Uid)
+}
+
+func (m *LDAPUidGidMapper) gidLocalToFiler(gid uint32) (uint32, bool) {
+	g, err := user.LookupGroupId(strconv.Itoa(int(gid)))
+	if err != nil {
+		return 0, false
+	}
+	return m.lookupId("cn", g.Name, "gidNumber")
+}
+
+func (m *LDAPUidGidMapper) gidFilerToLocal(gid uint32) (uint32, bool) {
+	groupname, found := m.lookup("gidNumber", strconv.Itoa(int(gid)), "cn")
+	if !found {
+		return 0, false
+	}
+	g, err := user.LookupGroup(groupname)
+	if err != nil {
+		return 0, false
+	}
+	return parseLocalId(g.Gid)
+}
+
+func parseLocalId(idStr string) (uint32, bool) {
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(id), true
+}
@@ -0,0 +1,132 @@
+package meta_cache
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLdapSearchResultEntry(t *testing.T) {
+	entry := berEncode(ldapTagSearchResultEntry,
+		berEncode(berTagOctetString, []byte("uid=alice,ou=people,dc=example,dc=com")),
+		berEncode(berTagSequence,
+			berEncode(berTagSequence,
+				berEncode(berTagOctetString, []byte("objectClass")),
+				berEncode(berTagSet, berEncode(berTagOctetString, []byte("posixAccount"))),
+			),
+			berEncode(berTagSequence,
+				berEncode(berTagOctetString, []byte("uidNumber")),
+				berEncode(berTagSet, berEncode(berTagOctetString, []byte("10001"))),
+			),
+		),
+	)
+	tag, op, rest, err := berParse(entry)
+	if err != nil || tag != ldapTagSearchResultEntry || len(rest) != 0 {
+		t.Fatalf("parse: tag 0x%x rest %d: %v", tag, len(rest), err)
+	}
+	values, err := parseSearchResultEntry(op, "uidNumber")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || values[0] != "10001" {
+		t.Errorf("values %v", values)
+	}
+}
+
+func TestBerLongLength(t *testing.T) {
+	value := make([]byte, 300)
+	tag, parsed, _, err := berParse(berEncode(berTagOctetString, value))
+	if err != nil || tag != berTagOctetString || len(parsed) != 300 {
+		t.Errorf("parse 300 bytes: tag 0x%x len %d: %v", tag, len(parsed), err)
+	}
+	for _, v := range []int64{0, 3, 127, 128, 256, -1, 1 << 40} {
+		if _, b, _, _ := berParse(berInteger(berTagInteger, v)); berParseInt(b) != v {
+			t.Errorf("integer %d parsed as %d", v, berParseInt(b))
+		}
+	}
+}
+
+func TestBerReadMaxLength(t *testing.T) {
+	// a length of 2^31 in 4 bytes, without the value
+	header := []byte{berTagSequence, 0x84, 0x80, 0x00, 0x00, 0x00}
+	if _, _, err := berRead(bufio.NewReader(bytes.NewReader(header))); err == nil {
+		t.Errorf("read a message of 2^31 bytes")
+	}
+}
+
+func TestLdapLookupCache(t *testing.T) {
+	searches := 0
+	m := &LDAPUidGidMapper{
+		ttl:           time.Minute,
+		lookupTimeout: time.Minute,
+		cache:         make(map[string]*ldapCacheEntry),
+		search: func(filterAttr, filterValue, attr string) (string, bool, error) {
+			searches++
+			if filterValue == "alice" {
+				return "10001", true, nil
+			}
+			return "", false, nil
+		},
+	}
+	for i := 0; i < 3; i++ {
+		if id, found := m.lookupId("uid", "alice", "uidNumber"); !found || id != 10001 {
+			t.Errorf("alice: %d %v", id, found)
+		}
+		if _, found := m.lookupId("uid", "bob", "uidNumber"); found {
+			t.Errorf("bob is found")
+		}
+	}
+	if searches != 2 {
+		t.Errorf("searches = %d, want 2", searches)
+	}
+}
+
+func TestLdapLookupTimeout(t *testing.T) {
+	release := make(chan struct{})
+	failing := false
+	m := &LDAPUidGidMapper{
+		ttl:           -time.Second, // always expired
+		lookupTimeout: 10 * time.Millisecond,
+		cache:         make(map[string]*ldapCacheEntry),
+		search: func(filterAttr, filterValue, attr string) (string, bool, error) {
+			<-release
+			if failing {
+				return "", false, errors.New("ldap server is down")
+			}
+			return "10001", true, nil
+		},
+	}
+
+	// the first lookup of a slow server times out without a cached entry
+	if _, found := m.lookupId("uid", "alice", "uidNumber"); found {
+		t.Errorf("alice is found before the search is done")
+	}
+	release <- struct{}{}
+	waitLdapSearches(m)
+	if id, found := m.lookupId("uid", "alice", "uidNumber"); !found || id != 10001 {
+		t.Errorf("alice: %d %v", id, found)
+	}
+
+	// the expired entry is used while the server is slow, and kept while the server is down
+	failing = true
+	close(release)
+	waitLdapSearches(m)
+	if id, found := m.lookupId("uid", "alice", "uidNumber"); !found || id != 10001 {
+		t.Errorf("alice: %d %v", id, found)
+	}
+	waitLdapSearches(m)
+}
+
+func waitLdapSearches(m *LDAPUidGidMapper) {
+	m.cacheLock.Lock()
+	var pending []chan struct{}
+	for _, done := range m.searching {
+		pending = append(pending, done)
+	}
+	m.cacheLock.Unlock()
+	for _, done := range pending {
+		<-done
+	}
+}
@@ -3,6 +3,7 @@ package meta_cache
 import (
 	"context"
 	"os"
+	"sync"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/filer/leveldb"
@@ -17,6 +18,10 @@ import (
 type MetaCache struct {
 	root       util.FullPath
 	localStore filer.VirtualFilerStore
+	// memCache is nil if disabled. memCacheLock orders the store writes and the memCache fills on a miss,
+	// so a fill with an older value can not overwrite a newer write.
+	memCache     *memCache
+	memCacheLock sync.Mutex
 	// sync.RWMutex
 	uidGidMapper   *UidGidMapper
 	markCachedFn   func(fullpath util.FullPath)
@@ -24,11 +29,12 @@ type MetaCache struct {
 	invalidateFunc func(fullpath util.FullPath, entry *filer_pb.Entry)
 }
 
-func NewMetaCache(dbFolder string, uidGidMapper *UidGidMapper, root util.FullPath,
+func NewMetaCache(dbFolder string, memCacheSizeMB int64, uidGidMapper *UidGidMapper, root util.FullPath,
 	markCachedFn func(path util.FullPath), isCachedFn func(path util.FullPath) bool, invalidateFunc func(util.FullPath, *filer_pb.Entry)) *MetaCache {
 	return &MetaCache{
 		root:         root,
 		localStore:   openMetaStore(dbFolder),
+		memCache:     newMemCache(memCacheSizeMB),
 		markCachedFn: markCachedFn,
 		isCachedFn:   isCachedFn,
 		uidGidMapper: uidGidMapper,
@@ -63,7 +69,31 @@ func (mc *MetaCache) InsertEntry(ctx context.Context, entry *filer.Entry) error
 }
 
 func (mc *MetaCache) doInsertEntry(ctx context.Context, entry *filer.Entry) error {
-	return mc.localStore.InsertEntry(ctx, entry)
+	return mc.storeInsertEntry(ctx, entry)
+}
+
+func (mc *MetaCache) storeInsertEntry(ctx context.Context, entry *filer.Entry) error {
+	if mc.memCache == nil {
+		return mc.localStore.InsertEntry(ctx, entry)
+	}
+	mc.memCacheLock.Lock()
+	defer mc.memCacheLock.Unlock()
+	if err := mc.localStore.InsertEntry(ctx, entry); err != nil {
+		mc.memCache.delete(entry.FullPath)
+		return err
+	}
+	mc.memCache.set(entry)
+	return nil
+}
+
+func (mc *MetaCache) storeDeleteEntry(ctx context.Context, fp util.FullPath) error {
+	if mc.memCache == nil {
+		return mc.localStore.DeleteEntry(ctx, fp)
+	}
+	mc.memCacheLock.Lock()
+	defer mc.memCacheLock.Unlock()
+	mc.memCache.delete(fp)
+	return mc.localStore.DeleteEntry(ctx, fp)
 }
 
 func (mc *MetaCache) AtomicUpdateEntryFromFiler(ctx context.Context, oldPath util.FullPath, newEntry *filer.Entry) error {
@@ -83,7 +113,7 @@ func (mc *MetaCache) AtomicUpdateEntryFromFiler(ctx context.Context, oldPath uti
 			} else {
 				ctx = context.WithValue(ctx, "OP", "MV")
 				glog.V(3).Infof("DeleteEntry %s", oldPath)
-				if err := mc.localStore.DeleteEntry(ctx, oldPath); err != nil {
+				if err := mc.storeDeleteEntry(ctx, oldPath); err != nil {
 					return err
 				}
 			}
@@ -96,7 +126,7 @@ func (mc *MetaCache) AtomicUpdateEntryFromFiler(ctx context.Context, oldPath uti
 		newDir, _ := newEntry.DirAndName()
 		if mc.isCachedFn(util.FullPath(newDir)) {
 			glog.V(3).Infof("InsertEntry %s/%s", newDir, newEntry.Name())
-			if err := mc.localStore.InsertEntry(ctx, newEntry); err != nil {
+			if err := mc.storeInsertEntry(ctx, newEntry); err != nil {
 				return err
 			}
 		}
@@ -107,13 +137,29 @@ func (mc *MetaCache) AtomicUpdateEntryFromFiler(ctx context.Context, oldPath uti
 func (mc *MetaCache) UpdateEntry(ctx context.Context, entry *filer.Entry) error {
 	//mc.Lock()
 	//defer mc.Unlock()
-	return mc.localStore.UpdateEntry(ctx, entry)
+	if mc.memCache == nil {
+		return mc.localStore.UpdateEntry(ctx, entry)
+	}
+	mc.memCacheLock.Lock()
+	defer mc.memCacheLock.Unlock()
+	if err := mc.localStore.UpdateEntry(ctx, entry); err != nil {
+		mc.memCache.delete(entry.FullPath)
+		return err
+	}
+	mc.memCache.set(entry)
+	return nil
 }
 
 func (mc *MetaCache) FindEntry(ctx context.Context, fp util.FullPath) (entry *filer.Entry, err error) {
 	//mc.RLock()
 	//defer mc.RUnlock()
-	entry, err = mc.localStore.FindEntry(ctx, fp)
+	if mc.memCache != nil {
+		if entry = mc.memCache.get(fp); entry == nil {
+			entry, err = mc.findEntryAndFillMemCache(ctx, fp)
+		}
+	} else {
+		entry, err = mc.localStore.FindEntry(ctx, fp)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -121,14 +167,31 @@ func (mc *MetaCache) FindEntry(ctx context.Context, fp util.FullPath) (entry *fi
 	return
 }
 
+func (mc *MetaCache) findEntryAndFillMemCache(ctx context.Context, fp util.FullPath) (*filer.Entry, error) {
+	mc.memCacheLock.Lock()
+	defer mc.memCacheLock.Unlock()
+	entry, err := mc.localStore.FindEntry(ctx, fp)
+	if err != nil {
+		return nil, err
+	}
+	mc.memCache.set(entry)
+	return entry, nil
+}
+
 func (mc *MetaCache) DeleteEntry(ctx context.Context, fp util.FullPath) (err error) {
 	//mc.Lock()
 	//defer mc.Unlock()
-	return mc.localStore.DeleteEntry(ctx, fp)
+	return mc.storeDeleteEntry(ctx, fp)
 }
 func (mc *MetaCache) DeleteFolderChildren(ctx context.Context, fp util.FullPath) (err error) {
 	//mc.Lock()
 	//defer mc.Unlock()
+	if mc.memCache == nil {
+		return mc.localStore.DeleteFolderChildren(ctx, fp)
+	}
+	mc.memCacheLock.Lock()
+	defer mc.memCacheLock.Unlock()
+	mc.memCache.deleteChildren(fp)
 	return mc.localStore.DeleteFolderChildren(ctx, fp)
 }
 
@@ -155,6 +218,9 @@ func (mc *MetaCache) Shutdown() {
 	//mc.Lock()
 	//defer mc.Unlock()
 	mc.localStore.Shutdown()
+	if mc.memCache != nil {
+		mc.memCache.stop()
+	}
 }
 
 func (mc *MetaCache) mapIdFromFilerToLocal(entry *filer.Entry) {
@@ -10,7 +10,8 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
-func EnsureVisited(mc *MetaCache, client filer_pb.FilerClient, dirPath util.FullPath) error {
+// EnsureVisited lists the directory and its parents not cached yet, each on the filer returned by clientFor
+func EnsureVisited(mc *MetaCache, clientFor func(dir util.FullPath) filer_pb.FilerClient, dirPath util.FullPath) error {
 
 	currentPath := dirPath
 
@@ -22,7 +23,7 @@ func EnsureVisited(mc *MetaCache, client filer_pb.FilerClient, dirPath util.Full
 			return nil
 		}
 
-		if err := doEnsureVisited(mc, client, currentPath); err != nil {
+		if err := doEnsureVisited(mc, clientFor(currentPath), currentPath); err != nil {
 			return err
 		}
 
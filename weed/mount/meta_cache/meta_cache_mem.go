@@ -0,0 +1,85 @@
+package meta_cache
+
+import (
+	"time"
+
+	"github.com/karlseguin/ccache/v2"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	// estimated memory of one entry in the memory cache
+	memCacheEntrySize = 2 * 1024
+	memCacheTtl       = time.Hour
+)
+
+// memCache is the in-memory LRU of the entries, in front of the on-disk store.
+// The on-disk store has all the entries, so an evicted entry is just read from the disk again.
+type memCache struct {
+	cache *ccache.Cache
+}
+
+func newMemCache(sizeMB int64) *memCache {
+	maxEntries := sizeMB * 1024 * 1024 / memCacheEntrySize
+	if maxEntries <= 0 {
+		return nil
+	}
+	pruneCount := maxEntries >> 3
+	if pruneCount <= 0 {
+		pruneCount = 1
+	}
+	return &memCache{
+		cache: ccache.New(ccache.Configure().MaxSize(maxEntries).ItemsToPrune(uint32(pruneCount))),
+	}
+}
+
+func (m *memCache) get(fp util.FullPath) *filer.Entry {
+	item := m.cache.Get(string(fp))
+	if item == nil {
+		return nil
+	}
+	item.Extend(memCacheTtl)
+	return cloneEntry(item.Value().(*filer.Entry))
+}
+
+func (m *memCache) set(entry *filer.Entry) {
+	// the hard links share the content kept separately in the on-disk store
+	if len(entry.HardLinkId) > 0 {
+		m.cache.Delete(string(entry.FullPath))
+		return
+	}
+	m.cache.Set(string(entry.FullPath), cloneEntry(entry), memCacheTtl)
+}
+
+func (m *memCache) delete(fp util.FullPath) {
+	m.cache.Delete(string(fp))
+}
+
+// deleteChildren drops all entries under the directory, not only the direct children
+func (m *memCache) deleteChildren(dir util.FullPath) {
+	prefix := string(dir)
+	if prefix != "/" {
+		prefix += "/"
+	}
+	m.cache.DeletePrefix(prefix)
+}
+
+func (m *memCache) stop() {
+	m.cache.Stop()
+}
+
+// cloneEntry copies the fields changed by the callers, so the cached entry stays the same as the on-disk one
+func cloneEntry(entry *filer.Entry) *filer.Entry {
+	clone := entry.ShallowClone()
+	if entry.Extended != nil {
+		clone.Extended = make(map[string][]byte, len(entry.Extended))
+		for k, v := range entry.Extended {
+			clone.Extended[k] = v
+		}
+	}
+	clone.Chunks = append([]*filer_pb.FileChunk(nil), entry.Chunks...)
+	return clone
+}
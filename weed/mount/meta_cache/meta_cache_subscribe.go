@@ -9,7 +9,9 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
-func SubscribeMetaEvents(mc *MetaCache, selfSignature int32, client filer_pb.FilerClient, dir string, lastTsNs int64) error {
+// SubscribeMetaEvents applies the metadata events under dir to the cache. The events of the entries
+// on another filer are skipped, i.e., the events with neither path in isOwnPath.
+func SubscribeMetaEvents(mc *MetaCache, selfSignature int32, client filer_pb.FilerClient, dir string, lastTsNs int64, isOwnPath func(path util.FullPath) bool) error {
 
 	processEventFn := func(resp *filer_pb.SubscribeMetadataResponse) error {
 		message := resp.EventNotification
@@ -20,6 +22,10 @@ func SubscribeMetaEvents(mc *MetaCache, selfSignature int32, client filer_pb.Fil
 			}
 		}
 
+		if !isOwnEvent(resp, isOwnPath) {
+			return nil
+		}
+
 		dir := resp.Directory
 		var oldPath util.FullPath
 		var newEntry *filer.Entry
@@ -80,3 +86,21 @@ func SubscribeMetaEvents(mc *MetaCache, selfSignature int32, client filer_pb.Fil
 
 	return nil
 }
+
+func isOwnEvent(resp *filer_pb.SubscribeMetadataResponse, isOwnPath func(path util.FullPath) bool) bool {
+	if isOwnPath == nil {
+		return true
+	}
+	message := resp.EventNotification
+	if message.OldEntry != nil && isOwnPath(util.NewFullPath(resp.Directory, message.OldEntry.Name)) {
+		return true
+	}
+	if message.NewEntry != nil {
+		dir := resp.Directory
+		if message.NewParentPath != "" {
+			dir = message.NewParentPath
+		}
+		return isOwnPath(util.NewFullPath(dir, message.NewEntry.Name))
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package mount
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/mount/meta_cache"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// NamespaceRouter maps the mount sub-paths to their own filers, e.g.,
+//
+//	namespaces:
+//	  - path: /data/tenantA
+//	    filer: filerA:8888
+//	  - path: /data/tenantB
+//	    filer: filerB1:8888,filerB2:8888
+//
+// The paths are relative to the mount directory. The longest matching path wins.
+// The paths not in any namespace go to the -filer of the mount.
+type NamespaceRouter struct {
+	configFile string
+	mountRoot  util.FullPath
+	routesLock sync.RWMutex
+	routes     []*namespaceRoute
+	onReload   func()
+}
+
+type namespaceRoute struct {
+	prefix     util.FullPath
+	filers     []pb.ServerAddress
+	filerIndex int32
+}
+
+type namespaceConfig struct {
+	Path  string `mapstructure:"path"`
+	Filer string `mapstructure:"filer"`
+}
+
+func NewNamespaceRouter(configFile string, mountRoot util.FullPath) (*NamespaceRouter, error) {
+	r := &NamespaceRouter{
+		configFile: configFile,
+		mountRoot:  mountRoot,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the config file again. The current routes are kept if the file is invalid.
+func (r *NamespaceRouter) Reload() error {
+	v := viper.New()
+	v.SetConfigFile(r.configFile)
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("read namespace config %s: %v", r.configFile, err)
+	}
+	var configs []namespaceConfig
+	if err := v.UnmarshalKey("namespaces", &configs); err != nil {
+		return fmt.Errorf("parse namespace config %s: %v", r.configFile, err)
+	}
+
+	var routes []*namespaceRoute
+	for _, c := range configs {
+		if !strings.HasPrefix(c.Path, "/") || strings.Trim(c.Path, "/") == "" || c.Filer == "" {
+			return fmt.Errorf("namespace config %s: invalid namespace path %q filer %q", r.configFile, c.Path, c.Filer)
+		}
+		routes = append(routes, &namespaceRoute{
+			prefix: r.mountRoot.Child(strings.Trim(c.Path, "/")),
+			filers: pb.ServerAddresses(c.Filer).ToAddresses(),
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return len(routes[i].prefix) > len(routes[j].prefix)
+	})
+
+	r.routesLock.Lock()
+	r.routes = routes
+	onReload := r.onReload
+	r.routesLock.Unlock()
+	glog.V(0).Infof("loaded %d namespaces from %s", len(routes), r.configFile)
+	if onReload != nil {
+		onReload()
+	}
+	return nil
+}
+
+// OnReload sets fn to run after each successful reload
+func (r *NamespaceRouter) OnReload(fn func()) {
+	r.routesLock.Lock()
+	r.onReload = fn
+	r.routesLock.Unlock()
+}
+
+func (r *NamespaceRouter) currentRoutes() []*namespaceRoute {
+	r.routesLock.RLock()
+	defer r.routesLock.RUnlock()
+	return r.routes
+}
+
+func (r *NamespaceRouter) route(path util.FullPath) *namespaceRoute {
+	r.routesLock.RLock()
+	defer r.routesLock.RUnlock()
+	for _, route := range r.routes {
+		if path == route.prefix || strings.HasPrefix(string(path), string(route.prefix)+"/") {
+			return route
+		}
+	}
+	return nil
+}
+
+// key identifies the namespace across the reloads
+func (route *namespaceRoute) key() string {
+	if route == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s@%v", route.prefix, route.filers)
+}
+
+// namespaceRoute returns the namespace of the path, or nil for the -filer of the mount
+func (wfs *WFS) namespaceRoute(path util.FullPath) *namespaceRoute {
+	if wfs.option.NamespaceRouter == nil {
+		return nil
+	}
+	return wfs.option.NamespaceRouter.route(path)
+}
+
+// filerClientFor returns the filer client of the namespace of the path.
+// Every filer rpc about a path goes to it, i.e., the lookups, the listings, the writes and the chunk assignments and lookups.
+func (wfs *WFS) filerClientFor(path util.FullPath) contextualFilerClient {
+	route := wfs.namespaceRoute(path)
+	if route == nil {
+		return wfs
+	}
+	return &namespaceFilerClient{wfs: wfs, route: route}
+}
+
+// currentFilerFor returns the filer of the path, for the filerProxy chunk urls
+func (wfs *WFS) currentFilerFor(path util.FullPath) pb.ServerAddress {
+	route := wfs.namespaceRoute(path)
+	if route == nil {
+		return wfs.getCurrentFiler()
+	}
+	return route.filers[atomic.LoadInt32(&route.filerIndex)]
+}
+
+// sameNamespace is false if the paths are on different filers, which can not rename or link across
+func (wfs *WFS) sameNamespace(a, b util.FullPath) bool {
+	return wfs.namespaceRoute(a).key() == wfs.namespaceRoute(b).key()
+}
+
+// subscribeNamespaces follows the metadata of each namespace on its own filer, once per namespace,
+// so the namespaces added by a reload are followed from startTsNs.
+func (wfs *WFS) subscribeNamespaces(startTsNs int64) {
+	wfs.namespaceSubscriptionsLock.Lock()
+	defer wfs.namespaceSubscriptionsLock.Unlock()
+	if wfs.namespaceSubscriptions == nil {
+		wfs.namespaceSubscriptions = make(map[string]bool)
+	}
+	for _, route := range wfs.option.NamespaceRouter.currentRoutes() {
+		key := route.key()
+		if wfs.namespaceSubscriptions[key] {
+			continue
+		}
+		wfs.namespaceSubscriptions[key] = true
+		// a namespace removed or changed by a reload no longer owns any path, so its subscription applies nothing
+		isOwnPath := func(path util.FullPath) bool {
+			return wfs.namespaceRoute(path).key() == key
+		}
+		go meta_cache.SubscribeMetaEvents(wfs.metaCache, wfs.signature, &namespaceFilerClient{wfs: wfs, route: route}, string(route.prefix), startTsNs, isOwnPath)
+	}
+}
+
+type namespaceFilerClient struct {
+	wfs   *WFS
+	route *namespaceRoute
+}
+
+var _ = filer_pb.FilerClient(&namespaceFilerClient{})
+
+func (c *namespaceFilerClient) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) (err error) {
+	return c.withFilerClientContext(context.Background(), streamingMode, fn)
+}
+
+func (c *namespaceFilerClient) withFilerClientContext(ctx context.Context, streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) (err error) {
+
+	return util.Retry("namespace filer grpc", func() error {
+
+		i := atomic.LoadInt32(&c.route.filerIndex)
+		n := len(c.route.filers)
+		for x := 0; x < n; x++ {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+
+			filerGrpcAddress := c.route.filers[i].ToGrpcAddress()
+			err = pb.WithGrpcClient(streamingMode, c.wfs.signature, func(grpcConnection *grpc.ClientConn) error {
+				client := filer_pb.NewSeaweedFilerClient(withContextDeadline(ctx, grpcConnection))
+				defer c.wfs.metrics.ObserveFilerRpc(time.Now())
+				return fn(client)
+			}, filerGrpcAddress, false, c.wfs.option.filerGrpcDialOption())
+
+			if err != nil {
+				glog.V(0).Infof("namespace %s WithFilerClient %d %v: %v", c.route.prefix, x, filerGrpcAddress, err)
+			} else {
+				atomic.StoreInt32(&c.route.filerIndex, i)
+				return nil
+			}
+
+			i++
+			if i >= int32(n) {
+				i = 0
+			}
+
+		}
+		return err
+	})
+
+}
+
+func (c *namespaceFilerClient) AdjustedUrl(location *filer_pb.Location) string {
+	return c.wfs.AdjustedUrl(location)
+}
+
+func (c *namespaceFilerClient) GetDataCenter() string {
+	return c.wfs.GetDataCenter()
+}
@@ -0,0 +1,204 @@
+package mount
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestNamespaceRouter(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "namespaces.yaml")
+	writeConfig := func(content string) {
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(`
+namespaces:
+  - path: /data/tenantA
+    filer: filerA:8888
+  - path: /data/tenantA/archive
+    filer: archive1:8888,archive2:8888
+`)
+	r, err := NewNamespaceRouter(configFile, "/buckets")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   util.FullPath
+		filers int
+		prefix util.FullPath
+	}{
+		{"/buckets/data/tenantA", 1, "/buckets/data/tenantA"},
+		{"/buckets/data/tenantA/x", 1, "/buckets/data/tenantA"},
+		{"/buckets/data/tenantA/archive/y", 2, "/buckets/data/tenantA/archive"},
+		{"/buckets/data/tenantAB", 0, ""},
+		{"/buckets/data", 0, ""},
+	}
+	for _, tt := range tests {
+		route := r.route(tt.path)
+		if tt.filers == 0 {
+			if route != nil {
+				t.Errorf("%s routed to %s", tt.path, route.prefix)
+			}
+			continue
+		}
+		if route == nil || route.prefix != tt.prefix || len(route.filers) != tt.filers {
+			t.Errorf("%s routed to %+v", tt.path, route)
+		}
+	}
+
+	// an invalid config keeps the current routes
+	writeConfig(`
+namespaces:
+  - path: relative
+    filer: filerA:8888
+`)
+	if err := r.Reload(); err == nil {
+		t.Errorf("expect invalid path error")
+	}
+	if r.route("/buckets/data/tenantA") == nil {
+		t.Errorf("routes are lost after a failed reload")
+	}
+}
+
+func TestNamespaceWriteThenRead(t *testing.T) {
+	mainFiler, mainAddress := startNamespaceTestFiler(t, "main-volume:8080")
+	tenantFiler, tenantAddress := startNamespaceTestFiler(t, "tenant-volume:8080")
+
+	configFile := filepath.Join(t.TempDir(), "namespaces.yaml")
+	config := fmt.Sprintf("namespaces:\n  - path: /tenantA\n    filer: %s\n", tenantAddress)
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	router, err := NewNamespaceRouter(configFile, "/buckets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wfs := &WFS{
+		option: &Option{
+			FilerAddresses:  []pb.ServerAddress{mainAddress},
+			GrpcDialOption:  grpc.WithTransportCredentials(insecure.NewCredentials()),
+			NamespaceRouter: router,
+		},
+		metrics: NewMountMetrics(prometheus.NewRegistry()),
+	}
+
+	// the fsync batch is split by the namespaces
+	errs := wfs.batchCreateEntries([]*filer_pb.CreateEntryRequest{
+		{Directory: "/buckets/tenantA", Entry: &filer_pb.Entry{Name: "a.txt"}},
+		{Directory: "/buckets/shared", Entry: &filer_pb.Entry{Name: "b.txt"}},
+	})
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !tenantFiler.has("/buckets/tenantA/a.txt") || tenantFiler.has("/buckets/shared/b.txt") {
+		t.Errorf("tenant filer entries: %v", tenantFiler.paths())
+	}
+	if !mainFiler.has("/buckets/shared/b.txt") || mainFiler.has("/buckets/tenantA/a.txt") {
+		t.Errorf("main filer entries: %v", mainFiler.paths())
+	}
+
+	// the entry written in the namespace is read back from its filer
+	written := util.FullPath("/buckets/tenantA/a.txt")
+	entry, err := filer_pb.GetEntry(wfs.filerClientFor(written), written)
+	if err != nil || entry == nil || entry.Name != "a.txt" {
+		t.Fatalf("read back %s: %v %v", written, entry, err)
+	}
+
+	// and so are its chunks
+	urls, err := wfs.LookupFn(written)("3,0123456789")
+	if err != nil || len(urls) != 1 || urls[0] != "http://tenant-volume:8080/3,0123456789" {
+		t.Errorf("lookup chunk of %s: %v %v", written, urls, err)
+	}
+	urls, err = wfs.LookupFn("/buckets/shared/b.txt")("3,0123456789")
+	if err != nil || len(urls) != 1 || urls[0] != "http://main-volume:8080/3,0123456789" {
+		t.Errorf("lookup chunk of /buckets/shared/b.txt: %v %v", urls, err)
+	}
+
+	// the namespaces can not rename or link across
+	if !wfs.sameNamespace("/buckets/tenantA/a.txt", "/buckets/tenantA/dir/c.txt") {
+		t.Errorf("expect the same namespace")
+	}
+	if wfs.sameNamespace("/buckets/tenantA/a.txt", "/buckets/shared/a.txt") {
+		t.Errorf("expect different namespaces")
+	}
+}
+
+// namespaceTestFiler keeps the created entries, and locates every volume on one volume server
+type namespaceTestFiler struct {
+	filer_pb.UnimplementedSeaweedFilerServer
+	volumeServer string
+	sync.Mutex
+	entries map[util.FullPath]*filer_pb.Entry
+}
+
+func startNamespaceTestFiler(t *testing.T, volumeServer string) (*namespaceTestFiler, pb.ServerAddress) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &namespaceTestFiler{volumeServer: volumeServer, entries: make(map[util.FullPath]*filer_pb.Entry)}
+	server := grpc.NewServer()
+	filer_pb.RegisterSeaweedFilerServer(server, f)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	port := listener.Addr().(*net.TCPAddr).Port
+	return f, pb.ServerAddress(fmt.Sprintf("localhost:%d.%d", port, port))
+}
+
+func (f *namespaceTestFiler) CreateEntry(ctx context.Context, req *filer_pb.CreateEntryRequest) (*filer_pb.CreateEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.entries[util.NewFullPath(req.Directory, req.Entry.Name)] = req.Entry
+	return &filer_pb.CreateEntryResponse{}, nil
+}
+
+func (f *namespaceTestFiler) LookupDirectoryEntry(ctx context.Context, req *filer_pb.LookupDirectoryEntryRequest) (*filer_pb.LookupDirectoryEntryResponse, error) {
+	f.Lock()
+	defer f.Unlock()
+	entry, found := f.entries[util.NewFullPath(req.Directory, req.Name)]
+	if !found {
+		return nil, fmt.Errorf("%s", filer_pb.ErrNotFound.Error())
+	}
+	return &filer_pb.LookupDirectoryEntryResponse{Entry: entry}, nil
+}
+
+func (f *namespaceTestFiler) LookupVolume(ctx context.Context, req *filer_pb.LookupVolumeRequest) (*filer_pb.LookupVolumeResponse, error) {
+	resp := &filer_pb.LookupVolumeResponse{LocationsMap: make(map[string]*filer_pb.Locations)}
+	for _, vid := range req.VolumeIds {
+		resp.LocationsMap[vid] = &filer_pb.Locations{Locations: []*filer_pb.Location{{Url: f.volumeServer}}}
+	}
+	return resp, nil
+}
+
+func (f *namespaceTestFiler) has(path util.FullPath) bool {
+	f.Lock()
+	defer f.Unlock()
+	_, found := f.entries[path]
+	return found
+}
+
+func (f *namespaceTestFiler) paths() (paths []util.FullPath) {
+	f.Lock()
+	defer f.Unlock()
+	for path := range f.entries {
+		paths = append(paths, path)
+	}
+	return
+}
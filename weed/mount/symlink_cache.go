@@ -0,0 +1,46 @@
+package mount
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/karlseguin/ccache/v2"
+)
+
+const (
+	symlinkCacheSize = 16 * 1024
+	symlinkCacheTtl  = time.Hour
+)
+
+// symlinkCache is the LRU of the symlink targets by inode.
+// A symlink target never changes, but the inode can be reused after the symlink is replaced,
+// so the local and remote changes of the symlink path evict its inode.
+type symlinkCache struct {
+	cache *ccache.Cache
+}
+
+func newSymlinkCache() *symlinkCache {
+	return &symlinkCache{
+		cache: ccache.New(ccache.Configure().MaxSize(symlinkCacheSize).ItemsToPrune(symlinkCacheSize >> 3)),
+	}
+}
+
+func (c *symlinkCache) get(inode uint64) (target string, found bool) {
+	item := c.cache.Get(strconv.FormatUint(inode, 10))
+	if item == nil {
+		return "", false
+	}
+	item.Extend(symlinkCacheTtl)
+	return item.Value().(string), true
+}
+
+func (c *symlinkCache) set(inode uint64, target string) {
+	c.cache.Set(strconv.FormatUint(inode, 10), target, symlinkCacheTtl)
+}
+
+func (c *symlinkCache) delete(inode uint64) {
+	if inode == 0 {
+		return
+	}
+	c.cache.Delete(strconv.FormatUint(inode, 10))
+}
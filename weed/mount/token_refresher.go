@@ -0,0 +1,190 @@
+package mount
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	minTokenRefreshWait = 10 * time.Second
+	maxTokenRefreshWait = 5 * time.Minute
+	tokenRequestTimeout = 10 * time.Second
+)
+
+// TokenRefresher keeps a JWT fresh by fetching a new one from the auth server before the
+// current one expires. It is used as per-RPC credentials, so all gRPC calls pick up the
+// latest token without remounting.
+type TokenRefresher struct {
+	tokenUrl      string
+	refreshBefore time.Duration
+	httpClient    *http.Client
+	token         atomic.Value // string
+}
+
+func NewTokenRefresher(tokenUrl string, refreshBefore time.Duration) (*TokenRefresher, error) {
+	tr := &TokenRefresher{
+		tokenUrl:      tokenUrl,
+		refreshBefore: refreshBefore,
+		httpClient:    &http.Client{Timeout: tokenRequestTimeout},
+	}
+	if err := tr.refresh(); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// Start refreshes the token ahead of its expiry, until stopCh is closed.
+// Failed refreshes are retried with exponential backoff.
+func (tr *TokenRefresher) Start(stopCh <-chan struct{}) {
+	failures := 0
+	expiredLogged := false
+	for {
+		expiresAt, err := tokenExpiresAt(tr.Token())
+		if err != nil {
+			glog.Warningf("parse jwt from %s: %v", tr.tokenUrl, err)
+		} else if expiresAt.IsZero() {
+			// the token never expires
+			return
+		}
+
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(refreshWait(expiresAt, time.Now(), tr.refreshBefore, failures)):
+		}
+
+		if err := tr.refresh(); err != nil {
+			failures++
+			if !expiresAt.IsZero() && time.Now().After(expiresAt) {
+				if !expiredLogged {
+					glog.Errorf("jwt from %s expired at %v, filer calls are failing authentication until it is refreshed: %v", tr.tokenUrl, expiresAt, err)
+					expiredLogged = true
+				}
+			} else {
+				glog.Warningf("refresh jwt from %s: %v", tr.tokenUrl, err)
+			}
+			continue
+		}
+		failures = 0
+		expiredLogged = false
+	}
+}
+
+// refreshWait is how long to wait before the next refresh. After failures, it backs off
+// exponentially, but never sleeps past the token expiry.
+func refreshWait(expiresAt, now time.Time, refreshBefore time.Duration, failures int) time.Duration {
+	if failures > 0 {
+		wait := minTokenRefreshWait
+		for i := 1; i < failures && wait < maxTokenRefreshWait; i++ {
+			wait *= 2
+		}
+		if wait > maxTokenRefreshWait {
+			wait = maxTokenRefreshWait
+		}
+		if untilExpiry := expiresAt.Sub(now); untilExpiry > minTokenRefreshWait && untilExpiry < wait {
+			wait = untilExpiry
+		}
+		return wait
+	}
+	if wait := expiresAt.Sub(now) - refreshBefore; wait > minTokenRefreshWait {
+		return wait
+	}
+	return minTokenRefreshWait
+}
+
+func (tr *TokenRefresher) Token() string {
+	if t, ok := tr.token.Load().(string); ok {
+		return t
+	}
+	return ""
+}
+
+func (tr *TokenRefresher) refresh() error {
+	resp, err := tr.httpClient.Get(tr.tokenUrl)
+	if err != nil {
+		return fmt.Errorf("get %s: %v", tr.tokenUrl, err)
+	}
+	defer util.CloseResponse(resp)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s: %s", tr.tokenUrl, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read %s: %v", tr.tokenUrl, err)
+	}
+	token := parseBearerToken(string(data))
+	if _, err := tokenExpiresAt(token); err != nil {
+		return fmt.Errorf("invalid jwt from %s: %v", tr.tokenUrl, err)
+	}
+	tr.token.Store(token)
+	glog.V(1).Infof("refreshed jwt from %s", tr.tokenUrl)
+	return nil
+}
+
+// parseBearerToken accepts the raw token, optionally prefixed with "Bearer ".
+func parseBearerToken(body string) string {
+	token := strings.TrimSpace(body)
+	if len(token) > 7 && strings.ToUpper(token[0:6]) == "BEARER" {
+		token = strings.TrimSpace(token[7:])
+	}
+	return token
+}
+
+// tokenExpiresAt reads the "exp" claim. The signature is verified by the filer, not here.
+func tokenExpiresAt(token string) (time.Time, error) {
+	claims := &jwt.StandardClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return time.Time{}, err
+	}
+	if claims.ExpiresAt == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(claims.ExpiresAt, 0), nil
+}
+
+func (tr *TokenRefresher) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		"authorization": "Bearer " + tr.Token(),
+	}, nil
+}
+
+// RequireTransportSecurity is true, so that the token never goes out in cleartext.
+func (tr *TokenRefresher) RequireTransportSecurity() bool {
+	return true
+}
+
+// CredentialsBundle combines the transport credentials with the refreshed token.
+func (tr *TokenRefresher) CredentialsBundle(transportCredentials credentials.TransportCredentials) credentials.Bundle {
+	return &tokenCredentialsBundle{
+		transportCredentials: transportCredentials,
+		tokenRefresher:       tr,
+	}
+}
+
+type tokenCredentialsBundle struct {
+	transportCredentials credentials.TransportCredentials
+	tokenRefresher       *TokenRefresher
+}
+
+func (b *tokenCredentialsBundle) TransportCredentials() credentials.TransportCredentials {
+	return b.transportCredentials
+}
+
+func (b *tokenCredentialsBundle) PerRPCCredentials() credentials.PerRPCCredentials {
+	return b.tokenRefresher
+}
+
+func (b *tokenCredentialsBundle) NewWithMode(mode string) (credentials.Bundle, error) {
+	return b, nil
+}
@@ -0,0 +1,116 @@
+package mount
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func signTestToken(t *testing.T, subject string, expiresAt time.Time) string {
+	claims := jwt.StandardClaims{Subject: subject}
+	if !expiresAt.IsZero() {
+		claims.ExpiresAt = expiresAt.Unix()
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("sign jwt: %v", err)
+	}
+	return token
+}
+
+func TestTokenExpiresAt(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	got, err := tokenExpiresAt(signTestToken(t, "a", expiresAt))
+	if err != nil || !got.Equal(expiresAt) {
+		t.Errorf("tokenExpiresAt = %v, %v, want %v", got, err, expiresAt)
+	}
+
+	got, err = tokenExpiresAt(signTestToken(t, "a", time.Time{}))
+	if err != nil || !got.IsZero() {
+		t.Errorf("tokenExpiresAt without exp = %v, %v, want zero time", got, err)
+	}
+
+	if _, err = tokenExpiresAt("not-a-jwt"); err == nil {
+		t.Errorf("tokenExpiresAt should fail on a malformed token")
+	}
+}
+
+func TestParseBearerToken(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{body: "abc.def.ghi", want: "abc.def.ghi"},
+		{body: "Bearer abc.def.ghi\n", want: "abc.def.ghi"},
+		{body: "  bearer abc.def.ghi", want: "abc.def.ghi"},
+	}
+	for _, tt := range tests {
+		if got := parseBearerToken(tt.body); got != tt.want {
+			t.Errorf("parseBearerToken(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshWait(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		failures  int
+		want      time.Duration
+	}{
+		{name: "refresh ahead of expiry", expiresAt: now.Add(time.Hour), want: 55 * time.Minute},
+		{name: "expiry too close", expiresAt: now.Add(time.Minute), want: minTokenRefreshWait},
+		{name: "first failure", expiresAt: now.Add(time.Hour), failures: 1, want: minTokenRefreshWait},
+		{name: "backoff", expiresAt: now.Add(time.Hour), failures: 3, want: 4 * minTokenRefreshWait},
+		{name: "backoff capped", expiresAt: now.Add(time.Hour), failures: 20, want: maxTokenRefreshWait},
+		{name: "backoff before expiry", expiresAt: now.Add(time.Minute), failures: 20, want: time.Minute},
+		{name: "backoff after expiry", expiresAt: now.Add(-time.Minute), failures: 20, want: maxTokenRefreshWait},
+	}
+	for _, tt := range tests {
+		if got := refreshWait(tt.expiresAt, now, 5*time.Minute, tt.failures); got != tt.want {
+			t.Errorf("%s: refreshWait = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTokenRefresherRefresh(t *testing.T) {
+	var served int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&served, 1)
+		fmt.Fprintf(w, "Bearer %s", signTestToken(t, fmt.Sprintf("token-%d", n), time.Now().Add(time.Second)))
+	}))
+	defer server.Close()
+
+	tr, err := NewTokenRefresher(server.URL, time.Minute)
+	if err != nil {
+		t.Fatalf("NewTokenRefresher: %v", err)
+	}
+	first := tr.Token()
+
+	md, err := tr.GetRequestMetadata(context.Background())
+	if err != nil || md["authorization"] != "Bearer "+first {
+		t.Fatalf("GetRequestMetadata = %v, %v", md, err)
+	}
+
+	if err = tr.refresh(); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	second := tr.Token()
+	if second == first {
+		t.Fatalf("token was not refreshed")
+	}
+	md, _ = tr.GetRequestMetadata(context.Background())
+	if md["authorization"] != "Bearer "+second {
+		t.Errorf("GetRequestMetadata = %v, want the refreshed token", md)
+	}
+	if !tr.RequireTransportSecurity() {
+		t.Errorf("the token must require transport security")
+	}
+}
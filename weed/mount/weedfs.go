@@ -6,17 +6,22 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/sony/gobreaker"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/mount/meta_cache"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mount_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/storage/types"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
@@ -30,7 +35,9 @@ type Option struct {
 	filerIndex         int32 // align memory for atomic read/write
 	FilerAddresses     []pb.ServerAddress
 	MountDirectory     string
+	LocalSocket        string // the unix socket of the SeaweedMount grpc service
 	GrpcDialOption     grpc.DialOption
+	FilerCredentials   credentials.Bundle // optional, only sent to filers
 	FilerMountRootPath string
 	Collection         string
 	Replication        string
@@ -40,10 +47,40 @@ type Option struct {
 	ConcurrentWriters  int
 	CacheDir           string
 	CacheSizeMB        int64
+	MemCacheSizeMB     int64 // for the metadata
 	DataCenter         string
 	Umask              os.FileMode
 	Quota              int64
 	DisableXAttr       bool
+	ShowTrash          bool // list the .trash directories of the soft deleted entries
+	// NamespaceRouter is optional, to look up the namespace sub-paths on their own filers
+	NamespaceRouter *NamespaceRouter
+	// HealthCheckInterval and OnUnhealthy enable the HealthMonitor, to remount an unresponsive mount
+	HealthCheckInterval time.Duration
+	OnUnhealthy         func()
+	// the timeouts of the filer rpcs of each fuse operation, 0 to wait forever
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	MetadataTimeout time.Duration // Lookup, ReadDir, and Getattr
+	// the filer circuit breaker opens after CircuitBreakerFailures consecutive failures within
+	// CircuitBreakerWindow, for CircuitBreakerOpenTime. 0 failures to disable it
+	CircuitBreakerFailures uint32
+	CircuitBreakerWindow   time.Duration
+	CircuitBreakerOpenTime time.Duration
+	// the chunk reads back off by random durations up to min(ReadRetryCap, ReadRetryBase * 2^attempt)
+	ReadRetryBase time.Duration
+	ReadRetryCap  time.Duration
+	// ReadAheadMB caps the memory of the chunks prefetched for the sequential reads of all files, 0 for no cap.
+	// A file read at random offsets is not prefetched.
+	ReadAheadMB int64
+	// MmapCoherence is one of MmapCoherenceNone, MmapCoherenceLocal, or MmapCoherenceDistributed.
+	MmapCoherence string
+	// MaxDownloadBytesPerSecond limits the read speed of the mount, 0 for unlimited.
+	// The directories with the DownloadRateXAttr override it for the files under them.
+	MaxDownloadBytesPerSecond int64
+	// MaxUploadBytesPerSecond limits the chunk uploads of the mount, 0 for unlimited.
+	// The directories with the UploadRateXAttr override it for the files under them.
+	MaxUploadBytesPerSecond int64
 
 	MountUid         uint32
 	MountGid         uint32
@@ -76,7 +113,27 @@ type WFS struct {
 	fhmap             *FileHandleToInode
 	dhmap             *DirectoryHandleToInode
 	fuseServer        *fuse.Server
+	metrics           *MountMetrics
+	fsyncBatcher      *fsyncBatcher
+	forgetQueue       chan forgetRequest
 	IsOverQuota       bool
+	symlinkCache      *symlinkCache
+	// dedupUnsupported is set if the filer does not have the chunk deduplication
+	dedupUnsupported atomic.Bool
+	// filerConf has the paths configured with dedup, nil until read
+	filerConf atomic.Pointer[filer.FilerConf]
+	// filerBreaker fails the filer calls fast while the filer is unavailable, nil if disabled
+	filerBreaker *gobreaker.CircuitBreaker
+	// readRetry backs off the chunk reads with jitter, nil for the fixed backoff
+	readRetry *filer.ReadRetryBackoff
+	// readAhead caps the prefetched chunks of all files, nil for no cap
+	readAhead *filer.ReadAheadLimit
+	// the speed limiters of the mount and of the directories with the rate xattrs
+	downloadLimiters *rateLimiters
+	uploadLimiters   *rateLimiters
+	// the namespaces with a metadata subscription on their own filers
+	namespaceSubscriptionsLock sync.Mutex
+	namespaceSubscriptions     map[string]bool
 }
 
 func NewSeaweedFileSystem(option *Option) *WFS {
@@ -87,23 +144,39 @@ func NewSeaweedFileSystem(option *Option) *WFS {
 		inodeToPath:   NewInodeToPath(util.FullPath(option.FilerMountRootPath)),
 		fhmap:         NewFileHandleToInode(),
 		dhmap:         NewDirectoryHandleToInode(),
+		metrics:       NewMountMetrics(stats.Gather),
+		forgetQueue:   make(chan forgetRequest, forgetQueueSize),
+		symlinkCache:  newSymlinkCache(),
 	}
 
+	wfs.filerBreaker = newFilerCircuitBreaker(option, wfs.metrics)
+	wfs.downloadLimiters = newRateLimiters(DownloadRateXAttr, option.MaxDownloadBytesPerSecond, util.FullPath(option.FilerMountRootPath), wfs.directoryRate)
+	wfs.uploadLimiters = newRateLimiters(UploadRateXAttr, option.MaxUploadBytesPerSecond, util.FullPath(option.FilerMountRootPath), wfs.directoryRate)
+	if option.ReadRetryBase > 0 && option.ReadRetryCap > 0 {
+		wfs.readRetry = &filer.ReadRetryBackoff{Base: option.ReadRetryBase, Cap: option.ReadRetryCap}
+	}
+	if option.ReadAheadMB > 0 {
+		wfs.readAhead = filer.NewReadAheadLimit(option.ReadAheadMB * 1024 * 1024)
+	}
 	wfs.option.filerIndex = int32(rand.Intn(len(option.FilerAddresses)))
 	wfs.option.setupUniqueCacheDirectory()
 	if option.CacheSizeMB > 0 {
 		wfs.chunkCache = chunk_cache.NewTieredChunkCache(256, option.getUniqueCacheDir(), option.CacheSizeMB, 1024*1024)
 	}
 
-	wfs.metaCache = meta_cache.NewMetaCache(path.Join(option.getUniqueCacheDir(), "meta"), option.UidGidMapper,
+	wfs.metaCache = meta_cache.NewMetaCache(path.Join(option.getUniqueCacheDir(), "meta"), option.MemCacheSizeMB, option.UidGidMapper,
 		util.FullPath(option.FilerMountRootPath),
 		func(path util.FullPath) {
 			wfs.inodeToPath.MarkChildrenCached(path)
 		}, func(path util.FullPath) bool {
 			return wfs.inodeToPath.IsChildrenCached(path)
 		}, func(filePath util.FullPath, entry *filer_pb.Entry) {
+			wfs.symlinkCache.delete(wfs.inodeToPath.GetInode(filePath))
+			wfs.invalidateRemoteChange(filePath, entry)
 		})
+	wfs.fsyncBatcher = newFsyncBatcher(wfs.batchCreateEntries, wfs.metrics)
 	grace.OnInterrupt(func() {
+		wfs.fsyncBatcher.Shutdown()
 		wfs.metaCache.Shutdown()
 		os.RemoveAll(option.getUniqueCacheDir())
 	})
@@ -116,8 +189,21 @@ func NewSeaweedFileSystem(option *Option) *WFS {
 
 func (wfs *WFS) StartBackgroundTasks() {
 	startTime := time.Now()
-	go meta_cache.SubscribeMetaEvents(wfs.metaCache, wfs.signature, wfs, wfs.option.FilerMountRootPath, startTime.UnixNano())
+	go meta_cache.SubscribeMetaEvents(wfs.metaCache, wfs.signature, wfs, wfs.option.FilerMountRootPath, startTime.UnixNano(), func(path util.FullPath) bool {
+		return wfs.namespaceRoute(path) == nil
+	})
+	if wfs.option.NamespaceRouter != nil {
+		wfs.subscribeNamespaces(startTime.UnixNano())
+		wfs.option.NamespaceRouter.OnReload(func() {
+			wfs.subscribeNamespaces(time.Now().UnixNano())
+		})
+	}
 	go wfs.loopCheckQuota()
+	go wfs.loopReadFilerConf()
+	go wfs.loopProcessForget()
+	if wfs.option.HealthCheckInterval > 0 && wfs.option.OnUnhealthy != nil {
+		go NewHealthMonitor(wfs, wfs.option.HealthCheckInterval, wfs.option.OnUnhealthy).Start()
+	}
 }
 
 func (wfs *WFS) String() string {
@@ -167,7 +253,12 @@ func (wfs *WFS) maybeLoadEntry(fullpath util.FullPath) (*filer_pb.Entry, fuse.St
 	}
 
 	// read from async meta cache
-	meta_cache.EnsureVisited(wfs.metaCache, wfs, util.FullPath(dir))
+	ctx, cancel := newTimeoutContext(wfs.option.MetadataTimeout)
+	defer cancel()
+	if err := wfs.ensureVisited(ctx, util.FullPath(dir)); err != nil && ctx.Err() != nil {
+		glog.Errorf("load entry %s: %v", fullpath, err)
+		return nil, timeoutStatus(ctx, fuse.EIO)
+	}
 	cachedEntry, cacheErr := wfs.metaCache.FindEntry(context.Background(), fullpath)
 	if cacheErr == filer_pb.ErrNotFound {
 		return nil, fuse.ENOENT
@@ -175,13 +266,14 @@ func (wfs *WFS) maybeLoadEntry(fullpath util.FullPath) (*filer_pb.Entry, fuse.St
 	return cachedEntry.ToProtoEntry(), fuse.OK
 }
 
-func (wfs *WFS) LookupFn() wdclient.LookupFileIdFunctionType {
+// LookupFn looks up the chunks of the file on the filer of its namespace
+func (wfs *WFS) LookupFn(path util.FullPath) wdclient.LookupFileIdFunctionType {
 	if wfs.option.VolumeServerAccess == "filerProxy" {
 		return func(fileId string) (targetUrls []string, err error) {
-			return []string{"http://" + wfs.getCurrentFiler().ToHttpAddress() + "/?proxyChunkId=" + fileId}, nil
+			return []string{"http://" + wfs.currentFilerFor(path).ToHttpAddress() + "/?proxyChunkId=" + fileId}, nil
 		}
 	}
-	return filer.LookupFn(wfs)
+	return filer.LookupFn(&timeoutFilerClient{contextualFilerClient: wfs.filerClientFor(path), timeout: wfs.option.ReadTimeout})
 }
 
 func (wfs *WFS) getCurrentFiler() pb.ServerAddress {
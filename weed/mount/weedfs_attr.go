@@ -11,6 +11,7 @@ import (
 )
 
 func (wfs *WFS) GetAttr(cancel <-chan struct{}, input *fuse.GetAttrIn, out *fuse.AttrOut) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("GetAttr", time.Now())
 	if input.NodeId == 1 {
 		wfs.setRootAttr(out)
 		return fuse.OK
@@ -35,6 +36,7 @@ func (wfs *WFS) GetAttr(cancel <-chan struct{}, input *fuse.GetAttrIn, out *fuse
 }
 
 func (wfs *WFS) SetAttr(cancel <-chan struct{}, input *fuse.SetAttrIn, out *fuse.AttrOut) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("SetAttr", time.Now())
 
 	if wfs.IsOverQuota {
 		return fuse.Status(syscall.ENOSPC)
@@ -80,6 +82,7 @@ func (wfs *WFS) SetAttr(cancel <-chan struct{}, input *fuse.SetAttrIn, out *fuse
 		}
 		entry.Attributes.Mtime = time.Now().Unix()
 		entry.Attributes.FileSize = size
+		wfs.invalidateLocalWrite(input.NodeId, int64(size), -1)
 
 	}
 
@@ -0,0 +1,77 @@
+package mount
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+// newFilerCircuitBreaker opens after the consecutive filer failures within the window, and stays open
+// for the open time, before letting one probe call through. It returns nil if the failures limit is 0.
+func newFilerCircuitBreaker(option *Option, metrics *MountMetrics) *gobreaker.CircuitBreaker {
+	if option.CircuitBreakerFailures == 0 {
+		return nil
+	}
+	metrics.SetCircuitBreakerState(gobreaker.StateClosed)
+	return gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:     "filer",
+		Interval: option.CircuitBreakerWindow,
+		Timeout:  option.CircuitBreakerOpenTime,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= option.CircuitBreakerFailures
+		},
+		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+			glog.V(0).Infof("%s circuit breaker: %s => %s", name, from, to)
+			metrics.SetCircuitBreakerState(to)
+		},
+		IsSuccessful: func(err error) bool {
+			return err == nil || !isFilerUnavailable(err)
+		},
+	})
+}
+
+// isFilerUnavailable tells the filer failures from the errors of the requests themselves, e.g., not found
+func isFilerUnavailable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	// the grpc errors are often wrapped as text
+	return strings.Contains(err.Error(), "transport") || strings.Contains(err.Error(), "DeadlineExceeded")
+}
+
+// isFilerCircuitOpen is true while the filer calls fail fast
+func (wfs *WFS) isFilerCircuitOpen() bool {
+	return wfs.filerBreaker != nil && wfs.filerBreaker.State() == gobreaker.StateOpen
+}
+
+func (wfs *WFS) withFilerCircuitBreaker(streamingMode bool, fn func() error) error {
+	// the long-lived metadata subscription would hold the single half-open probe
+	if wfs.filerBreaker == nil || streamingMode {
+		return fn()
+	}
+	_, err := wfs.filerBreaker.Execute(func() (interface{}, error) {
+		return nil, fn()
+	})
+	return err
+}
+
+// circuitBreakerStateValue is the gauge value of the state, 0 for closed, 1 for half-open, and 2 for open
+func circuitBreakerStateValue(state gobreaker.State) float64 {
+	switch state {
+	case gobreaker.StateHalfOpen:
+		return 1
+	case gobreaker.StateOpen:
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,65 @@
+package mount
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFilerCircuitBreaker(t *testing.T) {
+	metrics := NewMountMetrics(prometheus.NewRegistry())
+	wfs := &WFS{metrics: metrics}
+	wfs.filerBreaker = newFilerCircuitBreaker(&Option{
+		CircuitBreakerFailures: 2,
+		CircuitBreakerWindow:   10 * time.Second,
+		CircuitBreakerOpenTime: 50 * time.Millisecond,
+	}, metrics)
+
+	notFound := status.Error(codes.NotFound, "not found")
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	// the request errors do not trip the breaker
+	for i := 0; i < 3; i++ {
+		wfs.withFilerCircuitBreaker(false, func() error { return notFound })
+	}
+	if wfs.isFilerCircuitOpen() {
+		t.Fatalf("breaker opened by the request errors")
+	}
+
+	for i := 0; i < 2; i++ {
+		wfs.withFilerCircuitBreaker(false, func() error { return unavailable })
+	}
+	if !wfs.isFilerCircuitOpen() {
+		t.Fatalf("breaker is not opened by the filer failures")
+	}
+	if got := testutil.ToFloat64(metrics.circuitBreakerGauge); got != 2 {
+		t.Errorf("breaker state gauge = %v, want 2", got)
+	}
+	called := false
+	err := wfs.withFilerCircuitBreaker(false, func() error {
+		called = true
+		return nil
+	})
+	if called || !errors.Is(err, gobreaker.ErrOpenState) {
+		t.Errorf("open breaker called the filer, err %v", err)
+	}
+
+	// the streaming calls bypass the breaker
+	if err := wfs.withFilerCircuitBreaker(true, func() error { return nil }); err != nil {
+		t.Errorf("streaming call: %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := wfs.withFilerCircuitBreaker(false, func() error { return nil }); err != nil {
+		t.Errorf("half-open probe: %v", err)
+	}
+	if wfs.isFilerCircuitOpen() || testutil.ToFloat64(metrics.circuitBreakerGauge) != 0 {
+		t.Errorf("breaker is not closed after a successful probe")
+	}
+}
@@ -2,12 +2,13 @@ package mount
 
 import (
 	"context"
+	"syscall"
+	"time"
 
 	"github.com/hanwen/go-fuse/v2/fuse"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
-	"github.com/seaweedfs/seaweedfs/weed/mount/meta_cache"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 )
 
@@ -17,6 +18,7 @@ import (
 // name) pair.
 
 func (wfs *WFS) Lookup(cancel <-chan struct{}, header *fuse.InHeader, name string, out *fuse.EntryOut) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Lookup", time.Now())
 
 	if s := checkName(name); s != fuse.OK {
 		return s
@@ -29,10 +31,15 @@ func (wfs *WFS) Lookup(cancel <-chan struct{}, header *fuse.InHeader, name strin
 
 	fullFilePath := dirPath.Child(name)
 
-	visitErr := meta_cache.EnsureVisited(wfs.metaCache, wfs, dirPath)
+	ctx, cancelCtx := newTimeoutContext(wfs.option.MetadataTimeout)
+	defer cancelCtx()
+	visitErr := wfs.ensureVisited(ctx, dirPath)
 	if visitErr != nil {
 		glog.Errorf("dir Lookup %s: %v", dirPath, visitErr)
-		return fuse.EIO
+		if wfs.isFilerCircuitOpen() {
+			return fuse.Status(syscall.ESTALE)
+		}
+		return timeoutStatus(ctx, fuse.EIO)
 	}
 	localEntry, cacheErr := wfs.metaCache.FindEntry(context.Background(), fullFilePath)
 	if cacheErr == filer_pb.ErrNotFound {
@@ -41,10 +48,13 @@ func (wfs *WFS) Lookup(cancel <-chan struct{}, header *fuse.InHeader, name strin
 
 	if localEntry == nil {
 		// glog.V(3).Infof("dir Lookup cache miss %s", fullFilePath)
-		entry, err := filer_pb.GetEntry(wfs, fullFilePath)
+		entry, err := filer_pb.GetEntry(wfs.filerClientWithContext(ctx, fullFilePath), fullFilePath)
 		if err != nil {
 			glog.V(1).Infof("dir GetEntry %s: %v", fullFilePath, err)
-			return fuse.ENOENT
+			if wfs.isFilerCircuitOpen() {
+				return fuse.Status(syscall.ESTALE)
+			}
+			return timeoutStatus(ctx, fuse.ENOENT)
 		}
 		localEntry = filer.FromPbEntry(string(dirPath), entry)
 	} else {
@@ -22,6 +22,7 @@ import (
  * correct directory type bits use  mode|S_IFDIR
  * */
 func (wfs *WFS) Mkdir(cancel <-chan struct{}, in *fuse.MkdirIn, name string, out *fuse.EntryOut) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Mkdir", time.Now())
 
 	if wfs.IsOverQuota {
 		return fuse.Status(syscall.ENOSPC)
@@ -50,7 +51,7 @@ func (wfs *WFS) Mkdir(cancel <-chan struct{}, in *fuse.MkdirIn, name string, out
 
 	entryFullPath := dirFullPath.Child(name)
 
-	err := wfs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+	err := wfs.filerClientFor(entryFullPath).WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
 
 		wfs.mapPbIdFromLocalToFiler(newEntry)
 		defer wfs.mapPbIdFromFilerToLocal(newEntry)
@@ -78,7 +79,7 @@ func (wfs *WFS) Mkdir(cancel <-chan struct{}, in *fuse.MkdirIn, name string, out
 	glog.V(3).Infof("mkdir %s: %v", entryFullPath, err)
 
 	if err != nil {
-		return fuse.EIO
+		return createEntryStatus(err)
 	}
 
 	inode := wfs.inodeToPath.Lookup(entryFullPath, newEntry.Attributes.Crtime, true, false, 0, true)
@@ -91,6 +92,7 @@ func (wfs *WFS) Mkdir(cancel <-chan struct{}, in *fuse.MkdirIn, name string, out
 
 /** Remove a directory */
 func (wfs *WFS) Rmdir(cancel <-chan struct{}, header *fuse.InHeader, name string) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Rmdir", time.Now())
 
 	if name == "." {
 		return fuse.Status(syscall.EINVAL)
@@ -107,7 +109,7 @@ func (wfs *WFS) Rmdir(cancel <-chan struct{}, header *fuse.InHeader, name string
 
 	glog.V(3).Infof("remove directory: %v", entryFullPath)
 	ignoreRecursiveErr := true // ignore recursion error since the OS should manage it
-	err := filer_pb.Remove(wfs, string(dirFullPath), name, true, false, ignoreRecursiveErr, false, []int32{wfs.signature})
+	err := filer_pb.Remove(wfs.filerClientFor(entryFullPath), string(dirFullPath), name, true, false, ignoreRecursiveErr, false, []int32{wfs.signature})
 	if err != nil {
 		glog.V(0).Infof("remove %s: %v", entryFullPath, err)
 		if strings.Contains(err.Error(), filer.MsgFailDelNonEmptyFolder) {
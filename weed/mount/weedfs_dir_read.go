@@ -5,9 +5,10 @@ import (
 	"github.com/hanwen/go-fuse/v2/fuse"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
-	"github.com/seaweedfs/seaweedfs/weed/mount/meta_cache"
 	"math"
 	"sync"
+	"syscall"
+	"time"
 )
 
 type DirectoryHandleId uint64
@@ -85,6 +86,7 @@ func (wfs *WFS) ReleaseDirectoryHandle(dhid DirectoryHandleId) {
  * passed to readdir, releasedir and fsyncdir.
  */
 func (wfs *WFS) OpenDir(cancel <-chan struct{}, input *fuse.OpenIn, out *fuse.OpenOut) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("OpenDir", time.Now())
 	if !wfs.inodeToPath.HasInode(input.NodeId) {
 		return fuse.ENOENT
 	}
@@ -99,6 +101,7 @@ func (wfs *WFS) OpenDir(cancel <-chan struct{}, input *fuse.OpenIn, out *fuse.Op
  * path parameter will be NULL.
  */
 func (wfs *WFS) ReleaseDir(input *fuse.ReleaseIn) {
+	defer wfs.metrics.ObserveFuseOp("ReleaseDir", time.Now())
 	wfs.ReleaseDirectoryHandle(DirectoryHandleId(input.Fh))
 }
 
@@ -111,6 +114,7 @@ func (wfs *WFS) ReleaseDir(input *fuse.ReleaseIn) {
  * should be flushed, not the meta data
  */
 func (wfs *WFS) FsyncDir(cancel <-chan struct{}, input *fuse.FsyncIn) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("FsyncDir", time.Now())
 	return fuse.OK
 }
 
@@ -130,10 +134,12 @@ func (wfs *WFS) FsyncDir(cancel <-chan struct{}, input *fuse.FsyncIn) (code fuse
  * '1'.
  */
 func (wfs *WFS) ReadDir(cancel <-chan struct{}, input *fuse.ReadIn, out *fuse.DirEntryList) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("ReadDir", time.Now())
 	return wfs.doReadDirectory(input, out, false)
 }
 
 func (wfs *WFS) ReadDirPlus(cancel <-chan struct{}, input *fuse.ReadIn, out *fuse.DirEntryList) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("ReadDirPlus", time.Now())
 	return wfs.doReadDirectory(input, out, true)
 }
 
@@ -218,12 +224,20 @@ func (wfs *WFS) doReadDirectory(input *fuse.ReadIn, out *fuse.DirEntryList, isPl
 		}
 	}
 
+	ctx, cancelCtx := newTimeoutContext(wfs.option.MetadataTimeout)
+	defer cancelCtx()
 	var err error
-	if err = meta_cache.EnsureVisited(wfs.metaCache, wfs, dirPath); err != nil {
+	if err = wfs.ensureVisited(ctx, dirPath); err != nil {
 		glog.Errorf("dir ReadDirAll %s: %v", dirPath, err)
-		return fuse.EIO
+		if wfs.isFilerCircuitOpen() {
+			return fuse.Status(syscall.ESTALE)
+		}
+		return timeoutStatus(ctx, fuse.EIO)
 	}
 	listErr := wfs.metaCache.ListDirectoryEntries(context.Background(), dirPath, lastEntryName, false, int64(math.MaxInt32), func(entry *filer.Entry) bool {
+		if entry.Name() == filer.TrashDirName && !wfs.option.ShowTrash {
+			return true
+		}
 		dh.entryStream = append(dh.entryStream, entry)
 		return processEachEntryFn(entry)
 	})
@@ -28,6 +28,7 @@ import (
  * glibc release branches.)
  */
 func (wfs *WFS) CopyFileRange(cancel <-chan struct{}, in *fuse.CopyFileRangeIn) (written uint32, code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("CopyFileRange", time.Now())
 	// flags must equal 0 for this syscall as of now
 	if in.Flags != 0 {
 		return 0, fuse.EINVAL
@@ -69,11 +70,13 @@ func (wfs *WFS) CopyFileRange(cancel <-chan struct{}, in *fuse.CopyFileRangeIn)
 		in.OffOut, in.OffOut+in.Len,
 	)
 
+	ctx, cancelCtx := newTimeoutContext(wfs.option.ReadTimeout)
+	defer cancelCtx()
 	data := make([]byte, in.Len)
-	totalRead, err := readDataByFileHandle(data, fhIn, int64(in.OffIn))
+	totalRead, err := readDataByFileHandle(ctx, data, fhIn, int64(in.OffIn))
 	if err != nil {
 		glog.Warningf("file handle read %s %d: %v", fhIn.FullPath(), totalRead, err)
-		return 0, fuse.EIO
+		return 0, timeoutStatus(ctx, fuse.EIO)
 	}
 	data = data[:totalRead]
 
@@ -94,5 +97,7 @@ func (wfs *WFS) CopyFileRange(cancel <-chan struct{}, in *fuse.CopyFileRangeIn)
 		fhOut.contentType = http.DetectContentType(data)
 	}
 
+	wfs.invalidateLocalWrite(fhOut.inode, int64(in.OffOut), int64(written))
+
 	return written, fuse.OK
 }
@@ -2,6 +2,7 @@ package mount
 
 import (
 	"github.com/hanwen/go-fuse/v2/fuse"
+	"time"
 )
 
 /**
@@ -61,6 +62,7 @@ import (
 	 * @param fi file information
 */
 func (wfs *WFS) Open(cancel <-chan struct{}, in *fuse.OpenIn, out *fuse.OpenOut) (status fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Open", time.Now())
 	var fileHandle *FileHandle
 	fileHandle, status = wfs.AcquireHandle(in.NodeId, in.Uid, in.Gid)
 	if status == fuse.OK {
@@ -96,5 +98,6 @@ func (wfs *WFS) Open(cancel <-chan struct{}, in *fuse.OpenIn, out *fuse.OpenOut)
  * @param fi file information
  */
 func (wfs *WFS) Release(cancel <-chan struct{}, in *fuse.ReleaseIn) {
+	defer wfs.metrics.ObserveFuseOp("Release", time.Now())
 	wfs.ReleaseHandle(FileHandleId(in.Fh))
 }
@@ -2,6 +2,7 @@ package mount
 
 import (
 	"syscall"
+	"time"
 
 	"github.com/hanwen/go-fuse/v2/fuse"
 
@@ -19,6 +20,7 @@ const (
 // Lseek finds next data or hole segments after the specified offset
 // See https://man7.org/linux/man-pages/man2/lseek.2.html
 func (wfs *WFS) Lseek(cancel <-chan struct{}, in *fuse.LseekIn, out *fuse.LseekOut) fuse.Status {
+	defer wfs.metrics.ObserveFuseOp("Lseek", time.Now())
 	// not a documented feature
 	if in.Padding != 0 {
 		return fuse.EINVAL
@@ -23,6 +23,7 @@ import (
  * will be called instead.
  */
 func (wfs *WFS) Create(cancel <-chan struct{}, in *fuse.CreateIn, name string, out *fuse.CreateOut) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Create", time.Now())
 	// if implemented, need to use
 	// 	inode := wfs.inodeToPath.Lookup(entryFullPath)
 	// to ensure nlookup counter
@@ -36,10 +37,14 @@ func (wfs *WFS) Create(cancel <-chan struct{}, in *fuse.CreateIn, name string, o
  * regular files that will be called instead.
  */
 func (wfs *WFS) Mknod(cancel <-chan struct{}, in *fuse.MknodIn, name string, out *fuse.EntryOut) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Mknod", time.Now())
 
 	if wfs.IsOverQuota {
 		return fuse.Status(syscall.ENOSPC)
 	}
+	if wfs.isFilerCircuitOpen() {
+		return fuse.Status(syscall.EAGAIN)
+	}
 
 	if s := checkName(name); s != fuse.OK {
 		return s
@@ -70,7 +75,7 @@ func (wfs *WFS) Mknod(cancel <-chan struct{}, in *fuse.MknodIn, name string, out
 		},
 	}
 
-	err := wfs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+	err := wfs.filerClientFor(entryFullPath).WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
 
 		wfs.mapPbIdFromLocalToFiler(newEntry)
 		defer wfs.mapPbIdFromFilerToLocal(newEntry)
@@ -98,7 +103,7 @@ func (wfs *WFS) Mknod(cancel <-chan struct{}, in *fuse.MknodIn, name string, out
 	glog.V(3).Infof("mknod %s: %v", entryFullPath, err)
 
 	if err != nil {
-		return fuse.EIO
+		return createEntryStatus(err)
 	}
 
 	// this is to increase nlookup counter
@@ -112,6 +117,7 @@ func (wfs *WFS) Mknod(cancel <-chan struct{}, in *fuse.MknodIn, name string, out
 
 /** Remove a file */
 func (wfs *WFS) Unlink(cancel <-chan struct{}, header *fuse.InHeader, name string) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Unlink", time.Now())
 
 	dirFullPath, code := wfs.inodeToPath.GetPath(header.NodeId)
 	if code != fuse.OK {
@@ -133,7 +139,7 @@ func (wfs *WFS) Unlink(cancel <-chan struct{}, header *fuse.InHeader, name strin
 	// first, ensure the filer store can correctly delete
 	glog.V(3).Infof("remove file: %v", entryFullPath)
 	isDeleteData := entry != nil && entry.HardLinkCounter <= 1
-	err := filer_pb.Remove(wfs, string(dirFullPath), name, isDeleteData, false, false, false, []int32{wfs.signature})
+	err := filer_pb.Remove(wfs.filerClientFor(entryFullPath), string(dirFullPath), name, isDeleteData, false, false, false, []int32{wfs.signature})
 	if err != nil {
 		glog.V(0).Infof("remove %s: %v", entryFullPath, err)
 		return fuse.OK
@@ -145,6 +151,7 @@ func (wfs *WFS) Unlink(cancel <-chan struct{}, header *fuse.InHeader, name strin
 		return fuse.EIO
 	}
 
+	wfs.symlinkCache.delete(wfs.inodeToPath.GetInode(entryFullPath))
 	wfs.inodeToPath.RemovePath(entryFullPath)
 
 	return fuse.OK
@@ -2,8 +2,10 @@ package mount
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/hanwen/go-fuse/v2/fuse"
 
@@ -36,6 +38,7 @@ import (
  * @param fi file information
  */
 func (wfs *WFS) Read(cancel <-chan struct{}, in *fuse.ReadIn, buff []byte) (fuse.ReadResult, fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Read", time.Now())
 	fh := wfs.GetHandle(FileHandleId(in.Fh))
 	if fh == nil {
 		return nil, fuse.ENOENT
@@ -44,12 +47,15 @@ func (wfs *WFS) Read(cancel <-chan struct{}, in *fuse.ReadIn, buff []byte) (fuse
 	fh.RLock()
 	defer fh.RUnlock()
 
+	ctx, cancelCtx := newTimeoutContext(wfs.option.ReadTimeout)
+	defer cancelCtx()
 	offset := int64(in.Offset)
-	totalRead, err := readDataByFileHandle(buff, fh, offset)
+	totalRead, err := readDataByFileHandle(ctx, buff, fh, offset)
 	if err != nil {
 		glog.Warningf("file handle read %s %d: %v", fh.FullPath(), totalRead, err)
-		return nil, fuse.EIO
+		return nil, timeoutStatus(ctx, fuse.EIO)
 	}
+	wfs.throttleDownload(fh, int(totalRead))
 
 	if IsDebugFileReadWrite {
 		// print(".")
@@ -58,7 +64,7 @@ func (wfs *WFS) Read(cancel <-chan struct{}, in *fuse.ReadIn, buff []byte) (fuse
 		if bytes.Compare(mirrorData, buff[:totalRead]) != 0 {
 
 			againBuff := make([]byte, len(buff))
-			againRead, _ := readDataByFileHandle(buff, fh, offset)
+			againRead, _ := readDataByFileHandle(ctx, buff, fh, offset)
 			againCorrect := bytes.Compare(mirrorData, againBuff[:againRead]) == 0
 			againSame := bytes.Compare(buff[:totalRead], againBuff[:againRead]) == 0
 
@@ -71,13 +77,13 @@ func (wfs *WFS) Read(cancel <-chan struct{}, in *fuse.ReadIn, buff []byte) (fuse
 	return fuse.ReadResultData(buff[:totalRead]), fuse.OK
 }
 
-func readDataByFileHandle(buff []byte, fhIn *FileHandle, offset int64) (int64, error) {
+func readDataByFileHandle(ctx context.Context, buff []byte, fhIn *FileHandle, offset int64) (int64, error) {
 	// read data from source file
 	size := len(buff)
 	fhIn.lockForRead(offset, size)
 	defer fhIn.unlockForRead(offset, size)
 
-	n, tsNs, err := fhIn.readFromChunks(buff, offset)
+	n, tsNs, err := fhIn.readFromChunks(ctx, buff, offset)
 	if err == nil || err == io.EOF {
 		maxStop := fhIn.readFromDirtyPages(buff, offset, tsNs)
 		n = max(maxStop-offset, n)
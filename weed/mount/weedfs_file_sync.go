@@ -50,12 +50,13 @@ import (
  * [close]: http://pubs.opengroup.org/onlinepubs/9699919799/functions/close.html
  */
 func (wfs *WFS) Flush(cancel <-chan struct{}, in *fuse.FlushIn) fuse.Status {
+	defer wfs.metrics.ObserveFuseOp("Flush", time.Now())
 	fh := wfs.GetHandle(FileHandleId(in.Fh))
 	if fh == nil {
 		return fuse.ENOENT
 	}
 
-	return wfs.doFlush(fh, in.Uid, in.Gid)
+	return wfs.doFlush(fh, in.Uid, in.Gid, false)
 }
 
 /**
@@ -78,17 +79,19 @@ func (wfs *WFS) Flush(cancel <-chan struct{}, in *fuse.FlushIn) fuse.Status {
  * @param fi file information
  */
 func (wfs *WFS) Fsync(cancel <-chan struct{}, in *fuse.FsyncIn) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Fsync", time.Now())
 
 	fh := wfs.GetHandle(FileHandleId(in.Fh))
 	if fh == nil {
 		return fuse.ENOENT
 	}
 
-	return wfs.doFlush(fh, in.Uid, in.Gid)
+	return wfs.doFlush(fh, in.Uid, in.Gid, true)
 
 }
 
-func (wfs *WFS) doFlush(fh *FileHandle, uid, gid uint32) fuse.Status {
+// doFlush saves the entry with the filer. The fsync ones are batched with other fsync calls.
+func (wfs *WFS) doFlush(fh *FileHandle, uid, gid uint32, isFsync bool) fuse.Status {
 	fh.Lock()
 	defer fh.Unlock()
 
@@ -113,7 +116,9 @@ func (wfs *WFS) doFlush(fh *FileHandle, uid, gid uint32) fuse.Status {
 		return fuse.Status(syscall.ENOSPC)
 	}
 
-	err := wfs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+	ctx, cancelCtx := newTimeoutContext(wfs.option.WriteTimeout)
+	defer cancelCtx()
+	err := wfs.filerClientFor(fileFullPath).withFilerClientContext(ctx, false, func(client filer_pb.SeaweedFilerClient) error {
 		fh.entryLock.Lock()
 		defer fh.entryLock.Unlock()
 
@@ -151,8 +156,9 @@ func (wfs *WFS) doFlush(fh *FileHandle, uid, gid uint32) fuse.Status {
 
 		manifestChunks, nonManifestChunks := filer.SeparateManifestChunks(entry.GetChunks())
 
-		chunks, _ := filer.CompactFileChunks(wfs.LookupFn(), nonManifestChunks)
-		chunks, manifestErr := filer.MaybeManifestize(wfs.saveDataAsChunk(fileFullPath), chunks)
+		chunks, _ := filer.CompactFileChunks(wfs.LookupFn(fileFullPath), nonManifestChunks)
+		// the manifest chunks are never shared, the references are kept for their data chunks
+		chunks, manifestErr := filer.MaybeManifestize(wfs.uploadDataAsChunk(fileFullPath, false), chunks)
 		if manifestErr != nil {
 			// not good, but should be ok
 			glog.V(0).Infof("MaybeManifestize: %v", manifestErr)
@@ -162,7 +168,13 @@ func (wfs *WFS) doFlush(fh *FileHandle, uid, gid uint32) fuse.Status {
 		wfs.mapPbIdFromLocalToFiler(request.Entry)
 		defer wfs.mapPbIdFromFilerToLocal(request.Entry)
 
-		if err := filer_pb.CreateEntry(client, request); err != nil {
+		var err error
+		if isFsync {
+			err = wfs.fsyncBatcher.CreateEntry(request)
+		} else {
+			err = filer_pb.CreateEntry(client, request)
+		}
+		if err != nil {
 			glog.Errorf("fh flush create %s: %v", fileFullPath, err)
 			return fmt.Errorf("fh flush create %s: %v", fileFullPath, err)
 		}
@@ -178,7 +190,7 @@ func (wfs *WFS) doFlush(fh *FileHandle, uid, gid uint32) fuse.Status {
 
 	if err != nil {
 		glog.Errorf("%v fh %d flush: %v", fileFullPath, fh.fh, err)
-		return fuse.EIO
+		return timeoutStatus(ctx, fuse.EIO)
 	}
 
 	if IsDebugFileReadWrite {
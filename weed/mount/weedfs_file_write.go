@@ -34,10 +34,14 @@ import (
  * @param fi file information
  */
 func (wfs *WFS) Write(cancel <-chan struct{}, in *fuse.WriteIn, data []byte) (written uint32, code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Write", time.Now())
 
 	if wfs.IsOverQuota {
 		return 0, fuse.Status(syscall.ENOSPC)
 	}
+	if wfs.isFilerCircuitOpen() {
+		return 0, fuse.Status(syscall.EAGAIN)
+	}
 
 	fh := wfs.GetHandle(FileHandleId(in.Fh))
 	if fh == nil {
@@ -77,5 +81,7 @@ func (wfs *WFS) Write(cancel <-chan struct{}, in *fuse.WriteIn, data []byte) (wr
 		fh.mirrorFile.WriteAt(data, offset)
 	}
 
+	wfs.invalidateLocalWrite(fh.inode, offset, int64(written))
+
 	return written, fuse.OK
 }
@@ -0,0 +1,47 @@
+package mount
+
+import (
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// loopReadFilerConf keeps the filer.conf, to know the paths configured with dedup without asking the filer on each write
+func (wfs *WFS) loopReadFilerConf() {
+	for {
+		wfs.readFilerConf()
+		time.Sleep(61 * time.Second)
+	}
+}
+
+func (wfs *WFS) readFilerConf() {
+	fc := filer.NewFilerConf()
+	err := wfs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		content, err := filer.ReadInsideFiler(client, filer.DirectoryEtcSeaweedFS, filer.FilerConfName)
+		if err == filer_pb.ErrNotFound || len(content) == 0 {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return fc.LoadFromBytes(content)
+	})
+	if err != nil {
+		glog.V(1).Infof("read %s/%s: %v", filer.DirectoryEtcSeaweedFS, filer.FilerConfName, err)
+		return
+	}
+	wfs.filerConf.Store(fc)
+}
+
+// isDedupPath returns whether the chunks of the file are deduplicated by content hash.
+// The chunks with a ttl expire on their own, so they are never shared.
+func (wfs *WFS) isDedupPath(fullPath util.FullPath) bool {
+	if wfs.option.TtlSec > 0 || wfs.dedupUnsupported.Load() {
+		return false
+	}
+	fc := wfs.filerConf.Load()
+	return fc != nil && fc.MatchStorageRule(string(fullPath)).Dedup
+}
@@ -3,6 +3,7 @@ package mount
 import (
 	"context"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"time"
 )
 
 // Forget is called when the kernel discards entries from its
@@ -62,8 +63,46 @@ Side effects: increments the lookup count on success
 
 */
 func (wfs *WFS) Forget(nodeid, nlookup uint64) {
-	wfs.inodeToPath.Forget(nodeid, nlookup, func(dir util.FullPath) {
+	defer wfs.metrics.ObserveFuseOp("Forget", time.Now())
+	// the eviction is done by loopProcessForget, to not contend with Lookup on the fuse dispatch goroutine
+	wfs.forgetQueue <- forgetRequest{inode: nodeid, nlookup: nlookup}
+}
+
+const (
+	forgetQueueSize = 4096
+	forgetBatchSize = 1024
+)
+
+type forgetRequest struct {
+	inode   uint64
+	nlookup uint64
+}
+
+// loopProcessForget drains the forget queue in batches, evicting the forgotten inodes
+func (wfs *WFS) loopProcessForget() {
+	batch := make([]forgetRequest, 0, forgetBatchSize)
+	for forget := range wfs.forgetQueue {
+		batch = append(batch[:0], forget)
+	collect:
+		for len(batch) < forgetBatchSize {
+			select {
+			case forget = <-wfs.forgetQueue:
+				batch = append(batch, forget)
+			default:
+				break collect
+			}
+		}
+		wfs.processForgets(batch)
+	}
+}
+
+// processForgets only releases the file handles of the evicted inodes,
+// since an inode looked up again while its forget was queued may have been opened again.
+func (wfs *WFS) processForgets(batch []forgetRequest) {
+	evicted := wfs.inodeToPath.ForgetBatch(batch, func(dir util.FullPath) {
 		wfs.metaCache.DeleteFolderChildren(context.Background(), dir)
 	})
-	wfs.fhmap.ReleaseByInode(nodeid)
+	for _, inode := range evicted {
+		wfs.fhmap.ReleaseByInode(inode)
+	}
 }
@@ -0,0 +1,186 @@
+package mount
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	fsyncBatchMaxSize = 100
+	fsyncBatchMaxWait = 10 * time.Millisecond
+)
+
+// fsyncBatcher sends the entries saved by the fsync calls in short succession, e.g., from "git clone",
+// to the filer with one BatchFlushEntries call.
+type fsyncBatcher struct {
+	createFn  func(requests []*filer_pb.CreateEntryRequest) []error
+	metrics   *MountMetrics
+	requests  chan *fsyncRequest
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+	loopEnded sync.WaitGroup
+}
+
+type fsyncRequest struct {
+	request *filer_pb.CreateEntryRequest
+	done    chan error
+}
+
+func newFsyncBatcher(createFn func(requests []*filer_pb.CreateEntryRequest) []error, metrics *MountMetrics) *fsyncBatcher {
+	b := &fsyncBatcher{
+		createFn: createFn,
+		metrics:  metrics,
+		requests: make(chan *fsyncRequest, fsyncBatchMaxSize),
+		stopCh:   make(chan struct{}),
+	}
+	b.loopEnded.Add(1)
+	go b.loop()
+	return b
+}
+
+// CreateEntry blocks until the entry is saved in a batch.
+func (b *fsyncBatcher) CreateEntry(request *filer_pb.CreateEntryRequest) error {
+	r := &fsyncRequest{
+		request: request,
+		done:    make(chan error, 1),
+	}
+	select {
+	case b.requests <- r:
+	case <-b.stopCh:
+		return b.createFn([]*filer_pb.CreateEntryRequest{request})[0]
+	}
+	return <-r.done
+}
+
+// Shutdown saves the pending entries, and the later calls are not batched.
+func (b *fsyncBatcher) Shutdown() {
+	b.stopOnce.Do(func() {
+		close(b.stopCh)
+	})
+	b.loopEnded.Wait()
+}
+
+func (b *fsyncBatcher) loop() {
+	defer b.loopEnded.Done()
+	for {
+		var batch []*fsyncRequest
+		select {
+		case r := <-b.requests:
+			batch = append(batch, r)
+		case <-b.stopCh:
+			b.drain()
+			return
+		}
+
+		timer := time.NewTimer(fsyncBatchMaxWait)
+	collect:
+		for len(batch) < fsyncBatchMaxSize {
+			select {
+			case r := <-b.requests:
+				batch = append(batch, r)
+			case <-timer.C:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		b.flush(batch)
+	}
+}
+
+func (b *fsyncBatcher) drain() {
+	var batch []*fsyncRequest
+	for {
+		select {
+		case r := <-b.requests:
+			batch = append(batch, r)
+			if len(batch) >= fsyncBatchMaxSize {
+				b.flush(batch)
+				batch = nil
+			}
+		default:
+			if len(batch) > 0 {
+				b.flush(batch)
+			}
+			return
+		}
+	}
+}
+
+func (b *fsyncBatcher) flush(batch []*fsyncRequest) {
+	b.metrics.ObserveFsyncBatch(len(batch))
+	requests := make([]*filer_pb.CreateEntryRequest, len(batch))
+	for i, r := range batch {
+		requests[i] = r.request
+	}
+	errs := b.createFn(requests)
+	for i, r := range batch {
+		r.done <- errs[i]
+	}
+}
+
+// batchCreateEntries saves the entries with one BatchFlushEntries call per namespace filer
+func (wfs *WFS) batchCreateEntries(requests []*filer_pb.CreateEntryRequest) []error {
+	errs := make([]error, len(requests))
+	var keys []string
+	indexes := make(map[string][]int)
+	for i, request := range requests {
+		key := wfs.namespaceRoute(util.NewFullPath(request.Directory, request.Entry.Name)).key()
+		if _, found := indexes[key]; !found {
+			keys = append(keys, key)
+		}
+		indexes[key] = append(indexes[key], i)
+	}
+	for _, key := range keys {
+		batch := make([]*filer_pb.CreateEntryRequest, len(indexes[key]))
+		for j, i := range indexes[key] {
+			batch[j] = requests[i]
+		}
+		first := batch[0]
+		client := wfs.filerClientFor(util.NewFullPath(first.Directory, first.Entry.Name))
+		for j, err := range createEntriesOn(client, batch) {
+			errs[indexes[key][j]] = err
+		}
+	}
+	return errs
+}
+
+// createEntriesOn saves the entries with one BatchFlushEntries call,
+// or one by one if the filer does not support it.
+func createEntriesOn(filerClient filer_pb.FilerClient, requests []*filer_pb.CreateEntryRequest) []error {
+	errs := make([]error, len(requests))
+	err := filerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.BatchFlushEntries(context.Background(), &filer_pb.BatchFlushEntriesRequest{
+			Entries: requests,
+		})
+		if err != nil {
+			return err
+		}
+		if len(resp.Responses) != len(requests) {
+			return fmt.Errorf("batch flush %d entries: %d responses", len(requests), len(resp.Responses))
+		}
+		for i, r := range resp.Responses {
+			if r.Error != "" {
+				errs[i] = fmt.Errorf("CreateEntry: %v", r.Error)
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		return errs
+	}
+
+	glog.V(1).Infof("batch flush %d entries: %v", len(requests), err)
+	for i, request := range requests {
+		errs[i] = filerClient.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			return filer_pb.CreateEntry(client, request)
+		})
+	}
+	return errs
+}
@@ -0,0 +1,62 @@
+package mount
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+func TestFsyncBatcher(t *testing.T) {
+	var lock sync.Mutex
+	var batchSizes []int
+	b := newFsyncBatcher(func(requests []*filer_pb.CreateEntryRequest) []error {
+		lock.Lock()
+		batchSizes = append(batchSizes, len(requests))
+		lock.Unlock()
+		errs := make([]error, len(requests))
+		for i, r := range requests {
+			if r.Directory == "/bad" {
+				errs[i] = fmt.Errorf("bad directory")
+			}
+		}
+		return errs
+	}, NewMountMetrics(prometheus.NewRegistry()))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 20)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dir := "/good"
+			if i%5 == 0 {
+				dir = "/bad"
+			}
+			errs[i] = b.CreateEntry(&filer_pb.CreateEntryRequest{Directory: dir})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if (i%5 == 0) != (err != nil) {
+			t.Errorf("request %d: %v", i, err)
+		}
+	}
+	total := 0
+	for _, n := range batchSizes {
+		total += n
+	}
+	if total != len(errs) {
+		t.Errorf("batch sizes %v", batchSizes)
+	}
+
+	// after shutdown, the entries are still saved one by one
+	b.Shutdown()
+	if err := b.CreateEntry(&filer_pb.CreateEntryRequest{Directory: "/good"}); err != nil {
+		t.Errorf("create after shutdown: %v", err)
+	}
+}
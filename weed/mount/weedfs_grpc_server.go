@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mount_pb"
+	"os"
+	"strings"
 )
 
 func (wfs *WFS) Configure(ctx context.Context, request *mount_pb.ConfigureRequest) (*mount_pb.ConfigureResponse, error) {
@@ -15,3 +17,21 @@ func (wfs *WFS) Configure(ctx context.Context, request *mount_pb.ConfigureReques
 	wfs.option.Quota = request.GetCollectionCapacity()
 	return &mount_pb.ConfigureResponse{}, nil
 }
+
+// ListActiveMounts returns this mount. weed mount.list asks each local mount socket.
+func (wfs *WFS) ListActiveMounts(ctx context.Context, request *mount_pb.ListActiveMountsRequest) (*mount_pb.ListActiveMountsResponse, error) {
+	var filers []string
+	for _, address := range wfs.option.FilerAddresses {
+		filers = append(filers, string(address))
+	}
+	return &mount_pb.ListActiveMountsResponse{
+		Mounts: []*mount_pb.MountInfo{{
+			MountDirectory:     wfs.option.MountDirectory,
+			Filer:              strings.Join(filers, ","),
+			FilerMountRootPath: wfs.option.FilerMountRootPath,
+			Collection:         wfs.option.Collection,
+			LocalSocket:        wfs.option.LocalSocket,
+			Pid:                int64(os.Getpid()),
+		}},
+	}, nil
+}
@@ -0,0 +1,106 @@
+package mount
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+const (
+	healthStateHealthy    = "healthy"
+	healthStateDegraded   = "degraded"
+	healthStateRemounting = "remounting"
+
+	healthCheckMaxFailures = 3
+)
+
+// HealthMonitor periodically stats the mount root through the kernel, and pings the filer.
+// After healthCheckMaxFailures consecutive failures, onUnhealthy is called to remount,
+// and the monitoring goes on with the remounted file system.
+type HealthMonitor struct {
+	interval    time.Duration
+	check       func(timeout time.Duration) error
+	onUnhealthy func()
+	metrics     *MountMetrics
+	failures    int
+	checking    atomic.Bool
+}
+
+func NewHealthMonitor(wfs *WFS, interval time.Duration, onUnhealthy func()) *HealthMonitor {
+	return &HealthMonitor{
+		interval:    interval,
+		check:       wfs.checkHealth,
+		onUnhealthy: onUnhealthy,
+		metrics:     wfs.metrics,
+	}
+}
+
+func (hm *HealthMonitor) Start() {
+	hm.metrics.SetHealth(healthStateHealthy)
+	for {
+		time.Sleep(hm.interval)
+		hm.checkOnce()
+	}
+}
+
+// checkOnce returns true if onUnhealthy is called
+func (hm *HealthMonitor) checkOnce() bool {
+	err := hm.checkWithTimeout(hm.interval)
+	if err == nil {
+		if hm.failures > 0 {
+			glog.V(0).Infof("mount is healthy again after %d failures", hm.failures)
+		}
+		hm.failures = 0
+		hm.metrics.SetHealth(healthStateHealthy)
+		return false
+	}
+	hm.failures++
+	glog.Warningf("mount health check %d/%d: %v", hm.failures, healthCheckMaxFailures, err)
+	if hm.failures < healthCheckMaxFailures {
+		hm.metrics.SetHealth(healthStateDegraded)
+		return false
+	}
+	hm.metrics.SetHealth(healthStateRemounting)
+	hm.failures = 0
+	hm.onUnhealthy()
+	return true
+}
+
+// checkWithTimeout does not start another check while a previous one is still stuck
+func (hm *HealthMonitor) checkWithTimeout(timeout time.Duration) error {
+	if !hm.checking.CompareAndSwap(false, true) {
+		return fmt.Errorf("previous check is not finished")
+	}
+	done := make(chan error, 1)
+	go func() {
+		err := hm.check(timeout)
+		hm.checking.Store(false)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timeout after %v", timeout)
+	}
+}
+
+// checkHealth does a getattr on the root inode from the kernel side, and pings the filer.
+// The ping bypasses the circuit breaker, so it neither fails fast while the breaker is open,
+// nor counts as a filer call of the file system.
+func (wfs *WFS) checkHealth(timeout time.Duration) error {
+	if _, err := os.Stat(wfs.option.MountDirectory); err != nil {
+		return fmt.Errorf("stat %s: %v", wfs.option.MountDirectory, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return wfs.withFilerClientRetry(ctx, false, func(client filer_pb.SeaweedFilerClient) error {
+		_, err := client.Ping(ctx, &filer_pb.PingRequest{})
+		return err
+	})
+}
@@ -0,0 +1,74 @@
+package mount
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	var checkErr error
+	remounts := 0
+	hm := &HealthMonitor{
+		interval: time.Second,
+		check: func(timeout time.Duration) error {
+			return checkErr
+		},
+		onUnhealthy: func() {
+			remounts++
+		},
+		metrics: NewMountMetrics(prometheus.NewRegistry()),
+	}
+
+	checkErr = fmt.Errorf("filer is down")
+	for i := 1; i < healthCheckMaxFailures; i++ {
+		if hm.checkOnce() {
+			t.Fatalf("remount after %d failures", i)
+		}
+	}
+	if got := testutil.ToFloat64(hm.metrics.healthGauge.WithLabelValues(healthStateDegraded)); got != 1 {
+		t.Errorf("degraded = %v, want 1", got)
+	}
+
+	// a success resets the consecutive failures
+	checkErr = nil
+	hm.checkOnce()
+	checkErr = fmt.Errorf("filer is down")
+	for i := 1; i < healthCheckMaxFailures; i++ {
+		hm.checkOnce()
+	}
+	if remounts != 0 {
+		t.Fatalf("remounts = %d, want 0", remounts)
+	}
+
+	if !hm.checkOnce() || remounts != 1 {
+		t.Fatalf("remounts = %d, want 1", remounts)
+	}
+	if got := testutil.ToFloat64(hm.metrics.healthGauge.WithLabelValues(healthStateRemounting)); got != 1 {
+		t.Errorf("remounting = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(hm.metrics.healthGauge.WithLabelValues(healthStateHealthy)); got != 0 {
+		t.Errorf("healthy = %v, want 0", got)
+	}
+}
+
+func TestHealthMonitorTimeout(t *testing.T) {
+	release := make(chan struct{})
+	hm := &HealthMonitor{
+		check: func(timeout time.Duration) error {
+			<-release
+			return nil
+		},
+	}
+	if err := hm.checkWithTimeout(10 * time.Millisecond); err == nil {
+		t.Errorf("expect timeout")
+	}
+	// the stuck check is not started again
+	if err := hm.checkWithTimeout(10 * time.Millisecond); err == nil {
+		t.Errorf("expect the previous check not finished")
+	}
+	close(release)
+}
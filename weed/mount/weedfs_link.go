@@ -2,6 +2,7 @@ package mount
 
 import (
 	"context"
+	"strings"
 	"syscall"
 	"time"
 
@@ -25,6 +26,7 @@ When creating a link:
 
 /** Create a hard link to a file */
 func (wfs *WFS) Link(cancel <-chan struct{}, in *fuse.LinkIn, name string, out *fuse.EntryOut) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Link", time.Now())
 
 	if wfs.IsOverQuota {
 		return fuse.Status(syscall.ENOSPC)
@@ -43,12 +45,20 @@ func (wfs *WFS) Link(cancel <-chan struct{}, in *fuse.LinkIn, name string, out *
 		return
 	}
 	oldParentPath, _ := oldEntryPath.DirAndName()
+	newEntryPath := newParentPath.Child(name)
+	if !wfs.sameNamespace(oldEntryPath, newEntryPath) {
+		return fuse.Status(syscall.EXDEV)
+	}
 
 	oldEntry, status := wfs.maybeLoadEntry(oldEntryPath)
 	if status != fuse.OK {
 		return status
 	}
 
+	if oldEntry.HardLinkCounter >= filer.DefaultMaxHardLinks {
+		return fuse.Status(syscall.EMLINK)
+	}
+
 	// update old file to hardlink mode
 	if len(oldEntry.HardLinkId) == 0 {
 		oldEntry.HardLinkId = filer.NewHardLinkId()
@@ -79,7 +89,7 @@ func (wfs *WFS) Link(cancel <-chan struct{}, in *fuse.LinkIn, name string, out *
 	}
 
 	// apply changes to the filer, and also apply to local metaCache
-	err := wfs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+	err := wfs.filerClientFor(newEntryPath).WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
 
 		wfs.mapPbIdFromLocalToFiler(request.Entry)
 		defer wfs.mapPbIdFromFilerToLocal(request.Entry)
@@ -98,10 +108,11 @@ func (wfs *WFS) Link(cancel <-chan struct{}, in *fuse.LinkIn, name string, out *
 		return nil
 	})
 
-	newEntryPath := newParentPath.Child(name)
-
 	if err != nil {
 		glog.V(0).Infof("Link %v -> %s: %v", oldEntryPath, newEntryPath, err)
+		if strings.Contains(err.Error(), filer.ErrTooManyLinks.Error()) {
+			return fuse.Status(syscall.EMLINK)
+		}
 		return fuse.EIO
 	}
 
@@ -0,0 +1,189 @@
+package mount
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sony/gobreaker"
+
+	"github.com/seaweedfs/seaweedfs/weed/mount/meta_cache"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
+)
+
+const (
+	metaCacheLabel  = "meta"
+	chunkCacheLabel = "chunk"
+)
+
+// MountMetrics tracks fuse operations, meta cache and chunk cache efficiency, and filer rpc latency.
+// The collectors are shared if they are already registered, so that several mounts in one process
+// report into the same series.
+type MountMetrics struct {
+	fuseOpsCounter      *prometheus.CounterVec
+	fuseOpHistogram     *prometheus.HistogramVec
+	cacheHitsCounter    *prometheus.CounterVec
+	cacheMissesCounter  *prometheus.CounterVec
+	filerRpcHistogram   prometheus.Histogram
+	healthGauge         *prometheus.GaugeVec
+	fsyncBatchHistogram prometheus.Histogram
+	circuitBreakerGauge prometheus.Gauge
+}
+
+func NewMountMetrics(registerer prometheus.Registerer) *MountMetrics {
+	m := &MountMetrics{
+		fuseOpsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "seaweedfs",
+				Subsystem: "mount",
+				Name:      "fuse_ops_total",
+				Help:      "Counter of fuse operations.",
+			}, []string{"op"}),
+		fuseOpHistogram: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "seaweedfs",
+				Subsystem: "mount",
+				Name:      "fuse_op_duration_seconds",
+				Help:      "Bucketed histogram of fuse operation processing time.",
+				Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 24),
+			}, []string{"op"}),
+		cacheHitsCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "seaweedfs",
+				Subsystem: "mount",
+				Name:      "cache_hits_total",
+				Help:      "Counter of directory visits served by the meta cache, and chunk reads served by the chunk cache.",
+			}, []string{"cache"}),
+		cacheMissesCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "seaweedfs",
+				Subsystem: "mount",
+				Name:      "cache_misses_total",
+				Help:      "Counter of directory visits that list from the filer, and chunk reads that fetch from volume servers.",
+			}, []string{"cache"}),
+		filerRpcHistogram: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: "seaweedfs",
+				Subsystem: "mount",
+				Name:      "filer_rpc_duration_seconds",
+				Help:      "Bucketed histogram of filer grpc call time.",
+				Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 24),
+			}),
+		healthGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "seaweedfs",
+				Subsystem: "mount",
+				Name:      "health",
+				Help:      "The mount health state, 1 for the current state.",
+			}, []string{"state"}),
+		fsyncBatchHistogram: prometheus.NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: "seaweedfs",
+				Subsystem: "mount",
+				Name:      "fsync_batch_size",
+				Help:      "Bucketed histogram of the number of entries saved by one batch of fsync calls.",
+				Buckets:   prometheus.ExponentialBuckets(1, 2, 8),
+			}),
+		circuitBreakerGauge: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "seaweedfs",
+				Subsystem: "mount",
+				Name:      "circuit_breaker_state",
+				Help:      "The filer circuit breaker state, 0 for closed, 1 for half-open, and 2 for open.",
+			}),
+	}
+	m.fuseOpsCounter = registerOrReuse(registerer, m.fuseOpsCounter).(*prometheus.CounterVec)
+	m.fuseOpHistogram = registerOrReuse(registerer, m.fuseOpHistogram).(*prometheus.HistogramVec)
+	m.cacheHitsCounter = registerOrReuse(registerer, m.cacheHitsCounter).(*prometheus.CounterVec)
+	m.cacheMissesCounter = registerOrReuse(registerer, m.cacheMissesCounter).(*prometheus.CounterVec)
+	m.filerRpcHistogram = registerOrReuse(registerer, m.filerRpcHistogram).(prometheus.Histogram)
+	m.healthGauge = registerOrReuse(registerer, m.healthGauge).(*prometheus.GaugeVec)
+	m.fsyncBatchHistogram = registerOrReuse(registerer, m.fsyncBatchHistogram).(prometheus.Histogram)
+	m.circuitBreakerGauge = registerOrReuse(registerer, m.circuitBreakerGauge).(prometheus.Gauge)
+	return m
+}
+
+func registerOrReuse(registerer prometheus.Registerer, collector prometheus.Collector) prometheus.Collector {
+	if err := registerer.Register(collector); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			return alreadyRegistered.ExistingCollector
+		}
+		panic(err)
+	}
+	return collector
+}
+
+// ObserveFuseOp is meant to be deferred, e.g., defer wfs.metrics.ObserveFuseOp("Read", time.Now())
+func (m *MountMetrics) ObserveFuseOp(op string, start time.Time) {
+	m.fuseOpsCounter.WithLabelValues(op).Inc()
+	m.fuseOpHistogram.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
+
+func (m *MountMetrics) ObserveFilerRpc(start time.Time) {
+	m.filerRpcHistogram.Observe(time.Since(start).Seconds())
+}
+
+func (m *MountMetrics) ObserveFsyncBatch(size int) {
+	m.fsyncBatchHistogram.Observe(float64(size))
+}
+
+func (m *MountMetrics) SetHealth(state string) {
+	for _, s := range []string{healthStateHealthy, healthStateDegraded, healthStateRemounting} {
+		if s == state {
+			m.healthGauge.WithLabelValues(s).Set(1)
+		} else {
+			m.healthGauge.WithLabelValues(s).Set(0)
+		}
+	}
+}
+
+func (m *MountMetrics) SetCircuitBreakerState(state gobreaker.State) {
+	m.circuitBreakerGauge.Set(circuitBreakerStateValue(state))
+}
+
+func (m *MountMetrics) CacheHit(cache string) {
+	m.cacheHitsCounter.WithLabelValues(cache).Inc()
+}
+
+func (m *MountMetrics) CacheMiss(cache string) {
+	m.cacheMissesCounter.WithLabelValues(cache).Inc()
+}
+
+// ensureVisited loads the directory into the meta cache if needed, counting whether it was cached already.
+func (wfs *WFS) ensureVisited(ctx context.Context, dirPath util.FullPath) error {
+	if wfs.inodeToPath.IsChildrenCached(dirPath) {
+		wfs.metrics.CacheHit(metaCacheLabel)
+	} else {
+		wfs.metrics.CacheMiss(metaCacheLabel)
+	}
+	return meta_cache.EnsureVisited(wfs.metaCache, func(dir util.FullPath) filer_pb.FilerClient {
+		return wfs.filerClientWithContext(ctx, dir)
+	}, dirPath)
+}
+
+func (wfs *WFS) readChunkCache() chunk_cache.ChunkCache {
+	if wfs.chunkCache == nil {
+		return wfs.chunkCache
+	}
+	return &meteredChunkCache{ChunkCache: wfs.chunkCache, metrics: wfs.metrics}
+}
+
+// meteredChunkCache counts chunk cache hits and misses on the read path.
+type meteredChunkCache struct {
+	chunk_cache.ChunkCache
+	metrics *MountMetrics
+}
+
+func (c *meteredChunkCache) ReadChunkAt(data []byte, fileId string, offset uint64) (n int, err error) {
+	n, err = c.ChunkCache.ReadChunkAt(data, fileId, offset)
+	if n > 0 {
+		c.metrics.CacheHit(chunkCacheLabel)
+	} else {
+		c.metrics.CacheMiss(chunkCacheLabel)
+	}
+	return
+}
@@ -0,0 +1,41 @@
+package mount
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMountMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := NewMountMetrics(registry)
+
+	m.ObserveFuseOp("Lookup", time.Now())
+	m.ObserveFuseOp("Lookup", time.Now())
+	m.ObserveFuseOp("Read", time.Now())
+	m.CacheHit(metaCacheLabel)
+	m.CacheHit(metaCacheLabel)
+	m.CacheMiss(chunkCacheLabel)
+
+	if got := testutil.ToFloat64(m.fuseOpsCounter.WithLabelValues("Lookup")); got != 2 {
+		t.Errorf("Lookup ops = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.fuseOpsCounter.WithLabelValues("Read")); got != 1 {
+		t.Errorf("Read ops = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.cacheHitsCounter.WithLabelValues(metaCacheLabel)); got != 2 {
+		t.Errorf("meta cache hits = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.cacheMissesCounter.WithLabelValues(chunkCacheLabel)); got != 1 {
+		t.Errorf("chunk cache misses = %v, want 1", got)
+	}
+
+	// a second mount in the same process shares the registered collectors
+	another := NewMountMetrics(registry)
+	another.CacheHit(metaCacheLabel)
+	if got := testutil.ToFloat64(m.cacheHitsCounter.WithLabelValues(metaCacheLabel)); got != 3 {
+		t.Errorf("shared meta cache hits = %v, want 3", got)
+	}
+}
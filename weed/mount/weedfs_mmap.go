@@ -0,0 +1,65 @@
+package mount
+
+import (
+	"context"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// FUSE has no mmap operation. The kernel maps the files to its page cache, serves the page faults with Read,
+// and writes back the dirty pages with Write. The mapped pages stay coherent as long as the page cache is
+// invalidated when the file content changes, which is what the MmapCoherence modes do.
+const (
+	// MmapCoherenceNone keeps the cached pages until the kernel evicts them or the file is opened again.
+	MmapCoherenceNone = "none"
+	// MmapCoherenceLocal also invalidates the cached pages after each write or truncate through this mount.
+	MmapCoherenceLocal = "local"
+	// MmapCoherenceDistributed also invalidates the cached pages when the file is changed by other clients,
+	// as soon as the filer metadata event arrives.
+	MmapCoherenceDistributed = "distributed"
+)
+
+// invalidatePageCache drops the cached pages of the inode in [offset, offset+length), length -1 to the end.
+// The notification is sent asynchronously, since the kernel may be waiting for the fuse operation being served.
+func (wfs *WFS) invalidatePageCache(inode uint64, offset, length int64) {
+	if wfs.fuseServer == nil || inode == 0 {
+		return
+	}
+	go func() {
+		if status := wfs.fuseServer.InodeNotify(inode, offset, length); !status.Ok() {
+			glog.V(4).Infof("invalidate inode %d [%d,+%d): %v", inode, offset, length, status)
+		}
+	}()
+}
+
+// invalidateLocalWrite is called after this mount changes the file content.
+func (wfs *WFS) invalidateLocalWrite(inode uint64, offset, length int64) {
+	if wfs.option.MmapCoherence != MmapCoherenceLocal && wfs.option.MmapCoherence != MmapCoherenceDistributed {
+		return
+	}
+	wfs.invalidatePageCache(inode, offset, length)
+}
+
+// invalidateRemoteChange is called for the files changed or deleted by other clients.
+// An open file without local changes is switched to the new chunks, so the page faults read the new content.
+func (wfs *WFS) invalidateRemoteChange(filePath util.FullPath, entry *filer_pb.Entry) {
+	if wfs.option.MmapCoherence != MmapCoherenceDistributed || entry == nil || entry.IsDirectory {
+		return
+	}
+	inode := wfs.inodeToPath.GetInode(filePath)
+	if inode == 0 {
+		return
+	}
+	if fh, found := wfs.fhmap.FindFileHandle(inode); found {
+		if newEntry, err := wfs.metaCache.FindEntry(context.Background(), filePath); err == nil {
+			fh.Lock()
+			if !fh.dirtyMetadata {
+				fh.SetEntry(newEntry.ToProtoEntry())
+			}
+			fh.Unlock()
+		}
+	}
+	wfs.invalidatePageCache(inode, 0, -1)
+}
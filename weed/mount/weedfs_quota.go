@@ -2,12 +2,24 @@ package mount
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
-	"time"
 )
 
+// createEntryStatus returns ENOSPC if the filer rejected the entry for the inode quota of a parent directory
+func createEntryStatus(err error) fuse.Status {
+	if errors.Is(err, filer_pb.ErrInodeQuotaExceeded) {
+		return fuse.Status(syscall.ENOSPC)
+	}
+	return fuse.EIO
+}
+
 func (wfs *WFS) loopCheckQuota() {
 
 	for {
@@ -9,9 +9,12 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"io"
 	"strings"
 	"syscall"
+	"time"
 )
 
 /** Rename a file
@@ -131,6 +134,7 @@ const (
 )
 
 func (wfs *WFS) Rename(cancel <-chan struct{}, in *fuse.RenameIn, oldName string, newName string) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Rename", time.Now())
 	if wfs.IsOverQuota {
 		return fuse.Status(syscall.ENOSPC)
 	}
@@ -162,8 +166,13 @@ func (wfs *WFS) Rename(cancel <-chan struct{}, in *fuse.RenameIn, oldName string
 
 	glog.V(4).Infof("dir Rename %s => %s", oldPath, newPath)
 
+	// the namespaces are on different filers
+	if !wfs.sameNamespace(oldPath, newPath) {
+		return fuse.Status(syscall.EXDEV)
+	}
+
 	// update remote filer
-	err := wfs.WithFilerClient(true, func(client filer_pb.SeaweedFilerClient) error {
+	err := wfs.filerClientFor(oldPath).WithFilerClient(true, func(client filer_pb.SeaweedFilerClient) error {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
@@ -187,7 +196,9 @@ func (wfs *WFS) Rename(cancel <-chan struct{}, in *fuse.RenameIn, oldName string
 				if recvErr == io.EOF {
 					break
 				} else {
-					if strings.Contains(recvErr.Error(), "not empty") {
+					if status.Code(recvErr) == codes.ResourceExhausted {
+						code = fuse.Status(syscall.ENOSPC)
+					} else if strings.Contains(recvErr.Error(), "not empty") {
 						code = fuse.Status(syscall.ENOTEMPTY)
 					} else if strings.Contains(recvErr.Error(), "not directory") {
 						code = fuse.ENOTDIR
@@ -234,6 +245,8 @@ func (wfs *WFS) handleRenameResponse(ctx context.Context, resp *filer_pb.StreamR
 		newPath := newParent.Child(newName)
 
 		sourceInode, targetInode := wfs.inodeToPath.MovePath(oldPath, newPath)
+		wfs.symlinkCache.delete(sourceInode)
+		wfs.symlinkCache.delete(targetInode)
 		if sourceInode != 0 {
 			fh, foundFh := wfs.fhmap.FindFileHandle(sourceInode)
 			if foundFh {
@@ -18,6 +18,7 @@ type statsCache struct {
 }
 
 func (wfs *WFS) StatFs(cancel <-chan struct{}, in *fuse.InHeader, out *fuse.StatfsOut) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("StatFs", time.Now())
 
 	// glog.V(4).Infof("reading fs stats")
 
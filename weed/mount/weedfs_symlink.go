@@ -16,6 +16,7 @@ import (
 
 /** Create a symbolic link */
 func (wfs *WFS) Symlink(cancel <-chan struct{}, header *fuse.InHeader, target string, name string, out *fuse.EntryOut) (code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Symlink", time.Now())
 
 	if wfs.IsOverQuota {
 		return fuse.Status(syscall.ENOSPC)
@@ -48,7 +49,7 @@ func (wfs *WFS) Symlink(cancel <-chan struct{}, header *fuse.InHeader, target st
 		SkipCheckParentDirectory: true,
 	}
 
-	err := wfs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+	err := wfs.filerClientFor(entryFullPath).WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
 
 		wfs.mapPbIdFromLocalToFiler(request.Entry)
 		defer wfs.mapPbIdFromFilerToLocal(request.Entry)
@@ -67,6 +68,8 @@ func (wfs *WFS) Symlink(cancel <-chan struct{}, header *fuse.InHeader, target st
 	}
 
 	inode := wfs.inodeToPath.Lookup(entryFullPath, request.Entry.Attributes.Crtime, false, false, 0, true)
+	// the inode can be reused for the same path
+	wfs.symlinkCache.delete(inode)
 
 	wfs.outputPbEntry(out, inode, request.Entry)
 
@@ -74,6 +77,10 @@ func (wfs *WFS) Symlink(cancel <-chan struct{}, header *fuse.InHeader, target st
 }
 
 func (wfs *WFS) Readlink(cancel <-chan struct{}, header *fuse.InHeader) (out []byte, code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("Readlink", time.Now())
+	if target, found := wfs.symlinkCache.get(header.NodeId); found {
+		return []byte(target), fuse.OK
+	}
 	entryFullPath, code := wfs.inodeToPath.GetPath(header.NodeId)
 	if code != fuse.OK {
 		return
@@ -87,5 +94,7 @@ func (wfs *WFS) Readlink(cancel <-chan struct{}, header *fuse.InHeader) (out []b
 		return nil, fuse.EINVAL
 	}
 
+	wfs.symlinkCache.set(header.NodeId, entry.Attributes.SymlinkTarget)
+
 	return []byte(entry.Attributes.SymlinkTarget), fuse.OK
 }
@@ -0,0 +1,159 @@
+package mount
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/karlseguin/ccache/v2"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// DownloadRateXAttr and UploadRateXAttr on a directory limit the read and write speed of the files under it,
+// in bytes per second, overriding the mount options. 0 means unlimited. Linux tools need the "user." prefix.
+const (
+	DownloadRateXAttr = "seaweedfs.download-rate"
+	UploadRateXAttr   = "seaweedfs.upload-rate"
+)
+
+const (
+	rateLimiterCacheSize = 4 * 1024
+	// the rate xattr changes of the directories take effect within the ttl
+	rateLimiterCacheTtl = 10 * time.Second
+)
+
+// rateLimiters are the limiter of the mount, and the limiters of the directories with the rate xattr.
+// The resolved limiter of the files is cached by their parent directory.
+type rateLimiters struct {
+	xattrName     string
+	root          util.FullPath
+	directoryRate func(dir util.FullPath, xattrName string) (rate int64, found bool)
+	mount         *tokenBucket
+	directories   sync.Map
+	resolved      *ccache.Cache
+}
+
+func newRateLimiters(xattrName string, bytesPerSecond int64, root util.FullPath, directoryRate func(dir util.FullPath, xattrName string) (int64, bool)) *rateLimiters {
+	limiters := &rateLimiters{
+		xattrName:     xattrName,
+		root:          root,
+		directoryRate: directoryRate,
+		resolved:      ccache.New(ccache.Configure().MaxSize(rateLimiterCacheSize).ItemsToPrune(rateLimiterCacheSize >> 3)),
+	}
+	if bytesPerSecond > 0 {
+		limiters.mount = newTokenBucket(bytesPerSecond)
+	}
+	return limiters
+}
+
+// tokenBucket refills at the rate of bytes per second, holding up to one second of tokens
+type tokenBucket struct {
+	sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(bytesPerSecond int64) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(bytesPerSecond),
+		tokens: float64(bytesPerSecond),
+		last:   time.Now(),
+	}
+}
+
+// take drains n tokens, and waits for the refill if the bucket runs short
+func (b *tokenBucket) take(n int) {
+	b.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// throttleDownload waits until the file handle may return n more bytes
+func (wfs *WFS) throttleDownload(fh *FileHandle, n int) {
+	fh.downloadLimiterOnce.Do(func() {
+		fh.downloadLimiter = wfs.downloadLimiters.limiterFor(fh.FullPath())
+	})
+	if fh.downloadLimiter != nil && n > 0 {
+		fh.downloadLimiter.take(n)
+	}
+}
+
+// throttleUpload waits until the chunk of the file may be uploaded
+func (wfs *WFS) throttleUpload(fullPath util.FullPath, n int) {
+	if limiter := wfs.uploadLimiters.limiterFor(fullPath); limiter != nil && n > 0 {
+		limiter.take(n)
+	}
+}
+
+// limiterFor returns the limiter of the nearest directory with the rate xattr,
+// or the mount limiter. The files under the same directory share its limiter.
+func (limiters *rateLimiters) limiterFor(fullPath util.FullPath) *tokenBucket {
+	parent, _ := fullPath.DirAndName()
+	if item := limiters.resolved.Get(parent); item != nil && !item.Expired() {
+		return item.Value().(*tokenBucket)
+	}
+	limiter := limiters.resolve(fullPath)
+	limiters.resolved.Set(parent, limiter, rateLimiterCacheTtl)
+	return limiter
+}
+
+func (limiters *rateLimiters) resolve(fullPath util.FullPath) *tokenBucket {
+	for p := fullPath; p != limiters.root && p != "/"; {
+		dir, _ := p.DirAndName()
+		p = util.FullPath(dir)
+		rate, found := limiters.directoryRate(p, limiters.xattrName)
+		if !found {
+			continue
+		}
+		if rate <= 0 {
+			return nil
+		}
+		limiter, _ := limiters.directories.LoadOrStore(string(p), newTokenBucket(rate))
+		if bucket := limiter.(*tokenBucket); int64(bucket.rate) == rate {
+			return bucket
+		}
+		// the rate of the directory is changed
+		bucket := newTokenBucket(rate)
+		limiters.directories.Store(string(p), bucket)
+		return bucket
+	}
+	return limiters.mount
+}
+
+func (wfs *WFS) directoryRate(dir util.FullPath, xattrName string) (rate int64, found bool) {
+	entry, err := wfs.metaCache.FindEntry(context.Background(), dir)
+	if err != nil || entry == nil || entry.Extended == nil {
+		return 0, false
+	}
+	for _, name := range []string{xattrName, "user." + xattrName} {
+		value, ok := entry.Extended[XATTR_PREFIX+name]
+		if !ok {
+			continue
+		}
+		rate, err = strconv.ParseInt(strings.TrimSpace(string(value)), 10, 64)
+		if err != nil {
+			glog.Warningf("invalid %s %q on %s: %v", xattrName, value, dir, err)
+			return 0, false
+		}
+		return rate, true
+	}
+	return 0, false
+}
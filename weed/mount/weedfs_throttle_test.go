@@ -0,0 +1,127 @@
+package mount
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(1000)
+
+	// the first second of tokens is available at once
+	start := time.Now()
+	b.take(1000)
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("burst took %v", elapsed)
+	}
+
+	// then the bucket refills at the rate
+	start = time.Now()
+	b.take(200)
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("200 bytes at 1000 bytes/s took %v", elapsed)
+	}
+}
+
+func TestUploadRateLimiters(t *testing.T) {
+	mb := 1024 * 1024
+
+	// 12MB at 10MB/s, with the first 10MB of burst, takes at least 200ms
+	limiters := newRateLimiters(UploadRateXAttr, int64(10*mb), "/", noDirectoryRate)
+	start := time.Now()
+	for i := 0; i < 12; i++ {
+		limiters.mount.take(mb)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("12MB at 10MB/s took %v", elapsed)
+	}
+
+	if limiters = newRateLimiters(UploadRateXAttr, 0, "/", noDirectoryRate); limiters.mount != nil {
+		t.Errorf("expected no mount limiter without the upload limit")
+	}
+}
+
+func TestDownloadRateEnforced(t *testing.T) {
+	// 10000 bytes/s under /limited, unlimited elsewhere
+	wfs := &WFS{inodeToPath: NewInodeToPath("/")}
+	wfs.downloadLimiters = newRateLimiters(DownloadRateXAttr, 0, "/", func(dir util.FullPath, xattrName string) (int64, bool) {
+		if dir == "/limited" && xattrName == DownloadRateXAttr {
+			return 10000, true
+		}
+		return 0, false
+	})
+	openFile := func(path util.FullPath) *FileHandle {
+		return &FileHandle{wfs: wfs, inode: wfs.inodeToPath.Lookup(path, time.Now().Unix(), false, false, 0, true)}
+	}
+	a, b, other := openFile("/limited/a"), openFile("/limited/b"), openFile("/other/c")
+
+	// the burst of one second is read at once
+	start := time.Now()
+	wfs.throttleDownload(a, 10000)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("burst took %v", elapsed)
+	}
+
+	// the files of the directory share its limit, 2000 more bytes wait at least 200ms
+	start = time.Now()
+	wfs.throttleDownload(b, 2000)
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("2000 bytes at 10000 bytes/s after the burst took %v", elapsed)
+	}
+
+	// the reads are not faster than the rate
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		wfs.throttleDownload(a, 1000)
+	}
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond {
+		t.Errorf("4000 bytes at 10000 bytes/s took %v", elapsed)
+	}
+
+	// the other files are not limited
+	start = time.Now()
+	wfs.throttleDownload(other, 1<<30)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("unlimited read took %v", elapsed)
+	}
+}
+
+func TestRateLimiterCache(t *testing.T) {
+	lookups := make(map[util.FullPath]int)
+	limiters := newRateLimiters(UploadRateXAttr, 0, "/", func(dir util.FullPath, xattrName string) (int64, bool) {
+		lookups[dir]++
+		if dir == "/a" {
+			return 1000, true
+		}
+		return 0, false
+	})
+
+	// the files of the same directory resolve the ancestors once
+	first := limiters.limiterFor("/a/b/1")
+	if second := limiters.limiterFor("/a/b/2"); first == nil || first != second {
+		t.Fatalf("files under /a/b: %p and %p", first, second)
+	}
+	if lookups["/a/b"] != 1 || lookups["/a"] != 1 {
+		t.Errorf("lookups %v", lookups)
+	}
+
+	// another directory under /a resolves again, and shares the limiter of /a
+	if third := limiters.limiterFor("/a/c/3"); third != first {
+		t.Errorf("file under /a/c: %p, expected %p", third, first)
+	}
+	if lookups["/a/c"] != 1 || lookups["/a"] != 2 {
+		t.Errorf("lookups %v", lookups)
+	}
+
+	// the unlimited directories are cached too
+	limiters.limiterFor("/d/4")
+	if limiter := limiters.limiterFor("/d/5"); limiter != nil || lookups["/d"] != 1 {
+		t.Errorf("file under /d: %p, lookups %v", limiter, lookups)
+	}
+}
+
+func noDirectoryRate(dir util.FullPath, xattrName string) (int64, bool) {
+	return 0, false
+}
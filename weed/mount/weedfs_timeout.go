@@ -0,0 +1,97 @@
+package mount
+
+import (
+	"context"
+	"syscall"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// contextualFilerClient is implemented by the WFS and the namespace filer clients
+type contextualFilerClient interface {
+	filer_pb.FilerClient
+	withFilerClientContext(ctx context.Context, streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error
+}
+
+// contextFilerClient runs all the filer rpcs of one fuse operation under the context of the operation
+type contextFilerClient struct {
+	contextualFilerClient
+	ctx context.Context
+}
+
+func (c *contextFilerClient) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return c.withFilerClientContext(c.ctx, streamingMode, fn)
+}
+
+// timeoutFilerClient bounds each filer rpc by the timeout, for the long-lived users, e.g., the chunk lookups
+type timeoutFilerClient struct {
+	contextualFilerClient
+	timeout time.Duration
+}
+
+func (c *timeoutFilerClient) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	ctx, cancel := newTimeoutContext(c.timeout)
+	defer cancel()
+	return c.withFilerClientContext(ctx, streamingMode, fn)
+}
+
+// filerClientWithContext returns the filer client of the path, whose rpcs fail once ctx is done
+func (wfs *WFS) filerClientWithContext(ctx context.Context, path util.FullPath) filer_pb.FilerClient {
+	return &contextFilerClient{contextualFilerClient: wfs.filerClientFor(path), ctx: ctx}
+}
+
+// newTimeoutContext returns the context of one fuse operation, without a deadline if the timeout is 0
+func newTimeoutContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// timeoutStatus returns ETIMEDOUT if the operation failed because its context expired
+func timeoutStatus(ctx context.Context, status fuse.Status) fuse.Status {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fuse.Status(syscall.ETIMEDOUT)
+	}
+	return status
+}
+
+func withContextDeadline(ctx context.Context, conn *grpc.ClientConn) grpc.ClientConnInterface {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return conn
+	}
+	return &deadlineConn{ClientConnInterface: conn, deadline: deadline}
+}
+
+// deadlineConn applies the deadline of the fuse operation to every rpc on the connection
+type deadlineConn struct {
+	grpc.ClientConnInterface
+	deadline time.Time
+}
+
+func (c *deadlineConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	ctx, cancel := context.WithDeadline(ctx, c.deadline)
+	defer cancel()
+	return c.ClientConnInterface.Invoke(ctx, method, args, reply, opts...)
+}
+
+func (c *deadlineConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx, cancel := context.WithDeadline(ctx, c.deadline)
+	stream, err := c.ClientConnInterface.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		// the stream context is done once the stream is finished
+		<-stream.Context().Done()
+		cancel()
+	}()
+	return stream, nil
+}
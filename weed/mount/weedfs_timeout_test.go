@@ -0,0 +1,42 @@
+package mount
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fuse"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+func TestFilerClientTimeout(t *testing.T) {
+	wfs := &WFS{option: &Option{FilerAddresses: []pb.ServerAddress{"localhost:1"}}}
+
+	ctx, cancel := newTimeoutContext(time.Millisecond)
+	defer cancel()
+	time.Sleep(2 * time.Millisecond)
+
+	called := false
+	err := wfs.filerClientWithContext(ctx, "/dir").WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		called = true
+		return nil
+	})
+	if err != context.DeadlineExceeded || called {
+		t.Fatalf("expected the expired operation to fail without calling the filer, got %v", err)
+	}
+	if status := timeoutStatus(ctx, fuse.EIO); status != fuse.Status(syscall.ETIMEDOUT) {
+		t.Errorf("expected ETIMEDOUT, got %v", status)
+	}
+
+	ctx, cancel = newTimeoutContext(0)
+	defer cancel()
+	if _, hasDeadline := ctx.Deadline(); hasDeadline {
+		t.Errorf("expected no deadline for the 0 timeout")
+	}
+	if status := timeoutStatus(ctx, fuse.EIO); status != fuse.EIO {
+		t.Errorf("expected EIO, got %v", status)
+	}
+}
@@ -1,6 +1,8 @@
 package mount
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 
@@ -9,14 +11,41 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func (wfs *WFS) saveDataAsChunk(fullPath util.FullPath) filer.SaveDataAsChunkFunctionType {
+	return wfs.uploadDataAsChunk(fullPath, wfs.isDedupPath(fullPath))
+}
+
+// uploadDataAsChunk only hashes the data and asks the filer for the same content if dedup is set
+func (wfs *WFS) uploadDataAsChunk(fullPath util.FullPath, dedup bool) filer.SaveDataAsChunkFunctionType {
 
 	return func(reader io.Reader, filename string, offset int64, tsNs int64) (chunk *filer_pb.FileChunk, err error) {
 
-		fileId, uploadResult, err, data := operation.UploadWithRetry(
-			wfs,
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("read data: %v", err)
+		}
+		var hash [sha256.Size]byte
+		if dedup {
+			hash = sha256.Sum256(data)
+			var existingChunk *filer_pb.FileChunk
+			existingChunk, dedup = wfs.lookupChunkByHash(fullPath, hash[:])
+			if existingChunk != nil {
+				existingChunk.Offset, existingChunk.ModifiedTsNs = offset, tsNs
+				if offset == 0 {
+					wfs.chunkCache.SetChunk(existingChunk.GetFileIdString(), data)
+				}
+				return existingChunk, nil
+			}
+		}
+
+		wfs.throttleUpload(fullPath, len(data))
+
+		fileId, uploadResult, err, _ := operation.UploadWithRetry(
+			&timeoutFilerClient{contextualFilerClient: wfs.filerClientFor(fullPath), timeout: wfs.option.WriteTimeout},
 			&filer_pb.AssignVolumeRequest{
 				Count:       1,
 				Replication: wfs.option.Replication,
@@ -36,11 +65,11 @@ func (wfs *WFS) saveDataAsChunk(fullPath util.FullPath) filer.SaveDataAsChunkFun
 			func(host, fileId string) string {
 				fileUrl := fmt.Sprintf("http://%s/%s", host, fileId)
 				if wfs.option.VolumeServerAccess == "filerProxy" {
-					fileUrl = fmt.Sprintf("http://%s/?proxyChunkId=%s", wfs.getCurrentFiler(), fileId)
+					fileUrl = fmt.Sprintf("http://%s/?proxyChunkId=%s", wfs.currentFilerFor(fullPath), fileId)
 				}
 				return fileUrl
 			},
-			reader,
+			util.NewBytesReader(data),
 		)
 
 		if err != nil {
@@ -57,6 +86,58 @@ func (wfs *WFS) saveDataAsChunk(fullPath util.FullPath) filer.SaveDataAsChunkFun
 		}
 
 		chunk = uploadResult.ToPbFileChunk(fileId, offset, tsNs)
+		if dedup {
+			// the filer checks the references of the flagged chunks on deletion, as other files may share the chunk from now on
+			chunk.IsDeduplicated = wfs.saveChunkHash(fullPath, hash[:], chunk)
+		}
 		return chunk, nil
 	}
 }
+
+// lookupChunkByHash asks the filer for a chunk with the same content. dedup is false if the
+// collection is not deduplicated, or the filer does not support it.
+func (wfs *WFS) lookupChunkByHash(fullPath util.FullPath, hash []byte) (chunk *filer_pb.FileChunk, dedup bool) {
+	if wfs.dedupUnsupported.Load() {
+		return nil, false
+	}
+	err := wfs.filerClientFor(fullPath).WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.LookupChunkByHash(context.Background(), &filer_pb.LookupChunkByHashRequest{
+			Path:       string(fullPath),
+			Collection: wfs.option.Collection,
+			TtlSec:     wfs.option.TtlSec,
+			Sha256:     hash,
+		})
+		if err != nil {
+			return err
+		}
+		chunk, dedup = resp.Chunk, resp.Dedup
+		return nil
+	})
+	if status.Code(err) == codes.Unimplemented {
+		wfs.dedupUnsupported.Store(true)
+	}
+	if err != nil {
+		glog.V(1).Infof("lookup chunk by hash %s: %v", fullPath, err)
+		return nil, false
+	}
+	return
+}
+
+// saveChunkHash asks the filer to index the uploaded chunk by its content, which the filer reads back and hashes itself
+func (wfs *WFS) saveChunkHash(fullPath util.FullPath, hash []byte, chunk *filer_pb.FileChunk) bool {
+	err := wfs.filerClientFor(fullPath).WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		_, err := client.SaveChunkHash(context.Background(), &filer_pb.SaveChunkHashRequest{
+			Path:       string(fullPath),
+			Collection: wfs.option.Collection,
+			TtlSec:     wfs.option.TtlSec,
+			Sha256:     hash,
+			Chunk:      chunk,
+		})
+		return err
+	})
+	if err != nil {
+		glog.V(0).Infof("save chunk hash %s: %v", fullPath, err)
+		return false
+	}
+	return true
+}
@@ -2,10 +2,12 @@ package mount
 
 import (
 	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	sys "golang.org/x/sys/unix"
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 )
 
 const (
@@ -19,6 +21,7 @@ const (
 // number of bytes. If the buffer is too small, return ERANGE,
 // with the required buffer size.
 func (wfs *WFS) GetXAttr(cancel <-chan struct{}, header *fuse.InHeader, attr string, dest []byte) (size uint32, code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("GetXAttr", time.Now())
 
 	if wfs.option.DisableXAttr {
 		return 0, fuse.Status(syscall.ENOTSUP)
@@ -74,6 +77,7 @@ func (wfs *WFS) GetXAttr(cancel <-chan struct{}, header *fuse.InHeader, attr str
 //	       Perform a pure replace operation, which fails if the named
 //	       attribute does not already exist.
 func (wfs *WFS) SetXAttr(cancel <-chan struct{}, input *fuse.SetXAttrIn, attr string, data []byte) fuse.Status {
+	defer wfs.metrics.ObserveFuseOp("SetXAttr", time.Now())
 
 	if wfs.option.DisableXAttr {
 		return fuse.Status(syscall.ENOTSUP)
@@ -139,6 +143,7 @@ func (wfs *WFS) SetXAttr(cancel <-chan struct{}, input *fuse.SetXAttrIn, attr st
 // slice, and return the number of bytes. If the buffer is too
 // small, return ERANGE, with the required buffer size.
 func (wfs *WFS) ListXAttr(cancel <-chan struct{}, header *fuse.InHeader, dest []byte) (n uint32, code fuse.Status) {
+	defer wfs.metrics.ObserveFuseOp("ListXAttr", time.Now())
 
 	if wfs.option.DisableXAttr {
 		return 0, fuse.Status(syscall.ENOTSUP)
@@ -157,7 +162,7 @@ func (wfs *WFS) ListXAttr(cancel <-chan struct{}, header *fuse.InHeader, dest []
 
 	var data []byte
 	for k := range entry.Extended {
-		if strings.HasPrefix(k, XATTR_PREFIX) {
+		if strings.HasPrefix(k, XATTR_PREFIX) && !filer.IsDirectoryUsageKey(k) {
 			data = append(data, k[len(XATTR_PREFIX):]...)
 			data = append(data, 0)
 		}
@@ -173,6 +178,7 @@ func (wfs *WFS) ListXAttr(cancel <-chan struct{}, header *fuse.InHeader, dest []
 
 // RemoveXAttr removes an extended attribute.
 func (wfs *WFS) RemoveXAttr(cancel <-chan struct{}, header *fuse.InHeader, attr string) fuse.Status {
+	defer wfs.metrics.ObserveFuseOp("RemoveXAttr", time.Now())
 
 	if wfs.option.DisableXAttr {
 		return fuse.Status(syscall.ENOTSUP)
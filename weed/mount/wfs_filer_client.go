@@ -1,7 +1,9 @@
 package mount
 
 import (
+	"context"
 	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc"
 
@@ -14,18 +16,33 @@ import (
 var _ = filer_pb.FilerClient(&WFS{})
 
 func (wfs *WFS) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) (err error) {
+	return wfs.withFilerClientContext(context.Background(), streamingMode, fn)
+}
+
+// withFilerClientContext stops retrying after ctx is done, and bounds the rpcs by the deadline of ctx
+func (wfs *WFS) withFilerClientContext(ctx context.Context, streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) (err error) {
+	return wfs.withFilerCircuitBreaker(streamingMode, func() error {
+		return wfs.withFilerClientRetry(ctx, streamingMode, fn)
+	})
+}
+
+func (wfs *WFS) withFilerClientRetry(ctx context.Context, streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) (err error) {
 
 	return util.Retry("filer grpc", func() error {
 
 		i := atomic.LoadInt32(&wfs.option.filerIndex)
 		n := len(wfs.option.FilerAddresses)
 		for x := 0; x < n; x++ {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
 
 			filerGrpcAddress := wfs.option.FilerAddresses[i].ToGrpcAddress()
 			err = pb.WithGrpcClient(streamingMode, wfs.signature, func(grpcConnection *grpc.ClientConn) error {
-				client := filer_pb.NewSeaweedFilerClient(grpcConnection)
+				client := filer_pb.NewSeaweedFilerClient(withContextDeadline(ctx, grpcConnection))
+				defer wfs.metrics.ObserveFilerRpc(time.Now())
 				return fn(client)
-			}, filerGrpcAddress, false, wfs.option.GrpcDialOption)
+			}, filerGrpcAddress, false, wfs.option.filerGrpcDialOption())
 
 			if err != nil {
 				glog.V(0).Infof("WithFilerClient %d %v: %v", x, filerGrpcAddress, err)
@@ -45,6 +62,13 @@ func (wfs *WFS) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFile
 
 }
 
+func (option *Option) filerGrpcDialOption() grpc.DialOption {
+	if option.FilerCredentials != nil {
+		return grpc.WithCredentialsBundle(option.FilerCredentials)
+	}
+	return option.GrpcDialOption
+}
+
 func (wfs *WFS) AdjustedUrl(location *filer_pb.Location) string {
 	if wfs.option.VolumeServerAccess == "publicUrl" {
 		return location.PublicUrl
@@ -15,7 +15,7 @@ func (wfs *WFS) saveEntry(path util.FullPath, entry *filer_pb.Entry) (code fuse.
 
 	parentDir, _ := path.DirAndName()
 
-	err := wfs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+	err := wfs.filerClientFor(path).WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
 
 		wfs.mapPbIdFromLocalToFiler(entry)
 		defer wfs.mapPbIdFromFilerToLocal(entry)
@@ -0,0 +1,237 @@
+package nfs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/go-git/go-billy/v5"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// writeBufferLimit is the most data buffered by a file before it is uploaded as a chunk
+const writeBufferLimit = 4 * 1024 * 1024
+
+// FilerFile is an opened file. Contiguous writes are buffered and uploaded as one chunk,
+// and the entry is saved to the filer on Close.
+type FilerFile struct {
+	fs       *FilerFileSystem
+	name     string
+	fullPath util.FullPath
+	entry    *filer_pb.Entry
+	off      int64
+	reader   io.ReaderAt
+	dirty    bool
+
+	pending       bytes.Buffer
+	pendingOffset int64
+}
+
+var _ = billy.File(&FilerFile{})
+
+func newFilerFile(fs *FilerFileSystem, name string, fullPath util.FullPath, entry *filer_pb.Entry) *FilerFile {
+	return &FilerFile{
+		fs:       fs,
+		name:     name,
+		fullPath: fullPath,
+		entry:    entry,
+	}
+}
+
+func (f *FilerFile) Name() string {
+	return f.name
+}
+
+func (f *FilerFile) size() int64 {
+	return int64(filer.FileSize(f.entry))
+}
+
+func (f *FilerFile) Read(p []byte) (n int, err error) {
+	n, err = f.ReadAt(p, f.off)
+	f.off += int64(n)
+	return
+}
+
+func (f *FilerFile) ReadAt(p []byte, off int64) (n int, err error) {
+	if err = f.flush(); err != nil {
+		return 0, err
+	}
+	fileSize := f.size()
+	if off >= fileSize {
+		return 0, io.EOF
+	}
+	if len(f.entry.Content) > 0 {
+		n = copy(p, f.entry.Content[off:])
+	} else {
+		if f.reader == nil {
+			chunkViews := filer.ViewFromChunks(filer.LookupFn(f.fs), f.entry.GetChunks(), 0, fileSize)
+			f.reader = filer.NewChunkReaderAtFromClient(f.fs.readerCache, chunkViews, fileSize)
+		}
+		n, err = f.reader.ReadAt(p, off)
+	}
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	if err != nil && err != io.EOF {
+		glog.Errorf("nfs read %s: %v", f.fullPath, err)
+	}
+	return
+}
+
+func (f *FilerFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += f.size()
+	default:
+		return f.off, os.ErrInvalid
+	}
+	if offset < 0 {
+		return f.off, os.ErrInvalid
+	}
+	f.off = offset
+	return f.off, nil
+}
+
+func (f *FilerFile) Write(p []byte) (n int, err error) {
+	if f.pending.Len() > 0 && f.pendingOffset+int64(f.pending.Len()) != f.off {
+		if err = f.flush(); err != nil {
+			return 0, err
+		}
+	}
+	if f.pending.Len() == 0 {
+		f.pendingOffset = f.off
+	}
+	n, _ = f.pending.Write(p)
+	f.off += int64(n)
+	if f.off > int64(f.entry.Attributes.FileSize) {
+		f.entry.Attributes.FileSize = uint64(f.off)
+	}
+	f.dirty = true
+	if f.pending.Len() >= writeBufferLimit {
+		err = f.flush()
+	}
+	return
+}
+
+// flush uploads the buffered writes as a new chunk.
+func (f *FilerFile) flush() error {
+	if f.pending.Len() == 0 {
+		return nil
+	}
+	if len(f.entry.Content) > 0 {
+		// move the inline content to a chunk, so that it can be overwritten by the newer chunks
+		chunk, err := f.saveDataAsChunk(bytes.NewReader(f.entry.Content), 0, f.entry.Attributes.Mtime*1e9)
+		if err != nil {
+			return err
+		}
+		f.entry.Content = nil
+		f.entry.Chunks = append(f.entry.GetChunks(), chunk)
+	}
+	chunk, err := f.saveDataAsChunk(bytes.NewReader(f.pending.Bytes()), f.pendingOffset, time.Now().UnixNano())
+	if err != nil {
+		return err
+	}
+	f.entry.Chunks = append(f.entry.GetChunks(), chunk)
+	f.pending.Reset()
+	f.reader = nil
+	return nil
+}
+
+func (f *FilerFile) saveDataAsChunk(reader io.Reader, offset int64, tsNs int64) (chunk *filer_pb.FileChunk, err error) {
+	option := f.fs.option
+	fileId, uploadResult, uploadErr, _ := operation.UploadWithRetry(
+		f.fs,
+		&filer_pb.AssignVolumeRequest{
+			Count:       1,
+			Replication: option.Replication,
+			Collection:  option.Collection,
+			DiskType:    option.DiskType,
+			Path:        string(f.fullPath),
+		},
+		&operation.UploadOption{
+			Filename: f.entry.Name,
+			Cipher:   option.Cipher,
+		},
+		func(host, fileId string) string {
+			return fmt.Sprintf("http://%s/%s", host, fileId)
+		},
+		reader,
+	)
+	if uploadErr != nil {
+		glog.V(0).Infof("upload data %v: %v", f.fullPath, uploadErr)
+		return nil, fmt.Errorf("upload data: %v", uploadErr)
+	}
+	if uploadResult.Error != "" {
+		glog.V(0).Infof("upload failure %v: %v", f.fullPath, uploadResult.Error)
+		return nil, fmt.Errorf("upload result: %v", uploadResult.Error)
+	}
+	return uploadResult.ToPbFileChunk(fileId, offset, tsNs), nil
+}
+
+func (f *FilerFile) Truncate(size int64) error {
+	if err := f.flush(); err != nil {
+		return err
+	}
+	if size >= f.size() {
+		f.entry.Attributes.FileSize = uint64(size)
+		f.dirty = true
+		return nil
+	}
+	if int64(len(f.entry.Content)) > size {
+		f.entry.Content = f.entry.Content[:size]
+	}
+	var chunks []*filer_pb.FileChunk
+	for _, chunk := range f.entry.GetChunks() {
+		if chunk.Offset >= size {
+			continue
+		}
+		if chunk.Offset+int64(chunk.Size) > size {
+			chunk.Size = uint64(size - chunk.Offset)
+		}
+		chunks = append(chunks, chunk)
+	}
+	f.entry.Chunks = chunks
+	f.entry.Attributes.FileSize = uint64(size)
+	f.reader = nil
+	f.dirty = true
+	return nil
+}
+
+func (f *FilerFile) Close() error {
+	if err := f.flush(); err != nil {
+		return err
+	}
+	if !f.dirty {
+		return nil
+	}
+	manifestedChunks, manifestErr := filer.MaybeManifestize(func(reader io.Reader, name string, offset int64, tsNs int64) (*filer_pb.FileChunk, error) {
+		return f.saveDataAsChunk(reader, offset, tsNs)
+	}, f.entry.GetChunks())
+	if manifestErr != nil {
+		// not good, but should be ok
+		glog.V(0).Infof("file %s close MaybeManifestize: %v", f.fullPath, manifestErr)
+	} else {
+		f.entry.Chunks = manifestedChunks
+	}
+	f.entry.Attributes.Mtime = time.Now().Unix()
+	f.dirty = false
+	return f.fs.updateEntry(f.fullPath, f.entry)
+}
+
+func (f *FilerFile) Lock() error {
+	return nil
+}
+
+func (f *FilerFile) Unlock() error {
+	return nil
+}
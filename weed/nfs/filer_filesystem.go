@@ -0,0 +1,404 @@
+package nfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/willscott/go-nfs/file"
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
+	"github.com/seaweedfs/seaweedfs/weed/wdclient"
+)
+
+// FilerFileSystem is a billy.Filesystem backed by the filer gRPC api.
+type FilerFileSystem struct {
+	option      *NfsServerOption
+	root        string
+	readerCache *filer.ReaderCache
+	signature   int32
+	// onRename is called after an entry is renamed, with the old and new full paths
+	onRename func(oldPath, newPath util.FullPath)
+}
+
+var (
+	_ = billy.Filesystem(&FilerFileSystem{})
+	_ = billy.Change(&FilerFileSystem{})
+	_ = filer_pb.FilerClient(&FilerFileSystem{})
+)
+
+func newFilerFileSystem(option *NfsServerOption) *FilerFileSystem {
+	fs := &FilerFileSystem{
+		option:    option,
+		root:      path.Clean("/" + option.FilerRootPath),
+		signature: util.RandomInt32(),
+	}
+	// no chunk cache, the same as a mount without a cache dir
+	var chunkCache *chunk_cache.TieredChunkCache
+	fs.readerCache = filer.NewReaderCache(32, chunkCache, filer.LookupFn(fs))
+	return fs
+}
+
+func (fs *FilerFileSystem) WithFilerClient(streamingMode bool, fn func(filer_pb.SeaweedFilerClient) error) error {
+	return pb.WithGrpcClient(streamingMode, fs.signature, func(grpcConnection *grpc.ClientConn) error {
+		client := filer_pb.NewSeaweedFilerClient(grpcConnection)
+		return fn(client)
+	}, fs.option.Filer.ToGrpcAddress(), false, fs.option.GrpcDialOption)
+}
+
+func (fs *FilerFileSystem) AdjustedUrl(location *filer_pb.Location) string {
+	return location.Url
+}
+
+func (fs *FilerFileSystem) GetDataCenter() string {
+	return ""
+}
+
+func (fs *FilerFileSystem) GetLookupFileIdFunction() wdclient.LookupFileIdFunctionType {
+	return filer.LookupFn(fs)
+}
+
+func (fs *FilerFileSystem) fullPath(name string) util.FullPath {
+	return util.FullPath(path.Join(fs.root, path.Clean("/"+name)))
+}
+
+// relativePath splits the full path into the names under the root
+func (fs *FilerFileSystem) relativePath(fullPath util.FullPath) []string {
+	if fs.root == "/" {
+		return fullPath.Split()
+	}
+	return util.FullPath(strings.TrimPrefix(string(fullPath), fs.root)).Split()
+}
+
+func (fs *FilerFileSystem) lookup(name string) (util.FullPath, *filer_pb.Entry, error) {
+	fullPath := fs.fullPath(name)
+	if string(fullPath) == "/" {
+		return fullPath, &filer_pb.Entry{
+			Name:        "/",
+			IsDirectory: true,
+			Attributes: &filer_pb.FuseAttributes{
+				Mtime:    time.Now().Unix(),
+				FileMode: uint32(0777 | os.ModeDir),
+				Uid:      fs.option.Uid,
+				Gid:      fs.option.Gid,
+				Inode:    rootEntryId,
+			},
+		}, nil
+	}
+	entry, err := filer_pb.GetEntry(fs, fullPath)
+	if err == filer_pb.ErrNotFound || (err == nil && entry == nil) {
+		return fullPath, nil, os.ErrNotExist
+	}
+	if err != nil {
+		return fullPath, nil, err
+	}
+	return fullPath, entry, nil
+}
+
+func (fs *FilerFileSystem) Create(filename string) (billy.File, error) {
+	return fs.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (fs *FilerFileSystem) Open(filename string) (billy.File, error) {
+	return fs.OpenFile(filename, os.O_RDONLY, 0)
+}
+
+func (fs *FilerFileSystem) OpenFile(filename string, flag int, perm os.FileMode) (billy.File, error) {
+	glog.V(4).Infof("nfs open %s %x", filename, flag)
+	fullPath, entry, err := fs.lookup(filename)
+	if err != nil && err != os.ErrNotExist {
+		return nil, err
+	}
+
+	if entry != nil && flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+		return nil, os.ErrExist
+	}
+	if entry == nil {
+		if flag&os.O_CREATE == 0 {
+			return nil, os.ErrNotExist
+		}
+		if entry, err = fs.createEntry(fullPath, perm&os.ModePerm, ""); err != nil {
+			return nil, err
+		}
+	}
+	if entry.IsDirectory {
+		return nil, fmt.Errorf("%s is a directory", fullPath)
+	}
+
+	f := newFilerFile(fs, filename, fullPath, entry)
+	if flag&os.O_TRUNC != 0 && filer.FileSize(entry) > 0 {
+		if err = f.Truncate(0); err != nil {
+			return nil, err
+		}
+	}
+	if flag&os.O_APPEND != 0 {
+		f.off = int64(filer.FileSize(entry))
+	}
+	return f, nil
+}
+
+func (fs *FilerFileSystem) createEntry(fullPath util.FullPath, mode os.FileMode, symlinkTarget string) (*filer_pb.Entry, error) {
+	dir, name := fullPath.DirAndName()
+	now := time.Now().Unix()
+	entry := &filer_pb.Entry{
+		Name:        name,
+		IsDirectory: mode&os.ModeDir != 0,
+		Attributes: &filer_pb.FuseAttributes{
+			Mtime:         now,
+			Crtime:        now,
+			FileMode:      uint32(mode),
+			Uid:           fs.option.Uid,
+			Gid:           fs.option.Gid,
+			SymlinkTarget: symlinkTarget,
+		},
+	}
+	err := fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.CreateEntry(client, &filer_pb.CreateEntryRequest{
+			Directory:  dir,
+			Entry:      entry,
+			Signatures: []int32{fs.signature},
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create %s: %v", fullPath, err)
+	}
+	return entry, nil
+}
+
+func (fs *FilerFileSystem) updateEntry(fullPath util.FullPath, entry *filer_pb.Entry) error {
+	dir, _ := fullPath.DirAndName()
+	return fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		if _, err := client.UpdateEntry(context.Background(), &filer_pb.UpdateEntryRequest{
+			Directory:  dir,
+			Entry:      entry,
+			Signatures: []int32{fs.signature},
+		}); err != nil {
+			return fmt.Errorf("update %s: %v", fullPath, err)
+		}
+		return nil
+	})
+}
+
+func (fs *FilerFileSystem) Stat(filename string) (os.FileInfo, error) {
+	fullPath, entry, err := fs.lookup(filename)
+	// follow a few levels of symlinks
+	for i := 0; err == nil && entry.Attributes.SymlinkTarget != "" && i < 8; i++ {
+		target := entry.Attributes.SymlinkTarget
+		if !strings.HasPrefix(target, "/") {
+			dir, _ := fullPath.DirAndName()
+			target = path.Join(strings.TrimPrefix(dir, fs.root), target)
+		}
+		filename = target
+		fullPath, entry, err = fs.lookup(filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newFileInfo(fullPath, entry), nil
+}
+
+func (fs *FilerFileSystem) Lstat(filename string) (os.FileInfo, error) {
+	fullPath, entry, err := fs.lookup(filename)
+	if err != nil {
+		return nil, err
+	}
+	return newFileInfo(fullPath, entry), nil
+}
+
+func (fs *FilerFileSystem) Rename(oldpath, newpath string) error {
+	oldPath, newPath := fs.fullPath(oldpath), fs.fullPath(newpath)
+	oldDir, oldName := oldPath.DirAndName()
+	newDir, newName := newPath.DirAndName()
+	err := fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		_, err := client.AtomicRenameEntry(context.Background(), &filer_pb.AtomicRenameEntryRequest{
+			OldDirectory: oldDir,
+			OldName:      oldName,
+			NewDirectory: newDir,
+			NewName:      newName,
+			Signatures:   []int32{fs.signature},
+		})
+		return err
+	})
+	if err == nil && fs.onRename != nil {
+		fs.onRename(oldPath, newPath)
+	}
+	return err
+}
+
+// Remove deletes a file, or an empty directory.
+func (fs *FilerFileSystem) Remove(filename string) error {
+	fullPath, _, err := fs.lookup(filename)
+	if err != nil {
+		return err
+	}
+	dir, name := fullPath.DirAndName()
+	return filer_pb.Remove(fs, dir, name, true, false, false, false, []int32{fs.signature})
+}
+
+func (fs *FilerFileSystem) Join(elem ...string) string {
+	return path.Join(elem...)
+}
+
+func (fs *FilerFileSystem) TempFile(dir, prefix string) (billy.File, error) {
+	return nil, billy.ErrNotSupported
+}
+
+func (fs *FilerFileSystem) ReadDir(dirname string) (infos []os.FileInfo, err error) {
+	fullPath, entry, err := fs.lookup(dirname)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.IsDirectory {
+		return nil, fmt.Errorf("%s is not a directory", fullPath)
+	}
+	err = filer_pb.ReadDirAllEntries(fs, fullPath, "", func(entry *filer_pb.Entry, isLast bool) error {
+		infos = append(infos, newFileInfo(fullPath.Child(entry.Name), entry))
+		return nil
+	})
+	return infos, err
+}
+
+func (fs *FilerFileSystem) MkdirAll(filename string, perm os.FileMode) error {
+	fullPath, entry, err := fs.lookup(filename)
+	if err == nil {
+		if !entry.IsDirectory {
+			return os.ErrExist
+		}
+		return nil
+	}
+	if err != os.ErrNotExist {
+		return err
+	}
+	// the filer creates the missing parent directories
+	_, err = fs.createEntry(fullPath, perm&os.ModePerm|os.ModeDir, "")
+	return err
+}
+
+func (fs *FilerFileSystem) Symlink(target, link string) error {
+	fullPath, _, err := fs.lookup(link)
+	if err == nil {
+		return os.ErrExist
+	}
+	if err != os.ErrNotExist {
+		return err
+	}
+	_, err = fs.createEntry(fullPath, os.ModeSymlink|0777, target)
+	return err
+}
+
+func (fs *FilerFileSystem) Readlink(link string) (string, error) {
+	fullPath, entry, err := fs.lookup(link)
+	if err != nil {
+		return "", err
+	}
+	if entry.Attributes.SymlinkTarget == "" {
+		return "", fmt.Errorf("%s is not a symlink", fullPath)
+	}
+	return entry.Attributes.SymlinkTarget, nil
+}
+
+func (fs *FilerFileSystem) Chroot(p string) (billy.Filesystem, error) {
+	chrooted := *fs
+	chrooted.root = string(fs.fullPath(p))
+	return &chrooted, nil
+}
+
+func (fs *FilerFileSystem) Root() string {
+	return fs.root
+}
+
+func (fs *FilerFileSystem) changeAttributes(name string, fn func(attributes *filer_pb.FuseAttributes)) error {
+	fullPath, entry, err := fs.lookup(name)
+	if err != nil {
+		return err
+	}
+	if string(fullPath) == "/" {
+		return nil
+	}
+	fn(entry.Attributes)
+	return fs.updateEntry(fullPath, entry)
+}
+
+func (fs *FilerFileSystem) Chmod(name string, mode os.FileMode) error {
+	return fs.changeAttributes(name, func(attributes *filer_pb.FuseAttributes) {
+		attributes.FileMode = uint32(os.FileMode(attributes.FileMode)&^os.ModePerm | mode&os.ModePerm)
+	})
+}
+
+func (fs *FilerFileSystem) Lchown(name string, uid, gid int) error {
+	return fs.Chown(name, uid, gid)
+}
+
+func (fs *FilerFileSystem) Chown(name string, uid, gid int) error {
+	return fs.changeAttributes(name, func(attributes *filer_pb.FuseAttributes) {
+		if uid >= 0 {
+			attributes.Uid = uint32(uid)
+		}
+		if gid >= 0 {
+			attributes.Gid = uint32(gid)
+		}
+	})
+}
+
+func (fs *FilerFileSystem) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return fs.changeAttributes(name, func(attributes *filer_pb.FuseAttributes) {
+		attributes.Mtime = mtime.Unix()
+	})
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+	sys     *file.FileInfo
+}
+
+func newFileInfo(fullPath util.FullPath, entry *filer_pb.Entry) *fileInfo {
+	mode := os.FileMode(entry.Attributes.FileMode)
+	if entry.IsDirectory {
+		mode |= os.ModeDir
+	}
+	if entry.Attributes.SymlinkTarget != "" {
+		mode |= os.ModeSymlink
+	}
+	_, name := fullPath.DirAndName()
+	return &fileInfo{
+		name:    name,
+		size:    int64(filer.FileSize(entry)),
+		mode:    mode,
+		modTime: time.Unix(entry.Attributes.Mtime, 0),
+		sys: &file.FileInfo{
+			Nlink:  1,
+			UID:    entry.Attributes.Uid,
+			GID:    entry.Attributes.Gid,
+			Fileid: entryId(fullPath, entry),
+		},
+	}
+}
+
+// entryId is the inode of the entry, which stays the same when the entry is updated.
+// Entries created without an inode fall back to a hash of the path and creation time.
+func entryId(fullPath util.FullPath, entry *filer_pb.Entry) uint64 {
+	if entry.Attributes.Inode != 0 {
+		return entry.Attributes.Inode
+	}
+	return fullPath.AsInode(entry.Attributes.Crtime)
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *fileInfo) Sys() interface{}   { return fi.sys }
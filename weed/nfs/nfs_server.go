@@ -0,0 +1,243 @@
+package nfs
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-billy/v5"
+	gonfs "github.com/willscott/go-nfs"
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+type NfsServerOption struct {
+	Filer          pb.ServerAddress
+	FilerRootPath  string
+	GrpcDialOption grpc.DialOption
+	Collection     string
+	Replication    string
+	DiskType       string
+	Uid            uint32
+	Gid            uint32
+	Cipher         bool
+	// HandleLimit is the number of file handles remembered by the server
+	HandleLimit int
+}
+
+// NfsServer serves NFSv3 on top of the filer. Every NFS operation is translated
+// to filer gRPC calls by FilerFileSystem, and the file data goes to the volume servers.
+type NfsServer struct {
+	option  *NfsServerOption
+	fs      *FilerFileSystem
+	handles *handleCache
+}
+
+var _ = gonfs.Handler(&NfsServer{})
+
+func NewNfsServer(option *NfsServerOption) (*NfsServer, error) {
+	if option.HandleLimit <= 0 {
+		option.HandleLimit = 1024 * 1024
+	}
+	s := &NfsServer{
+		option:  option,
+		fs:      newFilerFileSystem(option),
+		handles: newHandleCache(option.HandleLimit),
+	}
+	s.fs.onRename = s.handles.rename
+	return s, nil
+}
+
+func (s *NfsServer) Serve(listener net.Listener) error {
+	return gonfs.Serve(listener, s)
+}
+
+func (s *NfsServer) Mount(ctx context.Context, conn net.Conn, req gonfs.MountRequest) (gonfs.MountStatus, billy.Filesystem, []gonfs.AuthFlavor) {
+	glog.V(0).Infof("nfs mount %s from %v", string(req.Dirpath), conn.RemoteAddr())
+	return gonfs.MountStatusOk, s.fs, []gonfs.AuthFlavor{gonfs.AuthFlavorNull}
+}
+
+func (s *NfsServer) Change(fs billy.Filesystem) billy.Change {
+	if change, ok := fs.(billy.Change); ok {
+		return change
+	}
+	return nil
+}
+
+func (s *NfsServer) FSStat(ctx context.Context, fs billy.Filesystem, stat *gonfs.FSStat) error {
+	return s.fs.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.Statistics(ctx, &filer_pb.StatisticsRequest{
+			Replication: s.option.Replication,
+			Collection:  s.option.Collection,
+			DiskType:    s.option.DiskType,
+		})
+		if err != nil {
+			return err
+		}
+		var free uint64
+		if resp.TotalSize > resp.UsedSize {
+			free = resp.TotalSize - resp.UsedSize
+		}
+		stat.TotalSize = resp.TotalSize
+		stat.FreeSize = free
+		stat.AvailableSize = free
+		// the filer does not limit the number of files
+		stat.TotalFiles = resp.FileCount + (1 << 40)
+		stat.FreeFiles = 1 << 40
+		stat.AvailableFiles = 1 << 40
+		return nil
+	})
+}
+
+// ToHandle returns the fixed size file handle of the path, which is the entry id of the path.
+// The handle stays valid when the entry is updated or renamed through this server.
+func (s *NfsServer) ToHandle(fs billy.Filesystem, path []string) []byte {
+	if len(path) == 0 {
+		return rootHandle[:]
+	}
+	fullPath := s.fs.fullPath(fs.Join(path...))
+	if handle, found := s.handles.lookup(fullPath); found {
+		return handle[:]
+	}
+	var handle fileHandle
+	if _, entry, err := s.fs.lookup(fs.Join(path...)); err == nil {
+		handle = toHandle(entryId(fullPath, entry))
+	} else {
+		// the entry is gone, the handle will be stale anyway
+		handle = toHandle(fullPath.AsInode(0))
+	}
+	s.handles.put(handle, fullPath)
+	return handle[:]
+}
+
+func (s *NfsServer) FromHandle(fh []byte) (billy.Filesystem, []string, error) {
+	var handle fileHandle
+	if len(fh) != len(handle) {
+		return nil, nil, &gonfs.NFSStatusError{NFSStatus: gonfs.NFSStatusBadHandle}
+	}
+	copy(handle[:], fh)
+	if handle == rootHandle {
+		return s.fs, []string{}, nil
+	}
+	fullPath, found := s.handles.get(handle)
+	if !found {
+		return nil, nil, &gonfs.NFSStatusError{NFSStatus: gonfs.NFSStatusStale}
+	}
+	return s.fs, s.fs.relativePath(fullPath), nil
+}
+
+func (s *NfsServer) InvalidateHandle(fs billy.Filesystem, fh []byte) error {
+	var handle fileHandle
+	if len(fh) == len(handle) {
+		copy(handle[:], fh)
+		s.handles.remove(handle)
+	}
+	return nil
+}
+
+func (s *NfsServer) HandleLimit() int {
+	return s.option.HandleLimit
+}
+
+// fileHandle is the NFS file handle, the big endian entry id.
+type fileHandle [8]byte
+
+// rootEntryId is the entry id of the mounted directory. Its handle is pinned, so that
+// the clients can keep using the mount after the server restarts.
+const rootEntryId = 1
+
+var rootHandle = toHandle(rootEntryId)
+
+func toHandle(entryId uint64) (handle fileHandle) {
+	binary.BigEndian.PutUint64(handle[:], entryId)
+	return
+}
+
+// handleCache remembers the path of the handles given out to NFS clients.
+// Except the root handle, handles are forgotten on restart, and clients then get a stale file handle error.
+type handleCache struct {
+	sync.Mutex
+	limit   int
+	paths   map[fileHandle]util.FullPath
+	handles map[util.FullPath]fileHandle
+}
+
+func newHandleCache(limit int) *handleCache {
+	return &handleCache{
+		limit:   limit,
+		paths:   make(map[fileHandle]util.FullPath),
+		handles: make(map[util.FullPath]fileHandle),
+	}
+}
+
+func (c *handleCache) put(handle fileHandle, fullPath util.FullPath) {
+	c.Lock()
+	defer c.Unlock()
+	if oldPath, found := c.paths[handle]; found {
+		delete(c.handles, oldPath)
+	} else if len(c.paths) >= c.limit {
+		// evict an arbitrary handle to stay in the limit
+		for h, p := range c.paths {
+			delete(c.paths, h)
+			delete(c.handles, p)
+			break
+		}
+	}
+	c.paths[handle] = fullPath
+	c.handles[fullPath] = handle
+}
+
+func (c *handleCache) get(handle fileHandle) (util.FullPath, bool) {
+	c.Lock()
+	defer c.Unlock()
+	fullPath, found := c.paths[handle]
+	return fullPath, found
+}
+
+func (c *handleCache) lookup(fullPath util.FullPath) (fileHandle, bool) {
+	c.Lock()
+	defer c.Unlock()
+	handle, found := c.handles[fullPath]
+	return handle, found
+}
+
+func (c *handleCache) remove(handle fileHandle) {
+	c.Lock()
+	defer c.Unlock()
+	if fullPath, found := c.paths[handle]; found {
+		delete(c.paths, handle)
+		delete(c.handles, fullPath)
+	}
+}
+
+// rename moves the handles of the renamed entry, and of the entries under it, to the new path.
+func (c *handleCache) rename(oldPath, newPath util.FullPath) {
+	c.Lock()
+	defer c.Unlock()
+	moved := make(map[fileHandle]util.FullPath)
+	for handle, fullPath := range c.paths {
+		if isUnder(fullPath, newPath) {
+			// the replaced entry
+			delete(c.paths, handle)
+			delete(c.handles, fullPath)
+		}
+		if isUnder(fullPath, oldPath) {
+			moved[handle] = newPath + fullPath[len(oldPath):]
+		}
+	}
+	for handle, renamed := range moved {
+		delete(c.handles, c.paths[handle])
+		c.paths[handle] = renamed
+		c.handles[renamed] = handle
+	}
+}
+
+func isUnder(fullPath, dir util.FullPath) bool {
+	return fullPath == dir || strings.HasPrefix(string(fullPath), string(dir)+"/")
+}
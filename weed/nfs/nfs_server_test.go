@@ -0,0 +1,82 @@
+package nfs
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestHandleCache(t *testing.T) {
+	c := newHandleCache(2)
+
+	a := toHandle(1001)
+	b := toHandle(1002)
+	if a == b || a == rootHandle {
+		t.Fatalf("different entries have the same handle")
+	}
+
+	c.put(a, "/a")
+	c.put(b, "/a/b")
+	if fullPath, found := c.get(b); !found || fullPath != "/a/b" {
+		t.Errorf("get b = %v, %v", fullPath, found)
+	}
+	if handle, found := c.lookup("/a/b"); !found || handle != b {
+		t.Errorf("lookup /a/b = %v, %v", handle, found)
+	}
+
+	c.put(toHandle(1003), "/c")
+	if len(c.paths) != 2 || len(c.handles) != 2 {
+		t.Errorf("cache size = %d %d, want 2", len(c.paths), len(c.handles))
+	}
+
+	c.remove(b)
+	if _, found := c.get(b); found {
+		t.Errorf("b is not removed")
+	}
+	if _, found := c.lookup("/a/b"); found {
+		t.Errorf("/a/b is not removed")
+	}
+}
+
+func TestHandleCacheRename(t *testing.T) {
+	c := newHandleCache(10)
+	a, b, ab, d := toHandle(1001), toHandle(1002), toHandle(1003), toHandle(1004)
+	c.put(a, "/a")
+	c.put(ab, "/a/b")
+	c.put(b, "/ab")
+	c.put(d, "/d")
+
+	c.rename("/a", "/d")
+
+	for handle, want := range map[fileHandle]util.FullPath{a: "/d", ab: "/d/b", b: "/ab"} {
+		if fullPath, found := c.get(handle); !found || fullPath != want {
+			t.Errorf("get %x = %v, %v, want %v", handle, fullPath, found, want)
+		}
+		if h, found := c.lookup(want); !found || h != handle {
+			t.Errorf("lookup %v = %x, %v", want, h, found)
+		}
+	}
+	if _, found := c.get(d); found {
+		t.Errorf("the replaced /d is not removed")
+	}
+	if _, found := c.lookup("/a"); found {
+		t.Errorf("/a is not renamed")
+	}
+}
+
+func TestEntryId(t *testing.T) {
+	entry := &filer_pb.Entry{Name: "b", Attributes: &filer_pb.FuseAttributes{Crtime: 1, Inode: 42}}
+	if id := entryId("/a/b", entry); id != 42 {
+		t.Errorf("entry id = %d, want the inode", id)
+	}
+	entry.Attributes.Mtime = 2
+	if id := entryId("/a/b", entry); id != 42 {
+		t.Errorf("entry id changed with the mtime")
+	}
+
+	fs := &FilerFileSystem{root: "/exports"}
+	if path := fs.relativePath("/exports/a/b"); len(path) != 2 || path[0] != "a" || path[1] != "b" {
+		t.Errorf("relative path = %q", path)
+	}
+}
@@ -0,0 +1,282 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/notification"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	SignatureHeader = "X-Seaweedfs-Signature"
+	TimestampHeader = "X-Seaweedfs-Timestamp"
+	// the receivers should reject the events signed longer ago, against replaying
+	DefaultSignatureMaxAge = 5 * time.Minute
+	maxAttempts            = 5
+	queueSize              = 10000
+	requestTimeout         = 10 * time.Second
+)
+
+// the first retry waits this long, doubled for each later retry
+var retryBackoff = time.Second
+
+func init() {
+	notification.MessageQueues = append(notification.MessageQueues, &WebhookQueue{})
+}
+
+// FilerEvent is the json body posted to the webhooks
+type FilerEvent struct {
+	EventType string `json:"event_type"`
+	Path      string `json:"path"`
+	Size      uint64 `json:"size"`
+	Mtime     int64  `json:"mtime"`
+	Uid       uint32 `json:"uid"`
+	Gid       uint32 `json:"gid"`
+	Mime      string `json:"mime"`
+}
+
+type Target struct {
+	Url        string `mapstructure:"url"`
+	PathPrefix string `mapstructure:"path_prefix"`
+}
+
+// matches checks the path prefix on the path segments, so "/buckets/a" matches "/buckets/a/x" but not "/buckets/ab"
+func (t *Target) matches(path string) bool {
+	prefix := strings.TrimSuffix(t.PathPrefix, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+type delivery struct {
+	target *Target
+	event  *FilerEvent
+}
+
+type deadLetter struct {
+	Url   string      `json:"url"`
+	Event *FilerEvent `json:"event"`
+	Error string      `json:"error"`
+	Time  time.Time   `json:"time"`
+}
+
+// WebhookQueue posts the filer events to the webhook targets by a pool of workers,
+// retrying with exponential backoff. The events failed after all retries are appended to the dead letter file.
+type WebhookQueue struct {
+	targets        []*Target
+	secret         []byte
+	client         *http.Client
+	deliveries     chan *delivery
+	deadLetterLock sync.Mutex
+	deadLetterFile string
+}
+
+func (k *WebhookQueue) GetName() string {
+	return "webhook"
+}
+
+func (k *WebhookQueue) Initialize(configuration util.Configuration, prefix string) (err error) {
+	unmarshaler, ok := configuration.(interface {
+		UnmarshalKey(key string, rawVal interface{}) error
+	})
+	if !ok {
+		return fmt.Errorf("%stargets can not be read from %T", prefix, configuration)
+	}
+	var targets []*Target
+	if err = unmarshaler.UnmarshalKey(prefix+"targets", &targets); err != nil {
+		return fmt.Errorf("parse %stargets: %v", prefix, err)
+	}
+	configuration.SetDefault(prefix+"workers", 4)
+	configuration.SetDefault(prefix+"dead_letter_file", "./webhook_dead_letter.jsonl")
+	glog.V(0).Infof("filer.notification.webhook.targets: %d", len(targets))
+	return k.initialize(
+		targets,
+		configuration.GetString(prefix+"secret"),
+		configuration.GetInt(prefix+"workers"),
+		configuration.GetString(prefix+"dead_letter_file"),
+	)
+}
+
+func (k *WebhookQueue) initialize(targets []*Target, secret string, workers int, deadLetterFile string) error {
+	if len(targets) == 0 {
+		return fmt.Errorf("no webhook targets")
+	}
+	for _, target := range targets {
+		if !strings.HasPrefix(target.Url, "http://") && !strings.HasPrefix(target.Url, "https://") {
+			return fmt.Errorf("webhook url %q should start with http:// or https://", target.Url)
+		}
+	}
+	if workers <= 0 {
+		return fmt.Errorf("webhook workers %d should be positive", workers)
+	}
+	k.targets = targets
+	k.secret = []byte(secret)
+	k.client = &http.Client{Timeout: requestTimeout}
+	k.deliveries = make(chan *delivery, queueSize)
+	k.deadLetterFile = deadLetterFile
+	for i := 0; i < workers; i++ {
+		go k.deliver()
+	}
+	return nil
+}
+
+func (k *WebhookQueue) SendMessage(key string, message proto.Message) (err error) {
+	eventNotification, ok := message.(*filer_pb.EventNotification)
+	if !ok {
+		return nil
+	}
+	event := toFilerEvent(key, eventNotification)
+	if event == nil {
+		return nil
+	}
+	for _, target := range k.targets {
+		if !target.matches(event.Path) {
+			continue
+		}
+		select {
+		case k.deliveries <- &delivery{target: target, event: event}:
+		default:
+			// do not block the filer writes
+			k.writeDeadLetter(target, event, fmt.Errorf("webhook queue is full"))
+		}
+	}
+	return nil
+}
+
+func toFilerEvent(key string, message *filer_pb.EventNotification) *FilerEvent {
+	event := &FilerEvent{Path: key}
+	entry := message.NewEntry
+	switch {
+	case message.OldEntry == nil && message.NewEntry != nil:
+		event.EventType = "create"
+	case message.OldEntry != nil && message.NewEntry == nil:
+		event.EventType = "delete"
+		entry = message.OldEntry
+	case message.OldEntry != nil && message.NewEntry != nil:
+		event.EventType = "update"
+		// for renaming, the key is the old path
+		event.Path = string(util.NewFullPath(message.NewParentPath, message.NewEntry.Name))
+	default:
+		return nil
+	}
+	event.Size = filer.FileSize(entry)
+	if attr := entry.Attributes; attr != nil {
+		event.Mtime = attr.Mtime
+		event.Uid = attr.Uid
+		event.Gid = attr.Gid
+		event.Mime = attr.Mime
+	}
+	return event
+}
+
+func (k *WebhookQueue) deliver() {
+	for d := range k.deliveries {
+		body, err := json.Marshal(d.event)
+		if err != nil {
+			k.writeDeadLetter(d.target, d.event, err)
+			continue
+		}
+		backoff := retryBackoff
+		for attempt := 1; ; attempt++ {
+			if err = k.post(d.target.Url, body); err == nil {
+				break
+			}
+			if attempt >= maxAttempts {
+				glog.Errorf("webhook %s %s: %v", d.target.Url, d.event.Path, err)
+				k.writeDeadLetter(d.target, d.event, err)
+				break
+			}
+			glog.V(1).Infof("webhook %s attempt %d: %v, retry in %v", d.target.Url, attempt, err, backoff)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+func (k *WebhookQueue) post(url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(k.secret) > 0 {
+		// each attempt is signed with its own time
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+Sign(k.secret, timestamp, body))
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	util.CloseResponse(resp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
+
+// Sign is the hex encoded HMAC-SHA256 of the timestamp, a ".", and the request body
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and the timestamp headers of a webhook request, for the receivers.
+// The events signed more than maxAge ago, or in the future, are rejected.
+func Verify(secret []byte, header http.Header, body []byte, maxAge time.Duration) error {
+	timestamp := header.Get(TimestampHeader)
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", TimestampHeader, timestamp)
+	}
+	if age := time.Since(time.Unix(signedAt, 0)); age > maxAge || age < -maxAge {
+		return fmt.Errorf("event signed %v ago, over %v", age, maxAge)
+	}
+	expected := "sha256=" + Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(header.Get(SignatureHeader)), []byte(expected)) {
+		return fmt.Errorf("invalid %s", SignatureHeader)
+	}
+	return nil
+}
+
+func (k *WebhookQueue) writeDeadLetter(target *Target, event *FilerEvent, deliveryErr error) {
+	line, err := json.Marshal(&deadLetter{
+		Url:   target.Url,
+		Event: event,
+		Error: deliveryErr.Error(),
+		Time:  time.Now(),
+	})
+	if err != nil {
+		glog.Errorf("marshal webhook dead letter %s: %v", event.Path, err)
+		return
+	}
+
+	k.deadLetterLock.Lock()
+	defer k.deadLetterLock.Unlock()
+	f, err := os.OpenFile(k.deadLetterFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		glog.Errorf("open webhook dead letter file %s: %v", k.deadLetterFile, err)
+		return
+	}
+	defer f.Close()
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		glog.Errorf("write webhook dead letter file %s: %v", k.deadLetterFile, err)
+	}
+}
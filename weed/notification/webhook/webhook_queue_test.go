@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+func TestTargetMatches(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		path     string
+		expected bool
+	}{
+		{"/buckets/a", "/buckets/a/x", true},
+		{"/buckets/a/", "/buckets/a/x", true},
+		{"/buckets/a", "/buckets/a", true},
+		{"/buckets/a", "/buckets/ab/x", false},
+		{"/buckets/a/", "/buckets/ab", false},
+		{"", "/any", true},
+		{"/", "/any", true},
+	}
+	for _, tt := range tests {
+		target := &Target{PathPrefix: tt.prefix}
+		assert.Equal(t, tt.expected, target.matches(tt.path), "prefix %q path %q", tt.prefix, tt.path)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret := []byte("secret")
+	body := []byte(`{"event_type":"create"}`)
+	signed := func(signedAt time.Time, body []byte) http.Header {
+		timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+		header := http.Header{}
+		header.Set(TimestampHeader, timestamp)
+		header.Set(SignatureHeader, "sha256="+Sign(secret, timestamp, body))
+		return header
+	}
+
+	assert.Nil(t, Verify(secret, signed(time.Now(), body), body, DefaultSignatureMaxAge))
+	assert.NotNil(t, Verify([]byte("other"), signed(time.Now(), body), body, DefaultSignatureMaxAge), "other secret")
+	assert.NotNil(t, Verify(secret, signed(time.Now(), []byte("{}")), body, DefaultSignatureMaxAge), "other body")
+	assert.NotNil(t, Verify(secret, signed(time.Now().Add(-time.Hour), body), body, DefaultSignatureMaxAge), "replayed")
+
+	// the timestamp is signed too
+	header := signed(time.Now().Add(-time.Hour), body)
+	header.Set(TimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	assert.NotNil(t, Verify(secret, header, body, DefaultSignatureMaxAge), "changed timestamp")
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	retryBackoff = time.Millisecond
+	defer func() {
+		retryBackoff = time.Second
+	}()
+
+	secret := []byte("secret")
+	received := make(chan *FilerEvent, 10)
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(secret, r.Header, body, DefaultSignatureMaxAge); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// the first attempt fails, and is retried
+		if atomic.AddInt32(&attempts, 1) == 1 || r.URL.Path == "/failing" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		event := &FilerEvent{}
+		json.Unmarshal(body, event)
+		received <- event
+	}))
+	defer server.Close()
+
+	deadLetterFile := filepath.Join(t.TempDir(), "dead_letter.jsonl")
+	k := &WebhookQueue{}
+	assert.Nil(t, k.initialize([]*Target{
+		{Url: server.URL + "/events", PathPrefix: "/buckets/a"},
+		{Url: server.URL + "/failing", PathPrefix: "/buckets/b"},
+	}, string(secret), 1, deadLetterFile))
+
+	newEntry := &filer_pb.Entry{Name: "x", Attributes: &filer_pb.FuseAttributes{FileSize: 3, Mime: "text/plain"}}
+	assert.Nil(t, k.SendMessage("/buckets/ab/x", &filer_pb.EventNotification{NewEntry: newEntry, NewParentPath: "/buckets/ab"}))
+	assert.Nil(t, k.SendMessage("/buckets/a/x", &filer_pb.EventNotification{NewEntry: newEntry, NewParentPath: "/buckets/a"}))
+	select {
+	case event := <-received:
+		assert.Equal(t, &FilerEvent{EventType: "create", Path: "/buckets/a/x", Size: 3, Mime: "text/plain"}, event)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("no event delivered")
+	}
+
+	// the failed events are written to the dead letter file after all retries
+	assert.Nil(t, k.SendMessage("/buckets/b/x", &filer_pb.EventNotification{OldEntry: newEntry}))
+	var deadLetters []byte
+	for start := time.Now(); len(deadLetters) == 0 && time.Since(start) < 10*time.Second; time.Sleep(10 * time.Millisecond) {
+		deadLetters, _ = os.ReadFile(deadLetterFile)
+	}
+	letter := &deadLetter{}
+	assert.Nil(t, json.Unmarshal(deadLetters, letter))
+	assert.Equal(t, "delete", letter.Event.EventType)
+	assert.Equal(t, server.URL+"/failing", letter.Url)
+	assert.Empty(t, received, "the event outside of the path prefix is delivered")
+}
@@ -24,14 +24,16 @@ type VolumeAssignRequest struct {
 }
 
 type AssignResult struct {
-	Fid       string              `json:"fid,omitempty"`
-	Url       string              `json:"url,omitempty"`
-	PublicUrl string              `json:"publicUrl,omitempty"`
-	GrpcPort  int                 `json:"grpcPort,omitempty"`
-	Count     uint64              `json:"count,omitempty"`
-	Error     string              `json:"error,omitempty"`
-	Auth      security.EncodedJwt `json:"auth,omitempty"`
-	Replicas  []Location          `json:"replicas,omitempty"`
+	Fid        string              `json:"fid,omitempty"`
+	Url        string              `json:"url,omitempty"`
+	PublicUrl  string              `json:"publicUrl,omitempty"`
+	GrpcPort   int                 `json:"grpcPort,omitempty"`
+	DataCenter string              `json:"dataCenter,omitempty"`
+	Rack       string              `json:"rack,omitempty"`
+	Count      uint64              `json:"count,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	Auth       security.EncodedJwt `json:"auth,omitempty"`
+	Replicas   []Location          `json:"replicas,omitempty"`
 }
 
 func Assign(masterFn GetMasterFn, grpcDialOption grpc.DialOption, primaryRequest *VolumeAssignRequest, alternativeRequests ...*VolumeAssignRequest) (*AssignResult, error) {
@@ -74,6 +76,8 @@ func Assign(masterFn GetMasterFn, grpcDialOption grpc.DialOption, primaryRequest
 			ret.Url = resp.Location.Url
 			ret.PublicUrl = resp.Location.PublicUrl
 			ret.GrpcPort = int(resp.Location.GrpcPort)
+			ret.DataCenter = resp.Location.DataCenter
+			ret.Rack = resp.Location.Rack
 			ret.Error = resp.Error
 			ret.Auth = security.EncodedJwt(resp.Auth)
 			for _, r := range resp.Replicas {
@@ -81,6 +85,7 @@ func Assign(masterFn GetMasterFn, grpcDialOption grpc.DialOption, primaryRequest
 					Url:        r.Url,
 					PublicUrl:  r.PublicUrl,
 					DataCenter: r.DataCenter,
+					Rack:       r.Rack,
 				})
 			}
 
@@ -138,12 +143,81 @@ type StorageOption struct {
 	Fsync             bool
 	VolumeGrowthCount uint32
 	SaveInside        bool
+	Residency         *Residency
+}
+
+// Residency restricts the chunks of the file at the path to the volume servers in the data centers, or racks
+type Residency struct {
+	Path        string
+	DataCenters []string
+	Racks       []string
 }
 
 func (so *StorageOption) TtlString() string {
 	return needle.SecondsToTTL(so.TtlSeconds)
 }
 
+// ToResidencyAssignRequests returns one request for each allowed location, starting from the requested one,
+// without the fallback to any location
+func (so *StorageOption) ToResidencyAssignRequests(count int) (requests []*VolumeAssignRequest) {
+	dataCenters := preferredFirst(so.Residency.DataCenters, so.DataCenter)
+	if len(dataCenters) == 0 {
+		dataCenters = []string{""}
+	}
+	racks := preferredFirst(so.Residency.Racks, so.Rack)
+	if len(racks) == 0 {
+		racks = []string{""}
+	}
+	for _, dataCenter := range dataCenters {
+		for _, rack := range racks {
+			requests = append(requests, &VolumeAssignRequest{
+				Count:               uint64(count),
+				Replication:         so.Replication,
+				Collection:          so.Collection,
+				Ttl:                 so.TtlString(),
+				DiskType:            so.DiskType,
+				DataCenter:          dataCenter,
+				Rack:                rack,
+				WritableVolumeCount: so.VolumeGrowthCount,
+			})
+		}
+	}
+	return
+}
+
+func preferredFirst(allowed []string, preferred string) []string {
+	for i, x := range allowed {
+		if x == preferred && i > 0 {
+			return append(append([]string{x}, allowed[:i]...), allowed[i+1:]...)
+		}
+	}
+	return allowed
+}
+
+// Violations returns the data centers and racks of the assigned volume, including its replicas,
+// not allowed by the residency. An unknown location, e.g., the rack from an older master, is not allowed.
+func (r *Residency) Violations(result *AssignResult) (locations []string) {
+	assigned := append([]Location{{DataCenter: result.DataCenter, Rack: result.Rack}}, result.Replicas...)
+	for _, location := range assigned {
+		if len(r.DataCenters) > 0 && !isAllowedLocation(r.DataCenters, location.DataCenter) {
+			locations = append(locations, "dataCenter:"+location.DataCenter)
+		}
+		if len(r.Racks) > 0 && !isAllowedLocation(r.Racks, location.Rack) {
+			locations = append(locations, "rack:"+location.Rack)
+		}
+	}
+	return
+}
+
+func isAllowedLocation(allowed []string, location string) bool {
+	for _, x := range allowed {
+		if x == location && location != "" {
+			return true
+		}
+	}
+	return false
+}
+
 func (so *StorageOption) ToAssignRequests(count int) (ar *VolumeAssignRequest, altRequest *VolumeAssignRequest) {
 	ar = &VolumeAssignRequest{
 		Count:               uint64(count),
@@ -18,6 +18,7 @@ type Location struct {
 	PublicUrl  string `json:"publicUrl,omitempty"`
 	DataCenter string `json:"dataCenter,omitempty"`
 	GrpcPort   int    `json:"grpcPort,omitempty"`
+	Rack       string `json:"rack,omitempty"`
 }
 
 func (l *Location) ServerAddress() pb.ServerAddress {
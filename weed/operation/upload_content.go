@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -22,16 +23,24 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
+// ErrVolumeServerUnavailable is returned when the volume server rejects the upload with 503,
+// when it is overloaded or shutting down
+var ErrVolumeServerUnavailable = errors.New("volume server unavailable")
+
 type UploadOption struct {
 	UploadUrl         string
 	Filename          string
 	Cipher            bool
 	IsInputCompressed bool
-	MimeType          string
-	PairMap           map[string]string
-	Jwt               security.EncodedJwt
-	RetryForever      bool
-	Md5               string
+	// the codec of the compressed input: gzip if empty, zstd or lz4
+	InputContentEncoding string
+	MimeType             string
+	PairMap              map[string]string
+	Jwt                  security.EncodedJwt
+	RetryForever         bool
+	Md5                  string
+	// the urls of the same file id on the other replicas, tried in turn when the volume server is unavailable
+	ReplicaUrls []string
 }
 
 type UploadResult struct {
@@ -162,7 +171,7 @@ func retriedUploadData(data []byte, option *UploadOption) (uploadResult *UploadR
 		if i > 0 {
 			time.Sleep(time.Millisecond * time.Duration(237*(i+1)))
 		}
-		uploadResult, err = doUploadData(data, option)
+		uploadResult, err = uploadDataToReplicas(data, option)
 		if err == nil {
 			uploadResult.RetryCount = i
 			return
@@ -172,6 +181,28 @@ func retriedUploadData(data []byte, option *UploadOption) (uploadResult *UploadR
 	return
 }
 
+// uploadDataToReplicas tries the other replicas of the volume if the volume server is overloaded or shutting down
+func uploadDataToReplicas(data []byte, option *UploadOption) (uploadResult *UploadResult, err error) {
+	uploadResult, err = doUploadData(data, option)
+	for _, replicaUrl := range option.ReplicaUrls {
+		if !errors.Is(err, ErrVolumeServerUnavailable) {
+			return
+		}
+		glog.V(1).Infof("try replica %s: %v", replicaUrl, err)
+		replicaOption := *option
+		replicaOption.UploadUrl = replicaUrl
+		uploadResult, err = doUploadData(data, &replicaOption)
+	}
+	return
+}
+
+func (option *UploadOption) inputContentEncoding() string {
+	if option.InputContentEncoding == "" {
+		return "gzip"
+	}
+	return option.InputContentEncoding
+}
+
 func doUploadData(data []byte, option *UploadOption) (uploadResult *UploadResult, err error) {
 	contentIsGzipped := option.IsInputCompressed
 	shouldGzipNow := false
@@ -207,7 +238,7 @@ func doUploadData(data []byte, option *UploadOption) (uploadResult *UploadResult
 		}
 	} else if option.IsInputCompressed {
 		// just to get the clear data length
-		clearData, err = util.DecompressData(data)
+		clearData, err = util.DecompressDataAs(data, option.inputContentEncoding())
 		if err == nil {
 			clearDataLen = len(clearData)
 		}
@@ -250,14 +281,15 @@ func doUploadData(data []byte, option *UploadOption) (uploadResult *UploadResult
 			_, err = w.Write(data)
 			return
 		}, len(data), &UploadOption{
-			UploadUrl:         option.UploadUrl,
-			Filename:          option.Filename,
-			Cipher:            false,
-			IsInputCompressed: contentIsGzipped,
-			MimeType:          option.MimeType,
-			PairMap:           option.PairMap,
-			Jwt:               option.Jwt,
-			Md5:               option.Md5,
+			UploadUrl:            option.UploadUrl,
+			Filename:             option.Filename,
+			Cipher:               false,
+			IsInputCompressed:    contentIsGzipped,
+			InputContentEncoding: option.InputContentEncoding,
+			MimeType:             option.MimeType,
+			PairMap:              option.PairMap,
+			Jwt:                  option.Jwt,
+			Md5:                  option.Md5,
 		})
 		if uploadResult == nil {
 			return
@@ -286,7 +318,7 @@ func upload_content(fillBufferFunction func(w io.Writer) error, originalDataSize
 		h.Set("Content-Type", option.MimeType)
 	}
 	if option.IsInputCompressed {
-		h.Set("Content-Encoding", "gzip")
+		h.Set("Content-Encoding", option.inputContentEncoding())
 	}
 	if option.Md5 != "" {
 		h.Set("Content-MD5", option.Md5)
@@ -336,6 +368,10 @@ func upload_content(fillBufferFunction func(w io.Writer) error, originalDataSize
 	}
 	// print("-")
 
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return nil, fmt.Errorf("upload %s to %v: %w", option.Filename, option.UploadUrl, ErrVolumeServerUnavailable)
+	}
+
 	var ret UploadResult
 	etag := getEtag(resp)
 	if resp.StatusCode == http.StatusNoContent {
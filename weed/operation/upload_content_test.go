@@ -0,0 +1,56 @@
+package operation
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type replicaMockClient struct {
+	unavailable map[string]bool
+	tried       []string
+}
+
+func (m *replicaMockClient) Do(req *http.Request) (*http.Response, error) {
+	m.tried = append(m.tried, req.URL.Host)
+	if m.unavailable[req.URL.Host] {
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       io.NopCloser(strings.NewReader(`{"error":"busy"}`)),
+		}, nil
+	}
+	return &http.Response{
+		StatusCode: http.StatusCreated,
+		Body:       io.NopCloser(strings.NewReader(`{"size":5}`)),
+	}, nil
+}
+
+func TestUploadDataToReplicas(t *testing.T) {
+	mc := &replicaMockClient{unavailable: map[string]bool{"a:8080": true}}
+	tmp := HttpClient
+	HttpClient = mc
+	defer func() {
+		HttpClient = tmp
+	}()
+
+	option := &UploadOption{
+		UploadUrl:   "http://a:8080/3,01637037d6",
+		ReplicaUrls: []string{"http://b:8080/3,01637037d6", "http://c:8080/3,01637037d6"},
+	}
+	if _, err := uploadDataToReplicas([]byte("hello"), option); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if strings.Join(mc.tried, ",") != "a:8080,b:8080" {
+		t.Errorf("unexpected tried servers %v", mc.tried)
+	}
+
+	mc.unavailable["b:8080"], mc.unavailable["c:8080"], mc.tried = true, true, nil
+	if _, err := uploadDataToReplicas([]byte("hello"), option); !errors.Is(err, ErrVolumeServerUnavailable) {
+		t.Errorf("expected unavailable error, got %v", err)
+	}
+	if len(mc.tried) != 3 {
+		t.Errorf("unexpected tried servers %v", mc.tried)
+	}
+}
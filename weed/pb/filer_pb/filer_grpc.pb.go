@@ -28,9 +28,14 @@ type SeaweedFilerClient interface {
 	UpdateEntry(ctx context.Context, in *UpdateEntryRequest, opts ...grpc.CallOption) (*UpdateEntryResponse, error)
 	AppendToEntry(ctx context.Context, in *AppendToEntryRequest, opts ...grpc.CallOption) (*AppendToEntryResponse, error)
 	DeleteEntry(ctx context.Context, in *DeleteEntryRequest, opts ...grpc.CallOption) (*DeleteEntryResponse, error)
+	EraseEntry(ctx context.Context, in *EraseEntryRequest, opts ...grpc.CallOption) (*EraseEntryResponse, error)
+	BatchDeleteEntries(ctx context.Context, in *BatchDeleteEntriesRequest, opts ...grpc.CallOption) (*BatchDeleteEntriesResponse, error)
+	BatchFlushEntries(ctx context.Context, in *BatchFlushEntriesRequest, opts ...grpc.CallOption) (*BatchFlushEntriesResponse, error)
 	AtomicRenameEntry(ctx context.Context, in *AtomicRenameEntryRequest, opts ...grpc.CallOption) (*AtomicRenameEntryResponse, error)
 	StreamRenameEntry(ctx context.Context, in *StreamRenameEntryRequest, opts ...grpc.CallOption) (SeaweedFiler_StreamRenameEntryClient, error)
 	AssignVolume(ctx context.Context, in *AssignVolumeRequest, opts ...grpc.CallOption) (*AssignVolumeResponse, error)
+	LookupChunkByHash(ctx context.Context, in *LookupChunkByHashRequest, opts ...grpc.CallOption) (*LookupChunkByHashResponse, error)
+	SaveChunkHash(ctx context.Context, in *SaveChunkHashRequest, opts ...grpc.CallOption) (*SaveChunkHashResponse, error)
 	LookupVolume(ctx context.Context, in *LookupVolumeRequest, opts ...grpc.CallOption) (*LookupVolumeResponse, error)
 	CollectionList(ctx context.Context, in *CollectionListRequest, opts ...grpc.CallOption) (*CollectionListResponse, error)
 	DeleteCollection(ctx context.Context, in *DeleteCollectionRequest, opts ...grpc.CallOption) (*DeleteCollectionResponse, error)
@@ -129,6 +134,33 @@ func (c *seaweedFilerClient) DeleteEntry(ctx context.Context, in *DeleteEntryReq
 	return out, nil
 }
 
+func (c *seaweedFilerClient) EraseEntry(ctx context.Context, in *EraseEntryRequest, opts ...grpc.CallOption) (*EraseEntryResponse, error) {
+	out := new(EraseEntryResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/EraseEntry", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) BatchDeleteEntries(ctx context.Context, in *BatchDeleteEntriesRequest, opts ...grpc.CallOption) (*BatchDeleteEntriesResponse, error) {
+	out := new(BatchDeleteEntriesResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/BatchDeleteEntries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) BatchFlushEntries(ctx context.Context, in *BatchFlushEntriesRequest, opts ...grpc.CallOption) (*BatchFlushEntriesResponse, error) {
+	out := new(BatchFlushEntriesResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/BatchFlushEntries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *seaweedFilerClient) AtomicRenameEntry(ctx context.Context, in *AtomicRenameEntryRequest, opts ...grpc.CallOption) (*AtomicRenameEntryResponse, error) {
 	out := new(AtomicRenameEntryResponse)
 	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/AtomicRenameEntry", in, out, opts...)
@@ -179,6 +211,24 @@ func (c *seaweedFilerClient) AssignVolume(ctx context.Context, in *AssignVolumeR
 	return out, nil
 }
 
+func (c *seaweedFilerClient) LookupChunkByHash(ctx context.Context, in *LookupChunkByHashRequest, opts ...grpc.CallOption) (*LookupChunkByHashResponse, error) {
+	out := new(LookupChunkByHashResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/LookupChunkByHash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedFilerClient) SaveChunkHash(ctx context.Context, in *SaveChunkHashRequest, opts ...grpc.CallOption) (*SaveChunkHashResponse, error) {
+	out := new(SaveChunkHashResponse)
+	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/SaveChunkHash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *seaweedFilerClient) LookupVolume(ctx context.Context, in *LookupVolumeRequest, opts ...grpc.CallOption) (*LookupVolumeResponse, error) {
 	out := new(LookupVolumeResponse)
 	err := c.cc.Invoke(ctx, "/filer_pb.SeaweedFiler/LookupVolume", in, out, opts...)
@@ -334,9 +384,14 @@ type SeaweedFilerServer interface {
 	UpdateEntry(context.Context, *UpdateEntryRequest) (*UpdateEntryResponse, error)
 	AppendToEntry(context.Context, *AppendToEntryRequest) (*AppendToEntryResponse, error)
 	DeleteEntry(context.Context, *DeleteEntryRequest) (*DeleteEntryResponse, error)
+	EraseEntry(context.Context, *EraseEntryRequest) (*EraseEntryResponse, error)
+	BatchDeleteEntries(context.Context, *BatchDeleteEntriesRequest) (*BatchDeleteEntriesResponse, error)
+	BatchFlushEntries(context.Context, *BatchFlushEntriesRequest) (*BatchFlushEntriesResponse, error)
 	AtomicRenameEntry(context.Context, *AtomicRenameEntryRequest) (*AtomicRenameEntryResponse, error)
 	StreamRenameEntry(*StreamRenameEntryRequest, SeaweedFiler_StreamRenameEntryServer) error
 	AssignVolume(context.Context, *AssignVolumeRequest) (*AssignVolumeResponse, error)
+	LookupChunkByHash(context.Context, *LookupChunkByHashRequest) (*LookupChunkByHashResponse, error)
+	SaveChunkHash(context.Context, *SaveChunkHashRequest) (*SaveChunkHashResponse, error)
 	LookupVolume(context.Context, *LookupVolumeRequest) (*LookupVolumeResponse, error)
 	CollectionList(context.Context, *CollectionListRequest) (*CollectionListResponse, error)
 	DeleteCollection(context.Context, *DeleteCollectionRequest) (*DeleteCollectionResponse, error)
@@ -373,6 +428,15 @@ func (UnimplementedSeaweedFilerServer) AppendToEntry(context.Context, *AppendToE
 func (UnimplementedSeaweedFilerServer) DeleteEntry(context.Context, *DeleteEntryRequest) (*DeleteEntryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteEntry not implemented")
 }
+func (UnimplementedSeaweedFilerServer) EraseEntry(context.Context, *EraseEntryRequest) (*EraseEntryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EraseEntry not implemented")
+}
+func (UnimplementedSeaweedFilerServer) BatchDeleteEntries(context.Context, *BatchDeleteEntriesRequest) (*BatchDeleteEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDeleteEntries not implemented")
+}
+func (UnimplementedSeaweedFilerServer) BatchFlushEntries(context.Context, *BatchFlushEntriesRequest) (*BatchFlushEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchFlushEntries not implemented")
+}
 func (UnimplementedSeaweedFilerServer) AtomicRenameEntry(context.Context, *AtomicRenameEntryRequest) (*AtomicRenameEntryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AtomicRenameEntry not implemented")
 }
@@ -382,6 +446,12 @@ func (UnimplementedSeaweedFilerServer) StreamRenameEntry(*StreamRenameEntryReque
 func (UnimplementedSeaweedFilerServer) AssignVolume(context.Context, *AssignVolumeRequest) (*AssignVolumeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AssignVolume not implemented")
 }
+func (UnimplementedSeaweedFilerServer) LookupChunkByHash(context.Context, *LookupChunkByHashRequest) (*LookupChunkByHashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupChunkByHash not implemented")
+}
+func (UnimplementedSeaweedFilerServer) SaveChunkHash(context.Context, *SaveChunkHashRequest) (*SaveChunkHashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveChunkHash not implemented")
+}
 func (UnimplementedSeaweedFilerServer) LookupVolume(context.Context, *LookupVolumeRequest) (*LookupVolumeResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LookupVolume not implemented")
 }
@@ -539,6 +609,60 @@ func _SeaweedFiler_DeleteEntry_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SeaweedFiler_EraseEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EraseEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).EraseEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/EraseEntry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).EraseEntry(ctx, req.(*EraseEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_BatchDeleteEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).BatchDeleteEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/BatchDeleteEntries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).BatchDeleteEntries(ctx, req.(*BatchDeleteEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_BatchFlushEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchFlushEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).BatchFlushEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/BatchFlushEntries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).BatchFlushEntries(ctx, req.(*BatchFlushEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SeaweedFiler_AtomicRenameEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AtomicRenameEntryRequest)
 	if err := dec(in); err != nil {
@@ -596,6 +720,42 @@ func _SeaweedFiler_AssignVolume_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SeaweedFiler_LookupChunkByHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupChunkByHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).LookupChunkByHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/LookupChunkByHash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).LookupChunkByHash(ctx, req.(*LookupChunkByHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SeaweedFiler_SaveChunkHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveChunkHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedFilerServer).SaveChunkHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/filer_pb.SeaweedFiler/SaveChunkHash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedFilerServer).SaveChunkHash(ctx, req.(*SaveChunkHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _SeaweedFiler_LookupVolume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(LookupVolumeRequest)
 	if err := dec(in); err != nil {
@@ -827,6 +987,18 @@ var SeaweedFiler_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteEntry",
 			Handler:    _SeaweedFiler_DeleteEntry_Handler,
 		},
+		{
+			MethodName: "EraseEntry",
+			Handler:    _SeaweedFiler_EraseEntry_Handler,
+		},
+		{
+			MethodName: "BatchDeleteEntries",
+			Handler:    _SeaweedFiler_BatchDeleteEntries_Handler,
+		},
+		{
+			MethodName: "BatchFlushEntries",
+			Handler:    _SeaweedFiler_BatchFlushEntries_Handler,
+		},
 		{
 			MethodName: "AtomicRenameEntry",
 			Handler:    _SeaweedFiler_AtomicRenameEntry_Handler,
@@ -835,6 +1007,14 @@ var SeaweedFiler_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AssignVolume",
 			Handler:    _SeaweedFiler_AssignVolume_Handler,
 		},
+		{
+			MethodName: "LookupChunkByHash",
+			Handler:    _SeaweedFiler_LookupChunkByHash_Handler,
+		},
+		{
+			MethodName: "SaveChunkHash",
+			Handler:    _SeaweedFiler_SaveChunkHash_Handler,
+		},
 		{
 			MethodName: "LookupVolume",
 			Handler:    _SeaweedFiler_LookupVolume_Handler,
@@ -116,6 +116,9 @@ func CreateEntry(client SeaweedFilerClient, request *CreateEntryRequest) error {
 	}
 	if resp.Error != "" {
 		glog.V(1).Infof("create entry %s/%s %v: %v", request.Directory, request.Entry.Name, request.OExcl, resp.Error)
+		if resp.ErrorCode == FilerError_INODE_QUOTA_EXCEEDED {
+			return fmt.Errorf("CreateEntry %s/%s: %w", request.Directory, request.Entry.Name, ErrInodeQuotaExceeded)
+		}
 		return fmt.Errorf("CreateEntry : %v", resp.Error)
 	}
 	return nil
@@ -147,6 +150,9 @@ func LookupEntry(client SeaweedFilerClient, request *LookupDirectoryEntryRequest
 
 var ErrNotFound = errors.New("filer: no entry is found in filer store")
 
+// ErrInodeQuotaExceeded is returned by CreateEntry when a parent directory has reached its inode quota
+var ErrInodeQuotaExceeded = errors.New("inode quota exceeded")
+
 func IsEmpty(event *SubscribeMetadataResponse) bool {
 	return event.EventNotification.NewEntry == nil && event.EventNotification.OldEntry == nil
 }
@@ -3,12 +3,9 @@ package pb
 import (
 	"context"
 	"fmt"
-	"google.golang.org/grpc/metadata"
-	"math/rand"
 	"net/http"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
@@ -27,18 +24,6 @@ const (
 	Max_Message_Size = 1 << 30 // 1 GB
 )
 
-var (
-	// cache grpc connections
-	grpcClients     = make(map[string]*versionedGrpcClient)
-	grpcClientsLock sync.Mutex
-)
-
-type versionedGrpcClient struct {
-	*grpc.ClientConn
-	version  int
-	errCount int
-}
-
 func init() {
 	http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost = 1024
 	http.DefaultTransport.(*http.Transport).MaxIdleConns = 1024
@@ -59,6 +44,8 @@ func NewGrpcServer(opts ...grpc.ServerOption) *grpc.Server {
 		grpc.MaxRecvMsgSize(Max_Message_Size),
 		grpc.MaxSendMsgSize(Max_Message_Size),
 	)
+	options = append(options, tracingServerOptions()...)
+	options = append(options, grpc.StatsHandler(&grpcServerStatsHandler{}))
 	for _, opt := range opts {
 		if opt != nil {
 			options = append(options, opt)
@@ -83,7 +70,10 @@ func GrpcDial(ctx context.Context, address string, waitForReady bool, opts ...gr
 			Time:                30 * time.Second, // client ping server if no activity for this long
 			Timeout:             20 * time.Second,
 			PermitWithoutStream: true,
-		}))
+		}),
+		grpc.WithChainUnaryInterceptor(tracingUnaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(tracingStreamClientInterceptor),
+	)
 	for _, opt := range opts {
 		if opt != nil {
 			options = append(options, opt)
@@ -92,72 +82,25 @@ func GrpcDial(ctx context.Context, address string, waitForReady bool, opts ...gr
 	return grpc.DialContext(ctx, address, options...)
 }
 
-func getOrCreateConnection(address string, waitForReady bool, opts ...grpc.DialOption) (*versionedGrpcClient, error) {
-
-	grpcClientsLock.Lock()
-	defer grpcClientsLock.Unlock()
-
-	existingConnection, found := grpcClients[address]
-	if found {
-		return existingConnection, nil
-	}
+// WithGrpcClient runs fn with a connection checked out from the pool of the address.
+// In streamingMode, the call can last long, and is counted on its connection until it returns.
+func WithGrpcClient(streamingMode bool, signature int32, fn func(*grpc.ClientConn) error, address string, waitForReady bool, opts ...grpc.DialOption) error {
 
-	ctx := context.Background()
-	grpcConnection, err := GrpcDial(ctx, address, waitForReady, opts...)
+	pool := getGrpcConnectionPool(address, waitForReady, opts...)
+	grpcConnection, release, err := pool.AcquireConnection(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("fail to dial %s: %v", address, err)
+		return fmt.Errorf("acquire grpc connection %s: %v", address, err)
 	}
+	defer release()
 
-	vgc := &versionedGrpcClient{
-		grpcConnection,
-		rand.Int(),
-		0,
-	}
-	grpcClients[address] = vgc
-
-	return vgc, nil
-}
-
-// WithGrpcClient In streamingMode, always use a fresh connection. Otherwise, try to reuse an existing connection.
-func WithGrpcClient(streamingMode bool, signature int32, fn func(*grpc.ClientConn) error, address string, waitForReady bool, opts ...grpc.DialOption) error {
-
-	if !streamingMode {
-		vgc, err := getOrCreateConnection(address, waitForReady, opts...)
-		if err != nil {
-			return fmt.Errorf("getOrCreateConnection %s: %v", address, err)
-		}
-		executionErr := fn(vgc.ClientConn)
-		if executionErr != nil {
-			if strings.Contains(executionErr.Error(), "transport") ||
-				strings.Contains(executionErr.Error(), "connection closed") {
-				grpcClientsLock.Lock()
-				if t, ok := grpcClients[address]; ok {
-					if t.version == vgc.version {
-						vgc.Close()
-						delete(grpcClients, address)
-					}
-				}
-				grpcClientsLock.Unlock()
-			}
-		}
-		return executionErr
-	} else {
-		ctx := context.Background()
-		if signature != 0 {
-			md := metadata.New(map[string]string{"sw-client-id": fmt.Sprintf("%d", signature)})
-			ctx = metadata.NewOutgoingContext(ctx, md)
-		}
-		grpcConnection, err := GrpcDial(ctx, address, waitForReady, opts...)
-		if err != nil {
-			return fmt.Errorf("fail to dial %s: %v", address, err)
-		}
-		defer grpcConnection.Close()
-		executionErr := fn(grpcConnection)
-		if executionErr != nil {
-			return executionErr
+	executionErr := fn(grpcConnection)
+	if executionErr != nil {
+		if strings.Contains(executionErr.Error(), "transport") ||
+			strings.Contains(executionErr.Error(), "connection closed") {
+			pool.MarkBroken(grpcConnection)
 		}
-		return nil
 	}
+	return executionErr
 
 }
 
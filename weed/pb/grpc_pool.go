@@ -0,0 +1,160 @@
+package pb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	// DefaultGrpcPoolSize is the number of connections kept for each server address
+	DefaultGrpcPoolSize = 4
+)
+
+var (
+	grpcPools     = make(map[string]*GrpcConnectionPool)
+	grpcPoolsLock sync.Mutex
+)
+
+// GrpcConnectionPool keeps a few persistent connections to one server address.
+// Each call checks out the least used connection, and the broken connections are replaced.
+type GrpcConnectionPool struct {
+	address      string
+	size         int
+	waitForReady bool
+	opts         []grpc.DialOption
+	sync.Mutex
+	connections []*pooledConnection
+}
+
+type pooledConnection struct {
+	*grpc.ClientConn
+	inUse  int
+	broken bool
+}
+
+func getGrpcConnectionPool(address string, waitForReady bool, opts ...grpc.DialOption) *GrpcConnectionPool {
+	grpcPoolsLock.Lock()
+	defer grpcPoolsLock.Unlock()
+
+	pool, found := grpcPools[address]
+	if !found {
+		v := util.GetViper()
+		v.SetDefault("grpc.client.pool_size", DefaultGrpcPoolSize)
+		pool = NewGrpcConnectionPool(address, v.GetInt("grpc.client.pool_size"), waitForReady, opts...)
+		grpcPools[address] = pool
+	}
+	return pool
+}
+
+func NewGrpcConnectionPool(address string, size int, waitForReady bool, opts ...grpc.DialOption) *GrpcConnectionPool {
+	if size <= 0 {
+		size = DefaultGrpcPoolSize
+	}
+	return &GrpcConnectionPool{
+		address:      address,
+		size:         size,
+		waitForReady: waitForReady,
+		opts:         opts,
+	}
+}
+
+// AcquireConnection checks out a connection. The release function must be called after the use.
+func (p *GrpcConnectionPool) AcquireConnection(ctx context.Context) (*grpc.ClientConn, func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.removeUnhealthy()
+
+	var chosen *pooledConnection
+	for _, c := range p.connections {
+		if chosen == nil || c.inUse < chosen.inUse {
+			chosen = c
+		}
+	}
+	if len(p.connections) < p.size && (chosen == nil || chosen.inUse > 0) {
+		conn, err := GrpcDial(ctx, p.address, p.waitForReady, p.opts...)
+		if err != nil {
+			if chosen == nil {
+				return nil, nil, fmt.Errorf("fail to dial %s: %v", p.address, err)
+			}
+			glog.V(1).Infof("fail to dial %s: %v", p.address, err)
+		} else {
+			chosen = &pooledConnection{ClientConn: conn}
+			p.connections = append(p.connections, chosen)
+		}
+	}
+
+	chosen.inUse++
+	var releaseOnce sync.Once
+	return chosen.ClientConn, func() {
+		releaseOnce.Do(func() {
+			p.release(chosen)
+		})
+	}, nil
+}
+
+// MarkBroken removes the connection from the pool, to be replaced on the next checkout.
+// It is closed after all its current calls are released.
+func (p *GrpcConnectionPool) MarkBroken(conn *grpc.ClientConn) {
+	p.Lock()
+	defer p.Unlock()
+	for i, c := range p.connections {
+		if c.ClientConn == conn {
+			c.broken = true
+			p.connections = append(p.connections[:i], p.connections[i+1:]...)
+			if c.inUse == 0 {
+				c.Close()
+			}
+			return
+		}
+	}
+}
+
+func (p *GrpcConnectionPool) release(c *pooledConnection) {
+	p.Lock()
+	defer p.Unlock()
+	c.inUse--
+	if c.broken && c.inUse == 0 {
+		c.Close()
+	}
+}
+
+// removeUnhealthy drops the connections shut down or failing to connect.
+// The idle and connecting ones are kept, since grpc reconnects them on the next call.
+func (p *GrpcConnectionPool) removeUnhealthy() {
+	healthy := p.connections[:0]
+	for _, c := range p.connections {
+		switch c.GetState() {
+		case connectivity.Shutdown, connectivity.TransientFailure:
+			glog.V(1).Infof("remove %v grpc connection to %s", c.GetState(), p.address)
+			c.broken = true
+			if c.inUse == 0 {
+				c.Close()
+			}
+		default:
+			healthy = append(healthy, c)
+		}
+	}
+	for i := len(healthy); i < len(p.connections); i++ {
+		p.connections[i] = nil
+	}
+	p.connections = healthy
+}
+
+// Size is the number of connections currently in the pool.
+func (p *GrpcConnectionPool) Size() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.connections)
+}
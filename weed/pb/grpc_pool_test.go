@@ -0,0 +1,56 @@
+package pb
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestGrpcConnectionPool(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	assert.Nil(t, err)
+	server := grpc.NewServer()
+	go server.Serve(listener)
+	defer server.Stop()
+
+	pool := NewGrpcConnectionPool(listener.Addr().String(), 2, false, grpc.WithTransportCredentials(insecure.NewCredentials()))
+
+	// an idle connection is reused
+	conn1, release1, err := pool.AcquireConnection(context.Background())
+	assert.Nil(t, err)
+	release1()
+	conn2, release2, err := pool.AcquireConnection(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, conn1, conn2)
+	assert.Equal(t, 1, pool.Size())
+
+	// a busy connection makes the pool grow, up to the size
+	conn3, release3, err := pool.AcquireConnection(context.Background())
+	assert.Nil(t, err)
+	assert.NotEqual(t, conn2, conn3)
+	conn4, release4, err := pool.AcquireConnection(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, pool.Size())
+	assert.Contains(t, []*grpc.ClientConn{conn2, conn3}, conn4)
+
+	// a broken connection is replaced
+	pool.MarkBroken(conn3)
+	assert.Equal(t, 1, pool.Size())
+	release2()
+	release3()
+	release4()
+	release4()
+	conn5, release5, err := pool.AcquireConnection(context.Background())
+	assert.Nil(t, err)
+	assert.NotEqual(t, conn3, conn5)
+	release5()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = pool.AcquireConnection(ctx)
+	assert.NotNil(t, err)
+}
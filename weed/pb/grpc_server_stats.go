@@ -0,0 +1,38 @@
+package pb
+
+import (
+	"context"
+
+	grpcstats "google.golang.org/grpc/stats"
+
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+)
+
+// grpcServerStatsHandler counts the open server connections, and measures the time of each rpc by method.
+type grpcServerStatsHandler struct{}
+
+type grpcMethodKey struct{}
+
+func (h *grpcServerStatsHandler) TagRPC(ctx context.Context, info *grpcstats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, grpcMethodKey{}, info.FullMethodName)
+}
+
+func (h *grpcServerStatsHandler) HandleRPC(ctx context.Context, s grpcstats.RPCStats) {
+	if end, ok := s.(*grpcstats.End); ok {
+		method, _ := ctx.Value(grpcMethodKey{}).(string)
+		stats.GrpcServerRequestHistogram.WithLabelValues(method).Observe(end.EndTime.Sub(end.BeginTime).Seconds())
+	}
+}
+
+func (h *grpcServerStatsHandler) TagConn(ctx context.Context, info *grpcstats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *grpcServerStatsHandler) HandleConn(ctx context.Context, s grpcstats.ConnStats) {
+	switch s.(type) {
+	case *grpcstats.ConnBegin:
+		stats.GrpcServerConnectionsGauge.Inc()
+	case *grpcstats.ConnEnd:
+		stats.GrpcServerConnectionsGauge.Dec()
+	}
+}
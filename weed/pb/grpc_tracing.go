@@ -0,0 +1,173 @@
+package pb
+
+import (
+	"context"
+	"path"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/util/grace"
+)
+
+const tracerName = "github.com/seaweedfs/seaweedfs/weed/pb"
+
+// tracedMethods are the rpc calls that get their own client span. All other calls
+// still propagate the trace context, so that server spans are linked to the caller.
+var tracedMethods = map[string]bool{
+	"CreateEntry":          true,
+	"LookupDirectoryEntry": true,
+	"ListEntries":          true,
+	"DeleteEntry":          true,
+	"AssignVolume":         true,
+}
+
+// LoadTracing sets up the global tracer provider from the [tracing] section in weed.toml.
+// Without an endpoint, tracing is a no-op.
+func LoadTracing(config *util.ViperProxy, serviceName string) {
+	config.SetDefault("tracing.sampling_rate", 0.01)
+	config.SetDefault("tracing.insecure", true)
+	endpoint := config.GetString("tracing.endpoint")
+	if !config.GetBool("tracing.enabled") || endpoint == "" {
+		return
+	}
+
+	options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if config.GetBool("tracing.insecure") {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(context.Background(), options...)
+	if err != nil {
+		glog.Errorf("create otlp trace exporter to %s: %v", endpoint, err)
+		return
+	}
+
+	samplingRate := config.GetFloat64("tracing.sampling_rate")
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRate))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion(util.VERSION),
+		)),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	grace.OnInterrupt(func() {
+		tracerProvider.Shutdown(context.Background())
+	})
+	glog.V(0).Infof("tracing %s to %s, sampling rate %v", serviceName, endpoint, samplingRate)
+}
+
+// metadataCarrier adapts gRPC metadata to the otel text map carrier.
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	if values := metadata.MD(mc).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (mc metadataCarrier) Set(key, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for k := range mc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func injectTraceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func extractTraceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+func startRpcSpan(ctx context.Context, fullMethod string, kind trace.SpanKind) (context.Context, trace.Span) {
+	service, method := path.Split(fullMethod)
+	return otel.Tracer(tracerName).Start(ctx, path.Base(service)+"/"+method,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("grpc"),
+			semconv.RPCService(path.Base(service)),
+			semconv.RPCMethod(method),
+		))
+}
+
+func endRpcSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func tracingUnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !tracedMethods[path.Base(method)] {
+		return invoker(injectTraceContext(ctx), method, req, reply, cc, opts...)
+	}
+	ctx, span := startRpcSpan(ctx, method, trace.SpanKindClient)
+	span.SetAttributes(attribute.String("net.peer.name", cc.Target()))
+	err := invoker(injectTraceContext(ctx), method, req, reply, cc, opts...)
+	endRpcSpan(span, err)
+	return err
+}
+
+func tracingStreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(injectTraceContext(ctx), desc, cc, method, opts...)
+}
+
+// tracingServerOptions continue the trace started by the caller, e.g., from "weed mount", with a server span.
+func tracingServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, span := startRpcSpan(extractTraceContext(ctx), info.FullMethod, trace.SpanKindServer)
+			resp, err := handler(ctx, req)
+			endRpcSpan(span, err)
+			return resp, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, span := startRpcSpan(extractTraceContext(ss.Context()), info.FullMethod, trace.SpanKindServer)
+			err := handler(srv, &tracedServerStream{ServerStream: ss, ctx: ctx})
+			endRpcSpan(span, err)
+			return err
+		}),
+	}
+}
+
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
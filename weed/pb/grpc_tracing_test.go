@@ -0,0 +1,37 @@
+package pb
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTraceContextPropagation(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	clientCtx := trace.ContextWithSpanContext(context.Background(), spanContext)
+	clientCtx = metadata.AppendToOutgoingContext(clientCtx, "sw-client-id", "7")
+
+	outgoing, _ := metadata.FromOutgoingContext(injectTraceContext(clientCtx))
+	if len(outgoing.Get("traceparent")) != 1 {
+		t.Fatalf("traceparent not injected: %v", outgoing)
+	}
+	if len(outgoing.Get("sw-client-id")) != 1 {
+		t.Fatalf("existing metadata is lost: %v", outgoing)
+	}
+
+	serverCtx := extractTraceContext(metadata.NewIncomingContext(context.Background(), outgoing))
+	remote := trace.SpanContextFromContext(serverCtx)
+	if remote.TraceID() != spanContext.TraceID() || remote.SpanID() != spanContext.SpanID() || !remote.IsRemote() {
+		t.Errorf("extracted %+v, want the client span %+v", remote, spanContext)
+	}
+}
@@ -0,0 +1,230 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+)
+
+const (
+	contentTypeGrpcWeb     = "application/grpc-web"
+	contentTypeGrpcWebText = "application/grpc-web-text"
+
+	frameHeaderSize = 5
+	// the flag of the frame with the trailers, after the data frames
+	trailerFrameFlag = 0x80
+)
+
+// Handler translates the gRPC-Web requests from browsers into calls to the gRPC server behind the connection.
+// The messages are forwarded without decoding. Only the unary and server streaming methods are supported,
+// since the browsers can not stream the requests.
+type Handler struct {
+	conn *grpc.ClientConn
+	// full method name, e.g., /filer_pb.SeaweedFiler/ListEntries, to whether the method is server streaming
+	methods map[string]bool
+	// the origins of the browser pages allowed to call, e.g., https://app.example.com
+	allowedOrigins map[string]bool
+	// authorize checks the caller, e.g., its ip and jwt, before the call
+	authorize      func(r *http.Request) error
+	maxMessageSize int
+}
+
+func NewHandler(conn *grpc.ClientConn, methods map[string]bool, allowedOrigins []string, authorize func(r *http.Request) error) *Handler {
+	h := &Handler{
+		conn:           conn,
+		methods:        methods,
+		allowedOrigins: make(map[string]bool),
+		authorize:      authorize,
+		maxMessageSize: pb.Max_Message_Size,
+	}
+	for _, origin := range allowedOrigins {
+		h.allowedOrigins[strings.TrimSuffix(origin, "/")] = true
+	}
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		w.Header().Add("Vary", "Origin")
+		if !h.allowedOrigins[origin] {
+			http.Error(w, fmt.Sprintf("origin %q is not allowed", origin), http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Expose-Headers", "grpc-status, grpc-message")
+	}
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "authorization, content-type, x-grpc-web, x-user-agent, grpc-timeout")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if h.authorize != nil {
+		if err := h.authorize(r); err != nil {
+			glog.V(1).Infof("grpc-web %s from %s: %v", r.URL.Path, r.RemoteAddr, err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	isText := strings.HasPrefix(contentType, contentTypeGrpcWebText)
+	if !isText && !strings.HasPrefix(contentType, contentTypeGrpcWeb) {
+		http.Error(w, fmt.Sprintf("unsupported content type %q", contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	isServerStreaming, found := h.methods[r.URL.Path]
+	if !found {
+		h.writeResponse(w, isText, nil, status.Errorf(codes.Unimplemented, "method %s is not exposed", r.URL.Path))
+		return
+	}
+
+	request, err := readRequestMessage(r.Body, isText, h.maxMessageSize)
+	if err == errMessageTooLarge {
+		h.writeResponse(w, isText, nil, status.Errorf(codes.ResourceExhausted, "message larger than max %d", h.maxMessageSize))
+		return
+	}
+	if err != nil {
+		h.writeResponse(w, isText, nil, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+
+	if !isServerStreaming {
+		var response []byte
+		err = h.conn.Invoke(r.Context(), r.URL.Path, &request, &response, grpc.ForceCodec(rawCodec{}))
+		h.writeResponse(w, isText, [][]byte{response}, err)
+		return
+	}
+
+	stream, err := h.conn.NewStream(r.Context(), &grpc.StreamDesc{ServerStreams: true}, r.URL.Path, grpc.ForceCodec(rawCodec{}))
+	if err == nil {
+		err = stream.SendMsg(&request)
+	}
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	if err != nil {
+		h.writeResponse(w, isText, nil, err)
+		return
+	}
+	w.Header().Set("Content-Type", responseContentType(isText))
+	w.WriteHeader(http.StatusOK)
+	for {
+		var response []byte
+		if err = stream.RecvMsg(&response); err != nil {
+			break
+		}
+		if err = writeFrame(w, isText, 0, response); err != nil {
+			glog.V(1).Infof("grpc-web %s: %v", r.URL.Path, err)
+			return
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	writeTrailers(w, isText, err)
+}
+
+func (h *Handler) writeResponse(w http.ResponseWriter, isText bool, messages [][]byte, err error) {
+	w.Header().Set("Content-Type", responseContentType(isText))
+	w.WriteHeader(http.StatusOK)
+	if err == nil {
+		for _, message := range messages {
+			if writeErr := writeFrame(w, isText, 0, message); writeErr != nil {
+				return
+			}
+		}
+	}
+	writeTrailers(w, isText, err)
+}
+
+func responseContentType(isText bool) string {
+	if isText {
+		return contentTypeGrpcWebText + "+proto"
+	}
+	return contentTypeGrpcWeb + "+proto"
+}
+
+var errMessageTooLarge = errors.New("message too large")
+
+// readRequestMessage reads the one message of the request, refusing the messages longer than maxSize
+func readRequestMessage(body io.Reader, isText bool, maxSize int) ([]byte, error) {
+	if isText {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	header := make([]byte, frameHeaderSize)
+	if _, err := io.ReadFull(body, header); err != nil {
+		return nil, fmt.Errorf("read frame header: %v", err)
+	}
+	if header[0] != 0 {
+		return nil, fmt.Errorf("unsupported frame flag %x", header[0])
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if int64(size) > int64(maxSize) {
+		return nil, errMessageTooLarge
+	}
+	message := make([]byte, size)
+	if _, err := io.ReadFull(body, message); err != nil {
+		return nil, fmt.Errorf("read message: %v", err)
+	}
+	return message, nil
+}
+
+func writeFrame(w io.Writer, isText bool, flag byte, data []byte) error {
+	frame := make([]byte, frameHeaderSize+len(data))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+	copy(frame[frameHeaderSize:], data)
+	if isText {
+		frame = []byte(base64.StdEncoding.EncodeToString(frame))
+	}
+	_, err := w.Write(frame)
+	return err
+}
+
+func writeTrailers(w io.Writer, isText bool, err error) {
+	st := status.Convert(err)
+	var trailers bytes.Buffer
+	fmt.Fprintf(&trailers, "grpc-status: %d\r\n", st.Code())
+	if st.Message() != "" {
+		fmt.Fprintf(&trailers, "grpc-message: %s\r\n", url.PathEscape(st.Message()))
+	}
+	writeFrame(w, isText, trailerFrameFlag, trailers.Bytes())
+}
+
+// rawCodec passes the encoded messages through
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return *(v.(*[]byte)), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*(v.(*[]byte)) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
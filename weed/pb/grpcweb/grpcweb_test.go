@@ -0,0 +1,117 @@
+package grpcweb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFrames(t *testing.T) {
+	for _, isText := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := writeFrame(&buf, isText, 0, []byte("message")); err != nil {
+			t.Fatalf("write frame: %v", err)
+		}
+		message, err := readRequestMessage(&buf, isText, 1024)
+		if err != nil {
+			t.Fatalf("read frame: %v", err)
+		}
+		if string(message) != "message" {
+			t.Errorf("text %v: read %q", isText, message)
+		}
+	}
+}
+
+func TestTrailers(t *testing.T) {
+	var buf bytes.Buffer
+	writeTrailers(&buf, false, status.Error(codes.NotFound, "no such entry"))
+	frame := buf.Bytes()
+	if frame[0] != trailerFrameFlag {
+		t.Errorf("trailer frame flag %x", frame[0])
+	}
+	trailers := string(frame[frameHeaderSize:])
+	if !strings.Contains(trailers, "grpc-status: 5\r\n") || !strings.Contains(trailers, "grpc-message: no%20such%20entry\r\n") {
+		t.Errorf("trailers %q", trailers)
+	}
+}
+
+func TestMethodNotExposed(t *testing.T) {
+	h := NewHandler(nil, map[string]bool{}, nil, nil)
+	var body bytes.Buffer
+	writeFrame(&body, true, 0, nil)
+	r := httptest.NewRequest(http.MethodPost, "/filer_pb.SeaweedFiler/DeleteEntry", &body)
+	r.Header.Set("Content-Type", contentTypeGrpcWebText)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	frame, err := base64.StdEncoding.DecodeString(w.Body.String())
+	if err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !strings.Contains(string(frame), "grpc-status: 12\r\n") {
+		t.Errorf("response %q", frame)
+	}
+}
+
+func TestMessageTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	writeFrame(&buf, false, 0, make([]byte, 1025))
+	if _, err := readRequestMessage(&buf, false, 1024); err != errMessageTooLarge {
+		t.Errorf("read a message over the max: %v", err)
+	}
+
+	// only the header is sent, with a 4GB length
+	h := NewHandler(nil, map[string]bool{"/filer_pb.SeaweedFiler/ListEntries": true}, nil, nil)
+	r := httptest.NewRequest(http.MethodPost, "/filer_pb.SeaweedFiler/ListEntries", bytes.NewReader([]byte{0, 0xff, 0xff, 0xff, 0xff}))
+	r.Header.Set("Content-Type", contentTypeGrpcWeb)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if !strings.Contains(w.Body.String(), "grpc-status: 8\r\n") {
+		t.Errorf("response %q", w.Body.String())
+	}
+}
+
+func TestOriginAndAuthorization(t *testing.T) {
+	h := NewHandler(nil, map[string]bool{}, []string{"https://app.example.com/"}, func(r *http.Request) error {
+		if r.Header.Get("Authorization") == "" {
+			return errors.New("missing jwt")
+		}
+		return nil
+	})
+	call := func(method, origin, authorization string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writeFrame(&body, false, 0, nil)
+		r := httptest.NewRequest(method, "/filer_pb.SeaweedFiler/DeleteEntry", &body)
+		r.Header.Set("Content-Type", contentTypeGrpcWeb)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := call(http.MethodPost, "https://evil.example.com", "Bearer x"); w.Code != http.StatusForbidden || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("other origin: %d %v", w.Code, w.Header())
+	}
+	if w := call(http.MethodOptions, "https://app.example.com", ""); w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Errorf("preflight: %d %v", w.Code, w.Header())
+	}
+	if w := call(http.MethodPost, "https://app.example.com", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("without jwt: %d", w.Code)
+	}
+	// authorized, and the method is not exposed
+	if w := call(http.MethodPost, "https://app.example.com", "Bearer x"); !strings.Contains(w.Body.String(), "grpc-status: 12\r\n") {
+		t.Errorf("authorized: %d %q", w.Code, w.Body.String())
+	}
+}
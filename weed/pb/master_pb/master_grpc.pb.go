@@ -29,6 +29,10 @@ type SeaweedClient interface {
 	Statistics(ctx context.Context, in *StatisticsRequest, opts ...grpc.CallOption) (*StatisticsResponse, error)
 	CollectionList(ctx context.Context, in *CollectionListRequest, opts ...grpc.CallOption) (*CollectionListResponse, error)
 	CollectionDelete(ctx context.Context, in *CollectionDeleteRequest, opts ...grpc.CallOption) (*CollectionDeleteResponse, error)
+	CollectionGCConfigSet(ctx context.Context, in *CollectionGCConfigSetRequest, opts ...grpc.CallOption) (*CollectionGCConfigSetResponse, error)
+	CollectionGCConfigGet(ctx context.Context, in *CollectionGCConfigGetRequest, opts ...grpc.CallOption) (*CollectionGCConfigGetResponse, error)
+	CollectionEcConfigSet(ctx context.Context, in *CollectionEcConfigSetRequest, opts ...grpc.CallOption) (*CollectionEcConfigSetResponse, error)
+	CollectionEcConfigGet(ctx context.Context, in *CollectionEcConfigGetRequest, opts ...grpc.CallOption) (*CollectionEcConfigGetResponse, error)
 	VolumeList(ctx context.Context, in *VolumeListRequest, opts ...grpc.CallOption) (*VolumeListResponse, error)
 	LookupEcVolume(ctx context.Context, in *LookupEcVolumeRequest, opts ...grpc.CallOption) (*LookupEcVolumeResponse, error)
 	VacuumVolume(ctx context.Context, in *VacuumVolumeRequest, opts ...grpc.CallOption) (*VacuumVolumeResponse, error)
@@ -160,6 +164,42 @@ func (c *seaweedClient) CollectionDelete(ctx context.Context, in *CollectionDele
 	return out, nil
 }
 
+func (c *seaweedClient) CollectionGCConfigSet(ctx context.Context, in *CollectionGCConfigSetRequest, opts ...grpc.CallOption) (*CollectionGCConfigSetResponse, error) {
+	out := new(CollectionGCConfigSetResponse)
+	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/CollectionGCConfigSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedClient) CollectionGCConfigGet(ctx context.Context, in *CollectionGCConfigGetRequest, opts ...grpc.CallOption) (*CollectionGCConfigGetResponse, error) {
+	out := new(CollectionGCConfigGetResponse)
+	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/CollectionGCConfigGet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedClient) CollectionEcConfigSet(ctx context.Context, in *CollectionEcConfigSetRequest, opts ...grpc.CallOption) (*CollectionEcConfigSetResponse, error) {
+	out := new(CollectionEcConfigSetResponse)
+	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/CollectionEcConfigSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *seaweedClient) CollectionEcConfigGet(ctx context.Context, in *CollectionEcConfigGetRequest, opts ...grpc.CallOption) (*CollectionEcConfigGetResponse, error) {
+	out := new(CollectionEcConfigGetResponse)
+	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/CollectionEcConfigGet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *seaweedClient) VolumeList(ctx context.Context, in *VolumeListRequest, opts ...grpc.CallOption) (*VolumeListResponse, error) {
 	out := new(VolumeListResponse)
 	err := c.cc.Invoke(ctx, "/master_pb.Seaweed/VolumeList", in, out, opts...)
@@ -297,6 +337,10 @@ type SeaweedServer interface {
 	Statistics(context.Context, *StatisticsRequest) (*StatisticsResponse, error)
 	CollectionList(context.Context, *CollectionListRequest) (*CollectionListResponse, error)
 	CollectionDelete(context.Context, *CollectionDeleteRequest) (*CollectionDeleteResponse, error)
+	CollectionGCConfigSet(context.Context, *CollectionGCConfigSetRequest) (*CollectionGCConfigSetResponse, error)
+	CollectionGCConfigGet(context.Context, *CollectionGCConfigGetRequest) (*CollectionGCConfigGetResponse, error)
+	CollectionEcConfigSet(context.Context, *CollectionEcConfigSetRequest) (*CollectionEcConfigSetResponse, error)
+	CollectionEcConfigGet(context.Context, *CollectionEcConfigGetRequest) (*CollectionEcConfigGetResponse, error)
 	VolumeList(context.Context, *VolumeListRequest) (*VolumeListResponse, error)
 	LookupEcVolume(context.Context, *LookupEcVolumeRequest) (*LookupEcVolumeResponse, error)
 	VacuumVolume(context.Context, *VacuumVolumeRequest) (*VacuumVolumeResponse, error)
@@ -339,6 +383,18 @@ func (UnimplementedSeaweedServer) CollectionList(context.Context, *CollectionLis
 func (UnimplementedSeaweedServer) CollectionDelete(context.Context, *CollectionDeleteRequest) (*CollectionDeleteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CollectionDelete not implemented")
 }
+func (UnimplementedSeaweedServer) CollectionGCConfigSet(context.Context, *CollectionGCConfigSetRequest) (*CollectionGCConfigSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CollectionGCConfigSet not implemented")
+}
+func (UnimplementedSeaweedServer) CollectionGCConfigGet(context.Context, *CollectionGCConfigGetRequest) (*CollectionGCConfigGetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CollectionGCConfigGet not implemented")
+}
+func (UnimplementedSeaweedServer) CollectionEcConfigSet(context.Context, *CollectionEcConfigSetRequest) (*CollectionEcConfigSetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CollectionEcConfigSet not implemented")
+}
+func (UnimplementedSeaweedServer) CollectionEcConfigGet(context.Context, *CollectionEcConfigGetRequest) (*CollectionEcConfigGetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CollectionEcConfigGet not implemented")
+}
 func (UnimplementedSeaweedServer) VolumeList(context.Context, *VolumeListRequest) (*VolumeListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VolumeList not implemented")
 }
@@ -536,6 +592,78 @@ func _Seaweed_CollectionDelete_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Seaweed_CollectionGCConfigSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectionGCConfigSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedServer).CollectionGCConfigSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/master_pb.Seaweed/CollectionGCConfigSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedServer).CollectionGCConfigSet(ctx, req.(*CollectionGCConfigSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Seaweed_CollectionGCConfigGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectionGCConfigGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedServer).CollectionGCConfigGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/master_pb.Seaweed/CollectionGCConfigGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedServer).CollectionGCConfigGet(ctx, req.(*CollectionGCConfigGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Seaweed_CollectionEcConfigSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectionEcConfigSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedServer).CollectionEcConfigSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/master_pb.Seaweed/CollectionEcConfigSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedServer).CollectionEcConfigSet(ctx, req.(*CollectionEcConfigSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Seaweed_CollectionEcConfigGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CollectionEcConfigGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedServer).CollectionEcConfigGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/master_pb.Seaweed/CollectionEcConfigGet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedServer).CollectionEcConfigGet(ctx, req.(*CollectionEcConfigGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Seaweed_VolumeList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(VolumeListRequest)
 	if err := dec(in); err != nil {
@@ -815,6 +943,22 @@ var Seaweed_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CollectionDelete",
 			Handler:    _Seaweed_CollectionDelete_Handler,
 		},
+		{
+			MethodName: "CollectionGCConfigSet",
+			Handler:    _Seaweed_CollectionGCConfigSet_Handler,
+		},
+		{
+			MethodName: "CollectionGCConfigGet",
+			Handler:    _Seaweed_CollectionGCConfigGet_Handler,
+		},
+		{
+			MethodName: "CollectionEcConfigSet",
+			Handler:    _Seaweed_CollectionEcConfigSet_Handler,
+		},
+		{
+			MethodName: "CollectionEcConfigGet",
+			Handler:    _Seaweed_CollectionEcConfigGet_Handler,
+		},
 		{
 			MethodName: "VolumeList",
 			Handler:    _Seaweed_VolumeList_Handler,
@@ -23,6 +23,7 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type SeaweedMountClient interface {
 	Configure(ctx context.Context, in *ConfigureRequest, opts ...grpc.CallOption) (*ConfigureResponse, error)
+	ListActiveMounts(ctx context.Context, in *ListActiveMountsRequest, opts ...grpc.CallOption) (*ListActiveMountsResponse, error)
 }
 
 type seaweedMountClient struct {
@@ -42,11 +43,21 @@ func (c *seaweedMountClient) Configure(ctx context.Context, in *ConfigureRequest
 	return out, nil
 }
 
+func (c *seaweedMountClient) ListActiveMounts(ctx context.Context, in *ListActiveMountsRequest, opts ...grpc.CallOption) (*ListActiveMountsResponse, error) {
+	out := new(ListActiveMountsResponse)
+	err := c.cc.Invoke(ctx, "/messaging_pb.SeaweedMount/ListActiveMounts", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SeaweedMountServer is the server API for SeaweedMount service.
 // All implementations must embed UnimplementedSeaweedMountServer
 // for forward compatibility
 type SeaweedMountServer interface {
 	Configure(context.Context, *ConfigureRequest) (*ConfigureResponse, error)
+	ListActiveMounts(context.Context, *ListActiveMountsRequest) (*ListActiveMountsResponse, error)
 	mustEmbedUnimplementedSeaweedMountServer()
 }
 
@@ -57,6 +68,9 @@ type UnimplementedSeaweedMountServer struct {
 func (UnimplementedSeaweedMountServer) Configure(context.Context, *ConfigureRequest) (*ConfigureResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Configure not implemented")
 }
+func (UnimplementedSeaweedMountServer) ListActiveMounts(context.Context, *ListActiveMountsRequest) (*ListActiveMountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListActiveMounts not implemented")
+}
 func (UnimplementedSeaweedMountServer) mustEmbedUnimplementedSeaweedMountServer() {}
 
 // UnsafeSeaweedMountServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +102,24 @@ func _SeaweedMount_Configure_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SeaweedMount_ListActiveMounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListActiveMountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SeaweedMountServer).ListActiveMounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/messaging_pb.SeaweedMount/ListActiveMounts",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SeaweedMountServer).ListActiveMounts(ctx, req.(*ListActiveMountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // SeaweedMount_ServiceDesc is the grpc.ServiceDesc for SeaweedMount service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,6 +131,10 @@ var SeaweedMount_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Configure",
 			Handler:    _SeaweedMount_Configure_Handler,
 		},
+		{
+			MethodName: "ListActiveMounts",
+			Handler:    _SeaweedMount_ListActiveMounts_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "mount.proto",
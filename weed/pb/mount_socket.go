@@ -0,0 +1,72 @@
+package pb
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/resolver/passthrough"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/mount_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const mountSocketPattern = "/tmp/seaweedfs-mount-*.sock"
+
+// LocalMountSocket is the default unix socket of the mount on the directory.
+func LocalMountSocket(mountDir string) string {
+	mountDirHash := util.HashToInt32([]byte(mountDir))
+	if mountDirHash < 0 {
+		mountDirHash = -mountDirHash
+	}
+	return fmt.Sprintf("/tmp/seaweedfs-mount-%d.sock", mountDirHash)
+}
+
+func WithMountSocketClient(localSocket string, fn func(client mount_pb.SeaweedMountClient) error) error {
+	clientConn, err := grpc.Dial("passthrough:///unix://"+localSocket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer clientConn.Close()
+	return fn(mount_pb.NewSeaweedMountClient(clientConn))
+}
+
+// ListMountsOnSocket asks the mount listening on the socket, and fails if no mount is serving it.
+func ListMountsOnSocket(localSocket string, timeout time.Duration) (mounts []*mount_pb.MountInfo, err error) {
+	err = WithMountSocketClient(localSocket, func(client mount_pb.SeaweedMountClient) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		resp, err := client.ListActiveMounts(ctx, &mount_pb.ListActiveMountsRequest{}, grpc.WaitForReady(false))
+		if err != nil {
+			return err
+		}
+		for _, m := range resp.Mounts {
+			if m.LocalSocket == "" {
+				m.LocalSocket = localSocket
+			}
+		}
+		mounts = resp.Mounts
+		return nil
+	})
+	return
+}
+
+// ListLocalMounts asks each default mount socket on this host. The stale sockets are skipped.
+// The mounts started with a custom -localSocket are not found.
+func ListLocalMounts(timeout time.Duration) (mounts []*mount_pb.MountInfo, err error) {
+	sockets, err := filepath.Glob(mountSocketPattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, localSocket := range sockets {
+		socketMounts, err := ListMountsOnSocket(localSocket, timeout)
+		if err != nil {
+			continue
+		}
+		mounts = append(mounts, socketMounts...)
+	}
+	return mounts, nil
+}
@@ -0,0 +1,79 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+// the instance metadata service of the azure vms issues the tokens of their managed identities
+const imdsTokenEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+
+type managedIdentityToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   string `json:"expires_in"`
+}
+
+// newManagedIdentityCredential authenticates with the managed identity of the vm, or the user assigned
+// identity of AZURE_CLIENT_ID, and refreshes the token before it expires.
+func newManagedIdentityCredential() (azblob.TokenCredential, error) {
+	clientId := os.Getenv("AZURE_CLIENT_ID")
+	token, expiresIn, err := fetchManagedIdentityToken(clientId)
+	if err != nil {
+		return nil, err
+	}
+	return azblob.NewTokenCredential(token, func(credential azblob.TokenCredential) time.Duration {
+		if token, expiresIn, err = fetchManagedIdentityToken(clientId); err != nil {
+			glog.Errorf("refresh azure managed identity token: %v", err)
+			return time.Minute
+		}
+		credential.SetToken(token)
+		return expiresIn * 4 / 5
+	}), nil
+}
+
+func fetchManagedIdentityToken(clientId string) (token string, expiresIn time.Duration, err error) {
+	query := url.Values{}
+	query.Set("api-version", "2018-02-01")
+	query.Set("resource", "https://storage.azure.com/")
+	if clientId != "" {
+		query.Set("client_id", clientId)
+	}
+	request, err := http.NewRequest(http.MethodGet, imdsTokenEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	request.Header.Set("Metadata", "true")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(request)
+	if err != nil {
+		return "", 0, fmt.Errorf("azure managed identity token: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("azure managed identity token: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("azure managed identity token: %s %s", resp.Status, string(body))
+	}
+
+	var t managedIdentityToken
+	if err = json.Unmarshal(body, &t); err != nil {
+		return "", 0, fmt.Errorf("parse azure managed identity token: %v", err)
+	}
+	seconds, err := strconv.Atoi(t.ExpiresIn)
+	if err != nil {
+		return "", 0, fmt.Errorf("parse azure managed identity token expiration %q: %v", t.ExpiresIn, err)
+	}
+	return t.AccessToken, time.Duration(seconds) * time.Second, nil
+}
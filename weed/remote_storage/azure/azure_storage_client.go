@@ -36,16 +36,27 @@ func (s azureRemoteStorageMaker) Make(conf *remote_pb.RemoteConf) (remote_storag
 
 	accountName, accountKey := conf.AzureAccountName, conf.AzureAccountKey
 	if len(accountName) == 0 || len(accountKey) == 0 {
-		accountName, accountKey = os.Getenv("AZURE_STORAGE_ACCOUNT"), os.Getenv("AZURE_STORAGE_ACCESS_KEY")
-		if len(accountName) == 0 || len(accountKey) == 0 {
-			return nil, fmt.Errorf("either AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_ACCESS_KEY environment variable is not set")
+		accountName, accountKey = util.Nvl(accountName, os.Getenv("AZURE_STORAGE_ACCOUNT")), os.Getenv("AZURE_STORAGE_ACCESS_KEY")
+		if len(accountName) == 0 {
+			return nil, fmt.Errorf("either azure account name or AZURE_STORAGE_ACCOUNT environment variable is not set")
 		}
 	}
 
-	// Use your Storage account's name and key to create a credential object.
-	credential, err := azblob.NewSharedKeyCredential(accountName, accountKey)
-	if err != nil {
-		return nil, fmt.Errorf("invalid Azure credential with account name:%s: %v", accountName, err)
+	var credential azblob.Credential
+	if len(accountKey) == 0 {
+		// without the account key, use the managed identity of the vm
+		tokenCredential, err := newManagedIdentityCredential()
+		if err != nil {
+			return nil, fmt.Errorf("no account key, and failed to use the managed identity for account name:%s: %v", accountName, err)
+		}
+		credential = tokenCredential
+	} else {
+		// Use your Storage account's name and key to create a credential object.
+		sharedKeyCredential, err := azblob.NewSharedKeyCredential(accountName, accountKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Azure credential with account name:%s: %v", accountName, err)
+		}
+		credential = sharedKeyCredential
 	}
 
 	// Create a request pipeline that is used to process HTTP(S) requests and responses.
@@ -107,7 +118,8 @@ func (az *azureRemoteStorageClient) ReadFile(loc *remote_pb.RemoteStorageLocatio
 
 	key := loc.Path[1:]
 	containerURL := az.serviceURL.NewContainerURL(loc.Bucket)
-	blobURL := containerURL.NewBlockBlobURL(key)
+	// any blob type, e.g., the page blobs, can be read
+	blobURL := containerURL.NewBlobURL(key)
 
 	downloadResponse, readErr := blobURL.Download(context.Background(), offset, size, azblob.BlobAccessConditions{}, false, azblob.ClientProvidedKeyOptions{})
 	if readErr != nil {
@@ -145,9 +157,13 @@ func (az *azureRemoteStorageClient) WriteFile(loc *remote_pb.RemoteStorageLocati
 		return nil, fmt.Errorf("unexpected reader: readerAt expected")
 	}
 	fileSize := int64(filer.FileSize(entry))
+	blockSize, err := blockSizeOf(fileSize)
+	if err != nil {
+		return nil, fmt.Errorf("azure upload to %s%s: %v", loc.Bucket, loc.Path, err)
+	}
 
 	_, err = uploadReaderAtToBlockBlob(context.Background(), readerAt, fileSize, blobURL, azblob.UploadToBlockBlobOptions{
-		BlockSize:       4 * 1024 * 1024,
+		BlockSize:       blockSize,
 		BlobHTTPHeaders: azblob.BlobHTTPHeaders{ContentType: entry.Attributes.Mime},
 		Metadata:        toMetadata(entry.Extended),
 		Parallelism:     16,
@@ -160,6 +176,26 @@ func (az *azureRemoteStorageClient) WriteFile(loc *remote_pb.RemoteStorageLocati
 	return az.readFileRemoteEntry(loc)
 }
 
+const (
+	azureMinBlockSize = 4 * 1024 * 1024
+	// the block size limit of the service versions before 2019-12-12
+	azureMaxBlockSize = 100 * 1024 * 1024
+	// about 4.77TB, under the 5TB limit of a blob
+	azureMaxBlobSize = azureMaxBlockSize * azblob.BlockBlobMaxBlocks
+)
+
+// blockSizeOf uploads the file in at most azblob.BlockBlobMaxBlocks blocks, each of at least 4MB
+func blockSizeOf(fileSize int64) (int64, error) {
+	if fileSize > azureMaxBlobSize {
+		return 0, fmt.Errorf("file size %d exceeds the azure block blob limit %d", fileSize, int64(azureMaxBlobSize))
+	}
+	blockSize := int64(azureMinBlockSize)
+	if blockSize*azblob.BlockBlobMaxBlocks < fileSize {
+		blockSize = (fileSize + azblob.BlockBlobMaxBlocks - 1) / azblob.BlockBlobMaxBlocks
+	}
+	return blockSize, nil
+}
+
 func (az *azureRemoteStorageClient) readFileRemoteEntry(loc *remote_pb.RemoteStorageLocation) (*filer_pb.RemoteEntry, error) {
 	key := loc.Path[1:]
 	containerURL := az.serviceURL.NewContainerURL(loc.Bucket)
@@ -7,8 +7,10 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"time"
 
 	"cloud.google.com/go/storage"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/remote_pb"
@@ -36,11 +38,7 @@ func (s gcsRemoteStorageMaker) Make(conf *remote_pb.RemoteConf) (remote_storage.
 	googleApplicationCredentials := conf.GcsGoogleApplicationCredentials
 
 	if googleApplicationCredentials == "" {
-		found := false
-		googleApplicationCredentials, found = os.LookupEnv("GOOGLE_APPLICATION_CREDENTIALS")
-		if !found {
-			return nil, fmt.Errorf("need to specific GOOGLE_APPLICATION_CREDENTIALS env variable")
-		}
+		googleApplicationCredentials, _ = os.LookupEnv("GOOGLE_APPLICATION_CREDENTIALS")
 	}
 
 	projectID := conf.GcsProjectId
@@ -52,9 +50,15 @@ func (s gcsRemoteStorageMaker) Make(conf *remote_pb.RemoteConf) (remote_storage.
 		}
 	}
 
-	googleApplicationCredentials = util.ResolvePath(googleApplicationCredentials)
+	var opts []option.ClientOption
+	if googleApplicationCredentials != "" {
+		opts = append(opts, option.WithCredentialsFile(util.ResolvePath(googleApplicationCredentials)))
+	} else {
+		// the application default credentials, e.g., the workload identity on GKE
+		glog.V(1).Infof("gcs %s uses the application default credentials", conf.Name)
+	}
 
-	c, err := storage.NewClient(context.Background(), option.WithCredentialsFile(googleApplicationCredentials))
+	c, err := storage.NewClient(context.Background(), opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %v", err)
 	}
@@ -135,6 +139,11 @@ func (gcs *gcsRemoteStorageClient) WriteFile(loc *remote_pb.RemoteStorageLocatio
 	metadata := toMetadata(entry.Extended)
 	wc := gcs.client.Bucket(loc.Bucket).Object(key).NewWriter(context.Background())
 	wc.Metadata = metadata
+	wc.StorageClass = gcs.storageClassOf(entry)
+	if filer.FileSize(entry) <= resumableUploadThreshold {
+		// upload in a single request, the resumable upload costs one more request
+		wc.ChunkSize = 0
+	}
 	if _, err = io.Copy(wc, reader); err != nil {
 		return nil, fmt.Errorf("upload to gcs %s/%s%s: %v", loc.Name, loc.Bucket, loc.Path, err)
 	}
@@ -164,6 +173,33 @@ func (gcs *gcsRemoteStorageClient) readFileRemoteEntry(loc *remote_pb.RemoteStor
 
 }
 
+const (
+	resumableUploadThreshold = 5 * 1024 * 1024
+	// the storage class of the file, in the extended attributes, e.g., NEARLINE
+	gcsStorageClassKey = "X-Goog-Storage-Class"
+)
+
+// storageClassOf returns the storage class set on the file, or, if gcs.storageClassByAge is set, the colder
+// classes for the files older than their minimum storage duration. It is empty for the bucket default.
+func (gcs *gcsRemoteStorageClient) storageClassOf(entry *filer_pb.Entry) string {
+	if storageClass, found := entry.Extended[gcsStorageClassKey]; found {
+		return strings.ToUpper(string(storageClass))
+	}
+	if !gcs.conf.GcsStorageClassByAge || entry.Attributes == nil {
+		return ""
+	}
+	age := time.Since(time.Unix(entry.Attributes.Mtime, 0))
+	switch {
+	case age >= 365*24*time.Hour:
+		return "ARCHIVE"
+	case age >= 90*24*time.Hour:
+		return "COLDLINE"
+	case age >= 30*24*time.Hour:
+		return "NEARLINE"
+	}
+	return ""
+}
+
 func toMetadata(attributes map[string][]byte) map[string]string {
 	metadata := make(map[string]string)
 	for k, v := range attributes {
@@ -190,6 +226,19 @@ func (gcs *gcsRemoteStorageClient) UpdateFileMetadata(loc *remote_pb.RemoteStora
 	} else {
 		// no way to delete the metadata yet
 	}
+	if err != nil {
+		return
+	}
+
+	// the storage class is changed by rewriting the object
+	if storageClass := gcs.storageClassOf(newEntry); storageClass != "" && storageClass != gcs.storageClassOf(oldEntry) {
+		object := gcs.client.Bucket(loc.Bucket).Object(key)
+		copier := object.CopierFrom(object)
+		copier.StorageClass = storageClass
+		if _, err = copier.Run(context.Background()); err != nil {
+			return fmt.Errorf("gcs set storage class of %s%s to %s: %v", loc.Bucket, key, storageClass, err)
+		}
+	}
 
 	return
 }
@@ -12,6 +12,9 @@ import (
 	"os"
 )
 
+// the bucket must have the transfer acceleration enabled
+const aliyunAccelerateEndpoint = "oss-accelerate.aliyuncs.com"
+
 func init() {
 	remote_storage.RemoteStorageClientMakers["aliyun"] = new(AliyunRemoteStorageMaker)
 }
@@ -30,8 +33,13 @@ func (s AliyunRemoteStorageMaker) Make(conf *remote_pb.RemoteConf) (remote_stora
 	accessKey := util.Nvl(conf.AliyunAccessKey, os.Getenv("ALICLOUD_ACCESS_KEY_ID"))
 	secretKey := util.Nvl(conf.AliyunSecretKey, os.Getenv("ALICLOUD_ACCESS_KEY_SECRET"))
 
+	endpoint := conf.AliyunEndpoint
+	if conf.AliyunTransferAcceleration {
+		endpoint = aliyunAccelerateEndpoint
+	}
+
 	config := &aws.Config{
-		Endpoint:                      aws.String(conf.AliyunEndpoint),
+		Endpoint:                      aws.String(endpoint),
 		Region:                        aws.String(conf.AliyunRegion),
 		S3ForcePathStyle:              aws.Bool(false),
 		S3DisableContentMD5Validation: aws.Bool(true),
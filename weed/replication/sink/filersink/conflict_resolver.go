@@ -0,0 +1,22 @@
+package filersink
+
+import (
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+// ConflictResolver decides whether a replicated entry overwrites the entry already on the target filer,
+// which may have been changed on the target since, e.g., with active-active filer.sync.
+type ConflictResolver interface {
+	ShouldOverwrite(existingEntry, newEntry *filer_pb.Entry) bool
+}
+
+// LastWriterWins keeps the entry with the later modification time.
+// The replicated entry wins a tie, so the updates within the same second are not lost.
+type LastWriterWins struct{}
+
+func (LastWriterWins) ShouldOverwrite(existingEntry, newEntry *filer_pb.Entry) bool {
+	if existingEntry.Attributes == nil || newEntry.Attributes == nil {
+		return true
+	}
+	return existingEntry.Attributes.Mtime <= newEntry.Attributes.Mtime
+}
@@ -34,6 +34,7 @@ type FilerSink struct {
 	isIncremental     bool
 	executor          *util.LimitedConcurrentExecutor
 	signature         int32
+	conflictResolver  ConflictResolver
 }
 
 func init() {
@@ -72,6 +73,18 @@ func (fs *FilerSink) SetSourceFiler(s *source.FilerSource) {
 	fs.filerSource = s
 }
 
+// SetConflictResolver replaces the default LastWriterWins
+func (fs *FilerSink) SetConflictResolver(resolver ConflictResolver) {
+	fs.conflictResolver = resolver
+}
+
+func (fs *FilerSink) shouldOverwrite(existingEntry, newEntry *filer_pb.Entry) bool {
+	if fs.conflictResolver == nil {
+		return LastWriterWins{}.ShouldOverwrite(existingEntry, newEntry)
+	}
+	return fs.conflictResolver.ShouldOverwrite(existingEntry, newEntry)
+}
+
 func (fs *FilerSink) DoInitialize(address, grpcAddress string, dir string,
 	replication string, collection string, ttlSec int, diskType string, grpcDialOption grpc.DialOption, writeChunkByFiler bool) (err error) {
 	fs.address = address
@@ -120,6 +133,10 @@ func (fs *FilerSink) CreateEntry(key string, entry *filer_pb.Entry, signatures [
 				glog.V(3).Infof("already replicated %s", key)
 				return nil
 			}
+			if !fs.shouldOverwrite(resp.Entry, entry) {
+				glog.V(2).Infof("keep the newer %s on the target", key)
+				return nil
+			}
 		}
 
 		replicatedChunks, err := fs.replicateChunks(entry.GetChunks(), key)
@@ -188,9 +205,9 @@ func (fs *FilerSink) UpdateEntry(key string, oldEntry *filer_pb.Entry, newParent
 
 	glog.V(4).Infof("oldEntry %+v, newEntry %+v, existingEntry: %+v", oldEntry, newEntry, existingEntry)
 
-	if existingEntry.Attributes.Mtime > newEntry.Attributes.Mtime {
+	if !fs.shouldOverwrite(existingEntry, newEntry) {
 		// skip if already changed
-		// this usually happens when the messages are not ordered
+		// this usually happens when the messages are not ordered, or the entry is changed on both filers
 		glog.V(2).Infof("late updates %s", key)
 	} else {
 		// find out what changed
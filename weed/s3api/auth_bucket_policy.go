@@ -0,0 +1,185 @@
+package s3api
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/policy"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+// the sub-resources of the requests which are not s3:GetObject, s3:PutObject, s3:DeleteObject or s3:ListBucket
+var bucketPolicySubResources = []string{
+	"accelerate", "acl", "analytics", "cors", "encryption", "intelligent-tiering", "inventory", "legal-hold",
+	"lifecycle", "location", "logging", "metrics", "notification", "object-lock", "ownershipControls", "policy",
+	"publicAccessBlock", "replication", "requestPayment", "retention", "tagging", "versioning", "versions", "website",
+}
+
+// bucketPolicyAction returns the bucket policy action of the request, or "" if the bucket policies do not cover it
+func bucketPolicyAction(r *http.Request, object string) string {
+	query := r.URL.Query()
+	for _, subResource := range bucketPolicySubResources {
+		if _, found := query[subResource]; found {
+			return ""
+		}
+	}
+	_, hasUploadId := query["uploadId"]
+	_, hasUploads := query["uploads"]
+
+	if object == "" || object == "/" {
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && !hasUploads {
+			return policy.ActionListBucket
+		}
+		return ""
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if !hasUploadId {
+			return policy.ActionGetObject
+		}
+	case http.MethodPut:
+		return policy.ActionPutObject
+	case http.MethodPost:
+		if hasUploadId || hasUploads {
+			return policy.ActionPutObject
+		}
+	case http.MethodDelete:
+		if !hasUploadId {
+			return policy.ActionDeleteObject
+		}
+	}
+	return ""
+}
+
+// evaluateBucketPolicy evaluates the bucket policy for the action on the object, DecisionNone if the bucket has no policy
+func (iam *IdentityAccessManagement) evaluateBucketPolicy(r *http.Request, identity *Identity, action, bucket, object string) policy.Decision {
+	if iam.getBucketPolicy == nil || action == "" || bucket == "" {
+		return policy.DecisionNone
+	}
+	bucketPolicy := iam.getBucketPolicy(bucket)
+	if bucketPolicy == nil {
+		return policy.DecisionNone
+	}
+
+	req := &policy.Request{
+		Action:          action,
+		ConditionValues: bucketPolicyConditionValues(r, identity, action),
+	}
+	if identity != nil && !identity.isAnonymous() {
+		req.Identity = identity.Name
+		req.AccountId = identity.AccountId
+	}
+	if action == policy.ActionListBucket {
+		req.Resource = policy.BucketResource(bucket)
+	} else {
+		req.Resource = policy.ObjectResource(bucket, object)
+	}
+	return bucketPolicy.Evaluate(req)
+}
+
+func bucketPolicyConditionValues(r *http.Request, identity *Identity, action string) map[string][]string {
+	secureTransport := r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+	values := map[string][]string{
+		"aws:SecureTransport": {strconv.FormatBool(secureTransport)},
+	}
+	// only the address of the connection, since the forwarded headers can be set by the client
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		values["aws:SourceIp"] = []string{host}
+	}
+	if userAgent := r.UserAgent(); userAgent != "" {
+		values["aws:UserAgent"] = []string{userAgent}
+	}
+	if referer := r.Referer(); referer != "" {
+		values["aws:Referer"] = []string{referer}
+	}
+	if identity != nil && !identity.isAnonymous() {
+		values["aws:username"] = []string{identity.Name}
+	}
+	if action == policy.ActionListBucket {
+		query := r.URL.Query()
+		for _, key := range []string{"prefix", "delimiter", "max-keys"} {
+			if value, found := query[key]; found {
+				values["s3:"+key] = value
+			}
+		}
+	}
+	return values
+}
+
+// authorizeByBucketPolicy checks the bucket policy before the actions of the identity:
+// an explicit deny rejects the request, and an explicit allow grants it even if the identity's actions do not.
+func (iam *IdentityAccessManagement) authorizeByBucketPolicy(r *http.Request, identity *Identity, action Action) s3err.ErrorCode {
+	bucket, object := s3_constants.GetBucketAndObject(r)
+	switch iam.evaluateBucketPolicy(r, identity, bucketPolicyAction(r, object), bucket, object) {
+	case policy.DecisionDeny:
+		return s3err.ErrAccessDenied
+	case policy.DecisionAllow:
+		return s3err.ErrNone
+	}
+	if !identity.canDo(action, bucket, object) {
+		return s3err.ErrAccessDenied
+	}
+	return s3err.ErrNone
+}
+
+// authorizeCopySource checks s3:GetObject on the source of a copy or an upload part copy,
+// which the bucket policy and the actions of the identity on the destination do not cover
+func (iam *IdentityAccessManagement) authorizeCopySource(r *http.Request, identity *Identity) s3err.ErrorCode {
+	if r.Method != http.MethodPut {
+		return s3err.ErrNone
+	}
+	bucket, object, found := copySource(r)
+	if !found {
+		return s3err.ErrNone
+	}
+	if !iam.canGetObjectAs(r, identity, bucket, object) {
+		return s3err.ErrAccessDenied
+	}
+	return s3err.ErrNone
+}
+
+// isDeleteDeniedByBucketPolicy checks one key of a multiple objects delete against the bucket policy,
+// with the identity already authenticated for the whole request
+func (s3a *S3ApiServer) isDeleteDeniedByBucketPolicy(r *http.Request, bucket, object string) bool {
+	if !s3a.iam.isEnabled() {
+		return false
+	}
+	var identity *Identity
+	if name := r.Header.Get(s3_constants.AmzIdentityId); name != "" {
+		identity = &Identity{Name: name, AccountId: r.Header.Get(s3_constants.AmzAccountId)}
+	}
+	return s3a.iam.evaluateBucketPolicy(r, identity, policy.ActionDeleteObject, bucket, object) == policy.DecisionDeny
+}
+
+// canPutObjectAs checks s3:PutObject on the object by the bucket policy and then the actions of the identity,
+// for the objects the gateway writes on behalf of an identity, e.g., the inventory reports. identity is nil if unknown.
+func (iam *IdentityAccessManagement) canPutObjectAs(r *http.Request, identity *Identity, bucket, object string) bool {
+	if !iam.isEnabled() {
+		return true
+	}
+	switch iam.evaluateBucketPolicy(r, identity, policy.ActionPutObject, bucket, object) {
+	case policy.DecisionDeny:
+		return false
+	case policy.DecisionAllow:
+		return true
+	}
+	return identity != nil && identity.canDo(s3_constants.ACTION_WRITE, bucket, object)
+}
+
+// canGetObjectAs checks s3:GetObject on the object by the bucket policy and then the actions of the identity,
+// for the objects read on behalf of an identity, e.g., the source of a copy. identity is nil if anonymous and unknown.
+func (iam *IdentityAccessManagement) canGetObjectAs(r *http.Request, identity *Identity, bucket, object string) bool {
+	if !iam.isEnabled() {
+		return true
+	}
+	switch iam.evaluateBucketPolicy(r, identity, policy.ActionGetObject, bucket, object) {
+	case policy.DecisionDeny:
+		return false
+	case policy.DecisionAllow:
+		return true
+	}
+	return identity != nil && identity.canDo(s3_constants.ACTION_READ, bucket, object)
+}
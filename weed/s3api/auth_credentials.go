@@ -13,6 +13,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/iam_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/policy"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 )
@@ -31,6 +32,9 @@ type IdentityAccessManagement struct {
 	identities    []*Identity
 	isAuthEnabled bool
 	domain        string
+
+	// getBucketPolicy returns the policy of the bucket, nil if it has none
+	getBucketPolicy func(bucket string) *policy.BucketPolicy
 }
 
 type Identity struct {
@@ -198,6 +202,17 @@ func (iam *IdentityAccessManagement) lookupByAccessKey(accessKey string) (identi
 	return nil, nil, false
 }
 
+func (iam *IdentityAccessManagement) lookupByName(name string) (identity *Identity, found bool) {
+	iam.m.RLock()
+	defer iam.m.RUnlock()
+	for _, ident := range iam.identities {
+		if ident.Name == name {
+			return ident, true
+		}
+	}
+	return nil, false
+}
+
 func (iam *IdentityAccessManagement) lookupAnonymous() (identity *Identity, found bool) {
 	iam.m.RLock()
 	defer iam.m.RUnlock()
@@ -267,6 +282,15 @@ func (iam *IdentityAccessManagement) authRequest(r *http.Request, action Action)
 		identity, found = iam.lookupAnonymous()
 		if !found {
 			r.Header.Set(s3_constants.AmzAuthType, authType)
+			bucket, object := s3_constants.GetBucketAndObject(r)
+			if iam.evaluateBucketPolicy(r, nil, bucketPolicyAction(r, object), bucket, object) == policy.DecisionAllow {
+				if errCode := iam.authorizeCopySource(r, nil); errCode != s3err.ErrNone {
+					return identity, errCode
+				}
+				r.Header.Del(s3_constants.AmzIdentityId)
+				r.Header.Del(s3_constants.AmzAccountId)
+				return identity, s3err.ErrNone
+			}
 			return identity, s3err.ErrAccessDenied
 		}
 	default:
@@ -282,10 +306,11 @@ func (iam *IdentityAccessManagement) authRequest(r *http.Request, action Action)
 
 	glog.V(3).Infof("user name: %v actions: %v, action: %v", identity.Name, identity.Actions, action)
 
-	bucket, object := s3_constants.GetBucketAndObject(r)
-
-	if !identity.canDo(action, bucket, object) {
-		return identity, s3err.ErrAccessDenied
+	if errCode := iam.authorizeByBucketPolicy(r, identity, action); errCode != s3err.ErrNone {
+		return identity, errCode
+	}
+	if errCode := iam.authorizeCopySource(r, identity); errCode != s3err.ErrNone {
+		return identity, errCode
 	}
 
 	if !identity.isAnonymous() {
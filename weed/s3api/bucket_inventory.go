@@ -0,0 +1,314 @@
+package s3api
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	inventoryCheckInterval = time.Hour
+	inventoryFileSchema    = "Bucket, Key, Size, LastModifiedDate, ETag, StorageClass, ReplicationStatus"
+	inventoryTimeFormat    = "2006-01-02T15-04Z"
+)
+
+type inventoryManifest struct {
+	SourceBucket      string                  `json:"sourceBucket"`
+	DestinationBucket string                  `json:"destinationBucket"`
+	Version           string                  `json:"version"`
+	CreationTimestamp string                  `json:"creationTimestamp"`
+	FileFormat        string                  `json:"fileFormat"`
+	FileSchema        string                  `json:"fileSchema"`
+	Files             []inventoryManifestFile `json:"files"`
+}
+
+type inventoryManifestFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	MD5checksum string `json:"MD5checksum"`
+}
+
+// loopInventory generates the bucket inventory reports when they are due.
+// The s3 gateways sharing a filer all check the schedules. The due run is marked on the bucket
+// before generating the report, so usually only one gateway generates it.
+func (s3a *S3ApiServer) loopInventory() {
+	for {
+		time.Sleep(inventoryCheckInterval)
+		if err := s3a.runDueInventories(time.Now()); err != nil {
+			glog.Warningf("bucket inventory: %v", err)
+		}
+	}
+}
+
+func (s3a *S3ApiServer) runDueInventories(now time.Time) error {
+	var buckets []string
+	err := filer_pb.List(s3a, s3a.option.BucketsPath, "", func(entry *filer_pb.Entry, isLast bool) error {
+		if _, ok := entry.Extended[s3_constants.ExtInventoryKey]; ok && entry.IsDirectory {
+			buckets = append(buckets, entry.Name)
+		}
+		return nil
+	}, "", false, math.MaxUint32)
+	if err != nil {
+		return fmt.Errorf("list buckets: %v", err)
+	}
+
+	for _, bucket := range buckets {
+		bucketEntry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+		if err != nil {
+			glog.Warningf("bucket inventory of %s: %v", bucket, err)
+			continue
+		}
+		configs, err := loadInventoryConfigurations(bucketEntry)
+		if err != nil {
+			glog.Warningf("bucket inventory of %s: %v", bucket, err)
+			continue
+		}
+		for id, config := range configs {
+			if !aws.BoolValue(config.IsEnabled) {
+				continue
+			}
+			lastRun, due := s3a.markInventoryRun(bucket, id, config, now)
+			if !due {
+				continue
+			}
+			glog.V(0).Infof("generate inventory %s of bucket %s", id, bucket)
+			if err := s3a.generateInventory(bucket, s3a.inventoryOwner(bucketEntry), config, now); err != nil {
+				glog.Errorf("generate inventory %s of bucket %s: %v", id, bucket, err)
+				// retry on the next check
+				s3a.setInventoryRun(bucket, id, lastRun)
+			}
+		}
+	}
+	return nil
+}
+
+func isInventoryDue(config *s3.InventoryConfiguration, lastRun, now time.Time) bool {
+	period := 24 * time.Hour
+	if aws.StringValue(config.Schedule.Frequency) == s3.InventoryFrequencyWeekly {
+		period = 7 * 24 * time.Hour
+	}
+	return now.Sub(lastRun) >= period
+}
+
+// markInventoryRun records the run on the bucket entry if the inventory is due, and returns the previous run time
+func (s3a *S3ApiServer) markInventoryRun(bucket, id string, config *s3.InventoryConfiguration, now time.Time) (lastRun time.Time, due bool) {
+	runs, err := s3a.loadInventoryRuns(bucket)
+	if err != nil {
+		glog.Warningf("bucket inventory of %s: %v", bucket, err)
+		return
+	}
+	if runs[id] > 0 {
+		lastRun = time.Unix(runs[id], 0)
+	}
+	if !isInventoryDue(config, lastRun, now) {
+		return
+	}
+	if err := s3a.setInventoryRun(bucket, id, now); err != nil {
+		glog.Warningf("bucket inventory of %s: %v", bucket, err)
+		return
+	}
+	return lastRun, true
+}
+
+func (s3a *S3ApiServer) loadInventoryRuns(bucket string) (runs map[string]int64, err error) {
+	bucketEntry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		return nil, err
+	}
+	runs = make(map[string]int64)
+	if runsBytes, ok := bucketEntry.Extended[s3_constants.ExtInventoryRunKey]; ok && len(runsBytes) > 0 {
+		err = json.Unmarshal(runsBytes, &runs)
+	}
+	return
+}
+
+func (s3a *S3ApiServer) setInventoryRun(bucket, id string, runTime time.Time) error {
+	bucketEntry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		return err
+	}
+	runs := make(map[string]int64)
+	if runsBytes, ok := bucketEntry.Extended[s3_constants.ExtInventoryRunKey]; ok && len(runsBytes) > 0 {
+		if err = json.Unmarshal(runsBytes, &runs); err != nil {
+			return err
+		}
+	}
+	if runTime.IsZero() {
+		delete(runs, id)
+	} else {
+		runs[id] = runTime.Unix()
+	}
+	runsBytes, err := json.Marshal(runs)
+	if err != nil {
+		return err
+	}
+	if bucketEntry.Extended == nil {
+		bucketEntry.Extended = make(map[string][]byte)
+	}
+	bucketEntry.Extended[s3_constants.ExtInventoryRunKey] = runsBytes
+	return s3a.updateEntry(s3a.option.BucketsPath, bucketEntry)
+}
+
+// inventoryOwner returns the owner of the bucket, whose permissions the reports are written with, nil if unknown
+func (s3a *S3ApiServer) inventoryOwner(bucketEntry *filer_pb.Entry) *Identity {
+	name, found := bucketEntry.Extended[s3_constants.AmzIdentityId]
+	if !found {
+		return nil
+	}
+	identity, _ := s3a.iam.lookupByName(string(name))
+	return identity
+}
+
+// inventoryReportDir is <prefix>/<bucket>/<id> in the destination bucket
+func inventoryReportDir(bucket string, config *s3.InventoryConfiguration) string {
+	return path.Join(aws.StringValue(config.Destination.S3BucketDestination.Prefix), bucket, aws.StringValue(config.Id))
+}
+
+// generateInventory writes the objects of the bucket as a gzipped csv file, and then the manifest, to
+// <prefix>/<bucket>/<id>/data/<time>.csv.gz and <prefix>/<bucket>/<id>/<time>/manifest.json of the destination bucket.
+func (s3a *S3ApiServer) generateInventory(bucket string, owner *Identity, config *s3.InventoryConfiguration, now time.Time) error {
+	dest := config.Destination.S3BucketDestination
+	destBucket := strings.TrimPrefix(aws.StringValue(dest.Bucket), inventoryBucketArnPrefix)
+	reportDir := inventoryReportDir(bucket, config)
+	reportTime := now.UTC().Format(inventoryTimeFormat)
+	dataKey := path.Join(reportDir, "data", reportTime+".csv.gz")
+
+	var prefix string
+	if config.Filter != nil {
+		prefix = aws.StringValue(config.Filter.Prefix)
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		gzipWriter := gzip.NewWriter(pipeWriter)
+		err := s3a.writeInventoryRows(bucket, prefix, csv.NewWriter(gzipWriter))
+		if closeErr := gzipWriter.Close(); err == nil {
+			err = closeErr
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+	counter := &inventoryCountingReader{reader: pipeReader}
+	md5, errCode := s3a.putInventoryFile(owner, destBucket, dataKey, "application/gzip", counter)
+	pipeReader.CloseWithError(io.ErrClosedPipe)
+	if errCode != s3err.ErrNone {
+		return fmt.Errorf("write %s/%s: %v", destBucket, dataKey, s3err.GetAPIError(errCode).Code)
+	}
+
+	manifest := &inventoryManifest{
+		SourceBucket:      bucket,
+		DestinationBucket: aws.StringValue(dest.Bucket),
+		Version:           "2016-11-30",
+		CreationTimestamp: strconv.FormatInt(now.UnixMilli(), 10),
+		FileFormat:        s3.InventoryFormatCsv,
+		FileSchema:        inventoryFileSchema,
+		Files: []inventoryManifestFile{{
+			Key:         dataKey,
+			Size:        counter.size,
+			MD5checksum: md5,
+		}},
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	manifestKey := path.Join(reportDir, reportTime, "manifest.json")
+	if _, errCode = s3a.putInventoryFile(owner, destBucket, manifestKey, "application/json", util.NewBytesReader(manifestBytes)); errCode != s3err.ErrNone {
+		return fmt.Errorf("write %s/%s: %v", destBucket, manifestKey, s3err.GetAPIError(errCode).Code)
+	}
+	return nil
+}
+
+// writeInventoryRows walks the bucket directories under the prefix, skipping the multipart uploads
+func (s3a *S3ApiServer) writeInventoryRows(bucket, prefix string, csvWriter *csv.Writer) (err error) {
+	bucketDir := util.FullPath(s3a.option.BucketsPath).Child(bucket)
+	startDir := bucketDir
+	if dir, _ := path.Split(prefix); dir != "" {
+		startDir = bucketDir.Child(strings.Trim(dir, "/"))
+	}
+	uploadsDir := string(bucketDir.Child(s3_constants.MultipartUploadsFolder))
+
+	var writeLock sync.Mutex
+	var writeErr error
+	err = filer_pb.TraverseBfs(s3a, startDir, func(parentPath util.FullPath, entry *filer_pb.Entry) {
+		if entry.IsDirectory {
+			return
+		}
+		if parentPath == util.FullPath(uploadsDir) || strings.HasPrefix(string(parentPath), uploadsDir+"/") {
+			return
+		}
+		key := strings.TrimPrefix(string(parentPath.Child(entry.Name)), string(bucketDir)+"/")
+		if !strings.HasPrefix(key, prefix) {
+			return
+		}
+		storageClass := string(entry.Extended[s3_constants.AmzStorageClass])
+		if storageClass == "" {
+			storageClass = s3.StorageClassStandard
+		}
+		row := []string{
+			bucket,
+			key,
+			strconv.FormatUint(filer.FileSize(entry), 10),
+			time.Unix(entry.Attributes.Mtime, 0).UTC().Format("2006-01-02T15:04:05.000Z"),
+			filer.ETag(entry),
+			storageClass,
+			"", // no replication
+		}
+
+		writeLock.Lock()
+		defer writeLock.Unlock()
+		if writeErr == nil {
+			writeErr = csvWriter.Write(row)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	csvWriter.Flush()
+	if writeErr != nil {
+		return writeErr
+	}
+	return csvWriter.Error()
+}
+
+// putInventoryFile writes the report file if the owner of the source bucket has s3:PutObject on it
+func (s3a *S3ApiServer) putInventoryFile(owner *Identity, bucket, key, contentType string, dataReader io.Reader) (etag string, code s3err.ErrorCode) {
+	r, err := http.NewRequest(http.MethodPut, "/"+bucket+"/"+key, nil)
+	if err != nil {
+		return "", s3err.ErrInternalError
+	}
+	if !s3a.iam.canPutObjectAs(r, owner, bucket, "/"+key) {
+		return "", s3err.ErrAccessDenied
+	}
+	r.Header.Set("Content-Type", contentType)
+	return s3a.putToFiler(r, s3a.toFilerUrl(bucket, "/"+key), dataReader, "", bucket)
+}
+
+type inventoryCountingReader struct {
+	reader io.Reader
+	size   int64
+}
+
+func (c *inventoryCountingReader) Read(p []byte) (n int, err error) {
+	n, err = c.reader.Read(p)
+	c.size += int64(n)
+	return
+}
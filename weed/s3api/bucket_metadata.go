@@ -5,6 +5,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/policy"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3account"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
@@ -39,6 +40,12 @@ type BucketMetaData struct {
 
 	// A list of grants for access controls.
 	Acl []*s3.Grant `locationName:"AccessControlList" locationNameList:"Grant" type:"list"`
+
+	// The cross-origin resource sharing rules, nil if not configured.
+	Cors *s3.CORSConfiguration
+
+	// The bucket policy, nil if not configured.
+	Policy *policy.BucketPolicy
 }
 
 type BucketRegistry struct {
@@ -133,6 +140,29 @@ func buildBucketMetadata(accountManager *s3account.AccountManager, entry *filer_
 				glog.Warningf("Unmarshal ACP grants: %s(%v), bucket: %s", string(acpGrantsBytes), err, bucketMetadata.Name)
 			}
 		}
+
+		//cors
+		corsBytes, ok := entry.Extended[s3_constants.ExtCorsKey]
+		if ok && len(corsBytes) > 0 {
+			var cors s3.CORSConfiguration
+			err := json.Unmarshal(corsBytes, &cors)
+			if err == nil {
+				bucketMetadata.Cors = &cors
+			} else {
+				glog.Warningf("Unmarshal CORS: %s(%v), bucket: %s", string(corsBytes), err, bucketMetadata.Name)
+			}
+		}
+
+		//policy
+		policyBytes, ok := entry.Extended[s3_constants.ExtPolicyKey]
+		if ok && len(policyBytes) > 0 {
+			bucketPolicy, err := policy.ParseBucketPolicy(policyBytes, entry.Name)
+			if err == nil {
+				bucketMetadata.Policy = bucketPolicy
+			} else {
+				glog.Warningf("Parse bucket policy: %s(%v), bucket: %s", string(policyBytes), err, bucketMetadata.Name)
+			}
+		}
 	}
 	return bucketMetadata
 }
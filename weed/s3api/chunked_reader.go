@@ -0,0 +1,176 @@
+package s3api
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+// The x-amz-content-sha256 of the aws-chunked bodies besides streamingContentSHA256.
+const (
+	streamingUnsignedPayloadTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	streamingContentSHA256Trailer   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+)
+
+// AWSChunkedReader decodes the aws-chunked framing of the request body:
+//
+//	<hex size>[;<extension>]\r\n<data>\r\n ... 0[;<extension>]\r\n[<trailing headers>\r\n]\r\n
+//
+// The chunk signatures are not verified, see s3ChunkedReader for that. If the trailing header named by
+// x-amz-trailer is a crc32, crc32c, sha1 or sha256 checksum, it is checked against the decoded data.
+type AWSChunkedReader struct {
+	body     io.ReadCloser
+	reader   *bufio.Reader
+	trailer  string
+	checksum hash.Hash
+	started  bool
+	n        uint64 // the unread bytes of the current chunk
+	err      error
+}
+
+func NewAWSChunkedReader(body io.ReadCloser, trailer string) *AWSChunkedReader {
+	trailer = strings.ToLower(strings.TrimSpace(trailer))
+	return &AWSChunkedReader{
+		body:     body,
+		reader:   bufio.NewReader(body),
+		trailer:  trailer,
+		checksum: newTrailerChecksum(trailer),
+	}
+}
+
+func newTrailerChecksum(trailer string) hash.Hash {
+	switch trailer {
+	case "x-amz-checksum-crc32":
+		return crc32.NewIEEE()
+	case "x-amz-checksum-crc32c":
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case "x-amz-checksum-sha1":
+		return sha1.New()
+	case "x-amz-checksum-sha256":
+		return sha256.New()
+	}
+	return nil
+}
+
+func (cr *AWSChunkedReader) Read(p []byte) (n int, err error) {
+	if cr.err != nil {
+		return 0, cr.err
+	}
+	if cr.n == 0 {
+		if cr.started {
+			if err = readCRLF(cr.reader); err != nil {
+				cr.err = errMalformedEncoding
+				return 0, cr.err
+			}
+		}
+		cr.started = true
+		if cr.n, cr.err = cr.readChunkSize(); cr.err != nil {
+			return 0, cr.err
+		}
+		if cr.n == 0 {
+			cr.err = cr.readTrailer()
+			return 0, cr.err
+		}
+	}
+	if uint64(len(p)) > cr.n {
+		p = p[:cr.n]
+	}
+	n, err = cr.reader.Read(p)
+	cr.n -= uint64(n)
+	if cr.checksum != nil {
+		cr.checksum.Write(p[:n])
+	}
+	if err == io.EOF {
+		// the chunk is shorter than its size
+		err = io.ErrUnexpectedEOF
+	}
+	cr.err = err
+	return n, err
+}
+
+func (cr *AWSChunkedReader) readChunkSize() (uint64, error) {
+	line, _, err := readChunkLine(cr.reader)
+	if err != nil {
+		return 0, err
+	}
+	if semi := bytes.IndexByte(line, ';'); semi >= 0 {
+		line = line[:semi]
+	}
+	return parseHexUint(bytes.TrimSpace(line))
+}
+
+// readTrailer reads the trailing headers up to the final empty line, and returns io.EOF if the checksum matches
+func (cr *AWSChunkedReader) readTrailer() error {
+	for {
+		line, err := cr.reader.ReadSlice('\n')
+		if err != nil {
+			if err == io.EOF && len(bytes.TrimSpace(line)) == 0 {
+				// some clients end the body right after the last chunk
+				return io.EOF
+			}
+			return errMalformedEncoding
+		}
+		line = trimTrailingWhitespace(line)
+		if len(line) == 0 {
+			return io.EOF
+		}
+		name, value, found := strings.Cut(string(line), ":")
+		if !found {
+			return errMalformedEncoding
+		}
+		if cr.checksum == nil || strings.ToLower(strings.TrimSpace(name)) != cr.trailer {
+			continue
+		}
+		if expected := base64.StdEncoding.EncodeToString(cr.checksum.Sum(nil)); strings.TrimSpace(value) != expected {
+			return fmt.Errorf("%s %s does not match the data %s", name, strings.TrimSpace(value), expected)
+		}
+	}
+}
+
+func (cr *AWSChunkedReader) Close() error {
+	return cr.body.Close()
+}
+
+// decodeAWSChunked wraps the aws-chunked request body in an AWSChunkedReader, unless the body is already decoded
+// by s3ChunkedReader. Without the identities, the chunk signatures can not be verified, and only the framing
+// is decoded. The signature of the trailing headers is not supported.
+func (iam *IdentityAccessManagement) decodeAWSChunked(r *http.Request, dataReader io.ReadCloser) (io.ReadCloser, s3err.ErrorCode) {
+	switch r.Header.Get("X-Amz-Content-Sha256") {
+	case streamingContentSHA256:
+		if iam.isEnabled() {
+			return dataReader, s3err.ErrNone
+		}
+	case streamingContentSHA256Trailer:
+		if iam.isEnabled() {
+			return nil, s3err.ErrNotImplemented
+		}
+	case streamingUnsignedPayloadTrailer:
+	default:
+		return dataReader, s3err.ErrNone
+	}
+
+	// aws-chunked only describes the transfer, and is not stored with the object
+	var encodings []string
+	for _, encoding := range strings.Split(r.Header.Get("Content-Encoding"), ",") {
+		if encoding = strings.TrimSpace(encoding); encoding != "" && encoding != "aws-chunked" {
+			encodings = append(encodings, encoding)
+		}
+	}
+	if len(encodings) > 0 {
+		r.Header.Set("Content-Encoding", strings.Join(encodings, ","))
+	} else {
+		r.Header.Del("Content-Encoding")
+	}
+
+	return NewAWSChunkedReader(dataReader, r.Header.Get("X-Amz-Trailer")), s3err.ErrNone
+}
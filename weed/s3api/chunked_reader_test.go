@@ -0,0 +1,79 @@
+package s3api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeAWSChunked(data string, chunkSize int, trailer string) string {
+	var sb strings.Builder
+	for len(data) > 0 {
+		n := chunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+		sb.WriteString(fmt.Sprintf("%x;chunk-signature=abc\r\n", n))
+		sb.WriteString(data[:n])
+		sb.WriteString("\r\n")
+		data = data[n:]
+	}
+	sb.WriteString("0\r\n")
+	sb.WriteString(trailer)
+	sb.WriteString("\r\n")
+	return sb.String()
+}
+
+func TestAWSChunkedReader(t *testing.T) {
+	data := strings.Repeat("hello world ", 100)
+	checksum := make([]byte, 4)
+	crc := crc32.ChecksumIEEE([]byte(data))
+	checksum[0], checksum[1], checksum[2], checksum[3] = byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc)
+	trailer := "x-amz-checksum-crc32:" + base64.StdEncoding.EncodeToString(checksum) + "\r\n"
+
+	body := encodeAWSChunked(data, 100, trailer)
+	decoded, err := io.ReadAll(NewAWSChunkedReader(io.NopCloser(strings.NewReader(body)), "x-amz-checksum-crc32"))
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(decoded))
+
+	// no trailer
+	decoded, err = io.ReadAll(NewAWSChunkedReader(io.NopCloser(strings.NewReader(encodeAWSChunked(data, 7, ""))), ""))
+	assert.NoError(t, err)
+	assert.Equal(t, data, string(decoded))
+
+	// wrong checksum
+	body = encodeAWSChunked(data+"!", 100, trailer)
+	_, err = io.ReadAll(NewAWSChunkedReader(io.NopCloser(strings.NewReader(body)), "x-amz-checksum-crc32"))
+	assert.Error(t, err)
+
+	// truncated
+	body = encodeAWSChunked(data, 100, "")
+	_, err = io.ReadAll(NewAWSChunkedReader(io.NopCloser(strings.NewReader(body[:len(body)/2])), ""))
+	assert.Error(t, err)
+}
+
+func TestDecodeAWSChunked(t *testing.T) {
+	iam := &IdentityAccessManagement{}
+
+	r := httptest.NewRequest("PUT", "/bucket/object", strings.NewReader(encodeAWSChunked("abc", 2, "")))
+	r.Header.Set("X-Amz-Content-Sha256", streamingUnsignedPayloadTrailer)
+	r.Header.Set("Content-Encoding", "aws-chunked,gzip")
+	reader, errCode := iam.decodeAWSChunked(r, r.Body)
+	assert.Equal(t, s3err.ErrNone, errCode)
+	decoded, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", string(decoded))
+	assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+
+	r = httptest.NewRequest("PUT", "/bucket/object", strings.NewReader("abc"))
+	reader, errCode = iam.decodeAWSChunked(r, r.Body)
+	assert.Equal(t, s3err.ErrNone, errCode)
+	assert.Equal(t, r.Body, reader)
+}
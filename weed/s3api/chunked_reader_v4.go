@@ -92,9 +92,7 @@ func (iam *IdentityAccessManagement) calculateSeedSignature(r *http.Request) (cr
 		return nil, "", "", time.Time{}, s3err.ErrInvalidAccessKeyID
 	}
 
-	bucket, object := s3_constants.GetBucketAndObject(r)
-	if !identity.canDo(s3_constants.ACTION_WRITE, bucket, object) {
-		errCode = s3err.ErrAccessDenied
+	if errCode = iam.authorizeByBucketPolicy(r, identity, s3_constants.ACTION_WRITE); errCode != s3err.ErrNone {
 		return
 	}
 
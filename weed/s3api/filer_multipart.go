@@ -92,8 +92,14 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 
 	mime := pentry.Attributes.Mime
 
+	// the parts may be uploaded in any order, and are assembled in the part number order
+	slices.SortStableFunc(entries, func(a, b *filer_pb.Entry) bool {
+		return partNumberOf(a.Name) < partNumberOf(b.Name)
+	})
+
 	var finalParts []*filer_pb.FileChunk
 	var offset int64
+	uploadedParts := make(map[int]bool)
 
 	for _, entry := range entries {
 		if strings.HasSuffix(entry.Name, ".part") && !entry.IsDirectory {
@@ -101,6 +107,7 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 			if !found {
 				continue
 			}
+			uploadedParts[partNumberOf(entry.Name)] = true
 			entryETag := hex.EncodeToString(entry.Attributes.GetMd5())
 			if partETag != "" && len(partETag) == 32 && entryETag != "" && entryETag != partETag {
 				glog.Errorf("completeMultipartUpload %s ETag mismatch chunk: %s part: %s", entry.Name, entryETag, partETag)
@@ -121,6 +128,13 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 		}
 	}
 
+	for _, part := range completedParts {
+		if !uploadedParts[part.PartNumber] {
+			glog.Errorf("completeMultipartUpload %s %s part %d is not uploaded", *input.Bucket, *input.UploadId, part.PartNumber)
+			return nil, s3err.ErrInvalidPart
+		}
+	}
+
 	entryName := filepath.Base(*input.Key)
 	dirName := filepath.Dir(*input.Key)
 	if dirName == "." {
@@ -174,9 +188,22 @@ func (s3a *S3ApiServer) completeMultipartUpload(input *s3.CompleteMultipartUploa
 	return
 }
 
+func partFileName(partNumber int) string {
+	return fmt.Sprintf("%04d.part", partNumber)
+}
+
+// partNumberOf returns the part number of the part file name, or 0 if the name is not a part
+func partNumberOf(fileName string) int {
+	partNumber, err := strconv.Atoi(strings.TrimSuffix(fileName, ".part"))
+	if err != nil {
+		return 0
+	}
+	return partNumber
+}
+
 func findByPartNumber(fileName string, parts []CompletedPart) (etag string, found bool) {
-	partNumber, formatErr := strconv.Atoi(fileName[:4])
-	if formatErr != nil {
+	partNumber := partNumberOf(fileName)
+	if partNumber == 0 {
 		return
 	}
 	x := sort.Search(len(parts), func(i int) bool {
@@ -312,7 +339,7 @@ func (s3a *S3ApiServer) listObjectParts(input *s3.ListPartsInput) (output *ListP
 		StorageClass:     aws.String("STANDARD"),
 	}
 
-	entries, isLast, err := s3a.list(s3a.genUploadsFolder(*input.Bucket)+"/"+*input.UploadId, "", fmt.Sprintf("%04d.part", *input.PartNumberMarker), false, uint32(*input.MaxParts))
+	entries, isLast, err := s3a.list(s3a.genUploadsFolder(*input.Bucket)+"/"+*input.UploadId, "", partFileName(int(*input.PartNumberMarker)), false, uint32(*input.MaxParts))
 	if err != nil {
 		glog.Errorf("listObjectParts %s %s error: %v", *input.Bucket, *input.UploadId, err)
 		return nil, s3err.ErrNoSuchUpload
@@ -135,3 +135,15 @@ func Test_findByPartNumber(t *testing.T) {
 		})
 	}
 }
+
+func Test_partNumberOf(t *testing.T) {
+	assert.Equal(t, 1, partNumberOf(partFileName(1)))
+	assert.Equal(t, 9999, partNumberOf(partFileName(9999)))
+	assert.Equal(t, 10000, partNumberOf(partFileName(10000)))
+	assert.Equal(t, 0, partNumberOf("abc.part"))
+	assert.Equal(t, 0, partNumberOf(""))
+
+	etag, found := findByPartNumber(partFileName(10000), []CompletedPart{{ETag: "aaa", PartNumber: 1000}, {ETag: "bbb", PartNumber: 10000}})
+	assert.True(t, found)
+	assert.Equal(t, "bbb", etag)
+}
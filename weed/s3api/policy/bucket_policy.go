@@ -0,0 +1,405 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// The actions a bucket policy can grant or deny.
+const (
+	ActionGetObject    = "s3:GetObject"
+	ActionPutObject    = "s3:PutObject"
+	ActionDeleteObject = "s3:DeleteObject"
+	ActionListBucket   = "s3:ListBucket"
+)
+
+var supportedActions = []string{ActionGetObject, ActionPutObject, ActionDeleteObject, ActionListBucket}
+
+const resourceArnPrefix = "arn:aws:s3:::"
+
+type Effect string
+
+const (
+	EffectAllow Effect = "Allow"
+	EffectDeny  Effect = "Deny"
+)
+
+type Decision int
+
+const (
+	// DecisionNone means no statement applies, and the identity's own permissions decide
+	DecisionNone Decision = iota
+	DecisionAllow
+	DecisionDeny
+)
+
+// BucketPolicy is an IAM-style bucket policy document.
+type BucketPolicy struct {
+	Version   string      `json:"Version,omitempty"`
+	Id        string      `json:"Id,omitempty"`
+	Statement []Statement `json:"Statement"`
+}
+
+type Statement struct {
+	Sid       string                          `json:"Sid,omitempty"`
+	Effect    Effect                          `json:"Effect"`
+	Principal *Principal                      `json:"Principal"`
+	Action    StringSet                       `json:"Action"`
+	Resource  StringSet                       `json:"Resource"`
+	Condition map[string]map[string]StringSet `json:"Condition,omitempty"`
+}
+
+// StringSet is a json string or an array of strings.
+type StringSet []string
+
+func (s *StringSet) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = StringSet{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("expecting a string or an array of strings: %s", data)
+	}
+	*s = multiple
+	return nil
+}
+
+func (s StringSet) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+	return json.Marshal([]string(s))
+}
+
+// Principal is "*" for everyone, including the anonymous requests, or {"AWS": [...]}.
+// An AWS principal is "*", an account id, "arn:aws:iam::<account id>:root" for the identities of the account,
+// "arn:aws:iam::<account id>:user/<name>", or just the identity name.
+type Principal struct {
+	Any bool
+	AWS StringSet
+}
+
+func (p *Principal) UnmarshalJSON(data []byte) error {
+	var wildcard string
+	if err := json.Unmarshal(data, &wildcard); err == nil {
+		if wildcard != "*" {
+			return fmt.Errorf("principal %q should be \"*\" or {\"AWS\": ...}", wildcard)
+		}
+		p.Any = true
+		return nil
+	}
+	var principals map[string]StringSet
+	if err := json.Unmarshal(data, &principals); err != nil {
+		return err
+	}
+	for key, values := range principals {
+		if key != "AWS" {
+			return fmt.Errorf("unsupported principal type %q", key)
+		}
+		p.AWS = values
+	}
+	return nil
+}
+
+func (p Principal) MarshalJSON() ([]byte, error) {
+	if p.Any {
+		return json.Marshal("*")
+	}
+	return json.Marshal(map[string]StringSet{"AWS": p.AWS})
+}
+
+// Request is what a statement is evaluated against.
+type Request struct {
+	// the authenticated identity, empty for anonymous requests
+	Identity  string
+	AccountId string
+	Action    string
+	// "arn:aws:s3:::bucket" for the bucket, or "arn:aws:s3:::bucket/key" for an object
+	Resource string
+	// the condition keys, e.g., "aws:SourceIp", "aws:SecureTransport", "s3:prefix"
+	ConditionValues map[string][]string
+}
+
+func BucketResource(bucket string) string {
+	return resourceArnPrefix + bucket
+}
+
+func ObjectResource(bucket, object string) string {
+	return resourceArnPrefix + bucket + "/" + strings.TrimPrefix(object, "/")
+}
+
+// ParseBucketPolicy parses and validates the policy of the bucket.
+func ParseBucketPolicy(data []byte, bucket string) (*BucketPolicy, error) {
+	var policy BucketPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, err
+	}
+	if len(policy.Statement) == 0 {
+		return nil, fmt.Errorf("no statement")
+	}
+	for i, statement := range policy.Statement {
+		if err := statement.validate(bucket); err != nil {
+			return nil, fmt.Errorf("statement %d: %v", i, err)
+		}
+	}
+	return &policy, nil
+}
+
+func (s *Statement) validate(bucket string) error {
+	if s.Effect != EffectAllow && s.Effect != EffectDeny {
+		return fmt.Errorf("effect %q should be Allow or Deny", s.Effect)
+	}
+	if s.Principal == nil || (!s.Principal.Any && len(s.Principal.AWS) == 0) {
+		return fmt.Errorf("missing principal")
+	}
+	if len(s.Action) == 0 {
+		return fmt.Errorf("missing action")
+	}
+	for _, action := range s.Action {
+		if !matchesAnyAction(action) {
+			return fmt.Errorf("unsupported action %q", action)
+		}
+	}
+	if len(s.Resource) == 0 {
+		return fmt.Errorf("missing resource")
+	}
+	for _, resource := range s.Resource {
+		name := strings.TrimPrefix(resource, resourceArnPrefix)
+		if name == resource {
+			return fmt.Errorf("resource %q should start with %s", resource, resourceArnPrefix)
+		}
+		if !matchWildcard(strings.SplitN(name, "/", 2)[0], bucket) {
+			return fmt.Errorf("resource %q is not in bucket %s", resource, bucket)
+		}
+	}
+	for operator, conditions := range s.Condition {
+		if _, found := conditionOperators[operator]; !found {
+			return fmt.Errorf("unsupported condition operator %q", operator)
+		}
+		if strings.HasPrefix(operator, "IpAddress") || strings.HasPrefix(operator, "NotIpAddress") {
+			for key, values := range conditions {
+				for _, value := range values {
+					if _, _, err := parseCidr(value); err != nil {
+						return fmt.Errorf("condition %s %s: %v", operator, key, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func matchesAnyAction(pattern string) bool {
+	for _, action := range supportedActions {
+		if matchAction(pattern, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns DecisionDeny if any statement denies the request, DecisionAllow if any allows it,
+// and DecisionNone otherwise.
+func (p *BucketPolicy) Evaluate(req *Request) Decision {
+	decision := DecisionNone
+	for _, statement := range p.Statement {
+		if !statement.matches(req) {
+			continue
+		}
+		if statement.Effect == EffectDeny {
+			return DecisionDeny
+		}
+		decision = DecisionAllow
+	}
+	return decision
+}
+
+func (s *Statement) matches(req *Request) bool {
+	return s.matchesPrincipal(req) && s.matchesAction(req.Action) && s.matchesResource(req.Resource) && s.matchesConditions(req)
+}
+
+func (s *Statement) matchesPrincipal(req *Request) bool {
+	if s.Principal.Any {
+		return true
+	}
+	for _, principal := range s.Principal.AWS {
+		if principal == "*" {
+			return true
+		}
+		if req.Identity == "" {
+			continue
+		}
+		if arn := strings.TrimPrefix(principal, "arn:aws:iam::"); arn != principal {
+			account, resource, _ := strings.Cut(arn, ":")
+			if account != req.AccountId && account != "*" {
+				continue
+			}
+			if resource == "root" || resource == "user/"+req.Identity || resource == "user/*" {
+				return true
+			}
+			continue
+		}
+		if principal == req.Identity || (req.AccountId != "" && principal == req.AccountId) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Statement) matchesAction(action string) bool {
+	for _, pattern := range s.Action {
+		if matchAction(pattern, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAction matches the action case-insensitively, as IAM does
+func matchAction(pattern, action string) bool {
+	return matchWildcard(strings.ToLower(pattern), strings.ToLower(action))
+}
+
+func (s *Statement) matchesResource(resource string) bool {
+	for _, pattern := range s.Resource {
+		if matchWildcard(pattern, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesConditions requires all operators, and all keys of each operator, to match
+func (s *Statement) matchesConditions(req *Request) bool {
+	for operator, conditions := range s.Condition {
+		evaluate := conditionOperators[operator]
+		for key, expected := range conditions {
+			if !evaluate(req.conditionValues(key), expected) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (req *Request) conditionValues(key string) []string {
+	for k, values := range req.ConditionValues {
+		// the condition keys are case-insensitive
+		if strings.EqualFold(k, key) {
+			return values
+		}
+	}
+	return nil
+}
+
+type conditionOperator func(values []string, expected StringSet) bool
+
+var conditionOperators = map[string]conditionOperator{
+	"StringEquals":              anyValue(func(v, e string) bool { return v == e }),
+	"StringNotEquals":           noValue(func(v, e string) bool { return v == e }),
+	"StringEqualsIgnoreCase":    anyValue(strings.EqualFold),
+	"StringNotEqualsIgnoreCase": noValue(strings.EqualFold),
+	"StringLike":                anyValue(func(v, e string) bool { return matchWildcard(e, v) }),
+	"StringNotLike":             noValue(func(v, e string) bool { return matchWildcard(e, v) }),
+	"IpAddress":                 anyValue(matchIp),
+	"NotIpAddress":              noValue(matchIp),
+	"Bool":                      anyValue(func(v, e string) bool { return strings.EqualFold(v, e) }),
+	"NumericEquals":             anyValue(numeric(func(v, e float64) bool { return v == e })),
+	"NumericNotEquals":          noValue(numeric(func(v, e float64) bool { return v == e })),
+	"NumericLessThan":           anyValue(numeric(func(v, e float64) bool { return v < e })),
+	"NumericLessThanEquals":     anyValue(numeric(func(v, e float64) bool { return v <= e })),
+	"NumericGreaterThan":        anyValue(numeric(func(v, e float64) bool { return v > e })),
+	"NumericGreaterThanEquals":  anyValue(numeric(func(v, e float64) bool { return v >= e })),
+}
+
+// anyValue matches if any request value matches any expected value, false if the request has no value
+func anyValue(match func(value, expected string) bool) conditionOperator {
+	return func(values []string, expected StringSet) bool {
+		for _, v := range values {
+			for _, e := range expected {
+				if match(v, e) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+// noValue matches if no request value matches the expected values, true if the request has no value
+func noValue(match func(value, expected string) bool) conditionOperator {
+	positive := anyValue(match)
+	return func(values []string, expected StringSet) bool {
+		return !positive(values, expected)
+	}
+}
+
+func numeric(compare func(value, expected float64) bool) func(value, expected string) bool {
+	return func(value, expected string) bool {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		e, err := strconv.ParseFloat(expected, 64)
+		if err != nil {
+			return false
+		}
+		return compare(v, e)
+	}
+}
+
+func matchIp(value, expected string) bool {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+	_, network, err := parseCidr(expected)
+	if err != nil {
+		return false
+	}
+	return network.Contains(ip)
+}
+
+// parseCidr accepts a single address as a /32 or /128 network
+func parseCidr(value string) (net.IP, *net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		if ip := net.ParseIP(value); ip != nil {
+			if ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+	}
+	return net.ParseCIDR(value)
+}
+
+// matchWildcard matches s against the pattern, where "*" matches any characters including "/", and "?" one character
+func matchWildcard(pattern, s string) bool {
+	p, i := 0, 0
+	starP, starI := -1, 0
+	for i < len(s) {
+		if p < len(pattern) && (pattern[p] == '?' || pattern[p] == s[i]) {
+			p++
+			i++
+		} else if p < len(pattern) && pattern[p] == '*' {
+			starP, starI = p, i
+			p++
+		} else if starP >= 0 {
+			p = starP + 1
+			starI++
+			i = starI
+		} else {
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
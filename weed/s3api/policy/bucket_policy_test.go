@@ -0,0 +1,192 @@
+package policy
+
+import (
+	"testing"
+)
+
+func mustParse(t *testing.T, bucket, document string) *BucketPolicy {
+	policy, err := ParseBucketPolicy([]byte(document), bucket)
+	if err != nil {
+		t.Fatalf("parse policy: %v", err)
+	}
+	return policy
+}
+
+func TestPublicRead(t *testing.T) {
+	policy := mustParse(t, "examplebucket", `{
+		"Version": "2012-10-17",
+		"Statement": [{
+			"Sid": "PublicRead",
+			"Effect": "Allow",
+			"Principal": "*",
+			"Action": "s3:GetObject",
+			"Resource": "arn:aws:s3:::examplebucket/*"
+		}]
+	}`)
+	if d := policy.Evaluate(&Request{Action: ActionGetObject, Resource: ObjectResource("examplebucket", "/a/b.txt")}); d != DecisionAllow {
+		t.Errorf("anonymous get: %v", d)
+	}
+	if d := policy.Evaluate(&Request{Action: ActionPutObject, Resource: ObjectResource("examplebucket", "/a/b.txt")}); d != DecisionNone {
+		t.Errorf("anonymous put: %v", d)
+	}
+	if d := policy.Evaluate(&Request{Action: ActionListBucket, Resource: BucketResource("examplebucket")}); d != DecisionNone {
+		t.Errorf("anonymous list: %v", d)
+	}
+}
+
+func TestDenyInsecureTransport(t *testing.T) {
+	policy := mustParse(t, "examplebucket", `{
+		"Statement": [{
+			"Effect": "Deny",
+			"Principal": {"AWS": "*"},
+			"Action": "s3:*",
+			"Resource": ["arn:aws:s3:::examplebucket", "arn:aws:s3:::examplebucket/*"],
+			"Condition": {"Bool": {"aws:SecureTransport": "false"}}
+		}, {
+			"Effect": "Allow",
+			"Principal": {"AWS": "arn:aws:iam::123456789012:root"},
+			"Action": "s3:*",
+			"Resource": ["arn:aws:s3:::examplebucket", "arn:aws:s3:::examplebucket/*"]
+		}]
+	}`)
+	req := &Request{
+		Identity:        "alice",
+		AccountId:       "123456789012",
+		Action:          ActionPutObject,
+		Resource:        ObjectResource("examplebucket", "/x"),
+		ConditionValues: map[string][]string{"aws:SecureTransport": {"false"}},
+	}
+	if d := policy.Evaluate(req); d != DecisionDeny {
+		t.Errorf("insecure put: %v", d)
+	}
+	req.ConditionValues["aws:SecureTransport"] = []string{"true"}
+	if d := policy.Evaluate(req); d != DecisionAllow {
+		t.Errorf("secure put: %v", d)
+	}
+	req.AccountId = "000000000000"
+	if d := policy.Evaluate(req); d != DecisionNone {
+		t.Errorf("other account: %v", d)
+	}
+}
+
+func TestIpRestriction(t *testing.T) {
+	policy := mustParse(t, "examplebucket", `{
+		"Statement": [{
+			"Effect": "Allow",
+			"Principal": "*",
+			"Action": "s3:GetObject",
+			"Resource": "arn:aws:s3:::examplebucket/*",
+			"Condition": {
+				"IpAddress": {"aws:SourceIp": ["192.168.1.0/24", "2001:db8::/32"]},
+				"NotIpAddress": {"aws:SourceIp": "192.168.1.188"}
+			}
+		}]
+	}`)
+	tests := []struct {
+		ip       string
+		decision Decision
+	}{
+		{"192.168.1.10", DecisionAllow},
+		{"192.168.1.188", DecisionNone},
+		{"192.168.2.10", DecisionNone},
+		{"2001:db8::1", DecisionAllow},
+		{"", DecisionNone},
+	}
+	for _, tt := range tests {
+		req := &Request{Action: ActionGetObject, Resource: ObjectResource("examplebucket", "/x"), ConditionValues: map[string][]string{}}
+		if tt.ip != "" {
+			req.ConditionValues["aws:SourceIp"] = []string{tt.ip}
+		}
+		if d := policy.Evaluate(req); d != tt.decision {
+			t.Errorf("source ip %q: %v, expected %v", tt.ip, d, tt.decision)
+		}
+	}
+}
+
+func TestUserPrincipal(t *testing.T) {
+	policy := mustParse(t, "examplebucket", `{
+		"Statement": [{
+			"Effect": "Allow",
+			"Principal": {"AWS": ["arn:aws:iam::123456789012:user/bob", "carol"]},
+			"Action": ["s3:PutObject", "s3:DeleteObject"],
+			"Resource": "arn:aws:s3:::examplebucket/home/*"
+		}, {
+			"Effect": "Allow",
+			"Principal": {"AWS": "arn:aws:iam::123456789012:user/bob"},
+			"Action": "s3:ListBucket",
+			"Resource": "arn:aws:s3:::examplebucket",
+			"Condition": {"StringLike": {"s3:prefix": ["home/bob/*", "home/bob"]}}
+		}]
+	}`)
+	if d := policy.Evaluate(&Request{Identity: "bob", AccountId: "123456789012", Action: ActionPutObject, Resource: ObjectResource("examplebucket", "/home/x")}); d != DecisionAllow {
+		t.Errorf("bob put: %v", d)
+	}
+	if d := policy.Evaluate(&Request{Identity: "carol", Action: ActionDeleteObject, Resource: ObjectResource("examplebucket", "/home/x")}); d != DecisionAllow {
+		t.Errorf("carol delete: %v", d)
+	}
+	if d := policy.Evaluate(&Request{Identity: "dave", AccountId: "123456789012", Action: ActionPutObject, Resource: ObjectResource("examplebucket", "/home/x")}); d != DecisionNone {
+		t.Errorf("dave put: %v", d)
+	}
+	if d := policy.Evaluate(&Request{Action: ActionPutObject, Resource: ObjectResource("examplebucket", "/home/x")}); d != DecisionNone {
+		t.Errorf("anonymous put: %v", d)
+	}
+
+	list := &Request{Identity: "bob", AccountId: "123456789012", Action: ActionListBucket, Resource: BucketResource("examplebucket"),
+		ConditionValues: map[string][]string{"s3:prefix": {"home/bob/photos/"}}}
+	if d := policy.Evaluate(list); d != DecisionAllow {
+		t.Errorf("bob list own prefix: %v", d)
+	}
+	list.ConditionValues["s3:prefix"] = []string{"home/alice/"}
+	if d := policy.Evaluate(list); d != DecisionNone {
+		t.Errorf("bob list other prefix: %v", d)
+	}
+	delete(list.ConditionValues, "s3:prefix")
+	if d := policy.Evaluate(list); d != DecisionNone {
+		t.Errorf("bob list without prefix: %v", d)
+	}
+}
+
+func TestParseBucketPolicyErrors(t *testing.T) {
+	tests := map[string]string{
+		"not json":           `{`,
+		"no statement":       `{"Statement": []}`,
+		"bad effect":         `{"Statement": [{"Effect": "Maybe", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::b/*"}]}`,
+		"no principal":       `{"Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::b/*"}]}`,
+		"bad principal":      `{"Statement": [{"Effect": "Allow", "Principal": "bob", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::b/*"}]}`,
+		"unsupported action": `{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:PutBucketAcl", "Resource": "arn:aws:s3:::b"}]}`,
+		"other bucket":       `{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::other/*"}]}`,
+		"not an arn":         `{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "b/*"}]}`,
+		"bad operator":       `{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::b/*", "Condition": {"DateEquals": {"aws:CurrentTime": "2020-01-01"}}}]}`,
+		"bad cidr":           `{"Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::b/*", "Condition": {"IpAddress": {"aws:SourceIp": "10.0.0.0/99"}}}]}`,
+	}
+	for name, document := range tests {
+		if _, err := ParseBucketPolicy([]byte(document), "b"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"*", "", true},
+		{"*", "a/b/c", true},
+		{"a*", "a/b", true},
+		{"a*c", "abc/c", true},
+		{"a*c", "abcd", false},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"arn:aws:s3:::b/*.jpg", "arn:aws:s3:::b/x/y.jpg", true},
+		{"arn:aws:s3:::b/*.jpg", "arn:aws:s3:::b/x/y.png", false},
+		{"abc", "abc", true},
+		{"abc", "abcd", false},
+	}
+	for _, tt := range tests {
+		if got := matchWildcard(tt.pattern, tt.s); got != tt.match {
+			t.Errorf("matchWildcard(%q, %q) = %v", tt.pattern, tt.s, got)
+		}
+	}
+}
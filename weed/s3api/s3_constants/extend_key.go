@@ -1,7 +1,11 @@
 package s3_constants
 
 const (
-	ExtAmzOwnerKey  = "Seaweed-X-Amz-Owner"
-	ExtAmzAclKey    = "Seaweed-X-Amz-Acl"
-	ExtOwnershipKey = "Seaweed-X-Amz-Ownership"
+	ExtAmzOwnerKey     = "Seaweed-X-Amz-Owner"
+	ExtAmzAclKey       = "Seaweed-X-Amz-Acl"
+	ExtOwnershipKey    = "Seaweed-X-Amz-Ownership"
+	ExtCorsKey         = "s3-cors"
+	ExtInventoryKey    = "s3-inventory"
+	ExtInventoryRunKey = "s3-inventory-runs"
+	ExtPolicyKey       = "s3-bucket-policy"
 )
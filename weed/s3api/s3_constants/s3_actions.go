@@ -8,6 +8,7 @@ const (
 	ACTION_LIST    = "List"
 
 	SeaweedStorageDestinationHeader = "x-seaweedfs-destination"
+	SeaweedUploadIdHeader           = "X-SeaweedFS-Upload-Id"
 	MultipartUploadsFolder          = ".uploads"
 	FolderMimeType                  = "httpd/unix-directory"
 )
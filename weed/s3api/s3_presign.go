@@ -0,0 +1,69 @@
+package s3api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the presigned urls can not be valid for longer than 7 days
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// Presigner generates AWS Signature Version 4 presigned urls, which are verified by doesPresignedSignatureMatch.
+type Presigner struct {
+	Endpoint  string // e.g., http://localhost:8333
+	Region    string // default to us-east-1
+	AccessKey string
+	SecretKey string
+}
+
+// GeneratePresignedURL signs the method on the object for the expiry.
+// The extraHeaders are signed, and must be sent with the same values when the url is used.
+func (p *Presigner) GeneratePresignedURL(bucket, key, method string, expiry time.Duration, extraHeaders map[string]string) (string, error) {
+	return p.presign(bucket, key, method, expiry, extraHeaders, time.Now().UTC())
+}
+
+func (p *Presigner) presign(bucket, key, method string, expiry time.Duration, extraHeaders map[string]string, now time.Time) (string, error) {
+	if p.AccessKey == "" || p.SecretKey == "" {
+		return "", fmt.Errorf("presign without access key and secret key")
+	}
+	if expiry < time.Second || expiry > maxPresignExpiry {
+		return "", fmt.Errorf("presign expiry %v is not between 1 second and %v", expiry, maxPresignExpiry)
+	}
+	u, err := url.Parse(p.Endpoint)
+	if err != nil {
+		return "", fmt.Errorf("parse endpoint %s: %v", p.Endpoint, err)
+	}
+	u.Path = "/" + bucket
+	if key != "" {
+		u.Path += "/" + strings.TrimPrefix(key, "/")
+	}
+	region := p.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	signedHeaders := make(http.Header)
+	signedHeaders.Set("host", u.Host)
+	for k, v := range extraHeaders {
+		signedHeaders.Set(k, v)
+	}
+
+	query := make(url.Values)
+	query.Set("X-Amz-Algorithm", signV4Algorithm)
+	query.Set("X-Amz-Credential", p.AccessKey+"/"+getScope(now, region))
+	query.Set("X-Amz-Date", now.Format(iso8601Format))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry/time.Second)))
+	query.Set("X-Amz-SignedHeaders", getSignedHeaders(signedHeaders))
+
+	canonicalRequest := getCanonicalRequest(signedHeaders, unsignedPayload, query.Encode(), u.Path, method)
+	stringToSign := getStringToSign(canonicalRequest, now, getScope(now, region))
+	signature := getSignature(getSigningKey(p.SecretKey, now, region, "s3"), stringToSign)
+	query.Set("X-Amz-Signature", signature)
+
+	u.RawQuery = strings.Replace(query.Encode(), "+", "%20", -1)
+	return u.String(), nil
+}
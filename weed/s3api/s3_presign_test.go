@@ -0,0 +1,97 @@
+package s3api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+func newPresignTestIam() *IdentityAccessManagement {
+	iam := NewIdentityAccessManagement(&S3ApiServerOption{})
+	iam.identities = []*Identity{
+		{
+			Name: "someone",
+			Credentials: []*Credential{
+				{
+					AccessKey: "access_key_1",
+					SecretKey: "secret_key_1",
+				},
+			},
+		},
+	}
+	return iam
+}
+
+func TestGeneratePresignedURL(t *testing.T) {
+	iam := newPresignTestIam()
+	p := &Presigner{
+		Endpoint:  "http://127.0.0.1:8333",
+		AccessKey: "access_key_1",
+		SecretKey: "secret_key_1",
+	}
+
+	presignedUrl, err := p.GeneratePresignedURL("bucket1", "dir/some file.txt", "PUT", 10*time.Minute, map[string]string{
+		"Content-Type": "text/plain",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("PUT", presignedUrl, nil)
+	req.Header.Set("Content-Type", "text/plain")
+	if _, errCode := iam.reqSignatureV4Verify(req); errCode != s3err.ErrNone {
+		t.Errorf("verify %s: %v", presignedUrl, errCode)
+	}
+
+	// the signed header must match
+	req.Header.Set("Content-Type", "application/json")
+	if _, errCode := iam.reqSignatureV4Verify(req); errCode != s3err.ErrSignatureDoesNotMatch {
+		t.Errorf("verify with a changed header: %v", errCode)
+	}
+
+	// expired
+	presignedUrl, err = p.presign("bucket1", "file", "GET", time.Minute, nil, time.Now().UTC().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ = http.NewRequest("GET", presignedUrl, nil)
+	if _, errCode := iam.reqSignatureV4Verify(req); errCode != s3err.ErrExpiredPresignRequest {
+		t.Errorf("verify an expired url: %v", errCode)
+	}
+
+	if _, err = p.GeneratePresignedURL("bucket1", "file", "GET", 8*24*time.Hour, nil); err == nil {
+		t.Errorf("expect an error for the expiry longer than 7 days")
+	}
+}
+
+// the same signature as the aws sdk presigner
+func TestGeneratePresignedURLWithAwsSdk(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	p := &Presigner{
+		Endpoint:  "http://127.0.0.1:8333",
+		AccessKey: "access_key_1",
+		SecretKey: "secret_key_1",
+	}
+	presignedUrl, err := p.presign("bucket1", "dir/file.txt", "GET", 10*time.Minute, nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "http://127.0.0.1:8333/bucket1/dir/file.txt", nil)
+	signer := v4.NewSigner(credentials.NewStaticCredentials("access_key_1", "secret_key_1", ""))
+	if _, err = signer.Presign(req, nil, "s3", "us-east-1", 10*time.Minute, now); err != nil {
+		t.Fatal(err)
+	}
+	ours, _ := http.NewRequest("GET", presignedUrl, nil)
+	if got, want := ours.URL.Query().Get("X-Amz-Signature"), req.URL.Query().Get("X-Amz-Signature"); got != want {
+		t.Errorf("signature %s, aws sdk %s", got, want)
+	}
+
+	if _, errCode := newPresignTestIam().reqSignatureV4Verify(req); errCode != s3err.ErrNone {
+		t.Errorf("verify the aws sdk presigned url %s: %v", req.URL, errCode)
+	}
+}
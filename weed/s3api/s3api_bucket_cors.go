@@ -0,0 +1,332 @@
+package s3api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/private/protocol/xml/xmlutil"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const maxCorsRules = 100
+
+var corsAllowedMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPut:    true,
+	http.MethodPost:   true,
+	http.MethodDelete: true,
+	http.MethodHead:   true,
+}
+
+// GetBucketCorsHandler Get bucket CORS
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketCors.html
+func (s3a *S3ApiServer) GetBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("GetBucketCorsHandler %s", bucket)
+
+	errCode := s3a.checkAccessByOwnership(r, bucket)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	bucketEntry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		if err == filer_pb.ErrNotFound {
+			s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchBucket)
+			return
+		}
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	corsBytes, ok := bucketEntry.Extended[s3_constants.ExtCorsKey]
+	if !ok {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchCORSConfiguration)
+		return
+	}
+	var cors s3.CORSConfiguration
+	if err = json.Unmarshal(corsBytes, &cors); err != nil {
+		glog.Errorf("unmarshal CORS of bucket %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	s3err.WriteAwsXMLResponse(w, r, http.StatusOK, &s3.PutBucketCorsInput{
+		CORSConfiguration: &cors,
+	})
+}
+
+// PutBucketCorsHandler Put bucket CORS
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketCors.html
+func (s3a *S3ApiServer) PutBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("PutBucketCorsHandler %s", bucket)
+
+	errCode := s3a.checkAccessByOwnership(r, bucket)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidRequest)
+		return
+	}
+
+	var cors s3.CORSConfiguration
+	defer util.CloseRequest(r)
+
+	err := xmlutil.UnmarshalXML(&cors, xml.NewDecoder(r.Body), "")
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	if errCode = validateCorsConfiguration(&cors); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	corsBytes, err := json.Marshal(&cors)
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	bucketEntry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		if err == filer_pb.ErrNotFound {
+			s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchBucket)
+			return
+		}
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	if bucketEntry.Extended == nil {
+		bucketEntry.Extended = make(map[string][]byte)
+	}
+	bucketEntry.Extended[s3_constants.ExtCorsKey] = corsBytes
+	err = s3a.updateEntry(s3a.option.BucketsPath, bucketEntry)
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+// DeleteBucketCorsHandler Delete bucket CORS
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketCors.html
+func (s3a *S3ApiServer) DeleteBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	glog.V(3).Infof("DeleteBucketCorsHandler %s", bucket)
+
+	errCode := s3a.checkAccessByOwnership(r, bucket)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	bucketEntry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		if err == filer_pb.ErrNotFound {
+			s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchBucket)
+			return
+		}
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	if _, ok := bucketEntry.Extended[s3_constants.ExtCorsKey]; ok {
+		delete(bucketEntry.Extended, s3_constants.ExtCorsKey)
+		err = s3a.updateEntry(s3a.option.BucketsPath, bucketEntry)
+		if err != nil {
+			s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+			return
+		}
+	}
+
+	s3err.WriteEmptyResponse(w, r, http.StatusNoContent)
+}
+
+// PreflightCorsHandler answers the OPTIONS preflight requests by the bucket CORS rules.
+// The browsers do not send credentials on the preflight requests, so the caller is not authenticated.
+// The buckets without CORS rules allow everything, as before the per-bucket configuration.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/RESTOPTIONSobject.html
+func (s3a *S3ApiServer) PreflightCorsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+
+	metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	if metadata.Cors == nil || len(metadata.Cors.CORSRules) == 0 {
+		AllowAllCorsHandler(w, r)
+		return
+	}
+
+	origin := r.Header.Get("Origin")
+	method := r.Header.Get("Access-Control-Request-Method")
+	if origin == "" || method == "" {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidRequest)
+		return
+	}
+
+	var requestHeaders []string
+	for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		if header = strings.TrimSpace(header); header != "" {
+			requestHeaders = append(requestHeaders, header)
+		}
+	}
+
+	rule := matchCorsRule(metadata.Cors, origin, method, requestHeaders)
+	if rule == nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrCORSForbidden)
+		return
+	}
+
+	setCorsHeaders(w, rule, origin)
+	if len(requestHeaders) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(requestHeaders, ", "))
+	}
+	writeSuccessResponseEmpty(w, r)
+}
+
+// AllowAllCorsHandler answers the OPTIONS requests without the bucket CORS rules
+func AllowAllCorsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Expose-Headers", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "*")
+	writeSuccessResponseEmpty(w, r)
+}
+
+// corsMiddleware sets the CORS response headers if the request origin and method match a rule of the bucket
+func (s3a *S3ApiServer) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		bucket, _ := s3_constants.GetBucketAndObject(r)
+		if origin != "" && bucket != "" && r.Method != http.MethodOptions {
+			if metadata, errCode := s3a.bucketRegistry.GetBucketMetadata(bucket); errCode == s3err.ErrNone {
+				if rule := matchCorsRule(metadata.Cors, origin, r.Method, nil); rule != nil {
+					setCorsHeaders(w, rule, origin)
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func validateCorsConfiguration(cors *s3.CORSConfiguration) s3err.ErrorCode {
+	if len(cors.CORSRules) == 0 || len(cors.CORSRules) > maxCorsRules {
+		return s3err.ErrMalformedXML
+	}
+	for _, rule := range cors.CORSRules {
+		if len(rule.AllowedOrigins) == 0 || len(rule.AllowedMethods) == 0 {
+			return s3err.ErrMalformedXML
+		}
+		for _, method := range rule.AllowedMethods {
+			if !corsAllowedMethods[aws.StringValue(method)] {
+				return s3err.ErrInvalidRequest
+			}
+		}
+		for _, origin := range rule.AllowedOrigins {
+			if strings.Count(aws.StringValue(origin), "*") > 1 {
+				return s3err.ErrInvalidRequest
+			}
+		}
+		for _, header := range rule.AllowedHeaders {
+			if strings.Count(aws.StringValue(header), "*") > 1 {
+				return s3err.ErrInvalidRequest
+			}
+		}
+		if aws.Int64Value(rule.MaxAgeSeconds) < 0 {
+			return s3err.ErrInvalidRequest
+		}
+	}
+	return s3err.ErrNone
+}
+
+// matchCorsRule returns the first rule allowing the origin, the method, and all the request headers
+func matchCorsRule(cors *s3.CORSConfiguration, origin, method string, requestHeaders []string) *s3.CORSRule {
+	if cors == nil {
+		return nil
+	}
+	for _, rule := range cors.CORSRules {
+		if !corsMatchAny(rule.AllowedOrigins, origin, false) {
+			continue
+		}
+		if !corsMatchAny(rule.AllowedMethods, method, false) {
+			continue
+		}
+		headersAllowed := true
+		for _, header := range requestHeaders {
+			if !corsMatchAny(rule.AllowedHeaders, header, true) {
+				headersAllowed = false
+				break
+			}
+		}
+		if headersAllowed {
+			return rule
+		}
+	}
+	return nil
+}
+
+// corsMatchAny checks the value against the patterns, each with at most one "*" wildcard
+func corsMatchAny(patterns []*string, value string, ignoreCase bool) bool {
+	if ignoreCase {
+		value = strings.ToLower(value)
+	}
+	for _, p := range patterns {
+		pattern := aws.StringValue(p)
+		if ignoreCase {
+			pattern = strings.ToLower(pattern)
+		}
+		prefix, suffix, hasWildcard := strings.Cut(pattern, "*")
+		if !hasWildcard {
+			if pattern == value {
+				return true
+			}
+			continue
+		}
+		if len(value) >= len(prefix)+len(suffix) && strings.HasPrefix(value, prefix) && strings.HasSuffix(value, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func setCorsHeaders(w http.ResponseWriter, rule *s3.CORSRule, origin string) {
+	header := w.Header()
+	allowAnyOrigin := false
+	for _, allowedOrigin := range rule.AllowedOrigins {
+		allowAnyOrigin = allowAnyOrigin || aws.StringValue(allowedOrigin) == "*"
+	}
+	if allowAnyOrigin {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	header.Set("Access-Control-Allow-Methods", strings.Join(aws.StringValueSlice(rule.AllowedMethods), ", "))
+	if len(rule.ExposeHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(aws.StringValueSlice(rule.ExposeHeaders), ", "))
+	}
+	if rule.MaxAgeSeconds != nil {
+		header.Set("Access-Control-Max-Age", strconv.FormatInt(*rule.MaxAgeSeconds, 10))
+	}
+	header.Add("Vary", "Origin, Access-Control-Request-Headers, Access-Control-Request-Method")
+}
@@ -0,0 +1,138 @@
+package s3api
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/private/protocol/xml/xmlutil"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+const testCorsXml = `<CORSConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <CORSRule>
+    <AllowedOrigin>https://*.example.com</AllowedOrigin>
+    <AllowedMethod>PUT</AllowedMethod>
+    <AllowedMethod>POST</AllowedMethod>
+    <AllowedHeader>x-amz-*</AllowedHeader>
+    <AllowedHeader>Content-Type</AllowedHeader>
+    <ExposeHeader>ETag</ExposeHeader>
+    <MaxAgeSeconds>3000</MaxAgeSeconds>
+  </CORSRule>
+  <CORSRule>
+    <AllowedOrigin>*</AllowedOrigin>
+    <AllowedMethod>GET</AllowedMethod>
+  </CORSRule>
+</CORSConfiguration>`
+
+func parseTestCors(t *testing.T) *s3.CORSConfiguration {
+	var cors s3.CORSConfiguration
+	if err := xmlutil.UnmarshalXML(&cors, xml.NewDecoder(strings.NewReader(testCorsXml)), ""); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if errCode := validateCorsConfiguration(&cors); errCode != s3err.ErrNone {
+		t.Fatalf("validate: %v", errCode)
+	}
+	return &cors
+}
+
+func TestMatchCorsRule(t *testing.T) {
+	cors := parseTestCors(t)
+
+	tests := []struct {
+		origin  string
+		method  string
+		headers []string
+		rule    int
+	}{
+		{"https://app.example.com", "PUT", []string{"X-Amz-Date", "content-type"}, 0},
+		{"https://app.example.com", "PUT", []string{"Authorization"}, -1},
+		{"https://app.example.com", "GET", nil, 1},
+		{"http://app.example.com", "PUT", nil, -1},
+		{"https://example.com", "PUT", nil, -1},
+		{"https://other.org", "GET", nil, 1},
+		{"https://other.org", "DELETE", nil, -1},
+	}
+	for _, tt := range tests {
+		rule := matchCorsRule(cors, tt.origin, tt.method, tt.headers)
+		if tt.rule < 0 {
+			if rule != nil {
+				t.Errorf("%s %s %v: unexpected match %v", tt.origin, tt.method, tt.headers, rule)
+			}
+			continue
+		}
+		if rule != cors.CORSRules[tt.rule] {
+			t.Errorf("%s %s %v: matched %v, expecting rule %d", tt.origin, tt.method, tt.headers, rule, tt.rule)
+		}
+	}
+
+	if matchCorsRule(nil, "https://other.org", "GET", nil) != nil {
+		t.Errorf("matched without CORS configuration")
+	}
+}
+
+func TestSetCorsHeaders(t *testing.T) {
+	cors := parseTestCors(t)
+
+	w := httptest.NewRecorder()
+	setCorsHeaders(w, cors.CORSRules[0], "https://app.example.com")
+	expected := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "PUT, POST",
+		"Access-Control-Expose-Headers":    "ETag",
+		"Access-Control-Max-Age":           "3000",
+	}
+	for k, v := range expected {
+		if got := w.Header().Get(k); got != v {
+			t.Errorf("%s: %q, expecting %q", k, got, v)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	setCorsHeaders(w, cors.CORSRules[1], "https://other.org")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin: %q, expecting *", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("Access-Control-Max-Age: %q, expecting none", got)
+	}
+}
+
+func TestAllowAllCorsHandler(t *testing.T) {
+	r := httptest.NewRequest("OPTIONS", "/bucket/object", nil)
+	w := httptest.NewRecorder()
+	AllowAllCorsHandler(w, r)
+	if w.Code != 200 {
+		t.Errorf("status %d, expecting 200", w.Code)
+	}
+	for _, k := range []string{"Access-Control-Allow-Origin", "Access-Control-Expose-Headers", "Access-Control-Allow-Methods", "Access-Control-Allow-Headers"} {
+		if got := w.Header().Get(k); got != "*" {
+			t.Errorf("%s: %q, expecting *", k, got)
+		}
+	}
+}
+
+func TestValidateCorsConfiguration(t *testing.T) {
+	invalid := []*s3.CORSConfiguration{
+		{},
+		{CORSRules: []*s3.CORSRule{{AllowedMethods: aws.StringSlice([]string{"GET"})}}},
+		{CORSRules: []*s3.CORSRule{{
+			AllowedOrigins: aws.StringSlice([]string{"*"}),
+			AllowedMethods: aws.StringSlice([]string{"PATCH"}),
+		}}},
+		{CORSRules: []*s3.CORSRule{{
+			AllowedOrigins: aws.StringSlice([]string{"https://*.*.example.com"}),
+			AllowedMethods: aws.StringSlice([]string{"GET"}),
+		}}},
+	}
+	for i, cors := range invalid {
+		if validateCorsConfiguration(cors) == s3err.ErrNone {
+			t.Errorf("invalid configuration %d is accepted", i)
+		}
+	}
+}
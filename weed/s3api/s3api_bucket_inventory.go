@@ -0,0 +1,189 @@
+package s3api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/private/protocol/xml/xmlutil"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const inventoryBucketArnPrefix = "arn:aws:s3:::"
+
+// GetBucketInventoryConfigurationHandler Get bucket inventory configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketInventoryConfiguration.html
+func (s3a *S3ApiServer) GetBucketInventoryConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	id := r.URL.Query().Get("id")
+	glog.V(3).Infof("GetBucketInventoryConfigurationHandler %s %s", bucket, id)
+
+	errCode := s3a.checkAccessByOwnership(r, bucket)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	bucketEntry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		if err == filer_pb.ErrNotFound {
+			s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchBucket)
+			return
+		}
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	configs, err := loadInventoryConfigurations(bucketEntry)
+	if err != nil {
+		glog.Errorf("unmarshal inventory configurations of bucket %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	config, found := configs[id]
+	if !found {
+		s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchConfiguration)
+		return
+	}
+
+	s3err.WriteAwsXMLResponse(w, r, http.StatusOK, &s3.GetBucketInventoryConfigurationOutput{
+		InventoryConfiguration: config,
+	})
+}
+
+// PutBucketInventoryConfigurationHandler Put bucket inventory configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketInventoryConfiguration.html
+func (s3a *S3ApiServer) PutBucketInventoryConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := s3_constants.GetBucketAndObject(r)
+	id := r.URL.Query().Get("id")
+	glog.V(3).Infof("PutBucketInventoryConfigurationHandler %s %s", bucket, id)
+
+	errCode := s3a.checkAccessByOwnership(r, bucket)
+	if errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidRequest)
+		return
+	}
+
+	var config s3.InventoryConfiguration
+	defer util.CloseRequest(r)
+
+	err := xmlutil.UnmarshalXML(&config, xml.NewDecoder(r.Body), "")
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrMalformedXML)
+		return
+	}
+	if errCode = validateInventoryConfiguration(id, &config); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+
+	destBucket := strings.TrimPrefix(*config.Destination.S3BucketDestination.Bucket, inventoryBucketArnPrefix)
+	if _, errCode = s3a.bucketRegistry.GetBucketMetadata(destBucket); errCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, errCode)
+		return
+	}
+	// the reports are written with the permissions of the bucket owner, so the caller can not write where it may not
+	identity, _ := s3a.iam.lookupByName(r.Header.Get(s3_constants.AmzIdentityId))
+	if !s3a.iam.canPutObjectAs(r, identity, destBucket, "/"+inventoryReportDir(bucket, &config)+"/") {
+		s3err.WriteErrorResponse(w, r, s3err.ErrAccessDenied)
+		return
+	}
+
+	bucketEntry, err := s3a.getEntry(s3a.option.BucketsPath, bucket)
+	if err != nil {
+		if err == filer_pb.ErrNotFound {
+			s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchBucket)
+			return
+		}
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	configs, err := loadInventoryConfigurations(bucketEntry)
+	if err != nil {
+		glog.Errorf("unmarshal inventory configurations of bucket %s: %v", bucket, err)
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+	configs[id] = &config
+	configsBytes, err := json.Marshal(configs)
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	if bucketEntry.Extended == nil {
+		bucketEntry.Extended = make(map[string][]byte)
+	}
+	bucketEntry.Extended[s3_constants.ExtInventoryKey] = configsBytes
+	err = s3a.updateEntry(s3a.option.BucketsPath, bucketEntry)
+	if err != nil {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
+
+	writeSuccessResponseEmpty(w, r)
+}
+
+// loadInventoryConfigurations returns the inventory configurations of the bucket, by the configuration id
+func loadInventoryConfigurations(bucketEntry *filer_pb.Entry) (configs map[string]*s3.InventoryConfiguration, err error) {
+	configs = make(map[string]*s3.InventoryConfiguration)
+	if configsBytes, ok := bucketEntry.Extended[s3_constants.ExtInventoryKey]; ok && len(configsBytes) > 0 {
+		err = json.Unmarshal(configsBytes, &configs)
+	}
+	return
+}
+
+func validateInventoryConfiguration(id string, config *s3.InventoryConfiguration) s3err.ErrorCode {
+	if id == "" || aws.StringValue(config.Id) != id {
+		return s3err.ErrInvalidRequest
+	}
+	if config.IsEnabled == nil {
+		return s3err.ErrMalformedXML
+	}
+	if config.Destination == nil || config.Destination.S3BucketDestination == nil || config.Schedule == nil {
+		return s3err.ErrMalformedXML
+	}
+	dest := config.Destination.S3BucketDestination
+	if !strings.HasPrefix(aws.StringValue(dest.Bucket), inventoryBucketArnPrefix) ||
+		strings.TrimPrefix(aws.StringValue(dest.Bucket), inventoryBucketArnPrefix) == "" {
+		return s3err.ErrInvalidRequest
+	}
+	switch aws.StringValue(dest.Format) {
+	case s3.InventoryFormatCsv:
+	case s3.InventoryFormatOrc, s3.InventoryFormatParquet:
+		// only the csv reports are written
+		return s3err.ErrNotImplemented
+	default:
+		return s3err.ErrInvalidRequest
+	}
+	if dest.Encryption != nil {
+		return s3err.ErrNotImplemented
+	}
+	switch aws.StringValue(config.Schedule.Frequency) {
+	case s3.InventoryFrequencyDaily, s3.InventoryFrequencyWeekly:
+	default:
+		return s3err.ErrInvalidRequest
+	}
+	switch aws.StringValue(config.IncludedObjectVersions) {
+	case s3.InventoryIncludedObjectVersionsCurrent:
+	case s3.InventoryIncludedObjectVersionsAll:
+		// there are no object versions, so all versions are the current ones
+	default:
+		return s3err.ErrInvalidRequest
+	}
+	return s3err.ErrNone
+}
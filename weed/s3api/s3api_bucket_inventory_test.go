@@ -0,0 +1,114 @@
+package s3api
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/private/protocol/xml/xmlutil"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/policy"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+)
+
+const testInventoryXml = `<InventoryConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Id>report1</Id>
+  <IsEnabled>true</IsEnabled>
+  <Filter><Prefix>logs/</Prefix></Filter>
+  <Destination>
+    <S3BucketDestination>
+      <Format>CSV</Format>
+      <Bucket>arn:aws:s3:::inventories</Bucket>
+      <Prefix>reports</Prefix>
+    </S3BucketDestination>
+  </Destination>
+  <Schedule><Frequency>Weekly</Frequency></Schedule>
+  <IncludedObjectVersions>Current</IncludedObjectVersions>
+</InventoryConfiguration>`
+
+func TestValidateInventoryConfiguration(t *testing.T) {
+	var config s3.InventoryConfiguration
+	if err := xmlutil.UnmarshalXML(&config, xml.NewDecoder(strings.NewReader(testInventoryXml)), ""); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if errCode := validateInventoryConfiguration("report1", &config); errCode != s3err.ErrNone {
+		t.Fatalf("validate: %v", s3err.GetAPIError(errCode).Code)
+	}
+	if errCode := validateInventoryConfiguration("report2", &config); errCode != s3err.ErrInvalidRequest {
+		t.Errorf("mismatched id: %v", s3err.GetAPIError(errCode).Code)
+	}
+
+	config.Destination.S3BucketDestination.Format = aws.String(s3.InventoryFormatOrc)
+	if errCode := validateInventoryConfiguration("report1", &config); errCode != s3err.ErrNotImplemented {
+		t.Errorf("orc format: %v", s3err.GetAPIError(errCode).Code)
+	}
+	config.Destination.S3BucketDestination.Format = aws.String(s3.InventoryFormatCsv)
+
+	config.Destination.S3BucketDestination.Bucket = aws.String("inventories")
+	if errCode := validateInventoryConfiguration("report1", &config); errCode != s3err.ErrInvalidRequest {
+		t.Errorf("bucket without arn: %v", s3err.GetAPIError(errCode).Code)
+	}
+}
+
+func TestIsInventoryDue(t *testing.T) {
+	now := time.Now()
+	daily := &s3.InventoryConfiguration{Schedule: &s3.InventorySchedule{Frequency: aws.String(s3.InventoryFrequencyDaily)}}
+	weekly := &s3.InventoryConfiguration{Schedule: &s3.InventorySchedule{Frequency: aws.String(s3.InventoryFrequencyWeekly)}}
+
+	tests := []struct {
+		config  *s3.InventoryConfiguration
+		lastRun time.Time
+		due     bool
+	}{
+		{daily, time.Time{}, true},
+		{daily, now.Add(-23 * time.Hour), false},
+		{daily, now.Add(-24 * time.Hour), true},
+		{weekly, now.Add(-6 * 24 * time.Hour), false},
+		{weekly, now.Add(-7 * 24 * time.Hour), true},
+	}
+	for i, tt := range tests {
+		if due := isInventoryDue(tt.config, tt.lastRun, now); due != tt.due {
+			t.Errorf("case %d: due %v, expecting %v", i, due, tt.due)
+		}
+	}
+}
+
+func TestInventoryPutPermission(t *testing.T) {
+	iam := &IdentityAccessManagement{isAuthEnabled: true}
+	owner := &Identity{Name: "owner", Actions: []Action{"Write:inventories"}}
+	other := &Identity{Name: "other", Actions: []Action{"Write:logs"}}
+	r := httptest.NewRequest("PUT", "/inventories/reports/logs/report1/data/x.csv.gz", nil)
+	key := "/reports/logs/report1/data/x.csv.gz"
+
+	if !iam.canPutObjectAs(r, owner, "inventories", key) {
+		t.Errorf("owner can not write the report")
+	}
+	if iam.canPutObjectAs(r, other, "inventories", key) {
+		t.Errorf("other can write the report")
+	}
+	if iam.canPutObjectAs(r, nil, "inventories", key) {
+		t.Errorf("unknown owner can write the report")
+	}
+
+	denyReports, err := policy.ParseBucketPolicy([]byte(`{
+		"Statement": [{
+			"Effect": "Deny",
+			"Principal": "*",
+			"Action": "s3:PutObject",
+			"Resource": "arn:aws:s3:::inventories/reports/*"
+		}]
+	}`), "inventories")
+	if err != nil {
+		t.Fatal(err)
+	}
+	iam.getBucketPolicy = func(bucket string) *policy.BucketPolicy {
+		return denyReports
+	}
+	if iam.canPutObjectAs(r, owner, "inventories", key) {
+		t.Errorf("owner can write the report denied by the bucket policy")
+	}
+}
@@ -118,6 +118,20 @@ func (s3a *S3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 
 }
 
+// copySource returns the bucket and object of the X-Amz-Copy-Source header, found false if the request is not a copy
+func copySource(r *http.Request) (bucket, object string, found bool) {
+	header := r.Header.Get("X-Amz-Copy-Source")
+	if header == "" {
+		return "", "", false
+	}
+	cpSrcPath, err := url.QueryUnescape(header)
+	if err != nil {
+		cpSrcPath = header
+	}
+	bucket, object = pathToBucketAndObject(cpSrcPath)
+	return bucket, object, true
+}
+
 func pathToBucketAndObject(path string) (bucket, object string) {
 	path = strings.TrimPrefix(path, "/")
 	parts := strings.SplitN(path, "/", 2)
@@ -163,6 +177,10 @@ func (s3a *S3ApiServer) CopyObjectPartHandler(w http.ResponseWriter, r *http.Req
 	glog.V(3).Infof("CopyObjectPartHandler %s %s => %s part %d", srcBucket, srcObject, dstBucket, partID)
 
 	// check partID with maximum part ID for multipart objects
+	if partID < 1 {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidPart)
+		return
+	}
 	if partID > globalMaxPartID {
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidMaxParts)
 		return
@@ -170,8 +188,8 @@ func (s3a *S3ApiServer) CopyObjectPartHandler(w http.ResponseWriter, r *http.Req
 
 	rangeHeader := r.Header.Get("x-amz-copy-source-range")
 
-	dstUrl := fmt.Sprintf("http://%s%s/%s/%04d.part",
-		s3a.option.Filer.ToHttpAddress(), s3a.genUploadsFolder(dstBucket), uploadID, partID)
+	dstUrl := fmt.Sprintf("http://%s%s/%s/%s",
+		s3a.option.Filer.ToHttpAddress(), s3a.genUploadsFolder(dstBucket), uploadID, partFileName(partID))
 	srcUrl := fmt.Sprintf("http://%s%s/%s%s",
 		s3a.option.Filer.ToHttpAddress(), s3a.option.BucketsPath, srcBucket, urlEscapeObject(srcObject))
 
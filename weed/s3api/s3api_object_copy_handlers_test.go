@@ -2,8 +2,12 @@ package s3api
 
 import (
 	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/policy"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sort"
 	"strings"
@@ -436,3 +440,52 @@ func transferHeaderToH(data map[string][]string) H {
 	}
 	return m
 }
+
+func TestCopySourceAuthorization(t *testing.T) {
+	publicPolicy, err := policy.ParseBucketPolicy([]byte(`{
+		"Statement": [{
+			"Effect": "Allow",
+			"Principal": "*",
+			"Action": ["s3:GetObject", "s3:PutObject"],
+			"Resource": "arn:aws:s3:::public/*"
+		}]
+	}`), "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	iam := &IdentityAccessManagement{isAuthEnabled: true}
+	iam.getBucketPolicy = func(bucket string) *policy.BucketPolicy {
+		if bucket == "public" {
+			return publicPolicy
+		}
+		return nil
+	}
+	copyRequest := func(target, copySource string) *http.Request {
+		r := httptest.NewRequest("PUT", target, nil)
+		r.Header.Set("X-Amz-Copy-Source", copySource)
+		return mux.SetURLVars(r, map[string]string{"bucket": "public", "object": "copied"})
+	}
+
+	// the destination bucket policy allows anyone to write, but not to read the private bucket
+	for _, target := range []string{"/public/copied", "/public/copied?partNumber=1&uploadId=upload1"} {
+		if _, errCode := iam.authRequest(copyRequest(target, "/private/secret"), s3_constants.ACTION_WRITE); errCode != s3err.ErrAccessDenied {
+			t.Errorf("anonymous copy %s from a private bucket: %v", target, errCode)
+		}
+		if _, errCode := iam.authRequest(copyRequest(target, "public/shared%20file"), s3_constants.ACTION_WRITE); errCode != s3err.ErrNone {
+			t.Errorf("anonymous copy %s from the public bucket: %v", target, errCode)
+		}
+	}
+
+	writer := &Identity{Name: "writer", Actions: []Action{"Write:public"}}
+	reader := &Identity{Name: "reader", Actions: []Action{"Write:public", "Read:private"}}
+	r := copyRequest("/public/copied", "/private/secret")
+	if errCode := iam.authorizeCopySource(r, writer); errCode != s3err.ErrAccessDenied {
+		t.Errorf("copy from a bucket not readable by the identity: %v", errCode)
+	}
+	if errCode := iam.authorizeCopySource(r, reader); errCode != s3err.ErrNone {
+		t.Errorf("copy from a bucket readable by the identity: %v", errCode)
+	}
+	if errCode := iam.authorizeCopySource(httptest.NewRequest("PUT", "/public/copied", nil), writer); errCode != s3err.ErrNone {
+		t.Errorf("not a copy: %v", errCode)
+	}
+}
@@ -85,11 +85,11 @@ func (s3a *S3ApiServer) PutObjectHandler(w http.ResponseWriter, r *http.Request)
 			s3err.WriteErrorResponse(w, r, s3ErrCode)
 			return
 		}
-	} else {
-		if authTypeStreamingSigned == rAuthType {
-			s3err.WriteErrorResponse(w, r, s3err.ErrAuthNotSetup)
-			return
-		}
+	}
+	dataReader, s3ErrCode := s3a.iam.decodeAWSChunked(r, dataReader)
+	if s3ErrCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, s3ErrCode)
+		return
 	}
 	defer dataReader.Close()
 
@@ -115,7 +115,13 @@ func (s3a *S3ApiServer) PutObjectHandler(w http.ResponseWriter, r *http.Request)
 			dataReader = mimeDetect(r, dataReader)
 		}
 
-		etag, errCode := s3a.putToFiler(r, uploadUrl, dataReader, "", bucket)
+		var etag string
+		var errCode s3err.ErrorCode
+		if s3a.shouldUploadDirectly(r) {
+			etag, errCode = s3a.putToVolumes(r, dataReader, bucket, object)
+		} else {
+			etag, errCode = s3a.putToFiler(r, uploadUrl, dataReader, "", bucket)
+		}
 
 		if errCode != s3err.ErrNone {
 			s3err.WriteErrorResponse(w, r, errCode)
@@ -283,6 +289,14 @@ func (s3a *S3ApiServer) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *h
 			if object.ObjectName == "" {
 				continue
 			}
+			if s3a.isDeleteDeniedByBucketPolicy(r, bucket, object.ObjectName) {
+				deleteErrors = append(deleteErrors, DeleteError{
+					Code:    "AccessDenied",
+					Message: "Access Denied",
+					Key:     object.ObjectName,
+				})
+				continue
+			}
 			lastSeparator := strings.LastIndex(object.ObjectName, "/")
 			parentDirectoryPath, entryName, isDeleteData, isRecursive := "", object.ObjectName, true, false
 			if lastSeparator > 0 && lastSeparator+1 < len(object.ObjectName) {
@@ -0,0 +1,302 @@
+package s3api
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	weed_server "github.com/seaweedfs/seaweedfs/weed/server"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"golang.org/x/exp/slices"
+)
+
+// the chunks of one object or part uploaded at the same time, as the filer does
+const directUploadConcurrency = 4
+
+// shouldUploadDirectly is true for the objects larger than the filer chunk size, if -directUpload is set
+func (s3a *S3ApiServer) shouldUploadDirectly(r *http.Request) bool {
+	if !s3a.option.DirectUpload || s3a.option.ChunkSizeLimit <= 0 {
+		return false
+	}
+	return expectedBodySize(r) > s3a.option.ChunkSizeLimit
+}
+
+// expectedBodySize is the object size announced by the request, or -1 if unknown
+func expectedBodySize(r *http.Request) int64 {
+	// the aws-chunked bodies are larger than the object for the chunk framing
+	if decoded, err := strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64); err == nil {
+		return decoded
+	}
+	return r.ContentLength
+}
+
+// putToVolumes uploads the object in chunks to the volume servers assigned by the filer, and then creates
+// the entry on the filer. Unlike putToFiler, the object data is not copied through the filer.
+// The uploaded chunks are deleted if the object fails to complete.
+func (s3a *S3ApiServer) putToVolumes(r *http.Request, dataReader io.Reader, bucket, object string) (etag string, code s3err.ErrorCode) {
+	fullPath := util.FullPath(fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, removeDuplicateSlashes(object)))
+	dir, name := fullPath.DirAndName()
+
+	var collection string
+	if s3a.option.FilerGroup != "" {
+		collection = s3a.getCollectionName(bucket)
+	}
+	mime := r.Header.Get("Content-Type")
+	if mime == "application/octet-stream" {
+		mime = ""
+	}
+
+	chunks, md5bytes, size, code := s3a.uploadToVolumes(dataReader, expectedBodySize(r), string(fullPath), collection)
+	if code != s3err.ErrNone {
+		return "", code
+	}
+
+	manifestedChunks, err := filer.MaybeManifestize(func(reader io.Reader, filename string, offset int64, tsNs int64) (*filer_pb.FileChunk, error) {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		return s3a.uploadChunk(string(fullPath), collection, data, offset)
+	}, chunks)
+	if err != nil {
+		glog.Errorf("direct upload %s manifest: %v", fullPath, err)
+		s3a.deleteChunks(chunks)
+		return "", s3err.ErrInternalError
+	}
+
+	err = s3a.mkFile(dir, name, manifestedChunks, func(entry *filer_pb.Entry) {
+		entry.Attributes.FileMode = uint32(0660)
+		entry.Attributes.Mime = mime
+		entry.Attributes.Md5 = md5bytes
+		entry.Attributes.FileSize = uint64(size)
+		entry.Extended = objectExtended(r)
+	})
+	if err != nil {
+		glog.Errorf("direct upload %s: %v", fullPath, err)
+		// the chunks are not referenced by any entry
+		s3a.deleteChunks(manifestedChunks)
+		if len(manifestedChunks) != len(chunks) {
+			s3a.deleteChunks(chunks)
+		}
+		return "", filerErrorToS3Error(err.Error())
+	}
+
+	return fmt.Sprintf("%x", md5bytes), s3err.ErrNone
+}
+
+// putPartToVolumes uploads a part of the multipart upload to the volume servers directly, and creates the part
+// entry in the upload folder. The parts are separate entries until the upload completes, so they can be
+// uploaded in any order and at the same time.
+func (s3a *S3ApiServer) putPartToVolumes(r *http.Request, dataReader io.Reader, bucket, uploadID string, partID int, destination string) (etag string, code s3err.ErrorCode) {
+	uploadDir := s3a.genUploadsFolder(bucket) + "/" + uploadID
+	partName := partFileName(partID)
+
+	var collection string
+	if s3a.option.FilerGroup != "" {
+		collection = s3a.getCollectionName(bucket)
+	}
+
+	// assign the volumes for the destination, to follow the storage rules of the object
+	chunks, md5bytes, size, code := s3a.uploadToVolumes(dataReader, expectedBodySize(r), destination, collection)
+	if code != s3err.ErrNone {
+		return "", code
+	}
+
+	err := s3a.mkFile(uploadDir, partName, chunks, func(entry *filer_pb.Entry) {
+		entry.Attributes.FileMode = uint32(0660)
+		entry.Attributes.Md5 = md5bytes
+		entry.Attributes.FileSize = uint64(size)
+	})
+	if err != nil {
+		glog.Errorf("direct upload %s/%s: %v", uploadDir, partName, err)
+		s3a.deleteChunks(chunks)
+		return "", s3err.ErrInternalError
+	}
+
+	return fmt.Sprintf("%x", md5bytes), s3err.ErrNone
+}
+
+// uploadToVolumes reads the data in chunks of ChunkSizeLimit, and uploads up to directUploadConcurrency chunks
+// at the same time. The returned chunks are sorted by offset. The uploaded chunks are deleted on failure,
+// including a read error or a body shorter or longer than the expectedSize, unless it is -1.
+func (s3a *S3ApiServer) uploadToVolumes(dataReader io.Reader, expectedSize int64, path, collection string) (chunks []*filer_pb.FileChunk, md5bytes []byte, size int64, code s3err.ErrorCode) {
+	hash := md5.New()
+	reader := io.TeeReader(dataReader, hash)
+
+	var wg sync.WaitGroup
+	var chunksLock sync.Mutex
+	var uploadErr error
+	limitChan := make(chan struct{}, directUploadConcurrency)
+	for {
+		limitChan <- struct{}{}
+
+		chunksLock.Lock()
+		failed := uploadErr != nil
+		chunksLock.Unlock()
+		if failed {
+			<-limitChan
+			break
+		}
+
+		buf := make([]byte, s3a.option.ChunkSizeLimit)
+		n, readErr := readFullChunk(reader, buf)
+		if n > 0 {
+			wg.Add(1)
+			go func(data []byte, offset int64) {
+				defer func() {
+					<-limitChan
+					wg.Done()
+				}()
+				chunk, err := s3a.uploadChunk(path, collection, data, offset)
+				chunksLock.Lock()
+				defer chunksLock.Unlock()
+				if err != nil {
+					if uploadErr == nil {
+						uploadErr = err
+					}
+					return
+				}
+				chunks = append(chunks, chunk)
+			}(buf[:n], size)
+			size += int64(n)
+		} else {
+			<-limitChan
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr == nil && expectedSize >= 0 && size > expectedSize {
+			readErr = fmt.Errorf("more than the expected %d bytes", expectedSize)
+		}
+		if readErr != nil {
+			wg.Wait()
+			glog.Errorf("direct upload %s read: %v", path, readErr)
+			s3a.deleteChunks(chunks)
+			return nil, nil, 0, s3err.ErrIncompleteBody
+		}
+	}
+	wg.Wait()
+
+	if uploadErr == nil && expectedSize >= 0 && size != expectedSize {
+		glog.Errorf("direct upload %s: read %d bytes, expected %d", path, size, expectedSize)
+		s3a.deleteChunks(chunks)
+		return nil, nil, 0, s3err.ErrIncompleteBody
+	}
+
+	if uploadErr != nil {
+		glog.Errorf("direct upload %s: %v", path, uploadErr)
+		s3a.deleteChunks(chunks)
+		return nil, nil, 0, s3err.ErrInternalError
+	}
+
+	slices.SortFunc(chunks, func(a, b *filer_pb.FileChunk) bool {
+		return a.Offset < b.Offset
+	})
+	return chunks, hash.Sum(nil), size, s3err.ErrNone
+}
+
+// readFullChunk fills the buf, unless the data ends. Unlike io.ReadFull, the end of the data is only io.EOF,
+// and any other error, e.g., an io.ErrUnexpectedEOF from a truncated body, is returned.
+func readFullChunk(reader io.Reader, buf []byte) (n int, err error) {
+	for n < len(buf) && err == nil {
+		var nn int
+		nn, err = reader.Read(buf[n:])
+		n += nn
+	}
+	if err == io.EOF && n == len(buf) {
+		err = nil
+	}
+	return n, err
+}
+
+func (s3a *S3ApiServer) uploadChunk(path, collection string, data []byte, offset int64) (*filer_pb.FileChunk, error) {
+	fileId, uploadResult, err, _ := operation.UploadWithRetry(
+		s3a,
+		&filer_pb.AssignVolumeRequest{
+			Count:      1,
+			Collection: collection,
+			DataCenter: s3a.option.DataCenter,
+			Path:       path,
+		},
+		&operation.UploadOption{
+			Filename: path,
+			Cipher:   s3a.option.Cipher,
+		},
+		func(host, fileId string) string {
+			return fmt.Sprintf("http://%s/%s", host, fileId)
+		},
+		util.NewBytesReader(data),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("upload chunk at %d: %v", offset, err)
+	}
+	if uploadResult.Error != "" {
+		return nil, fmt.Errorf("upload chunk at %d: %v", offset, uploadResult.Error)
+	}
+	return uploadResult.ToPbFileChunk(fileId, offset, time.Now().UnixNano()), nil
+}
+
+func (s3a *S3ApiServer) deleteChunks(chunks []*filer_pb.FileChunk) {
+	if len(chunks) == 0 {
+		return
+	}
+	var fileIds []string
+	for _, chunk := range chunks {
+		fileIds = append(fileIds, chunk.GetFileIdString())
+	}
+	if _, err := operation.DeleteFilesWithLookupVolumeId(s3a.option.GrpcDialOption, fileIds, s3a.lookupVolumeIds); err != nil {
+		glog.Errorf("delete %d orphaned chunks: %v", len(fileIds), err)
+	}
+}
+
+func (s3a *S3ApiServer) lookupVolumeIds(vids []string) (map[string]*operation.LookupResult, error) {
+	results := make(map[string]*operation.LookupResult)
+	err := s3a.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err := client.LookupVolume(context.Background(), &filer_pb.LookupVolumeRequest{VolumeIds: vids})
+		if err != nil {
+			return err
+		}
+		for vid, locations := range resp.LocationsMap {
+			result := &operation.LookupResult{VolumeOrFileId: vid}
+			for _, loc := range locations.Locations {
+				result.Locations = append(result.Locations, operation.Location{
+					Url:        loc.Url,
+					PublicUrl:  loc.PublicUrl,
+					DataCenter: loc.DataCenter,
+					GrpcPort:   int(loc.GrpcPort),
+				})
+			}
+			results[vid] = result
+		}
+		return nil
+	})
+	return results, err
+}
+
+// objectExtended keeps the same object metadata from the request headers as the filer does on upload
+func objectExtended(r *http.Request) map[string][]byte {
+	extended := weed_server.SaveAmzMetaData(r, nil, false)
+	for k, v := range r.Header {
+		if len(v) > 0 && len(v[0]) > 0 {
+			if strings.HasPrefix(k, needle.PairNamePrefix) || k == "Cache-Control" || k == "Expires" || k == "Content-Disposition" {
+				extended[k] = []byte(v[0])
+			}
+			if k == "Response-Content-Disposition" {
+				extended["Content-Disposition"] = []byte(v[0])
+			}
+		}
+	}
+	return extended
+}
@@ -0,0 +1,72 @@
+package s3api
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShouldUploadDirectly(t *testing.T) {
+	s3a := &S3ApiServer{option: &S3ApiServerOption{ChunkSizeLimit: 4}}
+	large := httptest.NewRequest("PUT", "/bucket/object", strings.NewReader("12345"))
+	small := httptest.NewRequest("PUT", "/bucket/object", strings.NewReader("1234"))
+
+	assert.False(t, s3a.shouldUploadDirectly(large), "not enabled")
+
+	s3a.option.DirectUpload = true
+	assert.True(t, s3a.shouldUploadDirectly(large))
+	assert.False(t, s3a.shouldUploadDirectly(small))
+
+	// the aws-chunked framing is not counted
+	small.Header.Set("X-Amz-Decoded-Content-Length", "3")
+	large.Header.Set("X-Amz-Decoded-Content-Length", "30")
+	assert.Equal(t, int64(3), expectedBodySize(small))
+	assert.False(t, s3a.shouldUploadDirectly(small))
+	assert.True(t, s3a.shouldUploadDirectly(large))
+}
+
+func TestReadFullChunk(t *testing.T) {
+	buf := make([]byte, 4)
+
+	reader := iotest.OneByteReader(strings.NewReader("123456"))
+	n, err := readFullChunk(reader, buf)
+	assert.Equal(t, 4, n)
+	assert.NoError(t, err)
+	n, err = readFullChunk(reader, buf)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, io.EOF, err)
+
+	// a truncated body is an error, not the end of the data
+	n, err = readFullChunk(io.MultiReader(strings.NewReader("12"), iotest.ErrReader(io.ErrUnexpectedEOF)), buf)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestUploadToVolumesIncompleteBody(t *testing.T) {
+	s3a := &S3ApiServer{option: &S3ApiServerOption{ChunkSizeLimit: 4}}
+
+	_, _, _, code := s3a.uploadToVolumes(strings.NewReader(""), 5, "/buckets/b/o", "")
+	assert.Equal(t, s3err.ErrIncompleteBody, code, "shorter than the content length")
+
+	_, _, _, code = s3a.uploadToVolumes(iotest.ErrReader(io.ErrUnexpectedEOF), -1, "/buckets/b/o", "")
+	assert.Equal(t, s3err.ErrIncompleteBody, code, "read error")
+}
+
+func TestObjectExtended(t *testing.T) {
+	r := httptest.NewRequest("PUT", "/bucket/object", nil)
+	r.Header.Set("X-Amz-Meta-Color", "blue")
+	r.Header.Set("Cache-Control", "no-cache")
+	r.Header.Set("Response-Content-Disposition", "attachment")
+	r.Header.Set("Authorization", "secret")
+
+	extended := objectExtended(r)
+	assert.Equal(t, "blue", string(extended["X-Amz-Meta-Color"]))
+	assert.Equal(t, "no-cache", string(extended["Cache-Control"]))
+	assert.Equal(t, "attachment", string(extended["Content-Disposition"]))
+	assert.NotContains(t, extended, "Authorization")
+}
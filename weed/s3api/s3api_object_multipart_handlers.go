@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3err"
 	weed_server "github.com/seaweedfs/seaweedfs/weed/server"
@@ -23,7 +24,7 @@ const (
 	maxObjectListSizeLimit = 10000 // Limit number of objects in a listObjectsResponse.
 	maxUploadsList         = 10000 // Limit number of uploads in a listUploadsResponse.
 	maxPartsList           = 10000 // Limit number of parts in a listPartsResponse.
-	globalMaxPartID        = 100000
+	globalMaxPartID        = 10000
 )
 
 // NewMultipartUploadHandler - New multipart upload.
@@ -221,10 +222,22 @@ func (s3a *S3ApiServer) PutObjectPartHandler(w http.ResponseWriter, r *http.Requ
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidPart)
 		return
 	}
+	if partID < 1 {
+		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidPart)
+		return
+	}
 	if partID > globalMaxPartID {
 		s3err.WriteErrorResponse(w, r, s3err.ErrInvalidMaxParts)
 		return
 	}
+	if _, err := s3a.getEntry(s3a.genUploadsFolder(bucket), uploadID); err != nil {
+		if err == filer_pb.ErrNotFound {
+			s3err.WriteErrorResponse(w, r, s3err.ErrNoSuchUpload)
+			return
+		}
+		s3err.WriteErrorResponse(w, r, s3err.ErrInternalError)
+		return
+	}
 
 	dataReader := r.Body
 	if s3a.iam.isEnabled() {
@@ -243,19 +256,35 @@ func (s3a *S3ApiServer) PutObjectPartHandler(w http.ResponseWriter, r *http.Requ
 			return
 		}
 	}
+	dataReader, s3ErrCode := s3a.iam.decodeAWSChunked(r, dataReader)
+	if s3ErrCode != s3err.ErrNone {
+		s3err.WriteErrorResponse(w, r, s3ErrCode)
+		return
+	}
 	defer dataReader.Close()
 
 	glog.V(2).Infof("PutObjectPartHandler %s %s %04d", bucket, uploadID, partID)
 
-	uploadUrl := fmt.Sprintf("http://%s%s/%s/%04d.part",
-		s3a.option.Filer.ToHttpAddress(), s3a.genUploadsFolder(bucket), uploadID, partID)
+	uploadUrl := fmt.Sprintf("http://%s%s/%s/%s",
+		s3a.option.Filer.ToHttpAddress(), s3a.genUploadsFolder(bucket), uploadID, partFileName(partID))
 
 	if partID == 1 && r.Header.Get("Content-Type") == "" {
 		dataReader = mimeDetect(r, dataReader)
 	}
 	destination := fmt.Sprintf("%s/%s%s", s3a.option.BucketsPath, bucket, object)
 
-	etag, errCode := s3a.putToFiler(r, uploadUrl, dataReader, destination, bucket)
+	// the filer tracks the progress of all the parts under the multipart upload id
+	if r.Header.Get(s3_constants.SeaweedUploadIdHeader) == "" {
+		r.Header.Set(s3_constants.SeaweedUploadIdHeader, uploadID)
+	}
+
+	var etag string
+	var errCode s3err.ErrorCode
+	if s3a.shouldUploadDirectly(r) {
+		etag, errCode = s3a.putPartToVolumes(r, dataReader, bucket, uploadID, partID, destination)
+	} else {
+		etag, errCode = s3a.putToFiler(r, uploadUrl, dataReader, destination, bucket)
+	}
 	if errCode != s3err.ErrNone {
 		s3err.WriteErrorResponse(w, r, errCode)
 		return
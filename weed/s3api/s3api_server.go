@@ -32,6 +32,10 @@ type S3ApiServerOption struct {
 	LocalFilerSocket          string
 	DataCenter                string
 	FilerGroup                string
+	// upload the objects larger than ChunkSizeLimit to the volume servers directly, encrypted if Cipher is set
+	DirectUpload   bool
+	ChunkSizeLimit int64
+	Cipher         bool
 }
 
 type S3ApiServer struct {
@@ -65,6 +69,7 @@ func NewS3ApiServer(router *mux.Router, option *S3ApiServerOption) (s3ApiServer
 	}
 	s3ApiServer.accountManager = s3account.NewAccountManager(s3ApiServer)
 	s3ApiServer.bucketRegistry = NewBucketRegistry(s3ApiServer)
+	s3ApiServer.iam.getBucketPolicy = s3ApiServer.getBucketPolicy
 	if option.LocalFilerSocket == "" {
 		s3ApiServer.client = &http.Client{Transport: &http.Transport{
 			MaxIdleConns:        1024,
@@ -83,6 +88,7 @@ func NewS3ApiServer(router *mux.Router, option *S3ApiServerOption) (s3ApiServer
 	s3ApiServer.registerRouter(router)
 
 	go s3ApiServer.subscribeMetaEvents("s3", time.Now().UnixNano(), filer.DirectoryEtcRoot, []string{option.BucketsPath})
+	go s3ApiServer.loopInventory()
 	return s3ApiServer, nil
 }
 
@@ -93,15 +99,6 @@ func (s3a *S3ApiServer) registerRouter(router *mux.Router) {
 	// Readiness Probe
 	apiRouter.Methods("GET").Path("/status").HandlerFunc(s3a.StatusHandler)
 
-	apiRouter.Methods("OPTIONS").HandlerFunc(
-		func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Expose-Headers", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "*")
-			w.Header().Set("Access-Control-Allow-Headers", "*")
-			writeSuccessResponseEmpty(w, r)
-		})
-
 	var routers []*mux.Router
 	if s3a.option.DomainName != "" {
 		domainNames := strings.Split(s3a.option.DomainName, ",")
@@ -116,6 +113,12 @@ func (s3a *S3ApiServer) registerRouter(router *mux.Router) {
 
 	for _, bucket := range routers {
 
+		// set the CORS response headers by the bucket CORS rules
+		bucket.Use(s3a.corsMiddleware)
+
+		// CORS preflight, not authenticated
+		bucket.Methods("OPTIONS").HandlerFunc(track(s3a.PreflightCorsHandler, "OPTIONS"))
+
 		// each case should follow the next rule:
 		// - requesting object with query must precede any other methods
 		// - requesting object must precede any methods with buckets
@@ -208,6 +211,11 @@ func (s3a *S3ApiServer) registerRouter(router *mux.Router) {
 		// DeleteBucketLifecycleConfiguration
 		bucket.Methods("DELETE").HandlerFunc(track(s3a.iam.Auth(s3a.cb.Limit(s3a.DeleteBucketLifecycleHandler, ACTION_WRITE)), "DELETE")).Queries("lifecycle", "")
 
+		// GetBucketInventoryConfiguration
+		bucket.Methods("GET").HandlerFunc(track(s3a.iam.Auth(s3a.cb.Limit(s3a.GetBucketInventoryConfigurationHandler, ACTION_READ)), "GET")).Queries("inventory", "")
+		// PutBucketInventoryConfiguration
+		bucket.Methods("PUT").HandlerFunc(track(s3a.iam.Auth(s3a.cb.Limit(s3a.PutBucketInventoryConfigurationHandler, ACTION_WRITE)), "PUT")).Queries("inventory", "")
+
 		// GetBucketLocation
 		bucket.Methods("GET").HandlerFunc(track(s3a.iam.Auth(s3a.cb.Limit(s3a.GetBucketLocationHandler, ACTION_READ)), "GET")).Queries("location", "")
 
@@ -250,6 +258,9 @@ func (s3a *S3ApiServer) registerRouter(router *mux.Router) {
 	// ListBuckets
 	apiRouter.Methods("GET").Path("/").HandlerFunc(track(s3a.ListBucketsHandler, "LIST"))
 
+	// CORS preflight outside of the buckets
+	apiRouter.Methods("OPTIONS").HandlerFunc(track(AllowAllCorsHandler, "OPTIONS"))
+
 	// NotFound
 	apiRouter.NotFoundHandler = http.HandlerFunc(s3err.NotFoundHandler)
 
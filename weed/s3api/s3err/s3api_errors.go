@@ -76,10 +76,12 @@ const (
 	ErrPostPolicyConditionInvalidFormat
 	ErrEntityTooSmall
 	ErrEntityTooLarge
+	ErrIncompleteBody
 	ErrMissingFields
 	ErrMissingCredTag
 	ErrCredMalformed
 	ErrMalformedXML
+	ErrMalformedPolicy
 	ErrMalformedDate
 	ErrMalformedPresignedDate
 	ErrMalformedCredentialDate
@@ -109,6 +111,8 @@ const (
 	ErrRequestBytesExceed
 
 	OwnershipControlsNotFoundError
+	ErrCORSForbidden
+	ErrNoSuchConfiguration
 )
 
 // error code to APIError structure, these fields carry respective
@@ -236,6 +240,11 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "The XML you provided was not well-formed or did not validate against our published schema.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrMalformedPolicy: {
+		Code:           "MalformedPolicy",
+		Description:    "The bucket policy is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrAuthHeaderEmpty: {
 		Code:           "InvalidArgument",
 		Description:    "Authorization header is invalid -- one and only one ' ' (space) required.",
@@ -271,6 +280,11 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "Your proposed upload exceeds the maximum allowed object size.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrIncompleteBody: {
+		Code:           "IncompleteBody",
+		Description:    "You did not provide the number of bytes specified by the Content-Length HTTP header.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrMissingFields: {
 		Code:           "MissingFields",
 		Description:    "Missing fields in request.",
@@ -422,6 +436,16 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "The bucket ownership controls were not found",
 		HTTPStatusCode: http.StatusNotFound,
 	},
+	ErrCORSForbidden: {
+		Code:           "AccessForbidden",
+		Description:    "CORSResponse: This CORS request is not allowed.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrNoSuchConfiguration: {
+		Code:           "NoSuchConfiguration",
+		Description:    "The specified configuration does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
 }
 
 // GetAPIError provides API Error for input API error code.
@@ -124,3 +124,25 @@ func (g *Guard) checkWhiteList(w http.ResponseWriter, r *http.Request) error {
 	glog.V(0).Infof("Not in whitelist: %s", r.RemoteAddr)
 	return fmt.Errorf("Not in whitelist: %s", r.RemoteAddr)
 }
+
+// CheckFilerRead checks the white list, and the filer read jwt if the read signing key is set
+func (g *Guard) CheckFilerRead(r *http.Request) error {
+	if err := g.checkWhiteList(nil, r); err != nil {
+		return err
+	}
+	if len(g.ReadSigningKey) == 0 {
+		return nil
+	}
+	tokenStr := GetJwt(r)
+	if tokenStr == "" {
+		return ErrUnauthorized
+	}
+	token, err := DecodeJwt(g.ReadSigningKey, tokenStr, &SeaweedFilerClaims{})
+	if err != nil {
+		return fmt.Errorf("jwt verification: %v", err)
+	}
+	if !token.Valid {
+		return ErrUnauthorized
+	}
+	return nil
+}
@@ -1,9 +1,11 @@
 package security
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/credentials/tls/certprovider/pemfile"
 	"google.golang.org/grpc/security/advancedtls"
@@ -90,13 +92,19 @@ func LoadServerTLS(config *util.ViperProxy, component string) (grpc.ServerOption
 }
 
 func LoadClientTLS(config *util.ViperProxy, component string) grpc.DialOption {
+	return grpc.WithTransportCredentials(LoadClientTransportCredentials(config, component))
+}
+
+// LoadClientTransportCredentials is the same as LoadClientTLS, but returns the credentials
+// so that they can be bundled with per-RPC credentials.
+func LoadClientTransportCredentials(config *util.ViperProxy, component string) credentials.TransportCredentials {
 	if config == nil {
-		return grpc.WithTransportCredentials(insecure.NewCredentials())
+		return insecure.NewCredentials()
 	}
 
 	certFileName, keyFileName, caFileName := config.GetString(component+".cert"), config.GetString(component+".key"), config.GetString("grpc.ca")
 	if certFileName == "" || keyFileName == "" || caFileName == "" {
-		return grpc.WithTransportCredentials(insecure.NewCredentials())
+		return insecure.NewCredentials()
 	}
 
 	clientOptions := pemfile.Options{
@@ -107,7 +115,7 @@ func LoadClientTLS(config *util.ViperProxy, component string) grpc.DialOption {
 	clientProvider, err := pemfile.NewProvider(clientOptions)
 	if err != nil {
 		glog.Warningf("pemfile.NewProvider(%v) failed %v", clientOptions, err)
-		return grpc.WithTransportCredentials(insecure.NewCredentials())
+		return insecure.NewCredentials()
 	}
 	clientRootOptions := pemfile.Options{
 		RootFile:        config.GetString("grpc.ca"),
@@ -116,7 +124,7 @@ func LoadClientTLS(config *util.ViperProxy, component string) grpc.DialOption {
 	clientRootProvider, err := pemfile.NewProvider(clientRootOptions)
 	if err != nil {
 		glog.Warningf("pemfile.NewProvider(%v) failed: %v", clientRootOptions, err)
-		return grpc.WithTransportCredentials(insecure.NewCredentials())
+		return insecure.NewCredentials()
 	}
 	options := &advancedtls.ClientOptions{
 		IdentityOptions: advancedtls.IdentityCertificateOptions{
@@ -133,9 +141,9 @@ func LoadClientTLS(config *util.ViperProxy, component string) grpc.DialOption {
 	ta, err := advancedtls.NewClientCreds(options)
 	if err != nil {
 		glog.Warningf("advancedtls.NewClientCreds(%v) failed: %v", options, err)
-		return grpc.WithTransportCredentials(insecure.NewCredentials())
+		return insecure.NewCredentials()
 	}
-	return grpc.WithTransportCredentials(ta)
+	return ta
 }
 
 func LoadClientTLSHTTP(clientCertFile string) *tls.Config {
@@ -155,6 +163,40 @@ func LoadClientTLSHTTP(clientCertFile string) *tls.Config {
 	}
 }
 
+// LoadServerTLSConfig loads the cert and key of a server not using grpc, e.g., "weed ftp".
+// The files are reloaded periodically, so that the certificate can be renewed in place.
+func LoadServerTLSConfig(config *util.ViperProxy, component string) (*tls.Config, error) {
+	if config == nil {
+		return nil, nil
+	}
+	options := pemfile.Options{
+		CertFile:        config.GetString(component + ".cert"),
+		KeyFile:         config.GetString(component + ".key"),
+		RefreshDuration: CredRefreshingInterval,
+	}
+	if options.CertFile == "" || options.KeyFile == "" {
+		return nil, nil
+	}
+	provider, err := pemfile.NewProvider(options)
+	if err != nil {
+		return nil, fmt.Errorf("pemfile.NewProvider(%v) %v: %v", options, component, err)
+	}
+	return &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			keyMaterial, err := provider.KeyMaterial(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("load %s certificate: %v", component, err)
+			}
+			if len(keyMaterial.Certs) == 0 {
+				return nil, fmt.Errorf("no %s certificate", component)
+			}
+			return &keyMaterial.Certs[0], nil
+		},
+	}, nil
+}
+
 func (a Authenticator) Authenticate(params *advancedtls.VerificationFuncParams) (*advancedtls.VerificationResults, error) {
 	if a.AllowedWildcardDomain != "" && strings.HasSuffix(params.Leaf.Subject.CommonName, a.AllowedWildcardDomain) {
 		return &advancedtls.VerificationResults{}, nil
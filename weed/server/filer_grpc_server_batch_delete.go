@@ -0,0 +1,82 @@
+package weed_server
+
+import (
+	"context"
+	"sort"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// BatchDeleteEntries deletes many entries in one call. The entries under the same
+// directory are deleted in one store transaction, if the store supports transactions.
+func (fs *FilerServer) BatchDeleteEntries(ctx context.Context, req *filer_pb.BatchDeleteEntriesRequest) (*filer_pb.BatchDeleteEntriesResponse, error) {
+
+	glog.V(4).Infof("BatchDeleteEntries %d entries", len(req.Paths))
+
+	resp := &filer_pb.BatchDeleteEntriesResponse{
+		Results: make([]*filer_pb.BatchDeleteResult, len(req.Paths)),
+	}
+
+	// group the entries by their parent directory
+	groups := make(map[string][]int)
+	for i, p := range req.Paths {
+		fullPath := util.FullPath(p)
+		resp.Results[i] = &filer_pb.BatchDeleteResult{Path: p}
+		if req.IsDeleteData && !req.IsFromOtherCluster {
+			if moved, err := fs.moveToTrash(ctx, fullPath, req.IsRecursive, req.Signatures); moved {
+				if err != nil {
+					resp.Results[i].Error = err.Error()
+				}
+				continue
+			}
+		}
+		dir, _ := fullPath.DirAndName()
+		groups[dir] = append(groups[dir], i)
+	}
+
+	dirs := make([]string, 0, len(groups))
+	for dir := range groups {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		fs.batchDeleteInDirectory(ctx, req, resp.Results, groups[dir])
+	}
+
+	return resp, nil
+}
+
+func (fs *FilerServer) batchDeleteInDirectory(ctx context.Context, req *filer_pb.BatchDeleteEntriesRequest, results []*filer_pb.BatchDeleteResult, indexes []int) {
+	txCtx, err := fs.filer.BeginTransaction(ctx)
+	if err != nil {
+		for _, i := range indexes {
+			results[i].Error = err.Error()
+		}
+		return
+	}
+	// delete the chunks and publish the events only if the transaction is committed
+	txCtx = filer.WithAfterCommit(txCtx)
+
+	for _, i := range indexes {
+		err := fs.filer.DeleteEntryMetaAndData(txCtx, util.FullPath(req.Paths[i]), req.IsRecursive, req.IgnoreRecursiveError, req.IsDeleteData, req.IsFromOtherCluster, req.Signatures)
+		if err != nil && err != filer_pb.ErrNotFound {
+			results[i].Error = err.Error()
+		}
+	}
+
+	if commitErr := fs.filer.CommitTransaction(txCtx); commitErr != nil {
+		fs.filer.RollbackTransaction(txCtx)
+		filer.DiscardAfterCommit(txCtx)
+		for _, i := range indexes {
+			if results[i].Error == "" {
+				results[i].Error = commitErr.Error()
+			}
+		}
+		return
+	}
+	filer.RunAfterCommit(txCtx)
+}
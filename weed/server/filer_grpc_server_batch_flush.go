@@ -0,0 +1,49 @@
+package weed_server
+
+import (
+	"context"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+// BatchFlushEntries saves the entries flushed by the mount fsync calls, as a series of CreateEntry
+// in one store transaction, so the stores able to batch the writes send them together.
+// The metadata events and the chunk deletions wait for the commit. If the commit fails, e.g., a store
+// aborting the whole transaction on one failed entry, nothing is saved, and the entries are saved one by one.
+func (fs *FilerServer) BatchFlushEntries(ctx context.Context, req *filer_pb.BatchFlushEntriesRequest) (*filer_pb.BatchFlushEntriesResponse, error) {
+
+	glog.V(4).Infof("BatchFlushEntries %d entries", len(req.Entries))
+
+	resp := &filer_pb.BatchFlushEntriesResponse{
+		Responses: make([]*filer_pb.CreateEntryResponse, len(req.Entries)),
+	}
+	txCtx, err := fs.filer.BeginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	txCtx = filer.WithAfterCommit(txCtx)
+	for i, entryRequest := range req.Entries {
+		resp.Responses[i] = fs.flushEntry(txCtx, entryRequest)
+	}
+	if commitErr := fs.filer.CommitTransaction(txCtx); commitErr != nil {
+		fs.filer.RollbackTransaction(txCtx)
+		filer.DiscardAfterCommit(txCtx)
+		glog.V(1).Infof("BatchFlushEntries commit %d entries: %v, save them one by one", len(req.Entries), commitErr)
+		for i, entryRequest := range req.Entries {
+			resp.Responses[i] = fs.flushEntry(ctx, entryRequest)
+		}
+		return resp, nil
+	}
+	filer.RunAfterCommit(txCtx)
+	return resp, nil
+}
+
+func (fs *FilerServer) flushEntry(ctx context.Context, req *filer_pb.CreateEntryRequest) *filer_pb.CreateEntryResponse {
+	createResp, err := fs.CreateEntry(ctx, req)
+	if err != nil {
+		createResp = &filer_pb.CreateEntryResponse{Error: err.Error()}
+	}
+	return createResp
+}
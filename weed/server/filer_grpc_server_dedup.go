@@ -0,0 +1,101 @@
+package weed_server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+// dedupCollection returns the collection of the writes to the path, if the collection is deduplicated.
+// The chunks with a ttl expire on their own, so they are never shared.
+func (fs *FilerServer) dedupCollection(path, collection string, ttlSec int32) (string, bool) {
+	if !fs.filer.FilerConf.MatchStorageRule(path).Dedup {
+		return "", false
+	}
+	so, err := fs.detectStorageOption(path, collection, "", ttlSec, "", "", "", "")
+	if err != nil || so.TtlSeconds > 0 {
+		return "", false
+	}
+	return so.Collection, true
+}
+
+// dedupResidencyAllows only reuses a chunk for the file if all the replicas of the chunk are in the
+// data centers allowed by the residency policy of the file. The volume locations have no racks,
+// so a chunk is never reused for the files restricted to racks.
+func (fs *FilerServer) dedupResidencyAllows(path string, chunk *filer_pb.FileChunk) bool {
+	residency := fs.residencyPolicy.Residency(path)
+	if residency == nil {
+		return true
+	}
+	if len(residency.Racks) > 0 {
+		return false
+	}
+	fid, err := filer_pb.ToFileIdObject(chunk.GetFileIdString())
+	if err != nil {
+		return false
+	}
+	locations, found := fs.filer.MasterClient.GetLocations(fid.VolumeId)
+	if !found {
+		return false
+	}
+	for _, location := range locations {
+		allowed := false
+		for _, dataCenter := range residency.DataCenters {
+			allowed = allowed || dataCenter == location.DataCenter
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+func (fs *FilerServer) LookupChunkByHash(ctx context.Context, req *filer_pb.LookupChunkByHashRequest) (*filer_pb.LookupChunkByHashResponse, error) {
+
+	if len(req.Sha256) != sha256.Size {
+		return nil, fmt.Errorf("invalid sha256 length %d", len(req.Sha256))
+	}
+
+	collection, dedup := fs.dedupCollection(req.Path, req.Collection, req.TtlSec)
+	if !dedup {
+		return &filer_pb.LookupChunkByHashResponse{}, nil
+	}
+
+	chunk, found := fs.filer.DedupIndex.LookupChunkByHash(ctx, collection, req.Sha256)
+	if found && !fs.dedupResidencyAllows(req.Path, chunk) {
+		glog.V(3).Infof("dedup %s %x: chunk %s is outside of the residency", req.Path, req.Sha256, chunk.GetFileIdString())
+		chunk = nil
+	} else if found {
+		glog.V(4).Infof("dedup %s %x: chunk %s", req.Path, req.Sha256, chunk.GetFileIdString())
+	}
+	return &filer_pb.LookupChunkByHashResponse{
+		Dedup: true,
+		Chunk: chunk,
+	}, nil
+}
+
+func (fs *FilerServer) SaveChunkHash(ctx context.Context, req *filer_pb.SaveChunkHashRequest) (*filer_pb.SaveChunkHashResponse, error) {
+
+	if req.Chunk == nil {
+		return nil, fmt.Errorf("missing chunk")
+	}
+
+	collection, dedup := fs.dedupCollection(req.Path, req.Collection, req.TtlSec)
+	if !dedup {
+		return &filer_pb.SaveChunkHashResponse{}, nil
+	}
+
+	hash, err := fs.filer.DedupIndex.AddChunk(ctx, collection, req.Chunk)
+	if err != nil {
+		glog.Errorf("save chunk hash %s %s: %v", req.Path, req.Chunk.GetFileIdString(), err)
+		return nil, fmt.Errorf("save chunk hash: %v", err)
+	}
+	if len(req.Sha256) > 0 && !bytes.Equal(hash, req.Sha256) {
+		glog.Warningf("save chunk hash %s %s: content sha256 %x, claimed %x", req.Path, req.Chunk.GetFileIdString(), hash, req.Sha256)
+	}
+	return &filer_pb.SaveChunkHashResponse{}, nil
+}
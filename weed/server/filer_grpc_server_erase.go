@@ -0,0 +1,182 @@
+package weed_server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/security"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+	"github.com/seaweedfs/seaweedfs/weed/wdclient"
+)
+
+const (
+	eraseReplicaNotFound = "not found"
+	eraseReplicaZeroed   = "zeroed"
+)
+
+// EraseEntry overwrites the data chunks of a file with zeros, optionally reads back every replica
+// of every chunk to verify the zeros are stored, and then deletes the file.
+// The response is the audit trail of the erasure. If any chunk is not overwritten or verified,
+// the file is kept, so the erasure can be retried.
+// The volume servers append the zeros as a new version of the needle, so the
+// volumes in the response should be vacuumed to also drop the old bytes from disk.
+func (fs *FilerServer) EraseEntry(ctx context.Context, req *filer_pb.EraseEntryRequest) (*filer_pb.EraseEntryResponse, error) {
+	p := util.NewFullPath(req.Directory, req.Name)
+	resp := &filer_pb.EraseEntryResponse{
+		StartedAtNs: time.Now().UnixNano(),
+	}
+	err := fs.eraseEntry(ctx, p, req.Verify, resp)
+	resp.EndedAtNs = time.Now().UnixNano()
+	if err != nil {
+		glog.V(0).Infof("erase %s: %v", p, err)
+		resp.Error = err.Error()
+		return resp, nil
+	}
+	glog.V(0).Infof("erased %s: %d chunks, verified %v", p, len(resp.Chunks), resp.Verified)
+	return resp, nil
+}
+
+func (fs *FilerServer) eraseEntry(ctx context.Context, p util.FullPath, verify bool, resp *filer_pb.EraseEntryResponse) error {
+	entry, err := fs.filer.FindEntry(ctx, p)
+	if err != nil {
+		return err
+	}
+	if entry.IsDirectory() {
+		return fmt.Errorf("%s is a directory", p)
+	}
+
+	lookupFn := fs.filer.MasterClient.GetLookupFileIdFunction()
+	dataChunks, manifestChunks, err := filer.ResolveChunkManifest(lookupFn, entry.GetChunks(), 0, math.MaxInt64)
+	if err != nil {
+		return fmt.Errorf("resolve chunk manifest: %v", err)
+	}
+
+	v := util.GetViper()
+	signingKey := security.SigningKey(v.GetString("jwt.signing.key"))
+	expiresAfterSec := v.GetInt("jwt.signing.expires_after_seconds")
+
+	volumeIds := make(map[needle.VolumeId]bool)
+	var overwriteErr error
+	for _, chunk := range append(dataChunks, manifestChunks...) {
+		fileId := chunk.GetFileIdString()
+		erasedChunk := &filer_pb.ErasedChunk{
+			FileId: fileId,
+			Size:   chunk.Size,
+		}
+		resp.Chunks = append(resp.Chunks, erasedChunk)
+		if fid, parseErr := needle.ParseFileIdFromString(fileId); parseErr == nil {
+			volumeIds[fid.VolumeId] = true
+		}
+		if err := fs.overwriteChunk(lookupFn, fileId, chunk.Size, security.GenJwtForVolumeServer(signingKey, expiresAfterSec, fileId)); err != nil {
+			erasedChunk.Error = err.Error()
+			overwriteErr = err
+			continue
+		}
+		erasedChunk.Overwritten = true
+	}
+	for vid := range volumeIds {
+		resp.VolumeIds = append(resp.VolumeIds, uint32(vid))
+	}
+	if overwriteErr != nil {
+		return fmt.Errorf("overwrite chunks of %s: %v", p, overwriteErr)
+	}
+
+	if verify {
+		// read back the overwritten needles, while the file still refers to them
+		resp.Verified = true
+		for _, erasedChunk := range resp.Chunks {
+			erasedChunk.Replicas = verifyErasedChunk(lookupFn, erasedChunk.FileId)
+			for _, state := range erasedChunk.Replicas {
+				if state != eraseReplicaZeroed {
+					resp.Verified = false
+				}
+			}
+		}
+		if !resp.Verified {
+			return fmt.Errorf("verify overwritten chunks of %s: some replicas are not zeroed", p)
+		}
+	}
+
+	if err := fs.filer.DeleteEntryMetaAndData(ctx, p, false, false, true, false, nil); err != nil {
+		return fmt.Errorf("delete %s: %v", p, err)
+	}
+	return nil
+}
+
+// overwriteChunk writes zeros of the same size to the chunk file id.
+// The volume server replicates the write to the other replicas.
+func (fs *FilerServer) overwriteChunk(lookupFn wdclient.LookupFileIdFunctionType, fileId string, size uint64, jwt security.EncodedJwt) error {
+	urls, err := lookupFn(fileId)
+	if err != nil {
+		return fmt.Errorf("lookup %s: %v", fileId, err)
+	}
+	if len(urls) == 0 {
+		return fmt.Errorf("lookup %s: no location", fileId)
+	}
+	uploadResult, err, _ := operation.Upload(bytes.NewReader(make([]byte, size)), &operation.UploadOption{
+		UploadUrl: urls[0],
+		MimeType:  "application/octet-stream",
+		Jwt:       jwt,
+	})
+	if err != nil {
+		return err
+	}
+	if uploadResult.Error != "" {
+		return errors.New(uploadResult.Error)
+	}
+	return nil
+}
+
+// verifyErasedChunk reads each replica of the chunk, expecting zeros.
+func verifyErasedChunk(lookupFn wdclient.LookupFileIdFunctionType, fileId string) map[string]string {
+	replicas := make(map[string]string)
+	urls, err := lookupFn(fileId)
+	if err != nil {
+		replicas[fileId] = fmt.Sprintf("lookup: %v", err)
+		return replicas
+	}
+	for _, url := range urls {
+		replicas[url] = readErasedReplica(url)
+	}
+	return replicas
+}
+
+func readErasedReplica(url string) string {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err.Error()
+	}
+	defer util.CloseResponse(resp)
+	if resp.StatusCode == http.StatusNotFound {
+		return eraseReplicaNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp.Status
+	}
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		for _, b := range buf[:n] {
+			if b != 0 {
+				return "not zeroed"
+			}
+		}
+		if err == io.EOF {
+			return eraseReplicaZeroed
+		}
+		if err != nil {
+			return err.Error()
+		}
+	}
+}
@@ -68,7 +68,7 @@ func (fs *FilerServer) CacheRemoteObjectToLocalCluster(ctx context.Context, req
 	if err != nil {
 		return resp, err
 	}
-	assignRequest, altRequest := so.ToAssignRequests(1)
+	so.Residency = fs.residencyPolicy.Residency(string(util.FullPath(req.Directory).Child(req.Name)))
 
 	// find a good chunk size
 	chunkSize := int64(5 * 1024 * 1024)
@@ -97,7 +97,7 @@ func (fs *FilerServer) CacheRemoteObjectToLocalCluster(ctx context.Context, req
 			}
 
 			// assign one volume server
-			assignResult, err := operation.Assign(fs.filer.GetMaster, fs.grpcDialOption, assignRequest, altRequest)
+			assignResult, err := fs.assignVolume(so, 1)
 			if err != nil {
 				fetchAndWriteErr = err
 				return
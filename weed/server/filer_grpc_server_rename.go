@@ -2,6 +2,7 @@ package weed_server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"time"
@@ -10,6 +11,8 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func (fs *FilerServer) AtomicRenameEntry(ctx context.Context, req *filer_pb.AtomicRenameEntryRequest) (*filer_pb.AtomicRenameEntryResponse, error) {
@@ -37,7 +40,7 @@ func (fs *FilerServer) AtomicRenameEntry(ctx context.Context, req *filer_pb.Atom
 	moveErr := fs.moveEntry(ctx, nil, oldParent, oldEntry, newParent, req.NewName, req.Signatures)
 	if moveErr != nil {
 		fs.filer.RollbackTransaction(ctx)
-		return nil, fmt.Errorf("%s/%s move error: %v", req.OldDirectory, req.OldName, moveErr)
+		return nil, renameError(fmt.Errorf("%s/%s move error: %w", req.OldDirectory, req.OldName, moveErr))
 	} else {
 		if commitError := fs.filer.CommitTransaction(ctx); commitError != nil {
 			fs.filer.RollbackTransaction(ctx)
@@ -90,7 +93,7 @@ func (fs *FilerServer) StreamRenameEntry(req *filer_pb.StreamRenameEntryRequest,
 	moveErr := fs.moveEntry(ctx, stream, oldParent, oldEntry, newParent, req.NewName, req.Signatures)
 	if moveErr != nil {
 		fs.filer.RollbackTransaction(ctx)
-		return fmt.Errorf("%s/%s move error: %v", req.OldDirectory, req.OldName, moveErr)
+		return renameError(fmt.Errorf("%s/%s move error: %w", req.OldDirectory, req.OldName, moveErr))
 	} else {
 		if commitError := fs.filer.CommitTransaction(ctx); commitError != nil {
 			fs.filer.RollbackTransaction(ctx)
@@ -111,12 +114,20 @@ func (fs *FilerServer) moveEntry(ctx context.Context, stream filer_pb.SeaweedFil
 		}
 		return nil
 	}, signatures); err != nil {
-		return fmt.Errorf("fail to move %s => %s: %v", oldParent.Child(entry.Name()), newParent.Child(newName), err)
+		return fmt.Errorf("fail to move %s => %s: %w", oldParent.Child(entry.Name()), newParent.Child(newName), err)
 	}
 
 	return nil
 }
 
+// renameError returns the inode quota error with the ResourceExhausted code, for the clients to tell it apart
+func renameError(err error) error {
+	if errors.Is(err, filer.ErrInodeQuotaExceeded) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return err
+}
+
 func (fs *FilerServer) moveFolderSubEntries(ctx context.Context, stream filer_pb.SeaweedFiler_StreamRenameEntryServer, oldParent util.FullPath, entry *filer.Entry, newParent util.FullPath, newName string, signatures []int32) error {
 
 	currentDirPath := oldParent.Child(entry.Name())
@@ -173,7 +184,8 @@ func (fs *FilerServer) moveSelfEntry(ctx context.Context, stream filer_pb.Seawee
 		Remote:          entry.Remote,
 		Quota:           entry.Quota,
 	}
-	if createErr := fs.filer.CreateEntry(ctx, newEntry, false, false, signatures, false); createErr != nil {
+	// the entry moved within a quota root does not count against the inode quota
+	if createErr := fs.filer.CreateEntry(filer.WithRenameSource(ctx, oldPath), newEntry, false, false, signatures, false); createErr != nil {
 		return createErr
 	}
 	if stream != nil {
@@ -277,6 +277,9 @@ func matchByDirectory(dirPath string, directories []string) bool {
 func (fs *FilerServer) addClient(clientType string, clientAddress string, clientId int32, clientEpoch int32) (alreadyKnown bool, clientName string) {
 	clientName = clientType + "@" + clientAddress
 	glog.V(0).Infof("+ listener %v", clientName)
+	fs.connectedClientsLock.Lock()
+	fs.connectedClients[clientName]++
+	fs.connectedClientsLock.Unlock()
 	if clientId != 0 {
 		fs.knownListenersLock.Lock()
 		defer fs.knownListenersLock.Unlock()
@@ -292,6 +295,11 @@ func (fs *FilerServer) addClient(clientType string, clientAddress string, client
 
 func (fs *FilerServer) deleteClient(clientName string, clientId int32, clientEpoch int32) {
 	glog.V(0).Infof("- listener %v", clientName)
+	fs.connectedClientsLock.Lock()
+	if fs.connectedClients[clientName]--; fs.connectedClients[clientName] <= 0 {
+		delete(fs.connectedClients, clientName)
+	}
+	fs.connectedClientsLock.Unlock()
 	if clientId != 0 {
 		fs.knownListenersLock.Lock()
 		defer fs.knownListenersLock.Unlock()
@@ -301,3 +309,13 @@ func (fs *FilerServer) deleteClient(clientName string, clientId int32, clientEpo
 		}
 	}
 }
+
+func (fs *FilerServer) listConnectedClients() map[string]int {
+	fs.connectedClientsLock.Lock()
+	defer fs.connectedClientsLock.Unlock()
+	clients := make(map[string]int, len(fs.connectedClients))
+	for name, count := range fs.connectedClients {
+		clients[name] = count
+	}
+	return clients
+}
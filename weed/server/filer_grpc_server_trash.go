@@ -0,0 +1,47 @@
+package weed_server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// moveToTrash moves the entry to the trash of the nearest parent directory with a trash ttl.
+// It returns false if the entry should be deleted as usual.
+func (fs *FilerServer) moveToTrash(ctx context.Context, fullPath util.FullPath, isRecursive bool, signatures []int32) (bool, error) {
+	trashRoot, _, found := fs.filer.FindTrashRoot(ctx, fullPath)
+	if !found {
+		return false, nil
+	}
+	entry, err := fs.filer.FindEntry(ctx, fullPath)
+	if err != nil {
+		return false, nil
+	}
+	if entry.IsDirectory() && !isRecursive {
+		// an empty directory is simply deleted, and a non-empty one fails as usual
+		return false, nil
+	}
+
+	trashDir, err := fs.filer.PrepareTrashDir(ctx, trashRoot, fullPath, signatures)
+	if err != nil {
+		return true, fmt.Errorf("prepare trash for %s: %v", fullPath, err)
+	}
+
+	ctx, err = fs.filer.BeginTransaction(ctx)
+	if err != nil {
+		return true, err
+	}
+	oldParent, _ := fullPath.DirAndName()
+	if moveErr := fs.moveEntry(ctx, nil, util.FullPath(oldParent), entry, trashDir, entry.Name(), signatures); moveErr != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return true, fmt.Errorf("move %s to trash: %v", fullPath, moveErr)
+	}
+	if commitErr := fs.filer.CommitTransaction(ctx); commitErr != nil {
+		fs.filer.RollbackTransaction(ctx)
+		return true, fmt.Errorf("move %s to trash commit: %v", fullPath, commitErr)
+	}
+	glog.V(2).Infof("moved %s to %s", fullPath, trashDir)
+	return true, nil
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 
+	"github.com/graphql-go/graphql"
 	"google.golang.org/grpc"
 
 	"github.com/seaweedfs/seaweedfs/weed/util/grace"
@@ -23,6 +24,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	_ "github.com/seaweedfs/seaweedfs/weed/filer/arangodb"
 	_ "github.com/seaweedfs/seaweedfs/weed/filer/cassandra"
+	_ "github.com/seaweedfs/seaweedfs/weed/filer/cockroachdb"
 	_ "github.com/seaweedfs/seaweedfs/weed/filer/elastic/v7"
 	_ "github.com/seaweedfs/seaweedfs/weed/filer/etcd"
 	_ "github.com/seaweedfs/seaweedfs/weed/filer/hbase"
@@ -46,6 +48,7 @@ import (
 	_ "github.com/seaweedfs/seaweedfs/weed/notification/google_pub_sub"
 	_ "github.com/seaweedfs/seaweedfs/weed/notification/kafka"
 	_ "github.com/seaweedfs/seaweedfs/weed/notification/log"
+	_ "github.com/seaweedfs/seaweedfs/weed/notification/webhook"
 	"github.com/seaweedfs/seaweedfs/weed/security"
 )
 
@@ -70,6 +73,13 @@ type FilerOption struct {
 	ShowUIDirectoryDelete bool
 	DownloadMaxBytesPs    int64
 	DiskType              string
+	AuditLogger           *filer.AuditLogger
+	EnableTiering         bool
+	EnableIntegrityCheck  bool
+	IntegrityScanBytesPs  int64
+	UploadStatus          bool
+	// AdminMux, if set, serves the OpenAPI spec and its Swagger UI on the admin port
+	AdminMux *http.ServeMux
 }
 
 type FilerServer struct {
@@ -82,6 +92,7 @@ type FilerServer struct {
 	filer          *filer.Filer
 	filerGuard     *security.Guard
 	grpcDialOption grpc.DialOption
+	mimeDetector   *filer.MimeDetector
 
 	// metrics read from the master
 	metricsAddress     string
@@ -94,6 +105,17 @@ type FilerServer struct {
 	// track known metadata listeners
 	knownListenersLock sync.Mutex
 	knownListeners     map[int32]int32
+
+	// the subscribed clients by name, for the admin dashboard
+	connectedClientsLock sync.Mutex
+	connectedClients     map[string]int
+
+	// the in-progress uploads, by upload id
+	uploads sync.Map
+
+	residencyPolicy *filer.ResidencyPolicy
+
+	graphqlSchema graphql.Schema
 }
 
 func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption) (fs *FilerServer, err error) {
@@ -111,6 +133,7 @@ func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption)
 		option:                option,
 		grpcDialOption:        security.LoadClientTLS(util.GetViper(), "grpc.filer"),
 		knownListeners:        make(map[int32]int32),
+		connectedClients:      make(map[string]int),
 		inFlightDataLimitCond: sync.NewCond(new(sync.Mutex)),
 	}
 	fs.listenersCond = sync.NewCond(&fs.listenersLock)
@@ -123,6 +146,7 @@ func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption)
 		fs.listenersCond.Broadcast()
 	})
 	fs.filer.Cipher = option.Cipher
+	fs.mimeDetector = filer.NewMimeDetector(fs.filer)
 	// we do not support IP whitelist right now
 	fs.filerGuard = security.NewGuard([]string{}, signingKey, expiresAfterSec, readSigningKey, readExpiresAfterSec)
 
@@ -147,17 +171,57 @@ func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption)
 	fs.option.recursiveDelete = v.GetBool("filer.options.recursive_delete")
 	v.SetDefault("filer.options.buckets_folder", "/buckets")
 	fs.filer.DirBucketsPath = v.GetString("filer.options.buckets_folder")
+	v.SetDefault("filer.options.max_hard_links", filer.DefaultMaxHardLinks)
+	fs.filer.MaxHardLinks = int32(v.GetInt("filer.options.max_hard_links"))
 	// TODO deprecated, will be be removed after 2020-12-31
 	// replaced by https://github.com/seaweedfs/seaweedfs/wiki/Path-Specific-Configuration
 	// fs.filer.FsyncBuckets = v.GetStringSlice("filer.options.buckets_fsync")
 	isFresh := fs.filer.LoadConfiguration(v)
 
+	if fs.residencyPolicy, err = filer.LoadResidencyPolicy(v); err != nil {
+		glog.Fatalf("%v", err)
+	}
+	if fs.graphqlSchema, err = newFilerGraphqlSchema(fs); err != nil {
+		glog.Fatalf("graphql schema: %v", err)
+	}
+	if pluginDir := v.GetString("filer.plugins.dir"); pluginDir != "" {
+		if err = filer.LoadPlugins(pluginDir); err != nil {
+			glog.Fatalf("%v", err)
+		}
+	}
+	filer.RegisterPlugin(fs.filer.TagIndex)
+	filer.RegisterPlugin(fs.filer.InodeQuota)
+	filer.RegisterPlugin(fs.filer.TrashRoots)
+	tieringPolicy := filer.NewTieringPolicy(fs.filer, filer.TieringScanInterval)
+	filer.RegisterPlugin(tieringPolicy)
+	if clamdAddress := v.GetString("filer.plugins.clamd_address"); clamdAddress != "" {
+		filer.RegisterPlugin(filer.NewVirusScanPlugin(fs.filer, clamdAddress,
+			int64(v.GetInt("filer.plugins.clamd_max_size_mb"))*1024*1024, v.GetBool("filer.plugins.clamd_reject_oversized")))
+	}
+
 	notification.LoadConfiguration(v, "notification.")
 
+	integrityChecker := filer.NewIntegrityChecker(fs.filer, filer.IntegrityScanInterval, option.IntegrityScanBytesPs)
+
 	handleStaticResources(defaultMux)
 	if !option.DisableHttp {
+		dashboard := filer.NewAdminDashboard(fs.filer, fs.listConnectedClients)
+		defaultMux.HandleFunc("/admin/ui", fs.adminOnly(dashboard.PageHandler))
+		defaultMux.HandleFunc("/admin/ui/events", fs.adminOnly(dashboard.EventsHandler))
+		defaultMux.HandleFunc("/ws/metrics", fs.adminOnly(stats.MetricsWebSocketHandler))
+		defaultMux.HandleFunc("/admin/config", fs.adminOnly(fs.filerConfigHandler))
+		defaultMux.HandleFunc("/admin/integrity/report", fs.adminOnly(integrityChecker.ReportHandler))
+		defaultMux.HandleFunc("/graphql", fs.graphqlHandler)
+		if option.UploadStatus {
+			defaultMux.HandleFunc("/upload/status", fs.UploadStatusHandler)
+		}
 		defaultMux.HandleFunc("/", fs.filerHandler)
 	}
+	if option.AdminMux != nil {
+		handleStaticResources(option.AdminMux)
+		option.AdminMux.HandleFunc("/api/openapi.json", fs.adminOnly(fs.openApiSpecHandler))
+		option.AdminMux.HandleFunc("/api/docs", fs.adminOnly(fs.openApiDocsHandler))
+	}
 	if defaultMux != readonlyMux {
 		handleStaticResources(readonlyMux)
 		readonlyMux.HandleFunc("/", fs.readonlyFilerHandler)
@@ -177,6 +241,15 @@ func NewFilerServer(defaultMux, readonlyMux *http.ServeMux, option *FilerOption)
 
 	fs.filer.LoadRemoteStorageConfAndMapping()
 
+	if option.EnableTiering {
+		go tieringPolicy.Run()
+	}
+	if option.EnableIntegrityCheck {
+		go integrityChecker.Run()
+	}
+
+	go fs.filer.LoopPurgeTrash()
+
 	grace.OnInterrupt(func() {
 		fs.filer.Shutdown()
 	})
@@ -31,6 +31,14 @@ func (fs *FilerServer) filerHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	isReadHttpCall := r.Method == "GET" || r.Method == "HEAD"
+	if !isReadHttpCall && fs.option.AuditLogger != nil {
+		auditWriter := &auditResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		w = auditWriter
+		defer func() {
+			fs.auditHttp(r, auditWriter.statusCode)
+		}()
+	}
+
 	if !fs.maybeCheckJwtAuthorization(r, !isReadHttpCall) {
 		writeJsonError(w, r, http.StatusUnauthorized, errors.New("wrong jwt"))
 		return
@@ -87,9 +95,15 @@ func (fs *FilerServer) filerHandler(w http.ResponseWriter, r *http.Request) {
 			if _, ok := r.URL.Query()["tagging"]; ok {
 				fs.PutTaggingHandler(w, r)
 			} else {
+				if fs.option.UploadStatus {
+					defer fs.trackUpload(w, r, contentLength)()
+				}
 				fs.PostHandler(w, r, contentLength)
 			}
 		} else { // method == "POST"
+			if fs.option.UploadStatus {
+				defer fs.trackUpload(w, r, contentLength)()
+			}
 			fs.PostHandler(w, r, contentLength)
 		}
 	}
@@ -0,0 +1,61 @@
+package weed_server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	ui "github.com/seaweedfs/seaweedfs/weed/server/filer_ui"
+)
+
+// adminOnly requires the read jwt, if the filer read signing key is configured, for the admin pages
+func (fs *FilerServer) adminOnly(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			writeJsonError(w, r, http.StatusMethodNotAllowed, errors.New("only GET is allowed"))
+			return
+		}
+		if !fs.maybeCheckJwtAuthorization(r, false) {
+			writeJsonError(w, r, http.StatusUnauthorized, errors.New("wrong jwt"))
+			return
+		}
+		fn(w, r)
+	}
+}
+
+// filerConfigHandler shows the active filer.conf, and whether its latest change failed to load
+func (fs *FilerServer) filerConfigHandler(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := fs.filer.FilerConf.ToText(&buf); err != nil {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	status := fs.filer.FilerConfReloadStatus()
+	m := map[string]interface{}{
+		"config":   json.RawMessage(buf.Bytes()),
+		"loadedAt": status.LoadedAt,
+		"pending":  status.Pending(),
+	}
+	if status.Pending() {
+		m["failedAt"] = status.FailedAt
+		m["error"] = status.Error
+	}
+	writeJsonQuiet(w, r, http.StatusOK, m)
+}
+
+// openApiSpecHandler serves the spec on the admin port, pointing to the filer http port for the requests
+func (fs *FilerServer) openApiSpecHandler(w http.ResponseWriter, r *http.Request) {
+	spec := make(map[string]interface{})
+	if err := json.Unmarshal(ui.OpenApiSpec, &spec); err != nil {
+		writeJsonError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	spec["servers"] = []map[string]string{{"url": "http://" + fs.option.Host.ToHttpAddress()}}
+	writeJsonQuiet(w, r, http.StatusOK, spec)
+}
+
+func (fs *FilerServer) openApiDocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(ui.OpenApiDocsHtml)
+}
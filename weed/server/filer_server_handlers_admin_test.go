@@ -0,0 +1,74 @@
+package weed_server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	ui "github.com/seaweedfs/seaweedfs/weed/server/filer_ui"
+)
+
+func TestOpenApiSpec(t *testing.T) {
+	var spec struct {
+		OpenApi string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name string `json:"name"`
+			} `json:"parameters"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(ui.OpenApiSpec, &spec); err != nil {
+		t.Fatalf("parse openapi spec: %v", err)
+	}
+	if spec.OpenApi != "3.0.3" {
+		t.Errorf("openapi version %s", spec.OpenApi)
+	}
+	// the routes of the filer http port
+	for _, path := range []string{"/{path}", "/upload/status", "/admin/ui", "/admin/ui/events", "/ws/metrics",
+		"/admin/config", "/admin/integrity/report", "/graphql"} {
+		if _, found := spec.Paths[path]; !found {
+			t.Errorf("%s is not described", path)
+		}
+	}
+
+	// the query parameters read by the file handlers
+	expected := map[string][]string{
+		"get": {"metadata", "resolveManifest", "op", "useCache", "tags", "path", "limit", "lastFileName",
+			"sort", "order", "offset", "namePattern", "namePatternExclude"},
+		"post": {"collection", "replication", "ttl", "disk", "fsync", "dataCenter", "rack", "dataNode",
+			"saveInside", "maxMB", "offset", "op", "mode", "skipCheckParentDir", "mv.from"},
+		"put":    {"collection", "maxMB", "offset", "op", "tagging"},
+		"delete": {"recursive", "ignoreRecursiveError", "skipChunkDeletion", "tagging"},
+	}
+	for method, names := range expected {
+		documented := make(map[string]bool)
+		for _, p := range spec.Paths["/{path}"][method].Parameters {
+			documented[p.Name] = true
+		}
+		for _, name := range names {
+			if !documented[name] {
+				t.Errorf("%s /{path} parameter %s is not described", method, name)
+			}
+		}
+	}
+}
+
+func TestOpenApiSpecServer(t *testing.T) {
+	fs := &FilerServer{option: &FilerOption{Host: pb.NewServerAddress("filer1", 8888, 18888)}}
+
+	w := httptest.NewRecorder()
+	fs.openApiSpecHandler(w, httptest.NewRequest("GET", "/api/openapi.json", nil))
+
+	var spec struct {
+		Servers []struct {
+			Url string `json:"url"`
+		} `json:"servers"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("parse served spec: %v", err)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0].Url != "http://filer1:8888" {
+		t.Errorf("servers %+v", spec.Servers)
+	}
+}
@@ -0,0 +1,33 @@
+package weed_server
+
+import (
+	"net/http"
+)
+
+type auditResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *auditResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// auditHttp records one http mutation into the filer audit log, mirroring the gRPC operation names.
+func (fs *FilerServer) auditHttp(r *http.Request, statusCode int) {
+	query := r.URL.Query()
+	_, isTagging := query["tagging"]
+	switch {
+	case r.Method == "DELETE" && isTagging:
+		fs.option.AuditLogger.LogHttp(r, "DeleteTagging", r.URL.Path, "", 0, statusCode)
+	case r.Method == "DELETE":
+		fs.option.AuditLogger.LogHttp(r, "DeleteEntry", r.URL.Path, "", 0, statusCode)
+	case r.Method == "PUT" && isTagging:
+		fs.option.AuditLogger.LogHttp(r, "PutTagging", r.URL.Path, "", 0, statusCode)
+	case query.Get("mv.from") != "":
+		fs.option.AuditLogger.LogHttp(r, "Move", query.Get("mv.from"), r.URL.Path, 0, statusCode)
+	default:
+		fs.option.AuditLogger.LogHttp(r, "Upload", r.URL.Path, "", uint64(getContentLength(r)), statusCode)
+	}
+}
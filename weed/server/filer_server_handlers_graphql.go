@@ -0,0 +1,284 @@
+package weed_server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	graphqlDefaultLimit = 100
+	graphqlMaxLimit     = 1000
+	graphqlPageSize     = 1024
+)
+
+type graphqlCanWriteKey struct{}
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphqlHandler serves the filer metadata queries, with GET ?query=...&variables=... or POST json.
+// The mutations need the write jwt, and a POST.
+//
+//	curl -d '{"query": "{ listEntries(dir: \"/buckets\") { path size mtime } }"}' http://localhost:8888/graphql
+func (fs *FilerServer) graphqlHandler(w http.ResponseWriter, r *http.Request) {
+	if !fs.maybeCheckJwtAuthorization(r, false) {
+		writeJsonError(w, r, http.StatusUnauthorized, errors.New("wrong jwt"))
+		return
+	}
+
+	request := &graphqlRequest{}
+	switch r.Method {
+	case http.MethodGet:
+		request.Query = r.FormValue("query")
+		request.OperationName = r.FormValue("operationName")
+		if variables := r.FormValue("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("parse variables: %v", err))
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("parse graphql request: %v", err))
+			return
+		}
+	default:
+		writeJsonError(w, r, http.StatusMethodNotAllowed, errors.New("only GET and POST are allowed"))
+		return
+	}
+
+	canWrite := r.Method == http.MethodPost && fs.maybeCheckJwtAuthorization(r, true)
+	result := graphql.Do(graphql.Params{
+		Schema:         fs.graphqlSchema,
+		RequestString:  request.Query,
+		VariableValues: request.Variables,
+		OperationName:  request.OperationName,
+		Context:        context.WithValue(r.Context(), graphqlCanWriteKey{}, canWrite),
+	})
+	writeJsonQuiet(w, r, http.StatusOK, result)
+}
+
+func newFilerGraphqlSchema(fs *FilerServer) (graphql.Schema, error) {
+	chunkType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Chunk",
+		Fields: graphql.Fields{
+			"fileId": chunkField(graphql.String, func(c *filer_pb.FileChunk) interface{} { return c.GetFileIdString() }),
+			"offset": chunkField(graphql.Float, func(c *filer_pb.FileChunk) interface{} { return float64(c.Offset) }),
+			"size":   chunkField(graphql.Float, func(c *filer_pb.FileChunk) interface{} { return float64(c.Size) }),
+			"mtime": chunkField(graphql.String, func(c *filer_pb.FileChunk) interface{} {
+				return time.Unix(0, c.ModifiedTsNs).UTC().Format(time.RFC3339Nano)
+			}),
+		},
+	})
+	xattrType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Xattr",
+		Fields: graphql.Fields{
+			"name":  &graphql.Field{Type: graphql.String},
+			"value": &graphql.Field{Type: graphql.String},
+		},
+	})
+	entryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Entry",
+		Fields: graphql.Fields{
+			"path":        entryField(graphql.String, func(e *filer.Entry) interface{} { return string(e.FullPath) }),
+			"isDirectory": entryField(graphql.Boolean, func(e *filer.Entry) interface{} { return e.IsDirectory() }),
+			// the sizes can exceed the 32 bit graphql Int
+			"size":   entryField(graphql.Float, func(e *filer.Entry) interface{} { return float64(e.Size()) }),
+			"mtime":  entryField(graphql.String, func(e *filer.Entry) interface{} { return e.Attr.Mtime.UTC().Format(time.RFC3339) }),
+			"mime":   entryField(graphql.String, func(e *filer.Entry) interface{} { return e.Attr.Mime }),
+			"chunks": entryField(graphql.NewList(chunkType), func(e *filer.Entry) interface{} { return e.GetChunks() }),
+			"xattrs": entryField(graphql.NewList(xattrType), func(e *filer.Entry) interface{} {
+				var xattrs []map[string]interface{}
+				for name, value := range e.Extended {
+					xattrs = append(xattrs, map[string]interface{}{"name": name, "value": string(value)})
+				}
+				return xattrs
+			}),
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"entry": &graphql.Field{
+				Type: entryType,
+				Args: graphql.FieldConfigArgument{
+					"path": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					entry, err := fs.filer.FindEntry(p.Context, util.FullPath(p.Args["path"].(string)))
+					if err == filer_pb.ErrNotFound {
+						return nil, nil
+					}
+					return entry, err
+				},
+			},
+			"listEntries": &graphql.Field{
+				Type: graphql.NewList(entryType),
+				Args: graphql.FieldConfigArgument{
+					"dir":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int},
+					"after": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					after, _ := p.Args["after"].(string)
+					entries, _, err := fs.filer.ListDirectoryEntries(p.Context, util.FullPath(p.Args["dir"].(string)), after, false, int64(graphqlLimit(p.Args)), "", "", "")
+					return entries, err
+				},
+			},
+			"searchEntries": &graphql.Field{
+				Type: graphql.NewList(entryType),
+				Args: graphql.FieldConfigArgument{
+					"dir":       &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"pattern":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"recursive": &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"limit":     &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					recursive, _ := p.Args["recursive"].(bool)
+					return searchEntries(p.Context, fs.filer, util.FullPath(p.Args["dir"].(string)), p.Args["pattern"].(string), recursive, graphqlLimit(p.Args))
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"deleteEntry": &graphql.Field{
+				Type: graphql.Boolean,
+				Args: graphql.FieldConfigArgument{
+					"path":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"recursive": &graphql.ArgumentConfig{Type: graphql.Boolean},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := checkGraphqlCanWrite(p.Context); err != nil {
+						return nil, err
+					}
+					recursive, _ := p.Args["recursive"].(bool)
+					err := fs.filer.DeleteEntryMetaAndData(p.Context, util.FullPath(p.Args["path"].(string)), recursive, false, true, false, nil)
+					if err == filer_pb.ErrNotFound {
+						return false, nil
+					}
+					return err == nil, err
+				},
+			},
+			"createDirectory": &graphql.Field{
+				Type: entryType,
+				Args: graphql.FieldConfigArgument{
+					"path": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := checkGraphqlCanWrite(p.Context); err != nil {
+						return nil, err
+					}
+					now := time.Now()
+					entry := &filer.Entry{
+						FullPath: util.FullPath(p.Args["path"].(string)),
+						Attr: filer.Attr{
+							Mtime:  now,
+							Crtime: now,
+							Mode:   os.ModeDir | 0770,
+							Uid:    OS_UID,
+							Gid:    OS_GID,
+						},
+					}
+					if err := fs.filer.CreateEntry(p.Context, entry, true, false, nil, false); err != nil {
+						return nil, err
+					}
+					return entry, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+func entryField(fieldType graphql.Output, fn func(e *filer.Entry) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return fn(p.Source.(*filer.Entry)), nil
+		},
+	}
+}
+
+func chunkField(fieldType graphql.Output, fn func(c *filer_pb.FileChunk) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: fieldType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return fn(p.Source.(*filer_pb.FileChunk)), nil
+		},
+	}
+}
+
+func graphqlLimit(args map[string]interface{}) int {
+	limit, ok := args["limit"].(int)
+	if !ok || limit <= 0 {
+		return graphqlDefaultLimit
+	}
+	if limit > graphqlMaxLimit {
+		return graphqlMaxLimit
+	}
+	return limit
+}
+
+func checkGraphqlCanWrite(ctx context.Context) error {
+	if canWrite, _ := ctx.Value(graphqlCanWriteKey{}).(bool); !canWrite {
+		return errors.New("the mutations need a POST with the write jwt")
+	}
+	return nil
+}
+
+// searchEntries finds the entries with the name matching the wildcard pattern, in the directory
+// and, if recursive, in its sub directories, breadth first, up to the limit
+func searchEntries(ctx context.Context, f *filer.Filer, dir util.FullPath, pattern string, recursive bool, limit int) (found []*filer.Entry, err error) {
+	if _, err = filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("pattern %q: %v", pattern, err)
+	}
+	dirs := []util.FullPath{dir}
+	for len(dirs) > 0 && len(found) < limit {
+		current := dirs[0]
+		dirs = dirs[1:]
+		lastFileName := ""
+		for len(found) < limit {
+			count := 0
+			lastFileName, err = f.StreamListDirectoryEntries(ctx, current, lastFileName, false, graphqlPageSize, "", "", "", func(entry *filer.Entry) bool {
+				count++
+				if matched, _ := filepath.Match(pattern, entry.Name()); matched {
+					found = append(found, entry)
+				}
+				if recursive && entry.IsDirectory() {
+					dirs = append(dirs, entry.FullPath)
+				}
+				return len(found) < limit
+			})
+			if err != nil {
+				return nil, err
+			}
+			if count < graphqlPageSize {
+				break
+			}
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,43 @@
+package weed_server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+func TestGraphqlLimit(t *testing.T) {
+	tests := []struct {
+		args map[string]interface{}
+		want int
+	}{
+		{map[string]interface{}{}, graphqlDefaultLimit},
+		{map[string]interface{}{"limit": 0}, graphqlDefaultLimit},
+		{map[string]interface{}{"limit": -5}, graphqlDefaultLimit},
+		{map[string]interface{}{"limit": 10}, 10},
+		{map[string]interface{}{"limit": graphqlMaxLimit + 1}, graphqlMaxLimit},
+	}
+	for _, tt := range tests {
+		if got := graphqlLimit(tt.args); got != tt.want {
+			t.Errorf("graphqlLimit(%v) = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestGraphqlMutationNeedsWrite(t *testing.T) {
+	schema, err := newFilerGraphqlSchema(&FilerServer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the mutation is refused before reaching the filer
+	result := graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: `mutation { deleteEntry(path: "/dir") }`,
+		Context:       context.WithValue(context.Background(), graphqlCanWriteKey{}, false),
+	})
+	if len(result.Errors) != 1 || result.Errors[0].Message != "the mutations need a POST with the write jwt" {
+		t.Errorf("unexpected errors %v", result.Errors)
+	}
+}
@@ -50,7 +50,7 @@ func checkPreconditions(w http.ResponseWriter, r *http.Request, entry *filer.Ent
 	ifMatchETagHeader := r.Header.Get("If-Match")
 	ifUnmodifiedSinceHeader := r.Header.Get("If-Unmodified-Since")
 	if ifMatchETagHeader != "" {
-		if util.CanonicalizeETag(etag) != util.CanonicalizeETag(ifMatchETagHeader) {
+		if !etagMatches(etag, ifMatchETagHeader) {
 			w.WriteHeader(http.StatusPreconditionFailed)
 			return true
 		}
@@ -66,13 +66,15 @@ func checkPreconditions(w http.ResponseWriter, r *http.Request, entry *filer.Ent
 	ifNoneMatchETagHeader := r.Header.Get("If-None-Match")
 	ifModifiedSinceHeader := r.Header.Get("If-Modified-Since")
 	if ifNoneMatchETagHeader != "" {
-		if util.CanonicalizeETag(etag) == util.CanonicalizeETag(ifNoneMatchETagHeader) {
+		if etagMatches(etag, ifNoneMatchETagHeader) {
+			setEtag(w, etag)
 			w.WriteHeader(http.StatusNotModified)
 			return true
 		}
 	} else if ifModifiedSinceHeader != "" {
 		if t, parseError := time.Parse(http.TimeFormat, ifModifiedSinceHeader); parseError == nil {
 			if !t.Before(entry.Attr.Mtime) {
+				setEtag(w, etag)
 				w.WriteHeader(http.StatusNotModified)
 				return true
 			}
@@ -82,8 +84,33 @@ func checkPreconditions(w http.ResponseWriter, r *http.Request, entry *filer.Ent
 	return false
 }
 
+// etagMatches checks the etag against the comma separated etags of If-Match or If-None-Match, where "*" matches any.
+// The weak etags compare as the strong ones, since an entry has only one representation.
+func etagMatches(etag string, header string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	etag = util.CanonicalizeETag(etag)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if util.CanonicalizeETag(candidate) == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request) {
 
+	if r.URL.Query().Get("op") == "usage" {
+		fs.directoryUsageHandler(w, r)
+		return
+	}
+	if r.URL.Query().Has("tags") {
+		fs.tagSearchHandler(w, r)
+		return
+	}
+
 	path := r.URL.Path
 	isForDirectory := strings.HasSuffix(path, "/")
 	if isForDirectory && len(path) > 1 {
@@ -161,6 +188,10 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 			mimeType = mime.TypeByExtension(ext)
 		}
 	}
+	if mimeType == "" && r.Method != http.MethodHead {
+		// a HEAD request does not read the content, so it is not worth reading the first chunk
+		mimeType = fs.mimeDetector.Detect(entry)
+	}
 	if mimeType != "" {
 		w.Header().Set("Content-Type", mimeType)
 	} else {
@@ -229,7 +260,7 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	processRangeRequest(r, w, totalSize, mimeType, func(writer io.Writer, offset int64, size int64) error {
+	writeFn := func(writer io.Writer, offset int64, size int64) error {
 		if offset+size <= int64(len(entry.Content)) {
 			_, err := writer.Write(entry.Content[offset : offset+size])
 			if err != nil {
@@ -259,5 +290,12 @@ func (fs *FilerServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 			glog.Errorf("failed to stream content %s: %v", r.URL, err)
 		}
 		return err
-	})
+	}
+
+	if shouldBrotli(w, r, mimeType, totalSize) {
+		writeBrotli(w, entry, totalSize, writeFn)
+		return
+	}
+
+	processRangeRequest(r, w, totalSize, mimeType, writeFn)
 }
@@ -0,0 +1,89 @@
+package weed_server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+)
+
+// shouldBrotli checks whether the whole content of a text like file can be sent brotli compressed.
+// The stored encodings, the ranges, and the S3 gateway requests are sent as is.
+func shouldBrotli(w http.ResponseWriter, r *http.Request, mimeType string, totalSize int64) bool {
+	if totalSize == 0 || r.Header.Get("Range") != "" || w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+	if r.Header.Get(s3_constants.AmzIdentityId) != "" {
+		return false
+	}
+	return acceptsEncoding(r, "br") && isCompressibleMimeType(mimeType)
+}
+
+// acceptsEncoding checks the Accept-Encoding header for the encoding, skipping the ones with q=0
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(accepted, ";")
+		if strings.TrimSpace(name) != encoding {
+			continue
+		}
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func isCompressibleMimeType(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	mimeType = strings.TrimSpace(mimeType)
+	if strings.HasPrefix(mimeType, "text/") || strings.HasSuffix(mimeType, "+json") || strings.HasSuffix(mimeType, "+xml") {
+		return true
+	}
+	switch mimeType {
+	case "application/json", "application/javascript", "application/xml", "application/x-yaml", "application/yaml", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// writeBrotli sends the inline content compressed at once, and stream compresses the chunks.
+func writeBrotli(w http.ResponseWriter, entry *filer.Entry, totalSize int64, writeFn func(writer io.Writer, offset int64, size int64) error) {
+	w.Header().Set("Content-Encoding", "br")
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	if totalSize <= int64(len(entry.Content)) {
+		var buf bytes.Buffer
+		bw := brotli.NewWriterLevel(&buf, brotli.DefaultCompression)
+		bw.Write(entry.Content[:totalSize])
+		if err := bw.Close(); err != nil {
+			glog.Errorf("brotli compress %s: %v", entry.FullPath, err)
+			w.Header().Del("Content-Encoding")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		w.Write(buf.Bytes())
+		return
+	}
+
+	// the compressed length is unknown until the end, so the response is chunked
+	bw := brotli.NewWriterLevel(w, brotli.BestSpeed)
+	if err := writeFn(bw, 0, totalSize); err != nil {
+		// the brotli stream is left unfinished, so the client sees the truncation
+		glog.Errorf("brotli stream %s: %v", entry.FullPath, err)
+		return
+	}
+	if err := bw.Close(); err != nil {
+		glog.Errorf("brotli stream %s: %v", entry.FullPath, err)
+	}
+}
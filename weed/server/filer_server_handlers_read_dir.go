@@ -2,10 +2,12 @@ package weed_server
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	ui "github.com/seaweedfs/seaweedfs/weed/server/filer_ui"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
@@ -16,6 +18,8 @@ import (
 // files are sorted by name and paginated via "lastFileName" and "limit".
 // sub directories are listed on the first page, when "lastFileName"
 // is empty.
+// With "sort=name|size|mtime|ctime" and "order=asc|desc", the whole directory,
+// up to filer.MaxSortedListingEntries, is sorted in memory and paginated via "offset" and "limit".
 func (fs *FilerServer) listDirectoryHandler(w http.ResponseWriter, r *http.Request) {
 
 	stats.FilerRequestCounter.WithLabelValues(stats.DirList).Inc()
@@ -34,7 +38,28 @@ func (fs *FilerServer) listDirectoryHandler(w http.ResponseWriter, r *http.Reque
 	namePattern := r.FormValue("namePattern")
 	namePatternExclude := r.FormValue("namePatternExclude")
 
-	entries, shouldDisplayLoadMore, err := fs.filer.ListDirectoryEntries(context.Background(), util.FullPath(path), lastFileName, false, int64(limit), "", namePattern, namePatternExclude)
+	var entries []*filer.Entry
+	var shouldDisplayLoadMore bool
+	var err error
+	if sortBy := r.FormValue("sort"); sortBy != "" {
+		order := r.FormValue("order")
+		if order != "" && order != "asc" && order != "desc" {
+			writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("order should be asc or desc"))
+			return
+		}
+		offset, _ := strconv.Atoi(r.FormValue("offset"))
+		entries, shouldDisplayLoadMore, err = fs.filer.ListSortedDirectoryEntries(context.Background(), util.FullPath(path), sortBy, order == "desc", offset, limit, namePattern, namePatternExclude)
+		if err == filer.ErrTooManyEntriesToSort {
+			writeJsonError(w, r, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		if err == filer.ErrUnknownSort {
+			writeJsonError(w, r, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		entries, shouldDisplayLoadMore, err = fs.filer.ListDirectoryEntries(context.Background(), util.FullPath(path), lastFileName, false, int64(limit), "", namePattern, namePatternExclude)
+	}
 
 	if err != nil {
 		glog.V(0).Infof("listDirectory %s %s %d: %s", path, lastFileName, limit, err)
@@ -0,0 +1,133 @@
+package weed_server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+)
+
+func TestCheckPreconditions(t *testing.T) {
+	mtime := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	entry := &filer.Entry{
+		FullPath: "/buckets/b/obj",
+		Attr: filer.Attr{
+			Mtime: mtime,
+			Md5:   []byte{0xd4, 0x1d, 0x8c, 0xd9, 0x8f, 0x00, 0xb2, 0x04, 0xe9, 0x80, 0x09, 0x98, 0xec, 0xf8, 0x42, 0x7e},
+		},
+	}
+	etag := `"d41d8cd98f00b204e9800998ecf8427e"`
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{"no condition", nil, http.StatusOK},
+		{"if-match", map[string]string{"If-Match": etag}, http.StatusOK},
+		{"if-match other", map[string]string{"If-Match": `"abc"`}, http.StatusPreconditionFailed},
+		{"if-match list", map[string]string{"If-Match": `"abc", ` + etag}, http.StatusOK},
+		{"if-match any", map[string]string{"If-Match": "*"}, http.StatusOK},
+		{"if-none-match", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"if-none-match weak", map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{"if-none-match other", map[string]string{"If-None-Match": `"abc"`}, http.StatusOK},
+		{"if-modified-since same", map[string]string{"If-Modified-Since": mtime.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"if-modified-since before", map[string]string{"If-Modified-Since": mtime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		{"if-unmodified-since before", map[string]string{"If-Unmodified-Since": mtime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusPreconditionFailed},
+		{"if-unmodified-since after", map[string]string{"If-Unmodified-Since": mtime.Add(time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		// the etag conditions take precedence over the dates
+		{"if-match and unmodified-since", map[string]string{"If-Match": etag, "If-Unmodified-Since": mtime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		{"if-none-match other and modified-since", map[string]string{"If-None-Match": `"abc"`, "If-Modified-Since": mtime.Format(http.TimeFormat)}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/buckets/b/obj", nil)
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		if stopped := checkPreconditions(w, r, entry); stopped != (tt.status != http.StatusOK) {
+			t.Errorf("%s: stopped %v", tt.name, stopped)
+		}
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, expected %d", tt.name, w.Code, tt.status)
+		}
+		if tt.status == http.StatusNotModified && w.Header().Get("ETag") != etag {
+			t.Errorf("%s: etag %q", tt.name, w.Header().Get("ETag"))
+		}
+	}
+}
+
+func TestShouldBrotli(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		mimeType string
+		expected bool
+	}{
+		{"br", map[string]string{"Accept-Encoding": "gzip, deflate, br"}, "text/plain; charset=utf-8", true},
+		{"json", map[string]string{"Accept-Encoding": "br;q=0.8"}, "application/json", true},
+		{"gzip only", map[string]string{"Accept-Encoding": "gzip"}, "text/html", false},
+		{"br refused", map[string]string{"Accept-Encoding": "br;q=0, gzip"}, "text/html", false},
+		{"binary", map[string]string{"Accept-Encoding": "br"}, "image/png", false},
+		{"range", map[string]string{"Accept-Encoding": "br", "Range": "bytes=0-9"}, "text/plain", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/dir/file", nil)
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		if actual := shouldBrotli(httptest.NewRecorder(), r, tt.mimeType, 100); actual != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, actual)
+		}
+	}
+
+	// the stored encoding is sent as is
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Encoding", "gzip")
+	r := httptest.NewRequest(http.MethodGet, "/dir/file", nil)
+	r.Header.Set("Accept-Encoding", "br")
+	if shouldBrotli(w, r, "text/plain", 100) {
+		t.Errorf("expected no brotli over the stored gzip encoding")
+	}
+}
+
+func TestWriteBrotli(t *testing.T) {
+	data := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog\n"), 1000)
+	writeFn := func(writer io.Writer, offset int64, size int64) error {
+		// the chunks are written in pieces
+		for ; size > 0; offset, size = offset+1000, size-1000 {
+			n := size
+			if n > 1000 {
+				n = 1000
+			}
+			if _, err := writer.Write(data[offset : offset+n]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, entry := range []*filer.Entry{
+		{FullPath: "/dir/inline", Content: data},
+		{FullPath: "/dir/chunked"},
+	} {
+		w := httptest.NewRecorder()
+		writeBrotli(w, entry, int64(len(data)), writeFn)
+		if encoding := w.Header().Get("Content-Encoding"); encoding != "br" {
+			t.Fatalf("%s: Content-Encoding %q", entry.FullPath, encoding)
+		}
+		if w.Body.Len() >= len(data) {
+			t.Errorf("%s: compressed %d bytes into %d", entry.FullPath, len(data), w.Body.Len())
+		}
+		decompressed, err := io.ReadAll(brotli.NewReader(w.Body))
+		if err != nil || !bytes.Equal(decompressed, data) {
+			t.Errorf("%s: decompressed %d bytes: %v", entry.FullPath, len(decompressed), err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package weed_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+type TagSearchResult struct {
+	Path        string            `json:"path,omitempty"`
+	IsDirectory bool              `json:"isDirectory,omitempty"`
+	Size        uint64            `json:"size,omitempty"`
+	Mtime       int64             `json:"mtime,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// tagSearchHandler serves GET /<dir>?tags=key1:val1,key2:val2, or GET /filer?path=<dir>&tags=key1:val1,key2:val2,
+// streaming the entries having all the extended attributes as newline-delimited JSON.
+// An error after the first entry is sent as the last line, with only the "error" field.
+func (fs *FilerServer) tagSearchHandler(w http.ResponseWriter, r *http.Request) {
+	path := r.FormValue("path")
+	if path == "" {
+		path = r.URL.Path
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	tags, err := filer.ParseTags(r.FormValue("tags"))
+	if err != nil {
+		writeJsonError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if path != "/" {
+		dir, err := fs.filer.FindEntry(r.Context(), util.FullPath(path))
+		if err != nil {
+			writeJsonError(w, r, http.StatusNotFound, err)
+			return
+		}
+		if !dir.IsDirectory() {
+			writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("%s is not a directory", path))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	err = fs.filer.SearchByTags(r.Context(), util.FullPath(path), tags, func(entry *filer.Entry) error {
+		result := &TagSearchResult{
+			Path:        string(entry.FullPath),
+			IsDirectory: entry.IsDirectory(),
+			Size:        entry.Size(),
+			Mtime:       entry.Attr.Mtime.Unix(),
+			Tags:        make(map[string]string, len(tags)),
+		}
+		for key := range tags {
+			result.Tags[key] = string(entry.Extended[key])
+		}
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil && r.Context().Err() == nil {
+		glog.V(1).Infof("search %s by tags %v: %v", path, tags, err)
+		encoder.Encode(&TagSearchResult{Error: err.Error()})
+	}
+}
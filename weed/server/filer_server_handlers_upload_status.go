@@ -0,0 +1,120 @@
+package weed_server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+)
+
+// the finished uploads are kept for a while, so the pollers can see them completed
+const uploadStatusRetention = time.Minute
+
+// uploadStatus is the progress of the PUT and POST requests sharing an upload id,
+// e.g., the parts of an s3 multipart upload.
+type uploadStatus struct {
+	bytesWritten int64
+	total        int64
+	startedAt    time.Time
+
+	sync.Mutex
+	active  int
+	retired bool
+}
+
+type UploadStatusResult struct {
+	BytesWritten int64     `json:"bytes_written"`
+	Total        int64     `json:"total"`
+	Percent      float64   `json:"percent"`
+	StartedAt    time.Time `json:"started_at"`
+}
+
+// trackUpload counts the bytes read from the request body under the upload id from the
+// X-SeaweedFS-Upload-Id request header, or a new one returned in the same response header.
+// A client sets the header to poll the progress while its request is still uploading.
+// It is only called when the filer runs with -upload-status.
+func (fs *FilerServer) trackUpload(w http.ResponseWriter, r *http.Request, contentLength int64) (done func()) {
+	uploadId := r.Header.Get(s3_constants.SeaweedUploadIdHeader)
+	if uploadId == "" {
+		uploadId = uuid.New().String()
+	}
+	w.Header().Set(s3_constants.SeaweedUploadIdHeader, uploadId)
+
+	var status *uploadStatus
+	for {
+		value, _ := fs.uploads.LoadOrStore(uploadId, &uploadStatus{startedAt: time.Now()})
+		status = value.(*uploadStatus)
+		status.Lock()
+		if !status.retired {
+			status.active++
+			status.Unlock()
+			break
+		}
+		// being removed, start a new one
+		status.Unlock()
+	}
+	atomic.AddInt64(&status.total, contentLength)
+	r.Body = &uploadCountingReader{ReadCloser: r.Body, status: status}
+
+	return func() {
+		status.Lock()
+		status.active--
+		status.Unlock()
+		time.AfterFunc(uploadStatusRetention, func() {
+			status.Lock()
+			defer status.Unlock()
+			if status.active == 0 && !status.retired {
+				status.retired = true
+				fs.uploads.CompareAndDelete(uploadId, status)
+			}
+		})
+	}
+}
+
+// UploadStatusHandler returns the progress of an upload, by GET /upload/status?uploadId=<id>
+func (fs *FilerServer) UploadStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !fs.maybeCheckJwtAuthorization(r, false) {
+		writeJsonError(w, r, http.StatusUnauthorized, errors.New("wrong jwt"))
+		return
+	}
+
+	value, found := fs.uploads.Load(r.URL.Query().Get("uploadId"))
+	if !found {
+		writeJsonError(w, r, http.StatusNotFound, errors.New("upload not found"))
+		return
+	}
+	writeJsonQuiet(w, r, http.StatusOK, value.(*uploadStatus).result())
+}
+
+func (s *uploadStatus) result() *UploadStatusResult {
+	result := &UploadStatusResult{
+		BytesWritten: atomic.LoadInt64(&s.bytesWritten),
+		Total:        atomic.LoadInt64(&s.total),
+		StartedAt:    s.startedAt,
+	}
+	if result.Total > 0 {
+		result.Percent = float64(result.BytesWritten) * 100 / float64(result.Total)
+	}
+	return result
+}
+
+type uploadCountingReader struct {
+	io.ReadCloser
+	status *uploadStatus
+}
+
+func (r *uploadCountingReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	atomic.AddInt64(&r.status.bytesWritten, int64(n))
+	return
+}
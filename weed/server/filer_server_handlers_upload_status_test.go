@@ -0,0 +1,58 @@
+package weed_server
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
+)
+
+func TestTrackUpload(t *testing.T) {
+	fs := &FilerServer{}
+
+	// two parts of the same upload
+	r1 := httptest.NewRequest("PUT", "/dir/file.part1", strings.NewReader(strings.Repeat("a", 100)))
+	r1.Header.Set(s3_constants.SeaweedUploadIdHeader, "upload1")
+	w1 := httptest.NewRecorder()
+	done1 := fs.trackUpload(w1, r1, 100)
+
+	r2 := httptest.NewRequest("PUT", "/dir/file.part2", strings.NewReader(strings.Repeat("b", 300)))
+	r2.Header.Set(s3_constants.SeaweedUploadIdHeader, "upload1")
+	done2 := fs.trackUpload(httptest.NewRecorder(), r2, 300)
+
+	if got := w1.Header().Get(s3_constants.SeaweedUploadIdHeader); got != "upload1" {
+		t.Errorf("upload id header %q", got)
+	}
+
+	if _, err := io.ReadAll(r1.Body); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(r2.Body, make([]byte, 100)); err != nil {
+		t.Fatal(err)
+	}
+
+	value, found := fs.uploads.Load("upload1")
+	if !found {
+		t.Fatalf("upload1 is not tracked")
+	}
+	result := value.(*uploadStatus).result()
+	if result.BytesWritten != 200 || result.Total != 400 || result.Percent != 50 {
+		t.Errorf("unexpected status %+v", result)
+	}
+	done1()
+	done2()
+
+	// without the request header, a new upload id is returned
+	w3 := httptest.NewRecorder()
+	r3 := httptest.NewRequest("POST", "/dir/", strings.NewReader("c"))
+	fs.trackUpload(w3, r3, 1)()
+	uploadId := w3.Header().Get(s3_constants.SeaweedUploadIdHeader)
+	if uploadId == "" || uploadId == "upload1" {
+		t.Errorf("new upload id %q", uploadId)
+	}
+	if _, found := fs.uploads.Load(uploadId); !found {
+		t.Errorf("%s is not tracked", uploadId)
+	}
+}
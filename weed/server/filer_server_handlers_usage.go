@@ -0,0 +1,48 @@
+package weed_server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+type DirectoryUsageResult struct {
+	Path      string `json:"path"`
+	Bytes     uint64 `json:"bytes"`
+	Inodes    uint64 `json:"inodes"`
+	UpdatedAt int64  `json:"updatedAt,omitempty"`
+	Pending   bool   `json:"pending,omitempty"`
+}
+
+// directoryUsageHandler serves GET /<dir>?op=usage, or GET /filer?path=<dir>&op=usage.
+// The usage is as of updatedAt, and a usage older than an hour is walked again in the background.
+// Add useCache=false to walk the directory again.
+func (fs *FilerServer) directoryUsageHandler(w http.ResponseWriter, r *http.Request) {
+	path := r.FormValue("path")
+	if path == "" {
+		path = r.URL.Path
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	usage, err := fs.filer.GetDirectoryUsage(r.Context(), util.FullPath(path), r.FormValue("useCache") != "false")
+	switch {
+	case err == filer.ErrDirectoryUsagePending:
+		writeJsonQuiet(w, r, http.StatusAccepted, &DirectoryUsageResult{Path: path, Pending: true})
+	case err == filer_pb.ErrNotFound:
+		writeJsonError(w, r, http.StatusNotFound, err)
+	case err != nil:
+		writeJsonError(w, r, http.StatusBadRequest, err)
+	default:
+		writeJsonQuiet(w, r, http.StatusOK, &DirectoryUsageResult{
+			Path:      path,
+			Bytes:     usage.Bytes,
+			Inodes:    usage.Inodes,
+			UpdatedAt: usage.UpdatedAt.Unix(),
+		})
+	}
+}
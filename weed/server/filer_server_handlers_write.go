@@ -11,6 +11,7 @@ import (
 
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -33,7 +34,8 @@ type FilerPostResult struct {
 	Error string `json:"error,omitempty"`
 }
 
-func (fs *FilerServer) assignNewFileInfo(so *operation.StorageOption) (fileId, urlLocation string, auth security.EncodedJwt, err error) {
+// assignNewFileInfo returns the url of the preferred replica, and the urls on the other replicas to try if it is unavailable
+func (fs *FilerServer) assignNewFileInfo(so *operation.StorageOption) (fileId, urlLocation string, replicaUrlLocations []string, auth security.EncodedJwt, err error) {
 
 	stats.FilerRequestCounter.WithLabelValues(stats.ChunkAssign).Inc()
 	start := time.Now()
@@ -41,9 +43,7 @@ func (fs *FilerServer) assignNewFileInfo(so *operation.StorageOption) (fileId, u
 		stats.FilerRequestHistogram.WithLabelValues(stats.ChunkAssign).Observe(time.Since(start).Seconds())
 	}()
 
-	ar, altRequest := so.ToAssignRequests(1)
-
-	assignResult, ae := operation.Assign(fs.filer.GetMaster, fs.grpcDialOption, ar, altRequest)
+	assignResult, ae := fs.assignVolume(so, 1)
 	if ae != nil {
 		glog.Errorf("failing to assign a file id: %v", ae)
 		err = ae
@@ -60,14 +60,60 @@ func (fs *FilerServer) assignNewFileInfo(so *operation.StorageOption) (fileId, u
 			}
 		}
 	}
-	urlLocation = "http://" + assignUrl + "/" + assignResult.Fid
-	if so.Fsync {
-		urlLocation += "?fsync=true"
+	toUrlLocation := func(url string) string {
+		urlLocation := "http://" + url + "/" + assignResult.Fid
+		if so.Fsync {
+			urlLocation += "?fsync=true"
+		}
+		return urlLocation
+	}
+	urlLocation = toUrlLocation(assignUrl)
+	if assignUrl != assignResult.Url {
+		replicaUrlLocations = append(replicaUrlLocations, toUrlLocation(assignResult.Url))
+	}
+	for _, repl := range assignResult.Replicas {
+		if repl.Url != assignUrl {
+			replicaUrlLocations = append(replicaUrlLocations, toUrlLocation(repl.Url))
+		}
 	}
 	auth = assignResult.Auth
 	return
 }
 
+// assignVolume assigns the file ids, only in the allowed locations if the file has a residency policy
+func (fs *FilerServer) assignVolume(so *operation.StorageOption, count int) (*operation.AssignResult, error) {
+	if so.Residency == nil {
+		ar, altRequest := so.ToAssignRequests(count)
+		return operation.Assign(fs.filer.GetMaster, fs.grpcDialOption, ar, altRequest)
+	}
+
+	// the volume with replicas outside of the allowed locations is rejected, and placed in the next location
+	var lastErr error
+	for _, request := range so.ToResidencyAssignRequests(count) {
+		assignResult, err := operation.Assign(fs.filer.GetMaster, fs.grpcDialOption, request)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		violations := so.Residency.Violations(assignResult)
+		if len(violations) == 0 {
+			return assignResult, nil
+		}
+		lastErr = fmt.Errorf("file id %s is placed in %v", assignResult.Fid, violations)
+		glog.Warningf("residency violation %s: %v", so.Residency.Path, lastErr)
+		if fs.option.AuditLogger != nil {
+			fs.option.AuditLogger.Log(&filer.AuditRecord{
+				Time:      time.Now(),
+				Operation: "ResidencyViolation",
+				Path:      so.Residency.Path,
+				Status:    "rejected",
+				Error:     lastErr.Error(),
+			})
+		}
+	}
+	return nil, fmt.Errorf("%w: no volume for %s in data centers %v racks %v: %v", filer.ErrNoPolicy, so.Residency.Path, so.Residency.DataCenters, so.Residency.Racks, lastErr)
+}
+
 func (fs *FilerServer) PostHandler(w http.ResponseWriter, r *http.Request, contentLength int64) {
 
 	ctx := context.Background()
@@ -248,6 +294,7 @@ func (fs *FilerServer) detectStorageOption(requestURI, qCollection, qReplication
 		DiskType:          util.Nvl(diskType, rule.DiskType),
 		Fsync:             rule.Fsync,
 		VolumeGrowthCount: rule.VolumeGrowthCount,
+		Residency:         fs.residencyPolicy.Residency(requestURI),
 	}, nil
 }
 
@@ -271,7 +271,7 @@ func (fs *FilerServer) saveAsChunk(so *operation.StorageOption) filer.SaveDataAs
 
 		err := util.Retry("saveAsChunk", func() error {
 			// assign one file id for one chunk
-			assignedFileId, urlLocation, auth, assignErr := fs.assignNewFileInfo(so)
+			assignedFileId, urlLocation, replicaUrlLocations, auth, assignErr := fs.assignNewFileInfo(so)
 			if assignErr != nil {
 				return assignErr
 			}
@@ -287,6 +287,7 @@ func (fs *FilerServer) saveAsChunk(so *operation.StorageOption) filer.SaveDataAs
 				MimeType:          "",
 				PairMap:           nil,
 				Jwt:               auth,
+				ReplicaUrls:       replicaUrlLocations,
 			}
 			var uploadErr error
 			uploadResult, uploadErr, _ = operation.Upload(reader, uploadOption)
@@ -19,7 +19,7 @@ import (
 // handling single chunk POST or PUT upload
 func (fs *FilerServer) encrypt(ctx context.Context, w http.ResponseWriter, r *http.Request, so *operation.StorageOption) (filerResult *FilerPostResult, err error) {
 
-	fileId, urlLocation, auth, err := fs.assignNewFileInfo(so)
+	fileId, urlLocation, replicaUrlLocations, auth, err := fs.assignNewFileInfo(so)
 
 	if err != nil || fileId == "" || urlLocation == "" {
 		return nil, fmt.Errorf("fail to allocate volume for %s, collection:%s, datacenter:%s", r.URL.Path, so.Collection, so.DataCenter)
@@ -52,6 +52,7 @@ func (fs *FilerServer) encrypt(ctx context.Context, w http.ResponseWriter, r *ht
 		MimeType:          pu.MimeType,
 		PairMap:           pu.PairMap,
 		Jwt:               auth,
+		ReplicaUrls:       replicaUrlLocations,
 	}
 	uploadResult, uploadError := operation.UploadData(uncompressedData, uploadOption)
 	if uploadError != nil {
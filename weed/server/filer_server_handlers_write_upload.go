@@ -137,7 +137,7 @@ func (fs *FilerServer) uploadReaderToChunks(w http.ResponseWriter, r *http.Reque
 	return fileChunks, md5Hash, chunkOffset, nil, smallContent
 }
 
-func (fs *FilerServer) doUpload(urlLocation string, limitedReader io.Reader, fileName string, contentType string, pairMap map[string]string, auth security.EncodedJwt) (*operation.UploadResult, error, []byte) {
+func (fs *FilerServer) doUpload(urlLocation string, replicaUrlLocations []string, limitedReader io.Reader, fileName string, contentType string, pairMap map[string]string, auth security.EncodedJwt) (*operation.UploadResult, error, []byte) {
 
 	stats.FilerRequestCounter.WithLabelValues(stats.ChunkUpload).Inc()
 	start := time.Now()
@@ -153,6 +153,7 @@ func (fs *FilerServer) doUpload(urlLocation string, limitedReader io.Reader, fil
 		MimeType:          contentType,
 		PairMap:           pairMap,
 		Jwt:               auth,
+		ReplicaUrls:       replicaUrlLocations,
 	}
 	uploadResult, err, data := operation.Upload(limitedReader, uploadOption)
 	if uploadResult != nil && uploadResult.RetryCount > 0 {
@@ -166,6 +167,7 @@ func (fs *FilerServer) dataToChunk(fileName, contentType string, data []byte, ch
 
 	// retry to assign a different file id
 	var fileId, urlLocation string
+	var replicaUrlLocations []string
 	var auth security.EncodedJwt
 	var uploadErr error
 	var uploadResult *operation.UploadResult
@@ -173,14 +175,14 @@ func (fs *FilerServer) dataToChunk(fileName, contentType string, data []byte, ch
 
 	err := util.Retry("filerDataToChunk", func() error {
 		// assign one file id for one chunk
-		fileId, urlLocation, auth, uploadErr = fs.assignNewFileInfo(so)
+		fileId, urlLocation, replicaUrlLocations, auth, uploadErr = fs.assignNewFileInfo(so)
 		if uploadErr != nil {
 			glog.V(4).Infof("retry later due to assign error: %v", uploadErr)
 			stats.FilerRequestCounter.WithLabelValues(stats.ChunkAssignRetry).Inc()
 			return uploadErr
 		}
 		// upload the chunk to the volume server
-		uploadResult, uploadErr, _ = fs.doUpload(urlLocation, dataReader, fileName, contentType, nil, auth)
+		uploadResult, uploadErr, _ = fs.doUpload(urlLocation, replicaUrlLocations, dataReader, fileName, contentType, nil, auth)
 		if uploadErr != nil {
 			glog.V(4).Infof("retry later due to upload error: %v", uploadErr)
 			stats.FilerRequestCounter.WithLabelValues(stats.ChunkDoUploadRetry).Inc()
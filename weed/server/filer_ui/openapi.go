@@ -0,0 +1,15 @@
+package filer_ui
+
+import (
+	_ "embed"
+)
+
+// OpenApiSpec describes the filer http api. Keep it in sync with the filer http handlers.
+//
+//go:embed openapi.json
+var OpenApiSpec []byte
+
+// OpenApiDocsHtml renders the OpenApiSpec, with only the bundled static resources and no third party scripts
+//
+//go:embed openapi_docs.html
+var OpenApiDocsHtml []byte
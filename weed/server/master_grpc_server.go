@@ -58,6 +58,30 @@ func (ms *MasterServer) UnRegisterUuids(ip string, port int) {
 	glog.V(0).Infof("remove volume server %v, online volume server: %v", key, ms.Topo.UuidMap)
 }
 
+// unRegisterDataNode removes the volume server, and tells the clients about its volumes being gone
+func (ms *MasterServer) unRegisterDataNode(dn *topology.DataNode) {
+	message := &master_pb.VolumeLocation{
+		DataCenter: dn.GetDataCenterId(),
+		Url:        dn.Url(),
+		PublicUrl:  dn.PublicUrl,
+	}
+	for _, v := range dn.GetVolumes() {
+		message.DeletedVids = append(message.DeletedVids, uint32(v.Id))
+	}
+	for _, s := range dn.GetEcShards() {
+		message.DeletedVids = append(message.DeletedVids, uint32(s.VolumeId))
+	}
+
+	// if the volume server disconnects and reconnects quickly
+	//  the unregister and register can race with each other
+	ms.Topo.UnRegisterDataNode(dn)
+	ms.UnRegisterUuids(dn.Ip, dn.Port)
+
+	if len(message.DeletedVids) > 0 {
+		ms.broadcastToClients(&master_pb.KeepConnectedResponse{VolumeLocation: message})
+	}
+}
+
 func (ms *MasterServer) SendHeartbeat(stream master_pb.Seaweed_SendHeartbeatServer) error {
 	var dn *topology.DataNode
 
@@ -68,28 +92,13 @@ func (ms *MasterServer) SendHeartbeat(stream master_pb.Seaweed_SendHeartbeatServ
 				glog.V(0).Infof("disconnect phantom volume server %s:%d remaining %d", dn.Ip, dn.Port, dn.Counter)
 				return
 			}
-
-			message := &master_pb.VolumeLocation{
-				DataCenter: dn.GetDataCenterId(),
-				Url:        dn.Url(),
-				PublicUrl:  dn.PublicUrl,
-			}
-			for _, v := range dn.GetVolumes() {
-				message.DeletedVids = append(message.DeletedVids, uint32(v.Id))
-			}
-			for _, s := range dn.GetEcShards() {
-				message.DeletedVids = append(message.DeletedVids, uint32(s.VolumeId))
+			if dn.IsTerminating {
+				// already removed as a dead volume server
+				return
 			}
 
-			// if the volume server disconnects and reconnects quickly
-			//  the unregister and register can race with each other
-			ms.Topo.UnRegisterDataNode(dn)
+			ms.unRegisterDataNode(dn)
 			glog.V(0).Infof("unregister disconnected volume server %s:%d", dn.Ip, dn.Port)
-			ms.UnRegisterUuids(dn.Ip, dn.Port)
-
-			if len(message.DeletedVids) > 0 {
-				ms.broadcastToClients(&master_pb.KeepConnectedResponse{VolumeLocation: message})
-			}
 		}
 	}()
 
@@ -157,6 +166,12 @@ func (ms *MasterServer) SendHeartbeat(stream master_pb.Seaweed_SendHeartbeatServ
 			dn.Counter++
 		}
 
+		if dn.IsTerminating {
+			// the volume server reconnects to register again
+			return fmt.Errorf("volume server %s:%d has been removed as dead", dn.Ip, dn.Port)
+		}
+		dn.LastSeen = time.Now().Unix()
+
 		dn.AdjustMaxVolumeCounts(heartbeat.MaxVolumeCounts)
 
 		glog.V(4).Infof("master received heartbeat %s", heartbeat.String())
@@ -186,6 +201,7 @@ func (ms *MasterServer) SendHeartbeat(stream master_pb.Seaweed_SendHeartbeatServ
 		}
 
 		if len(heartbeat.Volumes) > 0 || heartbeat.HasNoVolumes {
+			dn.IsReadOnly = heartbeat.ReadOnly
 			if heartbeat.Ip != "" {
 				dcName, rackName := ms.Topo.Configuration.Locate(heartbeat.Ip, heartbeat.DataCenter, heartbeat.Rack)
 				ms.Topo.DataNodeRegistration(dcName, rackName, dn)
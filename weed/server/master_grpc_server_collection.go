@@ -8,6 +8,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
 )
 
 func (ms *MasterServer) CollectionList(ctx context.Context, req *master_pb.CollectionListRequest) (*master_pb.CollectionListResponse, error) {
@@ -93,3 +94,56 @@ func (ms *MasterServer) doDeleteEcCollection(collectionName string) error {
 
 	return nil
 }
+
+func (ms *MasterServer) CollectionGCConfigSet(ctx context.Context, req *master_pb.CollectionGCConfigSetRequest) (*master_pb.CollectionGCConfigSetResponse, error) {
+
+	if !ms.Topo.IsLeader() {
+		return nil, raft.NotLeaderError
+	}
+
+	if err := ms.Topo.SetCollectionGarbageThreshold(req.Collection, req.GarbageThreshold, req.UseDefault); err != nil {
+		return nil, err
+	}
+
+	return &master_pb.CollectionGCConfigSetResponse{}, nil
+}
+
+func (ms *MasterServer) CollectionGCConfigGet(ctx context.Context, req *master_pb.CollectionGCConfigGetRequest) (*master_pb.CollectionGCConfigGetResponse, error) {
+
+	resp := &master_pb.CollectionGCConfigGetResponse{
+		GarbageThreshold: ms.option.GarbageThreshold,
+	}
+	if threshold, found := ms.Topo.CollectionGCConfig.Get(req.Collection); found {
+		resp.GarbageThreshold = threshold
+		resp.IsCollectionThreshold = true
+	}
+
+	return resp, nil
+}
+
+func (ms *MasterServer) CollectionEcConfigSet(ctx context.Context, req *master_pb.CollectionEcConfigSetRequest) (*master_pb.CollectionEcConfigSetResponse, error) {
+
+	if !ms.Topo.IsLeader() {
+		return nil, raft.NotLeaderError
+	}
+
+	if err := ms.Topo.SetCollectionEcConfig(req.Collection, erasure_coding.NewECConfig(req.DataShards, req.ParityShards)); err != nil {
+		return nil, err
+	}
+
+	return &master_pb.CollectionEcConfigSetResponse{}, nil
+}
+
+func (ms *MasterServer) CollectionEcConfigGet(ctx context.Context, req *master_pb.CollectionEcConfigGetRequest) (*master_pb.CollectionEcConfigGetResponse, error) {
+
+	ecConfig, found := ms.Topo.CollectionECConfig.Get(req.Collection)
+	if !found {
+		ecConfig = erasure_coding.DefaultECConfig
+	}
+
+	return &master_pb.CollectionEcConfigGetResponse{
+		DataShards:         uint32(ecConfig.DataShards),
+		ParityShards:       uint32(ecConfig.ParityShards),
+		IsCollectionConfig: found,
+	}, nil
+}
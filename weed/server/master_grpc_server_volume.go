@@ -171,6 +171,7 @@ func (ms *MasterServer) Assign(ctx context.Context, req *master_pb.AssignRequest
 					PublicUrl:  r.PublicUrl,
 					GrpcPort:   uint32(r.GrpcPort),
 					DataCenter: r.GetDataCenterId(),
+					Rack:       r.GetRackId(),
 				})
 			}
 			return &master_pb.AssignResponse{
@@ -180,6 +181,7 @@ func (ms *MasterServer) Assign(ctx context.Context, req *master_pb.AssignRequest
 					PublicUrl:  dn.PublicUrl,
 					GrpcPort:   uint32(dn.GrpcPort),
 					DataCenter: dn.GetDataCenterId(),
+					Rack:       dn.GetRackId(),
 				},
 				Count:    count,
 				Auth:     string(security.GenJwtForVolumeServer(ms.guard.SigningKey, ms.guard.ExpiresAfterSec, fid)),
@@ -254,6 +256,9 @@ func (ms *MasterServer) LookupEcVolume(ctx context.Context, req *master_pb.Looku
 	resp.VolumeId = req.VolumeId
 
 	for shardId, shardLocations := range ecLocations.Locations {
+		if len(shardLocations) == 0 {
+			continue
+		}
 		var locations []*master_pb.Location
 		for _, dn := range shardLocations {
 			locations = append(locations, &master_pb.Location{
@@ -279,7 +284,7 @@ func (ms *MasterServer) VacuumVolume(ctx context.Context, req *master_pb.VacuumV
 
 	resp := &master_pb.VacuumVolumeResponse{}
 
-	ms.Topo.Vacuum(ms.grpcDialOption, float64(req.GarbageThreshold), req.VolumeId, req.Collection, ms.preallocateSize)
+	ms.Topo.Vacuum(ms.grpcDialOption, float64(req.GarbageThreshold), false, req.VolumeId, req.Collection, ms.preallocateSize)
 
 	return resp, nil
 }
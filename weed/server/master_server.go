@@ -15,6 +15,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 
 	"github.com/seaweedfs/seaweedfs/weed/cluster"
+	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 
 	"github.com/gorilla/mux"
@@ -45,11 +46,15 @@ type MasterOption struct {
 	// PulseSeconds            int
 	DefaultReplicaPlacement string
 	GarbageThreshold        float64
+	VacuumParallelism       int
 	WhiteList               []string
 	DisableHttp             bool
 	MetricsAddress          string
 	MetricsIntervalSec      int
 	IsFollower              bool
+	// remove the volume servers without heartbeat for this long, 0 to disable
+	DeadVolumeServerTimeout time.Duration
+	AuditLogger             *filer.AuditLogger
 }
 
 type MasterServer struct {
@@ -75,6 +80,13 @@ type MasterServer struct {
 
 	adminLocks *AdminLocks
 
+	replicationRepair *replicationRepairer
+	volumeRebalance   *volumeRebalancer
+
+	// the dead volume servers kept for their volumes without live replica, to their volumes as audited
+	keptDeadVolumeServersLock sync.Mutex
+	keptDeadVolumeServers     map[string]string
+
 	Cluster *cluster.Cluster
 }
 
@@ -105,14 +117,16 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]pb.Se
 
 	grpcDialOption := security.LoadClientTLS(v, "grpc.master")
 	ms := &MasterServer{
-		option:          option,
-		preallocateSize: preallocateSize,
-		vgCh:            make(chan *topology.VolumeGrowRequest, 1<<6),
-		clientChans:     make(map[string]chan *master_pb.KeepConnectedResponse),
-		grpcDialOption:  grpcDialOption,
-		MasterClient:    wdclient.NewMasterClient(grpcDialOption, "", cluster.MasterType, option.Master, "", "", peers),
-		adminLocks:      NewAdminLocks(),
-		Cluster:         cluster.NewCluster(),
+		option:            option,
+		preallocateSize:   preallocateSize,
+		vgCh:              make(chan *topology.VolumeGrowRequest, 1<<6),
+		clientChans:       make(map[string]chan *master_pb.KeepConnectedResponse),
+		grpcDialOption:    grpcDialOption,
+		MasterClient:      wdclient.NewMasterClient(grpcDialOption, "", cluster.MasterType, option.Master, "", "", peers),
+		adminLocks:        NewAdminLocks(),
+		replicationRepair: &replicationRepairer{},
+		volumeRebalance:   &volumeRebalancer{},
+		Cluster:           cluster.NewCluster(),
 	}
 	ms.boundedLeaderChan = make(chan int, 16)
 
@@ -123,6 +137,10 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]pb.Se
 		glog.Fatalf("create sequencer failed.")
 	}
 	ms.Topo = topology.NewTopology("topo", seq, uint64(ms.option.VolumeSizeLimitMB)*1024*1024, 5, replicationAsMin)
+	ms.Topo.VacuumScheduler = topology.NewParallelVacuumScheduler(ms.option.VacuumParallelism)
+	if err := stats.Gather.Register(topology.NewMetricsCollector(ms.Topo)); err != nil {
+		glog.V(0).Infof("register topology metrics: %v", err)
+	}
 	ms.vg = topology.NewDefaultVolumeGrowth()
 	glog.V(0).Infoln("Volume Size Limit is", ms.option.VolumeSizeLimitMB, "MB")
 
@@ -139,7 +157,14 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]pb.Se
 		r.HandleFunc("/vol/grow", ms.proxyToLeader(ms.guard.WhiteList(ms.volumeGrowHandler)))
 		r.HandleFunc("/vol/status", ms.proxyToLeader(ms.guard.WhiteList(ms.volumeStatusHandler)))
 		r.HandleFunc("/vol/vacuum", ms.proxyToLeader(ms.guard.WhiteList(ms.volumeVacuumHandler)))
+		r.HandleFunc("/admin/gc/status", ms.proxyToLeader(ms.guard.WhiteList(ms.gcStatusHandler)))
+		r.HandleFunc("/admin/repair/status", ms.proxyToLeader(ms.guard.WhiteList(ms.repairStatusHandler)))
+		r.HandleFunc("/admin/rebalance/plan", ms.proxyToLeader(ms.guard.WhiteList(ms.rebalancePlanHandler)))
+		r.HandleFunc("/admin/rebalance/status", ms.proxyToLeader(ms.guard.WhiteList(ms.rebalanceStatusHandler)))
+		r.HandleFunc("/admin/topology/remove-dead", ms.proxyToLeader(ms.guard.WhiteList(ms.removeDeadVolumeServersHandler)))
 		r.HandleFunc("/submit", ms.guard.WhiteList(ms.submitFromMasterServerHandler))
+		r.Handle("/metrics", stats.MetricsHandler())
+		r.HandleFunc("/ws/metrics", ms.guard.WhiteList(stats.MetricsWebSocketHandler))
 		/*
 			r.HandleFunc("/stats/health", ms.guard.WhiteList(statsHealthHandler))
 			r.HandleFunc("/stats/counter", ms.guard.WhiteList(statsCounterHandler))
@@ -159,6 +184,9 @@ func NewMasterServer(r *mux.Router, option *MasterOption, peers map[string]pb.Se
 
 	if !option.IsFollower {
 		ms.startAdminScripts()
+		ms.startReplicationRepair()
+		ms.startVolumeRebalance()
+		go ms.DetectDeadVolumeServers()
 	}
 
 	return ms
@@ -273,22 +301,10 @@ func (ms *MasterServer) startAdminScripts() {
 		scriptLines = append(scriptLines, "unlock")
 	}
 
-	masterAddress := string(ms.option.Master)
-
-	var shellOptions shell.ShellOptions
-	shellOptions.GrpcDialOption = security.LoadClientTLS(v, "grpc.master")
-	shellOptions.Masters = &masterAddress
-
-	shellOptions.Directory = "/"
-	emptyFilerGroup := ""
-	shellOptions.FilerGroup = &emptyFilerGroup
-
-	commandEnv := shell.NewCommandEnv(&shellOptions)
+	shellOptions, commandEnv := ms.newShellCommandEnv()
 
 	reg, _ := regexp.Compile(`'.*?'|".*?"|\S+`)
 
-	go commandEnv.MasterClient.KeepConnectedToMaster()
-
 	go func() {
 		for {
 			time.Sleep(time.Duration(sleepMinutes) * time.Minute)
@@ -307,6 +323,25 @@ func (ms *MasterServer) startAdminScripts() {
 	}()
 }
 
+// newShellCommandEnv creates the environment to run the shell commands against this master
+func (ms *MasterServer) newShellCommandEnv() (*shell.ShellOptions, *shell.CommandEnv) {
+	masterAddress := string(ms.option.Master)
+
+	var shellOptions shell.ShellOptions
+	shellOptions.GrpcDialOption = security.LoadClientTLS(util.GetViper(), "grpc.master")
+	shellOptions.Masters = &masterAddress
+
+	shellOptions.Directory = "/"
+	emptyFilerGroup := ""
+	shellOptions.FilerGroup = &emptyFilerGroup
+
+	commandEnv := shell.NewCommandEnv(&shellOptions)
+
+	go commandEnv.MasterClient.KeepConnectedToMaster()
+
+	return &shellOptions, commandEnv
+}
+
 func processEachCmd(reg *regexp.Regexp, line string, commandEnv *shell.CommandEnv) {
 	cmds := reg.FindAllString(line, -1)
 	if len(cmds) == 0 {
@@ -0,0 +1,131 @@
+package weed_server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+// DeadVolumeServer is one volume server found without heartbeat by RemoveDeadVolumeServers
+type DeadVolumeServer struct {
+	Url      string    `json:"url"`
+	LastSeen time.Time `json:"lastSeen"`
+	Removed  bool      `json:"removed"`
+	// the volume server is kept while these volumes have no other live replica
+	VolumesWithoutLiveReplica []uint32 `json:"volumesWithoutLiveReplica,omitempty"`
+}
+
+// DetectDeadVolumeServers periodically removes the volume servers without heartbeat
+// for DeadVolumeServerTimeout, and re-replicates their volumes.
+func (ms *MasterServer) DetectDeadVolumeServers() {
+	timeout := ms.option.DeadVolumeServerTimeout
+	if timeout <= 0 {
+		return
+	}
+	glog.V(0).Infof("remove the volume servers without heartbeat for %v", timeout)
+
+	for {
+		time.Sleep(timeout / 10)
+		if !ms.Topo.IsLeader() {
+			continue
+		}
+		ms.RemoveDeadVolumeServers(timeout)
+	}
+}
+
+// RemoveDeadVolumeServers removes the volume servers without heartbeat for the timeout, if all their volumes
+// have another live replica, and then copies the missing replicas.
+// A kept volume server is only logged and audited again when its volumes without live replica change.
+func (ms *MasterServer) RemoveDeadVolumeServers(timeout time.Duration) (servers []DeadVolumeServer) {
+	freshThreshold := time.Now().Add(-timeout).Unix()
+
+	ms.keptDeadVolumeServersLock.Lock()
+	defer ms.keptDeadVolumeServersLock.Unlock()
+	kept := make(map[string]string)
+
+	removed := 0
+	for _, dn := range ms.Topo.DeadDataNodes(freshThreshold) {
+		server := DeadVolumeServer{
+			Url:      dn.Url(),
+			LastSeen: time.Unix(dn.LastSeen, 0),
+		}
+		for _, vid := range ms.Topo.VolumesWithoutLiveReplica(dn, freshThreshold) {
+			server.VolumesWithoutLiveReplica = append(server.VolumesWithoutLiveReplica, uint32(vid))
+		}
+		if len(server.VolumesWithoutLiveReplica) > 0 {
+			volumes := fmt.Sprintf("%v", server.VolumesWithoutLiveReplica)
+			if ms.keptDeadVolumeServers[server.Url] != volumes {
+				glog.Warningf("keep dead volume server %s: volumes %s have no other live replica", server.Url, volumes)
+				ms.audit("KeepDeadVolumeServer", server.Url, "skipped", fmt.Errorf("volumes %s have no other live replica", volumes))
+			}
+			kept[server.Url] = volumes
+		} else {
+			ms.unRegisterDataNode(dn)
+			glog.V(0).Infof("removed dead volume server %s, last seen %v", server.Url, server.LastSeen)
+			ms.audit("RemoveDeadVolumeServer", server.Url, "removed", nil)
+			server.Removed = true
+			removed++
+		}
+		servers = append(servers, server)
+	}
+	// the volume servers back alive or removed are forgotten
+	ms.keptDeadVolumeServers = kept
+
+	if removed > 0 {
+		go ms.repairDeadVolumeServerReplicas()
+	}
+	return
+}
+
+func (ms *MasterServer) repairDeadVolumeServerReplicas() {
+	volumes := ms.Topo.UnderReplicatedVolumes()
+	err := ms.repairReplication()
+	if err != nil {
+		glog.Errorf("re-replicate the volumes of the dead volume servers: %v", err)
+	}
+	ms.audit("ReplicateVolumes", fmt.Sprintf("%d under-replicated volumes", len(volumes)), "done", err)
+}
+
+func (ms *MasterServer) audit(operation, target, status string, err error) {
+	if ms.option.AuditLogger == nil {
+		return
+	}
+	record := &filer.AuditRecord{
+		Time:      time.Now(),
+		Operation: operation,
+		Path:      target,
+		Status:    status,
+	}
+	if err != nil {
+		record.Status = "error"
+		record.Error = err.Error()
+	}
+	ms.option.AuditLogger.Log(record)
+}
+
+// curl -X POST http://localhost:9333/admin/topology/remove-dead?timeout=10m
+func (ms *MasterServer) removeDeadVolumeServersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJsonError(w, r, http.StatusMethodNotAllowed, fmt.Errorf("only POST is allowed"))
+		return
+	}
+	timeout := ms.option.DeadVolumeServerTimeout
+	if t := r.FormValue("timeout"); t != "" {
+		var err error
+		if timeout, err = time.ParseDuration(t); err != nil {
+			writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("parse timeout %s: %v", t, err))
+			return
+		}
+	}
+	if timeout <= 0 {
+		writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("timeout should be positive"))
+		return
+	}
+	writeJsonQuiet(w, r, http.StatusOK, map[string]interface{}{
+		"timeout": timeout.String(),
+		"servers": ms.RemoveDeadVolumeServers(timeout),
+	})
+}
@@ -0,0 +1,69 @@
+package weed_server
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/sequence"
+	"github.com/seaweedfs/seaweedfs/weed/storage"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+)
+
+func TestKeptDeadVolumeServerAuditedOnce(t *testing.T) {
+	topo := topology.NewTopology("weedfs", sequence.NewMemorySequencer(), 32*1024, 5, false)
+	rack := topo.GetOrCreateDataCenter("dc1").GetOrCreateRack("rack1")
+	dn := rack.GetOrCreateDataNode("127.0.0.1", 34534, 0, "127.0.0.1", map[string]uint32{"": 25})
+
+	rp, _ := super_block.NewReplicaPlacementFromString("000")
+	v1 := storage.VolumeInfo{Id: needle.VolumeId(1), Version: needle.CurrentVersion, ReplicaPlacement: rp, Ttl: needle.EMPTY_TTL}
+	v2 := storage.VolumeInfo{Id: needle.VolumeId(2), Version: needle.CurrentVersion, ReplicaPlacement: rp, Ttl: needle.EMPTY_TTL}
+	dn.UpdateVolumes([]storage.VolumeInfo{v1})
+	topo.RegisterVolumeLayout(v1, dn)
+	dn.LastSeen = time.Now().Add(-time.Hour).Unix()
+
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	auditLogger := filer.NewAuditLogger(auditLog, nil)
+	ms := &MasterServer{Topo: topo, option: &MasterOption{AuditLogger: auditLogger}}
+	auditCount := func() int {
+		data, err := os.ReadFile(auditLog)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		return bytes.Count(data, []byte("\n"))
+	}
+
+	for i := 0; i < 3; i++ {
+		servers := ms.RemoveDeadVolumeServers(time.Minute)
+		if len(servers) != 1 || servers[0].Removed {
+			t.Fatalf("the single copy volume server should be kept: %+v", servers)
+		}
+	}
+	if count := auditCount(); count != 1 {
+		t.Errorf("audited %d times for the same kept volume server", count)
+	}
+
+	// another volume without replica changes the state
+	dn.UpdateVolumes([]storage.VolumeInfo{v1, v2})
+	topo.RegisterVolumeLayout(v2, dn)
+	ms.RemoveDeadVolumeServers(time.Minute)
+	ms.RemoveDeadVolumeServers(time.Minute)
+	if count := auditCount(); count != 2 {
+		t.Errorf("audited %d times after the volumes changed", count)
+	}
+
+	// back alive, and then dead again
+	dn.LastSeen = time.Now().Unix()
+	ms.RemoveDeadVolumeServers(time.Minute)
+	dn.LastSeen = time.Now().Add(-time.Hour).Unix()
+	ms.RemoveDeadVolumeServers(time.Minute)
+	if count := auditCount(); count != 3 {
+		t.Errorf("audited %d times after the volume server was dead again", count)
+	}
+	auditLogger.Close()
+}
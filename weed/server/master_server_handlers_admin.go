@@ -65,10 +65,17 @@ func (ms *MasterServer) volumeVacuumHandler(w http.ResponseWriter, r *http.Reque
 		}
 	}
 	// glog.Infoln("garbageThreshold =", gcThreshold)
-	ms.Topo.Vacuum(ms.grpcDialOption, gcThreshold, 0, "", ms.preallocateSize)
+	ms.Topo.Vacuum(ms.grpcDialOption, gcThreshold, gcString == "", 0, "", ms.preallocateSize)
 	ms.dirStatusHandler(w, r)
 }
 
+func (ms *MasterServer) gcStatusHandler(w http.ResponseWriter, r *http.Request) {
+	m := make(map[string]interface{})
+	m["Version"] = util.Version()
+	m["Jobs"] = ms.Topo.VacuumScheduler.Status()
+	writeJsonQuiet(w, r, http.StatusOK, m)
+}
+
 func (ms *MasterServer) volumeGrowHandler(w http.ResponseWriter, r *http.Request) {
 	count := 0
 	option, err := ms.getVolumeGrowOption(r)
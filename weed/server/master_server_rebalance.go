@@ -0,0 +1,285 @@
+package weed_server
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/shell"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// VolumeMove is a volume replica moved from one volume server to another
+type VolumeMove struct {
+	VolumeId   uint32 `json:"volumeId"`
+	Collection string `json:"collection"`
+	DiskType   string `json:"diskType"`
+	Size       uint64 `json:"size"`
+	Source     string `json:"source"`
+	Target     string `json:"target"`
+}
+
+// VolumeRebalancePlan is served by /admin/rebalance/plan
+type VolumeRebalancePlan struct {
+	UsagePercent     map[string]float64 `json:"usagePercent"`
+	StdDevPercent    float64            `json:"stdDevPercent"`
+	ThresholdPercent float64            `json:"thresholdPercent"`
+	Move             *VolumeMove        `json:"move,omitempty"`
+}
+
+// VolumeRebalanceStatus is served by /admin/rebalance/status
+type VolumeRebalanceStatus struct {
+	Enabled       bool        `json:"enabled"`
+	LastCheckAt   time.Time   `json:"lastCheckAt,omitempty"`
+	Moving        *VolumeMove `json:"moving,omitempty"`
+	LastMove      *VolumeMove `json:"lastMove,omitempty"`
+	LastMoveAt    time.Time   `json:"lastMoveAt,omitempty"`
+	LastMoveError string      `json:"lastMoveError,omitempty"`
+	Moved         int         `json:"moved"`
+}
+
+type volumeRebalancer struct {
+	sync.Mutex
+	status VolumeRebalanceStatus
+
+	// one move at a time, to limit the io on the volume servers
+	moveLock   sync.Mutex
+	commandEnv *shell.CommandEnv
+}
+
+func (vr *volumeRebalancer) recordCheck(now time.Time) {
+	vr.Lock()
+	defer vr.Unlock()
+	vr.status.LastCheckAt = now
+}
+
+func (vr *volumeRebalancer) recordMoving(move *VolumeMove) {
+	vr.Lock()
+	defer vr.Unlock()
+	vr.status.Moving = move
+}
+
+func (vr *volumeRebalancer) recordMove(now time.Time, move *VolumeMove, err error) {
+	vr.Lock()
+	defer vr.Unlock()
+	vr.status.Moving = nil
+	vr.status.LastMove = move
+	vr.status.LastMoveAt = now
+	vr.status.LastMoveError = ""
+	if err != nil {
+		vr.status.LastMoveError = err.Error()
+	} else {
+		vr.status.Moved++
+	}
+}
+
+func (vr *volumeRebalancer) Status() VolumeRebalanceStatus {
+	vr.Lock()
+	defer vr.Unlock()
+	return vr.status
+}
+
+// startVolumeRebalance periodically checks the disk usage of the volume servers, and when it is uneven,
+// moves one volume from the most used volume server to the least used one, configured in master.toml:
+//
+//	[master.volume_rebalance]
+//	enabled = true
+//	interval_minutes = 60
+//	threshold_percent = 15
+func (ms *MasterServer) startVolumeRebalance() {
+	v := util.GetViper()
+	if !v.GetBool("master.volume_rebalance.enabled") {
+		return
+	}
+	v.SetDefault("master.volume_rebalance.interval_minutes", 60)
+	interval := time.Duration(v.GetInt("master.volume_rebalance.interval_minutes")) * time.Minute
+	if interval <= 0 {
+		return
+	}
+	glog.V(0).Infof("volume rebalance every %v", interval)
+	ms.volumeRebalance.status.Enabled = true
+
+	go func() {
+		wait := interval
+		for {
+			time.Sleep(wait)
+			wait = interval
+			if !ms.Topo.IsLeader() || ms.MasterClient.GetMaster() == "" {
+				continue
+			}
+			plan := ms.volumeRebalancePlan()
+			ms.volumeRebalance.recordCheck(time.Now())
+			if plan.Move == nil {
+				continue
+			}
+			glog.V(0).Infof("disk usage deviation %.1f%% > %.1f%%, move volume %d from %s to %s",
+				plan.StdDevPercent, plan.ThresholdPercent, plan.Move.VolumeId, plan.Move.Source, plan.Move.Target)
+			if err := ms.moveVolume(plan.Move); err != nil {
+				glog.Errorf("volume rebalance: %v", err)
+				continue
+			}
+			// check again soon, after the heartbeats report the moved volume
+			wait = time.Minute
+		}
+	}()
+}
+
+func (ms *MasterServer) volumeRebalancePlan() VolumeRebalancePlan {
+	v := util.GetViper()
+	v.SetDefault("master.volume_rebalance.threshold_percent", 15)
+	return planVolumeRebalance(ms.Topo.ToTopologyInfo(), uint64(ms.option.VolumeSizeLimitMB)*1024*1024,
+		v.GetFloat64("master.volume_rebalance.threshold_percent"))
+}
+
+func (ms *MasterServer) moveVolume(move *VolumeMove) (err error) {
+	vr := ms.volumeRebalance
+	vr.moveLock.Lock()
+	defer vr.moveLock.Unlock()
+	vr.recordMoving(move)
+	defer func() {
+		vr.recordMove(time.Now(), move, err)
+	}()
+
+	if vr.commandEnv == nil {
+		_, vr.commandEnv = ms.newShellCommandEnv()
+	}
+	commandEnv := vr.commandEnv
+
+	var output bytes.Buffer
+	if err = runShellCommand(commandEnv, &output, "lock"); err != nil {
+		return
+	}
+	defer runShellCommand(commandEnv, &output, "unlock")
+
+	if err = runShellCommand(commandEnv, &output, "volume.move",
+		"-volumeId", fmt.Sprint(move.VolumeId), "-source", move.Source, "-target", move.Target, "-disk", move.DiskType); err != nil {
+		return fmt.Errorf("move volume %d from %s to %s: %v", move.VolumeId, move.Source, move.Target, err)
+	}
+	glog.V(1).Infof("volume.move: %s", output.String())
+	return
+}
+
+type rebalanceServer struct {
+	address    string
+	dataCenter string
+	rack       string
+	used       uint64
+	capacity   uint64
+	freeSlots  map[string]int64
+	volumes    []*master_pb.VolumeInformationMessage
+	hasVolume  map[uint32]bool
+}
+
+func (s *rebalanceServer) usagePercent(used uint64) float64 {
+	return float64(used) * 100 / float64(s.capacity)
+}
+
+// planVolumeRebalance computes the disk usage percentage of the volume servers, as the size of their volumes
+// over their volume slots. If the standard deviation is over the threshold, it picks the largest volume to move
+// from the most used volume server to the least used one, without making the latter more used than the former.
+// A volume with several replicas is only moved within its rack, to keep the replica placement.
+func planVolumeRebalance(topo *master_pb.TopologyInfo, volumeSizeLimit uint64, thresholdPercent float64) (plan VolumeRebalancePlan) {
+	plan.UsagePercent = make(map[string]float64)
+	plan.ThresholdPercent = thresholdPercent
+
+	var servers []*rebalanceServer
+	for _, dc := range topo.DataCenterInfos {
+		for _, rack := range dc.RackInfos {
+			for _, dn := range rack.DataNodeInfos {
+				s := &rebalanceServer{
+					address:    string(pb.NewServerAddressFromDataNode(dn)),
+					dataCenter: dc.Id,
+					rack:       rack.Id,
+					freeSlots:  make(map[string]int64),
+					hasVolume:  make(map[uint32]bool),
+				}
+				for diskType, disk := range dn.DiskInfos {
+					s.capacity += uint64(disk.MaxVolumeCount) * volumeSizeLimit
+					s.freeSlots[diskType] = disk.MaxVolumeCount - disk.VolumeCount
+					for _, vi := range disk.VolumeInfos {
+						s.hasVolume[vi.Id] = true
+						if vi.RemoteStorageName != "" {
+							continue
+						}
+						s.used += vi.Size
+						s.volumes = append(s.volumes, vi)
+					}
+				}
+				if s.capacity == 0 {
+					continue
+				}
+				servers = append(servers, s)
+				plan.UsagePercent[s.address] = s.usagePercent(s.used)
+			}
+		}
+	}
+	if len(servers) < 2 {
+		return
+	}
+
+	var sum, squareSum float64
+	for _, s := range servers {
+		usage := s.usagePercent(s.used)
+		sum += usage
+		squareSum += usage * usage
+	}
+	mean := sum / float64(len(servers))
+	plan.StdDevPercent = math.Sqrt(math.Max(squareSum/float64(len(servers))-mean*mean, 0))
+	if plan.StdDevPercent <= thresholdPercent {
+		return
+	}
+
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].usagePercent(servers[i].used) > servers[j].usagePercent(servers[j].used)
+	})
+	source, target := servers[0], servers[len(servers)-1]
+	sameRack := source.dataCenter == target.dataCenter && source.rack == target.rack
+
+	var candidate *master_pb.VolumeInformationMessage
+	for _, vi := range source.volumes {
+		if target.hasVolume[vi.Id] || target.freeSlots[string(types.ToDiskType(vi.DiskType))] <= 0 {
+			continue
+		}
+		if !sameRack {
+			rp, err := super_block.NewReplicaPlacementFromByte(byte(vi.ReplicaPlacement))
+			if err != nil || rp.GetCopyCount() > 1 {
+				continue
+			}
+		}
+		if source.usagePercent(source.used-vi.Size) < target.usagePercent(target.used+vi.Size) {
+			continue
+		}
+		if candidate == nil || vi.Size > candidate.Size {
+			candidate = vi
+		}
+	}
+	if candidate == nil {
+		return
+	}
+	plan.Move = &VolumeMove{
+		VolumeId:   candidate.Id,
+		Collection: candidate.Collection,
+		DiskType:   candidate.DiskType,
+		Size:       candidate.Size,
+		Source:     source.address,
+		Target:     target.address,
+	}
+	return
+}
+
+func (ms *MasterServer) rebalancePlanHandler(w http.ResponseWriter, r *http.Request) {
+	writeJsonQuiet(w, r, http.StatusOK, ms.volumeRebalancePlan())
+}
+
+func (ms *MasterServer) rebalanceStatusHandler(w http.ResponseWriter, r *http.Request) {
+	writeJsonQuiet(w, r, http.StatusOK, ms.volumeRebalance.Status())
+}
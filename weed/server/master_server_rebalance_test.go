@@ -0,0 +1,83 @@
+package weed_server
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+)
+
+func rebalanceTestNode(id string, maxVolumeCount int64, volumes ...*master_pb.VolumeInformationMessage) *master_pb.DataNodeInfo {
+	return &master_pb.DataNodeInfo{
+		Id: id,
+		DiskInfos: map[string]*master_pb.DiskInfo{
+			"": {
+				MaxVolumeCount: maxVolumeCount,
+				VolumeCount:    int64(len(volumes)),
+				VolumeInfos:    volumes,
+			},
+		},
+	}
+}
+
+func TestPlanVolumeRebalance(t *testing.T) {
+	topo := &master_pb.TopologyInfo{
+		DataCenterInfos: []*master_pb.DataCenterInfo{{
+			Id: "dc1",
+			RackInfos: []*master_pb.RackInfo{{
+				Id: "rack1",
+				DataNodeInfos: []*master_pb.DataNodeInfo{
+					rebalanceTestNode("a:8080", 4,
+						&master_pb.VolumeInformationMessage{Id: 1, Size: 90},
+						&master_pb.VolumeInformationMessage{Id: 2, Size: 80},
+						&master_pb.VolumeInformationMessage{Id: 3, Size: 30},
+					),
+					rebalanceTestNode("b:8080", 4,
+						&master_pb.VolumeInformationMessage{Id: 4, Size: 50},
+					),
+				},
+			}},
+		}},
+	}
+
+	plan := planVolumeRebalance(topo, 100, 15)
+	if plan.UsagePercent["a:8080"] != 50 || plan.UsagePercent["b:8080"] != 12.5 {
+		t.Fatalf("unexpected usage %v", plan.UsagePercent)
+	}
+	if plan.Move == nil {
+		t.Fatalf("no move for deviation %.1f%%", plan.StdDevPercent)
+	}
+	// moving volume 1 or 2 would leave a less used than b
+	if plan.Move.VolumeId != 3 || plan.Move.Source != "a:8080" || plan.Move.Target != "b:8080" {
+		t.Errorf("unexpected move %+v", plan.Move)
+	}
+
+	if plan = planVolumeRebalance(topo, 100, 30); plan.Move != nil {
+		t.Errorf("unexpected move %+v under the threshold", plan.Move)
+	}
+}
+
+func TestPlanVolumeRebalanceKeepsPlacement(t *testing.T) {
+	topo := &master_pb.TopologyInfo{
+		DataCenterInfos: []*master_pb.DataCenterInfo{{
+			Id: "dc1",
+			RackInfos: []*master_pb.RackInfo{{
+				Id: "rack1",
+				DataNodeInfos: []*master_pb.DataNodeInfo{
+					rebalanceTestNode("a:8080", 4,
+						&master_pb.VolumeInformationMessage{Id: 1, Size: 80, ReplicaPlacement: 1},
+						&master_pb.VolumeInformationMessage{Id: 2, Size: 80, ReplicaPlacement: 1},
+					),
+				},
+			}, {
+				Id: "rack2",
+				DataNodeInfos: []*master_pb.DataNodeInfo{
+					rebalanceTestNode("b:8080", 4),
+				},
+			}},
+		}},
+	}
+
+	if plan := planVolumeRebalance(topo, 100, 15); plan.Move != nil {
+		t.Errorf("unexpected move %+v of a replicated volume to another rack", plan.Move)
+	}
+}
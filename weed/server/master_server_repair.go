@@ -0,0 +1,157 @@
+package weed_server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/shell"
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// ReplicationRepairStatus is served by /admin/repair/status
+type ReplicationRepairStatus struct {
+	Enabled         bool                             `json:"enabled"`
+	LastScanAt      time.Time                        `json:"lastScanAt,omitempty"`
+	UnderReplicated []topology.UnderReplicatedVolume `json:"underReplicated"`
+	LastRepairAt    time.Time                        `json:"lastRepairAt,omitempty"`
+	LastRepairError string                           `json:"lastRepairError,omitempty"`
+}
+
+type replicationRepairer struct {
+	sync.Mutex
+	status ReplicationRepairStatus
+	// volume id => the scan first finding it under-replicated
+	since map[uint32]time.Time
+
+	// one repair at a time, by the periodic scan or after removing dead volume servers
+	repairLock sync.Mutex
+	commandEnv *shell.CommandEnv
+}
+
+// scan records the under-replicated volumes, and returns whether some have stayed under-replicated
+// since before the grace time, so a volume server restarting does not trigger any copying.
+func (rr *replicationRepairer) scan(now time.Time, volumes []topology.UnderReplicatedVolume, graceTime time.Time) (needRepair bool) {
+	rr.Lock()
+	defer rr.Unlock()
+
+	since := make(map[uint32]time.Time, len(volumes))
+	for _, v := range volumes {
+		t, found := rr.since[v.VolumeId]
+		if !found {
+			t = now
+		}
+		since[v.VolumeId] = t
+		if !t.After(graceTime) {
+			needRepair = true
+		}
+	}
+	rr.since = since
+	rr.status.LastScanAt = now
+	rr.status.UnderReplicated = volumes
+	return
+}
+
+func (rr *replicationRepairer) recordRepair(now time.Time, err error) {
+	rr.Lock()
+	defer rr.Unlock()
+	rr.status.LastRepairAt = now
+	rr.status.LastRepairError = ""
+	if err != nil {
+		rr.status.LastRepairError = err.Error()
+	}
+}
+
+func (rr *replicationRepairer) Status() ReplicationRepairStatus {
+	rr.Lock()
+	defer rr.Unlock()
+	return rr.status
+}
+
+// startReplicationRepair periodically scans the topology for volumes with missing replicas,
+// and copies them back with volume.fix.replication, configured in master.toml:
+//
+//	[master.replication_repair]
+//	enabled = true
+//	interval_minutes = 10
+func (ms *MasterServer) startReplicationRepair() {
+	v := util.GetViper()
+	if !v.GetBool("master.replication_repair.enabled") {
+		return
+	}
+	v.SetDefault("master.replication_repair.interval_minutes", 10)
+	interval := time.Duration(v.GetInt("master.replication_repair.interval_minutes")) * time.Minute
+	if interval <= 0 {
+		return
+	}
+	glog.V(0).Infof("replication repair every %v", interval)
+	ms.replicationRepair.status.Enabled = true
+
+	go func() {
+		for {
+			time.Sleep(interval)
+			if !ms.Topo.IsLeader() || ms.MasterClient.GetMaster() == "" {
+				continue
+			}
+			now := time.Now()
+			volumes := ms.Topo.UnderReplicatedVolumes()
+			if !ms.replicationRepair.scan(now, volumes, now.Add(-interval)) {
+				continue
+			}
+			glog.V(0).Infof("repairing %d under-replicated volumes", len(volumes))
+			if err := ms.repairReplication(); err != nil {
+				glog.Errorf("replication repair: %v", err)
+			}
+		}
+	}()
+}
+
+// repairReplication only adds the missing replicas, leaving the over-replicated volumes to the maintenance scripts
+func (ms *MasterServer) repairReplication() (err error) {
+	rr := ms.replicationRepair
+	rr.repairLock.Lock()
+	defer rr.repairLock.Unlock()
+	defer func() {
+		rr.recordRepair(time.Now(), err)
+	}()
+
+	if rr.commandEnv == nil {
+		_, rr.commandEnv = ms.newShellCommandEnv()
+	}
+	commandEnv := rr.commandEnv
+
+	var output bytes.Buffer
+	if err = runShellCommand(commandEnv, &output, "lock"); err != nil {
+		return
+	}
+	defer runShellCommand(commandEnv, &output, "unlock")
+
+	if err = runShellCommand(commandEnv, &output, "volume.fix.replication", "-noDelete"); err != nil {
+		return
+	}
+	glog.V(1).Infof("volume.fix.replication: %s", output.String())
+	return
+}
+
+func runShellCommand(commandEnv *shell.CommandEnv, output *bytes.Buffer, name string, args ...string) error {
+	for _, c := range shell.Commands {
+		if c.Name() == name {
+			return c.Do(args, commandEnv, output)
+		}
+	}
+	return fmt.Errorf("unknown command %s", name)
+}
+
+func (ms *MasterServer) repairStatusHandler(w http.ResponseWriter, r *http.Request) {
+	status := ms.replicationRepair.Status()
+	if !status.Enabled {
+		// still useful to find the volumes with missing replicas
+		status.LastScanAt = time.Now()
+		status.UnderReplicated = ms.Topo.UnderReplicatedVolumes()
+	}
+	writeJsonQuiet(w, r, http.StatusOK, status)
+}
@@ -0,0 +1,34 @@
+package weed_server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/topology"
+)
+
+func TestReplicationRepairGraceTime(t *testing.T) {
+	rr := &replicationRepairer{}
+	interval := 10 * time.Minute
+	start := time.Now()
+	volumes := []topology.UnderReplicatedVolume{{VolumeId: 1, Expected: 2}}
+
+	if rr.scan(start, volumes, start.Add(-interval)) {
+		t.Errorf("repair right after the replica went missing")
+	}
+	if len(rr.Status().UnderReplicated) != 1 {
+		t.Errorf("under-replicated volume not in the status")
+	}
+
+	next := start.Add(interval)
+	if !rr.scan(next, volumes, next.Add(-interval)) {
+		t.Errorf("no repair for the volume under-replicated for one interval")
+	}
+
+	// the replica came back, and went missing again
+	rr.scan(next, nil, next.Add(-interval))
+	later := next.Add(interval)
+	if rr.scan(later, volumes, later.Add(-interval)) {
+		t.Errorf("repair right after the replica went missing again")
+	}
+}
@@ -52,7 +52,9 @@ var _ hashicorpRaft.FSM = &StateMachine{}
 
 func (s StateMachine) Save() ([]byte, error) {
 	state := topology.MaxVolumeIdCommand{
-		MaxVolumeId: s.topo.GetMaxVolumeId(),
+		MaxVolumeId:                 s.topo.GetMaxVolumeId(),
+		CollectionGarbageThresholds: s.topo.CollectionGCConfig.Thresholds(),
+		CollectionEcConfigs:         s.topo.CollectionECConfig.Configs(),
 	}
 	glog.V(1).Infof("Save raft state %+v", state)
 	return json.Marshal(state)
@@ -66,6 +68,8 @@ func (s StateMachine) Recovery(data []byte) error {
 	}
 	glog.V(1).Infof("Recovery raft state %+v", state)
 	s.topo.UpAdjustMaxVolumeId(state.MaxVolumeId)
+	s.topo.ApplyCollectionGarbageThresholds(state.CollectionGarbageThresholds)
+	s.topo.ApplyCollectionEcConfigs(state.CollectionEcConfigs)
 	return nil
 }
 
@@ -77,6 +81,8 @@ func (s *StateMachine) Apply(l *hashicorpRaft.Log) interface{} {
 		return err
 	}
 	s.topo.UpAdjustMaxVolumeId(state.MaxVolumeId)
+	s.topo.ApplyCollectionGarbageThresholds(state.CollectionGarbageThresholds)
+	s.topo.ApplyCollectionEcConfigs(state.CollectionEcConfigs)
 
 	glog.V(1).Infoln("max volume id", before, "==>", s.topo.GetMaxVolumeId())
 	return nil
@@ -84,7 +90,9 @@ func (s *StateMachine) Apply(l *hashicorpRaft.Log) interface{} {
 
 func (s *StateMachine) Snapshot() (hashicorpRaft.FSMSnapshot, error) {
 	return &topology.MaxVolumeIdCommand{
-		MaxVolumeId: s.topo.GetMaxVolumeId(),
+		MaxVolumeId:                 s.topo.GetMaxVolumeId(),
+		CollectionGarbageThresholds: s.topo.CollectionGCConfig.Thresholds(),
+		CollectionEcConfigs:         s.topo.CollectionECConfig.Configs(),
 	}, nil
 }
 
@@ -63,6 +63,9 @@ func (vs *VolumeServer) WriteNeedleBlob(ctx context.Context, req *volume_server_
 	if err = v.WriteNeedleBlob(types.NeedleId(req.NeedleId), req.NeedleBlob, types.Size(req.Size)); err != nil {
 		return nil, fmt.Errorf("write blob needle %d size %d: %v", req.NeedleId, req.Size, err)
 	}
+	if vs.store.NeedleCache != nil {
+		vs.store.NeedleCache.Delete(needle.VolumeId(req.VolumeId), types.NeedleId(req.NeedleId))
+	}
 
 	return resp, nil
 }
@@ -1,6 +1,8 @@
 package weed_server
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
@@ -10,6 +12,8 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/storage/types"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util"
@@ -17,6 +21,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/security"
 	"github.com/seaweedfs/seaweedfs/weed/storage"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 )
 
 type VolumeServer struct {
@@ -28,6 +33,8 @@ type VolumeServer struct {
 	inFlightUploadDataLimitCond   *sync.Cond
 	inFlightDownloadDataLimitCond *sync.Cond
 	inflightUploadDataTimeout     time.Duration
+	writeQueueDepth               int64
+	maxWriteQueueDepth            int64
 	hasSlowRead                   bool
 	readBufferSizeMB              int
 
@@ -50,6 +57,10 @@ type VolumeServer struct {
 	fileSizeLimitBytes      int64
 	isHeartbeating          bool
 	stopChan                chan bool
+
+	shutdownLock   sync.RWMutex
+	isShuttingDown bool
+	inFlightWrites sync.WaitGroup
 }
 
 func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
@@ -70,6 +81,11 @@ func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 	hasSlowRead bool,
 	readBufferSizeMB int,
 	ldbTimeout int64,
+	collectionConfigs map[string]*storage.CollectionConfig,
+	maxCacheMemMB int,
+	maxWriteQueueDepth int,
+	readOnly bool,
+	compactionSegmentMB int,
 ) *VolumeServer {
 
 	v := util.GetViper()
@@ -99,6 +115,7 @@ func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 		concurrentUploadLimit:         concurrentUploadLimit,
 		concurrentDownloadLimit:       concurrentDownloadLimit,
 		inflightUploadDataTimeout:     inflightUploadDataTimeout,
+		maxWriteQueueDepth:            int64(maxWriteQueueDepth),
 		hasSlowRead:                   hasSlowRead,
 		readBufferSizeMB:              readBufferSizeMB,
 		ldbTimout:                     ldbTimeout,
@@ -108,11 +125,25 @@ func NewVolumeServer(adminMux, publicMux *http.ServeMux, ip string,
 	vs.checkWithMaster()
 
 	vs.store = storage.NewStore(vs.grpcDialOption, ip, port, grpcPort, publicUrl, folders, maxCounts, minFreeSpaces, idxFolder, vs.needleMapKind, diskTypes, ldbTimeout)
+	vs.store.CollectionConfigs = collectionConfigs
+	vs.store.IncrementalCompactSegmentSize = int64(compactionSegmentMB) * 1024 * 1024
+	if err := stats.Gather.Register(storage.NewStoreMetricsCollector(vs.store)); err != nil {
+		glog.V(0).Infof("register volume metrics: %v", err)
+	}
+	if maxCacheMemMB > 0 {
+		vs.store.NeedleCache = storage.NewNeedleCache(int64(maxCacheMemMB) * 1024 * 1024)
+	}
+	if readOnly {
+		glog.V(0).Infof("volume server %s:%d is read only", ip, port)
+		vs.store.SetReadOnly()
+	}
 	vs.guard = security.NewGuard(whiteList, signingKey, expiresAfterSec, readSigningKey, readExpiresAfterSec)
 
 	handleStaticResources(adminMux)
 	adminMux.HandleFunc("/status", vs.statusHandler)
 	adminMux.HandleFunc("/healthz", vs.healthzHandler)
+	adminMux.HandleFunc("/admin/prepare-restart", vs.guard.WhiteList(vs.prepareRestartHandler))
+	adminMux.HandleFunc("/ws/metrics", vs.guard.WhiteList(stats.MetricsWebSocketHandler))
 	if signingKey == "" || enableUiAccess {
 		// only expose the volume server details for safe environments
 		adminMux.HandleFunc("/ui/index.html", vs.uiStatusHandler)
@@ -150,3 +181,153 @@ func (vs *VolumeServer) Shutdown() {
 	vs.store.Close()
 	glog.V(0).Infoln("Shut down successfully!")
 }
+
+// GracefulShutdown rejects the new http requests with 503 and the new gRPC writes with Unavailable,
+// waits for the in-flight http and gRPC writes, and then fsyncs any later writes.
+// The volumes are synced and closed by Shutdown, which must still be called if an error is returned
+// because the writes are not finished within the timeout.
+func (vs *VolumeServer) GracefulShutdown(timeout time.Duration) (err error) {
+	glog.V(0).Infof("Shutting down volume server gracefully, waiting up to %v for in-flight writes ...", timeout)
+	vs.shutdownLock.Lock()
+	vs.isShuttingDown = true
+	vs.shutdownLock.Unlock()
+
+	err = vs.waitForInFlightWrites(timeout)
+
+	vs.store.SetStopping()
+	return err
+}
+
+// PrepareForRestart leaves the master, so no new writes are assigned to this volume server,
+// waits for the in-flight http and gRPC writes, and syncs the volumes to disk. Later writes are fsynced.
+// Once left, the master no longer returns this volume server in the lookups, so the volumes are only readable
+// from their other replicas, or by the clients with the location cached, until the restart.
+// Unless forced, it refuses if any volume has no other replica.
+func (vs *VolumeServer) PrepareForRestart(timeout time.Duration, force bool) error {
+	if singleCopyVolumes := vs.singleCopyVolumes(); len(singleCopyVolumes) > 0 && !force {
+		return fmt.Errorf("volumes %v have no other replicas, and would be unreadable until the restart", singleCopyVolumes)
+	}
+
+	glog.V(0).Infof("Preparing volume server for restart, waiting up to %v for in-flight writes ...", timeout)
+	vs.StopHeartbeat()
+
+	if err := vs.waitForInFlightWrites(timeout); err != nil {
+		return err
+	}
+
+	if err := vs.store.SetStopping(); err != nil {
+		return err
+	}
+	glog.V(0).Infof("Volume server is ready to restart")
+	return nil
+}
+
+func (vs *VolumeServer) singleCopyVolumes() (volumeIds []needle.VolumeId) {
+	for _, v := range vs.store.VolumeInfos() {
+		if v.ReplicaPlacement == nil || v.ReplicaPlacement.GetCopyCount() == 1 {
+			volumeIds = append(volumeIds, v.Id)
+		}
+	}
+	return
+}
+
+func (vs *VolumeServer) waitForInFlightWrites(timeout time.Duration) error {
+	drained := make(chan struct{})
+	go func() {
+		vs.inFlightWrites.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("in-flight writes are not finished in %v", timeout)
+	}
+}
+
+// beginRequest returns false if the volume server is shutting down.
+// The accepted writes are tracked until endRequest, so GracefulShutdown can wait for them.
+func (vs *VolumeServer) beginRequest(isWrite bool) bool {
+	vs.shutdownLock.RLock()
+	defer vs.shutdownLock.RUnlock()
+	if vs.isShuttingDown {
+		return false
+	}
+	if isWrite {
+		vs.inFlightWrites.Add(1)
+	}
+	return true
+}
+
+func (vs *VolumeServer) endRequest(isWrite bool) {
+	if isWrite {
+		vs.inFlightWrites.Done()
+	}
+}
+
+func isWriteRequest(r *http.Request) bool {
+	return r.Method == http.MethodPut || r.Method == http.MethodPost || r.Method == http.MethodDelete
+}
+
+// the gRPC methods changing the volumes, tracked as the in-flight writes
+var grpcWriteMethods = map[string]bool{
+	"/volume_server_pb.VolumeServer/BatchDelete":                 true,
+	"/volume_server_pb.VolumeServer/VacuumVolumeCompact":         true,
+	"/volume_server_pb.VolumeServer/VacuumVolumeCommit":          true,
+	"/volume_server_pb.VolumeServer/VacuumVolumeCleanup":         true,
+	"/volume_server_pb.VolumeServer/DeleteCollection":            true,
+	"/volume_server_pb.VolumeServer/AllocateVolume":              true,
+	"/volume_server_pb.VolumeServer/VolumeMount":                 true,
+	"/volume_server_pb.VolumeServer/VolumeUnmount":               true,
+	"/volume_server_pb.VolumeServer/VolumeDelete":                true,
+	"/volume_server_pb.VolumeServer/VolumeMarkReadonly":          true,
+	"/volume_server_pb.VolumeServer/VolumeMarkWritable":          true,
+	"/volume_server_pb.VolumeServer/VolumeConfigure":             true,
+	"/volume_server_pb.VolumeServer/VolumeCopy":                  true,
+	"/volume_server_pb.VolumeServer/WriteNeedleBlob":             true,
+	"/volume_server_pb.VolumeServer/VolumeTailReceiver":          true,
+	"/volume_server_pb.VolumeServer/VolumeEcShardsGenerate":      true,
+	"/volume_server_pb.VolumeServer/VolumeEcShardsRebuild":       true,
+	"/volume_server_pb.VolumeServer/VolumeEcShardsCopy":          true,
+	"/volume_server_pb.VolumeServer/VolumeEcShardsDelete":        true,
+	"/volume_server_pb.VolumeServer/VolumeEcShardsMount":         true,
+	"/volume_server_pb.VolumeServer/VolumeEcShardsUnmount":       true,
+	"/volume_server_pb.VolumeServer/VolumeEcBlobDelete":          true,
+	"/volume_server_pb.VolumeServer/VolumeEcShardsToVolume":      true,
+	"/volume_server_pb.VolumeServer/VolumeTierMoveDatToRemote":   true,
+	"/volume_server_pb.VolumeServer/VolumeTierMoveDatFromRemote": true,
+	"/volume_server_pb.VolumeServer/FetchAndWriteNeedle":         true,
+}
+
+var errShuttingDown = status.Error(codes.Unavailable, "volume server is shutting down")
+
+// GrpcShutdownOptions track the gRPC writes, so GracefulShutdown waits for them,
+// and reject the new ones while shutting down. The gRPC reads are served until the gRPC server stops.
+func (vs *VolumeServer) GrpcShutdownOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(vs.unaryShutdownInterceptor),
+		grpc.ChainStreamInterceptor(vs.streamShutdownInterceptor),
+	}
+}
+
+func (vs *VolumeServer) unaryShutdownInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !grpcWriteMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	if !vs.beginRequest(true) {
+		return nil, errShuttingDown
+	}
+	defer vs.endRequest(true)
+	return handler(ctx, req)
+}
+
+func (vs *VolumeServer) streamShutdownInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !grpcWriteMethods[info.FullMethod] {
+		return handler(srv, ss)
+	}
+	if !vs.beginRequest(true) {
+		return errShuttingDown
+	}
+	defer vs.endRequest(true)
+	return handler(srv, ss)
+}
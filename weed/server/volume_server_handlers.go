@@ -36,6 +36,12 @@ func (vs *VolumeServer) privateStoreHandler(w http.ResponseWriter, r *http.Reque
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 	}
+	isWrite := isWriteRequest(r)
+	if !vs.beginRequest(isWrite) {
+		writeServiceUnavailable(w, r)
+		return
+	}
+	defer vs.endRequest(isWrite)
 	stats.VolumeServerRequestCounter.WithLabelValues(r.Method).Inc()
 	start := time.Now()
 	defer func(start time.Time) {
@@ -65,6 +71,21 @@ func (vs *VolumeServer) privateStoreHandler(w http.ResponseWriter, r *http.Reque
 		stats.DeleteRequest()
 		vs.guard.WhiteList(vs.DeleteHandler)(w, r)
 	case "PUT", "POST":
+		writeQueueDepth := atomic.AddInt64(&vs.writeQueueDepth, 1)
+		stats.VolumeServerWriteQueueDepthGauge.Inc()
+		defer func() {
+			atomic.AddInt64(&vs.writeQueueDepth, -1)
+			stats.VolumeServerWriteQueueDepthGauge.Dec()
+		}()
+		// the replication is not rejected, since the write has been accepted by another replica
+		if vs.maxWriteQueueDepth != 0 && writeQueueDepth > vs.maxWriteQueueDepth && r.URL.Query().Get("type") != "replicate" {
+			err := fmt.Errorf("reject because write queue depth %d > %d", writeQueueDepth, vs.maxWriteQueueDepth)
+			glog.V(1).Infof("too many writes: %v", err)
+			w.Header().Set("Retry-After", "1")
+			writeJsonError(w, r, http.StatusServiceUnavailable, err)
+			return
+		}
+
 		contentLength := getContentLength(r)
 		// exclude the replication from the concurrentUploadLimitMB
 		if r.URL.Query().Get("type") != "replicate" && vs.concurrentUploadLimit != 0 {
@@ -105,6 +126,12 @@ func (vs *VolumeServer) privateStoreHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// writeServiceUnavailable asks the client to retry on another replica while the volume server is shutting down
+func writeServiceUnavailable(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Connection", "close")
+	writeJsonError(w, r, http.StatusServiceUnavailable, fmt.Errorf("volume server is shutting down"))
+}
+
 func getContentLength(r *http.Request) int64 {
 	contentLength := r.Header.Get("Content-Length")
 	if contentLength != "" {
@@ -123,6 +150,12 @@ func (vs *VolumeServer) publicReadOnlyHandler(w http.ResponseWriter, r *http.Req
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 	}
+	isWrite := isWriteRequest(r)
+	if !vs.beginRequest(isWrite) {
+		writeServiceUnavailable(w, r)
+		return
+	}
+	defer vs.endRequest(isWrite)
 	switch r.Method {
 	case "GET", "HEAD":
 		stats.ReadRequest()
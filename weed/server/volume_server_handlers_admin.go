@@ -1,15 +1,19 @@
 package weed_server
 
 import (
+	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/topology"
 	"net/http"
 	"path/filepath"
+	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
 	"github.com/seaweedfs/seaweedfs/weed/stats"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 )
 
+const DefaultPrepareRestartTimeout = time.Minute
+
 func (vs *VolumeServer) healthzHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Server", "SeaweedFS Volume "+util.VERSION)
 	volumeInfos := vs.store.VolumeInfos()
@@ -28,6 +32,28 @@ func (vs *VolumeServer) healthzHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// prepareRestartHandler answers once the volume server is safe to restart, see PrepareForRestart
+func (vs *VolumeServer) prepareRestartHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJsonError(w, r, http.StatusMethodNotAllowed, fmt.Errorf("only POST is supported"))
+		return
+	}
+	timeout := DefaultPrepareRestartTimeout
+	if t := r.FormValue("timeout"); t != "" {
+		var err error
+		if timeout, err = time.ParseDuration(t); err != nil {
+			writeJsonError(w, r, http.StatusBadRequest, fmt.Errorf("invalid timeout %s: %v", t, err))
+			return
+		}
+	}
+	force := r.FormValue("force") == "true"
+	if err := vs.PrepareForRestart(timeout, force); err != nil {
+		writeJsonError(w, r, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJsonQuiet(w, r, http.StatusOK, map[string]interface{}{"ready": true})
+}
+
 func (vs *VolumeServer) statusHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Server", "SeaweedFS Volume "+util.VERSION)
 	m := make(map[string]interface{})
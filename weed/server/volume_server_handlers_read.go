@@ -120,6 +120,7 @@ func (vs *VolumeServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 		ReadDeleted:    r.FormValue("readDeleted") == "true",
 		HasSlowRead:    vs.hasSlowRead,
 		ReadBufferSize: vs.readBufferSizeMB * 1024 * 1024,
+		UseSendfile:    r.TLS == nil,
 	}
 
 	var count int
@@ -205,18 +206,17 @@ func (vs *VolumeServer) GetOrHeadHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	if n.IsCompressed() {
+		contentEncoding := n.Compression()
 		_, _, _, shouldResize := shouldResizeImages(ext, r)
 		_, _, _, _, shouldCrop := shouldCropImages(ext, r)
 		if shouldResize || shouldCrop {
-			if n.Data, err = util.DecompressData(n.Data); err != nil {
+			if n.Data, err = util.DecompressDataAs(n.Data, contentEncoding); err != nil {
 				glog.V(0).Infoln("ungzip error:", err, r.URL.Path)
 			}
-			// } else if strings.Contains(r.Header.Get("Accept-Encoding"), "zstd") && util.IsZstdContent(n.Data) {
-			//	w.Header().Set("Content-Encoding", "zstd")
-		} else if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") && util.IsGzippedContent(n.Data) {
-			w.Header().Set("Content-Encoding", "gzip")
+		} else if contentEncoding != "" && strings.Contains(r.Header.Get("Accept-Encoding"), contentEncoding) {
+			w.Header().Set("Content-Encoding", contentEncoding)
 		} else {
-			if n.Data, err = util.DecompressData(n.Data); err != nil {
+			if n.Data, err = util.DecompressDataAs(n.Data, contentEncoding); err != nil {
 				glog.V(0).Infoln("uncompress error:", err, r.URL.Path)
 			}
 		}
@@ -0,0 +1,107 @@
+package weed_server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage"
+)
+
+func TestGracefulShutdown(t *testing.T) {
+	vs := &VolumeServer{store: &storage.Store{}}
+
+	if !vs.beginRequest(true) {
+		t.Fatalf("write rejected before shutdown")
+	}
+
+	// the write can not finish, so the shutdown times out whatever the timeout is
+	if err := vs.GracefulShutdown(0); err == nil {
+		t.Errorf("shutdown finished with a write in flight")
+	}
+
+	if vs.beginRequest(false) {
+		t.Errorf("read accepted during shutdown")
+	}
+	if vs.beginRequest(true) {
+		t.Errorf("write accepted during shutdown")
+	}
+
+	shutdownErr := make(chan error)
+	go func() {
+		shutdownErr <- vs.GracefulShutdown(time.Hour)
+	}()
+	vs.endRequest(true)
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("shutdown after the write is finished: %v", err)
+	}
+}
+
+func TestGracefulShutdownGrpcWrites(t *testing.T) {
+	vs := &VolumeServer{store: &storage.Store{}}
+
+	writeInfo := &grpc.UnaryServerInfo{FullMethod: "/volume_server_pb.VolumeServer/WriteNeedleBlob"}
+	readInfo := &grpc.UnaryServerInfo{FullMethod: "/volume_server_pb.VolumeServer/ReadNeedleBlob"}
+
+	started, finish, finished := make(chan struct{}), make(chan struct{}), make(chan error)
+	go func() {
+		_, err := vs.unaryShutdownInterceptor(context.Background(), nil, writeInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-finish
+			return nil, nil
+		})
+		finished <- err
+	}()
+	<-started
+
+	if err := vs.GracefulShutdown(0); err == nil {
+		t.Errorf("shutdown finished with a gRPC write in flight")
+	}
+
+	handled := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handled = true
+		return nil, nil
+	}
+	if _, err := vs.unaryShutdownInterceptor(context.Background(), nil, writeInfo, handler); status.Code(err) != codes.Unavailable || handled {
+		t.Errorf("gRPC write accepted during shutdown: %v", err)
+	}
+	if _, err := vs.unaryShutdownInterceptor(context.Background(), nil, readInfo, handler); err != nil || !handled {
+		t.Errorf("gRPC read rejected during shutdown: %v", err)
+	}
+
+	close(finish)
+	if err := <-finished; err != nil {
+		t.Errorf("in-flight gRPC write: %v", err)
+	}
+	if err := vs.GracefulShutdown(time.Hour); err != nil {
+		t.Errorf("shutdown after the gRPC write is finished: %v", err)
+	}
+}
+
+func TestPrepareForRestart(t *testing.T) {
+	vs := &VolumeServer{store: &storage.Store{}}
+
+	if !vs.beginRequest(true) {
+		t.Fatalf("write rejected before restart")
+	}
+
+	if err := vs.PrepareForRestart(0, false); err == nil {
+		t.Errorf("prepared for restart with a write in flight")
+	}
+
+	// unlike the shutdown, requests are still served until the restart
+	if !vs.beginRequest(false) {
+		t.Errorf("read rejected while preparing for restart")
+	}
+	vs.endRequest(false)
+
+	vs.endRequest(true)
+	if err := vs.PrepareForRestart(time.Hour, false); err != nil {
+		t.Errorf("prepare for restart after the write is finished: %v", err)
+	}
+}
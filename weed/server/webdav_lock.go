@@ -0,0 +1,306 @@
+package weed_server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redsync/redsync/v4"
+	"github.com/go-redsync/redsync/v4/redis/goredis/v8"
+	"github.com/google/uuid"
+	"golang.org/x/net/webdav"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+const webDavLockCollectInterval = time.Minute
+
+// webDavLock is one LOCK, persisted in the lock store.
+type webDavLock struct {
+	Token     string    `json:"token"`
+	Root      string    `json:"root"`
+	ZeroDepth bool      `json:"zero_depth"`
+	OwnerXML  string    `json:"owner,omitempty"`
+	Duration  int64     `json:"duration"` // nanoseconds, negative means infinite
+	Expiry    time.Time `json:"expiry"`   // zero if the lock never expires
+	Server    string    `json:"server"`   // the webdav server that created the lock
+}
+
+func (l *webDavLock) details() webdav.LockDetails {
+	return webdav.LockDetails{
+		Root:      l.Root,
+		Duration:  time.Duration(l.Duration),
+		OwnerXML:  l.OwnerXML,
+		ZeroDepth: l.ZeroDepth,
+	}
+}
+
+func (l *webDavLock) setDuration(now time.Time, duration time.Duration) {
+	l.Duration = int64(duration)
+	l.Expiry = time.Time{}
+	if duration >= 0 {
+		l.Expiry = now.Add(duration)
+	}
+}
+
+func (l *webDavLock) expired(now time.Time) bool {
+	return !l.Expiry.IsZero() && !now.Before(l.Expiry)
+}
+
+// covers tells whether the lock applies to the named resource.
+func (l *webDavLock) covers(name string) bool {
+	if name == l.Root {
+		return true
+	}
+	return !l.ZeroDepth && isWebDavDescendant(name, l.Root)
+}
+
+func isWebDavDescendant(name, root string) bool {
+	return root == "/" || strings.HasPrefix(name, root+"/")
+}
+
+// webDavLockStore keeps the locks by token. update runs fn atomically, and saves the locks fn changed.
+type webDavLockStore interface {
+	update(fn func(locks map[string]*webDavLock) error) error
+}
+
+// webDavLockSystem implements RFC 4918 LOCK and UNLOCK semantics with uuid lock tokens,
+// on top of an in-memory or redis lock store. Expired locks are also removed in the background.
+// With a shared lock store, each server only removes the locks it created, since only it knows
+// whether its requests still hold them. Expired locks of the other servers are ignored.
+type webDavLockSystem struct {
+	store  webDavLockStore
+	server string
+	// held locks are being used by an in-flight request on this server
+	heldLock sync.Mutex
+	held     map[string]bool
+}
+
+func newWebDavLockSystem(store webDavLockStore, server string) *webDavLockSystem {
+	ls := &webDavLockSystem{
+		store:  store,
+		server: server,
+		held:   make(map[string]bool),
+	}
+	go ls.loopCollectExpired()
+	return ls
+}
+
+func (ls *webDavLockSystem) loopCollectExpired() {
+	for {
+		time.Sleep(webDavLockCollectInterval)
+		if err := ls.store.update(func(locks map[string]*webDavLock) error {
+			ls.collectExpired(time.Now(), locks)
+			return nil
+		}); err != nil {
+			glog.Warningf("collect expired webdav locks: %v", err)
+		}
+	}
+}
+
+func (ls *webDavLockSystem) collectExpired(now time.Time, locks map[string]*webDavLock) {
+	ls.heldLock.Lock()
+	defer ls.heldLock.Unlock()
+	for token, l := range locks {
+		if l.Server == ls.server && l.expired(now) && !ls.held[token] {
+			delete(locks, token)
+		}
+	}
+}
+
+func (ls *webDavLockSystem) Confirm(now time.Time, name0, name1 string, conditions ...webdav.Condition) (release func(), err error) {
+	var tokens []string
+	err = ls.store.update(func(locks map[string]*webDavLock) error {
+		ls.collectExpired(now, locks)
+		ls.heldLock.Lock()
+		defer ls.heldLock.Unlock()
+		for _, name := range []string{name0, name1} {
+			if name == "" {
+				continue
+			}
+			token := ls.lookup(now, locks, path.Clean("/"+name), conditions...)
+			if token == "" {
+				return webdav.ErrConfirmationFailed
+			}
+			if len(tokens) == 0 || tokens[0] != token {
+				tokens = append(tokens, token)
+			}
+		}
+		for _, token := range tokens {
+			ls.held[token] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		ls.heldLock.Lock()
+		defer ls.heldLock.Unlock()
+		for _, token := range tokens {
+			delete(ls.held, token)
+		}
+	}, nil
+}
+
+// lookup returns the token of a lock on the named resource that matches one of the
+// conditions, i.e. the token in the "If" header, and is not held by another request.
+func (ls *webDavLockSystem) lookup(now time.Time, locks map[string]*webDavLock, name string, conditions ...webdav.Condition) string {
+	for _, c := range conditions {
+		l, found := locks[c.Token]
+		if !found || l.expired(now) || ls.held[c.Token] {
+			continue
+		}
+		if l.covers(name) {
+			return c.Token
+		}
+	}
+	return ""
+}
+
+func (ls *webDavLockSystem) Create(now time.Time, details webdav.LockDetails) (token string, err error) {
+	root := path.Clean("/" + details.Root)
+	err = ls.store.update(func(locks map[string]*webDavLock) error {
+		ls.collectExpired(now, locks)
+		for _, l := range locks {
+			if l.expired(now) {
+				continue
+			}
+			if l.Root == root {
+				return webdav.ErrLocked
+			}
+			// a depth infinity lock conflicts with any lock on its children
+			if !details.ZeroDepth && isWebDavDescendant(l.Root, root) {
+				return webdav.ErrLocked
+			}
+			if !l.ZeroDepth && isWebDavDescendant(root, l.Root) {
+				return webdav.ErrLocked
+			}
+		}
+		l := &webDavLock{
+			Token:     "urn:uuid:" + uuid.New().String(),
+			Root:      root,
+			ZeroDepth: details.ZeroDepth,
+			OwnerXML:  details.OwnerXML,
+			Server:    ls.server,
+		}
+		l.setDuration(now, details.Duration)
+		locks[l.Token] = l
+		token = l.Token
+		return nil
+	})
+	return
+}
+
+func (ls *webDavLockSystem) Refresh(now time.Time, token string, duration time.Duration) (details webdav.LockDetails, err error) {
+	err = ls.store.update(func(locks map[string]*webDavLock) error {
+		ls.collectExpired(now, locks)
+		l, found := locks[token]
+		if !found || l.expired(now) {
+			return webdav.ErrNoSuchLock
+		}
+		if ls.isHeld(token) {
+			return webdav.ErrLocked
+		}
+		l.setDuration(now, duration)
+		details = l.details()
+		return nil
+	})
+	return
+}
+
+func (ls *webDavLockSystem) Unlock(now time.Time, token string) error {
+	return ls.store.update(func(locks map[string]*webDavLock) error {
+		ls.collectExpired(now, locks)
+		if l, found := locks[token]; !found || l.expired(now) {
+			return webdav.ErrNoSuchLock
+		}
+		if ls.isHeld(token) {
+			return webdav.ErrLocked
+		}
+		delete(locks, token)
+		return nil
+	})
+}
+
+func (ls *webDavLockSystem) isHeld(token string) bool {
+	ls.heldLock.Lock()
+	defer ls.heldLock.Unlock()
+	return ls.held[token]
+}
+
+// webDavMemLockStore keeps the locks of a single webdav server.
+type webDavMemLockStore struct {
+	sync.Mutex
+	locks map[string]*webDavLock
+}
+
+func newWebDavMemLockStore() *webDavMemLockStore {
+	return &webDavMemLockStore{
+		locks: make(map[string]*webDavLock),
+	}
+}
+
+func (store *webDavMemLockStore) update(fn func(locks map[string]*webDavLock) error) error {
+	store.Lock()
+	defer store.Unlock()
+	return fn(store.locks)
+}
+
+// webDavRedisLockStore shares the locks among several webdav servers.
+// All locks are kept in one key, guarded by a redsync mutex.
+type webDavRedisLockStore struct {
+	client  redis.UniversalClient
+	redsync *redsync.Redsync
+	key     string
+}
+
+func newWebDavRedisLockStore(address, password string, database int) *webDavRedisLockStore {
+	client := redis.NewClient(&redis.Options{
+		Addr:     address,
+		Password: password,
+		DB:       database,
+	})
+	return &webDavRedisLockStore{
+		client:  client,
+		redsync: redsync.New(goredis.NewPool(client)),
+		key:     "seaweedfs:webdav:locks",
+	}
+}
+
+func (store *webDavRedisLockStore) update(fn func(locks map[string]*webDavLock) error) error {
+	mutex := store.redsync.NewMutex(store.key + "lock")
+	if err := mutex.Lock(); err != nil {
+		return fmt.Errorf("lock %s: %v", store.key, err)
+	}
+	defer mutex.Unlock()
+
+	ctx := context.Background()
+	locks := make(map[string]*webDavLock)
+	data, err := store.client.Get(ctx, store.key).Bytes()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("read %s: %v", store.key, err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &locks); err != nil {
+			return fmt.Errorf("decode %s: %v", store.key, err)
+		}
+	}
+
+	if err := fn(locks); err != nil {
+		return err
+	}
+
+	if data, err = json.Marshal(locks); err != nil {
+		return fmt.Errorf("encode %s: %v", store.key, err)
+	}
+	if err := store.client.Set(ctx, store.key, data, 0).Err(); err != nil {
+		return fmt.Errorf("write %s: %v", store.key, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package weed_server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+func TestWebDavLockSystem(t *testing.T) {
+	ls := &webDavLockSystem{store: newWebDavMemLockStore(), held: make(map[string]bool)}
+	now := time.Now()
+
+	token, err := ls.Create(now, webdav.LockDetails{Root: "/docs", Duration: time.Minute})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(token, "urn:uuid:") {
+		t.Errorf("token %s is not a uuid urn", token)
+	}
+
+	// depth infinity locks all children
+	if _, err = ls.Create(now, webdav.LockDetails{Root: "/docs/a.docx", ZeroDepth: true, Duration: time.Minute}); err != webdav.ErrLocked {
+		t.Errorf("create under an infinite lock: %v, want ErrLocked", err)
+	}
+	if _, err = ls.Create(now, webdav.LockDetails{Root: "/", Duration: time.Minute}); err != webdav.ErrLocked {
+		t.Errorf("create over a locked child: %v, want ErrLocked", err)
+	}
+	if _, err = ls.Confirm(now, "/docs/a.docx", ""); err != webdav.ErrConfirmationFailed {
+		t.Errorf("confirm without If token: %v, want ErrConfirmationFailed", err)
+	}
+	release, err := ls.Confirm(now, "/docs/a.docx", "", webdav.Condition{Token: token})
+	if err != nil {
+		t.Fatalf("confirm with If token: %v", err)
+	}
+	if err = ls.Unlock(now, token); err != webdav.ErrLocked {
+		t.Errorf("unlock a held lock: %v, want ErrLocked", err)
+	}
+	release()
+
+	if _, err = ls.Refresh(now, token, 2*time.Minute); err != nil {
+		t.Errorf("refresh: %v", err)
+	}
+	if err = ls.Unlock(now, token); err != nil {
+		t.Errorf("unlock: %v", err)
+	}
+	if err = ls.Unlock(now, token); err != webdav.ErrNoSuchLock {
+		t.Errorf("unlock twice: %v, want ErrNoSuchLock", err)
+	}
+
+	// expired locks are dropped
+	if _, err = ls.Create(now, webdav.LockDetails{Root: "/tmp", Duration: time.Second}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err = ls.Create(now.Add(2*time.Second), webdav.LockDetails{Root: "/tmp", Duration: time.Second}); err != nil {
+		t.Errorf("create over an expired lock: %v", err)
+	}
+}
+
+func TestWebDavLockSystemSharedStore(t *testing.T) {
+	store := newWebDavMemLockStore()
+	ls1 := &webDavLockSystem{store: store, server: "webdav1:7333", held: make(map[string]bool)}
+	ls2 := &webDavLockSystem{store: store, server: "webdav2:7333", held: make(map[string]bool)}
+	now := time.Now()
+
+	token, err := ls1.Create(now, webdav.LockDetails{Root: "/docs", Duration: time.Second})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err = ls2.Create(now, webdav.LockDetails{Root: "/docs", Duration: time.Second}); err != webdav.ErrLocked {
+		t.Errorf("create on another server: %v, want ErrLocked", err)
+	}
+	release, err := ls1.Confirm(now, "/docs/a.docx", "", webdav.Condition{Token: token})
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+
+	// the lock expires while a request on the first server still holds it
+	later := now.Add(2 * time.Second)
+	if _, err = ls2.Create(later, webdav.LockDetails{Root: "/docs", Duration: time.Minute}); err != nil {
+		t.Errorf("create over an expired lock of another server: %v", err)
+	}
+	if _, found := store.locks[token]; !found {
+		t.Errorf("another server removed the held lock")
+	}
+	release()
+
+	ls1.collectExpired(later, store.locks)
+	if _, found := store.locks[token]; found {
+		t.Errorf("expired lock is not removed by its own server")
+	}
+	if len(store.locks) != 1 {
+		t.Errorf("%d locks left, want 1", len(store.locks))
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/util"
 	"github.com/seaweedfs/seaweedfs/weed/util/chunk_cache"
+	"github.com/seaweedfs/seaweedfs/weed/wdclient"
 
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
@@ -38,6 +39,11 @@ type WebDavOption struct {
 	Cipher         bool
 	CacheDir       string
 	CacheSizeMB    int64
+	// LockRedisAddress shares LOCK tokens among webdav servers. Locks are kept in memory if empty.
+	LockRedisAddress  string
+	LockRedisPassword string
+	// LockServerId tells the locks created by this server apart in the shared lock store
+	LockServerId string
 }
 
 type WebDavServer struct {
@@ -46,6 +52,7 @@ type WebDavServer struct {
 	filer          *filer.Filer
 	grpcDialOption grpc.DialOption
 	Handler        *webdav.Handler
+	fs             *WebDavFileSystem
 }
 
 func max(x, y int64) int64 {
@@ -57,20 +64,26 @@ func max(x, y int64) int64 {
 
 func NewWebDavServer(option *WebDavOption) (ws *WebDavServer, err error) {
 
-	fs, _ := NewWebDavFileSystem(option)
+	fs, _ := newWebDavFileSystem(option)
 
 	// Fix no set filer.path , accessing "/" returns "//"
 	if option.FilerRootPath == "/" {
 		option.FilerRootPath = ""
 	}
 
+	var lockStore webDavLockStore = newWebDavMemLockStore()
+	if option.LockRedisAddress != "" {
+		lockStore = newWebDavRedisLockStore(option.LockRedisAddress, option.LockRedisPassword, 0)
+	}
+
 	ws = &WebDavServer{
 		option:         option,
 		grpcDialOption: security.LoadClientTLS(util.GetViper(), "grpc.filer"),
 		Handler: &webdav.Handler{
 			FileSystem: fs,
-			LockSystem: webdav.NewMemLS(),
+			LockSystem: newWebDavLockSystem(lockStore, option.LockServerId),
 		},
+		fs: fs,
 	}
 
 	return ws, nil
@@ -114,6 +127,10 @@ type WebDavFile struct {
 }
 
 func NewWebDavFileSystem(option *WebDavOption) (webdav.FileSystem, error) {
+	return newWebDavFileSystem(option)
+}
+
+func newWebDavFileSystem(option *WebDavOption) (*WebDavFileSystem, error) {
 
 	cacheUniqueId := util.Md5String([]byte("webdav" + string(option.Filer) + util.Version()))[0:8]
 	cacheDir := path.Join(option.CacheDir, cacheUniqueId)
@@ -145,6 +162,9 @@ func (fs *WebDavFileSystem) AdjustedUrl(location *filer_pb.Location) string {
 func (fs *WebDavFileSystem) GetDataCenter() string {
 	return ""
 }
+func (fs *WebDavFileSystem) GetLookupFileIdFunction() wdclient.LookupFileIdFunctionType {
+	return filer.LookupFn(fs)
+}
 
 func clearName(name string) (string, error) {
 	slashed := strings.HasSuffix(name, "/")
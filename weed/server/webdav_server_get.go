@@ -0,0 +1,68 @@
+package weed_server
+
+import (
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+// ServeHTTP streams whole-file GET requests straight from the volume servers,
+// so that large files are never held in memory. Everything else, including
+// range requests and conditional GETs, is served by the webdav handler.
+func (ws *WebDavServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !ws.streamGet(w, r) {
+		ws.Handler.ServeHTTP(w, r)
+	}
+}
+
+// streamGet returns false if the request is not handled.
+func (ws *WebDavServer) streamGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet || r.Header.Get("Range") != "" ||
+		r.Header.Get("If-Modified-Since") != "" || r.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	fullFilePath, err := clearName(ws.option.FilerRootPath + r.URL.Path)
+	if err != nil {
+		return false
+	}
+	entry, err := filer_pb.GetEntry(ws.fs, util.FullPath(fullFilePath))
+	if err != nil || entry == nil || entry.IsDirectory || entry.IsInRemoteOnly() {
+		return false
+	}
+
+	glog.V(2).Infof("WebDavServer.streamGet %v", fullFilePath)
+
+	fileSize := int64(filer.FileSize(entry))
+	mimeType := entry.Attributes.Mime
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(entry.Name))
+	}
+	if mimeType != "" {
+		w.Header().Set("Content-Type", mimeType)
+	}
+	w.Header().Set("Last-Modified", time.Unix(entry.Attributes.Mtime, 0).UTC().Format(http.TimeFormat))
+	if etag := filer.ETag(entry); etag != "" {
+		w.Header().Set("ETag", "\""+etag+"\"")
+	}
+	// the size is always known from the entry. The chunked transfer encoding
+	// is only used by net/http when a Content-Length can not be set.
+	w.Header().Set("Content-Length", strconv.FormatInt(fileSize, 10))
+	w.WriteHeader(http.StatusOK)
+
+	if len(entry.Content) > 0 {
+		w.Write(entry.Content)
+		return true
+	}
+	if err = filer.StreamContent(ws.fs, w, entry.GetChunks(), 0, fileSize); err != nil {
+		// the headers are sent already
+		glog.Errorf("stream %s: %v", fullFilePath, err)
+	}
+	return true
+}
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"encoding/json"
-	"flag"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
 	"golang.org/x/exp/slices"
@@ -16,6 +15,7 @@ func init() {
 }
 
 type commandAutoDLVolumeList struct {
+	flagCommand
 	collectionPattern *string
 	readonly          *bool
 	volumeId          *uint64
@@ -35,7 +35,7 @@ func (c *commandAutoDLVolumeList) Help() string {
 
 func (c *commandAutoDLVolumeList) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	volumeListCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volumeListCommand := newFlagSet(c.Name(), commandEnv)
 	c.collectionPattern = volumeListCommand.String("collectionPattern", "", "match with wildcard characters '*' and '?'")
 	c.readonly = volumeListCommand.Bool("readonly", false, "show only readonly")
 	c.volumeId = volumeListCommand.Uint64("volumeId", 0, "show only volume id")
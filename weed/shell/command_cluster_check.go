@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"io"
 
@@ -18,6 +17,7 @@ func init() {
 }
 
 type commandClusterCheck struct {
+	flagCommand
 }
 
 func (c *commandClusterCheck) Name() string {
@@ -34,7 +34,7 @@ func (c *commandClusterCheck) Help() string {
 
 func (c *commandClusterCheck) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	clusterPsCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	clusterPsCommand := newFlagSet(c.Name(), commandEnv)
 	if err = clusterPsCommand.Parse(args); err != nil {
 		return nil
 	}
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/cluster"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
@@ -21,6 +20,7 @@ func init() {
 }
 
 type commandClusterPs struct {
+	flagCommand
 }
 
 func (c *commandClusterPs) Name() string {
@@ -37,7 +37,7 @@ func (c *commandClusterPs) Help() string {
 
 func (c *commandClusterPs) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	clusterPsCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	clusterPsCommand := newFlagSet(c.Name(), commandEnv)
 	if err = clusterPsCommand.Parse(args); err != nil {
 		return nil
 	}
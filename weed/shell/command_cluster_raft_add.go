@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"io"
@@ -13,6 +12,7 @@ func init() {
 }
 
 type commandRaftServerAdd struct {
+	flagCommand
 }
 
 func (c *commandRaftServerAdd) Name() string {
@@ -29,7 +29,7 @@ func (c *commandRaftServerAdd) Help() string {
 
 func (c *commandRaftServerAdd) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	raftServerAddCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	raftServerAddCommand := newFlagSet(c.Name(), commandEnv)
 	serverId := raftServerAddCommand.String("id", "", "server id")
 	serverAddress := raftServerAddCommand.String("address", "", "server grpc address")
 	serverVoter := raftServerAddCommand.Bool("voter", true, "assign it a vote")
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"io"
@@ -13,6 +12,7 @@ func init() {
 }
 
 type commandRaftClusterPs struct {
+	flagCommand
 }
 
 func (c *commandRaftClusterPs) Name() string {
@@ -28,7 +28,7 @@ func (c *commandRaftClusterPs) Help() string {
 
 func (c *commandRaftClusterPs) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	raftClusterPsCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	raftClusterPsCommand := newFlagSet(c.Name(), commandEnv)
 	if err = raftClusterPsCommand.Parse(args); err != nil {
 		return nil
 	}
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"io"
@@ -13,6 +12,7 @@ func init() {
 }
 
 type commandRaftServerRemove struct {
+	flagCommand
 }
 
 func (c *commandRaftServerRemove) Name() string {
@@ -29,7 +29,7 @@ func (c *commandRaftServerRemove) Help() string {
 
 func (c *commandRaftServerRemove) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	raftServerAddCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	raftServerAddCommand := newFlagSet(c.Name(), commandEnv)
 	serverId := raftServerAddCommand.String("id", "", "server id")
 	if err = raftServerAddCommand.Parse(args); err != nil {
 		return nil
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"io"
 
@@ -14,6 +13,7 @@ func init() {
 }
 
 type commandCollectionDelete struct {
+	flagCommand
 }
 
 func (c *commandCollectionDelete) Name() string {
@@ -30,7 +30,7 @@ func (c *commandCollectionDelete) Help() string {
 
 func (c *commandCollectionDelete) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	colDeleteCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	colDeleteCommand := newFlagSet(c.Name(), commandEnv)
 	collectionName := colDeleteCommand.String("collection", "", "collection to delete. Use '_default_' for the empty-named collection.")
 	applyBalancing := colDeleteCommand.Bool("force", false, "apply the collection")
 	if err = colDeleteCommand.Parse(args); err != nil {
@@ -0,0 +1,161 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+)
+
+func init() {
+	Commands = append(Commands, &commandCollectionEcConfigSet{})
+	Commands = append(Commands, &commandCollectionEcConfigGet{})
+}
+
+type commandCollectionEcConfigSet struct {
+	flagCommand
+}
+
+func (c *commandCollectionEcConfigSet) Name() string {
+	return "collection.ec.config.set"
+}
+
+func (c *commandCollectionEcConfigSet) Help() string {
+	return `set the erasure coding stripe of a collection
+
+	collection.ec.config.set <collection_name> -data-shards=6 -parity-shards=2
+	collection.ec.config.set <collection_name> -useDefault
+
+	ec.encode splits the collection's volumes into this many data shards, and adds this many parity shards,
+	instead of the default 10+4. Any data-shards of the shards can reconstruct the volume.
+	E.g., 6+2 needs fewer servers for a small cluster, and 12+4 has less overhead for a large one.
+	The stripes are kept in the raft state of the masters. Each encoded volume keeps its own stripe,
+	so changing it only affects the volumes encoded later.
+
+`
+}
+
+func (c *commandCollectionEcConfigSet) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	collectionName, args := parseCollectionArg(args)
+
+	ecConfigCommand := newFlagSet(c.Name(), commandEnv)
+	dataShards := ecConfigCommand.Int("data-shards", erasure_coding.DataShardsCount, "the number of data shards")
+	parityShards := ecConfigCommand.Int("parity-shards", erasure_coding.ParityShardsCount, "the number of parity shards")
+	useDefault := ecConfigCommand.Bool("useDefault", false, "remove the collection's stripe, going back to the default 10+4")
+	if err = ecConfigCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if collectionName == "" {
+		return fmt.Errorf("empty collection name is not allowed")
+	}
+	if collectionName == "_default_" {
+		collectionName = ""
+	}
+	ecConfig := erasure_coding.ECConfig{DataShards: *dataShards, ParityShards: *parityShards}
+	if *useDefault {
+		ecConfig = erasure_coding.DefaultECConfig
+	}
+	if err = ecConfig.Validate(); err != nil {
+		return err
+	}
+
+	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		_, err = client.CollectionEcConfigSet(context.Background(), &master_pb.CollectionEcConfigSetRequest{
+			Collection:   collectionName,
+			DataShards:   uint32(ecConfig.DataShards),
+			ParityShards: uint32(ecConfig.ParityShards),
+		})
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(writer, "collection '%s' erasure coding stripe is %s.\n", collectionName, ecConfig)
+
+	return nil
+}
+
+type commandCollectionEcConfigGet struct {
+	flagCommand
+}
+
+func (c *commandCollectionEcConfigGet) Name() string {
+	return "collection.ec.config.get"
+}
+
+func (c *commandCollectionEcConfigGet) Help() string {
+	return `show the erasure coding stripe of a collection
+
+	collection.ec.config.get <collection_name>
+
+`
+}
+
+func (c *commandCollectionEcConfigGet) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	collectionName, args := parseCollectionArg(args)
+
+	ecConfigCommand := newFlagSet(c.Name(), commandEnv)
+	if err = ecConfigCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if collectionName == "" {
+		return fmt.Errorf("empty collection name is not allowed")
+	}
+	if collectionName == "_default_" {
+		collectionName = ""
+	}
+
+	var resp *master_pb.CollectionEcConfigGetResponse
+	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		resp, err = client.CollectionEcConfigGet(context.Background(), &master_pb.CollectionEcConfigGetRequest{
+			Collection: collectionName,
+		})
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	ecConfig := erasure_coding.NewECConfig(resp.DataShards, resp.ParityShards)
+	if resp.IsCollectionConfig {
+		fmt.Fprintf(writer, "collection '%s' erasure coding stripe: %s\n", collectionName, ecConfig)
+	} else {
+		fmt.Fprintf(writer, "collection '%s' erasure coding stripe: %s (the default)\n", collectionName, ecConfig)
+	}
+
+	return nil
+}
+
+// parseCollectionArg takes the leading collection name off the command arguments
+func parseCollectionArg(args []string) (collection string, rest []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+// collectionEcConfig returns the stripe to encode the collection's volumes with
+func collectionEcConfig(commandEnv *CommandEnv, collection string) (ecConfig erasure_coding.ECConfig, err error) {
+	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		resp, getErr := client.CollectionEcConfigGet(context.Background(), &master_pb.CollectionEcConfigGetRequest{
+			Collection: collection,
+		})
+		if getErr != nil {
+			return getErr
+		}
+		ecConfig = erasure_coding.NewECConfig(resp.DataShards, resp.ParityShards)
+		return nil
+	})
+	if err != nil {
+		return ecConfig, fmt.Errorf("get collection %s ec config: %v", collection, err)
+	}
+	return ecConfig, nil
+}
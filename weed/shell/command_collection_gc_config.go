@@ -0,0 +1,128 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandCollectionGCConfigSet{})
+	Commands = append(Commands, &commandCollectionGCConfigGet{})
+}
+
+type commandCollectionGCConfigSet struct {
+	flagCommand
+}
+
+func (c *commandCollectionGCConfigSet) Name() string {
+	return "collection.gc.config.set"
+}
+
+func (c *commandCollectionGCConfigSet) Help() string {
+	return `set the garbage threshold to vacuum the volumes of a collection
+
+	collection.gc.config.set -collection <collection_name> -garbageThreshold 0.01
+	collection.gc.config.set -collection <collection_name> -useDefault
+
+	The automatic vacuum compacts the collection's volumes with more garbage than this threshold,
+	instead of the master's -garbageThreshold. E.g., compact a write-once backup collection aggressively with 0.01,
+	and a collection with high churn with 0.3.
+	The thresholds are kept in the raft state of the masters.
+
+`
+}
+
+func (c *commandCollectionGCConfigSet) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	gcConfigCommand := newFlagSet(c.Name(), commandEnv)
+	collectionName := gcConfigCommand.String("collection", "", "the collection name. Use '_default_' for the empty-named collection.")
+	garbageThreshold := gcConfigCommand.Float64("garbageThreshold", -1, "vacuum when garbage is more than this limit, in [0, 1]")
+	useDefault := gcConfigCommand.Bool("useDefault", false, "remove the collection's threshold, going back to the master's -garbageThreshold")
+	if err = gcConfigCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if *collectionName == "" {
+		return fmt.Errorf("empty collection name is not allowed")
+	}
+	if *collectionName == "_default_" {
+		*collectionName = ""
+	}
+	if !*useDefault && (*garbageThreshold < 0 || *garbageThreshold > 1) {
+		return fmt.Errorf("-garbageThreshold should be in [0, 1], or use -useDefault")
+	}
+
+	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		_, err = client.CollectionGCConfigSet(context.Background(), &master_pb.CollectionGCConfigSetRequest{
+			Collection:       *collectionName,
+			GarbageThreshold: *garbageThreshold,
+			UseDefault:       *useDefault,
+		})
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	if *useDefault {
+		fmt.Fprintf(writer, "collection '%s' uses the master's garbage threshold.\n", *collectionName)
+	} else {
+		fmt.Fprintf(writer, "collection '%s' garbage threshold is %v.\n", *collectionName, *garbageThreshold)
+	}
+
+	return nil
+}
+
+type commandCollectionGCConfigGet struct {
+	flagCommand
+}
+
+func (c *commandCollectionGCConfigGet) Name() string {
+	return "collection.gc.config.get"
+}
+
+func (c *commandCollectionGCConfigGet) Help() string {
+	return `show the garbage threshold to vacuum the volumes of a collection
+
+	collection.gc.config.get -collection <collection_name>
+
+`
+}
+
+func (c *commandCollectionGCConfigGet) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	gcConfigCommand := newFlagSet(c.Name(), commandEnv)
+	collectionName := gcConfigCommand.String("collection", "", "the collection name. Use '_default_' for the empty-named collection.")
+	if err = gcConfigCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if *collectionName == "" {
+		return fmt.Errorf("empty collection name is not allowed")
+	}
+	if *collectionName == "_default_" {
+		*collectionName = ""
+	}
+
+	var resp *master_pb.CollectionGCConfigGetResponse
+	err = commandEnv.MasterClient.WithClient(false, func(client master_pb.SeaweedClient) error {
+		resp, err = client.CollectionGCConfigGet(context.Background(), &master_pb.CollectionGCConfigGetRequest{
+			Collection: *collectionName,
+		})
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	if resp.IsCollectionThreshold {
+		fmt.Fprintf(writer, "collection '%s' garbage threshold: %v\n", *collectionName, resp.GarbageThreshold)
+	} else {
+		fmt.Fprintf(writer, "collection '%s' garbage threshold: %v (the master's default)\n", *collectionName, resp.GarbageThreshold)
+	}
+
+	return nil
+}
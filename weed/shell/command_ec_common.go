@@ -299,6 +299,20 @@ func findEcVolumeShards(ecNode *EcNode, vid needle.VolumeId) erasure_coding.Shar
 	return 0
 }
 
+// findEcVolumeConfig returns the stripe of the ec volume reported by the volume servers, the default 10+4 if none has
+func findEcVolumeConfig(ecNodes []*EcNode, vid needle.VolumeId) erasure_coding.ECConfig {
+	for _, ecNode := range ecNodes {
+		if diskInfo, found := ecNode.info.DiskInfos[string(types.HardDriveType)]; found {
+			for _, shardInfo := range diskInfo.EcShardInfos {
+				if needle.VolumeId(shardInfo.Id) == vid && shardInfo.DataShards > 0 {
+					return erasure_coding.NewECConfig(shardInfo.DataShards, shardInfo.ParityShards)
+				}
+			}
+		}
+	}
+	return erasure_coding.DefaultECConfig
+}
+
 func (ecNode *EcNode) addEcVolumeShards(vid needle.VolumeId, collection string, shardIds []uint32) *EcNode {
 
 	foundVolume := false
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"io"
@@ -25,6 +24,7 @@ func init() {
 }
 
 type commandEcEncode struct {
+	flagCommand
 }
 
 func (c *commandEcEncode) Name() string {
@@ -42,8 +42,8 @@ func (c *commandEcEncode) Help() string {
 	2. apply erasure coding to the volume
 	3. move the encoded shards to multiple volume servers
 
-	The erasure coding is 10.4. So ideally you have more than 14 volume servers, and you can afford
-	to lose 4 volume servers.
+	The erasure coding is 10.4, unless the collection has its own stripe set by collection.ec.config.set.
+	So ideally you have more than 14 volume servers, and you can afford to lose 4 volume servers.
 
 	If the number of volumes are not high, the worst case is that you only have 4 volume servers,
 	and the shards are spread as 4,4,3,3, respectively. You can afford to lose one volume server.
@@ -56,7 +56,7 @@ func (c *commandEcEncode) Help() string {
 
 func (c *commandEcEncode) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	encodeCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	encodeCommand := newFlagSet(c.Name(), commandEnv)
 	volumeId := encodeCommand.Int("volumeId", 0, "the volume id")
 	collection := encodeCommand.String("collection", "", "the collection name")
 	fullPercentage := encodeCommand.Float64("fullPercent", 95, "the volume reaches the percentage of max volume size")
@@ -105,6 +105,11 @@ func doEcEncode(commandEnv *CommandEnv, collection string, vid needle.VolumeId,
 
 	// fmt.Printf("found ec %d shards on %v\n", vid, locations)
 
+	ecConfig, err := collectionEcConfig(commandEnv, collection)
+	if err != nil {
+		return fmt.Errorf("get ec config of collection %s: %v", collection, err)
+	}
+
 	// mark the volume as readonly
 	err = markVolumeReplicasWritable(commandEnv.option.GrpcDialOption, vid, locations, false)
 	if err != nil {
@@ -112,13 +117,13 @@ func doEcEncode(commandEnv *CommandEnv, collection string, vid needle.VolumeId,
 	}
 
 	// generate ec shards
-	err = generateEcShards(commandEnv.option.GrpcDialOption, vid, collection, locations[0].ServerAddress())
+	err = generateEcShards(commandEnv.option.GrpcDialOption, vid, collection, ecConfig, locations[0].ServerAddress())
 	if err != nil {
 		return fmt.Errorf("generate ec shards for volume %d on %s: %v", vid, locations[0].Url, err)
 	}
 
 	// balance the ec shards to current cluster
-	err = spreadEcShards(commandEnv, vid, collection, locations, parallelCopy)
+	err = spreadEcShards(commandEnv, vid, collection, ecConfig, locations, parallelCopy)
 	if err != nil {
 		return fmt.Errorf("spread ec shards for volume %d from %s: %v", vid, locations[0].Url, err)
 	}
@@ -126,14 +131,16 @@ func doEcEncode(commandEnv *CommandEnv, collection string, vid needle.VolumeId,
 	return nil
 }
 
-func generateEcShards(grpcDialOption grpc.DialOption, volumeId needle.VolumeId, collection string, sourceVolumeServer pb.ServerAddress) error {
+func generateEcShards(grpcDialOption grpc.DialOption, volumeId needle.VolumeId, collection string, ecConfig erasure_coding.ECConfig, sourceVolumeServer pb.ServerAddress) error {
 
-	fmt.Printf("generateEcShards %s %d on %s ...\n", collection, volumeId, sourceVolumeServer)
+	fmt.Printf("generateEcShards %s %d with %s shards on %s ...\n", collection, volumeId, ecConfig, sourceVolumeServer)
 
 	err := operation.WithVolumeServerClient(false, sourceVolumeServer, grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
 		_, genErr := volumeServerClient.VolumeEcShardsGenerate(context.Background(), &volume_server_pb.VolumeEcShardsGenerateRequest{
-			VolumeId:   uint32(volumeId),
-			Collection: collection,
+			VolumeId:     uint32(volumeId),
+			Collection:   collection,
+			DataShards:   uint32(ecConfig.DataShards),
+			ParityShards: uint32(ecConfig.ParityShards),
 		})
 		return genErr
 	})
@@ -142,23 +149,24 @@ func generateEcShards(grpcDialOption grpc.DialOption, volumeId needle.VolumeId,
 
 }
 
-func spreadEcShards(commandEnv *CommandEnv, volumeId needle.VolumeId, collection string, existingLocations []wdclient.Location, parallelCopy bool) (err error) {
+func spreadEcShards(commandEnv *CommandEnv, volumeId needle.VolumeId, collection string, ecConfig erasure_coding.ECConfig, existingLocations []wdclient.Location, parallelCopy bool) (err error) {
 
 	allEcNodes, totalFreeEcSlots, err := collectEcNodes(commandEnv, "")
 	if err != nil {
 		return err
 	}
 
-	if totalFreeEcSlots < erasure_coding.TotalShardsCount {
+	totalShards := ecConfig.TotalShards()
+	if totalFreeEcSlots < totalShards {
 		return fmt.Errorf("not enough free ec shard slots. only %d left", totalFreeEcSlots)
 	}
 	allocatedDataNodes := allEcNodes
-	if len(allocatedDataNodes) > erasure_coding.TotalShardsCount {
-		allocatedDataNodes = allocatedDataNodes[:erasure_coding.TotalShardsCount]
+	if len(allocatedDataNodes) > totalShards {
+		allocatedDataNodes = allocatedDataNodes[:totalShards]
 	}
 
 	// calculate how many shards to allocate for these servers
-	allocatedEcIds := balancedEcDistribution(allocatedDataNodes)
+	allocatedEcIds := balancedEcDistribution(allocatedDataNodes, totalShards)
 
 	// ask the data nodes to copy from the source volume server
 	copiedShardIds, err := parallelCopyEcShardsFromSource(commandEnv.option.GrpcDialOption, allocatedDataNodes, allocatedEcIds, volumeId, collection, existingLocations[0], parallelCopy)
@@ -250,11 +258,11 @@ func parallelCopyEcShardsFromSource(grpcDialOption grpc.DialOption, targetServer
 	return
 }
 
-func balancedEcDistribution(servers []*EcNode) (allocated [][]uint32) {
+func balancedEcDistribution(servers []*EcNode, totalShards int) (allocated [][]uint32) {
 	allocated = make([][]uint32, len(servers))
 	allocatedShardIdIndex := uint32(0)
 	serverIndex := rand.Intn(len(servers))
-	for allocatedShardIdIndex < erasure_coding.TotalShardsCount {
+	for allocatedShardIdIndex < uint32(totalShards) {
 		if servers[serverIndex].freeEcSlot > 0 {
 			allocated[serverIndex] = append(allocated[serverIndex], allocatedShardIdIndex)
 			allocatedShardIdIndex++
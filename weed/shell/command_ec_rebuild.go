@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"io"
@@ -19,6 +18,7 @@ func init() {
 }
 
 type commandEcRebuild struct {
+	flagCommand
 }
 
 func (c *commandEcRebuild) Name() string {
@@ -57,7 +57,7 @@ func (c *commandEcRebuild) Help() string {
 
 func (c *commandEcRebuild) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	fixCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	fixCommand := newFlagSet(c.Name(), commandEnv)
 	collection := fixCommand.String("collection", "EACH_COLLECTION", "collection name, or \"EACH_COLLECTION\" for each collection")
 	applyChanges := fixCommand.Bool("force", false, "apply the changes")
 	if err = fixCommand.Parse(args); err != nil {
@@ -108,16 +108,16 @@ func rebuildEcVolumes(commandEnv *CommandEnv, allEcNodes []*EcNode, collection s
 
 	for vid, locations := range ecShardMap {
 		shardCount := locations.shardCount()
-		if shardCount == erasure_coding.TotalShardsCount {
+		if shardCount == locations.ECConfig.TotalShards() {
 			continue
 		}
-		if shardCount < erasure_coding.DataShardsCount {
+		if shardCount < locations.ECConfig.DataShards {
 			return fmt.Errorf("ec volume %d is unrepairable with %d shards\n", vid, shardCount)
 		}
 
 		sortEcNodesByFreeslotsDescending(allEcNodes)
 
-		if allEcNodes[0].freeEcSlot < erasure_coding.TotalShardsCount {
+		if allEcNodes[0].freeEcSlot < locations.ECConfig.TotalShards() {
 			return fmt.Errorf("disk space is not enough")
 		}
 
@@ -129,7 +129,7 @@ func rebuildEcVolumes(commandEnv *CommandEnv, allEcNodes []*EcNode, collection s
 	return nil
 }
 
-func rebuildOneEcVolume(commandEnv *CommandEnv, rebuilder *EcNode, collection string, volumeId needle.VolumeId, locations EcShardLocations, writer io.Writer, applyChanges bool) error {
+func rebuildOneEcVolume(commandEnv *CommandEnv, rebuilder *EcNode, collection string, volumeId needle.VolumeId, locations *EcShardLocations, writer io.Writer, applyChanges bool) error {
 
 	if !commandEnv.isLocked() {
 		return fmt.Errorf("lock is lost")
@@ -190,7 +190,7 @@ func generateMissingShards(grpcDialOption grpc.DialOption, collection string, vo
 	return
 }
 
-func prepareDataToRecover(commandEnv *CommandEnv, rebuilder *EcNode, collection string, volumeId needle.VolumeId, locations EcShardLocations, writer io.Writer, applyBalancing bool) (copiedShardIds []uint32, localShardIds []uint32, err error) {
+func prepareDataToRecover(commandEnv *CommandEnv, rebuilder *EcNode, collection string, volumeId needle.VolumeId, locations *EcShardLocations, writer io.Writer, applyBalancing bool) (copiedShardIds []uint32, localShardIds []uint32, err error) {
 
 	needEcxFile := true
 	var localShardBits erasure_coding.ShardBits
@@ -203,7 +203,7 @@ func prepareDataToRecover(commandEnv *CommandEnv, rebuilder *EcNode, collection
 		}
 	}
 
-	for shardId, ecNodes := range locations {
+	for shardId, ecNodes := range locations.Locations {
 
 		if len(ecNodes) == 0 {
 			fmt.Fprintf(writer, "missing shard %d.%d\n", volumeId, shardId)
@@ -243,7 +243,7 @@ func prepareDataToRecover(commandEnv *CommandEnv, rebuilder *EcNode, collection
 
 	}
 
-	if len(copiedShardIds)+len(localShardIds) >= erasure_coding.DataShardsCount {
+	if len(copiedShardIds)+len(localShardIds) >= locations.ECConfig.DataShards {
 		return copiedShardIds, localShardIds, nil
 	}
 
@@ -251,8 +251,13 @@ func prepareDataToRecover(commandEnv *CommandEnv, rebuilder *EcNode, collection
 
 }
 
-type EcShardMap map[needle.VolumeId]EcShardLocations
-type EcShardLocations [][]*EcNode
+type EcShardMap map[needle.VolumeId]*EcShardLocations
+
+// EcShardLocations has the nodes of each shard of the ec volume's stripe
+type EcShardLocations struct {
+	ECConfig  erasure_coding.ECConfig
+	Locations [][]*EcNode
+}
 
 func (ecShardMap EcShardMap) registerEcNode(ecNode *EcNode, collection string) {
 	for _, diskInfo := range ecNode.info.DiskInfos {
@@ -260,19 +265,25 @@ func (ecShardMap EcShardMap) registerEcNode(ecNode *EcNode, collection string) {
 			if shardInfo.Collection == collection {
 				existing, found := ecShardMap[needle.VolumeId(shardInfo.Id)]
 				if !found {
-					existing = make([][]*EcNode, erasure_coding.TotalShardsCount)
+					ecConfig := erasure_coding.NewECConfig(shardInfo.DataShards, shardInfo.ParityShards)
+					existing = &EcShardLocations{
+						ECConfig:  ecConfig,
+						Locations: make([][]*EcNode, ecConfig.TotalShards()),
+					}
 					ecShardMap[needle.VolumeId(shardInfo.Id)] = existing
 				}
 				for _, shardId := range erasure_coding.ShardBits(shardInfo.EcIndexBits).ShardIds() {
-					existing[shardId] = append(existing[shardId], ecNode)
+					if int(shardId) < len(existing.Locations) {
+						existing.Locations[shardId] = append(existing.Locations[shardId], ecNode)
+					}
 				}
 			}
 		}
 	}
 }
 
-func (ecShardLocations EcShardLocations) shardCount() (count int) {
-	for _, locations := range ecShardLocations {
+func (ecShardLocations *EcShardLocations) shardCount() (count int) {
+	for _, locations := range ecShardLocations.Locations {
 		if len(locations) > 0 {
 			count++
 		}
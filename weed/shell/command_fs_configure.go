@@ -2,7 +2,6 @@ package shell
 
 import (
 	"bytes"
-	"flag"
 	"fmt"
 	"io"
 	"regexp"
@@ -18,6 +17,7 @@ func init() {
 }
 
 type commandFsConfigure struct {
+	flagCommand
 }
 
 func (c *commandFsConfigure) Name() string {
@@ -48,7 +48,7 @@ func (c *commandFsConfigure) Help() string {
 
 func (c *commandFsConfigure) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	fsConfigureCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	fsConfigureCommand := newFlagSet(c.Name(), commandEnv)
 	locationPrefix := fsConfigureCommand.String("locationPrefix", "", "path prefix, required to update the path-specific configuration")
 	collection := fsConfigureCommand.String("collection", "", "assign writes to this collection")
 	replication := fsConfigureCommand.String("replication", "", "assign writes with this replication")
@@ -56,6 +56,7 @@ func (c *commandFsConfigure) Do(args []string, commandEnv *CommandEnv, writer io
 	diskType := fsConfigureCommand.String("disk", "", "[hdd|ssd|<tag>] hard drive or solid state drive or any tag")
 	fsync := fsConfigureCommand.Bool("fsync", false, "fsync for the writes")
 	isReadOnly := fsConfigureCommand.Bool("readOnly", false, "disable writes")
+	dedup := fsConfigureCommand.Bool("dedup", false, "reuse the chunks with the same content, for the mount writes to this collection")
 	dataCenter := fsConfigureCommand.String("dataCenter", "", "assign writes to this dataCenter")
 	rack := fsConfigureCommand.String("rack", "", "assign writes to this rack")
 	dataNode := fsConfigureCommand.String("dataNode", "", "assign writes to this dataNode")
@@ -85,6 +86,7 @@ func (c *commandFsConfigure) Do(args []string, commandEnv *CommandEnv, writer io
 			DataCenter:        *dataCenter,
 			Rack:              *rack,
 			DataNode:          *dataNode,
+			Dedup:             *dedup,
 		}
 
 		// check collection
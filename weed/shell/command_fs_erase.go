@@ -0,0 +1,96 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsErase{})
+}
+
+type commandFsErase struct {
+	flagCommand
+}
+
+func (c *commandFsErase) Name() string {
+	return "fs.erase"
+}
+
+func (c *commandFsErase) Help() string {
+	return `overwrite the data of a file with zeros, and then delete the file
+
+	fs.erase [-verify] /buckets/dir/file
+
+	The filer overwrites every chunk of the file with zeros of the same size. With -verify, it reads back
+	every replica of every chunk, and keeps the file if any replica is not zeroed, so the erasure can be retried.
+	The report printed is the audit trail of the erasure.
+
+	The volume servers append the zeros as a new version of each chunk, so run "volume.vacuum -volumeId"
+	on the reported volumes to also drop the old bytes from disk.
+
+`
+}
+
+func (c *commandFsErase) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	fsEraseCommand := newFlagSet(c.Name(), commandEnv)
+	verify := fsEraseCommand.Bool("verify", false, "read back every replica of every chunk before deleting the file")
+	if err = fsEraseCommand.Parse(args); err != nil {
+		return nil
+	}
+	if fsEraseCommand.NArg() != 1 {
+		return fmt.Errorf("need the file path")
+	}
+
+	path, err := commandEnv.parseUrl(fsEraseCommand.Arg(0))
+	if err != nil {
+		return err
+	}
+	dir, name := util.FullPath(path).DirAndName()
+
+	var resp *filer_pb.EraseEntryResponse
+	err = commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		resp, err = client.EraseEntry(context.Background(), &filer_pb.EraseEntryRequest{
+			Directory: dir,
+			Name:      name,
+			Verify:    *verify,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(writer, "erase %s\n", path)
+	fmt.Fprintf(writer, "  started %v, ended %v\n", time.Unix(0, resp.StartedAtNs).UTC(), time.Unix(0, resp.EndedAtNs).UTC())
+	for _, chunk := range resp.Chunks {
+		fmt.Fprintf(writer, "  chunk %s size %d overwritten %v", chunk.FileId, chunk.Size, chunk.Overwritten)
+		if chunk.Error != "" {
+			fmt.Fprintf(writer, " error: %s", chunk.Error)
+		}
+		fmt.Fprintln(writer)
+		var urls []string
+		for url := range chunk.Replicas {
+			urls = append(urls, url)
+		}
+		sort.Strings(urls)
+		for _, url := range urls {
+			fmt.Fprintf(writer, "    replica %s: %s\n", url, chunk.Replicas[url])
+		}
+	}
+	fmt.Fprintf(writer, "  volumes to vacuum: %v\n", resp.VolumeIds)
+	if *verify {
+		fmt.Fprintf(writer, "  verified: %v\n", resp.Verified)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("erase %s: %s", path, resp.Error)
+	}
+	return nil
+}
@@ -0,0 +1,500 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandFsFsck{})
+}
+
+// FsckLostFoundDir keeps the entries created by "fs.fsck -fix" for the chunks not referenced by any entry
+const FsckLostFoundDir = "/lost+found"
+
+type commandFsFsck struct {
+	flagCommand
+}
+
+func (c *commandFsFsck) Name() string {
+	return "fs.fsck"
+}
+
+func (c *commandFsFsck) Help() string {
+	return `check the filer entries against the volumes, and optionally repair them
+
+	fs.fsck                        # print what would be repaired
+	fs.fsck -fix                   # repair
+	fs.fsck -fix -report=fsck.log  # also append the repairs to a report file
+
+	The whole filer namespace is scanned, and these are checked:
+	1. the chunks on volumes unknown to the master, which are lost, or on volume servers offline.
+	   Only with -removeChunksOnLostVolumes, the repair removes the chunks from the entry,
+	   and updates the file size to the end of the remaining chunks.
+	2. the chunks on volumes not referenced by any entry, found as in "volume.fsck".
+	   The repair creates an entry for each chunk in ` + FsckLostFoundDir + `, named by the file id.
+	   The chunks dropped by the entries changed within -pendingDeletionWindow may be still queued
+	   for deletion by the filer, and are skipped.
+	3. the hard link counters not matching the number of entries sharing the hard link.
+	   The repair updates the counters.
+
+	-apply is the same as -fix. Only chunks written before -cutoffTimeAgo count as not referenced.
+
+`
+}
+
+type fsckHardLink struct {
+	counter int32
+	paths   []util.FullPath
+	entry   *filer_pb.Entry
+}
+
+type fsckMissingChunks struct {
+	path   util.FullPath
+	entry  *filer_pb.Entry
+	chunks []*filer_pb.FileChunk
+}
+
+// fsckReport prints each repair, and appends it to the report file if any
+type fsckReport struct {
+	writer io.Writer
+	file   *os.File
+	fix    bool
+	count  int
+}
+
+func (r *fsckReport) repair(format string, args ...interface{}) {
+	r.count++
+	line := fmt.Sprintf(format, args...)
+	if !r.fix {
+		line = "would " + line
+	}
+	fmt.Fprintln(r.writer, line)
+	if r.file != nil {
+		fmt.Fprintf(r.file, "%s %s\n", time.Now().Format(time.RFC3339), line)
+	}
+}
+
+func (c *commandFsFsck) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	fsckCommand := newFlagSet(c.Name(), commandEnv)
+	isVerbose := fsckCommand.Bool("v", false, "print all hard links, not only the mismatched ones")
+	fix := fsckCommand.Bool("fix", false, "repair the entries, instead of only printing what would be repaired")
+	applyFix := fsckCommand.Bool("apply", false, "same as -fix")
+	reportFile := fsckCommand.String("report", "", "append the repairs to this file")
+	tempPath := fsckCommand.String("tempPath", os.TempDir(), "path for temporary idx files")
+	cutoffTimeAgo := fsckCommand.Duration("cutoffTimeAgo", 5*time.Minute, "only check the chunks written on volume servers before this cutoff time")
+	removeLost := fsckCommand.Bool("removeChunksOnLostVolumes", false, "remove the chunks on the volumes unknown to the master. Make sure all volume servers are online!")
+	pendingDeletionWindow := fsckCommand.Duration("pendingDeletionWindow", time.Hour, "skip the unreferenced chunks dropped by the entries changed within this time")
+	if err = fsckCommand.Parse(args); err != nil {
+		return nil
+	}
+	if err = commandEnv.confirmIsLocked(args); err != nil {
+		return
+	}
+
+	report := &fsckReport{writer: writer, fix: *fix || *applyFix}
+	if *reportFile != "" {
+		if report.file, err = os.OpenFile(*reportFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644); err != nil {
+			return fmt.Errorf("open report file: %v", err)
+		}
+		defer report.file.Close()
+	}
+
+	// the volumes, and the chunks not referenced by any entry
+	volumeFsck := &commandVolumeFsck{
+		env:                      commandEnv,
+		writer:                   writer,
+		collection:               new(string),
+		volumeIds:                make(map[uint32]bool),
+		verbose:                  new(bool),
+		forcePurging:             new(bool),
+		findMissingChunksInFiler: new(bool),
+		verifyNeedle:             new(bool),
+	}
+	if volumeFsck.tempFolder, err = os.MkdirTemp(*tempPath, "sw_fsck"); err != nil {
+		return fmt.Errorf("failed to create temp folder: %v", err)
+	}
+	defer os.RemoveAll(volumeFsck.tempFolder)
+	now := time.Now()
+	volumes, orphanFileIds, err := c.findOrphanChunks(volumeFsck, now.Add(-*cutoffTimeAgo))
+	if err != nil {
+		return err
+	}
+	pendingDeletion, err := c.findDroppedChunks(commandEnv, now.Add(-*pendingDeletionWindow), now)
+	if err != nil {
+		return fmt.Errorf("read the recently dropped chunks: %v", err)
+	}
+	orphanFileIds = skipPendingDeletion(orphanFileIds, pendingDeletion)
+
+	var lock sync.Mutex
+	hardLinks := make(map[string]*fsckHardLink)
+	var missingChunks []*fsckMissingChunks
+	err = filer_pb.TraverseBfs(commandEnv, "/", func(parentPath util.FullPath, entry *filer_pb.Entry) {
+		var missing []*filer_pb.FileChunk
+		for _, chunk := range entry.GetChunks() {
+			fid, parseErr := needle.ParseFileIdFromString(chunk.GetFileIdString())
+			if parseErr == nil && !volumes[uint32(fid.VolumeId)] {
+				missing = append(missing, chunk)
+			}
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		if len(missing) > 0 {
+			missingChunks = append(missingChunks, &fsckMissingChunks{
+				path:   parentPath.Child(entry.Name),
+				entry:  entry,
+				chunks: missing,
+			})
+		}
+		if len(entry.HardLinkId) == 0 {
+			return
+		}
+		hardLink, found := hardLinks[string(entry.HardLinkId)]
+		if !found {
+			hardLink = &fsckHardLink{
+				counter: entry.HardLinkCounter,
+				entry:   entry,
+			}
+			hardLinks[string(entry.HardLinkId)] = hardLink
+		}
+		hardLink.paths = append(hardLink.paths, parentPath.Child(entry.Name))
+	})
+	if err != nil {
+		return fmt.Errorf("traverse: %v", err)
+	}
+
+	// the volumes not in the topology may still be known to the master, and are only lost if not
+	lost, err := c.findLostVolumes(commandEnv, missingChunks)
+	if err != nil {
+		return fmt.Errorf("look up the volumes not in the topology: %v", err)
+	}
+	missingChunks = chunksOnLostVolumes(missingChunks, lost)
+	sort.Slice(missingChunks, func(i, j int) bool {
+		return missingChunks[i].path < missingChunks[j].path
+	})
+	c.reportMissingChunks(commandEnv, report, missingChunks, *removeLost)
+
+	c.recoverOrphanChunks(commandEnv, report, orphanFileIds)
+
+	var mismatched int
+	for hardLinkId, hardLink := range hardLinks {
+		sort.Slice(hardLink.paths, func(i, j int) bool {
+			return hardLink.paths[i] < hardLink.paths[j]
+		})
+		count := int32(len(hardLink.paths))
+		if hardLink.counter == count {
+			if *isVerbose {
+				fmt.Fprintf(writer, "hard link %x: %d entries %v\n", hardLinkId, count, hardLink.paths)
+			}
+			continue
+		}
+		mismatched++
+		report.repair("set hard link %x counter %d to %d entries %v", hardLinkId, hardLink.counter, count, hardLink.paths)
+		if !report.fix {
+			continue
+		}
+		if err := c.fixHardLinkCounter(commandEnv, hardLink.paths[0], hardLink.entry, count); err != nil {
+			fmt.Fprintf(writer, "  fix %s: %v\n", hardLink.paths[0], err)
+		}
+	}
+
+	fmt.Fprintf(writer, "checked %d hard links, %d mismatched; %d entries with chunks on lost volumes; %d chunks not referenced\n",
+		len(hardLinks), mismatched, len(missingChunks), len(orphanFileIds))
+	if report.fix {
+		fmt.Fprintf(writer, "%d repairs\n", report.count)
+	}
+
+	return nil
+}
+
+// findOrphanChunks returns the existing volumes, and the chunks written before the cutoff time
+// but not referenced by any entry, on any replica of the volume.
+func (c *commandFsFsck) findOrphanChunks(volumeFsck *commandVolumeFsck, cutoff time.Time) (volumes map[uint32]bool, orphanFileIds []string, err error) {
+	dataNodeVolumeIdToVInfo, err := volumeFsck.collectVolumeIds()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to collect all volume locations: %v", err)
+	}
+	volumes = make(map[uint32]bool)
+	for dataNodeId, volumeIdToVInfo := range dataNodeVolumeIdToVInfo {
+		for volumeId, vinfo := range volumeIdToVInfo {
+			volumes[volumeId] = true
+			if err = volumeFsck.collectOneVolumeFileIds(dataNodeId, volumeId, vinfo, uint64(cutoff.UnixNano())); err != nil {
+				return nil, nil, fmt.Errorf("failed to collect file ids from volume %d on %s: %v", volumeId, vinfo.server, err)
+			}
+		}
+	}
+	if err = volumeFsck.collectFilerFileIdAndPaths(dataNodeVolumeIdToVInfo, false, 0); err != nil {
+		return nil, nil, fmt.Errorf("failed to collect file ids from filer: %v", err)
+	}
+
+	orphans := make(map[string]bool)
+	for dataNodeId, volumeIdToVInfo := range dataNodeVolumeIdToVInfo {
+		for volumeId, vinfo := range volumeIdToVInfo {
+			_, fileIds, _, checkErr := volumeFsck.oneVolumeFileIdsSubtractFilerFileIds(dataNodeId, volumeId, &vinfo)
+			if checkErr != nil {
+				return nil, nil, fmt.Errorf("failed to collect file ids from volume %d on %s: %v", volumeId, vinfo.server, checkErr)
+			}
+			for _, fileId := range fileIds {
+				orphans[fileId] = true
+			}
+		}
+	}
+	for fileId := range orphans {
+		orphanFileIds = append(orphanFileIds, fileId)
+	}
+	sort.Strings(orphanFileIds)
+	return volumes, orphanFileIds, nil
+}
+
+// findLostVolumes looks up the volumes of the missing chunks, and returns the ones the master does not know at all
+func (c *commandFsFsck) findLostVolumes(commandEnv *CommandEnv, missingChunks []*fsckMissingChunks) (map[uint32]bool, error) {
+	candidates := make(map[string]bool)
+	for _, m := range missingChunks {
+		for _, chunk := range m.chunks {
+			if fid, err := needle.ParseFileIdFromString(chunk.GetFileIdString()); err == nil {
+				candidates[fid.VolumeId.String()] = true
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	var volumeIds []string
+	for volumeId := range candidates {
+		volumeIds = append(volumeIds, volumeId)
+	}
+	volumeIdLocations, err := lookupVolumeIds(commandEnv, volumeIds)
+	if err != nil {
+		return nil, err
+	}
+	return lostVolumesOf(volumeIdLocations), nil
+}
+
+// lostVolumesOf returns the volumes looked up without any location
+func lostVolumesOf(volumeIdLocations []*master_pb.LookupVolumeResponse_VolumeIdLocation) map[uint32]bool {
+	lost := make(map[uint32]bool)
+	for _, loc := range volumeIdLocations {
+		if len(loc.Locations) > 0 {
+			continue
+		}
+		if volumeId, err := needle.NewVolumeId(loc.VolumeOrFileId); err == nil {
+			lost[uint32(volumeId)] = true
+		}
+	}
+	return lost
+}
+
+// chunksOnLostVolumes keeps the chunks on the lost volumes, and the entries having any
+func chunksOnLostVolumes(missingChunks []*fsckMissingChunks, lost map[uint32]bool) (onLost []*fsckMissingChunks) {
+	for _, m := range missingChunks {
+		var chunks []*filer_pb.FileChunk
+		for _, chunk := range m.chunks {
+			if fid, err := needle.ParseFileIdFromString(chunk.GetFileIdString()); err == nil && lost[uint32(fid.VolumeId)] {
+				chunks = append(chunks, chunk)
+			}
+		}
+		if len(chunks) > 0 {
+			onLost = append(onLost, &fsckMissingChunks{path: m.path, entry: m.entry, chunks: chunks})
+		}
+	}
+	return
+}
+
+// reportMissingChunks only removes the chunks on the lost volumes if asked explicitly,
+// since the volumes on the volume servers offline are unknown to the master as well
+func (c *commandFsFsck) reportMissingChunks(commandEnv *CommandEnv, report *fsckReport, missingChunks []*fsckMissingChunks, removeLost bool) {
+	if !removeLost {
+		for _, m := range missingChunks {
+			var fileIds []string
+			for _, chunk := range m.chunks {
+				fileIds = append(fileIds, chunk.GetFileIdString())
+			}
+			fmt.Fprintf(report.writer, "%s has chunks %v on volumes unknown to the master\n", m.path, fileIds)
+		}
+		if len(missingChunks) > 0 {
+			fmt.Fprintf(report.writer, "make sure all volume servers are online, and add -removeChunksOnLostVolumes to remove the chunks\n")
+		}
+		return
+	}
+	for _, m := range missingChunks {
+		c.removeMissingChunks(commandEnv, report, m)
+	}
+}
+
+// findDroppedChunks reads the metadata changes in the time range,
+// and returns the chunks dropped by the changed or deleted entries
+func (c *commandFsFsck) findDroppedChunks(commandEnv *CommandEnv, since, until time.Time) (map[string]bool, error) {
+	dropped := make(map[string]bool)
+	err := pb.WithFilerClientFollowMetadata(commandEnv, &pb.MetadataFollowOption{
+		ClientName:     "shell:fs.fsck",
+		ClientId:       util.RandomInt32(),
+		PathPrefix:     "/",
+		StartTsNs:      since.UnixNano(),
+		StopTsNs:       until.UnixNano(),
+		EventErrorType: pb.TrivialOnError,
+	}, func(resp *filer_pb.SubscribeMetadataResponse) error {
+		for _, fileId := range droppedFileIds(resp.EventNotification) {
+			dropped[fileId] = true
+		}
+		return nil
+	})
+	return dropped, err
+}
+
+// droppedFileIds returns the chunks of the old entry not in the new entry
+func droppedFileIds(event *filer_pb.EventNotification) (fileIds []string) {
+	if event.OldEntry == nil {
+		return nil
+	}
+	kept := make(map[string]bool)
+	for _, chunk := range event.NewEntry.GetChunks() {
+		kept[chunk.GetFileIdString()] = true
+	}
+	for _, chunk := range event.OldEntry.GetChunks() {
+		if fileId := chunk.GetFileIdString(); !kept[fileId] {
+			fileIds = append(fileIds, fileId)
+		}
+	}
+	return
+}
+
+func skipPendingDeletion(orphanFileIds []string, pendingDeletion map[string]bool) (recoverable []string) {
+	for _, fileId := range orphanFileIds {
+		if !pendingDeletion[fileId] {
+			recoverable = append(recoverable, fileId)
+		}
+	}
+	return
+}
+
+// removeMissingChunks drops the chunks on missing volumes, and shortens the file to the end of the remaining chunks.
+func (c *commandFsFsck) removeMissingChunks(commandEnv *CommandEnv, report *fsckReport, m *fsckMissingChunks) {
+	missing := make(map[*filer_pb.FileChunk]bool, len(m.chunks))
+	var fileIds []string
+	for _, chunk := range m.chunks {
+		missing[chunk] = true
+		fileIds = append(fileIds, chunk.GetFileIdString())
+	}
+	var remaining []*filer_pb.FileChunk
+	for _, chunk := range m.entry.GetChunks() {
+		if !missing[chunk] {
+			remaining = append(remaining, chunk)
+		}
+	}
+	fileSize := filer.TotalSize(remaining)
+	report.repair("remove chunks %v on lost volumes from %s, and set its size %d to %d", fileIds, m.path, filer.FileSize(m.entry), fileSize)
+	if !report.fix {
+		return
+	}
+
+	dir, _ := m.path.DirAndName()
+	err := commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		m.entry.Chunks = remaining
+		if m.entry.Attributes != nil {
+			m.entry.Attributes.FileSize = fileSize
+		}
+		return filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory: dir,
+			Entry:     m.entry,
+		})
+	})
+	if err != nil {
+		fmt.Fprintf(report.writer, "  fix %s: %v\n", m.path, err)
+	}
+}
+
+// recoverOrphanChunks creates an entry in FsckLostFoundDir for each chunk not referenced by any entry,
+// with the size of the content served by the volume server.
+func (c *commandFsFsck) recoverOrphanChunks(commandEnv *CommandEnv, report *fsckReport, orphanFileIds []string) {
+	for _, fileId := range orphanFileIds {
+		p := util.FullPath(FsckLostFoundDir).Child(fileId)
+		if !report.fix {
+			report.repair("create %s for the chunk not referenced by any entry", p)
+			continue
+		}
+		size, err := c.chunkSize(commandEnv, fileId)
+		if err != nil {
+			fmt.Fprintf(report.writer, "read chunk %s: %v\n", fileId, err)
+			continue
+		}
+		report.repair("create %s for the chunk not referenced by any entry, of %d bytes", p, size)
+		now := time.Now()
+		err = commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+			return filer_pb.CreateEntry(client, &filer_pb.CreateEntryRequest{
+				Directory: FsckLostFoundDir,
+				Entry: &filer_pb.Entry{
+					Name: fileId,
+					Attributes: &filer_pb.FuseAttributes{
+						Mtime:    now.Unix(),
+						Crtime:   now.Unix(),
+						FileMode: 0644,
+						FileSize: size,
+					},
+					Chunks: []*filer_pb.FileChunk{{
+						FileId:       fileId,
+						Size:         size,
+						ModifiedTsNs: now.UnixNano(),
+					}},
+				},
+			})
+		})
+		if err != nil {
+			fmt.Fprintf(report.writer, "  fix %s: %v\n", p, err)
+		}
+	}
+}
+
+// chunkSize asks a volume server for the uncompressed size of the chunk
+func (c *commandFsFsck) chunkSize(commandEnv *CommandEnv, fileId string) (uint64, error) {
+	urls, err := commandEnv.MasterClient.LookupFileIdWithFallback(fileId)
+	if err != nil {
+		return 0, err
+	}
+	if len(urls) == 0 {
+		return 0, fmt.Errorf("no location")
+	}
+	req, err := http.NewRequest(http.MethodHead, urls[0], nil)
+	if err != nil {
+		return 0, err
+	}
+	// the compressed chunks are served uncompressed, as the filer reads them
+	req.Header.Set("Accept-Encoding", "identity")
+	resp, err := util.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer util.CloseResponse(resp)
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%s: %s", urls[0], resp.Status)
+	}
+	if resp.ContentLength < 0 {
+		return 0, fmt.Errorf("%s: unknown size", urls[0])
+	}
+	return uint64(resp.ContentLength), nil
+}
+
+// fixHardLinkCounter updates one of the entries, which saves the counter shared by all entries of the hard link.
+func (c *commandFsFsck) fixHardLinkCounter(commandEnv *CommandEnv, p util.FullPath, entry *filer_pb.Entry, count int32) error {
+	dir, _ := p.DirAndName()
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		entry.HardLinkCounter = count
+		return filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory: dir,
+			Entry:     entry,
+		})
+	})
+}
@@ -0,0 +1,94 @@
+package shell
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+)
+
+func TestVolumeIdToServerOfAllDisks(t *testing.T) {
+	topo := &master_pb.TopologyInfo{
+		DataCenterInfos: []*master_pb.DataCenterInfo{{
+			Id: "dc1",
+			RackInfos: []*master_pb.RackInfo{{
+				Id: "rack1",
+				DataNodeInfos: []*master_pb.DataNodeInfo{{
+					Id: "127.0.0.1:8080",
+					DiskInfos: map[string]*master_pb.DiskInfo{
+						"":    {VolumeInfos: []*master_pb.VolumeInformationMessage{{Id: 1}, {Id: 2}}},
+						"ssd": {VolumeInfos: []*master_pb.VolumeInformationMessage{{Id: 3}}, EcShardInfos: []*master_pb.VolumeEcShardInformationMessage{{Id: 4}}},
+					},
+				}},
+			}},
+		}},
+	}
+
+	volumes := volumeIdToServerOf(topo)["127.0.0.1:8080"]
+	assert.Equal(t, 4, len(volumes), "the volumes of all disk types")
+	assert.True(t, volumes[4].isEcVolume)
+}
+
+func TestLostVolumes(t *testing.T) {
+	lost := lostVolumesOf([]*master_pb.LookupVolumeResponse_VolumeIdLocation{
+		{VolumeOrFileId: "1", Locations: []*master_pb.Location{{Url: "127.0.0.1:8080"}}},
+		{VolumeOrFileId: "2", Error: "volumeId 2 not found"},
+	})
+	assert.Equal(t, map[uint32]bool{2: true}, lost, "only the volumes the master does not know")
+
+	entry := &filer_pb.Entry{Name: "file", Chunks: []*filer_pb.FileChunk{{FileId: "1,01637037d6"}, {FileId: "2,02637037d6"}}}
+	onLost := chunksOnLostVolumes([]*fsckMissingChunks{
+		{path: "/dir/file", entry: entry, chunks: entry.Chunks},
+		{path: "/dir/other", entry: entry, chunks: entry.Chunks[:1]},
+	}, lost)
+	assert.Equal(t, 1, len(onLost))
+	assert.Equal(t, []*filer_pb.FileChunk{entry.Chunks[1]}, onLost[0].chunks)
+}
+
+func TestReportMissingChunksNeedsFlag(t *testing.T) {
+	entry := &filer_pb.Entry{Name: "file", Chunks: []*filer_pb.FileChunk{{FileId: "2,02637037d6", Size: 10}}}
+	missingChunks := []*fsckMissingChunks{{path: "/dir/file", entry: entry, chunks: entry.Chunks}}
+	c := &commandFsFsck{}
+
+	var out bytes.Buffer
+	report := &fsckReport{writer: &out}
+	c.reportMissingChunks(nil, report, missingChunks, false)
+	assert.Equal(t, 0, report.count, "not repaired without -removeChunksOnLostVolumes")
+	assert.Contains(t, out.String(), "-removeChunksOnLostVolumes")
+
+	out.Reset()
+	c.reportMissingChunks(nil, report, missingChunks, true)
+	assert.Equal(t, 1, report.count)
+	assert.True(t, strings.HasPrefix(out.String(), "would remove chunks [2,02637037d6]"), out.String())
+}
+
+func TestSkipPendingDeletion(t *testing.T) {
+	chunk := func(fileId string) *filer_pb.FileChunk {
+		return &filer_pb.FileChunk{FileId: fileId}
+	}
+	deleted := &filer_pb.EventNotification{
+		OldEntry: &filer_pb.Entry{Name: "deleted", Chunks: []*filer_pb.FileChunk{chunk("1,01"), chunk("1,02")}},
+	}
+	overwritten := &filer_pb.EventNotification{
+		OldEntry: &filer_pb.Entry{Name: "overwritten", Chunks: []*filer_pb.FileChunk{chunk("1,03"), chunk("1,04")}},
+		NewEntry: &filer_pb.Entry{Name: "overwritten", Chunks: []*filer_pb.FileChunk{chunk("1,04"), chunk("1,05")}},
+	}
+	created := &filer_pb.EventNotification{
+		NewEntry: &filer_pb.Entry{Name: "created", Chunks: []*filer_pb.FileChunk{chunk("1,06")}},
+	}
+	assert.Equal(t, []string{"1,01", "1,02"}, droppedFileIds(deleted))
+	assert.Equal(t, []string{"1,03"}, droppedFileIds(overwritten))
+	assert.Empty(t, droppedFileIds(created))
+
+	pendingDeletion := make(map[string]bool)
+	for _, event := range []*filer_pb.EventNotification{deleted, overwritten, created} {
+		for _, fileId := range droppedFileIds(event) {
+			pendingDeletion[fileId] = true
+		}
+	}
+	assert.Equal(t, []string{"1,07"}, skipPendingDeletion([]string{"1,01", "1,03", "1,07"}, pendingDeletion))
+}
@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
@@ -17,6 +16,7 @@ func init() {
 }
 
 type commandFsMetaChangeVolumeId struct {
+	flagCommand
 }
 
 func (c *commandFsMetaChangeVolumeId) Name() string {
@@ -39,7 +39,7 @@ func (c *commandFsMetaChangeVolumeId) Help() string {
 
 func (c *commandFsMetaChangeVolumeId) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	fsMetaChangeVolumeIdCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	fsMetaChangeVolumeIdCommand := newFlagSet(c.Name(), commandEnv)
 	dir := fsMetaChangeVolumeIdCommand.String("dir", "/", "fix all metadata under this folder")
 	mappingFileName := fsMetaChangeVolumeIdCommand.String("mapping", "", "a file with multiple volume id changes, with each line as x=>y")
 	fromVolumeId := fsMetaChangeVolumeIdCommand.Uint("fromVolumeId", 0, "change metadata with this volume id")
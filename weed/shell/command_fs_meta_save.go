@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"io"
@@ -23,6 +22,7 @@ func init() {
 }
 
 type commandFsMetaSave struct {
+	flagCommand
 }
 
 func (c *commandFsMetaSave) Name() string {
@@ -46,7 +46,7 @@ func (c *commandFsMetaSave) Help() string {
 
 func (c *commandFsMetaSave) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	fsMetaSaveCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	fsMetaSaveCommand := newFlagSet(c.Name(), commandEnv)
 	verbose := fsMetaSaveCommand.Bool("v", false, "print out each processed files")
 	outputFileName := fsMetaSaveCommand.String("o", "", "output the meta data to this file")
 	isObfuscate := fsMetaSaveCommand.Bool("obfuscate", false, "obfuscate the file names")
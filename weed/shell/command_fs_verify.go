@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
@@ -25,6 +24,7 @@ func init() {
 }
 
 type commandFsVerify struct {
+	flagCommand
 	env                *CommandEnv
 	volumeServers      []pb.ServerAddress
 	volumeIds          map[uint32][]pb.ServerAddress
@@ -51,7 +51,7 @@ func (c *commandFsVerify) Help() string {
 func (c *commandFsVerify) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 	c.env = commandEnv
 	c.writer = writer
-	fsVerifyCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	fsVerifyCommand := newFlagSet(c.Name(), commandEnv)
 	c.verbose = fsVerifyCommand.Bool("v", false, "print out each processed files")
 	modifyTimeAgo := fsVerifyCommand.Duration("modifyTimeAgo", 0, "only include files after this modify time to verify")
 	c.concurrency = fsVerifyCommand.Int("concurrency", 0, "number of parallel verification per volume server")
@@ -0,0 +1,216 @@
+package shell
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandMetaDiff{})
+}
+
+const (
+	DiffOnlyInFiler1 = "only_in_filer1"
+	DiffOnlyInFiler2 = "only_in_filer2"
+	DiffChanged      = "different"
+)
+
+type commandMetaDiff struct {
+	flagCommand
+}
+
+// DiffRecord is one difference found by meta.diff
+type DiffRecord struct {
+	Type       string         `json:"type"`
+	Path       string         `json:"path"`
+	Filer1Attr *DiffEntryAttr `json:"filer1_attr,omitempty"`
+	Filer2Attr *DiffEntryAttr `json:"filer2_attr,omitempty"`
+}
+
+type DiffEntryAttr struct {
+	IsDirectory bool   `json:"is_directory"`
+	Size        uint64 `json:"size"`
+	Md5         string `json:"md5,omitempty"`
+	Mtime       int64  `json:"mtime"`
+}
+
+func (c *commandMetaDiff) Name() string {
+	return "meta.diff"
+}
+
+func (c *commandMetaDiff) Help() string {
+	return `compare the meta data of two filers under a directory
+
+	meta.diff [-concurrency=8] <filer1_host:port> <filer2_host:port> <path>
+
+	meta.diff localhost:8888 remote:8888 /buckets
+
+	Both filers are walked at the same time. Each difference is printed as one json line:
+		{"type":"only_in_filer1|only_in_filer2|different","path":...,"filer1_attr":...,"filer2_attr":...}
+	The entries are different if their md5 or size are different, or one is a file and the other a directory.
+	A directory only on one filer is reported once, without its sub entries.
+	This is useful to verify the cross data center replication, or a migration between filer stores.
+
+`
+}
+
+func (c *commandMetaDiff) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	metaDiffCommand := newFlagSet(c.Name(), commandEnv)
+	concurrency := metaDiffCommand.Int("concurrency", 8, "number of directories listed in parallel")
+	if err = metaDiffCommand.Parse(args); err != nil {
+		return err
+	}
+	if metaDiffCommand.NArg() != 3 {
+		return fmt.Errorf("need to have <filer1_host:port> <filer2_host:port> <path>")
+	}
+	if *concurrency <= 0 {
+		return fmt.Errorf("concurrency should be positive")
+	}
+
+	path, parseErr := commandEnv.parseUrl(metaDiffCommand.Arg(2))
+	if parseErr != nil {
+		return parseErr
+	}
+
+	differ := &metaDiffer{
+		filers:         [2]pb.ServerAddress{pb.ServerAddress(metaDiffCommand.Arg(0)), pb.ServerAddress(metaDiffCommand.Arg(1))},
+		grpcDialOption: commandEnv.option.GrpcDialOption,
+		encoder:        json.NewEncoder(writer),
+		limiter:        make(chan struct{}, *concurrency),
+	}
+	differ.compareDirectory(util.FullPath(path))
+	differ.wg.Wait()
+	return differ.err
+}
+
+type metaDiffer struct {
+	filers         [2]pb.ServerAddress
+	grpcDialOption grpc.DialOption
+	limiter        chan struct{}
+	wg             sync.WaitGroup
+
+	sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+func (d *metaDiffer) compareDirectory(dir util.FullPath) {
+	if d.hasError() {
+		return
+	}
+
+	var entries [2][]*filer_pb.Entry
+	var errs [2]error
+	var listWg sync.WaitGroup
+	d.limiter <- struct{}{}
+	for i := range d.filers {
+		listWg.Add(1)
+		go func(i int) {
+			defer listWg.Done()
+			entries[i], errs[i] = d.listDirectory(d.filers[i], dir)
+		}(i)
+	}
+	listWg.Wait()
+	<-d.limiter
+
+	for i, err := range errs {
+		if err != nil {
+			d.setError(fmt.Errorf("list %s on %s: %v", dir, d.filers[i], err))
+			return
+		}
+	}
+
+	diffEntries(dir, entries[0], entries[1], d.report, func(subDir util.FullPath) {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.compareDirectory(subDir)
+		}()
+	})
+}
+
+func (d *metaDiffer) listDirectory(filerAddress pb.ServerAddress, dir util.FullPath) (entries []*filer_pb.Entry, err error) {
+	err = pb.WithFilerClient(false, 0, filerAddress, d.grpcDialOption, func(client filer_pb.SeaweedFilerClient) error {
+		return filer_pb.SeaweedList(client, string(dir), "", func(entry *filer_pb.Entry, isLast bool) error {
+			entries = append(entries, entry)
+			return nil
+		}, "", false, math.MaxUint32)
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return
+}
+
+func (d *metaDiffer) report(record *DiffRecord) {
+	d.Lock()
+	defer d.Unlock()
+	if d.err == nil {
+		d.err = d.encoder.Encode(record)
+	}
+}
+
+func (d *metaDiffer) hasError() bool {
+	d.Lock()
+	defer d.Unlock()
+	return d.err != nil
+}
+
+func (d *metaDiffer) setError(err error) {
+	d.Lock()
+	defer d.Unlock()
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+// diffEntries merges the two lists of entries sorted by name, reports the differences,
+// and descends into the directories on both sides
+func diffEntries(dir util.FullPath, entries1, entries2 []*filer_pb.Entry, report func(*DiffRecord), descend func(util.FullPath)) {
+	i, j := 0, 0
+	for i < len(entries1) || j < len(entries2) {
+		switch {
+		case j >= len(entries2) || i < len(entries1) && entries1[i].Name < entries2[j].Name:
+			report(&DiffRecord{Type: DiffOnlyInFiler1, Path: string(dir.Child(entries1[i].Name)), Filer1Attr: toDiffEntryAttr(entries1[i])})
+			i++
+		case i >= len(entries1) || entries1[i].Name > entries2[j].Name:
+			report(&DiffRecord{Type: DiffOnlyInFiler2, Path: string(dir.Child(entries2[j].Name)), Filer2Attr: toDiffEntryAttr(entries2[j])})
+			j++
+		default:
+			entry1, entry2 := entries1[i], entries2[j]
+			path := dir.Child(entry1.Name)
+			if entry1.IsDirectory && entry2.IsDirectory {
+				descend(path)
+			} else if entry1.IsDirectory != entry2.IsDirectory ||
+				filer.FileSize(entry1) != filer.FileSize(entry2) ||
+				!bytes.Equal(entry1.GetAttributes().GetMd5(), entry2.GetAttributes().GetMd5()) {
+				report(&DiffRecord{Type: DiffChanged, Path: string(path), Filer1Attr: toDiffEntryAttr(entry1), Filer2Attr: toDiffEntryAttr(entry2)})
+			}
+			i++
+			j++
+		}
+	}
+}
+
+func toDiffEntryAttr(entry *filer_pb.Entry) *DiffEntryAttr {
+	return &DiffEntryAttr{
+		IsDirectory: entry.IsDirectory,
+		Size:        filer.FileSize(entry),
+		Md5:         hex.EncodeToString(entry.GetAttributes().GetMd5()),
+		Mtime:       entry.GetAttributes().GetMtime(),
+	}
+}
@@ -0,0 +1,53 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestDiffEntries(t *testing.T) {
+	file := func(name string, size uint64, md5 string) *filer_pb.Entry {
+		return &filer_pb.Entry{Name: name, Attributes: &filer_pb.FuseAttributes{FileSize: size, Md5: []byte(md5)}}
+	}
+	dir := func(name string) *filer_pb.Entry {
+		return &filer_pb.Entry{Name: name, IsDirectory: true, Attributes: &filer_pb.FuseAttributes{}}
+	}
+
+	entries1 := []*filer_pb.Entry{file("a", 1, "x"), dir("b"), file("c", 2, "y"), file("d", 3, "z"), dir("e"), file("g", 1, "")}
+	entries2 := []*filer_pb.Entry{dir("b"), file("c", 2, "y"), file("d", 3, "w"), file("e", 1, ""), file("f", 1, ""), file("g", 2, "")}
+
+	var records []*DiffRecord
+	var descended []util.FullPath
+	diffEntries("/dir", entries1, entries2, func(record *DiffRecord) {
+		records = append(records, record)
+	}, func(subDir util.FullPath) {
+		descended = append(descended, subDir)
+	})
+
+	expected := []struct {
+		diffType string
+		path     string
+	}{
+		{DiffOnlyInFiler1, "/dir/a"},
+		{DiffChanged, "/dir/d"},
+		{DiffChanged, "/dir/e"},
+		{DiffOnlyInFiler2, "/dir/f"},
+		{DiffChanged, "/dir/g"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records, got %d", len(expected), len(records))
+	}
+	for i, e := range expected {
+		if records[i].Type != e.diffType || records[i].Path != e.path {
+			t.Errorf("record %d: expected %s %s, got %s %s", i, e.diffType, e.path, records[i].Type, records[i].Path)
+		}
+	}
+	if records[0].Filer2Attr != nil || records[3].Filer1Attr != nil {
+		t.Errorf("unexpected attributes of the missing entries")
+	}
+	if len(descended) != 1 || descended[0] != "/dir/b" {
+		t.Errorf("unexpected descended directories %v", descended)
+	}
+}
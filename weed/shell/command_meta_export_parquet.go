@@ -0,0 +1,153 @@
+package shell
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	parquet_writer "github.com/xitongsys/parquet-go/writer"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+func init() {
+	Commands = append(Commands, &commandMetaExportParquet{})
+}
+
+// parquetRowGroupSize is the number of entries in one parquet row group
+const parquetRowGroupSize = 100000
+
+type commandMetaExportParquet struct {
+	flagCommand
+}
+
+// parquetEntry is one row of the exported parquet file
+type parquetEntry struct {
+	Path       string `parquet:"name=path, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Size       int64  `parquet:"name=size, type=INT64"`
+	Mtime      int64  `parquet:"name=mtime, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Ctime      int64  `parquet:"name=ctime, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Uid        int64  `parquet:"name=uid, type=INT64"`
+	Gid        int64  `parquet:"name=gid, type=INT64"`
+	Mode       int64  `parquet:"name=mode, type=INT64"`
+	Mime       string `parquet:"name=mime, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	ChunkCount int32  `parquet:"name=chunk_count, type=INT32"`
+	Md5        string `parquet:"name=md5, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+func (c *commandMetaExportParquet) Name() string {
+	return "meta.export.parquet"
+}
+
+func (c *commandMetaExportParquet) Help() string {
+	return `export directory and file meta data to a local parquet file, for analytics
+
+	meta.export.parquet <path> <output dir>
+
+	meta.export.parquet / /tmp/meta
+	meta.export.parquet -v /buckets/b1 .
+
+	The meta data is saved into a local <output dir>/<filer_host>-<port>-<time>.parquet file,
+	with the columns path, size, mtime, ctime, uid, gid, mode, mime, chunk_count and md5.
+	It can be queried with Spark, DuckDB, Presto, etc.
+
+`
+}
+
+func (c *commandMetaExportParquet) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	metaExportCommand := newFlagSet(c.Name(), commandEnv)
+	verbose := metaExportCommand.Bool("v", false, "print out each processed files")
+	if err = metaExportCommand.Parse(args); err != nil {
+		return err
+	}
+	if metaExportCommand.NArg() != 2 {
+		return fmt.Errorf("need to have <path> and <output dir>")
+	}
+
+	path, parseErr := commandEnv.parseUrl(metaExportCommand.Arg(0))
+	if parseErr != nil {
+		return parseErr
+	}
+	outputDir := metaExportCommand.Arg(1)
+	if err = os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("create %s: %v", outputDir, err)
+	}
+
+	t := time.Now()
+	fileName := filepath.Join(outputDir, fmt.Sprintf("%s-%4d%02d%02d-%02d%02d%02d.parquet",
+		commandEnv.option.FilerAddress.ToHttpAddress(), t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second()))
+
+	fw, err := local.NewLocalFileWriter(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s: %v", fileName, err)
+	}
+	defer fw.Close()
+	pw, err := parquet_writer.NewParquetWriter(fw, new(parquetEntry), 4)
+	if err != nil {
+		return fmt.Errorf("create parquet writer: %v", err)
+	}
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	var writeErr error
+	err = doTraverseBfsAndSaving(commandEnv, writer, path, *verbose, func(entry *filer_pb.FullEntry, outputChan chan interface{}) error {
+		outputChan <- toParquetEntry(entry)
+		return nil
+	}, func(outputChan chan interface{}) {
+		count := 0
+		for item := range outputChan {
+			if writeErr != nil {
+				continue
+			}
+			if writeErr = pw.Write(item); writeErr != nil {
+				continue
+			}
+			// write out the row group, instead of keeping all the entries in memory
+			if count++; count%parquetRowGroupSize == 0 {
+				writeErr = pw.Flush(true)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return fmt.Errorf("write %s: %v", fileName, writeErr)
+	}
+	if err = pw.WriteStop(); err != nil {
+		return fmt.Errorf("write %s: %v", fileName, err)
+	}
+
+	fmt.Fprintf(writer, "meta data for http://%s%s is exported to %s\n", commandEnv.option.FilerAddress.ToHttpAddress(), path, fileName)
+	return nil
+}
+
+func toParquetEntry(fullEntry *filer_pb.FullEntry) *parquetEntry {
+	entry := fullEntry.Entry
+	row := &parquetEntry{
+		Path:       fullEntry.Dir + "/" + entry.Name,
+		Size:       int64(filer.FileSize(entry)),
+		ChunkCount: int32(len(entry.GetChunks())),
+	}
+	if fullEntry.Dir == "/" {
+		row.Path = "/" + entry.Name
+	}
+	if attr := entry.Attributes; attr != nil {
+		row.Mtime = attr.Mtime * 1000
+		row.Ctime = attr.Crtime * 1000
+		row.Uid = int64(attr.Uid)
+		row.Gid = int64(attr.Gid)
+		row.Mode = int64(attr.FileMode)
+		row.Mime = attr.Mime
+		if len(attr.Md5) > 0 {
+			row.Md5 = hex.EncodeToString(attr.Md5)
+		}
+	}
+	return row
+}
@@ -2,13 +2,8 @@ package shell
 
 import (
 	"context"
-	"flag"
-	"fmt"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/mount_pb"
-	"github.com/seaweedfs/seaweedfs/weed/util"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	_ "google.golang.org/grpc/resolver/passthrough"
 	"io"
 )
 
@@ -17,6 +12,7 @@ func init() {
 }
 
 type commandMountConfigure struct {
+	flagCommand
 }
 
 func (c *commandMountConfigure) Name() string {
@@ -36,28 +32,18 @@ func (c *commandMountConfigure) Help() string {
 
 func (c *commandMountConfigure) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	mountConfigureCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	mountConfigureCommand := newFlagSet(c.Name(), commandEnv)
 	mountDir := mountConfigureCommand.String("dir", "", "the mount directory same as how \"weed mount -dir=<mount_directory>\" was started")
 	mountQuota := mountConfigureCommand.Int("quotaMB", 0, "the quota in MB")
 	if err = mountConfigureCommand.Parse(args); err != nil {
 		return nil
 	}
 
-	mountDirHash := util.HashToInt32([]byte(*mountDir))
-	if mountDirHash < 0 {
-		mountDirHash = -mountDirHash
-	}
-	localSocket := fmt.Sprintf("/tmp/seaweedfs-mount-%d.sock", mountDirHash)
-
-	clientConn, err := grpc.Dial("passthrough:///unix://"+localSocket, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		return
-	}
-	defer clientConn.Close()
-
-	client := mount_pb.NewSeaweedMountClient(clientConn)
-	_, err = client.Configure(context.Background(), &mount_pb.ConfigureRequest{
-		CollectionCapacity: int64(*mountQuota) * 1024 * 1024,
+	err = pb.WithMountSocketClient(pb.LocalMountSocket(*mountDir), func(client mount_pb.SeaweedMountClient) error {
+		_, err := client.Configure(context.Background(), &mount_pb.ConfigureRequest{
+			CollectionCapacity: int64(*mountQuota) * 1024 * 1024,
+		})
+		return err
 	})
 
 	return
@@ -0,0 +1,45 @@
+package shell
+
+import (
+	"fmt"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"io"
+	"time"
+)
+
+func init() {
+	Commands = append(Commands, &commandMountList{})
+}
+
+type commandMountList struct {
+}
+
+func (c *commandMountList) Name() string {
+	return "mount.list"
+}
+
+func (c *commandMountList) Help() string {
+	return `list the active mounts on current server
+
+	mount.list
+
+	This command connects with each local mount via its unix socket, so it can only run locally.
+	The mounts started with a custom "weed mount -localSocket=<socket>" are not listed.
+
+`
+}
+
+func (c *commandMountList) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	mounts, err := pb.ListLocalMounts(time.Second)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range mounts {
+		fmt.Fprintf(writer, "%s\tfiler:%s%s\tcollection:\"%s\"\tpid:%d\tsocket:%s\n", m.MountDirectory, m.Filer, m.FilerMountRootPath, m.Collection, m.Pid, m.LocalSocket)
+	}
+	fmt.Fprintf(writer, "Total %d mounts.\n", len(mounts))
+
+	return nil
+}
@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -16,6 +15,7 @@ func init() {
 }
 
 type commandRemoteCache struct {
+	flagCommand
 }
 
 func (c *commandRemoteCache) Name() string {
@@ -48,7 +48,7 @@ func (c *commandRemoteCache) Help() string {
 
 func (c *commandRemoteCache) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	remoteMountCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	remoteMountCommand := newFlagSet(c.Name(), commandEnv)
 
 	dir := remoteMountCommand.String("dir", "", "a mounted directory or one of its sub folders in filer")
 	concurrency := remoteMountCommand.Int("concurrent", 32, "concurrent file downloading")
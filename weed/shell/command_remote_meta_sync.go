@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -17,6 +16,7 @@ func init() {
 }
 
 type commandRemoteMetaSync struct {
+	flagCommand
 }
 
 func (c *commandRemoteMetaSync) Name() string {
@@ -46,7 +46,7 @@ func (c *commandRemoteMetaSync) Help() string {
 
 func (c *commandRemoteMetaSync) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	remoteMetaSyncCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	remoteMetaSyncCommand := newFlagSet(c.Name(), commandEnv)
 
 	dir := remoteMetaSyncCommand.String("dir", "", "a directory in filer")
 
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -21,6 +20,7 @@ func init() {
 }
 
 type commandRemoteMount struct {
+	flagCommand
 }
 
 func (c *commandRemoteMount) Name() string {
@@ -46,7 +46,7 @@ func (c *commandRemoteMount) Help() string {
 
 func (c *commandRemoteMount) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	remoteMountCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	remoteMountCommand := newFlagSet(c.Name(), commandEnv)
 
 	dir := remoteMountCommand.String("dir", "", "a directory in filer")
 	nonEmpty := remoteMountCommand.Bool("nonempty", false, "allows the mounting over a non-empty directory")
@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/pb/remote_pb"
@@ -17,6 +16,7 @@ func init() {
 }
 
 type commandRemoteMountBuckets struct {
+	flagCommand
 }
 
 func (c *commandRemoteMountBuckets) Name() string {
@@ -40,7 +40,7 @@ func (c *commandRemoteMountBuckets) Help() string {
 
 func (c *commandRemoteMountBuckets) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	remoteMountBucketsCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	remoteMountBucketsCommand := newFlagSet(c.Name(), commandEnv)
 
 	remote := remoteMountBucketsCommand.String("remote", "", "an already configured storage name")
 	bucketPattern := remoteMountBucketsCommand.String("bucketPattern", "", "match existing bucket name with wildcard characters '*' and '?'")
@@ -18,6 +18,7 @@ func init() {
 }
 
 type commandRemoteUncache struct {
+	flagCommand
 }
 
 func (c *commandRemoteUncache) Name() string {
@@ -42,7 +43,7 @@ func (c *commandRemoteUncache) Help() string {
 
 func (c *commandRemoteUncache) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	remoteUncacheCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	remoteUncacheCommand := newFlagSet(c.Name(), commandEnv)
 
 	dir := remoteUncacheCommand.String("dir", "", "a directory in filer")
 	fileFiler := newFileFilter(remoteUncacheCommand)
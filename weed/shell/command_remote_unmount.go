@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -17,6 +16,7 @@ func init() {
 }
 
 type commandRemoteUnmount struct {
+	flagCommand
 }
 
 func (c *commandRemoteUnmount) Name() string {
@@ -39,7 +39,7 @@ func (c *commandRemoteUnmount) Help() string {
 
 func (c *commandRemoteUnmount) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	remoteMountCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	remoteMountCommand := newFlagSet(c.Name(), commandEnv)
 
 	dir := remoteMountCommand.String("dir", "", "a directory in filer")
 
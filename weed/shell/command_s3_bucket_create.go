@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3bucket"
@@ -16,6 +15,7 @@ func init() {
 }
 
 type commandS3BucketCreate struct {
+	flagCommand
 }
 
 func (c *commandS3BucketCreate) Name() string {
@@ -32,7 +32,7 @@ func (c *commandS3BucketCreate) Help() string {
 
 func (c *commandS3BucketCreate) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	bucketCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	bucketCommand := newFlagSet(c.Name(), commandEnv)
 	bucketName := bucketCommand.String("name", "", "bucket name")
 	if err = bucketCommand.Parse(args); err != nil {
 		return nil
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"io"
@@ -15,6 +14,7 @@ func init() {
 }
 
 type commandS3BucketDelete struct {
+	flagCommand
 }
 
 func (c *commandS3BucketDelete) Name() string {
@@ -30,7 +30,7 @@ func (c *commandS3BucketDelete) Help() string {
 
 func (c *commandS3BucketDelete) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	bucketCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	bucketCommand := newFlagSet(c.Name(), commandEnv)
 	bucketName := bucketCommand.String("name", "", "bucket name")
 	if err = bucketCommand.Parse(args); err != nil {
 		return nil
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"io"
 	"math"
@@ -15,6 +14,7 @@ func init() {
 }
 
 type commandS3BucketList struct {
+	flagCommand
 }
 
 func (c *commandS3BucketList) Name() string {
@@ -29,7 +29,7 @@ func (c *commandS3BucketList) Help() string {
 
 func (c *commandS3BucketList) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	bucketCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	bucketCommand := newFlagSet(c.Name(), commandEnv)
 	if err = bucketCommand.Parse(args); err != nil {
 		return nil
 	}
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"io"
 
@@ -14,6 +13,7 @@ func init() {
 }
 
 type commandS3BucketQuota struct {
+	flagCommand
 }
 
 func (c *commandS3BucketQuota) Name() string {
@@ -30,7 +30,7 @@ func (c *commandS3BucketQuota) Help() string {
 
 func (c *commandS3BucketQuota) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	bucketCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	bucketCommand := newFlagSet(c.Name(), commandEnv)
 	bucketName := bucketCommand.String("name", "", "bucket name")
 	operationName := bucketCommand.String("op", "set", "operation name [set|get|remove|enable|disable]")
 	sizeMB := bucketCommand.Int64("sizeMB", 0, "bucket quota size in MiB")
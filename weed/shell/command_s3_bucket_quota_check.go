@@ -2,7 +2,6 @@ package shell
 
 import (
 	"bytes"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
@@ -15,6 +14,7 @@ func init() {
 }
 
 type commandS3BucketQuotaEnforce struct {
+	flagCommand
 }
 
 func (c *commandS3BucketQuotaEnforce) Name() string {
@@ -31,7 +31,7 @@ func (c *commandS3BucketQuotaEnforce) Help() string {
 
 func (c *commandS3BucketQuotaEnforce) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	bucketCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	bucketCommand := newFlagSet(c.Name(), commandEnv)
 	applyQuotaLimit := bucketCommand.Bool("apply", false, "actually change the buckets readonly attribute")
 	if err = bucketCommand.Parse(args); err != nil {
 		return nil
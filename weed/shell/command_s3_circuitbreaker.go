@@ -2,7 +2,6 @@ package shell
 
 import (
 	"bytes"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/pb/s3_pb"
@@ -21,6 +20,7 @@ func init() {
 }
 
 type commandS3CircuitBreaker struct {
+	flagCommand
 }
 
 func (c *commandS3CircuitBreaker) Name() string {
@@ -55,7 +55,7 @@ func (c *commandS3CircuitBreaker) Do(args []string, commandEnv *CommandEnv, writ
 	dir := s3_constants.CircuitBreakerConfigDir
 	file := s3_constants.CircuitBreakerConfigFile
 
-	s3CircuitBreakerCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	s3CircuitBreakerCommand := newFlagSet(c.Name(), commandEnv)
 	buckets := s3CircuitBreakerCommand.String("buckets", "", "the bucket name(s) to configure, eg: -buckets x,y,z")
 	global := s3CircuitBreakerCommand.Bool("global", false, "configure global circuit breaker")
 
@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/s3api/s3_constants"
 	"github.com/seaweedfs/seaweedfs/weed/security"
@@ -18,6 +17,7 @@ func init() {
 }
 
 type commandS3CleanUploads struct {
+	flagCommand
 }
 
 func (c *commandS3CleanUploads) Name() string {
@@ -35,7 +35,7 @@ func (c *commandS3CleanUploads) Help() string {
 
 func (c *commandS3CleanUploads) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	bucketCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	bucketCommand := newFlagSet(c.Name(), commandEnv)
 	uploadedTimeAgo := bucketCommand.Duration("timeAgo", 24*time.Hour, "created time before now. \"1.5h\" or \"2h45m\". Valid time units are \"m\", \"h\"")
 	if err = bucketCommand.Parse(args); err != nil {
 		return nil
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"bytes"
-	"flag"
 	"fmt"
 	"io"
 	"sort"
@@ -19,6 +18,7 @@ func init() {
 }
 
 type commandS3Configure struct {
+	flagCommand
 }
 
 func (c *commandS3Configure) Name() string {
@@ -35,7 +35,7 @@ func (c *commandS3Configure) Help() string {
 
 func (c *commandS3Configure) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	s3ConfigureCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	s3ConfigureCommand := newFlagSet(c.Name(), commandEnv)
 	actions := s3ConfigureCommand.String("actions", "", "comma separated actions names: Read,Write,List,Tagging,Admin")
 	user := s3ConfigureCommand.String("user", "", "user name")
 	buckets := s3ConfigureCommand.String("buckets", "", "bucket name")
@@ -0,0 +1,60 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandTrashEmpty{})
+}
+
+type commandTrashEmpty struct {
+}
+
+func (c *commandTrashEmpty) Name() string {
+	return "trash.empty"
+}
+
+func (c *commandTrashEmpty) Help() string {
+	return `permanently delete the entries in the trash of a directory
+
+	trash.empty <directory>
+
+	trash.empty /dir
+
+	The directory is the one with the "seaweedfs.trash-ttl" xattr.
+`
+}
+
+func (c *commandTrashEmpty) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	if len(args) != 1 {
+		return fmt.Errorf("need to have 1 argument")
+	}
+
+	dir, err := commandEnv.parseUrl(args[0])
+	if err != nil {
+		return err
+	}
+	trashPath := util.FullPath(dir).Child(filer.TrashDirName)
+
+	entry, err := filer_pb.GetEntry(commandEnv, trashPath)
+	if err != nil && err != filer_pb.ErrNotFound {
+		return err
+	}
+	if entry == nil {
+		fmt.Fprintf(writer, "trash of %s is empty\n", dir)
+		return nil
+	}
+
+	if err = filer_pb.Remove(commandEnv, dir, filer.TrashDirName, true, true, true, false, nil); err != nil {
+		return fmt.Errorf("empty trash %s: %v", trashPath, err)
+	}
+	fmt.Fprintf(writer, "emptied %s\n", trashPath)
+	return nil
+}
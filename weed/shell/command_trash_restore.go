@@ -0,0 +1,103 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandTrashRestore{})
+}
+
+type commandTrashRestore struct {
+}
+
+func (c *commandTrashRestore) Name() string {
+	return "trash.restore"
+}
+
+func (c *commandTrashRestore) Help() string {
+	return `restore a deleted file or directory from the trash
+
+	trash.restore <deleted entry>
+
+	trash.restore /dir/file_name
+
+	A deleted entry is kept in the trash of its nearest parent directory
+	with the "seaweedfs.trash-ttl" xattr, until the ttl is passed.
+	It is restored to its original path, which must not exist.
+`
+}
+
+func (c *commandTrashRestore) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	if len(args) != 1 {
+		return fmt.Errorf("need to have 1 argument")
+	}
+
+	originalPath, err := commandEnv.parseUrl(args[0])
+	if err != nil {
+		return err
+	}
+	fullPath := util.FullPath(originalPath)
+	if filer.IsInTrash(fullPath) {
+		return fmt.Errorf("%s is in the trash", fullPath)
+	}
+
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+
+		trashRoot, err := findTrashRoot(client, fullPath)
+		if err != nil {
+			return err
+		}
+		trashDir := filer.TrashDir(trashRoot, fullPath)
+		dir, name := fullPath.DirAndName()
+
+		if entry, err := filer_pb.GetEntry(commandEnv, fullPath); err == nil && entry != nil {
+			return fmt.Errorf("%s already exists", fullPath)
+		}
+		if entry, err := filer_pb.GetEntry(commandEnv, trashDir.Child(name)); err != nil || entry == nil {
+			return fmt.Errorf("%s is not found in the trash of %s", fullPath, trashRoot)
+		}
+
+		if _, err = client.AtomicRenameEntry(context.Background(), &filer_pb.AtomicRenameEntryRequest{
+			OldDirectory: string(trashDir),
+			OldName:      name,
+			NewDirectory: dir,
+			NewName:      name,
+		}); err != nil {
+			return fmt.Errorf("restore %s: %v", fullPath, err)
+		}
+
+		trashParent, trashName := trashDir.DirAndName()
+		if err = filer_pb.Remove(commandEnv, trashParent, trashName, true, true, true, false, nil); err != nil {
+			fmt.Fprintf(writer, "remove %s: %v\n", trashDir, err)
+		}
+
+		fmt.Fprintf(writer, "restore: %s\n", fullPath)
+		return nil
+	})
+
+}
+
+// findTrashRoot returns the nearest parent directory of p with a trash ttl.
+func findTrashRoot(client filer_pb.SeaweedFilerClient, p util.FullPath) (util.FullPath, error) {
+	dir, _ := p.DirAndName()
+	for dir != "/" {
+		parent, name := util.FullPath(dir).DirAndName()
+		resp, err := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+			Directory: parent,
+			Name:      name,
+		})
+		if err == nil && resp.Entry.Extended[filer.TrashTtlKey] != nil {
+			return util.FullPath(dir), nil
+		}
+		dir = parent
+	}
+	return "", fmt.Errorf("no parent directory of %s has the %s xattr", p, filer.TrashTtlKey)
+}
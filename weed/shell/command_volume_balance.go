@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -22,6 +21,7 @@ func init() {
 }
 
 type commandVolumeBalance struct {
+	flagCommand
 }
 
 func (c *commandVolumeBalance) Name() string {
@@ -65,7 +65,7 @@ func (c *commandVolumeBalance) Help() string {
 
 func (c *commandVolumeBalance) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	balanceCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	balanceCommand := newFlagSet(c.Name(), commandEnv)
 	collection := balanceCommand.String("collection", "ALL_COLLECTIONS", "collection name, or use \"ALL_COLLECTIONS\" across collections, \"EACH_COLLECTION\" for each collection")
 	dc := balanceCommand.String("dataCenter", "", "only apply the balancing for this dataCenter")
 	applyBalancing := balanceCommand.Bool("force", false, "apply the balancing plan.")
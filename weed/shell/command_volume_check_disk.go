@@ -3,7 +3,6 @@ package shell
 import (
 	"bytes"
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
@@ -22,6 +21,7 @@ func init() {
 }
 
 type commandVolumeCheckDisk struct {
+	flagCommand
 	env           *CommandEnv
 	syncDeletions *bool
 }
@@ -46,7 +46,7 @@ func (c *commandVolumeCheckDisk) Help() string {
 
 func (c *commandVolumeCheckDisk) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	fsckCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	fsckCommand := newFlagSet(c.Name(), commandEnv)
 	slowMode := fsckCommand.Bool("slow", false, "slow mode checks all replicas even file counts are the same")
 	verbose := fsckCommand.Bool("v", false, "verbose mode")
 	volumeId := fsckCommand.Uint("volumeId", 0, "the volume id")
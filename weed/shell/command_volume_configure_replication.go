@@ -3,7 +3,6 @@ package shell
 import (
 	"context"
 	"errors"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"io"
@@ -21,6 +20,7 @@ func init() {
 }
 
 type commandVolumeConfigureReplication struct {
+	flagCommand
 }
 
 func (c *commandVolumeConfigureReplication) Name() string {
@@ -37,7 +37,7 @@ func (c *commandVolumeConfigureReplication) Help() string {
 
 func (c *commandVolumeConfigureReplication) Do(args []string, commandEnv *CommandEnv, _ io.Writer) (err error) {
 
-	configureReplicationCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	configureReplicationCommand := newFlagSet(c.Name(), commandEnv)
 	volumeIdInt := configureReplicationCommand.Int("volumeId", 0, "the volume id")
 	replicationString := configureReplicationCommand.String("replication", "", "the intended replication value")
 	collectionPattern := configureReplicationCommand.String("collectionPattern", "", "match with wildcard characters '*' and '?'")
@@ -1,9 +1,12 @@
 package shell
 
 import (
-	"flag"
+	"context"
 	"fmt"
+	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
+	"google.golang.org/grpc"
 	"io"
 
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
@@ -14,6 +17,7 @@ func init() {
 }
 
 type commandVolumeCopy struct {
+	flagCommand
 }
 
 func (c *commandVolumeCopy) Name() string {
@@ -23,20 +27,27 @@ func (c *commandVolumeCopy) Name() string {
 func (c *commandVolumeCopy) Help() string {
 	return `copy a volume from one volume server to another volume server
 
-	volume.copy -source <source volume server host:port> -target <target volume server host:port> -volumeId <volume id>
+	volume.copy -source <source volume server host:port> -target <target volume server host:port> -volumeId <volume id> [-remove-source]
 
 	This command copies a volume from one volume server to another volume server.
 	Usually you will want to unmount the volume first before copying.
 
+	The file count and the .dat size of the copy are checked against the source. A copy not matching is deleted,
+	otherwise the target volume server reports it to the master with its next heartbeat.
+	A writable source taking new writes right after the copy fails the check, so mark it readonly first.
+	With -remove-source, the source volume is marked readonly before the copy, and deleted after the check,
+	so no writes are lost in between. If a step fails, the source volume is marked writable again.
+
 `
 }
 
 func (c *commandVolumeCopy) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	volCopyCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volCopyCommand := newFlagSet(c.Name(), commandEnv)
 	volumeIdInt := volCopyCommand.Int("volumeId", 0, "the volume id")
 	sourceNodeStr := volCopyCommand.String("source", "", "the source volume server <host>:<port>")
 	targetNodeStr := volCopyCommand.String("target", "", "the target volume server <host>:<port>")
+	removeSource := volCopyCommand.Bool("remove-source", false, "delete the source volume after the copy is verified")
 	if err = volCopyCommand.Parse(args); err != nil {
 		return nil
 	}
@@ -53,6 +64,86 @@ func (c *commandVolumeCopy) Do(args []string, commandEnv *CommandEnv, writer io.
 		return fmt.Errorf("source and target volume servers are the same!")
 	}
 
-	_, err = copyVolume(commandEnv.option.GrpcDialOption, writer, volumeId, sourceVolumeServer, targetVolumeServer, "", 0)
+	grpcDialOption := commandEnv.option.GrpcDialOption
+	if *removeSource {
+		// copyVolume keeps the source readonly only during the copy. Keep it readonly until the deletion, so no writes are lost
+		var isReadOnly bool
+		if isReadOnly, err = isVolumeReadOnly(grpcDialOption, volumeId, sourceVolumeServer); err != nil {
+			return fmt.Errorf("read volume %d status on %s: %v", volumeId, sourceVolumeServer, err)
+		}
+		if !isReadOnly {
+			if err = markVolumeWritable(grpcDialOption, volumeId, sourceVolumeServer, false); err != nil {
+				return fmt.Errorf("mark volume %d readonly on %s: %v", volumeId, sourceVolumeServer, err)
+			}
+			defer func() {
+				if err == nil {
+					return
+				}
+				if writableErr := markVolumeWritable(grpcDialOption, volumeId, sourceVolumeServer, true); writableErr != nil {
+					fmt.Fprintf(writer, "mark volume %d writable on %s: %v\n", volumeId, sourceVolumeServer, writableErr)
+				}
+			}()
+		}
+	}
+
+	if _, err = copyVolume(grpcDialOption, writer, volumeId, sourceVolumeServer, targetVolumeServer, "", 0); err != nil {
+		return
+	}
+
+	if err = verifyVolumeCopy(grpcDialOption, volumeId, sourceVolumeServer, targetVolumeServer); err != nil {
+		if deleteErr := deleteVolume(grpcDialOption, volumeId, targetVolumeServer); deleteErr != nil {
+			fmt.Fprintf(writer, "delete the copy of volume %d on %s: %v\n", volumeId, targetVolumeServer, deleteErr)
+		}
+		return err
+	}
+	fmt.Fprintf(writer, "copied volume %d from %s to %s\n", volumeId, sourceVolumeServer, targetVolumeServer)
+
+	if *removeSource {
+		if err = deleteVolume(grpcDialOption, volumeId, sourceVolumeServer); err != nil {
+			return fmt.Errorf("delete volume %d from %s: %v", volumeId, sourceVolumeServer, err)
+		}
+		fmt.Fprintf(writer, "deleted volume %d from %s\n", volumeId, sourceVolumeServer)
+	}
+	return nil
+}
+
+// verifyVolumeCopy checks the copy has the same file count and .dat size as the source
+func verifyVolumeCopy(grpcDialOption grpc.DialOption, volumeId needle.VolumeId, sourceVolumeServer, targetVolumeServer pb.ServerAddress) error {
+	source, err := readVolumeFileStatus(grpcDialOption, volumeId, sourceVolumeServer)
+	if err != nil {
+		return fmt.Errorf("read volume %d status on %s: %v", volumeId, sourceVolumeServer, err)
+	}
+	target, err := readVolumeFileStatus(grpcDialOption, volumeId, targetVolumeServer)
+	if err != nil {
+		return fmt.Errorf("read volume %d status on %s: %v", volumeId, targetVolumeServer, err)
+	}
+	if source.FileCount != target.FileCount || source.DatFileSize != target.DatFileSize {
+		return fmt.Errorf("volume %d on %s has %d files in %d bytes, but the copy on %s has %d files in %d bytes",
+			volumeId, sourceVolumeServer, source.FileCount, source.DatFileSize, targetVolumeServer, target.FileCount, target.DatFileSize)
+	}
+	return nil
+}
+
+func isVolumeReadOnly(grpcDialOption grpc.DialOption, volumeId needle.VolumeId, volumeServer pb.ServerAddress) (isReadOnly bool, err error) {
+	err = operation.WithVolumeServerClient(false, volumeServer, grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
+		resp, statusErr := volumeServerClient.VolumeStatus(context.Background(), &volume_server_pb.VolumeStatusRequest{
+			VolumeId: uint32(volumeId),
+		})
+		if statusErr != nil {
+			return statusErr
+		}
+		isReadOnly = resp.IsReadOnly
+		return nil
+	})
+	return
+}
+
+func readVolumeFileStatus(grpcDialOption grpc.DialOption, volumeId needle.VolumeId, volumeServer pb.ServerAddress) (resp *volume_server_pb.ReadVolumeFileStatusResponse, err error) {
+	err = operation.WithVolumeServerClient(false, volumeServer, grpcDialOption, func(volumeServerClient volume_server_pb.VolumeServerClient) error {
+		resp, err = volumeServerClient.ReadVolumeFileStatus(context.Background(), &volume_server_pb.ReadVolumeFileStatusRequest{
+			VolumeId: uint32(volumeId),
+		})
+		return err
+	})
 	return
 }
@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"flag"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"io"
 
@@ -13,6 +12,7 @@ func init() {
 }
 
 type commandVolumeDelete struct {
+	flagCommand
 }
 
 func (c *commandVolumeDelete) Name() string {
@@ -31,7 +31,7 @@ func (c *commandVolumeDelete) Help() string {
 
 func (c *commandVolumeDelete) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	volDeleteCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volDeleteCommand := newFlagSet(c.Name(), commandEnv)
 	volumeIdInt := volDeleteCommand.Int("volumeId", 0, "the volume id")
 	nodeStr := volDeleteCommand.String("node", "", "the volume server <host>:<port>")
 	if err = volDeleteCommand.Parse(args); err != nil {
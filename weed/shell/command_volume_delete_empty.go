@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"flag"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
@@ -15,6 +14,7 @@ func init() {
 }
 
 type commandVolumeDeleteEmpty struct {
+	flagCommand
 }
 
 func (c *commandVolumeDeleteEmpty) Name() string {
@@ -33,7 +33,7 @@ func (c *commandVolumeDeleteEmpty) Help() string {
 
 func (c *commandVolumeDeleteEmpty) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	volDeleteCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volDeleteCommand := newFlagSet(c.Name(), commandEnv)
 	quietPeriod := volDeleteCommand.Duration("quietFor", 24*time.Hour, "select empty volumes with no recent writes, avoid newly created ones")
 	applyBalancing := volDeleteCommand.Bool("force", false, "apply to delete empty volumes")
 	if err = volDeleteCommand.Parse(args); err != nil {
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
@@ -24,6 +23,7 @@ func init() {
 }
 
 type commandVolumeFixReplication struct {
+	flagCommand
 	collectionPattern *string
 }
 
@@ -54,7 +54,7 @@ func (c *commandVolumeFixReplication) Help() string {
 
 func (c *commandVolumeFixReplication) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	volFixReplicationCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volFixReplicationCommand := newFlagSet(c.Name(), commandEnv)
 	c.collectionPattern = volFixReplicationCommand.String("collectionPattern", "", "match with wildcard characters '*' and '?'")
 	skipChange := volFixReplicationCommand.Bool("n", false, "skip the changes")
 	noDelete := volFixReplicationCommand.Bool("noDelete", false, "Do not delete over-replicated volumes, only fix under-replication")
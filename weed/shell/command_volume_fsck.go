@@ -5,7 +5,6 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/filer"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
@@ -41,6 +40,7 @@ const (
 )
 
 type commandVolumeFsck struct {
+	flagCommand
 	env                      *CommandEnv
 	writer                   io.Writer
 	bucketsPath              string
@@ -79,7 +79,7 @@ func (c *commandVolumeFsck) Help() string {
 
 func (c *commandVolumeFsck) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	fsckCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	fsckCommand := newFlagSet(c.Name(), commandEnv)
 	c.verbose = fsckCommand.Bool("v", false, "verbose mode")
 	c.findMissingChunksInFiler = fsckCommand.Bool("findMissingChunksInFiler", false, "see \"help volume.fsck\"")
 	c.collection = fsckCommand.String("collection", "", "the collection name")
@@ -618,17 +618,28 @@ func (c *commandVolumeFsck) collectVolumeIds() (volumeIdToServer map[string]map[
 		fmt.Fprintf(c.writer, "collecting volume id and locations from master ...\n")
 	}
 
-	volumeIdToServer = make(map[string]map[uint32]VInfo)
 	// collect topology information
 	topologyInfo, _, err := collectTopologyInfo(c.env, 0)
 	if err != nil {
 		return
 	}
 
+	volumeIdToServer = volumeIdToServerOf(topologyInfo)
+	if *c.verbose {
+		for dataNodeId, volumeIdToVInfo := range volumeIdToServer {
+			fmt.Fprintf(c.writer, "dn %+v collected %d volumes and locations.\n", dataNodeId, len(volumeIdToVInfo))
+		}
+	}
+	return
+}
+
+// volumeIdToServerOf returns the volumes and ec volumes on each data node, on all of its disks
+func volumeIdToServerOf(topologyInfo *master_pb.TopologyInfo) map[string]map[uint32]VInfo {
+	volumeIdToServer := make(map[string]map[uint32]VInfo)
 	eachDataNode(topologyInfo, func(dc string, rack RackId, t *master_pb.DataNodeInfo) {
+		dataNodeId := t.GetId()
+		volumeIdToServer[dataNodeId] = make(map[uint32]VInfo)
 		for _, diskInfo := range t.DiskInfos {
-			dataNodeId := t.GetId()
-			volumeIdToServer[dataNodeId] = make(map[uint32]VInfo)
 			for _, vi := range diskInfo.VolumeInfos {
 				volumeIdToServer[dataNodeId][vi.Id] = VInfo{
 					server:     pb.NewServerAddressFromDataNode(t),
@@ -645,12 +656,9 @@ func (c *commandVolumeFsck) collectVolumeIds() (volumeIdToServer map[string]map[
 					isReadOnly: true,
 				}
 			}
-			if *c.verbose {
-				fmt.Fprintf(c.writer, "dn %+v collected %d volumes and locations.\n", dataNodeId, len(volumeIdToServer[dataNodeId]))
-			}
 		}
 	})
-	return
+	return volumeIdToServer
 }
 
 func (c *commandVolumeFsck) purgeFileIdsForOneVolume(volumeId uint32, fileIds []string) (err error) {
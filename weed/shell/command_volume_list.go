@@ -2,7 +2,6 @@ package shell
 
 import (
 	"bytes"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
@@ -17,6 +16,7 @@ func init() {
 }
 
 type commandVolumeList struct {
+	flagCommand
 	collectionPattern *string
 	dataCenter        *string
 	rack              *string
@@ -39,7 +39,7 @@ func (c *commandVolumeList) Help() string {
 
 func (c *commandVolumeList) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	volumeListCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volumeListCommand := newFlagSet(c.Name(), commandEnv)
 	verbosityLevel := volumeListCommand.Int("v", 5, "verbose mode: 0, 1, 2, 3, 4, 5")
 	c.collectionPattern = volumeListCommand.String("collectionPattern", "", "match with wildcard characters '*' and '?'")
 	c.readonly = volumeListCommand.Bool("readonly", false, "show only readonly")
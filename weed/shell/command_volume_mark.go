@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"io"
@@ -14,6 +13,7 @@ func init() {
 }
 
 type commandVolumeMark struct {
+	flagCommand
 }
 
 func (c *commandVolumeMark) Name() string {
@@ -29,7 +29,7 @@ func (c *commandVolumeMark) Help() string {
 
 func (c *commandVolumeMark) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	volMarkCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volMarkCommand := newFlagSet(c.Name(), commandEnv)
 	volumeIdInt := volMarkCommand.Int("volumeId", 0, "the volume id")
 	nodeStr := volMarkCommand.String("node", "", "the volume server <host>:<port>")
 	writable := volMarkCommand.Bool("writable", false, "volume mark writable")
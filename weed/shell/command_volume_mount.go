@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"io"
 
@@ -17,6 +16,7 @@ func init() {
 }
 
 type commandVolumeMount struct {
+	flagCommand
 }
 
 func (c *commandVolumeMount) Name() string {
@@ -35,7 +35,7 @@ func (c *commandVolumeMount) Help() string {
 
 func (c *commandVolumeMount) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	volMountCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volMountCommand := newFlagSet(c.Name(), commandEnv)
 	volumeIdInt := volMountCommand.Int("volumeId", 0, "the volume id")
 	nodeStr := volMountCommand.String("node", "", "the volume server <host>:<port>")
 	if err = volMountCommand.Parse(args); err != nil {
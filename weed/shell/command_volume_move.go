@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"github.com/seaweedfs/seaweedfs/weed/wdclient"
@@ -21,6 +20,7 @@ func init() {
 }
 
 type commandVolumeMove struct {
+	flagCommand
 }
 
 func (c *commandVolumeMove) Name() string {
@@ -50,7 +50,7 @@ func (c *commandVolumeMove) Help() string {
 
 func (c *commandVolumeMove) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	volMoveCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volMoveCommand := newFlagSet(c.Name(), commandEnv)
 	volumeIdInt := volMoveCommand.Int("volumeId", 0, "the volume id")
 	sourceNodeStr := volMoveCommand.String("source", "", "the source volume server <host>:<port>")
 	targetNodeStr := volMoveCommand.String("target", "", "the target volume server <host>:<port>")
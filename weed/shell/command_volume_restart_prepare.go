@@ -0,0 +1,71 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandVolumeRestartPrepare{})
+}
+
+type commandVolumeRestartPrepare struct {
+	flagCommand
+}
+
+func (c *commandVolumeRestartPrepare) Name() string {
+	return "volume.restart.prepare"
+}
+
+func (c *commandVolumeRestartPrepare) Help() string {
+	return `prepare a volume server for a rolling restart
+
+	volume.restart.prepare -node <volume server host:port> [-timeout 1m] [-force]
+
+	The volume server stops sending heartbeats to the master, so no new writes are assigned to it,
+	waits for the in-flight writes to finish, and syncs all volumes to disk.
+	Once this command returns successfully, it is safe to restart the volume server.
+
+	The master no longer returns the volume server in the lookups, so its volumes are only readable
+	from their other replicas until the restart. It refuses if any volume has no other replica,
+	unless -force is set.
+
+	This operation is not revocable unless the volume server is restarted.
+`
+}
+
+func (c *commandVolumeRestartPrepare) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	prepareCommand := newFlagSet(c.Name(), commandEnv)
+	volumeServer := prepareCommand.String("node", "", "<host>:<port> of the volume server")
+	timeout := prepareCommand.Duration("timeout", time.Minute, "wait time for the in-flight writes")
+	force := prepareCommand.Bool("force", false, "prepare even if some volumes have no other replicas, which are unreadable until the restart")
+	if err = prepareCommand.Parse(args); err != nil {
+		return nil
+	}
+
+	if err = commandEnv.confirmIsLocked(args); err != nil {
+		return
+	}
+
+	if *volumeServer == "" {
+		return fmt.Errorf("need to specify volume server by -node=<host>:<port>")
+	}
+
+	address := pb.ServerAddress(*volumeServer)
+	if _, err = util.Post(fmt.Sprintf("http://%s/admin/prepare-restart", address.ToHttpAddress()), url.Values{
+		"timeout": []string{timeout.String()},
+		"force":   []string{strconv.FormatBool(*force)},
+	}); err != nil {
+		return fmt.Errorf("prepare volume server %s for restart: %v", address, err)
+	}
+
+	fmt.Fprintf(writer, "volume server %s is ready to restart.\n", address)
+	return nil
+}
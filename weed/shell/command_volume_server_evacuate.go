@@ -1,7 +1,6 @@
 package shell
 
 import (
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
 	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
@@ -18,6 +17,7 @@ func init() {
 }
 
 type commandVolumeServerEvacuate struct {
+	flagCommand
 	topologyInfo *master_pb.TopologyInfo
 	targetServer *string
 	volumeRack   *string
@@ -47,7 +47,7 @@ func (c *commandVolumeServerEvacuate) Help() string {
 
 func (c *commandVolumeServerEvacuate) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	vsEvacuateCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	vsEvacuateCommand := newFlagSet(c.Name(), commandEnv)
 	volumeServer := vsEvacuateCommand.String("node", "", "<host>:<port> of the volume server")
 	c.volumeRack = vsEvacuateCommand.String("rack", "", "source rack for the volume servers")
 	c.targetServer = vsEvacuateCommand.String("target", "", "<host>:<port> of target volume")
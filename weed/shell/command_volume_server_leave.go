@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
@@ -16,6 +15,7 @@ func init() {
 }
 
 type commandVolumeServerLeave struct {
+	flagCommand
 }
 
 func (c *commandVolumeServerLeave) Name() string {
@@ -37,7 +37,7 @@ func (c *commandVolumeServerLeave) Help() string {
 
 func (c *commandVolumeServerLeave) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	vsLeaveCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	vsLeaveCommand := newFlagSet(c.Name(), commandEnv)
 	volumeServer := vsLeaveCommand.String("node", "", "<host>:<port> of the volume server")
 	if err = vsLeaveCommand.Parse(args); err != nil {
 		return nil
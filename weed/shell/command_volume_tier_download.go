@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"io"
@@ -20,6 +19,7 @@ func init() {
 }
 
 type commandVolumeTierDownload struct {
+	flagCommand
 }
 
 func (c *commandVolumeTierDownload) Name() string {
@@ -43,7 +43,7 @@ func (c *commandVolumeTierDownload) Help() string {
 
 func (c *commandVolumeTierDownload) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	tierCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	tierCommand := newFlagSet(c.Name(), commandEnv)
 	volumeId := tierCommand.Int("volumeId", 0, "the volume id")
 	collection := tierCommand.String("collection", "", "the collection name")
 	if err = tierCommand.Parse(args); err != nil {
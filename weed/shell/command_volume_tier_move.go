@@ -3,7 +3,6 @@ package shell
 import (
 	"context"
 	"errors"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
@@ -30,6 +29,7 @@ type volumeTierMoveJob struct {
 }
 
 type commandVolumeTierMove struct {
+	flagCommand
 	activeServers sync.Map
 	queues        map[pb.ServerAddress]chan volumeTierMoveJob
 	//activeServers     map[pb.ServerAddress]struct{}
@@ -54,7 +54,7 @@ func (c *commandVolumeTierMove) Help() string {
 
 func (c *commandVolumeTierMove) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	tierCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	tierCommand := newFlagSet(c.Name(), commandEnv)
 	collectionPattern := tierCommand.String("collectionPattern", "", "match with wildcard characters '*' and '?'")
 	fullPercentage := tierCommand.Float64("fullPercent", 95, "the volume reaches the percentage of max volume size")
 	quietPeriod := tierCommand.Duration("quietFor", 24*time.Hour, "select volumes without no writes for this period")
@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"io"
@@ -20,6 +19,7 @@ func init() {
 }
 
 type commandVolumeTierUpload struct {
+	flagCommand
 }
 
 func (c *commandVolumeTierUpload) Name() string {
@@ -57,7 +57,7 @@ func (c *commandVolumeTierUpload) Help() string {
 
 func (c *commandVolumeTierUpload) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	tierCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	tierCommand := newFlagSet(c.Name(), commandEnv)
 	volumeId := tierCommand.Int("volumeId", 0, "the volume id")
 	collection := tierCommand.String("collection", "", "the collection name")
 	fullPercentage := tierCommand.Float64("fullPercent", 95, "the volume reaches the percentage of max volume size")
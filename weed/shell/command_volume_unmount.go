@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/pb"
 	"io"
@@ -18,6 +17,7 @@ func init() {
 }
 
 type commandVolumeUnmount struct {
+	flagCommand
 }
 
 func (c *commandVolumeUnmount) Name() string {
@@ -36,7 +36,7 @@ func (c *commandVolumeUnmount) Help() string {
 
 func (c *commandVolumeUnmount) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	volUnmountCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volUnmountCommand := newFlagSet(c.Name(), commandEnv)
 	volumeIdInt := volUnmountCommand.Int("volumeId", 0, "the volume id")
 	nodeStr := volUnmountCommand.String("node", "", "the volume server <host>:<port>")
 	if err = volUnmountCommand.Parse(args); err != nil {
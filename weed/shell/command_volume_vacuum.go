@@ -2,7 +2,6 @@ package shell
 
 import (
 	"context"
-	"flag"
 	"io"
 
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
@@ -13,6 +12,7 @@ func init() {
 }
 
 type commandVacuum struct {
+	flagCommand
 }
 
 func (c *commandVacuum) Name() string {
@@ -29,7 +29,7 @@ func (c *commandVacuum) Help() string {
 
 func (c *commandVacuum) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
 
-	volumeVacuumCommand := flag.NewFlagSet(c.Name(), flag.ContinueOnError)
+	volumeVacuumCommand := newFlagSet(c.Name(), commandEnv)
 	garbageThreshold := volumeVacuumCommand.Float64("garbageThreshold", 0.3, "vacuum when garbage is more than this limit")
 	collection := volumeVacuumCommand.String("collection", "", "vacuum this collection")
 	volumeId := volumeVacuumCommand.Uint("volumeId", 0, "the volume id")
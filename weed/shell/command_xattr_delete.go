@@ -0,0 +1,61 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandXattrDelete{})
+}
+
+type commandXattrDelete struct {
+}
+
+func (c *commandXattrDelete) Name() string {
+	return "xattr.delete"
+}
+
+func (c *commandXattrDelete) Help() string {
+	return `delete one extended attribute of a file or directory
+
+	xattr.delete <path> <key>
+`
+}
+
+func (c *commandXattrDelete) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	if len(args) != 2 {
+		return fmt.Errorf("usage: xattr.delete <path> <key>")
+	}
+
+	path, err := commandEnv.parseUrl(args[0])
+	if err != nil {
+		return err
+	}
+	key := args[1]
+
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		entry, err := lookupXattrEntry(client, path)
+		if err != nil {
+			return err
+		}
+		if _, found := entry.Extended[key]; !found {
+			return fmt.Errorf("%s has no extended attribute %s", path, key)
+		}
+		delete(entry.Extended, key)
+
+		dir, _ := util.FullPath(path).DirAndName()
+		if err = filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory: dir,
+			Entry:     entry,
+		}); err != nil {
+			return fmt.Errorf("update %s: %v", path, err)
+		}
+		return nil
+	})
+
+}
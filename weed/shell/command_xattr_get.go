@@ -0,0 +1,116 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/seaweedfs/seaweedfs/weed/filer"
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandXattrGet{})
+}
+
+type commandXattrGet struct {
+}
+
+func (c *commandXattrGet) Name() string {
+	return "xattr.get"
+}
+
+func (c *commandXattrGet) Help() string {
+	return `print the extended attributes of a file or directory
+
+	xattr.get <path>        # print all extended attributes
+	xattr.get <path> <key>  # print one extended attribute
+
+	The directory usage attributes, xattr-size, xattr-inode and xattr-usage-at, are also shown parsed.
+	The values which are not printable text are shown in hex.
+`
+}
+
+func (c *commandXattrGet) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	if len(args) != 1 && len(args) != 2 {
+		return fmt.Errorf("usage: xattr.get <path> [key]")
+	}
+
+	path, err := commandEnv.parseUrl(args[0])
+	if err != nil {
+		return err
+	}
+
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		entry, err := lookupXattrEntry(client, path)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 2 {
+			value, found := entry.Extended[args[1]]
+			if !found {
+				return fmt.Errorf("%s has no extended attribute %s", path, args[1])
+			}
+			fmt.Fprintf(writer, "%s: %s\n", args[1], formatXattrValue(args[1], value))
+			return nil
+		}
+
+		var keys []string
+		for key := range entry.Extended {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(writer, "%s: %s\n", key, formatXattrValue(key, entry.Extended[key]))
+		}
+		return nil
+	})
+
+}
+
+func lookupXattrEntry(client filer_pb.SeaweedFilerClient, path string) (*filer_pb.Entry, error) {
+	dir, name := util.FullPath(path).DirAndName()
+	resp, err := filer_pb.LookupEntry(client, &filer_pb.LookupDirectoryEntryRequest{
+		Directory: dir,
+		Name:      name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("lookup %s: %v", path, err)
+	}
+	return resp.Entry, nil
+}
+
+func formatXattrValue(key string, value []byte) string {
+	var text string
+	if utf8.Valid(value) {
+		text = strconv.Quote(string(value))
+	} else {
+		text = fmt.Sprintf("0x%x", value)
+	}
+
+	switch key {
+	case filer.DirUsageSizeKey:
+		if size, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			return fmt.Sprintf("%s (%s)", text, util.BytesToHumanReadable(size))
+		}
+	case filer.DirUsageInodeKey:
+		if inodes, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			return fmt.Sprintf("%s (%d files and directories)", text, inodes)
+		}
+	case filer.InodeQuotaXattr:
+		if limit, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+			return fmt.Sprintf("%s (at most %d files and directories)", text, limit)
+		}
+	case filer.DirUsageAtKey:
+		if at, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			return fmt.Sprintf("%s (%s)", text, time.Unix(at, 0).Format(time.RFC3339))
+		}
+	}
+	return text
+}
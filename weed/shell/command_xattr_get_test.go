@@ -0,0 +1,22 @@
+package shell
+
+import (
+	"testing"
+)
+
+func TestFormatXattrValue(t *testing.T) {
+	tests := []struct {
+		key, value, expected string
+	}{
+		{"xattr-size", "1610612736", `"1610612736" (1.50 GiB)`},
+		{"xattr-inode", "42", `"42" (42 files and directories)`},
+		{"xattr-size", "abc", `"abc"`},
+		{"Seaweed-Project", "apollo", `"apollo"`},
+		{"binary", "\xff\x00", "0xff00"},
+	}
+	for _, test := range tests {
+		if actual := formatXattrValue(test.key, []byte(test.value)); actual != test.expected {
+			t.Errorf("format %s %q: expected %s, got %s", test.key, test.value, test.expected, actual)
+		}
+	}
+}
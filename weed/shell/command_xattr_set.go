@@ -0,0 +1,65 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func init() {
+	Commands = append(Commands, &commandXattrSet{})
+}
+
+type commandXattrSet struct {
+}
+
+func (c *commandXattrSet) Name() string {
+	return "xattr.set"
+}
+
+func (c *commandXattrSet) Help() string {
+	return `set one extended attribute of a file or directory
+
+	xattr.set <path> <key> <value>
+
+	e.g., fix the directory usage
+	xattr.set /buckets/b1 xattr-size 1073741824
+`
+}
+
+func (c *commandXattrSet) Do(args []string, commandEnv *CommandEnv, writer io.Writer) (err error) {
+
+	if len(args) != 3 {
+		return fmt.Errorf("usage: xattr.set <path> <key> <value>")
+	}
+
+	path, err := commandEnv.parseUrl(args[0])
+	if err != nil {
+		return err
+	}
+	key, value := args[1], args[2]
+
+	return commandEnv.WithFilerClient(false, func(client filer_pb.SeaweedFilerClient) error {
+		entry, err := lookupXattrEntry(client, path)
+		if err != nil {
+			return err
+		}
+		if entry.Extended == nil {
+			entry.Extended = make(map[string][]byte)
+		}
+		entry.Extended[key] = []byte(value)
+
+		dir, _ := util.FullPath(path).DirAndName()
+		if err = filer_pb.UpdateEntry(client, &filer_pb.UpdateEntryRequest{
+			Directory: dir,
+			Entry:     entry,
+		}); err != nil {
+			return fmt.Errorf("update %s: %v", path, err)
+		}
+		fmt.Fprintf(writer, "%s: %s\n", key, formatXattrValue(key, entry.Extended[key]))
+		return nil
+	})
+
+}
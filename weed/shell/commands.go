@@ -2,6 +2,7 @@ package shell
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
@@ -36,6 +37,9 @@ type CommandEnv struct {
 	MasterClient *wdclient.MasterClient
 	option       *ShellOptions
 	locker       *exclusive_locks.ExclusiveLocker
+	// set when the tab completion runs a command with -h to collect its flags
+	probingFlags bool
+	probedFlags  *flag.FlagSet
 }
 
 type command interface {
@@ -58,6 +62,17 @@ func NewCommandEnv(options *ShellOptions) *CommandEnv {
 	return ce
 }
 
+// newFlagSet creates the FlagSet to parse the command arguments.
+// When the tab completion runs the command to collect its flags, the usage is not printed.
+func newFlagSet(name string, commandEnv *CommandEnv) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	if commandEnv != nil && commandEnv.probingFlags {
+		flagSet.SetOutput(io.Discard)
+		commandEnv.probedFlags = flagSet
+	}
+	return flagSet
+}
+
 func (ce *CommandEnv) parseUrl(input string) (path string, err error) {
 	if strings.HasPrefix(input, "http") {
 		err = fmt.Errorf("http://<filer>:<port> prefix is not supported any more")
@@ -0,0 +1,142 @@
+package shell
+
+import (
+	"flag"
+	"io"
+	"strings"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/filer_pb"
+)
+
+// the most filer entries listed for one path completion
+const maxPathCompletions = 1024
+
+// flagCommand is embedded by the commands that create their FlagSet with newFlagSet
+// and parse the arguments before anything else, so the tab completion can run them with -h to collect their flags.
+type flagCommand struct{}
+
+func (flagCommand) parsesFlagsFirst() {}
+
+type flagParsingCommand interface {
+	parsesFlagsFirst()
+}
+
+type shellCompleter struct {
+	commandEnv *CommandEnv
+	flagNames  map[string][]string
+}
+
+func newShellCompleter(commandEnv *CommandEnv) *shellCompleter {
+	return &shellCompleter{
+		commandEnv: commandEnv,
+		flagNames:  make(map[string][]string),
+	}
+}
+
+// complete the word before the cursor: a command name first, then the flag names of the command after "-",
+// and the filer paths for the other arguments and the flag values after "=".
+func (sc *shellCompleter) complete(line string, pos int) (head string, completions []string, tail string) {
+	head, tail = line[:pos], line[pos:]
+
+	// complete the last one of the commands separated by ";"
+	cmd := head[strings.LastIndex(head, ";")+1:]
+	wordStart := strings.LastIndexAny(cmd, " \t") + 1
+	word := cmd[wordStart:]
+	head = head[:len(head)-len(word)]
+
+	args := strings.Fields(cmd[:wordStart])
+	switch {
+	case len(args) == 0:
+		completions = completeCommandName(word)
+	case args[0] == "help" || args[0] == "?":
+		if len(args) == 1 {
+			completions = completeCommandName(word)
+		}
+	case strings.HasPrefix(word, "-"):
+		if eq := strings.Index(word, "="); eq >= 0 {
+			for _, p := range sc.completePath(word[eq+1:]) {
+				completions = append(completions, word[:eq+1]+p)
+			}
+		} else {
+			completions = sc.completeFlagName(args[0], word)
+		}
+	default:
+		completions = sc.completePath(word)
+	}
+	return
+}
+
+func completeCommandName(prefix string) (completions []string) {
+	prefix = strings.ToLower(prefix)
+	for _, c := range Commands {
+		if strings.HasPrefix(c.Name(), prefix) {
+			completions = append(completions, c.Name())
+		}
+	}
+	return
+}
+
+func (sc *shellCompleter) completeFlagName(commandName string, word string) (completions []string) {
+	for _, c := range Commands {
+		if c.Name() != commandName && c.Name() != "fs."+commandName {
+			continue
+		}
+		names, found := sc.flagNames[c.Name()]
+		if !found {
+			names = collectFlagNames(c)
+			sc.flagNames[c.Name()] = names
+		}
+		dashes := "-"
+		if strings.HasPrefix(word, "--") {
+			dashes = "--"
+		}
+		for _, name := range names {
+			if strings.HasPrefix(name, strings.TrimPrefix(word, dashes)) {
+				completions = append(completions, dashes+name)
+			}
+		}
+	}
+	return
+}
+
+// collectFlagNames runs the command with -h, which returns as soon as its FlagSet fails to parse
+func collectFlagNames(c command) (names []string) {
+	if _, ok := c.(flagParsingCommand); !ok {
+		return nil
+	}
+	probeEnv := &CommandEnv{probingFlags: true}
+	if err := c.Do([]string{"-h"}, probeEnv, io.Discard); err != nil || probeEnv.probedFlags == nil {
+		return nil
+	}
+	probeEnv.probedFlags.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	return
+}
+
+// completePath lists the entries of the parent directory on the configured filer, with the rest of the path as the prefix
+func (sc *shellCompleter) completePath(partialPath string) (completions []string) {
+	if sc.commandEnv.option.FilerAddress == "" {
+		return nil
+	}
+
+	dir, prefix := partialPath[:strings.LastIndex(partialPath, "/")+1], partialPath[strings.LastIndex(partialPath, "/")+1:]
+	parentPath, err := sc.commandEnv.parseUrl(dir)
+	if err != nil {
+		return nil
+	}
+	if parentPath != "/" {
+		parentPath = strings.TrimSuffix(parentPath, "/")
+	}
+
+	filer_pb.List(sc.commandEnv, parentPath, prefix, func(entry *filer_pb.Entry, isLast bool) error {
+		if entry.IsDirectory {
+			completions = append(completions, dir+entry.Name+"/")
+		} else {
+			completions = append(completions, dir+entry.Name)
+		}
+		return nil
+	}, "", false, maxPathCompletions)
+
+	return
+}
@@ -0,0 +1,33 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShellCompletion(t *testing.T) {
+	sc := newShellCompleter(&CommandEnv{option: &ShellOptions{}})
+
+	tests := []struct {
+		line        string
+		head        string
+		completions []string
+	}{
+		{"volume.bal", "", []string{"volume.balance"}},
+		{"help collection.gc.config", "help ", []string{"collection.gc.config.set", "collection.gc.config.get"}},
+		{"volume.balance -forc", "volume.balance ", []string{"-force"}},
+		{"lock; volume.balance -data", "lock; volume.balance ", []string{"-dataCenter"}},
+		{"collection.ec.config.set c1 --parity", "collection.ec.config.set c1 ", []string{"--parity-shards"}},
+		{"meta.save -o", "meta.save ", []string{"-o", "-obfuscate"}},
+		// fs.ls parses its own flags
+		{"fs.ls -", "fs.ls ", nil},
+		// no filer to list the paths
+		{"fs.ls /buckets/", "fs.ls ", nil},
+	}
+	for _, tt := range tests {
+		head, completions, tail := sc.complete(tt.line, len(tt.line))
+		if head != tt.head || tail != "" || !reflect.DeepEqual(completions, tt.completions) {
+			t.Errorf("complete %q: %q %q %q, expected %q %q", tt.line, head, completions, tail, tt.head, tt.completions)
+		}
+	}
+}
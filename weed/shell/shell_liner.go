@@ -38,7 +38,6 @@ func RunShell(options ShellOptions) {
 	line.SetCtrlCAborts(true)
 	line.SetTabCompletionStyle(liner.TabPrints)
 
-	setCompletionHandler()
 	loadHistory()
 
 	defer saveHistory()
@@ -47,6 +46,8 @@ func RunShell(options ShellOptions) {
 
 	commandEnv := NewCommandEnv(&options)
 
+	setCompletionHandler(commandEnv)
+
 	go commandEnv.MasterClient.KeepConnectedToMaster()
 	commandEnv.MasterClient.WaitUntilConnected()
 
@@ -177,15 +178,8 @@ func printHelp(cmds []string) {
 	}
 }
 
-func setCompletionHandler() {
-	line.SetCompleter(func(line string) (c []string) {
-		for _, i := range Commands {
-			if strings.HasPrefix(i.Name(), strings.ToLower(line)) {
-				c = append(c, i.Name())
-			}
-		}
-		return
-	})
+func setCompletionHandler(commandEnv *CommandEnv) {
+	line.SetWordCompleter(newShellCompleter(commandEnv).complete)
 }
 
 func loadHistory() {
@@ -78,6 +78,23 @@ var (
 			Help:      "Counter of master leader changes.",
 		}, []string{"type"})
 
+	MasterGcBytesReclaimedCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "master",
+			Name:      "gc_bytes_reclaimed_total",
+			Help:      "Counter of the deleted bytes reclaimed by vacuuming the volume replicas.",
+		})
+
+	MasterGcDurationHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "master",
+			Name:      "gc_duration_seconds",
+			Help:      "Bucketed histogram of the time to vacuum a volume.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 16),
+		})
+
 	FilerRequestCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: Namespace,
@@ -103,6 +120,24 @@ var (
 			Help:      "The last send timestamp of the filer subscription.",
 		}, []string{"sourceFiler", "clientName", "path"})
 
+	FilerCassandraBatchSizeHistogram = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "filer",
+			Name:      "cassandra_batch_size",
+			Help:      "Bucketed histogram of the statements per cassandra unlogged batch.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 10),
+		})
+
+	FilerCassandraQueryHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "filer",
+			Name:      "cassandra_query_duration_seconds",
+			Help:      "Bucketed histogram of the cassandra query time.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 24),
+		}, []string{"type"})
+
 	FilerStoreCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: Namespace,
@@ -120,6 +155,22 @@ var (
 			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 24),
 		}, []string{"store", "type"})
 
+	FilerTieringCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "filer",
+			Name:      "tiering_total",
+			Help:      "Counter of files scanned, moved, failed, and bytes moved by the storage tiering.",
+		}, []string{"type"})
+
+	FilerIntegrityCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "filer",
+			Name:      "integrity_total",
+			Help:      "Counter of files verified, skipped, corrupted, failed to read, and bytes read by the integrity checker.",
+		}, []string{"type"})
+
 	FilerSyncOffsetGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: Namespace,
@@ -128,6 +179,14 @@ var (
 			Help:      "The offset of the filer synchronization service.",
 		}, []string{"sourceFiler", "targetFiler", "clientName", "path"})
 
+	FilerSyncLagGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "filerSync",
+			Name:      "replication_lag_seconds",
+			Help:      "The age of the last change synchronized by the filer synchronization service.",
+		}, []string{"sourceFiler", "targetFiler", "clientName", "path"})
+
 	VolumeServerRequestCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: Namespace,
@@ -210,6 +269,38 @@ var (
 			Help:      "Resource usage",
 		}, []string{"name", "type"})
 
+	VolumeServerCacheSizeGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "volumeServer",
+			Name:      "cache_size_bytes",
+			Help:      "Size of the needles in the read cache.",
+		})
+
+	VolumeServerCacheEvictionCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "volumeServer",
+			Name:      "cache_evictions_total",
+			Help:      "Counter of needles evicted from the read cache.",
+		})
+
+	VolumeServerCacheHitRatioGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "volumeServer",
+			Name:      "cache_hit_ratio",
+			Help:      "Ratio of the needle reads served by the read cache.",
+		})
+
+	VolumeServerWriteQueueDepthGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "volumeServer",
+			Name:      "write_queue_depth",
+			Help:      "Number of the write requests being processed or waiting.",
+		})
+
 	S3RequestCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: Namespace,
@@ -226,6 +317,23 @@ var (
 			Help:      "Bucketed histogram of s3 request processing time.",
 			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 24),
 		}, []string{"type", "bucket"})
+
+	GrpcServerRequestHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "grpc",
+			Name:      "server_request_seconds",
+			Help:      "Bucketed histogram of grpc server request processing time.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 2, 24),
+		}, []string{"method"})
+
+	GrpcServerConnectionsGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "grpc",
+			Name:      "server_connections",
+			Help:      "Number of open grpc server connections.",
+		})
 )
 
 func init() {
@@ -235,12 +343,19 @@ func init() {
 	Gather.MustRegister(MasterReceivedHeartbeatCounter)
 	Gather.MustRegister(MasterLeaderChangeCounter)
 	Gather.MustRegister(MasterReplicaPlacementMismatch)
+	Gather.MustRegister(MasterGcBytesReclaimedCounter)
+	Gather.MustRegister(MasterGcDurationHistogram)
 
 	Gather.MustRegister(FilerRequestCounter)
 	Gather.MustRegister(FilerRequestHistogram)
 	Gather.MustRegister(FilerStoreCounter)
+	Gather.MustRegister(FilerCassandraBatchSizeHistogram)
+	Gather.MustRegister(FilerCassandraQueryHistogram)
 	Gather.MustRegister(FilerStoreHistogram)
 	Gather.MustRegister(FilerSyncOffsetGauge)
+	Gather.MustRegister(FilerSyncLagGauge)
+	Gather.MustRegister(FilerTieringCounter)
+	Gather.MustRegister(FilerIntegrityCounter)
 	Gather.MustRegister(FilerServerLastSendTsOfSubscribeGauge)
 	Gather.MustRegister(collectors.NewGoCollector())
 	Gather.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
@@ -255,9 +370,16 @@ func init() {
 	Gather.MustRegister(VolumeServerReadOnlyVolumeGauge)
 	Gather.MustRegister(VolumeServerDiskSizeGauge)
 	Gather.MustRegister(VolumeServerResourceGauge)
+	Gather.MustRegister(VolumeServerCacheSizeGauge)
+	Gather.MustRegister(VolumeServerCacheEvictionCounter)
+	Gather.MustRegister(VolumeServerCacheHitRatioGauge)
+	Gather.MustRegister(VolumeServerWriteQueueDepthGauge)
 
 	Gather.MustRegister(S3RequestCounter)
 	Gather.MustRegister(S3RequestHistogram)
+
+	Gather.MustRegister(GrpcServerRequestHistogram)
+	Gather.MustRegister(GrpcServerConnectionsGauge)
 }
 
 func LoopPushingMetric(name, instance, addr string, intervalSeconds int) {
@@ -289,15 +411,19 @@ func JoinHostPort(host string, port int) string {
 	return net.JoinHostPort(host, portStr)
 }
 
-
 func StartMetricsServer(ip string, port int) {
 	if port == 0 {
 		return
 	}
-	http.Handle("/metrics", promhttp.HandlerFor(Gather, promhttp.HandlerOpts{}))
+	http.Handle("/metrics", MetricsHandler())
 	log.Fatal(http.ListenAndServe(JoinHostPort(ip, port), nil))
 }
 
+// MetricsHandler serves the metrics in the Prometheus text format
+func MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(Gather, promhttp.HandlerOpts{})
+}
+
 func SourceName(port uint32) string {
 	hostname, err := os.Hostname()
 	if err != nil {
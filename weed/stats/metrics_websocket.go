@@ -0,0 +1,161 @@
+package stats
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"golang.org/x/net/websocket"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+const (
+	metricsPushInterval     = time.Second
+	metricsPushWriteTimeout = 10 * time.Second
+)
+
+// MetricsSnapshot flattens the gathered metrics into {metric_name{label="value"}: value}.
+// A histogram or a summary is reported by its _count and _sum.
+func MetricsSnapshot() map[string]float64 {
+	families, err := Gather.Gather()
+	if err != nil {
+		glog.V(1).Infof("gather metrics: %v", err)
+	}
+	return flattenMetrics(families)
+}
+
+func flattenMetrics(families []*dto.MetricFamily) map[string]float64 {
+	snapshot := make(map[string]float64)
+	for _, family := range families {
+		name := family.GetName()
+		for _, m := range family.GetMetric() {
+			labels := metricLabels(m)
+			switch {
+			case m.GetCounter() != nil:
+				snapshot[name+labels] = m.GetCounter().GetValue()
+			case m.GetGauge() != nil:
+				snapshot[name+labels] = m.GetGauge().GetValue()
+			case m.GetUntyped() != nil:
+				snapshot[name+labels] = m.GetUntyped().GetValue()
+			case m.GetHistogram() != nil:
+				snapshot[name+"_count"+labels] = float64(m.GetHistogram().GetSampleCount())
+				snapshot[name+"_sum"+labels] = m.GetHistogram().GetSampleSum()
+			case m.GetSummary() != nil:
+				snapshot[name+"_count"+labels] = float64(m.GetSummary().GetSampleCount())
+				snapshot[name+"_sum"+labels] = m.GetSummary().GetSampleSum()
+			}
+		}
+	}
+	return snapshot
+}
+
+func metricLabels(m *dto.Metric) string {
+	if len(m.GetLabel()) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(m.GetLabel()))
+	for _, label := range m.GetLabel() {
+		pairs = append(pairs, label.GetName()+"="+`"`+label.GetValue()+`"`)
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// metricsBroadcaster gathers the metrics once per interval for all websocket clients.
+// Each client has room for one pending snapshot; a client still sending the previous one skips the new one,
+// so a slow client never holds back the others.
+type metricsBroadcaster struct {
+	sync.Mutex
+	clients map[chan []byte]struct{}
+	started bool
+}
+
+var wsMetrics = &metricsBroadcaster{clients: make(map[chan []byte]struct{})}
+
+func (b *metricsBroadcaster) subscribe() chan []byte {
+	ch := make(chan []byte, 1)
+	b.Lock()
+	defer b.Unlock()
+	b.clients[ch] = struct{}{}
+	if !b.started {
+		b.started = true
+		go b.loop()
+	}
+	return ch
+}
+
+func (b *metricsBroadcaster) unsubscribe(ch chan []byte) {
+	b.Lock()
+	defer b.Unlock()
+	delete(b.clients, ch)
+}
+
+func (b *metricsBroadcaster) loop() {
+	ticker := time.NewTicker(metricsPushInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		b.Lock()
+		hasClients := len(b.clients) > 0
+		b.Unlock()
+		if !hasClients {
+			continue
+		}
+		data, err := json.Marshal(MetricsSnapshot())
+		if err != nil {
+			glog.V(1).Infof("marshal metrics: %v", err)
+			continue
+		}
+		b.broadcast(data)
+	}
+}
+
+func (b *metricsBroadcaster) broadcast(data []byte) {
+	b.Lock()
+	defer b.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// MetricsWebSocketHandler pushes the metrics snapshot as a json text message every second.
+func MetricsWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	// no origin check, the browser dashboards may be served from other hosts
+	server := websocket.Server{Handler: serveMetricsWebSocket}
+	server.ServeHTTP(w, r)
+}
+
+func serveMetricsWebSocket(ws *websocket.Conn) {
+	defer ws.Close()
+	ch := wsMetrics.subscribe()
+	defer wsMetrics.unsubscribe(ch)
+
+	// the client sends nothing, reading only notices when it goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case data := <-ch:
+			ws.SetWriteDeadline(time.Now().Add(metricsPushWriteTimeout))
+			if err := websocket.Message.Send(ws, string(data)); err != nil {
+				glog.V(3).Infof("send metrics to %s: %v", ws.Request().RemoteAddr, err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFlattenMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests"}, []string{"type", "code"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency"})
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "connections"})
+	registry.MustRegister(counter, histogram, gauge)
+	counter.WithLabelValues("get", "200").Add(3)
+	histogram.Observe(0.5)
+	histogram.Observe(1.5)
+	gauge.Set(7)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	snapshot := flattenMetrics(families)
+
+	expected := map[string]float64{
+		`requests{code="200",type="get"}`: 3,
+		"latency_count":                   2,
+		"latency_sum":                     2,
+		"connections":                     7,
+	}
+	if len(snapshot) != len(expected) {
+		t.Errorf("snapshot %v, expected %v", snapshot, expected)
+	}
+	for name, value := range expected {
+		if snapshot[name] != value {
+			t.Errorf("%s = %v, expected %v", name, snapshot[name], value)
+		}
+	}
+}
+
+func TestMetricsBroadcastSkipsBusyClients(t *testing.T) {
+	b := &metricsBroadcaster{clients: make(map[chan []byte]struct{})}
+	busy := make(chan []byte, 1)
+	idle := make(chan []byte, 1)
+	b.clients[busy] = struct{}{}
+	b.clients[idle] = struct{}{}
+
+	b.broadcast([]byte("1"))
+	<-idle
+	// the busy client has not sent the first snapshot yet
+	b.broadcast([]byte("2"))
+
+	if got := string(<-busy); got != "1" {
+		t.Errorf("busy client got %s, expected the first snapshot", got)
+	}
+	if got := string(<-idle); got != "2" {
+		t.Errorf("idle client got %s, expected the second snapshot", got)
+	}
+}
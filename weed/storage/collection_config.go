@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+	CompressionLz4  = "lz4"
+)
+
+// CollectionConfig is the volume server settings of a collection, loaded from the -collection-config-file, e.g.,
+//
+//	collections:
+//	  - name: logs
+//	    compression: zstd
+//	  - name: images
+//	    compression: none
+//
+// The compression applies to the needles written after the config is loaded.
+// The codec is recorded in the needle flags, so the needles written with different codecs can always be read.
+type CollectionConfig struct {
+	Name        string `mapstructure:"name"`
+	Compression string `mapstructure:"compression"`
+}
+
+func LoadCollectionConfigs(configFile string) (map[string]*CollectionConfig, error) {
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read collection config %s: %v", configFile, err)
+	}
+	var configs []*CollectionConfig
+	if err := v.UnmarshalKey("collections", &configs); err != nil {
+		return nil, fmt.Errorf("parse collection config %s: %v", configFile, err)
+	}
+	collectionConfigs := make(map[string]*CollectionConfig)
+	for _, c := range configs {
+		switch c.Compression {
+		case "", CompressionNone, CompressionGzip, CompressionZstd, CompressionLz4:
+		default:
+			return nil, fmt.Errorf("collection config %s: collection %q has unknown compression %q", configFile, c.Name, c.Compression)
+		}
+		collectionConfigs[c.Name] = c
+	}
+	return collectionConfigs, nil
+}
+
+// compressNeedle compresses the needle data with the codec of the collection, if it saves at least 10%.
+// The data compressed with another codec, e.g., gzipped by the client, is re-compressed.
+// The chunk manifests are left gzipped, as their readers expect.
+func (c *CollectionConfig) compressNeedle(n *needle.Needle) error {
+	if c == nil || c.Compression == "" || c.Compression == CompressionNone || len(n.Data) == 0 || n.IsChunkedManifest() {
+		return nil
+	}
+	data := n.Data
+	if n.IsCompressed() {
+		if n.Compression() == c.Compression {
+			return nil
+		}
+		uncompressed, err := util.DecompressDataAs(data, n.Compression())
+		if err != nil {
+			// unknown compression, keep as is
+			return nil
+		}
+		data = uncompressed
+	}
+
+	compressed, err := compressData(data, c.Compression)
+	if err != nil {
+		return fmt.Errorf("compress with %s: %v", c.Compression, err)
+	}
+	if len(compressed)*10 >= len(data)*9 {
+		return nil
+	}
+	n.Data = compressed
+	n.SetCompression(c.Compression)
+	n.Checksum = needle.NewCRC(n.Data)
+	return nil
+}
+
+func compressData(data []byte, compression string) ([]byte, error) {
+	switch compression {
+	case CompressionGzip:
+		return util.GzipData(data)
+	case CompressionZstd:
+		return util.ZstdData(data)
+	case CompressionLz4:
+		return util.Lz4Data(data)
+	}
+	return nil, fmt.Errorf("unknown compression %s", compression)
+}
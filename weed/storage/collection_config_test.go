@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/seaweedfs/seaweedfs/weed/util"
+)
+
+func TestCollectionCompressionRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("volume creation: %v", err)
+	}
+	defer v.Close()
+
+	original := []byte(strings.Repeat("some compressible text ", 200))
+	gzipped, _ := util.GzipData(original)
+	zstdData, _ := util.ZstdData(original)
+
+	tests := []struct {
+		compression  string
+		data         []byte
+		isCompressed bool
+		stored       []byte // the data as stored, before any compression
+	}{
+		{CompressionZstd, original, false, original},
+		{CompressionLz4, original, false, original},
+		{CompressionGzip, original, false, original},
+		// gzipped by the client, re-compressed with the codec of the collection
+		{CompressionZstd, gzipped, true, original},
+		{CompressionNone, original, false, original},
+		// the magic number of the data does not matter, only the needle flags
+		{CompressionNone, zstdData, false, zstdData},
+	}
+
+	for i, tt := range tests {
+		id := uint64(i + 1)
+		n := &needle.Needle{Id: types.Uint64ToNeedleId(id), Data: append([]byte{}, tt.data...)}
+		if tt.isCompressed {
+			n.SetIsCompressed()
+		}
+		n.Checksum = needle.NewCRC(n.Data)
+
+		config := &CollectionConfig{Compression: tt.compression}
+		if err := config.compressNeedle(n); err != nil {
+			t.Fatalf("compress %s: %v", tt.compression, err)
+		}
+		if _, _, _, err := v.writeNeedle2(n, true, false); err != nil {
+			t.Fatalf("write %s: %v", tt.compression, err)
+		}
+
+		// read with a different codec configured, or none at all
+		readNeedle := &needle.Needle{Id: types.Uint64ToNeedleId(id)}
+		if _, err := v.readNeedle(readNeedle, nil, nil); err != nil {
+			t.Fatalf("read %s: %v", tt.compression, err)
+		}
+		if tt.compression == CompressionNone {
+			if readNeedle.IsCompressed() || readNeedle.Compression() != "" || !bytes.Equal(readNeedle.Data, tt.stored) {
+				t.Errorf("%s: unexpected compressed data", tt.compression)
+			}
+			continue
+		}
+		if readNeedle.Compression() != tt.compression {
+			t.Errorf("%s: compressed with %q", tt.compression, readNeedle.Compression())
+			continue
+		}
+		data, err := util.DecompressDataAs(readNeedle.Data, readNeedle.Compression())
+		if err != nil {
+			t.Fatalf("decompress %s: %v", tt.compression, err)
+		}
+		if !bytes.Equal(data, tt.stored) {
+			t.Errorf("%s: decompressed data mismatch", tt.compression)
+		}
+	}
+}
+
+func TestLoadCollectionConfigs(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "collections.yaml")
+	os.WriteFile(configFile, []byte(`
+collections:
+  - name: logs
+    compression: zstd
+  - name: Images
+    compression: none
+`), 0644)
+
+	configs, err := LoadCollectionConfigs(configFile)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if configs["logs"].Compression != CompressionZstd || configs["Images"].Compression != CompressionNone {
+		t.Errorf("unexpected configs %+v", configs)
+	}
+	if configs["other"] != nil {
+		t.Errorf("unexpected config of other collection")
+	}
+
+	os.WriteFile(configFile, []byte(`
+collections:
+  - name: logs
+    compression: brotli
+`), 0644)
+	if _, err := LoadCollectionConfigs(configFile); err == nil {
+		t.Errorf("unknown compression is accepted")
+	}
+}
+
+func BenchmarkNeedleDecompression(b *testing.B) {
+	var data []byte
+	for i := 0; len(data) < 1024*1024; i++ {
+		data = append(data, fmt.Sprintf(`{"id":%d,"name":"file-%d","size":%d,"mime":"text/plain"}`+"\n", i, i%1000, i*37%100000)...)
+	}
+
+	for _, compression := range []string{CompressionGzip, CompressionZstd} {
+		compressed, err := compressData(data, compression)
+		if err != nil {
+			b.Fatalf("compress %s: %v", compression, err)
+		}
+		b.Run(compression, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportMetric(float64(len(data))/float64(len(compressed)), "ratio")
+			for i := 0; i < b.N; i++ {
+				if _, err := util.DecompressData(compressed); err != nil {
+					b.Fatalf("decompress %s: %v", compression, err)
+				}
+			}
+		})
+	}
+}
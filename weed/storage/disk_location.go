@@ -35,6 +35,7 @@ type DiskLocation struct {
 	ecVolumesLock sync.RWMutex
 
 	isDiskSpaceLow bool
+	isReadOnly     bool // serving the existing volumes only
 }
 
 func GenerateDirUuid(dir string) (dirUuidString string, err error) {
@@ -361,10 +362,34 @@ func (l *DiskLocation) VolumesLen() int {
 	return len(l.volumes)
 }
 
-func (l *DiskLocation) SetStopping() {
+// occupiedVolumeSlots is the max volume count which leaves no free slots, as counted by the master
+func (l *DiskLocation) occupiedVolumeSlots() (slots int32) {
+	l.volumesLock.RLock()
+	for _, v := range l.volumes {
+		if !v.HasRemoteFile() {
+			slots++
+		}
+	}
+	l.volumesLock.RUnlock()
+
+	var ecShardCount int32
+	l.ecVolumesLock.RLock()
+	for _, ecVolume := range l.ecVolumes {
+		ecShardCount += int32(len(ecVolume.Shards))
+	}
+	l.ecVolumesLock.RUnlock()
+	if ecShardCount > 0 {
+		slots += ecShardCount/erasure_coding.DataShardsCount + 1
+	}
+	return
+}
+
+func (l *DiskLocation) SetStopping() (err error) {
 	l.volumesLock.Lock()
 	for _, v := range l.volumes {
-		v.SyncToDisk()
+		if syncErr := v.Sync(); syncErr != nil {
+			err = syncErr
+		}
 	}
 	l.volumesLock.Unlock()
 
@@ -0,0 +1,56 @@
+package erasure_coding
+
+import (
+	"fmt"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
+)
+
+// MaxShardCount is the most shards of one ec volume, limited by the ShardBits
+const MaxShardCount = 32
+
+// ECConfig is the erasure coding stripe of a volume: the .dat file is split into DataShards shards,
+// and ParityShards shards are computed from them. Any DataShards shards can reconstruct the data.
+type ECConfig struct {
+	DataShards   int `json:"dataShards"`
+	ParityShards int `json:"parityShards"`
+}
+
+var DefaultECConfig = ECConfig{DataShards: DataShardsCount, ParityShards: ParityShardsCount}
+
+// NewECConfig returns the stripe of the protobuf messages, the default 10+4 if not set
+func NewECConfig(dataShards, parityShards uint32) ECConfig {
+	if dataShards == 0 {
+		return DefaultECConfig
+	}
+	return ECConfig{DataShards: int(dataShards), ParityShards: int(parityShards)}
+}
+
+// LoadECConfig returns the stripe kept in the .vif file of the ec volume
+func LoadECConfig(volumeInfo *volume_server_pb.VolumeInfo) ECConfig {
+	return NewECConfig(volumeInfo.GetEcDataShards(), volumeInfo.GetEcParityShards())
+}
+
+// SaveTo keeps the stripe in the .vif file of the ec volume
+func (c ECConfig) SaveTo(volumeInfo *volume_server_pb.VolumeInfo) {
+	volumeInfo.EcDataShards = uint32(c.DataShards)
+	volumeInfo.EcParityShards = uint32(c.ParityShards)
+}
+
+func (c ECConfig) TotalShards() int {
+	return c.DataShards + c.ParityShards
+}
+
+func (c ECConfig) Validate() error {
+	if c.DataShards < 1 || c.ParityShards < 1 {
+		return fmt.Errorf("erasure coding %s needs at least 1 data shard and 1 parity shard", c)
+	}
+	if c.TotalShards() > MaxShardCount {
+		return fmt.Errorf("erasure coding %s has more than %d shards", c, MaxShardCount)
+	}
+	return nil
+}
+
+func (c ECConfig) String() string {
+	return fmt.Sprintf("%d+%d", c.DataShards, c.ParityShards)
+}
@@ -10,13 +10,14 @@ type Interval struct {
 	Size                types.Size
 	IsLargeBlock        bool
 	LargeBlockRowsCount int
+	DataShards          int
 }
 
-func LocateData(largeBlockLength, smallBlockLength int64, datSize int64, offset int64, size types.Size) (intervals []Interval) {
-	blockIndex, isLargeBlock, innerBlockOffset := locateOffset(largeBlockLength, smallBlockLength, datSize, offset)
+func LocateData(dataShards int, largeBlockLength, smallBlockLength int64, datSize int64, offset int64, size types.Size) (intervals []Interval) {
+	blockIndex, isLargeBlock, innerBlockOffset := locateOffset(dataShards, largeBlockLength, smallBlockLength, datSize, offset)
 
-	// adding DataShardsCount*smallBlockLength to ensure we can derive the number of large block size from a shard size
-	nLargeBlockRows := int((datSize + DataShardsCount*smallBlockLength) / (largeBlockLength * DataShardsCount))
+	// adding dataShards*smallBlockLength to ensure we can derive the number of large block size from a shard size
+	nLargeBlockRows := int((datSize + int64(dataShards)*smallBlockLength) / (largeBlockLength * int64(dataShards)))
 
 	for size > 0 {
 		interval := Interval{
@@ -24,6 +25,7 @@ func LocateData(largeBlockLength, smallBlockLength int64, datSize int64, offset
 			InnerBlockOffset:    innerBlockOffset,
 			IsLargeBlock:        isLargeBlock,
 			LargeBlockRowsCount: nLargeBlockRows,
+			DataShards:          dataShards,
 		}
 
 		blockRemaining := largeBlockLength - innerBlockOffset
@@ -41,7 +43,7 @@ func LocateData(largeBlockLength, smallBlockLength int64, datSize int64, offset
 
 		size -= interval.Size
 		blockIndex += 1
-		if isLargeBlock && blockIndex == nLargeBlockRows*DataShardsCount {
+		if isLargeBlock && blockIndex == nLargeBlockRows*dataShards {
 			isLargeBlock = false
 			blockIndex = 0
 		}
@@ -51,9 +53,9 @@ func LocateData(largeBlockLength, smallBlockLength int64, datSize int64, offset
 	return
 }
 
-func locateOffset(largeBlockLength, smallBlockLength int64, datSize int64, offset int64) (blockIndex int, isLargeBlock bool, innerBlockOffset int64) {
-	largeRowSize := largeBlockLength * DataShardsCount
-	nLargeBlockRows := datSize / (largeBlockLength * DataShardsCount)
+func locateOffset(dataShards int, largeBlockLength, smallBlockLength int64, datSize int64, offset int64) (blockIndex int, isLargeBlock bool, innerBlockOffset int64) {
+	largeRowSize := largeBlockLength * int64(dataShards)
+	nLargeBlockRows := datSize / largeRowSize
 
 	// if offset is within the large block area
 	if offset < nLargeBlockRows*largeRowSize {
@@ -76,12 +78,12 @@ func locateOffsetWithinBlocks(blockLength int64, offset int64) (blockIndex int,
 
 func (interval Interval) ToShardIdAndOffset(largeBlockSize, smallBlockSize int64) (ShardId, int64) {
 	ecFileOffset := interval.InnerBlockOffset
-	rowIndex := interval.BlockIndex / DataShardsCount
+	rowIndex := interval.BlockIndex / interval.DataShards
 	if interval.IsLargeBlock {
 		ecFileOffset += int64(rowIndex) * largeBlockSize
 	} else {
 		ecFileOffset += int64(interval.LargeBlockRowsCount)*largeBlockSize + int64(rowIndex)*smallBlockSize
 	}
-	ecFileIndex := interval.BlockIndex % DataShardsCount
+	ecFileIndex := interval.BlockIndex % interval.DataShards
 	return ShardId(ecFileIndex), ecFileOffset
 }
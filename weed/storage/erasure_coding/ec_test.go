@@ -22,7 +22,7 @@ func TestEncodingDecoding(t *testing.T) {
 	bufferSize := 50
 	baseFileName := "1"
 
-	err := generateEcFiles(baseFileName, bufferSize, largeBlockSize, smallBlockSize)
+	err := generateEcFiles(baseFileName, DefaultECConfig, bufferSize, largeBlockSize, smallBlockSize)
 	if err != nil {
 		t.Logf("generateEcFiles: %v", err)
 	}
@@ -32,16 +32,31 @@ func TestEncodingDecoding(t *testing.T) {
 		t.Logf("WriteSortedFileFromIdx: %v", err)
 	}
 
-	err = validateFiles(baseFileName)
+	err = validateFiles(baseFileName, DefaultECConfig)
 	if err != nil {
 		t.Logf("WriteSortedFileFromIdx: %v", err)
 	}
 
-	removeGeneratedFiles(baseFileName)
+	removeGeneratedFiles(baseFileName, DefaultECConfig)
 
 }
 
-func validateFiles(baseFileName string) error {
+func TestEncodingDecodingWithECConfig(t *testing.T) {
+	bufferSize := 50
+	baseFileName := "1"
+	ecConfig := ECConfig{DataShards: 6, ParityShards: 2}
+
+	if err := generateEcFiles(baseFileName, ecConfig, bufferSize, largeBlockSize, smallBlockSize); err != nil {
+		t.Fatalf("generateEcFiles: %v", err)
+	}
+	defer removeGeneratedFiles(baseFileName, ecConfig)
+
+	if err := validateFiles(baseFileName, ecConfig); err != nil {
+		t.Errorf("validateFiles %s: %v", ecConfig, err)
+	}
+}
+
+func validateFiles(baseFileName string, ecConfig ECConfig) error {
 	nm, err := readNeedleMap(baseFileName)
 	defer nm.Close()
 	if err != nil {
@@ -59,11 +74,11 @@ func validateFiles(baseFileName string) error {
 		return fmt.Errorf("failed to stat dat file: %v", err)
 	}
 
-	ecFiles, err := openEcFiles(baseFileName, true)
+	ecFiles, err := openEcFiles(baseFileName, ecConfig.TotalShards(), true)
 	defer closeEcFiles(ecFiles)
 
 	err = nm.AscendingVisit(func(value needle_map.NeedleValue) error {
-		return assertSame(datFile, fi.Size(), ecFiles, value.Offset, value.Size)
+		return assertSame(ecConfig, datFile, fi.Size(), ecFiles, value.Offset, value.Size)
 	})
 	if err != nil {
 		return fmt.Errorf("failed to check ec files: %v", err)
@@ -71,14 +86,14 @@ func validateFiles(baseFileName string) error {
 	return nil
 }
 
-func assertSame(datFile *os.File, datSize int64, ecFiles []*os.File, offset types.Offset, size types.Size) error {
+func assertSame(ecConfig ECConfig, datFile *os.File, datSize int64, ecFiles []*os.File, offset types.Offset, size types.Size) error {
 
 	data, err := readDatFile(datFile, offset, size)
 	if err != nil {
 		return fmt.Errorf("failed to read dat file: %v", err)
 	}
 
-	ecData, err := readEcFile(datSize, ecFiles, offset, size)
+	ecData, err := readEcFile(ecConfig, datSize, ecFiles, offset, size)
 	if err != nil {
 		return fmt.Errorf("failed to read ec file: %v", err)
 	}
@@ -103,12 +118,12 @@ func readDatFile(datFile *os.File, offset types.Offset, size types.Size) ([]byte
 	return data, nil
 }
 
-func readEcFile(datSize int64, ecFiles []*os.File, offset types.Offset, size types.Size) (data []byte, err error) {
+func readEcFile(ecConfig ECConfig, datSize int64, ecFiles []*os.File, offset types.Offset, size types.Size) (data []byte, err error) {
 
-	intervals := LocateData(largeBlockSize, smallBlockSize, datSize, offset.ToActualOffset(), size)
+	intervals := LocateData(ecConfig.DataShards, largeBlockSize, smallBlockSize, datSize, offset.ToActualOffset(), size)
 
 	for i, interval := range intervals {
-		if d, e := readOneInterval(interval, ecFiles); e != nil {
+		if d, e := readOneInterval(ecConfig, interval, ecFiles); e != nil {
 			return nil, e
 		} else {
 			if i == 0 {
@@ -122,14 +137,14 @@ func readEcFile(datSize int64, ecFiles []*os.File, offset types.Offset, size typ
 	return data, nil
 }
 
-func readOneInterval(interval Interval, ecFiles []*os.File) (data []byte, err error) {
+func readOneInterval(ecConfig ECConfig, interval Interval, ecFiles []*os.File) (data []byte, err error) {
 
 	ecFileIndex, ecFileOffset := interval.ToShardIdAndOffset(largeBlockSize, smallBlockSize)
 
 	data = make([]byte, interval.Size)
 	err = readFromFile(ecFiles[ecFileIndex], data, ecFileOffset)
 	{ // do some ec testing
-		ecData, err := readFromOtherEcFiles(ecFiles, int(ecFileIndex), ecFileOffset, interval.Size)
+		ecData, err := readFromOtherEcFiles(ecConfig, ecFiles, int(ecFileIndex), ecFileOffset, interval.Size)
 		if err != nil {
 			return nil, fmt.Errorf("ec reconstruct error: %v", err)
 		}
@@ -140,15 +155,15 @@ func readOneInterval(interval Interval, ecFiles []*os.File) (data []byte, err er
 	return
 }
 
-func readFromOtherEcFiles(ecFiles []*os.File, ecFileIndex int, ecFileOffset int64, size types.Size) (data []byte, err error) {
-	enc, err := reedsolomon.New(DataShardsCount, ParityShardsCount)
+func readFromOtherEcFiles(ecConfig ECConfig, ecFiles []*os.File, ecFileIndex int, ecFileOffset int64, size types.Size) (data []byte, err error) {
+	enc, err := reedsolomon.New(ecConfig.DataShards, ecConfig.ParityShards)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create encoder: %v", err)
 	}
 
-	bufs := make([][]byte, TotalShardsCount)
-	for i := 0; i < DataShardsCount; {
-		n := int(rand.Int31n(TotalShardsCount))
+	bufs := make([][]byte, ecConfig.TotalShards())
+	for i := 0; i < ecConfig.DataShards; {
+		n := int(rand.Int31n(int32(ecConfig.TotalShards())))
 		if n == ecFileIndex || bufs[n] != nil {
 			continue
 		}
@@ -178,8 +193,8 @@ func readFromFile(file *os.File, data []byte, ecFileOffset int64) (err error) {
 	return
 }
 
-func removeGeneratedFiles(baseFileName string) {
-	for i := 0; i < DataShardsCount+ParityShardsCount; i++ {
+func removeGeneratedFiles(baseFileName string, ecConfig ECConfig) {
+	for i := 0; i < ecConfig.TotalShards(); i++ {
 		fname := fmt.Sprintf("%s.ec%02d", baseFileName, i)
 		os.Remove(fname)
 	}
@@ -187,15 +202,15 @@ func removeGeneratedFiles(baseFileName string) {
 }
 
 func TestLocateData(t *testing.T) {
-	intervals := LocateData(largeBlockSize, smallBlockSize, DataShardsCount*largeBlockSize+1, DataShardsCount*largeBlockSize, 1)
+	intervals := LocateData(DataShardsCount, largeBlockSize, smallBlockSize, DataShardsCount*largeBlockSize+1, DataShardsCount*largeBlockSize, 1)
 	if len(intervals) != 1 {
 		t.Errorf("unexpected interval size %d", len(intervals))
 	}
-	if !intervals[0].sameAs(Interval{0, 0, 1, false, 1}) {
+	if !intervals[0].sameAs(Interval{0, 0, 1, false, 1, DataShardsCount}) {
 		t.Errorf("unexpected interval %+v", intervals[0])
 	}
 
-	intervals = LocateData(largeBlockSize, smallBlockSize, DataShardsCount*largeBlockSize+1, DataShardsCount*largeBlockSize/2+100, DataShardsCount*largeBlockSize+1-DataShardsCount*largeBlockSize/2-100)
+	intervals = LocateData(DataShardsCount, largeBlockSize, smallBlockSize, DataShardsCount*largeBlockSize+1, DataShardsCount*largeBlockSize/2+100, DataShardsCount*largeBlockSize+1-DataShardsCount*largeBlockSize/2-100)
 	fmt.Printf("%+v\n", intervals)
 }
 
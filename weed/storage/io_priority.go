@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// IoClass is the disk I/O scheduling class, honored by the cfq, bfq and mq-deadline schedulers.
+type IoClass int
+
+const (
+	IoClassNone IoClass = iota
+	IoClassRealtime
+	IoClassBestEffort
+	IoClassIdle
+)
+
+const MaxIoPriorityLevel = 7
+
+func ParseIoClass(class string) (IoClass, error) {
+	switch class {
+	case "", "none":
+		return IoClassNone, nil
+	case "realtime":
+		return IoClassRealtime, nil
+	case "best-effort":
+		return IoClassBestEffort, nil
+	case "idle":
+		return IoClassIdle, nil
+	}
+	return IoClassNone, fmt.Errorf("unknown disk io class %q, expecting none, best-effort, realtime or idle", class)
+}
+
+// SetDiskIoPriority sets the I/O class and priority level of all threads of the process.
+// The level, 0 for the highest and 7 for the lowest, only applies to the realtime and best-effort classes.
+func SetDiskIoPriority(class IoClass, level int) error {
+	if class == IoClassNone {
+		return nil
+	}
+	if level < 0 || level > MaxIoPriorityLevel {
+		return fmt.Errorf("disk io priority %d is not in [0, %d]", level, MaxIoPriorityLevel)
+	}
+	return setProcessIoPriority(class, level)
+}
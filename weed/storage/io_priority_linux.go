@@ -0,0 +1,80 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+)
+
+func ioprioValue(class IoClass, level int) uintptr {
+	return uintptr(class)<<ioprioClassShift | uintptr(level)
+}
+
+// setThreadIoPriority sets the priority of one thread, the calling thread if tid is 0
+func setThreadIoPriority(tid int, ioprio uintptr) error {
+	if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), ioprio); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func getThreadIoPriority() (uintptr, error) {
+	ioprio, _, errno := unix.Syscall(unix.SYS_IOPRIO_GET, ioprioWhoProcess, 0, 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return ioprio, nil
+}
+
+// setProcessIoPriority sets every existing thread, and the threads started later inherit it from their creators.
+func setProcessIoPriority(class IoClass, level int) error {
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		if err = setThreadIoPriority(tid, ioprioValue(class, level)); err != nil && err != unix.ESRCH {
+			return err
+		}
+	}
+	return nil
+}
+
+// withIdleIoPriority runs fn on a thread in the idle class, so its disk reads and writes
+// only get the disk time the other requests leave unused.
+func withIdleIoPriority(fn func() error) error {
+	runtime.LockOSThread()
+	previous, err := getThreadIoPriority()
+	if err == nil {
+		err = setThreadIoPriority(0, ioprioValue(IoClassIdle, 0))
+	}
+	if err != nil {
+		runtime.UnlockOSThread()
+		glog.V(1).Infof("set idle io priority: %v", err)
+		return fn()
+	}
+	fnErr := fn()
+	if err = setThreadIoPriority(0, previous); err != nil {
+		// keep the thread locked, it exits with the goroutine instead of serving others in the idle class
+		glog.Warningf("restore io priority: %v", err)
+		return fnErr
+	}
+	runtime.UnlockOSThread()
+	return fnErr
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"testing"
+)
+
+func TestParseIoClass(t *testing.T) {
+	for class, expected := range map[string]IoClass{
+		"":            IoClassNone,
+		"none":        IoClassNone,
+		"realtime":    IoClassRealtime,
+		"best-effort": IoClassBestEffort,
+		"idle":        IoClassIdle,
+	} {
+		if got, err := ParseIoClass(class); err != nil || got != expected {
+			t.Errorf("ParseIoClass(%q) = %v, %v, expected %v", class, got, err, expected)
+		}
+	}
+	if _, err := ParseIoClass("besteffort"); err == nil {
+		t.Errorf("expected an error for an unknown class")
+	}
+	if err := SetDiskIoPriority(IoClassBestEffort, 8); err == nil {
+		t.Errorf("expected an error for priority 8")
+	}
+}
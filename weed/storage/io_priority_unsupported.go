@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package storage
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func setProcessIoPriority(class IoClass, level int) error {
+	return fmt.Errorf("disk io priority is not supported on %s", runtime.GOOS)
+}
+
+func withIdleIoPriority(fn func() error) error {
+	return fn()
+}
@@ -27,17 +27,18 @@ type Needle struct {
 	Id     NeedleId `comment:"needle id"`
 	Size   Size     `comment:"sum of DataSize,Data,NameSize,Name,MimeSize,Mime"`
 
-	DataSize     uint32 `comment:"Data size"` //version2
-	Data         []byte `comment:"The actual file data"`
-	Flags        byte   `comment:"boolean flags"` //version2
-	NameSize     uint8  //version2
-	Name         []byte `comment:"maximum 255 characters"` //version2
-	MimeSize     uint8  //version2
-	Mime         []byte `comment:"maximum 255 characters"` //version2
-	PairsSize    uint16 //version2
-	Pairs        []byte `comment:"additional name value pairs, json format, maximum 64kB"`
-	LastModified uint64 //only store LastModifiedBytesLength bytes, which is 5 bytes to disk
-	Ttl          *TTL
+	DataSize         uint32 `comment:"Data size"` //version2
+	Data             []byte `comment:"The actual file data"`
+	Flags            byte   `comment:"boolean flags"` //version2
+	NameSize         uint8  //version2
+	Name             []byte `comment:"maximum 255 characters"` //version2
+	MimeSize         uint8  //version2
+	Mime             []byte `comment:"maximum 255 characters"` //version2
+	PairsSize        uint16 //version2
+	Pairs            []byte `comment:"additional name value pairs, json format, maximum 64kB"`
+	CompressionCodec byte   `comment:"the codec of the compressed data, gzip if not set"` //version2
+	LastModified     uint64 //only store LastModifiedBytesLength bytes, which is 5 bytes to disk
+	Ttl              *TTL
 
 	Checksum   CRC    `comment:"CRC32 to check integrity"`
 	AppendAtNs uint64 `comment:"append timestamp in nano seconds"` //version3
@@ -82,9 +83,9 @@ func CreateNeedleFromRequest(r *http.Request, fixJpgOrientation bool, sizeLimit
 			n.SetHasPairs()
 		}
 	}
-	if pu.IsGzipped {
+	if pu.ContentEncoding != "" {
 		// println(r.URL.Path, "is set to compressed", pu.FileName, pu.IsGzipped, "dataSize", pu.OriginalDataSize)
-		n.SetIsCompressed()
+		n.SetCompression(pu.ContentEncoding)
 	}
 	if n.LastModified == 0 {
 		n.LastModified = uint64(time.Now().Unix())
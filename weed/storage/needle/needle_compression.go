@@ -0,0 +1,32 @@
+package needle
+
+// the compression codecs of the compressed needles, by the codec byte stored when FlagHasCompressionCodec is set.
+// The compressed needles without the codec byte are gzipped, as written before the codecs were added.
+var compressionCodecs = []string{"gzip", "zstd", "lz4"}
+
+// SetCompression marks the needle data as compressed with the content encoding: gzip, zstd or lz4.
+func (n *Needle) SetCompression(contentEncoding string) {
+	n.SetIsCompressed()
+	n.Flags = n.Flags &^ FlagHasCompressionCodec
+	n.CompressionCodec = 0
+	for codec, encoding := range compressionCodecs {
+		if encoding == contentEncoding && codec > 0 {
+			n.Flags = n.Flags | FlagHasCompressionCodec
+			n.CompressionCodec = byte(codec)
+		}
+	}
+}
+
+// Compression returns the content encoding of the compressed needle data, or "" if the data is not compressed.
+func (n *Needle) Compression() string {
+	if !n.IsCompressed() {
+		return ""
+	}
+	if !n.HasCompressionCodec() {
+		return compressionCodecs[0]
+	}
+	if int(n.CompressionCodec) < len(compressionCodecs) {
+		return compressionCodecs[n.CompressionCodec]
+	}
+	return ""
+}
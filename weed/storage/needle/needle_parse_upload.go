@@ -24,7 +24,9 @@ type ParsedUpload struct {
 	MimeType    string
 	PairMap     map[string]string
 	IsGzipped   bool
-	// IsZstd           bool
+	// ContentEncoding is the codec of the compressed data: gzip, zstd or lz4, or "" if not compressed.
+	// The volume servers replicate the needles of a compressed collection with zstd or lz4.
+	ContentEncoding  string
 	OriginalDataSize int
 	ModifiedTime     uint64
 	Ttl              *TTL
@@ -58,8 +60,8 @@ func ParseUpload(r *http.Request, sizeLimit int64, bytesBuffer *bytes.Buffer) (p
 	pu.OriginalDataSize = len(pu.Data)
 	pu.UncompressedData = pu.Data
 	// println("received data", len(pu.Data), "isGzipped", pu.IsGzipped, "mime", pu.MimeType, "name", pu.FileName)
-	if pu.IsGzipped {
-		if unzipped, e := util.DecompressData(pu.Data); e == nil {
+	if pu.ContentEncoding != "" {
+		if unzipped, e := util.DecompressDataAs(pu.Data, pu.ContentEncoding); e == nil {
 			pu.OriginalDataSize = len(unzipped)
 			pu.UncompressedData = unzipped
 			// println("ungzipped data size", len(unzipped))
@@ -79,6 +81,7 @@ func ParseUpload(r *http.Request, sizeLimit int64, bytesBuffer *bytes.Buffer) (p
 				if len(compressedData)*10 < len(pu.Data)*9 {
 					pu.Data = compressedData
 					pu.IsGzipped = true
+					pu.ContentEncoding = "gzip"
 				}
 				// println("gzipped data size", len(compressedData))
 			}
@@ -100,8 +103,7 @@ func ParseUpload(r *http.Request, sizeLimit int64, bytesBuffer *bytes.Buffer) (p
 }
 
 func parsePut(r *http.Request, sizeLimit int64, pu *ParsedUpload) error {
-	pu.IsGzipped = r.Header.Get("Content-Encoding") == "gzip"
-	// pu.IsZstd = r.Header.Get("Content-Encoding") == "zstd"
+	pu.setContentEncoding(r.Header.Get("Content-Encoding"))
 	pu.MimeType = r.Header.Get("Content-Type")
 	pu.FileName = ""
 	dataSize, err := pu.bytesBuffer.ReadFrom(io.LimitReader(r.Body, sizeLimit+1))
@@ -199,8 +201,15 @@ func parseMultipart(r *http.Request, sizeLimit int64, pu *ParsedUpload) (e error
 		}
 
 	}
-	pu.IsGzipped = part.Header.Get("Content-Encoding") == "gzip"
-	// pu.IsZstd = part.Header.Get("Content-Encoding") == "zstd"
+	pu.setContentEncoding(part.Header.Get("Content-Encoding"))
 
 	return
 }
+
+func (pu *ParsedUpload) setContentEncoding(contentEncoding string) {
+	switch contentEncoding {
+	case "gzip", "zstd", "lz4":
+		pu.ContentEncoding = contentEncoding
+	}
+	pu.IsGzipped = contentEncoding == "gzip"
+}
@@ -18,6 +18,7 @@ const (
 	FlagHasLastModifiedDate = 0x08
 	FlagHasTtl              = 0x10
 	FlagHasPairs            = 0x20
+	FlagHasCompressionCodec = 0x40
 	FlagIsChunkManifest     = 0x80
 	LastModifiedBytesLength = 5
 	TtlBytesLength          = 2
@@ -174,6 +175,10 @@ func (n *Needle) readNeedleDataVersion2NonData(bytes []byte) (index int, err err
 		n.Pairs = bytes[index:end]
 		index = end
 	}
+	if index < lenBytes && n.HasCompressionCodec() {
+		n.CompressionCodec = bytes[index]
+		index = index + 1
+	}
 	return index, nil
 }
 
@@ -256,6 +261,9 @@ func (n *Needle) IsCompressed() bool {
 func (n *Needle) SetIsCompressed() {
 	n.Flags = n.Flags | FlagIsCompressed
 }
+func (n *Needle) HasCompressionCodec() bool {
+	return n.Flags&FlagHasCompressionCodec > 0
+}
 func (n *Needle) HasName() bool {
 	return n.Flags&FlagHasName > 0
 }
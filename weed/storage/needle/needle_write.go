@@ -61,6 +61,9 @@ func (n *Needle) prepareWriteBuffer(version Version, writeBytes *bytes.Buffer) (
 			if n.HasPairs() {
 				n.Size += 2 + Size(n.PairsSize)
 			}
+			if n.HasCompressionCodec() {
+				n.Size = n.Size + 1
+			}
 		} else {
 			n.Size = 0
 		}
@@ -95,6 +98,10 @@ func (n *Needle) prepareWriteBuffer(version Version, writeBytes *bytes.Buffer) (
 				writeBytes.Write(header[0:2])
 				writeBytes.Write(n.Pairs)
 			}
+			if n.HasCompressionCodec() {
+				util.Uint8toBytes(header[0:1], n.CompressionCodec)
+				writeBytes.Write(header[0:1])
+			}
 		}
 		padding := PaddingLength(n.Size, version)
 		util.Uint32toBytes(header[0:NeedleChecksumSize], uint32(n.Checksum))
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	. "github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+type needleCacheKey struct {
+	volumeId needle.VolumeId
+	needleId NeedleId
+}
+
+type needleCacheItem struct {
+	key    needleCacheKey
+	needle *needle.Needle
+	count  int
+	size   int64
+}
+
+// NeedleCache keeps the recently read needles in memory, evicting the least recently used ones
+// when the total size of their data goes over the limit.
+// The cached needles are shared by the readers, which should not change them.
+type NeedleCache struct {
+	sync.Mutex
+	maxBytes  int64
+	size      int64
+	items     map[needleCacheKey]*list.Element
+	lru       *list.List
+	hits      uint64
+	misses    uint64
+	evictions uint64
+	// changed by every deletion, so a read started before a write does not cache the old content
+	generation uint64
+}
+
+func NewNeedleCache(maxBytes int64) *NeedleCache {
+	return &NeedleCache{
+		maxBytes: maxBytes,
+		items:    make(map[needleCacheKey]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// needleCacheItemOverhead roughly counts the memory besides the needle data
+const needleCacheItemOverhead = 256
+
+func (c *NeedleCache) Get(vid needle.VolumeId, id NeedleId) (*needle.Needle, int, bool) {
+	c.Lock()
+	defer c.Unlock()
+	element, found := c.items[needleCacheKey{vid, id}]
+	if found {
+		c.hits++
+		c.lru.MoveToFront(element)
+	} else {
+		c.misses++
+	}
+	stats.VolumeServerCacheHitRatioGauge.Set(float64(c.hits) / float64(c.hits+c.misses))
+	if !found {
+		return nil, 0, false
+	}
+	item := element.Value.(*needleCacheItem)
+	return item.needle, item.count, true
+}
+
+// Generation should be taken before reading the needle to cache.
+func (c *NeedleCache) Generation() uint64 {
+	c.Lock()
+	defer c.Unlock()
+	return c.generation
+}
+
+// Set caches the needle read since the generation, unless its data is larger than 1/8 of the limit.
+func (c *NeedleCache) Set(vid needle.VolumeId, n *needle.Needle, count int, generation uint64) {
+	size := int64(len(n.Data)+len(n.Name)+len(n.Mime)+len(n.Pairs)) + needleCacheItemOverhead
+	if size > c.maxBytes/8 {
+		return
+	}
+	key := needleCacheKey{vid, n.Id}
+	c.Lock()
+	defer c.Unlock()
+	if generation != c.generation {
+		return
+	}
+	if element, found := c.items[key]; found {
+		c.remove(element)
+	}
+	c.items[key] = c.lru.PushFront(&needleCacheItem{
+		key:    key,
+		needle: n,
+		count:  count,
+		size:   size,
+	})
+	c.size += size
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+		c.evictions++
+		stats.VolumeServerCacheEvictionCounter.Inc()
+	}
+	stats.VolumeServerCacheSizeGauge.Set(float64(c.size))
+}
+
+// Delete drops the needle, after it is overwritten or deleted.
+func (c *NeedleCache) Delete(vid needle.VolumeId, id NeedleId) {
+	c.Lock()
+	defer c.Unlock()
+	c.generation++
+	if element, found := c.items[needleCacheKey{vid, id}]; found {
+		c.remove(element)
+		stats.VolumeServerCacheSizeGauge.Set(float64(c.size))
+	}
+}
+
+// DeleteVolume drops all needles of the volume, after it is unmounted or deleted.
+func (c *NeedleCache) DeleteVolume(vid needle.VolumeId) {
+	c.Lock()
+	defer c.Unlock()
+	c.generation++
+	for key, element := range c.items {
+		if key.volumeId == vid {
+			c.remove(element)
+		}
+	}
+	stats.VolumeServerCacheSizeGauge.Set(float64(c.size))
+}
+
+func (c *NeedleCache) remove(element *list.Element) {
+	item := element.Value.(*needleCacheItem)
+	c.lru.Remove(element)
+	delete(c.items, item.key)
+	c.size -= item.size
+}
+
+// Size returns the total size of the cached needles, and the number of evictions.
+func (c *NeedleCache) Size() (size int64, evictions uint64) {
+	c.Lock()
+	defer c.Unlock()
+	return c.size, c.evictions
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	. "github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func TestNeedleCacheEviction(t *testing.T) {
+	c := NewNeedleCache(8 * (1024 + needleCacheItemOverhead))
+	for i := 1; i <= 10; i++ {
+		c.Set(1, &needle.Needle{Id: NeedleId(i), Data: make([]byte, 1024)}, 1024, c.Generation())
+	}
+	if _, _, found := c.Get(1, 1); found {
+		t.Errorf("needle 1 should be evicted")
+	}
+	if _, _, found := c.Get(1, 10); !found {
+		t.Errorf("needle 10 should be cached")
+	}
+	size, evictions := c.Size()
+	if size != 8*(1024+needleCacheItemOverhead) || evictions != 2 {
+		t.Errorf("unexpected size %d and evictions %d", size, evictions)
+	}
+}
+
+func TestNeedleCacheInvalidation(t *testing.T) {
+	c := NewNeedleCache(1024 * 1024)
+	generation := c.Generation()
+	c.Set(1, &needle.Needle{Id: 1, Data: []byte("old")}, 3, generation)
+	c.Delete(1, 1)
+	// a read started before the deletion
+	c.Set(1, &needle.Needle{Id: 1, Data: []byte("old")}, 3, generation)
+	if _, _, found := c.Get(1, 1); found {
+		t.Errorf("stale needle should not be cached")
+	}
+
+	c.Set(2, &needle.Needle{Id: 1, Data: []byte("data")}, 4, c.Generation())
+	c.DeleteVolume(2)
+	if _, _, found := c.Get(2, 1); found {
+		t.Errorf("needle of the deleted volume should not be cached")
+	}
+}
@@ -49,6 +49,10 @@ type ReadOption struct {
 	// increasing ReadBufferSize can reduce the number of get locks times and shorten read P99 latency.
 	// but will increase memory usage a bit. Use with hasSlowRead normally.
 	ReadBufferSize int
+
+	// If UseSendfile is set to true, on Linux the streamed needle data is copied by the kernel
+	// from the .dat file to the socket with sendfile(2). It should not be set for TLS connections.
+	UseSendfile bool
 }
 
 /*
@@ -72,6 +76,11 @@ type Store struct {
 	NewEcShardsChan     chan master_pb.VolumeEcShardInformationMessage
 	DeletedEcShardsChan chan master_pb.VolumeEcShardInformationMessage
 	isStopping          bool
+	isReadOnly          bool
+	CollectionConfigs   map[string]*CollectionConfig // by collection name
+	// compact the volumes in place one segment of this size at a time, instead of copying them, if not 0
+	IncrementalCompactSegmentSize int64
+	NeedleCache                   *NeedleCache // optional
 }
 
 func (s *Store) String() (str string) {
@@ -120,6 +129,13 @@ func (s *Store) AddVolume(volumeId needle.VolumeId, collection string, needleMap
 }
 func (s *Store) DeleteCollection(collection string) (e error) {
 	for _, location := range s.Locations {
+		location.volumesLock.RLock()
+		for vid, v := range location.volumes {
+			if v.Collection == collection {
+				defer s.uncacheVolume(vid)
+			}
+		}
+		location.volumesLock.RUnlock()
 		e = location.DeleteCollectionFromDiskLocation(collection)
 		if e != nil {
 			return
@@ -144,7 +160,7 @@ func (s *Store) FindFreeLocation(diskType DiskType) (ret *DiskLocation) {
 		if diskType != location.DiskType {
 			continue
 		}
-		if location.isDiskSpaceLow {
+		if location.isDiskSpaceLow || location.isReadOnly {
 			continue
 		}
 		currentFreeCount := location.MaxVolumeCount - int32(location.VolumesLen())
@@ -246,6 +262,18 @@ func (s *Store) GetRack() string {
 	return s.rack
 }
 
+// SetReadOnly keeps serving the existing volumes, but rejects the writes, the deletes and new volumes,
+// and reports no free volume slots to the master.
+func (s *Store) SetReadOnly() {
+	s.isReadOnly = true
+	for _, location := range s.Locations {
+		location.isReadOnly = true
+	}
+}
+func (s *Store) IsReadOnly() bool {
+	return s.isReadOnly
+}
+
 func (s *Store) CollectHeartbeat() *master_pb.Heartbeat {
 	var volumeMessages []*master_pb.VolumeInformationMessage
 	maxVolumeCounts := make(map[string]uint32)
@@ -254,7 +282,11 @@ func (s *Store) CollectHeartbeat() *master_pb.Heartbeat {
 	collectionVolumeReadOnlyCount := make(map[string]map[string]uint8)
 	for _, location := range s.Locations {
 		var deleteVids []needle.VolumeId
-		maxVolumeCounts[string(location.DiskType)] += uint32(location.MaxVolumeCount)
+		if location.isReadOnly {
+			maxVolumeCounts[string(location.DiskType)] += uint32(location.occupiedVolumeSlots())
+		} else {
+			maxVolumeCounts[string(location.DiskType)] += uint32(location.MaxVolumeCount)
+		}
 		location.volumesLock.RLock()
 		for _, v := range location.volumes {
 			curMaxFileKey, volumeMessage := v.ToVolumeInformationMessage()
@@ -360,15 +392,20 @@ func (s *Store) CollectHeartbeat() *master_pb.Heartbeat {
 		Volumes:         volumeMessages,
 		HasNoVolumes:    len(volumeMessages) == 0,
 		LocationUuids:   uuidList,
+		ReadOnly:        s.isReadOnly,
 	}
 
 }
 
-func (s *Store) SetStopping() {
+// SetStopping syncs all volumes to disk, and fsyncs the later writes
+func (s *Store) SetStopping() (err error) {
 	s.isStopping = true
 	for _, location := range s.Locations {
-		location.SetStopping()
+		if syncErr := location.SetStopping(); syncErr != nil {
+			err = syncErr
+		}
 	}
+	return
 }
 
 func (s *Store) LoadNewVolumes() {
@@ -389,7 +426,14 @@ func (s *Store) WriteVolumeNeedle(i needle.VolumeId, n *needle.Needle, checkCook
 			err = fmt.Errorf("volume %d is read only", i)
 			return
 		}
+		if err = s.CollectionConfigs[v.Collection].compressNeedle(n); err != nil {
+			return
+		}
 		_, _, isUnchanged, err = v.writeNeedle2(n, checkCookie, fsync && s.isStopping)
+		s.uncacheNeedle(i, n.Id)
+		if err == nil && !isUnchanged {
+			v.writtenBytes.Add(uint64(len(n.Data)))
+		}
 		return
 	}
 	glog.V(0).Infoln("volume", i, "not found!")
@@ -399,21 +443,57 @@ func (s *Store) WriteVolumeNeedle(i needle.VolumeId, n *needle.Needle, checkCook
 
 func (s *Store) DeleteVolumeNeedle(i needle.VolumeId, n *needle.Needle) (Size, error) {
 	if v := s.findVolume(i); v != nil {
-		if v.noWriteOrDelete {
+		if v.noWriteOrDelete || v.location.isReadOnly {
 			return 0, fmt.Errorf("volume %d is read only", i)
 		}
+		defer s.uncacheNeedle(i, n.Id)
 		return v.deleteNeedle2(n)
 	}
 	return 0, fmt.Errorf("volume %d not found on %s:%d", i, s.Ip, s.Port)
 }
 
-func (s *Store) ReadVolumeNeedle(i needle.VolumeId, n *needle.Needle, readOption *ReadOption, onReadSizeFn func(size Size)) (int, error) {
+func (s *Store) ReadVolumeNeedle(i needle.VolumeId, n *needle.Needle, readOption *ReadOption, onReadSizeFn func(size Size)) (count int, err error) {
 	if v := s.findVolume(i); v != nil {
-		return v.readNeedle(n, readOption, onReadSizeFn)
+		defer func() {
+			// the data of the meta only reads is counted by ReadVolumeNeedleDataInto
+			if err == nil && count > 0 && (readOption == nil || !readOption.IsMetaOnly) {
+				v.readBytes.Add(uint64(count))
+			}
+		}()
+		if s.NeedleCache == nil || readOption != nil && (readOption.ReadDeleted || readOption.MustMetaOnly) {
+			return v.readNeedle(n, readOption, onReadSizeFn)
+		}
+		if cached, count, found := s.NeedleCache.Get(i, n.Id); found {
+			if onReadSizeFn != nil {
+				onReadSizeFn(cached.Size)
+			}
+			*n = *cached
+			return count, nil
+		}
+		generation := s.NeedleCache.Generation()
+		count, err = v.readNeedle(n, readOption, onReadSizeFn)
+		// the needles with ttl are checked for expiry on every read
+		if err == nil && count > 0 && (readOption == nil || !readOption.IsMetaOnly) && !n.HasTtl() {
+			cached := *n
+			s.NeedleCache.Set(i, &cached, count, generation)
+		}
+		return count, err
 	}
 	return 0, fmt.Errorf("volume %d not found", i)
 }
 
+func (s *Store) uncacheNeedle(i needle.VolumeId, id NeedleId) {
+	if s.NeedleCache != nil {
+		s.NeedleCache.Delete(i, id)
+	}
+}
+
+func (s *Store) uncacheVolume(i needle.VolumeId) {
+	if s.NeedleCache != nil {
+		s.NeedleCache.DeleteVolume(i)
+	}
+}
+
 func (s *Store) ReadVolumeNeedleMetaAt(i needle.VolumeId, n *needle.Needle, offset int64, size int32) error {
 	if v := s.findVolume(i); v != nil {
 		return v.readNeedleMetaAt(n, offset, size)
@@ -423,7 +503,11 @@ func (s *Store) ReadVolumeNeedleMetaAt(i needle.VolumeId, n *needle.Needle, offs
 
 func (s *Store) ReadVolumeNeedleDataInto(i needle.VolumeId, n *needle.Needle, readOption *ReadOption, writer io.Writer, offset int64, size int64) error {
 	if v := s.findVolume(i); v != nil {
-		return v.readNeedleDataInto(n, readOption, writer, offset, size)
+		err := v.readNeedleDataInto(n, readOption, writer, offset, size)
+		if err == nil {
+			v.readBytes.Add(uint64(size))
+		}
+		return err
 	}
 	return fmt.Errorf("volume %d not found", i)
 }
@@ -452,6 +536,9 @@ func (s *Store) MarkVolumeWritable(i needle.VolumeId) error {
 	if v == nil {
 		return fmt.Errorf("volume %d not found", i)
 	}
+	if v.location.isReadOnly {
+		return fmt.Errorf("volume %d is on a read only volume server", i)
+	}
 	v.noWriteLock.Lock()
 	v.noWriteOrDelete = false
 	v.noWriteLock.Unlock()
@@ -483,6 +570,7 @@ func (s *Store) UnmountVolume(i needle.VolumeId) error {
 	if v == nil {
 		return nil
 	}
+	defer s.uncacheVolume(i)
 	message := master_pb.VolumeShortInformationMessage{
 		Id:               uint32(v.Id),
 		Collection:       v.Collection,
@@ -512,6 +600,7 @@ func (s *Store) DeleteVolume(i needle.VolumeId) error {
 	if v == nil {
 		return fmt.Errorf("delete volume %d not found on disk", i)
 	}
+	defer s.uncacheVolume(i)
 	message := master_pb.VolumeShortInformationMessage{
 		Id:               uint32(v.Id),
 		Collection:       v.Collection,
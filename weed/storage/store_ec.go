@@ -54,12 +54,18 @@ func (s *Store) MountEcShards(collection string, vid needle.VolumeId, shardId er
 			glog.V(0).Infof("MountEcShards %d.%d", vid, shardId)
 
 			var shardBits erasure_coding.ShardBits
+			ecConfig := erasure_coding.DefaultECConfig
+			if ecVolume, found := location.FindEcVolume(vid); found {
+				ecConfig = ecVolume.ECConfig
+			}
 
 			s.NewEcShardsChan <- master_pb.VolumeEcShardInformationMessage{
-				Id:          uint32(vid),
-				Collection:  collection,
-				EcIndexBits: uint32(shardBits.AddShardId(shardId)),
-				DiskType:    string(location.DiskType),
+				Id:           uint32(vid),
+				Collection:   collection,
+				EcIndexBits:  uint32(shardBits.AddShardId(shardId)),
+				DiskType:     string(location.DiskType),
+				DataShards:   uint32(ecConfig.DataShards),
+				ParityShards: uint32(ecConfig.ParityShards),
 			}
 			return nil
 		} else if err == os.ErrNotExist {
@@ -238,11 +244,11 @@ func forgetShardId(ecVolume *erasure_coding.EcVolume, shardId erasure_coding.Sha
 func (s *Store) cachedLookupEcShardLocations(ecVolume *erasure_coding.EcVolume) (err error) {
 
 	shardCount := len(ecVolume.ShardLocations)
-	if shardCount < erasure_coding.DataShardsCount &&
+	if shardCount < ecVolume.ECConfig.DataShards &&
 		ecVolume.ShardLocationsRefreshTime.Add(11*time.Second).After(time.Now()) ||
-		shardCount == erasure_coding.TotalShardsCount &&
+		shardCount == ecVolume.ECConfig.TotalShards() &&
 			ecVolume.ShardLocationsRefreshTime.Add(37*time.Minute).After(time.Now()) ||
-		shardCount >= erasure_coding.DataShardsCount &&
+		shardCount >= ecVolume.ECConfig.DataShards &&
 			ecVolume.ShardLocationsRefreshTime.Add(7*time.Minute).After(time.Now()) {
 		// still fresh
 		return nil
@@ -258,8 +264,8 @@ func (s *Store) cachedLookupEcShardLocations(ecVolume *erasure_coding.EcVolume)
 		if err != nil {
 			return fmt.Errorf("lookup ec volume %d: %v", ecVolume.VolumeId, err)
 		}
-		if len(resp.ShardIdLocations) < erasure_coding.DataShardsCount {
-			return fmt.Errorf("only %d shards found but %d required", len(resp.ShardIdLocations), erasure_coding.DataShardsCount)
+		if len(resp.ShardIdLocations) < ecVolume.ECConfig.DataShards {
+			return fmt.Errorf("only %d shards found but %d required", len(resp.ShardIdLocations), ecVolume.ECConfig.DataShards)
 		}
 
 		ecVolume.ShardLocationsLock.Lock()
@@ -339,19 +345,19 @@ func (s *Store) doReadRemoteEcShardInterval(sourceDataNode pb.ServerAddress, nee
 func (s *Store) recoverOneRemoteEcShardInterval(needleId types.NeedleId, ecVolume *erasure_coding.EcVolume, shardIdToRecover erasure_coding.ShardId, buf []byte, offset int64) (n int, is_deleted bool, err error) {
 	glog.V(3).Infof("recover ec shard %d.%d from other locations", ecVolume.VolumeId, shardIdToRecover)
 
-	enc, err := reedsolomon.New(erasure_coding.DataShardsCount, erasure_coding.ParityShardsCount)
+	enc, err := reedsolomon.New(ecVolume.ECConfig.DataShards, ecVolume.ECConfig.ParityShards)
 	if err != nil {
 		return 0, false, fmt.Errorf("failed to create encoder: %v", err)
 	}
 
-	bufs := make([][]byte, erasure_coding.TotalShardsCount)
+	bufs := make([][]byte, ecVolume.ECConfig.TotalShards())
 
 	var wg sync.WaitGroup
 	ecVolume.ShardLocationsLock.RLock()
 	for shardId, locations := range ecVolume.ShardLocations {
 
 		// skip current shard or empty shard
-		if shardId == shardIdToRecover {
+		if shardId == shardIdToRecover || int(shardId) >= len(bufs) {
 			continue
 		}
 		if len(locations) == 0 {
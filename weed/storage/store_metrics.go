@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+)
+
+var (
+	volumeSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(stats.Namespace, "volume", "size_bytes"),
+		"Size of the volume .dat file.",
+		[]string{"volumeId", "collection"}, nil)
+	volumeFileCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(stats.Namespace, "volume", "file_count"),
+		"Number of files in the volume.",
+		[]string{"volumeId"}, nil)
+	volumeDeletedFileCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(stats.Namespace, "volume", "deleted_file_count"),
+		"Number of deleted files in the volume, until it is vacuumed.",
+		[]string{"volumeId"}, nil)
+	volumeDeletedBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(stats.Namespace, "volume", "deleted_bytes"),
+		"Bytes of the deleted files in the volume, until it is vacuumed.",
+		[]string{"volumeId"}, nil)
+	volumeReadBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(stats.Namespace, "volume", "read_bytes_total"),
+		"Counter of the file data read from the volume since it is loaded.",
+		[]string{"volumeId"}, nil)
+	volumeWriteBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(stats.Namespace, "volume", "write_bytes_total"),
+		"Counter of the file data written to the volume since it is loaded.",
+		[]string{"volumeId"}, nil)
+)
+
+// StoreMetricsCollector reports the statistics of each volume of the store on each scrape,
+// so the unmounted or deleted volumes are gone from the metrics too.
+type StoreMetricsCollector struct {
+	store *Store
+}
+
+func NewStoreMetricsCollector(store *Store) *StoreMetricsCollector {
+	return &StoreMetricsCollector{store: store}
+}
+
+func (c *StoreMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- volumeSizeDesc
+	ch <- volumeFileCountDesc
+	ch <- volumeDeletedFileCountDesc
+	ch <- volumeDeletedBytesDesc
+	ch <- volumeReadBytesDesc
+	ch <- volumeWriteBytesDesc
+}
+
+func (c *StoreMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, location := range c.store.Locations {
+		var volumes []*Volume
+		location.volumesLock.RLock()
+		for _, v := range location.volumes {
+			volumes = append(volumes, v)
+		}
+		location.volumesLock.RUnlock()
+
+		for _, v := range volumes {
+			_, datFileSize, _, fileCount, deletedCount, deletedSize, ok := v.collectStatus()
+			if !ok {
+				continue
+			}
+			vid := v.Id.String()
+			ch <- prometheus.MustNewConstMetric(volumeSizeDesc, prometheus.GaugeValue, float64(datFileSize), vid, v.Collection)
+			ch <- prometheus.MustNewConstMetric(volumeFileCountDesc, prometheus.GaugeValue, float64(fileCount), vid)
+			ch <- prometheus.MustNewConstMetric(volumeDeletedFileCountDesc, prometheus.GaugeValue, float64(deletedCount), vid)
+			ch <- prometheus.MustNewConstMetric(volumeDeletedBytesDesc, prometheus.GaugeValue, float64(deletedSize), vid)
+			ch <- prometheus.MustNewConstMetric(volumeReadBytesDesc, prometheus.CounterValue, float64(v.readBytes.Load()), vid)
+			ch <- prometheus.MustNewConstMetric(volumeWriteBytesDesc, prometheus.CounterValue, float64(v.writtenBytes.Load()), vid)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+)
+
+func TestStoreMetricsCollector(t *testing.T) {
+	dir := t.TempDir()
+	v, err := NewVolume(dir, dir, "c", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("volume creation: %v", err)
+	}
+	defer v.Close()
+	location := &DiskLocation{volumes: map[needle.VolumeId]*Volume{1: v}}
+	s := &Store{Locations: []*DiskLocation{location}}
+
+	var written uint64
+	for i := 1; i <= 3; i++ {
+		n := newEmptyNeedle(uint64(i))
+		n.Data = []byte("needle data")
+		n.Checksum = needle.NewCRC(n.Data)
+		if _, err := s.WriteVolumeNeedle(1, n, true, false); err != nil {
+			t.Fatalf("write needle %d: %v", i, err)
+		}
+		written += uint64(len(n.Data))
+	}
+	n := newEmptyNeedle(1)
+	count, err := s.ReadVolumeNeedle(1, n, nil, nil)
+	if err != nil {
+		t.Fatalf("read needle: %v", err)
+	}
+	if _, err := s.DeleteVolumeNeedle(1, newEmptyNeedle(2)); err != nil {
+		t.Fatalf("delete needle: %v", err)
+	}
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(NewStoreMetricsCollector(s))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			if m.GetGauge() != nil {
+				values[family.GetName()] = m.GetGauge().GetValue()
+			} else {
+				values[family.GetName()] = m.GetCounter().GetValue()
+			}
+		}
+	}
+
+	expected := map[string]float64{
+		"SeaweedFS_volume_file_count":         2,
+		"SeaweedFS_volume_deleted_file_count": 1,
+		"SeaweedFS_volume_read_bytes_total":   float64(count),
+		"SeaweedFS_volume_write_bytes_total":  float64(written),
+	}
+	for name, value := range expected {
+		if values[name] != value {
+			t.Errorf("%s = %v, expected %v", name, values[name], value)
+		}
+	}
+	if values["SeaweedFS_volume_size_bytes"] <= 0 {
+		t.Errorf("SeaweedFS_volume_size_bytes = %v, expected the .dat file size", values["SeaweedFS_volume_size_bytes"])
+	}
+}
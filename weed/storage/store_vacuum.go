@@ -17,11 +17,26 @@ func (s *Store) CheckCompactVolume(volumeId needle.VolumeId) (float64, error) {
 }
 func (s *Store) CompactVolume(vid needle.VolumeId, preallocate int64, compactionBytePerSecond int64, progressFn ProgressFunc) error {
 	if v := s.findVolume(vid); v != nil {
-		s := stats.NewDiskStatus(v.dir)
-		if int64(s.Free) < preallocate {
-			return fmt.Errorf("free space: %d bytes, not enough for %d bytes", s.Free, preallocate)
+		if v.location.isReadOnly {
+			return fmt.Errorf("volume id %d skips compact on a read only volume server", vid)
 		}
-		return v.Compact2(preallocate, compactionBytePerSecond, progressFn)
+		diskStatus := stats.NewDiskStatus(v.dir)
+		if int64(diskStatus.Free) < preallocate {
+			return fmt.Errorf("free space: %d bytes, not enough for %d bytes", diskStatus.Free, preallocate)
+		}
+		// compaction only gets the disk time left by the reads and writes, whatever the server's io class is
+		return withIdleIoPriority(func() error {
+			if s.IncrementalCompactSegmentSize > 0 && v.MemoryMapMaxSizeMb == 0 && !v.HasRemoteFile() {
+				err := IncrementalCompact(v, s.IncrementalCompactSegmentSize)
+				if err == nil {
+					v.isIncrementallyCompacted = true
+					return nil
+				}
+				// the segments compacted so far are kept, the rest is compacted by copying
+				glog.Warningf("volume %d falls back to compaction by copying: %v", vid, err)
+			}
+			return v.Compact2(preallocate, compactionBytePerSecond, progressFn)
+		})
 	}
 	return fmt.Errorf("volume id %d is not found during compact", vid)
 }
@@ -30,12 +45,17 @@ func (s *Store) CommitCompactVolume(vid needle.VolumeId) (bool, error) {
 		return false, fmt.Errorf("volume id %d skips compact because volume is stopping", vid)
 	}
 	if v := s.findVolume(vid); v != nil {
+		if v.isIncrementallyCompacted {
+			v.isIncrementallyCompacted = false
+			return v.IsReadOnly(), nil
+		}
 		return v.IsReadOnly(), v.CommitCompact()
 	}
 	return false, fmt.Errorf("volume id %d is not found during commit compact", vid)
 }
 func (s *Store) CommitCleanupVolume(vid needle.VolumeId) error {
 	if v := s.findVolume(vid); v != nil {
+		v.isIncrementallyCompacted = false
 		return v.cleanupCompact()
 	}
 	return fmt.Errorf("volume id %d is not found during cleaning up", vid)
@@ -5,6 +5,7 @@ import (
 	"path"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
@@ -47,11 +48,17 @@ type Volume struct {
 
 	isCompacting       bool
 	isCommitCompacting bool
+	// compacted in place, so there is nothing to commit
+	isIncrementallyCompacted bool
 
 	volumeInfo *volume_server_pb.VolumeInfo
 	location   *DiskLocation
 
 	lastIoError error
+
+	// the needle data read from and written to the volume, for the per volume metrics
+	readBytes    atomic.Uint64
+	writtenBytes atomic.Uint64
 }
 
 func NewVolume(dirname string, dirIdx string, collection string, id needle.VolumeId, needleMapKind NeedleMapKind, replicaPlacement *super_block.ReplicaPlacement, ttl *needle.TTL, preallocate int64, memoryMapMaxSizeMb uint32, ldbTimeout int64) (v *Volume, e error) {
@@ -183,18 +190,26 @@ func (v *Volume) DiskType() types.DiskType {
 }
 
 func (v *Volume) SyncToDisk() {
+	v.Sync()
+}
+
+// Sync flushes the index and the data of the volume to disk
+func (v *Volume) Sync() (err error) {
 	v.dataFileAccessLock.Lock()
 	defer v.dataFileAccessLock.Unlock()
 	if v.nm != nil {
-		if err := v.nm.Sync(); err != nil {
+		if err = v.nm.Sync(); err != nil {
 			glog.Warningf("Volume Close fail to sync volume idx %d", v.Id)
+			err = fmt.Errorf("sync volume %d index: %v", v.Id, err)
 		}
 	}
 	if v.DataBackend != nil {
-		if err := v.DataBackend.Sync(); err != nil {
+		if dataErr := v.DataBackend.Sync(); dataErr != nil {
 			glog.Warningf("Volume Close fail to sync volume %d", v.Id)
+			err = fmt.Errorf("sync volume %d data: %v", v.Id, dataErr)
 		}
 	}
+	return
 }
 
 // Close cleanly shuts down this volume
@@ -330,5 +345,5 @@ func (v *Volume) RemoteStorageNameKey() (storageName, storageKey string) {
 func (v *Volume) IsReadOnly() bool {
 	v.noWriteLock.RLock()
 	defer v.noWriteLock.RUnlock()
-	return v.noWriteOrDelete || v.noWriteCanDelete || v.location.isDiskSpaceLow
+	return v.noWriteOrDelete || v.noWriteCanDelete || v.location.isDiskSpaceLow || v.location.isReadOnly
 }
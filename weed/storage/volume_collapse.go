@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func collapseRange(file *os.File, offset, length int64) error {
+	return fmt.Errorf("collapsing file range is not supported on %s", runtime.GOOS)
+}
+
+func fileSystemBlockSize(dir string) (int64, error) {
+	return 0, fmt.Errorf("collapsing file range is not supported on %s", runtime.GOOS)
+}
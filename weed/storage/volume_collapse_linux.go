@@ -0,0 +1,24 @@
+//go:build linux
+// +build linux
+
+package storage
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// collapseRange removes the range from the file without leaving a hole, shifting the rest of the file.
+// Both offset and length must be multiples of the file system block size.
+func collapseRange(file *os.File, offset, length int64) error {
+	return unix.Fallocate(int(file.Fd()), unix.FALLOC_FL_COLLAPSE_RANGE, offset, length)
+}
+
+func fileSystemBlockSize(dir string) (int64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bsize), nil
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle_map"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	. "github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+// CorruptNeedle is a needle with readable header but failing the checksum, left out of the rebuilt index
+type CorruptNeedle struct {
+	Id     NeedleId
+	Offset int64
+	Size   Size
+	Error  string
+}
+
+// SkippedRange is a part of the .dat file without readable needle headers
+type SkippedRange struct {
+	Offset int64
+	Length int64
+}
+
+type IndexRebuildReport struct {
+	Needles        int
+	Deleted        int
+	CorruptNeedles []CorruptNeedle
+	SkippedRanges  []SkippedRange
+}
+
+// RebuildIndex scans the .dat file, and writes the .idx file with the needles passing the checksum.
+// After an unreadable needle header, the scan searches the following aligned offsets for the next needle
+// with a valid checksum. progress is called with the bytes scanned so far, and the .dat file size.
+func RebuildIndex(datFileName, idxFileName string, progress func(scanned, total int64)) (*IndexRebuildReport, error) {
+	datFile, err := os.Open(datFileName)
+	if err != nil {
+		return nil, err
+	}
+	datBackend := backend.NewDiskFile(datFile)
+	defer datBackend.Close()
+
+	superBlock, err := super_block.ReadSuperBlock(datBackend)
+	if err != nil {
+		return nil, fmt.Errorf("read super block of %s: %v", datFileName, err)
+	}
+	version := superBlock.Version
+	total, _, err := datBackend.GetStat()
+	if err != nil {
+		return nil, err
+	}
+
+	nm := needle_map.NewMemDb()
+	defer nm.Close()
+	report := &IndexRebuildReport{}
+
+	offset := int64(superBlock.BlockSize())
+	for offset+NeedleHeaderSize <= total {
+		if progress != nil {
+			progress(offset, total)
+		}
+		n, diskSize, err := readNeedleAt(datBackend, version, offset, total)
+		if err == nil {
+			if n.Size.IsValid() {
+				err = nm.Set(n.Id, ToOffset(offset), n.Size)
+				report.Needles++
+			} else {
+				err = nm.Delete(n.Id)
+				report.Deleted++
+			}
+			if err != nil {
+				return nil, err
+			}
+			offset += diskSize
+			continue
+		}
+		if n != nil {
+			// the header and the size are readable, only the content is corrupted
+			glog.V(0).Infof("corrupt needle %s at offset %d: %v", n.Id, offset, err)
+			report.CorruptNeedles = append(report.CorruptNeedles, CorruptNeedle{
+				Id:     n.Id,
+				Offset: offset,
+				Size:   n.Size,
+				Error:  err.Error(),
+			})
+			next := offset + diskSize
+			if next+NeedleHeaderSize <= total {
+				if _, _, err := readNeedleAt(datBackend, version, next, total); err != nil {
+					// the size in the header may be corrupted too
+					next = findNextNeedle(datBackend, version, offset+NeedlePaddingSize, total)
+				}
+			}
+			offset = next
+			continue
+		}
+		next := findNextNeedle(datBackend, version, offset+NeedlePaddingSize, total)
+		glog.V(0).Infof("skip unreadable range [%d,%d) of %s: %v", offset, next, datFileName, err)
+		report.SkippedRanges = append(report.SkippedRanges, SkippedRange{
+			Offset: offset,
+			Length: next - offset,
+		})
+		offset = next
+	}
+	if progress != nil {
+		progress(total, total)
+	}
+
+	if err = nm.SaveToIdx(idxFileName); err != nil {
+		os.Remove(idxFileName)
+		return nil, fmt.Errorf("save %s: %v", idxFileName, err)
+	}
+	return report, nil
+}
+
+// readNeedleAt returns the needle and its size on disk, or only the error if the needle header is not readable.
+func readNeedleAt(datBackend backend.BackendStorageFile, version needle.Version, offset, total int64) (*needle.Needle, int64, error) {
+	n, _, bodyLength, err := needle.ReadNeedleHeader(datBackend, version, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if n.Size < 0 || offset+NeedleHeaderSize+bodyLength > total {
+		return nil, 0, fmt.Errorf("invalid needle size %d", n.Size)
+	}
+	diskSize := NeedleHeaderSize + bodyLength
+	blob, err := needle.ReadNeedleBlob(datBackend, offset, n.Size, version)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err = n.ReadBytes(blob, offset, n.Size, version); err != nil {
+		return n, diskSize, err
+	}
+	return n, diskSize, nil
+}
+
+// findNextNeedle returns the first aligned offset with a non-empty needle passing the checksum, or the file size.
+// Needles have no magic number, and an empty needle has no checksum, so they cannot be told from zeroed bytes.
+func findNextNeedle(datBackend backend.BackendStorageFile, version needle.Version, offset, total int64) int64 {
+	for ; offset+NeedleHeaderSize <= total; offset += NeedlePaddingSize {
+		n, _, err := readNeedleAt(datBackend, version, offset, total)
+		if err == nil && n.Size.IsValid() {
+			return offset
+		}
+	}
+	return total
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle_map"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebuildIndex(t *testing.T) {
+	dir := t.TempDir()
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("volume creation: %v", err)
+	}
+	offsets := make(map[uint64]int64)
+	for i := uint64(1); i <= 10; i++ {
+		n := newEmptyNeedle(i)
+		n.Data = make([]byte, 100+i)
+		n.Checksum = needle.NewCRC(n.Data)
+		offset, _, _, err := v.writeNeedle2(n, true, false)
+		if err != nil {
+			t.Fatalf("write needle %d: %v", i, err)
+		}
+		offsets[i] = int64(offset)
+	}
+	if _, err = v.deleteNeedle2(newEmptyNeedle(2)); err != nil {
+		t.Fatalf("delete needle 2: %v", err)
+	}
+	datFileName := v.FileName(".dat")
+	v.Close()
+
+	datFile, err := os.OpenFile(datFileName, os.O_RDWR, 0644)
+	assert.Nil(t, err)
+	// corrupt the content of needle 3, and the header of needle 6
+	_, err = datFile.WriteAt([]byte{0xff}, offsets[3]+types.NeedleHeaderSize+types.DataSizeSize)
+	assert.Nil(t, err)
+	_, err = datFile.WriteAt([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, offsets[6])
+	assert.Nil(t, err)
+	datFile.Close()
+
+	idxFileName := filepath.Join(dir, "rebuilt.idx")
+	var lastScanned, lastTotal int64
+	report, err := RebuildIndex(datFileName, idxFileName, func(scanned, total int64) {
+		lastScanned, lastTotal = scanned, total
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, lastTotal, lastScanned, "progress ends at 100%")
+	assert.Equal(t, 8, report.Needles)
+	assert.Equal(t, 1, report.Deleted)
+	if assert.Len(t, report.CorruptNeedles, 1) {
+		assert.Equal(t, types.Uint64ToNeedleId(3), report.CorruptNeedles[0].Id)
+	}
+	if assert.Len(t, report.SkippedRanges, 1) {
+		assert.Equal(t, offsets[6], report.SkippedRanges[0].Offset)
+		assert.Equal(t, offsets[7]-offsets[6], report.SkippedRanges[0].Length)
+	}
+
+	nm := needle_map.NewMemDb()
+	defer nm.Close()
+	assert.Nil(t, nm.LoadFromIdx(idxFileName))
+	for i := uint64(1); i <= 10; i++ {
+		nv, found := nm.Get(types.Uint64ToNeedleId(i))
+		switch i {
+		case 2, 3, 6:
+			assert.False(t, found, "needle %d", i)
+		default:
+			if assert.True(t, found, "needle %d", i) {
+				assert.Equal(t, offsets[i], nv.Offset.ToActualOffset(), "needle %d", i)
+			}
+		}
+	}
+}
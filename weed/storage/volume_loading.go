@@ -43,6 +43,12 @@ func (v *Volume) load(alsoLoadIndex bool, createDatIfMissing bool, needleMapKind
 
 	hasVolumeInfoFile := v.maybeLoadVolumeInfo()
 
+	if alsoLoadIndex && !v.HasRemoteFile() {
+		if err = v.recoverIncrementalCompaction(); err != nil {
+			return fmt.Errorf("recover volume %d incremental compaction: %v", v.Id, err)
+		}
+	}
+
 	if v.HasRemoteFile() {
 		v.noWriteCanDelete = true
 		v.noWriteOrDelete = false
@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"github.com/seaweedfs/seaweedfs/weed/util/mem"
 	"io"
+	"os"
+	"runtime"
 	"time"
 
 	"github.com/seaweedfs/seaweedfs/weed/glog"
@@ -135,6 +138,23 @@ func (v *Volume) readNeedleDataInto(n *needle.Needle, readOption *ReadOption, wr
 		actualOffset += int64(MaxPossibleVolumeSize)
 	}
 
+	if readOption.UseSendfile && canSendfile {
+		if readOption.HasSlowRead {
+			v.dataFileAccessLock.RLock()
+		}
+		dataFile, openErr := v.openNeedleData(n, readOption, actualOffset, offset)
+		if readOption.HasSlowRead {
+			v.dataFileAccessLock.RUnlock()
+		}
+		if openErr == nil {
+			defer dataFile.Close()
+			return sendNeedleData(dataFile, writer, size)
+		}
+		if openErr != errSendfileUnsupported {
+			return openErr
+		}
+	}
+
 	buf := mem.Allocate(min(readOption.ReadBufferSize, int(size)))
 	defer mem.Free(buf)
 
@@ -189,6 +209,51 @@ func (v *Volume) readNeedleDataInto(n *needle.Needle, readOption *ReadOption, wr
 
 }
 
+// sendfile(2) is used by the Go runtime when copying from a file to a tcp connection
+const canSendfile = runtime.GOOS == "linux"
+
+var errSendfileUnsupported = errors.New("sendfile unsupported")
+
+// openNeedleData opens another descriptor of the .dat file positioned at the needle data, since the shared one
+// is only read with pread(2). If the volume is compacted during the read, the opened file stays readable.
+func (v *Volume) openNeedleData(n *needle.Needle, readOption *ReadOption, actualOffset int64, offset int64) (*os.File, error) {
+	diskFile, ok := v.DataBackend.(*backend.DiskFile)
+	if !ok {
+		return nil, errSendfileUnsupported
+	}
+	// possibly re-read needle offset if volume is compacted
+	if readOption.VolumeRevision != v.SuperBlock.CompactionRevision {
+		nv, ok := v.nm.Get(n.Id)
+		if !ok || nv.Offset.IsZero() {
+			return nil, ErrorNotFound
+		}
+		actualOffset = nv.Offset.ToActualOffset()
+		readOption.VolumeRevision = v.SuperBlock.CompactionRevision
+	}
+	dataFile, err := os.Open(diskFile.Name())
+	if err != nil {
+		return nil, err
+	}
+	if _, err = dataFile.Seek(actualOffset+NeedleHeaderSize+DataSizeSize+offset, io.SeekStart); err != nil {
+		dataFile.Close()
+		return nil, err
+	}
+	return dataFile, nil
+}
+
+// sendNeedleData lets the http response copy the data from the file, with sendfile(2) on a plain tcp connection.
+// The data does not pass through the process, so its checksum is not verified.
+func sendNeedleData(dataFile *os.File, writer io.Writer, size int64) error {
+	written, err := io.Copy(writer, io.LimitReader(dataFile, size))
+	if err != nil {
+		return fmt.Errorf("ReadNeedleData sendfile: %v", err)
+	}
+	if written != size {
+		return fmt.Errorf("ReadNeedleData sendfile: %d of %d bytes: %v", written, size, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
 func min(x, y int) int {
 	if x < y {
 		return x
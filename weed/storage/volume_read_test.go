@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"bytes"
+	"math/rand"
 	"testing"
 
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
@@ -94,3 +96,30 @@ func TestReadNeedMetaWithDeletesThenWrites(t *testing.T) {
 		expectedLastUpdateTime += 2000
 	}
 }
+
+func TestReadNeedleDataIntoWithSendfile(t *testing.T) {
+	dir := t.TempDir()
+
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("volume creation: %v", err)
+	}
+	defer v.Close()
+	n := newRandomNeedle(1)
+	n.Data = make([]byte, 4096)
+	rand.Read(n.Data)
+	n.Checksum = needle.NewCRC(n.Data)
+	if _, _, _, err := v.writeNeedle2(n, true, false); err != nil {
+		t.Fatalf("write needle: %v", err)
+	}
+
+	for _, useSendfile := range []bool{false, true} {
+		readNeedle := &needle.Needle{Id: n.Id, DataSize: uint32(len(n.Data)), Checksum: n.Checksum}
+		readOption := &ReadOption{ReadBufferSize: 1024, UseSendfile: useSendfile}
+		var buf bytes.Buffer
+		if err := v.readNeedleDataInto(readNeedle, readOption, &buf, 3, int64(len(n.Data))-5); err != nil {
+			t.Fatalf("read needle data with sendfile %v: %v", useSendfile, err)
+		}
+		assert.Equal(t, n.Data[3:len(n.Data)-2], buf.Bytes(), "read with sendfile %v", useSendfile)
+	}
+}
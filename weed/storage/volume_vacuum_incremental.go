@@ -0,0 +1,434 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+	"github.com/seaweedfs/seaweedfs/weed/storage/backend"
+	idx2 "github.com/seaweedfs/seaweedfs/weed/storage/idx"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle_map"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	. "github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+// the smallest needle to fill the gap between the compacted needles and the collapsed range
+const minPaddingNeedleSize = 48
+
+type segmentNeedle struct {
+	id        NeedleId
+	oldOffset int64
+	newOffset int64
+	size      Size
+}
+
+// IncrementalCompact compacts the volume one segment at a time, instead of rewriting the whole .dat file.
+// For each segmentSize-aligned segment, the live needles are copied to a compaction file, the garbage is
+// removed from the .dat file with fallocate(FALLOC_FL_COLLAPSE_RANGE), and the live needles are copied back.
+// The volume is locked only while one segment is compacted.
+//
+// segmentSize must be a multiple of the file system block size, and the file system must support
+// collapsing file ranges, e.g., ext4 or xfs on Linux.
+// The needle at the end of the volume is never moved, since it is verified against the last index entry
+// when the volume is loaded.
+//
+// Before the .dat file of a segment is changed, the compacted segment, the new index and a .cpm marker are
+// persisted, so after a crash the volume loading rolls the segment back or forward, see
+// recoverIncrementalCompaction.
+func IncrementalCompact(v *Volume, segmentSize int64) error {
+	if v.MemoryMapMaxSizeMb != 0 || v.HasRemoteFile() {
+		return fmt.Errorf("volume %d is not on local disk", v.Id)
+	}
+	blockSize, err := fileSystemBlockSize(v.dir)
+	if err != nil {
+		return fmt.Errorf("volume %d: %v", v.Id, err)
+	}
+	if segmentSize <= 0 || segmentSize%blockSize != 0 {
+		return fmt.Errorf("segment size %d is not a multiple of the file system block size %d", segmentSize, blockSize)
+	}
+	if v.isCompacting || v.isCommitCompacting {
+		return fmt.Errorf("volume %d is being compacted", v.Id)
+	}
+	v.isCompacting = true
+	defer func() {
+		v.isCompacting = false
+	}()
+
+	glog.V(0).Infof("Incrementally compacting volume %d with segment size %d ...", v.Id, segmentSize)
+	cursor := int64(v.SuperBlock.BlockSize())
+	var reclaimed int64
+	for {
+		next, collapsed, done, err := v.compactSegment(cursor, segmentSize, blockSize)
+		if err != nil {
+			return fmt.Errorf("compact volume %d segment at %d: %v", v.Id, cursor, err)
+		}
+		reclaimed += collapsed
+		if done {
+			break
+		}
+		cursor = next
+	}
+	glog.V(0).Infof("Incrementally compacted volume %d, reclaimed %d bytes", v.Id, reclaimed)
+	return nil
+}
+
+// compactSegment compacts the needles starting from the cursor to the end of its segment,
+// and returns where the next segment starts in the compacted volume.
+func (v *Volume) compactSegment(cursor, segmentSize, blockSize int64) (next, collapsed int64, done bool, err error) {
+	v.dataFileAccessLock.Lock()
+	defer v.dataFileAccessLock.Unlock()
+
+	if v.nm == nil || v.DataBackend == nil {
+		return 0, 0, false, fmt.Errorf("volume is not loaded")
+	}
+	version := v.Version()
+	fileSize, _, err := v.DataBackend.GetStat()
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	// find the needles starting in the segment
+	segmentEnd := (cursor/segmentSize + 1) * segmentSize
+	regionStart, regionEnd := cursor, cursor
+	var liveNeedles []*segmentNeedle
+	var liveSize int64
+	for regionEnd < segmentEnd {
+		if regionEnd >= fileSize {
+			done = true
+			break
+		}
+		n, _, bodyLength, readErr := needle.ReadNeedleHeader(v.DataBackend, version, regionEnd)
+		if readErr != nil {
+			return 0, 0, false, fmt.Errorf("read needle header at %d: %v", regionEnd, readErr)
+		}
+		needleEnd := regionEnd + NeedleHeaderSize + bodyLength
+		if needleEnd >= fileSize {
+			done = true
+			break
+		}
+		if nv, ok := v.nm.Get(n.Id); ok && nv.Offset.ToActualOffset() == regionEnd && !nv.Size.IsDeleted() {
+			liveNeedles = append(liveNeedles, &segmentNeedle{id: n.Id, oldOffset: regionEnd, size: nv.Size})
+			liveSize += needleEnd - regionEnd
+		}
+		regionEnd = needleEnd
+	}
+
+	// only whole blocks can be collapsed, the rest of the garbage is filled by a padding needle
+	collapseStart := (regionStart + liveSize + minPaddingNeedleSize + blockSize - 1) / blockSize * blockSize
+	collapseEnd := regionEnd / blockSize * blockSize
+	if collapseEnd <= collapseStart {
+		return regionEnd, 0, done, nil
+	}
+	collapseLength := collapseEnd - collapseStart
+	paddingSize := regionEnd - collapseLength - regionStart - liveSize
+
+	// copy the live needles and the padding needle to the compaction file
+	segmentFile, err := os.OpenFile(v.FileName(".cps"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	defer func() {
+		segmentFile.Close()
+		// kept for the recovery until the marker is removed
+		if _, statErr := os.Stat(v.FileName(".cpm")); os.IsNotExist(statErr) {
+			os.Remove(v.FileName(".cps"))
+		}
+	}()
+	segmentBackend := backend.NewDiskFile(segmentFile)
+	var compactedSize int64
+	needleIds := make(map[NeedleId]*segmentNeedle)
+	for _, ln := range liveNeedles {
+		blob, readErr := needle.ReadNeedleBlob(v.DataBackend, ln.oldOffset, ln.size, version)
+		if readErr != nil {
+			return 0, 0, false, fmt.Errorf("read needle at %d: %v", ln.oldOffset, readErr)
+		}
+		if _, err = segmentBackend.WriteAt(blob, compactedSize); err != nil {
+			return 0, 0, false, err
+		}
+		ln.newOffset = regionStart + compactedSize
+		needleIds[ln.id] = ln
+		compactedSize += int64(len(blob))
+	}
+	paddingNeedle, err := newPaddingNeedle(paddingSize, version)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if _, _, _, err = paddingNeedle.Append(segmentBackend, version, true); err != nil {
+		return 0, 0, false, err
+	}
+	if err = segmentFile.Sync(); err != nil {
+		return 0, 0, false, err
+	}
+
+	// write the index with the moved offsets, and the marker, before changing the .dat file
+	if err = v.DataBackend.Sync(); err != nil {
+		return 0, 0, false, err
+	}
+	if err = v.nm.Sync(); err != nil {
+		return 0, 0, false, err
+	}
+	if err = v.rewriteSegmentIndex(regionStart, regionEnd, collapseLength, needleIds); err != nil {
+		return 0, 0, false, err
+	}
+	marker := &segmentCompactionMarker{
+		FileSize:           fileSize,
+		RegionStart:        regionStart,
+		CollapseStart:      collapseStart,
+		CollapseLength:     collapseLength,
+		CompactionRevision: v.SuperBlock.CompactionRevision + 1,
+	}
+	if err = marker.save(v.FileName(".cpm")); err != nil {
+		os.Remove(v.FileName(".cpx"))
+		return 0, 0, false, err
+	}
+
+	// splice the compacted segment into the .dat file, and replace the .idx file.
+	// On any failure, the reloading finishes or rolls back the segment.
+	superBlock := v.SuperBlock
+	superBlock.CompactionRevision = marker.CompactionRevision
+	v.nm.Close()
+	v.nm = nil
+	v.DataBackend.Close()
+	v.DataBackend = nil
+	stats.VolumeServerVolumeCounter.WithLabelValues(v.Collection, "volume").Dec()
+	defer func() {
+		if loadErr := v.load(true, false, v.needleMapKind, 0); loadErr != nil && err == nil {
+			err = loadErr
+		}
+	}()
+
+	if err = spliceSegment(v.FileName(".dat"), segmentFile, superBlock, regionStart, collapseStart, collapseLength); err != nil {
+		return 0, 0, false, err
+	}
+	if err = v.finishSegmentCompaction(); err != nil {
+		return 0, 0, false, err
+	}
+
+	return regionEnd - collapseLength, collapseLength, done, nil
+}
+
+// spliceSegmentFault is set by the tests to fail the splicing before or after the range is collapsed
+var spliceSegmentFault func(collapsed bool) error
+
+// spliceSegment collapses the range first, so a failure leaves the .dat file unchanged
+func spliceSegment(datFileName string, segmentFile *os.File, superBlock super_block.SuperBlock, regionStart, collapseStart, collapseLength int64) error {
+	datFile, err := os.OpenFile(datFileName, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer datFile.Close()
+	if spliceSegmentFault != nil {
+		if err = spliceSegmentFault(false); err != nil {
+			return err
+		}
+	}
+	if err = collapseRange(datFile, collapseStart, collapseLength); err != nil {
+		return fmt.Errorf("collapse %s [%d,%d): %v", datFileName, collapseStart, collapseStart+collapseLength, err)
+	}
+	if spliceSegmentFault != nil {
+		if err = spliceSegmentFault(true); err != nil {
+			return err
+		}
+	}
+	return writeBackSegment(datFile, segmentFile, superBlock, regionStart)
+}
+
+// writeBackSegment writes the compacted segment into the collapsed range, and then bumps the compaction revision,
+// so the followers know the offsets are changed, and the recovery knows the segment is written
+func writeBackSegment(datFile, segmentFile *os.File, superBlock super_block.SuperBlock, regionStart int64) (err error) {
+	if _, err = segmentFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = io.Copy(io.NewOffsetWriter(datFile, regionStart), segmentFile); err != nil {
+		return fmt.Errorf("write compacted segment to %s: %v", datFile.Name(), err)
+	}
+	if err = datFile.Sync(); err != nil {
+		return err
+	}
+	if _, err = datFile.WriteAt(superBlock.Bytes(), 0); err != nil {
+		return err
+	}
+	return datFile.Sync()
+}
+
+// finishSegmentCompaction replaces the .idx file once the .dat file is compacted, and removes the marker
+func (v *Volume) finishSegmentCompaction() error {
+	if err := os.Rename(v.FileName(".cpx"), v.FileName(".idx")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("volume %d .dat is compacted, but .idx is not: %v", v.Id, err)
+	}
+	os.RemoveAll(v.FileName(".ldb"))
+	return removeSegmentCompactionFiles(v)
+}
+
+func removeSegmentCompactionFiles(v *Volume) error {
+	os.Remove(v.FileName(".cps"))
+	if err := os.Remove(v.FileName(".cpm")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// rewriteSegmentIndex writes the .cpx file in the order of the index entries, moves the live needles of the segment,
+// drops the overwritten ones, and shifts the entries after the segment by the collapsed length.
+func (v *Volume) rewriteSegmentIndex(regionStart, regionEnd, collapseLength int64, needleIds map[NeedleId]*segmentNeedle) error {
+	oldIdxFile, err := os.Open(v.FileName(".idx"))
+	if err != nil {
+		return err
+	}
+	defer oldIdxFile.Close()
+	newIdxFile, err := os.OpenFile(v.FileName(".cpx"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer newIdxFile.Close()
+
+	writer := bufio.NewWriter(newIdxFile)
+	err = idx2.WalkIndexFile(oldIdxFile, 0, func(key NeedleId, offset Offset, size Size) error {
+		actualOffset := offset.ToActualOffset()
+		switch {
+		case offset.IsZero() || actualOffset < regionStart:
+		case actualOffset >= regionEnd:
+			offset = ToOffset(actualOffset - collapseLength)
+		case size.IsDeleted():
+			// keep the deletion, though its tombstone needle is compacted away
+			offset = ToOffset(regionStart)
+		default:
+			ln, found := needleIds[key]
+			if !found || ln.oldOffset != actualOffset {
+				// overwritten or deleted
+				return nil
+			}
+			offset = ToOffset(ln.newOffset)
+		}
+		_, writeErr := writer.Write(needle_map.ToBytes(key, offset, size))
+		return writeErr
+	})
+	if err != nil {
+		return err
+	}
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+	return newIdxFile.Sync()
+}
+
+// newPaddingNeedle creates a needle with the exact disk size, to fill the gap in the compacted segment
+func newPaddingNeedle(diskSize int64, version needle.Version) (*needle.Needle, error) {
+	size := diskSize - NeedleHeaderSize - needle.NeedleChecksumSize - NeedlePaddingSize
+	if version == needle.Version3 {
+		size -= TimestampSize
+	}
+	dataSize := size
+	if version != needle.Version1 {
+		// data size and flags
+		dataSize -= 4 + 1
+	}
+	if dataSize <= 0 || needle.GetActualSize(Size(size), version) != diskSize {
+		return nil, fmt.Errorf("cannot pad %d bytes", diskSize)
+	}
+	n := &needle.Needle{Data: make([]byte, dataSize)}
+	n.Checksum = needle.NewCRC(n.Data)
+	return n, nil
+}
+
+// segmentCompactionMarker is persisted in the .cpm file while the .dat file of a segment is changed
+type segmentCompactionMarker struct {
+	FileSize           int64  `json:"fileSize"` // the .dat file size before collapsing
+	RegionStart        int64  `json:"regionStart"`
+	CollapseStart      int64  `json:"collapseStart"`
+	CollapseLength     int64  `json:"collapseLength"`
+	CompactionRevision uint16 `json:"compactionRevision"` // the revision once the segment is written back
+}
+
+func (m *segmentCompactionMarker) save(fileName string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fileName)
+		return err
+	}
+	// persist the new directory entry before the .dat file is changed
+	dir, err := os.Open(filepath.Dir(fileName))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// recoverIncrementalCompaction finishes or rolls back the segment being compacted when the volume server crashed.
+// The marker is only saved after the compacted segment and the new index are persisted, so:
+//   - if the .dat file is not collapsed, the segment is rolled back by removing the compaction files,
+//   - if the .dat file is collapsed, the compacted segment is written back,
+//   - once the compaction revision is bumped, the .idx file is replaced.
+func (v *Volume) recoverIncrementalCompaction() error {
+	data, err := os.ReadFile(v.FileName(".cpm"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	marker := &segmentCompactionMarker{}
+	if err = json.Unmarshal(data, marker); err != nil {
+		// the marker is not completely written, so the .dat file is not changed yet
+		glog.Warningf("volume %d rolls back the incomplete incremental compaction: %v", v.Id, err)
+		os.Remove(v.FileName(".cpx"))
+		return removeSegmentCompactionFiles(v)
+	}
+
+	datFile, err := os.OpenFile(v.FileName(".dat"), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer datFile.Close()
+	fileSize, _, err := backend.NewDiskFile(datFile).GetStat()
+	if err != nil {
+		return err
+	}
+	superBlock, err := super_block.ReadSuperBlock(backend.NewDiskFile(datFile))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case superBlock.CompactionRevision == marker.CompactionRevision:
+		glog.V(0).Infof("volume %d finishes the incremental compaction of the segment at %d", v.Id, marker.RegionStart)
+	case fileSize == marker.FileSize:
+		glog.V(0).Infof("volume %d rolls back the incremental compaction of the segment at %d", v.Id, marker.RegionStart)
+		os.Remove(v.FileName(".cpx"))
+		return removeSegmentCompactionFiles(v)
+	case fileSize == marker.FileSize-marker.CollapseLength:
+		glog.V(0).Infof("volume %d writes back the incrementally compacted segment at %d", v.Id, marker.RegionStart)
+		segmentFile, err := os.Open(v.FileName(".cps"))
+		if err != nil {
+			return fmt.Errorf("volume %d is collapsed at %d, but the compacted segment is lost: %v", v.Id, marker.CollapseStart, err)
+		}
+		defer segmentFile.Close()
+		superBlock.CompactionRevision = marker.CompactionRevision
+		if err = writeBackSegment(datFile, segmentFile, superBlock, marker.RegionStart); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("volume %d size %d does not match the incremental compaction marker %+v", v.Id, fileSize, marker)
+	}
+	return v.finishSegmentCompaction()
+}
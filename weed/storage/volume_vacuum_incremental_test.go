@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+	"github.com/seaweedfs/seaweedfs/weed/storage/super_block"
+	"github.com/seaweedfs/seaweedfs/weed/storage/types"
+)
+
+func TestIncrementalCompact(t *testing.T) {
+	dir := t.TempDir()
+	blockSize := collapsibleBlockSize(t, dir)
+
+	fileCount := 2000
+	v, infos := newVolumeWithGarbage(t, dir, fileCount)
+	beforeSize, _, _ := v.FileStat()
+	deletedSize := v.DeletedSize()
+
+	if err := IncrementalCompact(v, 8*blockSize); err != nil {
+		t.Fatalf("incremental compact: %v", err)
+	}
+	afterSize, _, _ := v.FileStat()
+	if afterSize >= beforeSize {
+		t.Errorf("volume size %d is not reduced from %d", afterSize, beforeSize)
+	}
+	t.Logf("volume size %d => %d", beforeSize, afterSize)
+	checkIncrementalCompactedFiles(t, v, infos)
+
+	// writes after the compaction
+	n := newRandomNeedle(uint64(fileCount + 1))
+	if _, _, _, err := v.writeNeedle2(n, true, false); err != nil {
+		t.Fatalf("write after compaction: %v", err)
+	}
+
+	v.Close()
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, nil, nil, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("volume reloading: %v", err)
+	}
+	defer v.Close()
+	checkIncrementalCompactedFiles(t, v, infos)
+	if v.DeletedSize() >= deletedSize {
+		t.Errorf("deleted size %d is not reduced from %d", v.DeletedSize(), deletedSize)
+	}
+}
+
+func checkIncrementalCompactedFiles(t *testing.T, v *Volume, infos []*needleInfo) {
+	for i, info := range infos {
+		n := newEmptyNeedle(uint64(i + 1))
+		size, err := v.readNeedle(n, nil, nil)
+		if info == nil {
+			if err == nil {
+				t.Fatalf("deleted file %d is read", i+1)
+			}
+			continue
+		}
+		if info.size == 0 {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("read file %d: %v", i+1, err)
+		}
+		if info.size != types.Size(size) || info.crc != n.Checksum {
+			t.Fatalf("read file %d mismatch", i+1)
+		}
+	}
+}
+
+func TestIncrementalCompactRecovery(t *testing.T) {
+	defer func() {
+		spliceSegmentFault = nil
+	}()
+
+	for _, crashAfterCollapse := range []bool{false, true} {
+		dir := t.TempDir()
+		blockSize := collapsibleBlockSize(t, dir)
+		v, infos := newVolumeWithGarbage(t, dir, 2000)
+		beforeSize, _, _ := v.FileStat()
+		revision := v.SuperBlock.CompactionRevision
+
+		spliceSegmentFault = func(collapsed bool) error {
+			if collapsed == crashAfterCollapse {
+				return fmt.Errorf("crash")
+			}
+			return nil
+		}
+		if err := IncrementalCompact(v, 8*blockSize); err == nil {
+			t.Fatalf("crash after collapse %v: no error", crashAfterCollapse)
+		}
+		spliceSegmentFault = nil
+
+		// the reloading rolls the segment back, or writes it back
+		afterSize, _, _ := v.FileStat()
+		if crashAfterCollapse && (afterSize >= beforeSize || v.SuperBlock.CompactionRevision != revision+1) {
+			t.Errorf("crash after collapse: size %d => %d, revision %d => %d", beforeSize, afterSize, revision, v.SuperBlock.CompactionRevision)
+		}
+		if !crashAfterCollapse && (afterSize != beforeSize || v.SuperBlock.CompactionRevision != revision) {
+			t.Errorf("crash before collapse: size %d => %d, revision %d => %d", beforeSize, afterSize, revision, v.SuperBlock.CompactionRevision)
+		}
+		for _, ext := range []string{".cpm", ".cps", ".cpx"} {
+			if _, err := os.Stat(v.FileName(ext)); !os.IsNotExist(err) {
+				t.Errorf("crash after collapse %v: %s is left", crashAfterCollapse, ext)
+			}
+		}
+		checkIncrementalCompactedFiles(t, v, infos)
+
+		if err := IncrementalCompact(v, 8*blockSize); err != nil {
+			t.Fatalf("incremental compact after the crash: %v", err)
+		}
+		v.Close()
+		v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, nil, nil, 0, 0, 0)
+		if err != nil {
+			t.Fatalf("volume reloading: %v", err)
+		}
+		checkIncrementalCompactedFiles(t, v, infos)
+		v.Close()
+	}
+}
+
+// collapsibleBlockSize skips the test if the file system can not collapse file ranges
+func collapsibleBlockSize(t *testing.T, dir string) int64 {
+	blockSize, err := fileSystemBlockSize(dir)
+	if err != nil {
+		t.Skipf("file system block size: %v", err)
+	}
+	probe, _ := os.Create(filepath.Join(dir, "probe"))
+	probe.Truncate(2 * blockSize)
+	err = collapseRange(probe, 0, blockSize)
+	probe.Close()
+	os.Remove(probe.Name())
+	if err != nil {
+		t.Skipf("collapse range is not supported: %v", err)
+	}
+	return blockSize
+}
+
+// newVolumeWithGarbage writes the files and deletes every second one
+func newVolumeWithGarbage(t *testing.T, dir string, fileCount int) (*Volume, []*needleInfo) {
+	v, err := NewVolume(dir, dir, "", 1, NeedleMapInMemory, &super_block.ReplicaPlacement{}, &needle.TTL{}, 0, 0, 0)
+	if err != nil {
+		t.Fatalf("volume creation: %v", err)
+	}
+
+	infos := make([]*needleInfo, fileCount)
+	for i := 1; i <= fileCount; i++ {
+		n := newRandomNeedle(uint64(i))
+		_, size, _, err := v.writeNeedle2(n, true, false)
+		if err != nil {
+			t.Fatalf("write file %d: %v", i, err)
+		}
+		infos[i-1] = &needleInfo{size: size, crc: n.Checksum}
+	}
+	for i := 2; i <= fileCount; i += 2 {
+		if _, err := v.deleteNeedle2(newEmptyNeedle(uint64(i))); err != nil {
+			t.Fatalf("delete file %d: %v", i, err)
+		}
+		infos[i-1] = nil
+	}
+	return v, infos
+}
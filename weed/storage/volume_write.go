@@ -79,6 +79,8 @@ func removeVolumeFiles(filename string) {
 	// compaction
 	os.Remove(filename + ".cpd")
 	os.Remove(filename + ".cpx")
+	os.Remove(filename + ".cps")
+	os.Remove(filename + ".cpm")
 	// level db index file
 	os.RemoveAll(filename + ".ldb")
 	// marker for damaged or incomplete volume
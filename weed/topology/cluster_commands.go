@@ -6,11 +6,16 @@ import (
 	hashicorpRaft "github.com/hashicorp/raft"
 	"github.com/seaweedfs/raft"
 	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
 	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
 )
 
+// MaxVolumeIdCommand is the replicated master state. A log changing the per-collection garbage thresholds
+// or ec configs carries all of them, and leaves MaxVolumeId zero.
 type MaxVolumeIdCommand struct {
-	MaxVolumeId needle.VolumeId `json:"maxVolumeId"`
+	MaxVolumeId                 needle.VolumeId                    `json:"maxVolumeId"`
+	CollectionGarbageThresholds map[string]float64                 `json:"collectionGarbageThresholds"`
+	CollectionEcConfigs         map[string]erasure_coding.ECConfig `json:"collectionEcConfigs"`
 }
 
 func NewMaxVolumeIdCommand(value needle.VolumeId) *MaxVolumeIdCommand {
@@ -28,6 +33,8 @@ func (c *MaxVolumeIdCommand) Apply(server raft.Server) (interface{}, error) {
 	topo := server.Context().(*Topology)
 	before := topo.GetMaxVolumeId()
 	topo.UpAdjustMaxVolumeId(c.MaxVolumeId)
+	topo.ApplyCollectionGarbageThresholds(c.CollectionGarbageThresholds)
+	topo.ApplyCollectionEcConfigs(c.CollectionEcConfigs)
 
 	glog.V(1).Infoln("max volume id", before, "==>", topo.GetMaxVolumeId())
 
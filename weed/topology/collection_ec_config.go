@@ -0,0 +1,89 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+)
+
+// CollectionECConfig keeps the per-collection erasure coding stripes, used by ec.encode instead of the default 10+4.
+// It is replicated in the raft logs and snapshots along with the max volume id.
+// The encoded volumes keep their own stripe in the .vif files, so changing it only affects the volumes encoded later.
+type CollectionECConfig struct {
+	sync.RWMutex
+	configs map[string]erasure_coding.ECConfig
+}
+
+func NewCollectionECConfig() *CollectionECConfig {
+	return &CollectionECConfig{configs: make(map[string]erasure_coding.ECConfig)}
+}
+
+func (c *CollectionECConfig) Get(collection string) (ecConfig erasure_coding.ECConfig, found bool) {
+	c.RLock()
+	defer c.RUnlock()
+	ecConfig, found = c.configs[collection]
+	return
+}
+
+// Configs returns a copy of all the per-collection stripes
+func (c *CollectionECConfig) Configs() map[string]erasure_coding.ECConfig {
+	c.RLock()
+	defer c.RUnlock()
+	configs := make(map[string]erasure_coding.ECConfig, len(c.configs))
+	for collection, ecConfig := range c.configs {
+		configs[collection] = ecConfig
+	}
+	return configs
+}
+
+func (c *CollectionECConfig) replace(configs map[string]erasure_coding.ECConfig) {
+	c.Lock()
+	defer c.Unlock()
+	c.configs = configs
+}
+
+// ApplyCollectionEcConfigs takes the stripes of a raft log or snapshot, nil if it has none
+func (t *Topology) ApplyCollectionEcConfigs(configs map[string]erasure_coding.ECConfig) {
+	if configs == nil {
+		return
+	}
+	t.CollectionECConfig.replace(configs)
+	glog.V(1).Infof("collection ec configs: %v", configs)
+}
+
+// SetCollectionEcConfig replicates the collection's stripe to all masters.
+// Setting the default 10+4 removes the collection's stripe.
+func (t *Topology) SetCollectionEcConfig(collection string, ecConfig erasure_coding.ECConfig) error {
+	if err := ecConfig.Validate(); err != nil {
+		return err
+	}
+	configs := t.CollectionECConfig.Configs()
+	if ecConfig == erasure_coding.DefaultECConfig {
+		delete(configs, collection)
+	} else {
+		configs[collection] = ecConfig
+	}
+	command := &MaxVolumeIdCommand{
+		CollectionEcConfigs: configs,
+	}
+
+	t.RaftServerAccessLock.RLock()
+	defer t.RaftServerAccessLock.RUnlock()
+
+	if t.RaftServer != nil {
+		_, err := t.RaftServer.Do(command)
+		return err
+	} else if t.HashicorpRaft != nil {
+		b, err := json.Marshal(command)
+		if err != nil {
+			return fmt.Errorf("failed marshal collection ec configs: %+v", err)
+		}
+		return t.HashicorpRaft.Apply(b, time.Second).Error()
+	}
+	t.CollectionECConfig.replace(configs)
+	return nil
+}
@@ -0,0 +1,47 @@
+package topology
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/sequence"
+	"github.com/seaweedfs/seaweedfs/weed/storage/erasure_coding"
+)
+
+func TestCollectionEcConfig(t *testing.T) {
+	topo := NewTopology("weedfs", sequence.NewMemorySequencer(), 32*1024, 5, false)
+
+	small := erasure_coding.ECConfig{DataShards: 6, ParityShards: 2}
+	if err := topo.SetCollectionEcConfig("small", small); err != nil {
+		t.Fatalf("set ec config: %v", err)
+	}
+	if err := topo.SetCollectionEcConfig("huge", erasure_coding.ECConfig{DataShards: 30, ParityShards: 4}); err == nil {
+		t.Errorf("expected an error for 34 shards")
+	}
+	if err := topo.SetCollectionEcConfig("none", erasure_coding.ECConfig{DataShards: 6}); err == nil {
+		t.Errorf("expected an error for no parity shards")
+	}
+	if got, _ := topo.CollectionECConfig.Get("small"); got != small {
+		t.Errorf("small ec config %s, expected %s", got, small)
+	}
+	if _, found := topo.CollectionECConfig.Get("other"); found {
+		t.Errorf("other has an ec config")
+	}
+
+	// the max volume id logs leave the stripes alone
+	var command MaxVolumeIdCommand
+	if err := json.Unmarshal([]byte(`{"maxVolumeId":7}`), &command); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	topo.ApplyCollectionEcConfigs(command.CollectionEcConfigs)
+	if _, found := topo.CollectionECConfig.Get("small"); !found {
+		t.Errorf("small ec config is lost")
+	}
+
+	if err := topo.SetCollectionEcConfig("small", erasure_coding.DefaultECConfig); err != nil {
+		t.Fatalf("reset ec config: %v", err)
+	}
+	if _, found := topo.CollectionECConfig.Get("small"); found {
+		t.Errorf("small ec config is not removed")
+	}
+}
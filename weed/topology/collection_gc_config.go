@@ -0,0 +1,95 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+)
+
+// CollectionGCConfig keeps the per-collection garbage thresholds, which override the master's -garbageThreshold
+// for the automatic vacuum. It is replicated in the raft logs and snapshots along with the max volume id.
+type CollectionGCConfig struct {
+	sync.RWMutex
+	thresholds map[string]float64
+}
+
+func NewCollectionGCConfig() *CollectionGCConfig {
+	return &CollectionGCConfig{thresholds: make(map[string]float64)}
+}
+
+// GarbageThreshold returns the collection's threshold, or defaultThreshold if it has none
+func (c *CollectionGCConfig) GarbageThreshold(collection string, defaultThreshold float64) float64 {
+	if threshold, found := c.Get(collection); found {
+		return threshold
+	}
+	return defaultThreshold
+}
+
+func (c *CollectionGCConfig) Get(collection string) (threshold float64, found bool) {
+	c.RLock()
+	defer c.RUnlock()
+	threshold, found = c.thresholds[collection]
+	return
+}
+
+// Thresholds returns a copy of all the per-collection thresholds
+func (c *CollectionGCConfig) Thresholds() map[string]float64 {
+	c.RLock()
+	defer c.RUnlock()
+	thresholds := make(map[string]float64, len(c.thresholds))
+	for collection, threshold := range c.thresholds {
+		thresholds[collection] = threshold
+	}
+	return thresholds
+}
+
+func (c *CollectionGCConfig) replace(thresholds map[string]float64) {
+	c.Lock()
+	defer c.Unlock()
+	c.thresholds = thresholds
+}
+
+// ApplyCollectionGarbageThresholds takes the thresholds of a raft log or snapshot, nil if it has none
+func (t *Topology) ApplyCollectionGarbageThresholds(thresholds map[string]float64) {
+	if thresholds == nil {
+		return
+	}
+	t.CollectionGCConfig.replace(thresholds)
+	glog.V(1).Infof("collection garbage thresholds: %v", thresholds)
+}
+
+// SetCollectionGarbageThreshold replicates the collection's threshold to all masters.
+// With useDefault, the collection goes back to the master's -garbageThreshold.
+func (t *Topology) SetCollectionGarbageThreshold(collection string, threshold float64, useDefault bool) error {
+	if !useDefault && (threshold < 0 || threshold > 1) {
+		return fmt.Errorf("garbage threshold %v is not in [0, 1]", threshold)
+	}
+	thresholds := t.CollectionGCConfig.Thresholds()
+	if useDefault {
+		delete(thresholds, collection)
+	} else {
+		thresholds[collection] = threshold
+	}
+	command := &MaxVolumeIdCommand{
+		CollectionGarbageThresholds: thresholds,
+	}
+
+	t.RaftServerAccessLock.RLock()
+	defer t.RaftServerAccessLock.RUnlock()
+
+	if t.RaftServer != nil {
+		_, err := t.RaftServer.Do(command)
+		return err
+	} else if t.HashicorpRaft != nil {
+		b, err := json.Marshal(command)
+		if err != nil {
+			return fmt.Errorf("failed marshal collection garbage thresholds: %+v", err)
+		}
+		return t.HashicorpRaft.Apply(b, time.Second).Error()
+	}
+	t.CollectionGCConfig.replace(thresholds)
+	return nil
+}
@@ -0,0 +1,42 @@
+package topology
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/sequence"
+)
+
+func TestCollectionGarbageThreshold(t *testing.T) {
+	topo := NewTopology("weedfs", sequence.NewMemorySequencer(), 32*1024, 5, false)
+
+	if err := topo.SetCollectionGarbageThreshold("backup", 0.01, false); err != nil {
+		t.Fatalf("set threshold: %v", err)
+	}
+	if err := topo.SetCollectionGarbageThreshold("churn", 1.5, false); err == nil {
+		t.Errorf("expected an error for threshold 1.5")
+	}
+	if got := topo.CollectionGCConfig.GarbageThreshold("backup", 0.3); got != 0.01 {
+		t.Errorf("backup threshold %v, expected 0.01", got)
+	}
+	if got := topo.CollectionGCConfig.GarbageThreshold("other", 0.3); got != 0.3 {
+		t.Errorf("other threshold %v, expected the default 0.3", got)
+	}
+
+	// the max volume id logs leave the thresholds alone
+	var command MaxVolumeIdCommand
+	if err := json.Unmarshal([]byte(`{"maxVolumeId":7}`), &command); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	topo.ApplyCollectionGarbageThresholds(command.CollectionGarbageThresholds)
+	if _, found := topo.CollectionGCConfig.Get("backup"); !found {
+		t.Errorf("backup threshold is lost")
+	}
+
+	if err := topo.SetCollectionGarbageThreshold("backup", 0, true); err != nil {
+		t.Fatalf("reset threshold: %v", err)
+	}
+	if _, found := topo.CollectionGCConfig.Get("backup"); found {
+		t.Errorf("backup threshold is not removed")
+	}
+}
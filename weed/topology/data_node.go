@@ -21,6 +21,7 @@ type DataNode struct {
 	LastSeen      int64 // unix time in seconds
 	Counter       int   // in race condition, the previous dataNode was not dead
 	IsTerminating bool
+	IsReadOnly    bool // serving the existing volumes only, reported by the volume server
 }
 
 func NewDataNode(id string) *DataNode {
@@ -39,6 +40,14 @@ func (dn *DataNode) String() string {
 	return fmt.Sprintf("Node:%s, Ip:%s, Port:%d, PublicUrl:%s", dn.NodeImpl.String(), dn.Ip, dn.Port, dn.PublicUrl)
 }
 
+// AvailableSpaceFor keeps the new volumes away from the read only volume servers
+func (dn *DataNode) AvailableSpaceFor(option *VolumeGrowOption) int64 {
+	if dn.IsReadOnly {
+		return 0
+	}
+	return dn.NodeImpl.AvailableSpaceFor(option)
+}
+
 func (dn *DataNode) AddOrUpdateVolume(v storage.VolumeInfo) (isNew, isChangedRO bool) {
 	dn.Lock()
 	defer dn.Unlock()
@@ -202,6 +211,13 @@ func (dn *DataNode) GetDataCenterId() string {
 	return ""
 }
 
+func (dn *DataNode) GetRackId() string {
+	if dn.Parent() == nil {
+		return ""
+	}
+	return string(dn.GetRack().Id())
+}
+
 func (dn *DataNode) GetRack() *Rack {
 	return dn.Parent().(*NodeImpl).value.(*Rack)
 }
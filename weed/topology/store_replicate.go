@@ -91,14 +91,15 @@ func ReplicatedWrite(masterFn operation.GetMasterFn, grpcDialOption grpc.DialOpt
 			// volume server do not know about encryption
 			// TODO optimize here to compress data only once
 			uploadOption := &operation.UploadOption{
-				UploadUrl:         u.String(),
-				Filename:          string(n.Name),
-				Cipher:            false,
-				IsInputCompressed: n.IsCompressed(),
-				MimeType:          string(n.Mime),
-				PairMap:           pairMap,
-				Jwt:               jwt,
-				Md5:               contentMd5,
+				UploadUrl:            u.String(),
+				Filename:             string(n.Name),
+				Cipher:               false,
+				IsInputCompressed:    n.IsCompressed(),
+				InputContentEncoding: n.Compression(),
+				MimeType:             string(n.Mime),
+				PairMap:              pairMap,
+				Jwt:                  jwt,
+				Md5:                  contentMd5,
 			}
 
 			_, err := operation.UploadData(n.Data, uploadOption)
@@ -39,6 +39,10 @@ type Topology struct {
 	replicationAsMin bool
 	isDisableVacuum  bool
 
+	VacuumScheduler    *ParallelVacuumScheduler
+	CollectionGCConfig *CollectionGCConfig
+	CollectionECConfig *CollectionECConfig
+
 	Sequence sequence.Sequencer
 
 	chanFullVolumes    chan storage.VolumeInfo
@@ -73,6 +77,10 @@ func NewTopology(id string, seq sequence.Sequencer, volumeSizeLimit uint64, puls
 
 	t.Configuration = &Configuration{}
 
+	t.VacuumScheduler = NewParallelVacuumScheduler(DefaultVacuumParallelism)
+	t.CollectionGCConfig = NewCollectionGCConfig()
+	t.CollectionECConfig = NewCollectionECConfig()
+
 	return t
 }
 
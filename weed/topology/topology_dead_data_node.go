@@ -0,0 +1,41 @@
+package topology
+
+import (
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+const DefaultDeadVolumeServerTimeout = 10 * time.Minute
+
+// DeadDataNodes returns the volume servers without any heartbeat since freshThreshold, in unix seconds.
+func (t *Topology) DeadDataNodes(freshThreshold int64) (dead []*DataNode) {
+	for _, dc := range t.Children() {
+		for _, rack := range dc.Children() {
+			for _, n := range rack.Children() {
+				dn := n.(*DataNode)
+				if dn.LastSeen < freshThreshold {
+					dead = append(dead, dn)
+				}
+			}
+		}
+	}
+	return
+}
+
+// VolumesWithoutLiveReplica returns the volumes on the data node without a replica on any other live data node.
+func (t *Topology) VolumesWithoutLiveReplica(dn *DataNode, freshThreshold int64) (vids []needle.VolumeId) {
+	for _, v := range dn.GetVolumes() {
+		hasLiveReplica := false
+		for _, replica := range t.Lookup(v.Collection, v.Id) {
+			if replica != dn && replica.LastSeen >= freshThreshold {
+				hasLiveReplica = true
+				break
+			}
+		}
+		if !hasLiveReplica {
+			vids = append(vids, v.Id)
+		}
+	}
+	return
+}
@@ -0,0 +1,108 @@
+package topology
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
+)
+
+var (
+	masterVolumeCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(stats.Namespace, "master", "volume_count"),
+		"Number of volume replicas.",
+		[]string{"collection", "rack", "datacenter"}, nil)
+	masterVolumeFreeSpaceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(stats.Namespace, "master", "volume_free_space_bytes"),
+		"Bytes left before the volume reaches the volume size limit, for its largest replica.",
+		[]string{"volumeId"}, nil)
+	masterActiveVolumeServersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(stats.Namespace, "master", "active_volume_servers"),
+		"Number of volume servers sending heartbeats.",
+		nil, nil)
+	masterRackCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(stats.Namespace, "master", "rack_count"),
+		"Number of racks with volume servers.",
+		nil, nil)
+)
+
+type volumeCountKey struct {
+	collection string
+	rack       string
+	dataCenter string
+}
+
+type topologyMetrics struct {
+	volumeCount         map[volumeCountKey]int
+	volumeFreeSpace     map[uint32]uint64
+	activeVolumeServers int
+	rackCount           int
+}
+
+// MetricsCollector reports the topology on each scrape, so the volumes gone from the topology are gone
+// from the metrics too. Only the leader reports, since the followers do not receive the heartbeats.
+type MetricsCollector struct {
+	topo *Topology
+}
+
+func NewMetricsCollector(topo *Topology) *MetricsCollector {
+	return &MetricsCollector{topo: topo}
+}
+
+func (c *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- masterVolumeCountDesc
+	ch <- masterVolumeFreeSpaceDesc
+	ch <- masterActiveVolumeServersDesc
+	ch <- masterRackCountDesc
+}
+
+func (c *MetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	if !c.topo.IsLeader() {
+		return
+	}
+	m := collectTopologyMetrics(c.topo.ToTopologyInfo(), c.topo.volumeSizeLimit)
+	for key, count := range m.volumeCount {
+		ch <- prometheus.MustNewConstMetric(masterVolumeCountDesc, prometheus.GaugeValue, float64(count),
+			key.collection, key.rack, key.dataCenter)
+	}
+	for vid, free := range m.volumeFreeSpace {
+		ch <- prometheus.MustNewConstMetric(masterVolumeFreeSpaceDesc, prometheus.GaugeValue, float64(free), fmt.Sprint(vid))
+	}
+	ch <- prometheus.MustNewConstMetric(masterActiveVolumeServersDesc, prometheus.GaugeValue, float64(m.activeVolumeServers))
+	ch <- prometheus.MustNewConstMetric(masterRackCountDesc, prometheus.GaugeValue, float64(m.rackCount))
+}
+
+func collectTopologyMetrics(topo *master_pb.TopologyInfo, volumeSizeLimit uint64) topologyMetrics {
+	m := topologyMetrics{
+		volumeCount:     make(map[volumeCountKey]int),
+		volumeFreeSpace: make(map[uint32]uint64),
+	}
+	volumeSize := make(map[uint32]uint64)
+	for _, dc := range topo.DataCenterInfos {
+		for _, rack := range dc.RackInfos {
+			if len(rack.DataNodeInfos) > 0 {
+				m.rackCount++
+			}
+			for _, dn := range rack.DataNodeInfos {
+				m.activeVolumeServers++
+				for _, disk := range dn.DiskInfos {
+					for _, vi := range disk.VolumeInfos {
+						m.volumeCount[volumeCountKey{vi.Collection, rack.Id, dc.Id}]++
+						if size, found := volumeSize[vi.Id]; !found || vi.Size > size {
+							volumeSize[vi.Id] = vi.Size
+						}
+					}
+				}
+			}
+		}
+	}
+	for vid, size := range volumeSize {
+		if size < volumeSizeLimit {
+			m.volumeFreeSpace[vid] = volumeSizeLimit - size
+		} else {
+			m.volumeFreeSpace[vid] = 0
+		}
+	}
+	return m
+}
@@ -0,0 +1,63 @@
+package topology
+
+import (
+	"testing"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb/master_pb"
+)
+
+func TestCollectTopologyMetrics(t *testing.T) {
+	topo := &master_pb.TopologyInfo{
+		DataCenterInfos: []*master_pb.DataCenterInfo{
+			{
+				Id: "dc1",
+				RackInfos: []*master_pb.RackInfo{
+					{
+						Id: "rack1",
+						DataNodeInfos: []*master_pb.DataNodeInfo{
+							{
+								Id: "a",
+								DiskInfos: map[string]*master_pb.DiskInfo{
+									"": {VolumeInfos: []*master_pb.VolumeInformationMessage{
+										{Id: 1, Size: 30},
+										{Id: 2, Collection: "c", Size: 120},
+									}},
+								},
+							},
+							{
+								Id: "b",
+								DiskInfos: map[string]*master_pb.DiskInfo{
+									"": {VolumeInfos: []*master_pb.VolumeInformationMessage{
+										{Id: 1, Size: 40},
+									}},
+								},
+							},
+						},
+					},
+					{Id: "empty"},
+				},
+			},
+		},
+	}
+
+	m := collectTopologyMetrics(topo, 100)
+
+	if m.activeVolumeServers != 2 {
+		t.Errorf("active volume servers %d, expected 2", m.activeVolumeServers)
+	}
+	if m.rackCount != 1 {
+		t.Errorf("rack count %d, expected 1", m.rackCount)
+	}
+	if count := m.volumeCount[volumeCountKey{"", "rack1", "dc1"}]; count != 2 {
+		t.Errorf("volume count of the default collection %d, expected 2", count)
+	}
+	if count := m.volumeCount[volumeCountKey{"c", "rack1", "dc1"}]; count != 1 {
+		t.Errorf("volume count of collection c %d, expected 1", count)
+	}
+	if free := m.volumeFreeSpace[1]; free != 60 {
+		t.Errorf("volume 1 free space %d, expected 60 for the largest replica", free)
+	}
+	if free := m.volumeFreeSpace[2]; free != 0 {
+		t.Errorf("volume 2 free space %d, expected 0 over the limit", free)
+	}
+}
@@ -0,0 +1,59 @@
+package topology
+
+import (
+	"sort"
+)
+
+// UnderReplicatedVolume is a volume with fewer replicas than its replica placement asks for.
+type UnderReplicatedVolume struct {
+	VolumeId    uint32   `json:"volumeId"`
+	Collection  string   `json:"collection"`
+	Replication string   `json:"replication"`
+	Replicas    []string `json:"replicas"`
+	Expected    int      `json:"expected"`
+}
+
+// UnderReplicatedVolumes lists the volumes missing some replicas, ordered by volume id.
+// Replication is per volume, so every chunk stored in such a volume is under-replicated too.
+func (t *Topology) UnderReplicatedVolumes() (volumes []UnderReplicatedVolume) {
+	for _, c := range t.collectionMap.Items() {
+		collection := c.(*Collection)
+		for _, l := range collection.storageType2VolumeLayout.Items() {
+			if l == nil {
+				continue
+			}
+			volumes = append(volumes, l.(*VolumeLayout).underReplicatedVolumes(collection.Name)...)
+		}
+	}
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i].VolumeId < volumes[j].VolumeId
+	})
+	return
+}
+
+func (vl *VolumeLayout) underReplicatedVolumes(collection string) (volumes []UnderReplicatedVolume) {
+	vl.accessLock.RLock()
+	defer vl.accessLock.RUnlock()
+
+	expected := vl.rp.GetCopyCount()
+	for vid, locationList := range vl.vid2location {
+		if locationList.Length() >= expected {
+			continue
+		}
+		volumes = append(volumes, UnderReplicatedVolume{
+			VolumeId:    uint32(vid),
+			Collection:  collection,
+			Replication: vl.rp.String(),
+			Replicas:    replicaUrls(locationList),
+			Expected:    expected,
+		})
+	}
+	return
+}
+
+func replicaUrls(locationList *VolumeLocationList) (urls []string) {
+	for _, dn := range locationList.list {
+		urls = append(urls, dn.Url())
+	}
+	return
+}
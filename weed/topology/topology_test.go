@@ -208,3 +208,74 @@ func TestAddRemoveVolume(t *testing.T) {
 	}
 
 }
+
+func TestUnderReplicatedVolumes(t *testing.T) {
+
+	topo := NewTopology("weedfs", sequence.NewMemorySequencer(), 32*1024, 5, false)
+
+	dc := topo.GetOrCreateDataCenter("dc1")
+	rack := dc.GetOrCreateRack("rack1")
+	maxVolumeCounts := make(map[string]uint32)
+	maxVolumeCounts[""] = 25
+	dn1 := rack.GetOrCreateDataNode("127.0.0.1", 34534, 0, "127.0.0.1", maxVolumeCounts)
+	dn2 := rack.GetOrCreateDataNode("127.0.0.2", 34534, 0, "127.0.0.2", maxVolumeCounts)
+
+	rp, _ := super_block.NewReplicaPlacementFromString("001")
+	v1 := storage.VolumeInfo{Id: needle.VolumeId(1), Collection: "c", Version: needle.CurrentVersion, ReplicaPlacement: rp, Ttl: needle.EMPTY_TTL}
+	v2 := storage.VolumeInfo{Id: needle.VolumeId(2), Collection: "c", Version: needle.CurrentVersion, ReplicaPlacement: rp, Ttl: needle.EMPTY_TTL}
+
+	dn1.UpdateVolumes([]storage.VolumeInfo{v1, v2})
+	dn2.UpdateVolumes([]storage.VolumeInfo{v1})
+	topo.RegisterVolumeLayout(v1, dn1)
+	topo.RegisterVolumeLayout(v1, dn2)
+	topo.RegisterVolumeLayout(v2, dn1)
+
+	volumes := topo.UnderReplicatedVolumes()
+	if len(volumes) != 1 {
+		t.Fatalf("expected 1 under-replicated volume, got %+v", volumes)
+	}
+	if volumes[0].VolumeId != 2 || volumes[0].Expected != 2 || len(volumes[0].Replicas) != 1 {
+		t.Errorf("unexpected under-replicated volume %+v", volumes[0])
+	}
+
+	topo.RegisterVolumeLayout(v2, dn2)
+	if volumes = topo.UnderReplicatedVolumes(); len(volumes) != 0 {
+		t.Errorf("expected no under-replicated volume, got %+v", volumes)
+	}
+}
+
+func TestDeadDataNodes(t *testing.T) {
+
+	topo := NewTopology("weedfs", sequence.NewMemorySequencer(), 32*1024, 5, false)
+
+	dc := topo.GetOrCreateDataCenter("dc1")
+	rack := dc.GetOrCreateRack("rack1")
+	maxVolumeCounts := make(map[string]uint32)
+	maxVolumeCounts[""] = 25
+	dn1 := rack.GetOrCreateDataNode("127.0.0.1", 34534, 0, "127.0.0.1", maxVolumeCounts)
+	dn2 := rack.GetOrCreateDataNode("127.0.0.2", 34534, 0, "127.0.0.2", maxVolumeCounts)
+
+	rp, _ := super_block.NewReplicaPlacementFromString("001")
+	v1 := storage.VolumeInfo{Id: needle.VolumeId(1), Version: needle.CurrentVersion, ReplicaPlacement: rp, Ttl: needle.EMPTY_TTL}
+	v2 := storage.VolumeInfo{Id: needle.VolumeId(2), Version: needle.CurrentVersion, ReplicaPlacement: rp, Ttl: needle.EMPTY_TTL}
+
+	dn1.UpdateVolumes([]storage.VolumeInfo{v1, v2})
+	dn2.UpdateVolumes([]storage.VolumeInfo{v1})
+	topo.RegisterVolumeLayout(v1, dn1)
+	topo.RegisterVolumeLayout(v1, dn2)
+	topo.RegisterVolumeLayout(v2, dn1)
+
+	freshThreshold := dn1.LastSeen
+	dn1.LastSeen = freshThreshold - 1
+
+	dead := topo.DeadDataNodes(freshThreshold)
+	if len(dead) != 1 || dead[0] != dn1 {
+		t.Fatalf("expected %s to be dead, got %v", dn1.Id(), dead)
+	}
+	if vids := topo.VolumesWithoutLiveReplica(dn1, freshThreshold); len(vids) != 1 || vids[0] != v2.Id {
+		t.Errorf("expected volume 2 without live replica, got %v", vids)
+	}
+	if vids := topo.VolumesWithoutLiveReplica(dn2, freshThreshold); len(vids) != 0 {
+		t.Errorf("expected all volumes on %s with live replica, got %v", dn2.Id(), vids)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/seaweedfs/seaweedfs/weed/glog"
 	"github.com/seaweedfs/seaweedfs/weed/operation"
 	"github.com/seaweedfs/seaweedfs/weed/pb/volume_server_pb"
+	"github.com/seaweedfs/seaweedfs/weed/stats"
 )
 
 func (t *Topology) batchVacuumVolumeCheck(grpcDialOption grpc.DialOption, vid needle.VolumeId,
@@ -63,7 +64,7 @@ func (t *Topology) batchVacuumVolumeCheck(grpcDialOption grpc.DialOption, vid ne
 }
 
 func (t *Topology) batchVacuumVolumeCompact(grpcDialOption grpc.DialOption, vl *VolumeLayout, vid needle.VolumeId,
-	locationlist *VolumeLocationList, preallocate int64) bool {
+	locationlist *VolumeLocationList, preallocate int64, job *VacuumJob) bool {
 	vl.accessLock.Lock()
 	vl.removeFromWritable(vid)
 	vl.accessLock.Unlock()
@@ -92,6 +93,7 @@ func (t *Topology) batchVacuumVolumeCompact(grpcDialOption grpc.DialOption, vl *
 					}
 					glog.V(0).Infof("%d vacuum %d on %s processed %d bytes, loadAvg %.02f%%",
 						index, vid, url, resp.ProcessedBytes, resp.LoadAvg_1M*100)
+					job.setProcessedBytes(url, uint64(resp.ProcessedBytes))
 				}
 				return nil
 			})
@@ -179,6 +181,7 @@ func (t *Topology) batchVacuumVolumeCommit(grpcDialOption grpc.DialOption, vl *V
 				glog.V(0).Infof("get volume info for volume: %d failed %v", vid, err)
 				return false
 			}
+			stats.MasterGcBytesReclaimedCounter.Add(float64(vInfo.DeletedByteCount))
 
 			dn.Lock()
 			disk := dn.getOrCreateDisk(vInfo.DiskType)
@@ -211,7 +214,9 @@ func (t *Topology) batchVacuumVolumeCleanup(grpcDialOption grpc.DialOption, vl *
 	}
 }
 
-func (t *Topology) Vacuum(grpcDialOption grpc.DialOption, garbageThreshold float64, volumeId uint32, collection string, preallocate int64) {
+// Vacuum compacts the volumes with more garbage than garbageThreshold.
+// With useCollectionThresholds, the collections' own thresholds take precedence over garbageThreshold.
+func (t *Topology) Vacuum(grpcDialOption grpc.DialOption, garbageThreshold float64, useCollectionThresholds bool, volumeId uint32, collection string, preallocate int64) {
 
 	// if there is vacuum going on, return immediately
 	swapped := atomic.CompareAndSwapInt64(&t.vacuumLockCounter, 0, 1)
@@ -220,10 +225,11 @@ func (t *Topology) Vacuum(grpcDialOption grpc.DialOption, garbageThreshold float
 	}
 	defer atomic.StoreInt64(&t.vacuumLockCounter, 0)
 
-	// now only one vacuum process going on
+	// now only one vacuum process going on, which vacuums up to VacuumScheduler parallelism volumes
 
 	glog.V(1).Infof("Start vacuum on demand with threshold: %f collection: %s volumeId: %d",
 		garbageThreshold, collection, volumeId)
+	var tasks []*vacuumTask
 	for _, col := range t.collectionMap.Items() {
 		c := col.(*Collection)
 		if collection != "" && collection != c.Name {
@@ -236,33 +242,41 @@ func (t *Topology) Vacuum(grpcDialOption grpc.DialOption, garbageThreshold float
 					vid := needle.VolumeId(volumeId)
 					volumeLayout.accessLock.RLock()
 					locationList, ok := volumeLayout.vid2location[vid]
+					if ok {
+						locationList = locationList.Copy()
+					}
 					volumeLayout.accessLock.RUnlock()
 					if ok {
-						t.vacuumOneVolumeId(grpcDialOption, volumeLayout, c, garbageThreshold, locationList, vid, preallocate)
+						tasks = append(tasks, &vacuumTask{volumeLayout: volumeLayout, collection: c, locationList: locationList, vid: vid})
 					}
 				} else {
-					t.vacuumOneVolumeLayout(grpcDialOption, volumeLayout, c, garbageThreshold, preallocate)
+					tasks = append(tasks, t.vacuumOneVolumeLayout(volumeLayout, c)...)
 				}
 			}
 		}
 	}
+
+	t.VacuumScheduler.run(tasks, func(task *vacuumTask, job *VacuumJob) {
+		threshold := garbageThreshold
+		if useCollectionThresholds {
+			threshold = t.CollectionGCConfig.GarbageThreshold(task.collection.Name, garbageThreshold)
+		}
+		t.vacuumOneVolumeId(grpcDialOption, task.volumeLayout, task.collection, threshold, task.locationList, task.vid, preallocate, job)
+	})
 }
 
-func (t *Topology) vacuumOneVolumeLayout(grpcDialOption grpc.DialOption, volumeLayout *VolumeLayout, c *Collection, garbageThreshold float64, preallocate int64) {
+func (t *Topology) vacuumOneVolumeLayout(volumeLayout *VolumeLayout, c *Collection) (tasks []*vacuumTask) {
 
 	volumeLayout.accessLock.RLock()
-	tmpMap := make(map[needle.VolumeId]*VolumeLocationList)
 	for vid, locationList := range volumeLayout.vid2location {
-		tmpMap[vid] = locationList.Copy()
+		tasks = append(tasks, &vacuumTask{volumeLayout: volumeLayout, collection: c, locationList: locationList.Copy(), vid: vid})
 	}
 	volumeLayout.accessLock.RUnlock()
 
-	for vid, locationList := range tmpMap {
-		t.vacuumOneVolumeId(grpcDialOption, volumeLayout, c, garbageThreshold, locationList, vid, preallocate)
-	}
+	return
 }
 
-func (t *Topology) vacuumOneVolumeId(grpcDialOption grpc.DialOption, volumeLayout *VolumeLayout, c *Collection, garbageThreshold float64, locationList *VolumeLocationList, vid needle.VolumeId, preallocate int64) {
+func (t *Topology) vacuumOneVolumeId(grpcDialOption grpc.DialOption, volumeLayout *VolumeLayout, c *Collection, garbageThreshold float64, locationList *VolumeLocationList, vid needle.VolumeId, preallocate int64, job *VacuumJob) {
 	volumeLayout.accessLock.RLock()
 	isReadOnly := volumeLayout.readonlyVolumes.IsTrue(vid)
 	isEnoughCopies := volumeLayout.enoughCopies(vid)
@@ -275,9 +289,15 @@ func (t *Topology) vacuumOneVolumeId(grpcDialOption grpc.DialOption, volumeLayou
 	glog.V(1).Infof("check vacuum on collection:%s volume:%d", c.Name, vid)
 	if vacuumLocationList, needVacuum := t.batchVacuumVolumeCheck(
 		grpcDialOption, vid, locationList, garbageThreshold); needVacuum {
-		if t.batchVacuumVolumeCompact(grpcDialOption, volumeLayout, vid, vacuumLocationList, preallocate) {
+		defer func(start time.Time) {
+			stats.MasterGcDurationHistogram.Observe(time.Since(start).Seconds())
+		}(time.Now())
+		job.setStage("compact")
+		if t.batchVacuumVolumeCompact(grpcDialOption, volumeLayout, vid, vacuumLocationList, preallocate, job) {
+			job.setStage("commit")
 			t.batchVacuumVolumeCommit(grpcDialOption, volumeLayout, vid, vacuumLocationList, locationList)
 		} else {
+			job.setStage("cleanup")
 			t.batchVacuumVolumeCleanup(grpcDialOption, volumeLayout, vid, vacuumLocationList)
 		}
 	}
@@ -0,0 +1,177 @@
+package topology
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/glog"
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+const DefaultVacuumParallelism = 4
+
+// vacuumTask is one volume to check, and vacuum if it has enough garbage.
+type vacuumTask struct {
+	volumeLayout *VolumeLayout
+	collection   *Collection
+	locationList *VolumeLocationList
+	vid          needle.VolumeId
+}
+
+// VacuumJob tracks the progress of one volume being vacuumed.
+type VacuumJob struct {
+	VolumeId   needle.VolumeId
+	Collection string
+	StartedAt  time.Time
+
+	lock           sync.Mutex
+	stage          string
+	totalBytes     uint64
+	processedBytes map[pb.ServerAddress]uint64
+}
+
+// VacuumJobStatus is the snapshot of a VacuumJob, for the /admin/gc/status endpoint.
+type VacuumJobStatus struct {
+	VolumeId       uint32    `json:"volumeId"`
+	Collection     string    `json:"collection"`
+	Stage          string    `json:"stage"`
+	StartedAt      time.Time `json:"startedAt"`
+	ProcessedBytes uint64    `json:"processedBytes"`
+	TotalBytes     uint64    `json:"totalBytes"`
+	Eta            string    `json:"eta,omitempty"`
+}
+
+func (job *VacuumJob) setStage(stage string) {
+	job.lock.Lock()
+	job.stage = stage
+	job.lock.Unlock()
+}
+
+func (job *VacuumJob) setProcessedBytes(url pb.ServerAddress, processedBytes uint64) {
+	job.lock.Lock()
+	job.processedBytes[url] = processedBytes
+	job.lock.Unlock()
+}
+
+func (job *VacuumJob) status(now time.Time) VacuumJobStatus {
+	job.lock.Lock()
+	defer job.lock.Unlock()
+
+	// the slowest replica decides when the volume is done
+	var processedBytes uint64
+	isFirst := true
+	for _, n := range job.processedBytes {
+		if isFirst || n < processedBytes {
+			processedBytes, isFirst = n, false
+		}
+	}
+	status := VacuumJobStatus{
+		VolumeId:       uint32(job.VolumeId),
+		Collection:     job.Collection,
+		Stage:          job.stage,
+		StartedAt:      job.StartedAt,
+		ProcessedBytes: processedBytes,
+		TotalBytes:     job.totalBytes,
+	}
+	if processedBytes > 0 && processedBytes < job.totalBytes {
+		elapsed := now.Sub(job.StartedAt)
+		eta := time.Duration(float64(elapsed) * float64(job.totalBytes-processedBytes) / float64(processedBytes))
+		status.Eta = eta.Round(time.Second).String()
+	}
+	return status
+}
+
+// ParallelVacuumScheduler vacuums up to parallelism volumes at the same time.
+// A volume is never vacuumed twice at the same time.
+type ParallelVacuumScheduler struct {
+	parallelism int
+
+	jobsLock sync.Mutex
+	jobs     map[needle.VolumeId]*VacuumJob
+}
+
+func NewParallelVacuumScheduler(parallelism int) *ParallelVacuumScheduler {
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+	return &ParallelVacuumScheduler{
+		parallelism: parallelism,
+		jobs:        make(map[needle.VolumeId]*VacuumJob),
+	}
+}
+
+// run processes the tasks with parallelism goroutines, and returns after all tasks are done.
+func (s *ParallelVacuumScheduler) run(tasks []*vacuumTask, fn func(task *vacuumTask, job *VacuumJob)) {
+	taskChan := make(chan *vacuumTask)
+	var wg sync.WaitGroup
+	for i := 0; i < s.parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range taskChan {
+				job := s.startJob(task)
+				if job == nil {
+					glog.V(1).Infof("volume %d is already being vacuumed", task.vid)
+					continue
+				}
+				fn(task, job)
+				s.finishJob(task.vid)
+			}
+		}()
+	}
+	for _, task := range tasks {
+		taskChan <- task
+	}
+	close(taskChan)
+	wg.Wait()
+}
+
+func (s *ParallelVacuumScheduler) startJob(task *vacuumTask) *VacuumJob {
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+	if _, found := s.jobs[task.vid]; found {
+		return nil
+	}
+	job := &VacuumJob{
+		VolumeId:       task.vid,
+		Collection:     task.collection.Name,
+		StartedAt:      time.Now(),
+		stage:          "check",
+		processedBytes: make(map[pb.ServerAddress]uint64),
+	}
+	for _, dn := range task.locationList.list {
+		if vInfo, err := dn.GetVolumesById(task.vid); err == nil && vInfo.Size > job.totalBytes {
+			job.totalBytes = vInfo.Size
+		}
+	}
+	s.jobs[task.vid] = job
+	return job
+}
+
+func (s *ParallelVacuumScheduler) finishJob(vid needle.VolumeId) {
+	s.jobsLock.Lock()
+	delete(s.jobs, vid)
+	s.jobsLock.Unlock()
+}
+
+// Status lists the volumes being vacuumed, ordered by volume id.
+func (s *ParallelVacuumScheduler) Status() []VacuumJobStatus {
+	s.jobsLock.Lock()
+	jobs := make([]*VacuumJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	s.jobsLock.Unlock()
+
+	now := time.Now()
+	statuses := make([]VacuumJobStatus, 0, len(jobs))
+	for _, job := range jobs {
+		statuses = append(statuses, job.status(now))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].VolumeId < statuses[j].VolumeId
+	})
+	return statuses
+}
@@ -0,0 +1,70 @@
+package topology
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/seaweedfs/seaweedfs/weed/pb"
+	"github.com/seaweedfs/seaweedfs/weed/storage/needle"
+)
+
+func TestParallelVacuumScheduler(t *testing.T) {
+	s := NewParallelVacuumScheduler(3)
+	c := &Collection{Name: "test"}
+
+	var tasks []*vacuumTask
+	for i := 1; i <= 10; i++ {
+		tasks = append(tasks, &vacuumTask{collection: c, locationList: NewVolumeLocationList(), vid: needle.VolumeId(i)})
+	}
+
+	var running, maxRunning int32
+	var lock sync.Mutex
+	vacuumed := make(map[needle.VolumeId]int)
+	s.run(tasks, func(task *vacuumTask, job *VacuumJob) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		if len(s.Status()) == 0 {
+			t.Errorf("volume %d is not in the status", task.vid)
+		}
+		time.Sleep(10 * time.Millisecond)
+		lock.Lock()
+		vacuumed[task.vid]++
+		lock.Unlock()
+		atomic.AddInt32(&running, -1)
+	})
+
+	if maxRunning > 3 {
+		t.Errorf("expected at most 3 parallel vacuums, got %d", maxRunning)
+	}
+	if len(vacuumed) != 10 {
+		t.Errorf("expected 10 vacuumed volumes, got %d", len(vacuumed))
+	}
+	if len(s.Status()) != 0 {
+		t.Errorf("expected no jobs after the run, got %v", s.Status())
+	}
+}
+
+func TestVacuumJobStatus(t *testing.T) {
+	job := &VacuumJob{
+		VolumeId:       1,
+		StartedAt:      time.Now().Add(-10 * time.Second),
+		totalBytes:     1000,
+		processedBytes: make(map[pb.ServerAddress]uint64),
+	}
+	job.setProcessedBytes("a:8080", 500)
+	job.setProcessedBytes("b:8080", 250)
+	status := job.status(job.StartedAt.Add(10 * time.Second))
+	if status.ProcessedBytes != 250 {
+		t.Errorf("expected the slowest replica 250, got %d", status.ProcessedBytes)
+	}
+	if status.Eta != "30s" {
+		t.Errorf("expected eta 30s, got %s", status.Eta)
+	}
+}
@@ -346,3 +346,33 @@ func TestFindEmptySlotsForOneVolumeScheduleByWeight(t *testing.T) {
 		fmt.Printf("%s : %d\n", k, v)
 	}
 }
+
+func TestFindEmptySlotsSkipsReadOnlyDataNodes(t *testing.T) {
+	topo := setup(topologyLayout)
+	for _, dc := range topo.Children() {
+		for _, rack := range dc.Children() {
+			for _, n := range rack.Children() {
+				if n.Id() == "server122" || n.Id() == "server123" {
+					n.(*DataNode).IsReadOnly = true
+				}
+			}
+		}
+	}
+	vg := NewDefaultVolumeGrowth()
+	rp, _ := super_block.NewReplicaPlacementFromString("000")
+	volumeGrowOption := &VolumeGrowOption{
+		ReplicaPlacement: rp,
+		DataCenter:       "dc1",
+	}
+	for i := 0; i < 100; i++ {
+		servers, err := vg.findEmptySlotsForOneVolume(topo, volumeGrowOption)
+		if err != nil {
+			t.Fatalf("finding empty slots error: %v", err)
+		}
+		for _, server := range servers {
+			if server.IsReadOnly {
+				t.Fatalf("assigned read only node %s", server.Id())
+			}
+		}
+	}
+}
@@ -3,10 +3,13 @@ package util
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"strings"
 
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+
 	"github.com/seaweedfs/seaweedfs/weed/glog"
-	// "github.com/klauspost/compress/zstd"
 )
 
 var (
@@ -60,11 +63,19 @@ func DecompressData(input []byte) ([]byte, error) {
 	if IsGzippedContent(input) {
 		return ungzipData(input)
 	}
-	/*
-		if IsZstdContent(input) {
-			return unzstdData(input)
-		}
-	*/
+	return input, UnsupportedCompression
+}
+
+// DecompressDataAs decompresses the data compressed with the content encoding: gzip, zstd or lz4.
+func DecompressDataAs(input []byte, contentEncoding string) ([]byte, error) {
+	switch contentEncoding {
+	case "gzip":
+		return ungzipData(input)
+	case "zstd":
+		return unzstdData(input)
+	case "lz4":
+		return unlz4Data(input)
+	}
 	return input, UnsupportedCompression
 }
 
@@ -75,8 +86,8 @@ func IsGzippedContent(data []byte) bool {
 	return data[0] == 31 && data[1] == 139
 }
 
-/*
-var zstdEncoder, _ = zstd.NewWriter(nil)
+// zstdEncoder uses level 1, which decompresses about twice as fast as gzip at a similar ratio
+var zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
 
 func ZstdData(input []byte) ([]byte, error) {
 	return zstdEncoder.EncodeAll(input, nil), nil
@@ -94,7 +105,26 @@ func IsZstdContent(data []byte) bool {
 	}
 	return data[3] == 0xFD && data[2] == 0x2F && data[1] == 0xB5 && data[0] == 0x28
 }
-*/
+
+func Lz4Data(input []byte) ([]byte, error) {
+	w := new(bytes.Buffer)
+	lz4Writer := lz4.NewWriter(w)
+	if _, err := lz4Writer.Write(input); err != nil {
+		return nil, err
+	}
+	if err := lz4Writer.Close(); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+func unlz4Data(input []byte) ([]byte, error) {
+	w := new(bytes.Buffer)
+	if _, err := io.Copy(w, lz4.NewReader(bytes.NewReader(input))); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
 
 /*
 * Default not to compressed since compression can be done on client side.
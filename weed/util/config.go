@@ -94,12 +94,30 @@ func (vp *ViperProxy) GetInt(key string) int {
 	return vp.Viper.GetInt(key)
 }
 
+func (vp *ViperProxy) GetFloat64(key string) float64 {
+	vp.Lock()
+	defer vp.Unlock()
+	return vp.Viper.GetFloat64(key)
+}
+
 func (vp *ViperProxy) GetStringSlice(key string) []string {
 	vp.Lock()
 	defer vp.Unlock()
 	return vp.Viper.GetStringSlice(key)
 }
 
+func (vp *ViperProxy) GetStringMap(key string) map[string]interface{} {
+	vp.Lock()
+	defer vp.Unlock()
+	return vp.Viper.GetStringMap(key)
+}
+
+func (vp *ViperProxy) UnmarshalKey(key string, rawVal interface{}) error {
+	vp.Lock()
+	defer vp.Unlock()
+	return vp.Viper.UnmarshalKey(key, rawVal)
+}
+
 func GetViper() *ViperProxy {
 	vp.Lock()
 	defer vp.Unlock()